@@ -0,0 +1,158 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BenchmarkRunSpec 定义一次压测：对哪个 LLMService、用什么负载、跑多久
+//
+// spec 创建后不再修改，想换参数就新建一个 BenchmarkRun（结果按对象保存，方便对比）
+type BenchmarkRunSpec struct {
+	// LLMService 是被压测的 LLMService 名称（同一个 namespace）
+	// 开启了 gateway 时请求经过 gateway，否则直接打 <name>-vllm Service
+	// +kubebuilder:validation:MinLength=1
+	LLMService string `json:"llmService"`
+
+	// Concurrency 是同时在途的请求数
+	// +kubebuilder:default=8
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1024
+	// +optional
+	Concurrency int32 `json:"concurrency,omitempty"`
+
+	// PromptTokens 是每个请求的 prompt 长度（近似 token 数）
+	// +kubebuilder:default=512
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PromptTokens int32 `json:"promptTokens,omitempty"`
+
+	// MaxTokens 是每个请求最多生成的 token 数
+	// +kubebuilder:default=128
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxTokens int32 `json:"maxTokens,omitempty"`
+
+	// Duration 是压测持续时间，到时间后不再发新请求，等在途请求完成
+	// +kubebuilder:default="60s"
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+// BenchmarkRun 的阶段
+const (
+	// BenchmarkPending 等待 LLMService 就绪
+	BenchmarkPending = "Pending"
+	// BenchmarkRunning 压测 Job 正在运行
+	BenchmarkRunning = "Running"
+	// BenchmarkSucceeded 压测完成，结果在 status.results
+	BenchmarkSucceeded = "Succeeded"
+	// BenchmarkFailed 压测 Job 失败，原因在 status.message
+	BenchmarkFailed = "Failed"
+)
+
+// BenchmarkRunStatus 是压测的进度和结果
+type BenchmarkRunStatus struct {
+	// Phase 是 Pending、Running、Succeeded 或 Failed
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// JobName 是运行压测的 Job
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// Target 是压测实际请求的地址
+	// +optional
+	Target string `json:"target,omitempty"`
+
+	// StartTime 是 Job 创建的时间
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime 是进入 Succeeded 或 Failed 的时间
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Message 说明当前阶段的原因（等什么、为什么失败）
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Results 是压测结果，Succeeded 之后才有
+	// +optional
+	Results *BenchmarkResults `json:"results,omitempty"`
+}
+
+// BenchmarkResults 是吞吐和延迟的统计
+//
+// 小数用字符串保存（例如 "12.50"），和 CostReport 一样，避免 CRD 里出现 float
+type BenchmarkResults struct {
+	// Requests 是完成的请求数（包括失败的）
+	Requests int64 `json:"requests"`
+
+	// Errors 是失败的请求数（连接错误、非 200）
+	Errors int64 `json:"errors"`
+
+	// RequestsPerSecond 是成功请求的吞吐
+	RequestsPerSecond string `json:"requestsPerSecond"`
+
+	// OutputTokensPerSecond 是生成 token 的吞吐（按响应里的 usage.completion_tokens 统计）
+	OutputTokensPerSecond string `json:"outputTokensPerSecond"`
+
+	// LatencyP50Ms、LatencyP90Ms、LatencyP99Ms 是成功请求端到端延迟的分位数（毫秒）
+	LatencyP50Ms int64 `json:"latencyP50Ms"`
+	LatencyP90Ms int64 `json:"latencyP90Ms"`
+	LatencyP99Ms int64 `json:"latencyP99Ms"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="LLMService",type=string,JSONPath=`.spec.llmService`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="RPS",type=string,JSONPath=`.status.results.requestsPerSecond`
+// +kubebuilder:printcolumn:name="P99(ms)",type=integer,JSONPath=`.status.results.latencyP99Ms`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// BenchmarkRun 对一个 LLMService 跑一次压测，把吞吐和延迟写进 status
+type BenchmarkRun struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec defines the desired state of BenchmarkRun
+	// +required
+	Spec BenchmarkRunSpec `json:"spec"`
+
+	// status defines the observed state of BenchmarkRun
+	// +optional
+	Status BenchmarkRunStatus `json:"status,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// BenchmarkRunList contains a list of BenchmarkRun
+type BenchmarkRunList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []BenchmarkRun `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&BenchmarkRun{}, &BenchmarkRunList{})
+}
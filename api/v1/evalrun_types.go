@@ -0,0 +1,242 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// EvalRunSpec 定义一次质量评测：对哪个 LLMService、用哪个评测集、怎么打分、多少分算通过
+//
+// 和 BenchmarkRun 一样 spec 创建后不再修改，换了候选版本就新建一个 EvalRun（每个版本的分数按对象保存）
+type EvalRunSpec struct {
+	// LLMService 是被评测的候选 LLMService 名称（同一个 namespace），
+	// 等它没有正在进行的滚动更新时才开始，评测的 Pod 模板版本记在 status.revision
+	// +kubebuilder:validation:MinLength=1
+	LLMService string `json:"llmService"`
+
+	// Prompts 是评测集
+	Prompts EvalPromptSet `json:"prompts"`
+
+	// Judge 是外部评审模型（LLM-as-a-judge），不设置时只按期望答案计算 accuracy
+	// +optional
+	Judge *EvalJudgeSpec `json:"judge,omitempty"`
+
+	// Thresholds 是通过条件，满足时 Passed condition 为 True；不设置时只要求没有失败的请求
+	// +optional
+	Thresholds *EvalThresholds `json:"thresholds,omitempty"`
+
+	// Concurrency 是同时在途的请求数
+	// +kubebuilder:default=4
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=256
+	// +optional
+	Concurrency int32 `json:"concurrency,omitempty"`
+
+	// MaxTokens 是每个回答最多生成的 token 数
+	// +kubebuilder:default=256
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxTokens int32 `json:"maxTokens,omitempty"`
+
+	// Timeout 是整个评测的时间上限（包括等待 gateway 唤醒服务）
+	// +kubebuilder:default="30m"
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// EvalPromptSet 引用保存评测集的 ConfigMap
+//
+// 内容是 JSONL，每行一个 {"prompt": "...", "expected": "..."}：
+// expected 是参考答案，回答里包含它（忽略大小写和首尾空白）算答对；没有 expected 的题只用来让 judge 打分
+type EvalPromptSet struct {
+	// ConfigMapName 是同一个 namespace 里的 ConfigMap 名称
+	// +kubebuilder:validation:MinLength=1
+	ConfigMapName string `json:"configMapName"`
+
+	// Key 是 ConfigMap 里的 key，默认 prompts.jsonl
+	// +kubebuilder:default=prompts.jsonl
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// DefaultEvalPromptsKey 是 EvalPromptSet.Key 的默认值
+const DefaultEvalPromptsKey = "prompts.jsonl"
+
+// EvalJudgeSpec 是外部评审模型，OpenAI 兼容的 /v1/chat/completions 接口
+//
+// 每道题把问题、参考答案、候选回答交给评审，评审认为候选回答不比参考答案差就算赢；
+// 没有参考答案的题只给问题和候选回答，评审认为回答正确、有帮助就算赢
+type EvalJudgeSpec struct {
+	// URL 是评审服务的地址，例如 https://api.openai.com 或集群内另一个 LLMService 的 Service
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+
+	// Model 是评审用的模型名
+	// +kubebuilder:validation:MinLength=1
+	Model string `json:"model"`
+
+	// APIKeySecretRef 是评审服务的 API key（Authorization: Bearer），集群内的服务不需要
+	// +optional
+	APIKeySecretRef *corev1.SecretKeySelector `json:"apiKeySecretRef,omitempty"`
+}
+
+// EvalThresholds 是评测的通过条件，小数用字符串保存（和 SLOSpec.Availability 一样，避免 CRD 里出现 float）
+type EvalThresholds struct {
+	// MinAccuracy 是 accuracy 的下限（0-1），例如 "0.8"
+	// +kubebuilder:validation:Pattern=`^(0?\.[0-9]+|[01](\.0+)?)$`
+	// +optional
+	MinAccuracy string `json:"minAccuracy,omitempty"`
+
+	// MinWinRate 是 judge 胜率的下限（0-1），需要设置 judge
+	// +kubebuilder:validation:Pattern=`^(0?\.[0-9]+|[01](\.0+)?)$`
+	// +optional
+	MinWinRate string `json:"minWinRate,omitempty"`
+
+	// MaxErrorRate 是请求失败（候选服务或评审出错，即 errors + judgeErrors）占比的上限（0-1），默认 0：任何失败都不通过
+	// +kubebuilder:validation:Pattern=`^(0?\.[0-9]+|[01](\.0+)?)$`
+	// +optional
+	MaxErrorRate string `json:"maxErrorRate,omitempty"`
+}
+
+// EvalRun 的阶段，和 BenchmarkRun 一样
+const (
+	// EvalPending 等待 LLMService 就绪、滚动更新结束
+	EvalPending = "Pending"
+	// EvalRunning 评测 Job 正在运行
+	EvalRunning = "Running"
+	// EvalSucceeded 评测完成，分数在 status.scores，是否通过看 Passed condition
+	EvalSucceeded = "Succeeded"
+	// EvalFailed 评测没有跑完（Job 失败、评测集有问题、评测期间候选版本变了），原因在 status.message
+	EvalFailed = "Failed"
+)
+
+// EvalConditionPassed 是评测是否满足 spec.thresholds 的 condition，发布流程据此决定是否放行候选版本：
+//
+//	kubectl wait --for=condition=Passed evalrun/<name>
+const EvalConditionPassed = "Passed"
+
+// EvalRunStatus 是评测的进度和结果
+type EvalRunStatus struct {
+	// Phase 是 Pending、Running、Succeeded 或 Failed
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// JobName 是运行评测的 Job
+	// +optional
+	JobName string `json:"jobName,omitempty"`
+
+	// Target 是评测实际请求的地址
+	// +optional
+	Target string `json:"target,omitempty"`
+
+	// Revision 是评测开始时 LLMService Pod 模板的 hash（Deployment 的 kubeinfer.io/template-hash），
+	// 分数只对这个版本有效
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// StartTime 是 Job 创建的时间
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime 是进入 Succeeded 或 Failed 的时间
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Message 说明当前阶段的原因（等什么、为什么失败、哪个阈值没达到）
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Scores 是评测分数，Succeeded 之后才有
+	// +optional
+	Scores *EvalScores `json:"scores,omitempty"`
+
+	// Conditions 目前只有 Passed
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// EvalScores 是评测的统计，小数用字符串保存（例如 "0.8500"）
+type EvalScores struct {
+	// Prompts 是评测集的题数
+	Prompts int64 `json:"prompts"`
+
+	// Errors 是候选服务回答失败（出错、超时）的题数，失败的题不参与打分
+	Errors int64 `json:"errors"`
+
+	// JudgeErrors 是评审出错或者没有回答 WIN/LOSS 的题数，这些题不计入 WinRate，accuracy 照样计算
+	// +optional
+	JudgeErrors int64 `json:"judgeErrors,omitempty"`
+
+	// Graded 是有参考答案、回答成功的题数，Accuracy 的分母
+	Graded int64 `json:"graded"`
+
+	// Accuracy 是回答包含参考答案的比例，没有可打分的题时为空
+	// +optional
+	Accuracy string `json:"accuracy,omitempty"`
+
+	// Judged 是评审给出结论的题数，WinRate 的分母
+	// +optional
+	Judged int64 `json:"judged,omitempty"`
+
+	// WinRate 是评审判为赢的比例，没有设置 judge 时为空
+	// +optional
+	WinRate string `json:"winRate,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="LLMService",type=string,JSONPath=`.spec.llmService`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Accuracy",type=string,JSONPath=`.status.scores.accuracy`
+// +kubebuilder:printcolumn:name="WinRate",type=string,JSONPath=`.status.scores.winRate`
+// +kubebuilder:printcolumn:name="Passed",type=string,JSONPath=`.status.conditions[?(@.type=="Passed")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// EvalRun 用评测集对一个 LLMService 的当前版本打分，把分数写进 status，
+// Passed condition 可以作为发布流程（金丝雀、Argo Rollouts 的分析步骤）的放行条件
+type EvalRun struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec defines the desired state of EvalRun
+	// +required
+	Spec EvalRunSpec `json:"spec"`
+
+	// status defines the observed state of EvalRun
+	// +optional
+	Status EvalRunStatus `json:"status,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// EvalRunList contains a list of EvalRun
+type EvalRunList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []EvalRun `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EvalRun{}, &EvalRunList{})
+}
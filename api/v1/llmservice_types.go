@@ -17,7 +17,10 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // LLMServiceSpec defines the desired state of LLMService
@@ -28,8 +31,13 @@ type LLMServiceSpec struct {
 
 	// +kubebuilder:validation:Required
 	// Model is the HuggingFace model ID, e.g., "deepseek-ai/deepseek-r1"
+	// 设置了 spec.modelSource.registry 时只是模型的名字（本地目录、快照、指标按它区分），例如 "acme/support-bot"
 	Model string `json:"model"`
 
+	// ModelSource 是拉取模型的附加选项（HuggingFace 的许可协议、MLflow/W&B 模型仓库）
+	// +optional
+	ModelSource *ModelSourceSpec `json:"modelSource,omitempty"`
+
 	// +kubebuilder:default=1
 	// +kubebuilder:validation:Minimum=1
 	// Replicas is the number of vLLM pods to run
@@ -39,16 +47,997 @@ type LLMServiceSpec struct {
 	// +kubebuilder:validation:Minimum=0
 	GpuPerReplica int32 `json:"gpuPerReplica,omitempty"`
 
-	// +kubebuilder:default=none
+	// CacheStrategy 是旧的分发开关，只剩 none/shared 两个值，表达不了现在的几种分发方式。
+	// shared 等同于 distribution.strategy=coordinator，none 等同于不设置（按副本数自动决定）；
+	// distribution.strategy 设置了时忽略这个字段
+	//
+	// Deprecated: 用 spec.distribution.strategy
 	// +kubebuilder:validation:Enum=none;shared
+	// +optional
 	CacheStrategy string `json:"cacheStrategy,omitempty"`
 
-	// +kubebuilder:default="vllm/vllm-openai:latest"
+	// Image 是 agent 容器的镜像，不设置时使用 operator 配置的 defaultImage（内置默认是 DefaultImage）
+	// +optional
 	Image string `json:"image,omitempty"`
 
+	// Images 按角色分别指定镜像：只运行 agent 的容器可以用不带 CUDA 的精简镜像，
+	// 推理容器用完整的 vLLM 镜像，拉镜像更快、占用的节点磁盘更少
+	// +optional
+	Images *ImagesSpec `json:"images,omitempty"`
+
+	// ImagePinning 把镜像 tag 解析成 digest，Deployment 固定用这个 digest（image:tag@sha256:...），
+	// 避免 :latest 这类 tag 被重新推送后，新起的 Pod 悄悄换了版本；
+	// 定期检查 tag 是否指向了新的 digest，按 onTagMove 提醒或者重新部署
+	// +optional
+	ImagePinning *ImagePinningSpec `json:"imagePinning,omitempty"`
+
+	// Runtime 选择推理后端：vllm（默认）、tgi、llamacpp
+	// 非 vLLM 后端需要同时把 Image 换成对应后端的镜像（镜像里还要有 agent）
+	// llamacpp 可以在没有 GPU 的节点上运行 GGUF 模型
+	// stub 只用于测试控制面：不下载模型（生成占位文件），agent 自己提供 echo 的 OpenAI 接口，任何带 /agent 的镜像都能跑
+	// +kubebuilder:default=vllm
+	// +kubebuilder:validation:Enum=vllm;tgi;llamacpp;stub
+	// +optional
+	Runtime string `json:"runtime,omitempty"`
+
+	// RuntimeSocket 让推理服务只监听 Pod 内的 unix socket，agent 的健康检查、排空、watchdog 都走 socket，
+	// 不会和用户在 extraArgs、sidecar 里占用的端口混淆；8000 端口由 agent 转发到 socket，对外不变。
+	// 支持 vllm（需要有 --uds 参数的版本）、llamacpp、stub；不支持 tgi 和 initContainer 分发模式
+	// +optional
+	RuntimeSocket bool `json:"runtimeSocket,omitempty"`
+
+	// DevMode 在没有 GPU 的开发集群（kind、minikube）上运行完整的控制面：选举、分发、gateway。
+	// 推理后端换成 CPU 版的 llama.cpp（忽略 Runtime），Image 为空时用 operator 配置的 devImage，
+	// 不申请 GPU，资源和探针按小模型设置；webhook 只允许不超过 3B 参数的模型
+	// +optional
+	DevMode bool `json:"devMode,omitempty"`
+
 	// +kubebuilder:validation:Pattern=`^\d+(Gi|Mi)$`
 	// GPUMemory requirement, e.g. "24Gi". Used for scheduling.
 	GPUMemory string `json:"gpuMemory,omitempty"`
+
+	// IdleTimeout 超过这段时间没有请求就进入休眠（缩容到 IdleReplicas）
+	// 请求量由 gateway 统计，所以设置了 IdleTimeout 会自动部署 gateway
+	// +optional
+	IdleTimeout *metav1.Duration `json:"idleTimeout,omitempty"`
+
+	// IdleReplicas 休眠时保留的副本数，默认 1（保留一个 Pod，模型缓存不丢）
+	// 设为 0 可以完全释放 GPU，但唤醒时需要重新准备模型
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	IdleReplicas *int32 `json:"idleReplicas,omitempty"`
+
+	// Gateway 配置 LLMService 前面的 OpenAI 兼容网关
+	// +optional
+	Gateway *GatewaySpec `json:"gateway,omitempty"`
+
+	// Distribution 配置模型在副本之间的分发方式
+	// +optional
+	Distribution *DistributionSpec `json:"distribution,omitempty"`
+
+	// Coordination 是 coordinator 选举的人工干预（排障、性能调优时固定 coordinator）
+	// +optional
+	Coordination *CoordinationSpec `json:"coordination,omitempty"`
+
+	// Resources 是 agent 容器的 CPU/内存 requests/limits，不设置时使用默认值
+	// GPU 不在这里配置，统一由 GpuPerReplica 生成 nvidia.com/gpu
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// MaxGenerationTime 是单个请求最长的生成时间，默认 5 分钟
+	// Pod 下线时会等 in-flight 请求完成，terminationGracePeriodSeconds 据此计算
+	// +optional
+	MaxGenerationTime *metav1.Duration `json:"maxGenerationTime,omitempty"`
+
+	// UpdateWindow 限制需要重启推理服务的变更（新镜像、新参数等）只在维护窗口内应用
+	// 不设置时立即应用；副本数变化不受限制
+	// +optional
+	UpdateWindow *UpdateWindow `json:"updateWindow,omitempty"`
+
+	// Probes 覆盖默认生成的探针，不设置的探针使用默认值
+	// +optional
+	Probes *ProbesSpec `json:"probes,omitempty"`
+
+	// Fleet 把这个 LLMService 复制到成员集群（只在 hub 集群上设置，需要 manager 开启 --enable-fleet）
+	// +optional
+	Fleet *FleetSpec `json:"fleet,omitempty"`
+
+	// SLO 是服务质量目标，按 gateway 的请求指标在滚动窗口内评估
+	// 指标来自 gateway，所以设置了 SLO 会自动部署 gateway；manager 需要配置 Prometheus 地址
+	// +optional
+	SLO *SLOSpec `json:"slo,omitempty"`
+
+	// VLLM 是只对 vLLM 后端生效的参数
+	// +optional
+	VLLM *VLLMSpec `json:"vllm,omitempty"`
+
+	// Env 是额外注入推理容器的环境变量，例如 HTTPS_PROXY、HF_HOME、NCCL_*
+	// agent 模式下 agent 启动的推理服务进程会继承这些变量；initContainer 模式下拉取模型的 agent 容器也会注入。
+	// 和 controller 生成的变量（POD_NAME、MODEL_REPO 等）同名时以 controller 的为准
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// EnvFrom 从 ConfigMap/Secret 批量注入环境变量（例如保存 HF_TOKEN 的 Secret），注入范围和 Env 一样
+	// +optional
+	EnvFrom []corev1.EnvFromSource `json:"envFrom,omitempty"`
+
+	// SharedMemorySize 是推理容器 /dev/shm 的大小，例如 "16Gi"
+	// vLLM 的 tensor parallel 进程之间通过共享内存通信，容器运行时默认的 64Mi 不够，多卡时必须设置。
+	// 用 medium=Memory 的 emptyDir 实现，占用的内存计入容器的内存 limit
+	// +optional
+	SharedMemorySize *resource.Quantity `json:"sharedMemorySize,omitempty"`
+
+	// Storage 是推理 Pod 的存储：模型目录放在哪个卷上，下载断点，HF_HOME 和临时文件的 scratch 卷
+	// +optional
+	Storage *StorageSpec `json:"storage,omitempty"`
+
+	// HugePages 给推理容器申请大页内存，挂载到 /dev/hugepages
+	// +optional
+	HugePages *HugePagesSpec `json:"hugePages,omitempty"`
+
+	// Networking 是多卡/多机推理的网络配置：主机网络、RDMA 设备、NCCL 参数
+	// +optional
+	Networking *NetworkingSpec `json:"networking,omitempty"`
+
+	// PodSecurity 是推理 Pod 和 gateway Pod 的安全加固选项（seccomp、AppArmor、用户命名空间），
+	// 给开启了 restricted 级别 Pod Security Admission 的命名空间用
+	// +optional
+	PodSecurity *PodSecuritySpec `json:"podSecurity,omitempty"`
+
+	// Autoscaling 选择由谁管理推理 Deployment 的副本数
+	// +optional
+	Autoscaling *AutoscalingSpec `json:"autoscaling,omitempty"`
+
+	// Rollout 配置推理 Deployment 的滚动更新和 Pod 下线
+	// +optional
+	Rollout *RolloutSpec `json:"rollout,omitempty"`
+
+	// Expose 把 OpenAI 兼容的接口发布到集群外（Ingress、Gateway API HTTPRoute 或 LoadBalancer Service）
+	// +optional
+	Expose *ExposeSpec `json:"expose,omitempty"`
+}
+
+// ModelSourceSpec 是模型来源的选项
+type ModelSourceSpec struct {
+	// AcceptLicense 确认接受 gated 模型（Llama、Gemma 等）的许可协议。
+	// 还需要先用 HF_TOKEN 对应的账号在模型页面上同意协议（或等作者审核通过）；
+	// 没有设置时 agent 不下载 gated 模型，设置 LicenseNotAccepted Condition
+	// +optional
+	AcceptLicense bool `json:"acceptLicense,omitempty"`
+
+	// Registry 从 MLflow Model Registry 或 W&B artifact 下载模型，不设置时从 HuggingFace 下载
+	// +optional
+	Registry *ModelRegistrySpec `json:"registry,omitempty"`
+}
+
+// ModelRegistrySpec 是 HuggingFace 之外的模型仓库，内部微调的模型不用先手工复制到 HuggingFace 或 S3
+//
+// coordinator 从这里列文件、下载（并行分段、断点续传、校验和 HuggingFace 一样），follower 照常从 coordinator 同步。
+// alias（MLflow 的 @champion、W&B 的 :latest）在 coordinator 下载时解析，指向新版本后要重启 coordinator 才会换
+type ModelRegistrySpec struct {
+	// URI 是模型版本：
+	//   - models:/<name>/<version> 或 models:/<name>@<alias>：MLflow Model Registry，
+	//     模型文件要由 tracking server 代理（mlflow server --serve-artifacts）
+	//   - wandb-artifact://<entity>/<project>/<artifact>[:<version|alias>]：W&B artifact，默认 :latest
+	// +kubebuilder:validation:Pattern=`^(models:/|wandb-artifact://)`
+	URI string `json:"uri"`
+
+	// Endpoint 是 MLflow tracking server 的地址（models:/ 必填），
+	// 或者私有部署的 W&B 地址（默认 https://api.wandb.ai）
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Path 是版本里模型目录的相对路径，例如 MLflow transformers flavor 的 model；为空时是根目录
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// CredentialsSecret 是同命名空间下的 Secret 名称，按来源读取这些 key（都是可选的）：
+	//   - token：MLflow 的 Bearer token，或者 W&B 的 API key
+	//   - username、password：MLflow 的 basic auth（没有 token 时）
+	// +optional
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+// ModelRegistrySpec.CredentialsSecret 里的 key
+const (
+	ModelRegistryTokenKey    = "token"
+	ModelRegistryUsernameKey = "username"
+	ModelRegistryPasswordKey = "password"
+)
+
+// ExposeSpec 描述怎么把推理服务发布到集群外
+//
+// 流量指向 gateway（开启时）或 vLLM Service，对外的地址写在 status.endpoint
+type ExposeSpec struct {
+	// Type 是 Ingress、GatewayAPI 或 LoadBalancer
+	//   - Ingress: 生成 networking.k8s.io/v1 Ingress，需要集群里有 ingress controller
+	//   - GatewayAPI: 生成 gateway.networking.k8s.io/v1 HTTPRoute，挂到 gatewayRef 指定的 Gateway 上
+	//   - LoadBalancer: 生成 type=LoadBalancer 的 Service（<name>-public，80 端口）
+	// +kubebuilder:validation:Enum=Ingress;GatewayAPI;LoadBalancer
+	Type string `json:"type"`
+
+	// Host 是对外的域名，Ingress 和 GatewayAPI 必填；
+	// LoadBalancer 设置时写到 external-dns 的 hostname 注解上
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// TLSSecretRef 是同命名空间下保存证书的 Secret（kubernetes.io/tls），只用于 Ingress
+	// GatewayAPI 的证书在 Gateway 的 listener 上配置
+	// +optional
+	TLSSecretRef *corev1.LocalObjectReference `json:"tlsSecretRef,omitempty"`
+
+	// IngressClassName 是 Ingress 使用的 IngressClass，不设置时用集群默认的
+	// +optional
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+
+	// GatewayRef 是 HTTPRoute 挂载的 Gateway，GatewayAPI 必填
+	// +optional
+	GatewayRef *GatewayReference `json:"gatewayRef,omitempty"`
+
+	// Annotations 加到生成的对象上，例如 cert-manager 的 issuer、云厂商 LB 的参数
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// GatewayReference 指向一个 Gateway API 的 Gateway
+type GatewayReference struct {
+	// Name 是 Gateway 名称
+	Name string `json:"name"`
+
+	// Namespace 是 Gateway 所在的命名空间，默认和 LLMService 相同
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// SectionName 是 Gateway 的 listener 名称，不设置时挂到所有 listener
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// ExposeSpec.Type 的取值
+const (
+	ExposeTypeIngress      = "Ingress"
+	ExposeTypeGatewayAPI   = "GatewayAPI"
+	ExposeTypeLoadBalancer = "LoadBalancer"
+)
+
+// ImagesSpec 按角色指定镜像，不设置的字段使用 spec.image 和 operator 配置的默认值
+type ImagesSpec struct {
+	// Runtime 是运行推理服务的主容器的镜像，和 spec.image 作用相同，两者只能设置一个
+	// +optional
+	Runtime string `json:"runtime,omitempty"`
+
+	// Agent 是只运行 agent、不运行推理服务的容器的镜像（distribution.mode=initContainer 的
+	// model-server 和 model-fetch），需要带 /agent，不需要 CUDA；不设置时用 operator 配置的 distribution.agentImage。
+	// agent 模式下 agent 和推理服务在同一个容器里，用的是 Runtime 镜像
+	// +optional
+	Agent string `json:"agent,omitempty"`
+
+	// Gateway 是 gateway Deployment 的镜像，不设置时用 operator 配置的 gateway.image
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+}
+
+// ImagePinningSpec 是镜像 digest 固定的配置
+//
+// 只支持公共仓库（匿名访问）；解析失败时继续用 tag，发 Warning Event。
+// 开启时 Pod 模板里的镜像从 tag 换成 tag@digest，会滚动重启一次（受 updateWindow 限制）
+type ImagePinningSpec struct {
+	// OnTagMove 是 tag 指向新的 digest 之后的处理：
+	//   - Notify（默认）：继续用固定的 digest，ImageTagMoved condition 置为 True 并发 Event
+	//   - Redeploy：改用新的 digest 滚动更新
+	// +kubebuilder:default=Notify
+	// +kubebuilder:validation:Enum=Notify;Redeploy
+	// +optional
+	OnTagMove string `json:"onTagMove,omitempty"`
+
+	// CheckInterval 是检查 tag 是否移动的间隔，默认 1h，不能小于 1m
+	// +optional
+	CheckInterval *metav1.Duration `json:"checkInterval,omitempty"`
+}
+
+// ImagePinningSpec.OnTagMove 的取值
+const (
+	TagMoveNotify   = "Notify"
+	TagMoveRedeploy = "Redeploy"
+)
+
+// RolloutSpec 覆盖滚动更新参数，不设置的字段使用默认值
+//
+// GPU 紧张的集群没有多余的卡给 surge 出来的 Pod，新 Pod 会一直 Pending、滚动更新卡住，
+// 这时设 maxSurge=0、maxUnavailable=1：先停一个旧 Pod 腾出 GPU，再启动新 Pod
+type RolloutSpec struct {
+	// MaxSurge 是滚动更新时最多比期望副本数多出来的 Pod 数（整数或百分比），默认 25%
+	// +optional
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+
+	// MaxUnavailable 是滚动更新时最多不可用的 Pod 数（整数或百分比），默认 25%
+	// 不能和 maxSurge 同时为 0
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// TerminationGracePeriodSeconds 覆盖按 maxGenerationTime 算出来的 Pod 下线时间
+	// 比 maxGenerationTime 短时，排空 in-flight 请求的时间相应缩短，超时的请求会被中断
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+}
+
+// AutoscalingSpec 定义副本数的管理方式
+type AutoscalingSpec struct {
+	// Mode 是 Builtin（默认）、External 或 KEDA
+	//   - Builtin: controller 按 spec.replicas、休眠、SLO 扩容计算副本数
+	//   - External: 副本数交给用户自己创建的 HPA / KEDA ScaledObject，controller 不再写 Deployment 的 replicas。
+	//     指标来自 gateway（Prometheus 指标或 /scaler/metrics），所以会自动部署 gateway
+	//   - KEDA: controller 生成一个 KEDA ScaledObject（集群里需要已经装好 KEDA），
+	//     按 gateway 的 Prometheus 指标扩缩容；manager 需要配置 autoscaling.prometheusURL
+	// External 和 KEDA 不能和 idleTimeout、slo.maxReplicas 一起使用
+	// +kubebuilder:default=Builtin
+	// +kubebuilder:validation:Enum=Builtin;External;KEDA
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// MinReplicas 是 KEDA 模式的最小副本数，默认 spec.replicas
+	// 设为 0 时没有请求会缩容到 0，新请求由 gateway 挡住等待 KEDA 扩容（和休眠唤醒一样）
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas 是 KEDA 模式的最大副本数，KEDA 模式必须设置
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxReplicas int32 `json:"maxReplicas,omitempty"`
+
+	// TargetQueueDepth 是每个副本平均的 in-flight 请求数目标（gateway 统计的排队和处理中的请求）
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetQueueDepth *int32 `json:"targetQueueDepth,omitempty"`
+
+	// TargetTokensPerSecond 是每个副本平均的 token 速率目标（prompt + completion）
+	// 和 TargetQueueDepth 至少设置一个；两个都设置时取需要副本数较多的那个
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetTokensPerSecond *int32 `json:"targetTokensPerSecond,omitempty"`
+
+	// CooldownPeriod 是负载下降后等多久才缩容，默认 10 分钟
+	// 新副本要重新加载模型，缩得太快会来回抖动
+	// +optional
+	CooldownPeriod *metav1.Duration `json:"cooldownPeriod,omitempty"`
+}
+
+// AutoscalingSpec.Mode 的取值
+const (
+	AutoscalingModeBuiltin  = "Builtin"
+	AutoscalingModeExternal = "External"
+	AutoscalingModeKEDA     = "KEDA"
+)
+
+// CoordinationSpec 是 coordinator 选举的人工干预
+type CoordinationSpec struct {
+	// PinnedPod 强制让这个 Pod 当 coordinator（break-glass）：
+	// controller 确认 Pod 属于这个 LLMService、在运行、所在节点 Ready 之后把它写到 lease 的
+	// kubeinfer.io/pinned-coordinator 注解上，这个 Pod 的 agent 马上接管 lease，其他 Pod 不再主动接管。
+	// Pod 不满足条件时不生效，status 的 CoordinatorPinned Condition 说明原因；去掉这个字段恢复正常选举
+	// +optional
+	PinnedPod string `json:"pinnedPod,omitempty"`
+}
+
+// StorageSpec 是推理 Pod 的存储配置
+type StorageSpec struct {
+	// Scratch 覆盖 scratch 卷的默认值
+	// +optional
+	Scratch *ScratchSpec `json:"scratch,omitempty"`
+
+	// Models 把模型目录（/models）放到一个已有的 PVC 上，不设置时每个 Pod 一个 emptyDir
+	// +optional
+	Models *ModelStorageSpec `json:"models,omitempty"`
+
+	// Checkpoint 把 follower 下载到一半的文件放到 PVC 上，Pod 被驱逐、重新调度后接着下载
+	// +optional
+	Checkpoint *CheckpointSpec `json:"checkpoint,omitempty"`
+}
+
+// CheckpointSpec 是 follower 下载断点所在的 PVC
+//
+// 每个 Pod 在 PVC 上有一个自己的目录，同步完成后搬到模型目录并删除；
+// Deployment 重建的 Pod 认领已经不在的 Pod 留下的目录，从断开的地方接着下载。
+// 副本分布在多个节点上时 PVC 必须是 ReadWriteMany。
+// controller 创建一个每小时运行的 CronJob，删除超过 TTL 没有变化的目录
+type CheckpointSpec struct {
+	// ClaimName 是同一命名空间下的 PVC 名称
+	// +kubebuilder:validation:MinLength=1
+	ClaimName string `json:"claimName"`
+
+	// TTL 是断点目录没有任何变化多久之后被清理，默认 24h
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+}
+
+// ModelStorageSpec 是模型目录所在的 PVC
+//
+// 所有副本挂载同一个 PVC，共用上面的同一份模型：coordinator 照常下载（写到卷上），
+// follower 不再通过 HTTP 传输，只等 coordinator 写完完整标记、按清单校验通过就启动推理服务。
+// 副本分布在多个节点上时 PVC 必须是 ReadWriteMany（NFS、CephFS、云厂商的文件存储）
+type ModelStorageSpec struct {
+	// ClaimName 是同一命名空间下的 PVC 名称
+	// +kubebuilder:validation:MinLength=1
+	ClaimName string `json:"claimName"`
+}
+
+// ScratchSpec 是 scratch 卷（/scratch）的配置
+//
+// huggingface-cli 和 hf_xet 把临时文件和分块缓存放在 HF_HOME 下，默认在容器的可写层里，
+// 大模型下载时能把节点的根分区写满。推理 Pod 总是挂一个 emptyDir 作为 HF_HOME 和 TMPDIR，
+// 用 sizeLimit 限制大小（超出时 kubelet 驱逐这个 Pod，而不是写满节点）。
+// spec.env 里设置了 HF_HOME、TMPDIR 的以用户的为准
+type ScratchSpec struct {
+	// Size 是 scratch 卷的 sizeLimit，不设置时按模型权重的估算大小加上余量计算（看不出模型大小时 20Gi）
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// Medium 为 Memory 时用 tmpfs，占用的内存计入容器的内存 limit，必须同时设置 size；默认用节点磁盘
+	// +kubebuilder:validation:Enum="";Memory
+	// +optional
+	Medium string `json:"medium,omitempty"`
+}
+
+// PodSecuritySpec 映射到 Pod 的 securityContext（AppArmor 同时写 1.30 以前使用的注解）
+type PodSecuritySpec struct {
+	// Restricted 让 Pod 符合 Pod Security Standards 的 restricted 级别：
+	// 以非 root 用户运行（runAsUser，默认 1000）、禁止提权、去掉所有 capability，
+	// 没有设置 seccompProfile 时用 RuntimeDefault。不能和 hostNetwork、RDMA（需要 IPC_LOCK）一起使用
+	// +optional
+	Restricted bool `json:"restricted,omitempty"`
+
+	// RunAsUser 是 restricted 模式下运行容器的用户，也作为 fsGroup；镜像里的文件需要这个用户能读
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+
+	// SeccompProfile 是 Pod 级别的 seccomp 配置（RuntimeDefault、Localhost、Unconfined）
+	// +optional
+	SeccompProfile *corev1.SeccompProfile `json:"seccompProfile,omitempty"`
+
+	// AppArmorProfile 是 Pod 级别的 AppArmor 配置（RuntimeDefault、Localhost、Unconfined），
+	// 只在启用了 AppArmor 的节点上生效
+	// +optional
+	AppArmorProfile *corev1.AppArmorProfile `json:"appArmorProfile,omitempty"`
+
+	// UserNamespace 让容器运行在独立的用户命名空间里（hostUsers: false），容器里的 root 不是节点上的 root。
+	// 需要 Kubernetes 1.30+ 打开 UserNamespacesSupport（1.33 起默认打开）、Linux 6.3+，不能和 hostNetwork 一起使用
+	// +optional
+	UserNamespace bool `json:"userNamespace,omitempty"`
+}
+
+// DefaultRunAsUser 是 PodSecuritySpec.Restricted 默认的运行用户
+const DefaultRunAsUser int64 = 1000
+
+// NetworkingSpec 定义推理 Pod 的网络
+type NetworkingSpec struct {
+	// HostNetwork 让 Pod 使用节点网络（绕过 CNI，NCCL 可以直接用节点网卡）
+	// 推理服务的端口（8000/8080/8081）会直接占用节点端口，同一个节点上只能跑一个副本
+	// +optional
+	HostNetwork bool `json:"hostNetwork,omitempty"`
+
+	// RDMA 给推理容器申请 RDMA 设备（由 RDMA device plugin 提供的扩展资源）
+	// +optional
+	RDMA *RDMASpec `json:"rdma,omitempty"`
+
+	// NCCLEnv 是 NCCL 的环境变量，例如 NCCL_IB_HCA: mlx5、NCCL_SOCKET_IFNAME: eth0
+	// key 必须以 NCCL_ 开头
+	// +optional
+	NCCLEnv map[string]string `json:"ncclEnv,omitempty"`
+}
+
+// RDMASpec 定义申请的 RDMA 设备
+type RDMASpec struct {
+	// ResourceName 是 device plugin 注册的扩展资源名，默认 rdma/rdma_shared_device_a
+	// （k8s-rdma-shared-dev-plugin 的默认配置）
+	// +optional
+	ResourceName string `json:"resourceName,omitempty"`
+
+	// Count 是每个副本申请的设备数，默认 1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Count int32 `json:"count,omitempty"`
+}
+
+// DefaultRDMAResourceName 是 RDMASpec.ResourceName 的默认值
+const DefaultRDMAResourceName = "rdma/rdma_shared_device_a"
+
+// HugePagesSpec 定义大页内存，节点需要预先配置对应大小的大页
+type HugePagesSpec struct {
+	// PageSize 是页大小：2Mi 或 1Gi
+	// +kubebuilder:default="2Mi"
+	// +kubebuilder:validation:Enum="2Mi";"1Gi"
+	// +optional
+	PageSize string `json:"pageSize,omitempty"`
+
+	// Size 是申请的总量，必须是 PageSize 的整数倍
+	Size resource.Quantity `json:"size"`
+}
+
+// DefaultHugePageSize 是 HugePagesSpec.PageSize 的默认值
+const DefaultHugePageSize = "2Mi"
+
+// VLLMSpec 定义 vLLM 专用的启动参数
+type VLLMSpec struct {
+	// ChatTemplateConfigMapRef 引用一个保存 Jinja chat template 的 ConfigMap，
+	// 挂载到每个副本并通过 --chat-template 传给 vLLM，覆盖模型自带的模板。
+	// ConfigMap 的内容变化后所有副本会滚动重启（受 UpdateWindow 限制）
+	// +optional
+	ChatTemplateConfigMapRef *ConfigMapKeyRef `json:"chatTemplateConfigMapRef,omitempty"`
+
+	// Tokenizer 覆盖模型自带的 tokenizer（--tokenizer），可以是 HuggingFace 仓库名或 Pod 内的路径
+	// +optional
+	Tokenizer string `json:"tokenizer,omitempty"`
+
+	// TensorParallelSize 是 --tensor-parallel-size，不能超过 GpuPerReplica，而且要能整除模型的注意力头数。
+	// 不设置时按 GpuPerReplica 推算（已知模型的头数时取不超过卡数、能整除头数的最大的 2 的幂）
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TensorParallelSize *int32 `json:"tensorParallelSize,omitempty"`
+
+	// GPUMemoryUtilization 是 --gpu-memory-utilization，vLLM 可以占用的显存比例（0-1），不设置时是 0.9。
+	// 用字符串是因为 CRD 不推荐 float（和 CostReport 一样），例如 "0.85"
+	// +kubebuilder:validation:Pattern=`^(0?\.[0-9]+|1(\.0+)?)$`
+	// +optional
+	GPUMemoryUtilization string `json:"gpuMemoryUtilization,omitempty"`
+
+	// MaxModelLen 是 --max-model-len，最大上下文长度，不设置时用模型自己的配置。
+	// 调小可以减少 KV cache 占用的显存
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxModelLen *int32 `json:"maxModelLen,omitempty"`
+
+	// Dtype 是 --dtype，模型权重和激活的数据类型，不设置时是 auto（按模型配置）
+	// +kubebuilder:validation:Enum=auto;half;float16;bfloat16;float;float32
+	// +optional
+	Dtype string `json:"dtype,omitempty"`
+
+	// ExtraArgs 是额外传给 vLLM 的命令行参数，按顺序追加在生成的参数后面。
+	// controller 自己生成的参数（--model、--port、--tensor-parallel-size、--chat-template 等）不能在这里设置，
+	// 要用对应的字段或 spec.env 里的 VLLM_* 变量
+	// +optional
+	ExtraArgs []VLLMArg `json:"extraArgs,omitempty"`
+}
+
+// VLLMArg 是一个 vLLM 命令行参数
+type VLLMArg struct {
+	// Name 是参数名，带 -- 前缀，例如 --enable-prefix-caching
+	// +kubebuilder:validation:Pattern=`^--[a-z0-9][a-z0-9-]*$`
+	Name string `json:"name"`
+
+	// Value 是参数值，作为单独的一个参数传递（可以包含空格，例如 JSON）。为空时是开关参数
+	// +optional
+	Value string `json:"value,omitempty"`
+}
+
+// ConfigMapKeyRef 引用同一个 namespace 里 ConfigMap 的一个 key
+type ConfigMapKeyRef struct {
+	// Name 是 ConfigMap 名称
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Key 是 ConfigMap 里的 key，默认 chat_template.jinja
+	// +kubebuilder:default=chat_template.jinja
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// DefaultChatTemplateKey 是 ConfigMapKeyRef.Key 的默认值
+const DefaultChatTemplateKey = "chat_template.jinja"
+
+// SLOSpec 定义服务质量目标，两个目标至少设置一个
+//
+// 任何一个目标在 Window 内没有达到就把 SLOViolated condition 设为 True 并发 Warning Event
+type SLOSpec struct {
+	// P99LatencyMs 是 gateway 观测到的端到端延迟 P99 上限（毫秒）
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	P99LatencyMs *int32 `json:"p99LatencyMs,omitempty"`
+
+	// Availability 是非 5xx 响应占比的下限（百分比），例如 "99.9"
+	// 用字符串是因为 CRD 不推荐 float（和 CostReport 一样）
+	// +kubebuilder:validation:Pattern=`^(100(\.0+)?|[0-9]{1,2}(\.[0-9]+)?)$`
+	// +optional
+	Availability string `json:"availability,omitempty"`
+
+	// Window 是滚动评估窗口，默认 5 分钟
+	// +optional
+	Window *metav1.Duration `json:"window,omitempty"`
+
+	// MaxReplicas 设置后违反 SLO 时自动扩容：每个窗口加一个副本，最多到 MaxReplicas；
+	// 恢复后每个窗口减一个，直到回到 spec.replicas。不设置时只告警
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+}
+
+// FleetSpec 定义 LLMService 复制到哪些成员集群
+//
+// 成员集群通过 kubeconfig Secret 注册：manager 命名空间下带
+// kubeinfer.io/member-cluster label 的 Secret，data.kubeconfig 是成员集群的 kubeconfig，
+// Secret 名称就是集群名称。成员集群需要安装同版本的 CRD 和 controller。
+type FleetSpec struct {
+	// Clusters 是要复制到的成员集群名称，为空表示所有已注册的成员集群
+	// +optional
+	Clusters []string `json:"clusters,omitempty"`
+
+	// ModelEndpoint 是 hub 集群 coordinator model server 对外暴露的地址，
+	// 例如 http://models.hub.example.com:8080
+	// 设置后成员集群的 coordinator 从这里复制模型，而不是各自从 HuggingFace 下载
+	// +optional
+	ModelEndpoint string `json:"modelEndpoint,omitempty"`
+}
+
+// UpdateWindow 定义允许重启推理服务的维护窗口
+//
+// 例如 schedule="0 2 * * 6"、duration=4h 表示每周六 02:00-06:00
+type UpdateWindow struct {
+	// Schedule 是窗口开始时间的 cron 表达式（分 时 日 月 周）
+	// +kubebuilder:validation:MinLength=9
+	Schedule string `json:"schedule"`
+
+	// Duration 窗口持续时间，默认 1 小时
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+
+	// TimeZone 是 Schedule 使用的 IANA 时区，例如 "Asia/Shanghai"，默认 UTC
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// ProbesSpec 定义 agent 容器的探针
+//
+// 默认值：
+// - startupProbe: GET /readyz，失败次数上限根据模型大小估算（大模型加载要十几分钟）
+// - readinessProbe: GET /readyz（vLLM 加载完成且没有卡死）
+// - livenessProbe: GET /healthz（agent 进程还能响应）
+type ProbesSpec struct {
+	// +optional
+	Startup *corev1.Probe `json:"startup,omitempty"`
+	// +optional
+	Readiness *corev1.Probe `json:"readiness,omitempty"`
+	// +optional
+	Liveness *corev1.Probe `json:"liveness,omitempty"`
+}
+
+// 推理后端（和 agent 的 INFERENCE_RUNTIME 一致）
+const (
+	RuntimeVLLM     = "vllm"
+	RuntimeTGI      = "tgi"
+	RuntimeLlamaCpp = "llamacpp"
+	RuntimeStub     = "stub"
+
+	// DefaultImage 是 Spec.Image 的默认值（vLLM 镜像）
+	DefaultImage = "vllm/vllm-openai:latest"
+
+	// DefaultDevImage 是 DevMode 下 Spec.Image 的默认值：agent + CPU 版 llama-server，
+	// 用 test/testdata/llamacpp-cpu/Dockerfile 在本地构建后 kind load 进集群
+	DefaultDevImage = "kubeinfer-agent:llamacpp-cpu"
+)
+
+// GPUResourceName 是 GpuPerReplica 对应的扩展资源名
+const GPUResourceName corev1.ResourceName = "nvidia.com/gpu"
+
+const (
+	// TopologyFlat 所有 follower 都从 coordinator 拿模型
+	TopologyFlat = "flat"
+	// TopologyZone 每个 zone 选一个 seeder，同 zone 的 follower 从 seeder 拿模型
+	TopologyZone = "zone"
+)
+
+const (
+	// LayoutFlat 模型文件直接放在模型目录下，换 revision 时旧文件归档到隐藏目录
+	LayoutFlat = "flat"
+	// LayoutVersioned 每个 revision 一个目录（<repo>/<revision>/），推理服务读 current 软链接
+	LayoutVersioned = "versioned"
+)
+
+const (
+	// LocalCopySize 本地文件大小和 coordinator 的清单一致就跳过
+	LocalCopySize = "size"
+	// LocalCopySHA256 还要比 sha256，大小相同内容不同的文件也重新下载
+	LocalCopySHA256 = "sha256"
+	// LocalCopyRedownload 不复用本地文件，全部重新下载
+	LocalCopyRedownload = "redownload"
+)
+
+const (
+	// StrategyDirect 每个副本各自从上游下载，不运行 model server
+	StrategyDirect = "direct"
+	// StrategyCoordinator 选出一个 coordinator 从上游下载，其他副本从它那里同步
+	StrategyCoordinator = "coordinator"
+	// StrategyP2P 在 coordinator 的基础上，同步完成的副本也给其他副本供货（peer serving）
+	StrategyP2P = "p2p"
+	// StrategyNodeCache 在 coordinator 的基础上，模型放在节点的 hostPath 上，Pod 重建后在同一个节点上不用重新下载
+	StrategyNodeCache = "nodeCache"
+
+	// CacheStrategyShared 是旧字段 cacheStrategy 里对应 StrategyCoordinator 的值
+	CacheStrategyShared = "shared"
+	// CacheStrategyNone 是旧字段 cacheStrategy 的默认值
+	CacheStrategyNone = "none"
+)
+
+const (
+	// DistributionModeAgent 主容器运行 agent，agent 拉取模型后启动推理服务
+	DistributionModeAgent = "agent"
+	// DistributionModeInitContainer initContainer 拉取模型，主容器只运行推理服务
+	DistributionModeInitContainer = "initContainer"
+)
+
+const (
+	// VerifySize 只校验文件大小（默认）
+	VerifySize = "size"
+	// VerifySHA256 coordinator 下载完成后生成 sha256 清单，follower 收到文件后逐个校验
+	VerifySHA256 = "sha256"
+	// VerifySignature 模型仓库里必须有发布者签名的清单，coordinator 和 follower 都校验签名和 sha256
+	VerifySignature = "signature"
+
+	// VerificationPublicKeyKey 是 Verification.PublicKeySecret 里 ed25519 公钥（PEM）的 key
+	VerificationPublicKeyKey = "public.pem"
+)
+
+// DistributionSpec 定义模型分发
+type DistributionSpec struct {
+	// Strategy 决定模型怎么到达各个副本
+	// - direct: 每个副本各自从上游下载，不运行 model server（同 modelServer=false）
+	// - coordinator: 选出一个 coordinator 下载，其他副本从它那里同步（按 topology 可以经过 zone 的 seeder）
+	// - p2p: coordinator，加上同步完成的副本也给其他副本供货（同 peerServing=true）
+	// - nodeCache: coordinator，模型目录放在节点的 hostPath（/var/lib/kubeinfer/models/<namespace>/<name>）上，
+	//   重建、滚动更新后落在同一个节点上的 Pod 直接复用；每个节点最多一个副本，滚动更新先删旧 Pod 再建新 Pod
+	// 不设置时看旧字段 cacheStrategy（shared 等同于 coordinator），再不然按副本数自动在 direct 和 coordinator 之间选择
+	// +kubebuilder:validation:Enum=direct;coordinator;p2p;nodeCache
+	// +optional
+	Strategy string `json:"strategy,omitempty"`
+
+	// Mode 决定模型由谁拉取
+	// - agent: 主容器运行 agent，agent 拉取模型后启动推理服务，镜像里需要带 agent（默认）
+	// - initContainer: initContainer 拉取模型，主容器直接运行推理服务，可以用官方 vLLM/TGI 镜像；
+	//   agent 以原生 sidecar 运行 model server 给其他副本供货（需要 Kubernetes 1.29+），
+	//   只支持 flat 拓扑，不支持 llamacpp（启动参数要等模型下载完才知道）
+	// +kubebuilder:validation:Enum=agent;initContainer
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// Topology 决定 follower 从哪里拿模型
+	// - flat: 所有 follower 都从 coordinator 拿（默认）
+	// - zone: 每个 zone 的 seeder 跨 zone 拿一次，同 zone 的 follower 从 seeder 拿，减少跨 AZ 流量
+	// 不设置时使用 operator 配置的 distribution.defaultTopology
+	// +kubebuilder:validation:Enum=flat;zone
+	// +optional
+	Topology string `json:"topology,omitempty"`
+
+	// Layout 决定模型目录的结构
+	// - flat: 模型文件直接放在模型目录下（默认）
+	// - versioned: 每个 revision 一个目录 <repo>/<revision>/，推理服务读 current 软链接；
+	//   新 revision 校验通过后才切换 current，旧 revision 留在磁盘上，切回去不用下载；
+	//   升级时内容没变的文件（sha256 相同）从旧 revision 硬链接过来，只下载改过的文件；
+	//   按 retention.keepRevisions 回收没有进程在用的 revision。适合复用的 hostPath/PVC，只支持 agent 模式
+	// +kubebuilder:validation:Enum=flat;versioned
+	// +optional
+	Layout string `json:"layout,omitempty"`
+
+	// UpstreamURL 是另一个集群 coordinator model server 的地址
+	// 设置后 coordinator 从这里复制模型（下载失败时退回 HuggingFace），fleet 模式下由 hub 自动设置
+	// +optional
+	UpstreamURL string `json:"upstreamURL,omitempty"`
+
+	// Verification 决定下载/传输完成后怎么校验模型文件，不设置时只校验大小
+	// +optional
+	Verification *VerificationSpec `json:"verification,omitempty"`
+
+	// LocalCopy 决定 follower 怎么对待本地已经有的模型文件（容器重启、nodeCache、PVC 上留下的副本）
+	// - size: 和 coordinator 的清单比大小，一致的跳过，只下载缺的和大小不对的（默认）
+	// - sha256: 还要比 sha256（清单或者 coordinator 的 /digests 里有时），要把本地文件读一遍
+	// - redownload: 不复用本地文件，全部重新下载
+	// coordinator 还没有清单（还在下载）时，本地已有的文件照旧跳过
+	// +kubebuilder:validation:Enum=size;sha256;redownload
+	// +optional
+	LocalCopy string `json:"localCopy,omitempty"`
+
+	// Download 调整 coordinator 从 HuggingFace 下载的并发，不设置时使用 agent 的默认值
+	// +optional
+	Download *DownloadSpec `json:"download,omitempty"`
+
+	// Bandwidth 限制 coordinator 的网卡带宽，并在上游下载和给 follower 供货之间分配
+	// +optional
+	Bandwidth *BandwidthSpec `json:"bandwidth,omitempty"`
+
+	// Retention 决定模型目录里保留几个 revision，不设置时只保留当前的
+	// +optional
+	Retention *RetentionSpec `json:"retention,omitempty"`
+
+	// PeerServing 为 true 时 follower 同步完成后也提供只读的 model server，并向 coordinator 注册；
+	// 正在同步的 follower 在 coordinator 挂掉时可以从这些 peer 继续，不用等新的 coordinator。
+	// peer 之间用 controller 生成的 token（<name>-model-server Secret）认证。
+	// 只支持 agent 模式的 flat 拓扑（zone 拓扑的 seeder 已经在给本 zone 供货）
+	// +optional
+	PeerServing bool `json:"peerServing,omitempty"`
+
+	// ModelServer 决定是否运行 8080 上给其他副本供货的 model server。
+	// 不设置时自动决定：副本数不会超过 1（replicas=1，没有 External/KEDA、slo.maxReplicas、fleet）时不运行，
+	// Pod 不声明 8080，不加 wait-coordinator 闸门，不创建 coordinator Service。
+	// 设为 false 时每个副本各自从上游下载（集群下载队列只对 lease 持有者生效），不支持 peerServing 和 zone 拓扑；
+	// 设为 true 时始终运行，例如另一个集群的 upstreamURL 指向这里。
+	// 自动决定时，副本数在 1 和更多之间变化会改 Pod 模板，已有的 Pod 被滚动重建
+	// +optional
+	ModelServer *bool `json:"modelServer,omitempty"`
+
+	// EvictionProtection 在 coordinator 给足够多的 follower 供货时阻止驱逐（节点 drain、集群缩容），
+	// 避免它被驱逐后所有 follower 从头重新下载；超过 MaxDuration 后不再阻止。
+	// 只支持 agent 模式（initContainer 模式的 agent 不上报传输状态）
+	// +optional
+	EvictionProtection *EvictionProtectionSpec `json:"evictionProtection,omitempty"`
+
+	// DownloadPriority 是在集群下载队列里的优先级，越大越先下载，相同时先到先得。
+	// 只在 operator 配置了 distribution.maxConcurrentDownloads 时生效
+	// +optional
+	DownloadPriority int32 `json:"downloadPriority,omitempty"`
+
+	// Snapshot 把校验过的模型备份到对象存储，之后的冷启动优先从备份恢复，
+	// 不受 HuggingFace 限流、仓库被删除的影响
+	// +optional
+	Snapshot *SnapshotSpec `json:"snapshot,omitempty"`
+}
+
+// SnapshotSpec 定义模型快照所在的对象存储（S3 兼容的 API）
+//
+// coordinator 第一次从上游下载并校验通过后，在后台把模型文件上传到 <url>/<model>/；
+// 之后的冷启动先从这里恢复（同样按 verification 校验），没有快照或者恢复失败时照常从上游下载
+type SnapshotSpec struct {
+	// URL 是 s3://<bucket>/<prefix> 或 gs://<bucket>/<prefix>
+	// +kubebuilder:validation:Pattern=`^(s3|gs)://[^/]+(/.*)?$`
+	URL string `json:"url"`
+
+	// Endpoint 是 S3 兼容服务的地址，例如 MinIO 的 http://minio.storage:9000；
+	// 不设置时 s3:// 用 AWS，gs:// 用 https://storage.googleapis.com
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Region 是 bucket 所在的区域，默认 us-east-1
+	// +optional
+	Region string `json:"region,omitempty"`
+
+	// CredentialsSecret 是同命名空间下的 Secret 名称，
+	// 包含 HMAC key 的 accessKeyID 和 secretAccessKey（GCS 在 Interoperability 里创建）
+	CredentialsSecret string `json:"credentialsSecret"`
+
+	// ReadOnly 为 true 时只从快照恢复，不上传（例如多个集群共用一个 bucket，只让一个集群写）
+	// +optional
+	ReadOnly bool `json:"readOnly,omitempty"`
+}
+
+// SnapshotSpec.CredentialsSecret 里的 key
+const (
+	SnapshotAccessKeyIDKey     = "accessKeyID"
+	SnapshotSecretAccessKeyKey = "secretAccessKey"
+)
+
+// EvictionProtectionSpec 定义 coordinator 供货期间的驱逐保护
+//
+// agent 把正在下载的 follower 数写到自己 Pod 的注解上，达到 MinActiveTransfers 时
+// controller 创建一个只选 coordinator 的 PodDisruptionBudget（maxUnavailable: 0），
+// 传输结束或者从 PDB 创建算起超过 MaxDuration 后放开，2*MaxDuration 后删除
+type EvictionProtectionSpec struct {
+	// MinActiveTransfers 是开始阻止驱逐的最少 follower 数
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinActiveTransfers int32 `json:"minActiveTransfers,omitempty"`
+
+	// MaxDuration 是最长阻止多久，防止传输卡住时节点永远 drain 不掉，默认 30m
+	// +optional
+	MaxDuration *metav1.Duration `json:"maxDuration,omitempty"`
+}
+
+// RetentionSpec 定义模型目录的垃圾回收策略
+//
+// 换模型或者上游仓库更新时，旧 revision 归档到模型目录下的隐藏目录（versioned 布局留在自己的目录里），
+// 切回来时不用重新下载；超出 KeepRevisions 的归档和不属于当前 revision 的文件会被删掉，推理服务正在使用的文件不动
+type RetentionSpec struct {
+	// KeepRevisions 是保留的 revision 数，包括当前的
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	// +optional
+	KeepRevisions int32 `json:"keepRevisions,omitempty"`
+}
+
+// BandwidthSpec 定义 coordinator 的带宽预算
+//
+// coordinator 还在从上游下载时，ServePercent 的带宽给 follower，剩下的给上游下载；
+// 上游下载完成后全部带宽给 follower
+type BandwidthSpec struct {
+	// Limit 是总带宽（bytes/s），例如 10G 网卡留一些余量可以设成 1Gi
+	// +kubebuilder:validation:Required
+	Limit resource.Quantity `json:"limit"`
+
+	// ServePercent 是上游下载期间给 follower 的百分比
+	// +kubebuilder:default=70
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=99
+	// +optional
+	ServePercent int32 `json:"servePercent,omitempty"`
+}
+
+// DownloadSpec 定义 coordinator 从 HuggingFace 并行下载的方式
+//
+// 多个文件同时下载；大于 ChunkSize 的文件按 HTTP Range 切成多段，每段一个连接
+type DownloadSpec struct {
+	// Concurrency 是同时打开的下载连接数（文件和分段共用）
+	// +kubebuilder:default=8
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=64
+	// +optional
+	Concurrency int32 `json:"concurrency,omitempty"`
+
+	// ChunkSize 是大文件每段的大小，不小于 1Mi，默认 256Mi
+	// +optional
+	ChunkSize *resource.Quantity `json:"chunkSize,omitempty"`
+}
+
+// VerificationSpec 定义模型文件的校验策略
+type VerificationSpec struct {
+	// Policy 是校验策略
+	// - size: 只校验文件大小（默认）
+	// - sha256: coordinator 生成 sha256 清单，follower 逐个校验
+	// - signature: 仓库里的 kubeinfer-manifest.json 必须有 ed25519 签名（kubeinfer-manifest.json.sig）
+	// +kubebuilder:default=size
+	// +kubebuilder:validation:Enum=size;sha256;signature
+	// +optional
+	Policy string `json:"policy,omitempty"`
+
+	// PublicKeySecret 是同命名空间下的 Secret 名称，public.pem 是验证签名的 ed25519 公钥
+	// Policy 为 signature 时必填
+	// +optional
+	PublicKeySecret string `json:"publicKeySecret,omitempty"`
+}
+
+// GatewaySpec 定义每个 LLMService 前面的网关
+//
+// 网关是一个反向代理，负责统计请求、在休眠时唤醒后端。
+type GatewaySpec struct {
+	// Enabled 为 true 时部署 gateway
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// FailoverSecret 是同命名空间下的 Secret 名称，upstreams.json 里列出其他集群的 gateway：
+	// [{"name":"eu-west","url":"https://eu.example.com","latencyPenalty":"80ms"}]
+	// 本地没有 ready 副本时 gateway 把请求溢出到这些集群
+	// +optional
+	FailoverSecret string `json:"failoverSecret,omitempty"`
+
+	// Guardrails 是 gateway 转发生成请求之前做的检查，修改后 gateway 滚动重启生效
+	// +optional
+	Guardrails *GuardrailsSpec `json:"guardrails,omitempty"`
+}
+
+// GuardrailsSpec 配置 gateway 的基础防护，不需要再在前面部署一层代理
+//
+// 只检查 /v1/completions 和 /v1/chat/completions 的请求；被拒绝的请求返回 400，
+// 错误格式和 OpenAI 一样（error.code 是 guardrail_<名称>）
+type GuardrailsSpec struct {
+	// MaxPromptTokens 是 prompt 的最大 token 数
+	// gateway 没有 tokenizer，按 4 个字符一个 token 估算
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxPromptTokens *int32 `json:"maxPromptTokens,omitempty"`
+
+	// BannedPatterns 是 RE2 正则表达式，prompt（chat 请求是所有 message 的内容）匹配任何一个就拒绝
+	// +optional
+	BannedPatterns []string `json:"bannedPatterns,omitempty"`
+
+	// OutputJSONSchema 是生成结果必须满足的 JSON Schema
+	// 请求没有自己指定 guided_json 或 response_format 时，gateway 把它作为 guided_json 传给 vLLM，
+	// 由 guided decoding 保证输出符合 schema（只支持 runtime=vllm）
+	// +optional
+	OutputJSONSchema string `json:"outputJSONSchema,omitempty"`
 }
 
 // LLMServiceStatus defines the observed state of LLMService
@@ -58,10 +1047,220 @@ type LLMServiceStatus struct {
 
 	Conditions       []LLMServiceCondition `json:"conditions,omitempty"`
 	CacheCoordinator string                `json:"cacheCoordinator,omitempty"`
+
+	// CoordinatorNode 是当前 coordinator Pod 所在的节点，用于节点故障检测
+	// +optional
+	CoordinatorNode string `json:"coordinatorNode,omitempty"`
+
+	// Fleet 是每个成员集群的复制状态（fleet 模式下由 hub 集群更新）
+	// +optional
+	Fleet []FleetMemberStatus `json:"fleet,omitempty"`
+
+	// Model 是给用户看的模型信息（以前散落在 -cache ConfigMap 里）
+	// +optional
+	Model *ModelStatus `json:"model,omitempty"`
+
+	// CostReport 是 GPU 使用量、利用率和估算费用的汇总，供平台团队做 chargeback
+	// +optional
+	CostReport *CostReport `json:"costReport,omitempty"`
+
+	// Plan 是 dry-run 模式（kubeinfer.io/dry-run: "true"）下计算出的变更计划
+	// +optional
+	Plan *ReconcilePlan `json:"plan,omitempty"`
+
+	// SLO 是最近一次 SLO 评估的结果（设置了 spec.slo 才有）
+	// +optional
+	SLO *SLOStatus `json:"slo,omitempty"`
+
+	// ChatTemplateChecksum 是当前挂载的 chat template 内容的 SHA-256 前缀，
+	// 写在 Pod 模板的 annotation 上，内容变化时触发滚动重启
+	// +optional
+	ChatTemplateChecksum string `json:"chatTemplateChecksum,omitempty"`
+
+	// ConfigChecksum 是推理 Pod 引用的 ConfigMap、Secret（spec.env、spec.envFrom、挂载的卷）内容的 SHA-256 前缀，
+	// 写在 Pod 模板的 annotation 上，内容变化时触发滚动重启
+	// +optional
+	ConfigChecksum string `json:"configChecksum,omitempty"`
+
+	// ScaledObject 是 KEDA 模式下 controller 生成的 ScaledObject 名称，
+	// 切换到其他模式时据此删除（没有装 KEDA 的集群不用每次都去查）
+	// +optional
+	ScaledObject string `json:"scaledObject,omitempty"`
+
+	// Endpoint 是 spec.expose 发布出去的地址
+	// +optional
+	Endpoint *EndpointStatus `json:"endpoint,omitempty"`
+
+	// Download 是 coordinator 在集群下载队列里的状态（operator 配置了 distribution.maxConcurrentDownloads 才有），
+	// 不需要从上游下载时为空
+	// +optional
+	Download *DownloadStatus `json:"download,omitempty"`
+
+	// Images 是 spec.imagePinning 固定下来的镜像 digest
+	// +optional
+	Images []PinnedImage `json:"images,omitempty"`
+}
+
+// PinnedImage 是一个镜像固定的 digest 和最近一次检查的结果
+type PinnedImage struct {
+	// Image 是配置的镜像（带 tag）
+	Image string `json:"image"`
+
+	// Digest 是 Deployment 正在使用的 digest
+	Digest string `json:"digest"`
+
+	// LatestDigest 是最近一次检查时 tag 指向的 digest，和 Digest 不同表示 tag 移动了
+	// +optional
+	LatestDigest string `json:"latestDigest,omitempty"`
+
+	// LastCheckTime 是最近一次查询镜像仓库的时间
+	LastCheckTime metav1.Time `json:"lastCheckTime"`
+}
+
+// 下载队列里的状态
+const (
+	// DownloadStateQueued 表示在排队，等其他 LLMService 下载完
+	DownloadStateQueued = "Queued"
+	// DownloadStateGranted 表示已经分到名额，agent 还没开始下载
+	DownloadStateGranted = "Granted"
+	// DownloadStateDownloading 表示正在从上游下载
+	DownloadStateDownloading = "Downloading"
+)
+
+// DownloadStatus 是 coordinator 在集群下载队列里的状态
+type DownloadStatus struct {
+	// State 是 Queued、Granted 或 Downloading
+	State string `json:"state"`
+
+	// Pod 是排队/下载的 coordinator Pod
+	Pod string `json:"pod"`
+
+	// Position 是在队列里的位置（从 1 开始），只在 Queued 时有
+	// +optional
+	Position int32 `json:"position,omitempty"`
+}
+
+// EndpointStatus 是对外发布的地址
+type EndpointStatus struct {
+	// Type 是生成的对象类型（和 spec.expose.type 一致），切换类型时据此删除旧对象
+	Type string `json:"type"`
+
+	// URL 是 OpenAI 兼容接口的根地址，例如 https://qwen.example.com/v1；
+	// LoadBalancer 还没分配地址时为空
+	// +optional
+	URL string `json:"url,omitempty"`
+}
+
+// SLOStatus 是最近一个窗口内观测到的服务质量
+type SLOStatus struct {
+	// P99LatencyMs 是窗口内的延迟 P99（毫秒），窗口内没有请求时为 0
+	// +optional
+	P99LatencyMs int64 `json:"p99LatencyMs,omitempty"`
+
+	// Availability 是窗口内非 5xx 响应的百分比，例如 "99.95"，窗口内没有请求时为空
+	// +optional
+	Availability string `json:"availability,omitempty"`
+
+	// Replicas 是因为违反 SLO 扩容后的副本数，0 表示没有扩容（使用 spec.replicas）
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// LastScaleTime 是最近一次因为 SLO 调整副本数的时间
+	// +optional
+	LastScaleTime *metav1.Time `json:"lastScaleTime,omitempty"`
+
+	// LastEvaluationTime 是最近一次评估的时间
+	LastEvaluationTime metav1.Time `json:"lastEvaluationTime"`
+}
+
+// FleetMemberStatus 是 LLMService 在一个成员集群里的副本状态
+type FleetMemberStatus struct {
+	// Cluster 是成员集群名称
+	Cluster string `json:"cluster"`
+
+	// Synced 表示成员集群里的 LLMService 已经和 hub 的 spec 一致
+	Synced bool `json:"synced"`
+
+	// AvailableReplicas 是成员集群里 ready 的副本数
+	// +optional
+	AvailableReplicas int32 `json:"availableReplicas,omitempty"`
+
+	// Message 是同步失败的原因
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ModelStatus 描述当前部署的模型
+type ModelStatus struct {
+	// Name 是模型仓库名，例如 "Qwen/Qwen2.5-7B-Instruct"
+	Name string `json:"name"`
+
+	// Runtime 是实际使用的推理引擎
+	Runtime string `json:"runtime"`
+
+	// EstimatedParameters 是估算的参数量，例如 "7B"，用于推算启动时间和显存
+	// +optional
+	EstimatedParameters string `json:"estimatedParameters,omitempty"`
+
+	// TensorParallelSize 是实际使用的 tensor parallel 大小（spec.vllm.tensorParallelSize 或按 GPU 数推算）
+	// +optional
+	TensorParallelSize int32 `json:"tensorParallelSize,omitempty"`
+}
+
+// ReconcilePlan 是 controller 将要对子资源做的变更，只计算不执行
+type ReconcilePlan struct {
+	// Changes 每一项是一条变更，例如 "scale Deployment qwen-deployment from 1 to 3"
+	// 为空表示没有需要执行的变更
+	// +optional
+	Changes []string `json:"changes,omitempty"`
+
+	// ObservedGeneration 是计划对应的 spec 版本
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// GeneratedAt 计划生成时间
+	GeneratedAt metav1.Time `json:"generatedAt"`
+}
+
+// CostReport 记录一个 LLMService 从 WindowStart 开始累计的 GPU 用量和费用
+//
+// 为什么用整数秒而不是 float？
+// - CRD 不推荐 float（不同语言序列化精度不一致）
+// - 累计值用 int64 秒存储，可读的小时数/费用用字符串展示
+type CostReport struct {
+	// GPUSeconds 累计 GPU 秒数 = ready 副本数 × 每副本 GPU 数 × 时长
+	GPUSeconds int64 `json:"gpuSeconds"`
+
+	// UtilizedGPUSeconds 按利用率折算的 GPU 秒数（利用率 100% 时等于 GPUSeconds）
+	UtilizedGPUSeconds int64 `json:"utilizedGPUSeconds"`
+
+	// GPUHours 是 GPUSeconds 换算成小时的可读值，例如 "12.50"
+	GPUHours string `json:"gpuHours"`
+
+	// AverageUtilizationPercent 按 GPU 时间加权的平均利用率（0-100）
+	AverageUtilizationPercent int32 `json:"averageUtilizationPercent"`
+
+	// EstimatedCost 按单价估算的费用，例如 "31.25"，是 EstimatedCostMicros 的可读值
+	EstimatedCost string `json:"estimatedCost"`
+
+	// EstimatedCostMicros 累计费用（百万分之一货币单位）
+	//
+	// 每段时间按当时的单价累加，单价热更新后只影响之后的费用，不会重算历史
+	// +optional
+	EstimatedCostMicros int64 `json:"estimatedCostMicros,omitempty"`
+
+	// Currency 费用单位，例如 "USD"
+	Currency string `json:"currency,omitempty"`
+
+	// WindowStart 开始统计的时间
+	WindowStart metav1.Time `json:"windowStart"`
+
+	// LastUpdateTime 上一次累计的时间，下一次累计从这里开始算
+	LastUpdateTime metav1.Time `json:"lastUpdateTime"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 
 // LLMService is the Schema for the llmservices API
 type LLMService struct {
@@ -89,6 +1288,61 @@ type LLMServiceList struct {
 	Items           []LLMService `json:"items"`
 }
 
+const (
+	// LastRequestTimeAnnotation 由 gateway 写入，记录最近一次请求的时间（RFC3339）
+	LastRequestTimeAnnotation = "kubeinfer.io/last-request-time"
+
+	// FleetSourceLabel 标记成员集群里由 fleet 复制出来的 LLMService，值是 hub 上的 LLMService 名称
+	// 只有带这个 label 的对象才会被 fleet 更新或删除
+	FleetSourceLabel = "kubeinfer.io/fleet-source"
+
+	// DryRunAnnotation 设为 "true" 时 controller 只计算变更计划（写到 Status.Plan 和 Event），
+	// 不修改任何子资源；去掉注解后才真正执行
+	DryRunAnnotation = "kubeinfer.io/dry-run"
+
+	// RestartedAtAnnotation 改成新的值（通常是当前时间）时滚动重启所有推理 Pod，coordinator 最后重启：
+	// kubectl annotate llmservice qwen kubeinfer.io/restartedAt="$(date -u +%FT%TZ)" --overwrite
+	RestartedAtAnnotation = "kubeinfer.io/restartedAt"
+)
+
+const (
+	// ConditionHibernated 为 True 表示因为空闲已经缩容，收到请求后会自动唤醒
+	ConditionHibernated = "Hibernated"
+
+	// ConditionPendingUpdate 为 True 表示有需要重启推理服务的变更，正在等维护窗口
+	ConditionPendingUpdate = "PendingUpdate"
+
+	// ConditionSLOViolated 为 True 表示最近一个窗口内没有达到 spec.slo，Message 是具体哪个目标
+	ConditionSLOViolated = "SLOViolated"
+
+	// ConditionAgentError 为 True 表示 agent 因为不可重试的错误退出（HF token 无效、磁盘满等），
+	// Reason 是 agent 终止消息里的错误类型（AuthFailed、DiskFull...）
+	ConditionAgentError = "AgentError"
+
+	// ConditionLicenseNotAccepted 为 True 表示模型是 gated 模型，许可协议还没有接受：
+	// spec.modelSource.acceptLicense 没有设置，或者 HF 账号还没同意协议/还在等作者审核。Message 里是要去哪里处理
+	ConditionLicenseNotAccepted = "LicenseNotAccepted"
+
+	// ConditionCUDAOutOfMemory 为 True 表示推理服务因为显存不足退出，Message 里有针对这个 LLMService 的处理建议
+	ConditionCUDAOutOfMemory = "CUDAOutOfMemory"
+
+	// ConditionDegraded 为 True 表示有副本（或 gateway 副本）没有 Ready，但还有副本在处理请求；
+	// 完全不可用时为 False，Reason 是 Unavailable——告警规则据此区分 warning 和 page
+	ConditionDegraded = "Degraded"
+
+	// ConditionImageTagMoved 为 True 表示 spec.imagePinning 固定的镜像 tag 已经指向新的 digest，
+	// 部署的还是旧的 digest（onTagMove=Notify），Message 里是哪些镜像
+	ConditionImageTagMoved = "ImageTagMoved"
+
+	// ConditionCoordinatorStable 为 True 表示当前 coordinator 已经连续持有 lease 超过 operator 配置的 lease.settlePeriod；
+	// 换了 coordinator、lease 没有持有者时为 False，settle 之前 controller 不按新角色调整 Pod
+	ConditionCoordinatorStable = "CoordinatorStable"
+
+	// ConditionCoordinatorPinned 为 True 表示 spec.coordination.pinnedPod 指定的 Pod 已经是 coordinator；
+	// False 时 Reason 说明是还在接管（Pending）还是 Pod 不满足条件、pin 没有生效
+	ConditionCoordinatorPinned = "CoordinatorPinned"
+)
+
 type LLMServiceCondition struct {
 	Type           string      `json:"type"`
 	Status         string      `json:"status"`
@@ -0,0 +1,105 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UsageReportSpec 标识报告统计的是哪个 LLMService 的哪一段时间
+//
+// UsageReport 由 gateway 创建和累加（每个 LLMService 每天一个，名称 <llmservice>-usage-<YYYYMMDD>），
+// 用户只读；删除 LLMService 时一起被回收
+type UsageReportSpec struct {
+	// LLMService 是被统计的 LLMService 名称
+	LLMService string `json:"llmService"`
+
+	// PeriodStart 和 PeriodEnd 是统计周期（UTC 自然日，左闭右开）
+	PeriodStart metav1.Time `json:"periodStart"`
+	PeriodEnd   metav1.Time `json:"periodEnd"`
+}
+
+// UsageReportStatus 是周期内累计的 token 用量
+type UsageReportStatus struct {
+	// Entries 按 API key 和模型分组，每个 gateway 副本定期把增量累加进来
+	// +optional
+	Entries []UsageEntry `json:"entries,omitempty"`
+
+	// PromptTokens 和 CompletionTokens 是所有 Entries 的合计
+	// +optional
+	PromptTokens int64 `json:"promptTokens,omitempty"`
+	// +optional
+	CompletionTokens int64 `json:"completionTokens,omitempty"`
+
+	// LastUpdateTime 是最近一次累加的时间
+	// +optional
+	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// UsageEntry 是一个 API key 对一个模型的用量
+type UsageEntry struct {
+	// Key 是 API key 的指纹（"sha256:" 加 SHA-256 的前 12 位十六进制），没有带 key 的请求是 "anonymous"
+	// 报告里不保存 key 原文，用同样的方法计算指纹就能对上
+	Key string `json:"key"`
+
+	// Model 是请求里的 model 字段
+	Model string `json:"model"`
+
+	// Requests 是计量到用量的请求数
+	Requests int64 `json:"requests"`
+
+	// PromptTokens 和 CompletionTokens 来自响应里的 usage
+	PromptTokens     int64 `json:"promptTokens"`
+	CompletionTokens int64 `json:"completionTokens"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="LLMService",type=string,JSONPath=`.spec.llmService`
+// +kubebuilder:printcolumn:name="Start",type=date,JSONPath=`.spec.periodStart`
+// +kubebuilder:printcolumn:name="Prompt",type=integer,JSONPath=`.status.promptTokens`
+// +kubebuilder:printcolumn:name="Completion",type=integer,JSONPath=`.status.completionTokens`
+
+// UsageReport 是一个 LLMService 在一个统计周期内按 API key 和模型汇总的 token 用量，用于内部计费
+type UsageReport struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// metadata is a standard object metadata
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitzero"`
+
+	// spec defines the reporting period of UsageReport
+	// +required
+	Spec UsageReportSpec `json:"spec"`
+
+	// status defines the accumulated usage of UsageReport
+	// +optional
+	Status UsageReportStatus `json:"status,omitzero"`
+}
+
+// +kubebuilder:object:root=true
+
+// UsageReportList contains a list of UsageReport
+type UsageReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitzero"`
+	Items           []UsageReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&UsageReport{}, &UsageReportList{})
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// V1FieldsAnnotation 保存 v1 才有的字段（完整的 v1 spec/status 的 JSON）。
+// 通过 v1beta1 读写对象时这些字段不可见，如果不保存下来，
+// 一次 v1beta1 的 Update 就会把 Gateway、Distribution、CostReport 等字段清空。
+const V1FieldsAnnotation = "kubeinfer.io/v1-fields"
+
+// v1Fields 是注解里保存的内容
+type v1Fields struct {
+	Spec   aiv1.LLMServiceSpec   `json:"spec"`
+	Status aiv1.LLMServiceStatus `json:"status"`
+}
+
+// ConvertTo converts this LLMService (v1beta1) to the Hub version (v1).
+func (src *LLMService) ConvertTo(dstRaw conversion.Hub) error {
+	dst, ok := dstRaw.(*aiv1.LLMService)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T", dstRaw)
+	}
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	// 先恢复 v1 独有的字段，再用 v1beta1 里的值覆盖共有字段：
+	// 用户通过 v1beta1 修改的字段以 v1beta1 为准
+	if raw, ok := dst.Annotations[V1FieldsAnnotation]; ok {
+		var saved v1Fields
+		if err := json.Unmarshal([]byte(raw), &saved); err != nil {
+			return fmt.Errorf("failed to decode %s annotation: %w", V1FieldsAnnotation, err)
+		}
+		dst.Spec = saved.Spec
+		dst.Status = saved.Status
+		delete(dst.Annotations, V1FieldsAnnotation)
+		if len(dst.Annotations) == 0 {
+			dst.Annotations = nil
+		}
+	}
+
+	dst.Spec.Model = src.Spec.Model
+	dst.Spec.Replicas = src.Spec.Replicas
+	dst.Spec.GpuPerReplica = src.Spec.GpuPerReplica
+	convertCacheStrategy(src.Spec.CacheStrategy, &dst.Spec)
+	dst.Spec.Image = src.Spec.Image
+	dst.Spec.GPUMemory = src.Spec.GPUMemory
+
+	dst.Status.AvailableReplicas = src.Status.AvailableReplicas
+	dst.Status.CacheCoordinator = src.Status.CacheCoordinator
+	dst.Status.Conditions = nil
+	for _, c := range src.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, aiv1.LLMServiceCondition(c))
+	}
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1) to this version (v1beta1).
+func (dst *LLMService) ConvertFrom(srcRaw conversion.Hub) error {
+	src, ok := srcRaw.(*aiv1.LLMService)
+	if !ok {
+		return fmt.Errorf("unexpected hub type %T", srcRaw)
+	}
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	dst.Spec = LLMServiceSpec{
+		Model:         src.Spec.Model,
+		Replicas:      src.Spec.Replicas,
+		GpuPerReplica: src.Spec.GpuPerReplica,
+		CacheStrategy: cacheStrategyOf(&src.Spec),
+		Image:         src.Spec.Image,
+		GPUMemory:     src.Spec.GPUMemory,
+	}
+
+	dst.Status = LLMServiceStatus{
+		AvailableReplicas: src.Status.AvailableReplicas,
+		CacheCoordinator:  src.Status.CacheCoordinator,
+	}
+	for _, c := range src.Status.Conditions {
+		dst.Status.Conditions = append(dst.Status.Conditions, LLMServiceCondition(c))
+	}
+
+	// 把完整的 v1 spec/status 存进注解，转回 v1 时不丢字段
+	raw, err := json.Marshal(v1Fields{Spec: src.Spec, Status: src.Status})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s annotation: %w", V1FieldsAnnotation, err)
+	}
+	if dst.Annotations == nil {
+		dst.Annotations = map[string]string{}
+	}
+	dst.Annotations[V1FieldsAnnotation] = string(raw)
+
+	return nil
+}
+
+// convertCacheStrategy 把 v1beta1 的 cacheStrategy 换成 v1 的 distribution.strategy，v1 里不再保留旧字段
+//
+// 只在 cacheStrategy 和注解里恢复的策略对不上时修改（用户通过 v1beta1 改了它）：
+// 改成 shared 是 coordinator 分发，改成 none 清掉策略（按副本数自动决定）
+func convertCacheStrategy(cacheStrategy string, dst *aiv1.LLMServiceSpec) {
+	dst.CacheStrategy = "" // nolint:staticcheck
+	strategy := ""
+	if dst.Distribution != nil {
+		strategy = dst.Distribution.Strategy
+	}
+	switch shared := cacheStrategy == aiv1.CacheStrategyShared; {
+	case shared && !sharedStrategy(strategy):
+		if dst.Distribution == nil {
+			dst.Distribution = &aiv1.DistributionSpec{}
+		}
+		dst.Distribution.Strategy = aiv1.StrategyCoordinator
+	case !shared && sharedStrategy(strategy):
+		dst.Distribution.Strategy = ""
+	}
+}
+
+// cacheStrategyOf 返回 v1 spec 在 v1beta1 里的 cacheStrategy
+func cacheStrategyOf(spec *aiv1.LLMServiceSpec) string {
+	if spec.Distribution != nil && spec.Distribution.Strategy != "" {
+		if sharedStrategy(spec.Distribution.Strategy) {
+			return aiv1.CacheStrategyShared
+		}
+		return aiv1.CacheStrategyNone
+	}
+	if spec.CacheStrategy == aiv1.CacheStrategyShared { // nolint:staticcheck
+		return aiv1.CacheStrategyShared
+	}
+	return aiv1.CacheStrategyNone
+}
+
+// sharedStrategy 判断 v1 的分发策略是不是副本之间共享模型（v1beta1 的 shared）
+func sharedStrategy(strategy string) bool {
+	return strategy == aiv1.StrategyCoordinator || strategy == aiv1.StrategyP2P || strategy == aiv1.StrategyNodeCache
+}
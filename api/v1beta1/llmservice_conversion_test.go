@@ -0,0 +1,149 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestConversionRoundTrip(t *testing.T) {
+	idle := metav1.Duration{Duration: 10 * time.Minute}
+	hub := &aiv1.LLMService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "qwen",
+			Namespace:   "default",
+			Annotations: map[string]string{"foo": "bar"},
+		},
+		Spec: aiv1.LLMServiceSpec{
+			Model:         "Qwen/Qwen2.5-0.5B",
+			Replicas:      3,
+			CacheStrategy: "shared",
+			IdleTimeout:   &idle,
+			Distribution:  &aiv1.DistributionSpec{Topology: aiv1.TopologyZone},
+		},
+		Status: aiv1.LLMServiceStatus{
+			AvailableReplicas: 2,
+			CoordinatorNode:   "node-a",
+			Conditions: []aiv1.LLMServiceCondition{
+				{Type: aiv1.ConditionHibernated, Status: "False"},
+			},
+		},
+	}
+
+	spoke := &LLMService{}
+	if err := spoke.ConvertFrom(hub); err != nil {
+		t.Fatalf("ConvertFrom: %v", err)
+	}
+	if spoke.Spec.Replicas != 3 || spoke.Spec.Model != hub.Spec.Model {
+		t.Fatalf("common spec fields not copied: %+v", spoke.Spec)
+	}
+	if _, ok := spoke.Annotations[V1FieldsAnnotation]; !ok {
+		t.Fatalf("expected %s annotation on v1beta1 object", V1FieldsAnnotation)
+	}
+
+	// 模拟 v1beta1 客户端修改了副本数
+	spoke.Spec.Replicas = 5
+
+	back := &aiv1.LLMService{}
+	if err := spoke.ConvertTo(back); err != nil {
+		t.Fatalf("ConvertTo: %v", err)
+	}
+	if back.Spec.Replicas != 5 {
+		t.Errorf("replicas = %d, want 5 (v1beta1 edit should win)", back.Spec.Replicas)
+	}
+	if back.Spec.IdleTimeout == nil || back.Spec.IdleTimeout.Duration != idle.Duration {
+		t.Errorf("idleTimeout lost in round trip: %v", back.Spec.IdleTimeout)
+	}
+	if back.Spec.Distribution == nil || back.Spec.Distribution.Topology != aiv1.TopologyZone {
+		t.Errorf("distribution lost in round trip: %v", back.Spec.Distribution)
+	}
+	if back.Status.CoordinatorNode != "node-a" {
+		t.Errorf("coordinatorNode = %q, want node-a", back.Status.CoordinatorNode)
+	}
+	if _, ok := back.Annotations[V1FieldsAnnotation]; ok {
+		t.Errorf("%s annotation should not leak into v1 object", V1FieldsAnnotation)
+	}
+	if back.Annotations["foo"] != "bar" {
+		t.Errorf("user annotations lost: %v", back.Annotations)
+	}
+}
+
+func TestConvertToWithoutAnnotation(t *testing.T) {
+	spoke := &LLMService{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy"},
+		Spec:       LLMServiceSpec{Model: "m", Replicas: 1, CacheStrategy: "none"},
+	}
+
+	hub := &aiv1.LLMService{}
+	if err := spoke.ConvertTo(hub); err != nil {
+		t.Fatalf("ConvertTo: %v", err)
+	}
+	if hub.Spec.Model != "m" || hub.Spec.Gateway != nil || hub.Spec.Distribution != nil {
+		t.Errorf("unexpected hub spec: %+v", hub.Spec)
+	}
+}
+
+func TestCacheStrategyConversion(t *testing.T) {
+	tests := []struct {
+		name          string
+		saved         string // 注解里恢复的 distribution.strategy
+		cacheStrategy string // v1beta1 里的值
+		want          string
+	}{
+		{name: "legacy shared", cacheStrategy: "shared", want: aiv1.StrategyCoordinator},
+		{name: "legacy none", cacheStrategy: "none", want: ""},
+		{name: "p2p kept", saved: aiv1.StrategyP2P, cacheStrategy: "shared", want: aiv1.StrategyP2P},
+		{name: "direct kept", saved: aiv1.StrategyDirect, cacheStrategy: "none", want: aiv1.StrategyDirect},
+		{name: "changed to none", saved: aiv1.StrategyNodeCache, cacheStrategy: "none", want: ""},
+		{name: "changed to shared", saved: aiv1.StrategyDirect, cacheStrategy: "shared", want: aiv1.StrategyCoordinator},
+	}
+	for _, tt := range tests {
+		hub := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Model: "m", Distribution: &aiv1.DistributionSpec{Strategy: tt.saved}}}
+		spoke := &LLMService{}
+		if err := spoke.ConvertFrom(hub); err != nil {
+			t.Fatalf("%s: ConvertFrom: %v", tt.name, err)
+		}
+		spoke.Spec.CacheStrategy = tt.cacheStrategy
+
+		back := &aiv1.LLMService{}
+		if err := spoke.ConvertTo(back); err != nil {
+			t.Fatalf("%s: ConvertTo: %v", tt.name, err)
+		}
+		if got := back.Spec.Distribution.Strategy; got != tt.want {
+			t.Errorf("%s: strategy = %q, want %q", tt.name, got, tt.want)
+		}
+		if back.Spec.CacheStrategy != "" { // nolint:staticcheck
+			t.Errorf("%s: cacheStrategy = %q, the legacy field must not be kept in v1", tt.name, back.Spec.CacheStrategy) // nolint:staticcheck
+		}
+	}
+
+	// v1 里的 p2p 在 v1beta1 里是 shared，direct 是 none
+	for strategy, want := range map[string]string{aiv1.StrategyP2P: "shared", aiv1.StrategyDirect: "none", "": "none"} {
+		spoke := &LLMService{}
+		hub := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Distribution: &aiv1.DistributionSpec{Strategy: strategy}}}
+		if err := spoke.ConvertFrom(hub); err != nil {
+			t.Fatal(err)
+		}
+		if spoke.Spec.CacheStrategy != want {
+			t.Errorf("strategy %q: cacheStrategy = %q, want %q", strategy, spoke.Spec.CacheStrategy, want)
+		}
+	}
+}
@@ -0,0 +1,604 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/admin"
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+	"github.com/Moore-Z/kubeinfer/internal/agent/coordinator"
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+	"github.com/Moore-Z/kubeinfer/internal/agent/follower"
+	"github.com/Moore-Z/kubeinfer/internal/agent/resolver"
+	"github.com/Moore-Z/kubeinfer/internal/agent/runtime"
+	"github.com/Moore-Z/kubeinfer/internal/agent/topology"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+// ============================================================================
+// kubeinfer agent：Deployment 主容器
+// ============================================================================
+//
+// 核心逻辑：
+// 1. 启动 LeaseManager，参与 coordinator 选举
+// 2. 如果抢到 Lease → 运行 Coordinator 逻辑
+// 3. 如果没抢到 → 运行 Follower 逻辑
+// 4. 如果角色变化（比如原 coordinator 挂了）→ 自动切换
+//
+// 这就是 "automatic failover" 的实现！
+// ============================================================================
+
+// newAgentCommand 创建 agent 子命令（不带子命令时也运行它）
+func newAgentCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "agent",
+		Short: "Elect a coordinator, distribute the model and run the inference server",
+		Args:  cobra.NoArgs,
+		RunE:  runAgentCommand,
+	}
+}
+
+// runAgentCommand 是 agent 子命令和根命令的入口
+func runAgentCommand(*cobra.Command, []string) error {
+	env := loadEnv()
+	if err := env.requireIdentity(); err != nil {
+		return err
+	}
+	clientset, err := bootstrap(env)
+	if err != nil {
+		return err
+	}
+	runAgent(env, clientset)
+	return nil
+}
+
+// runAgent 参与选举并运行当前角色，直到收到 SIGTERM
+func runAgent(env agentEnv, clientset *kubernetes.Clientset) {
+	namespace, modelPath, nodeName := env.Namespace, env.ModelPath, env.NodeName
+	topologyMode := os.Getenv("DISTRIBUTION_TOPOLOGY") // "zone" 时启用 zone 感知分发
+	drainTimeout := defaultDrainTimeout                // controller 根据 Spec.MaxGenerationTime 设置 DRAIN_TIMEOUT
+	if v := os.Getenv("DRAIN_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			drainTimeout = d
+		}
+	}
+
+	// ========================================
+	// Step 1: 创建 LeaseManager
+	// ========================================
+	// Lease 名称 = ConfigMap 名称 + "-lease"
+	// 例如：configMapName = "my-llm-cache" → leaseName = "my-llm-cache-lease"
+	// 这样每个 LLMService 有自己独立的选举
+	leaseName := env.leaseName()
+
+	lm, err := coordinator.NewLeaseManager(clientset, namespace, leaseName)
+	if err != nil {
+		log.Fatalf("❌ Failed to create LeaseManager: %v", err)
+	}
+	// 节点 label、磁盘和网卡决定接管顺序：合适的节点先抢（见 coordinator/candidacy.go）
+	preference := candidacy(clientset, nodeName, modelPath)
+	lm.WithPreference(preference)
+
+	// ========================================
+	// Step 2: 设置 Context 和信号处理
+	// ========================================
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	// 排空只做一次：preStop 的 /drain 和 SIGTERM 都会触发
+	var drainOnce sync.Once
+	drain := func() {
+		drainOnce.Do(func() {
+			rt, err := runtime.LoadFromEnv(distribution.LayoutFromEnv(modelPath).Current())
+			if err != nil {
+				log.Printf("⚠️  Cannot drain: %v", err)
+				return
+			}
+			runtime.Drain(context.Background(), rt, drainTimeout)
+		})
+	}
+
+	go func() {
+		sig := <-sigChan
+		log.Printf("📥 Received signal: %v, draining before shutdown...", sig)
+		// 先排空正在生成的请求，再停 vLLM（cancel 会停掉当前角色）
+		drain()
+		cancel()
+	}()
+
+	// 健康检查和 metrics（kubelet 探针、Prometheus 抓取），preStop 用的 /drain，以及排障用的 /debug/
+	debug := admin.NewServer(os.Getenv(admin.TokenEnv), env.PodName, distribution.LayoutFromEnv(modelPath)).
+		WithLease(clientset, namespace, leaseName)
+	go serveHealth(ctx, healthAddr, drain, debug)
+
+	// 只有一个副本、不运行 model server：不按角色切换
+	if !distribution.ModelServerEnabledFromEnv() {
+		runStandalone(ctx, lm, clientset, env)
+		releaseLease(lm)
+		log.Println("👋 Agent shut down gracefully")
+		return
+	}
+
+	// follower 查 coordinator 地址读本地缓存，coordinator 换人时马上知道
+	coord := newCoordinatorResolver(ctx, clientset, namespace, leaseName)
+
+	// ========================================
+	// Step 3: 运行选举循环
+	// ========================================
+	// LeaseManager.Run() 会：
+	// - 每 2 秒尝试获取或续约 Lease
+	// - 如果获得 Lease → 调用 onElected
+	// - 如果失去 Lease → 调用 onLost
+	//
+	// 注意：onElected 和 onLost 是回调函数，不能阻塞！
+	// 所以我们用 goroutine 来运行 coordinator/follower
+
+	// 用于控制当前运行的角色
+	var roleCancel context.CancelFunc
+	// coordinatorDone 在最近一次 coordinator 角色排空 model server、退出之后关闭
+	var coordinatorDone chan struct{}
+
+	// 停止当前角色
+	stopCurrentRole := func() {
+		if roleCancel != nil {
+			roleCancel()
+			roleCancel = nil
+		}
+	}
+
+	// 当选为 Coordinator 时的回调
+	onElected := func() {
+		log.Println("👑 Elected as Coordinator!")
+		stopCurrentRole()
+		admin.Agent.SetRole(cacheinfo.RoleCoordinator)
+
+		// 创建新的 context 用于 coordinator
+		roleCtx, cancel := context.WithCancel(ctx)
+		roleCancel = cancel
+		done := make(chan struct{})
+		coordinatorDone = done
+
+		// 在 goroutine 中运行（不能阻塞回调）
+		// 上一个 coordinator 还在排空时先从它补齐副本，准备好之后才打上 coordinator label，
+		// coordinator Service 才会指向这个 Pod（见 coordinator/handoff.go）
+		// 正在下载的 follower 数写到 Pod 注解上，controller 据此阻止驱逐
+		// 共享模型卷上副本本来就是同一份，不用从上一个 coordinator 拉
+		previous := ""
+		if prev := coord.Previous(env.PodName); prev.IP != "" && !distribution.SharedVolumeFromEnv() {
+			previous = follower.ModelServerURL(prev.IP)
+		}
+		transfers := coordinator.NewTransferTracker()
+		go reportTransfers(roleCtx, clientset, namespace, env.PodName, transfers)
+		go func() {
+			defer close(done)
+			runCoordinator(roleCtx, modelPath, transfers, downloadGate(clientset, env), previous, func() {
+				setRoleLabel(roleCtx, clientset, namespace, env.PodName, cacheinfo.RoleCoordinator)
+			})
+		}()
+	}
+
+	// 失去 Coordinator 身份（或者一开始就没抢到）时的回调
+	onLost := func() {
+		log.Println("📉 Not coordinator, becoming Follower...")
+		stopCurrentRole()
+		admin.Agent.SetRole(cacheinfo.RoleFollower)
+
+		roleCtx, cancel := context.WithCancel(ctx)
+		roleCancel = cancel
+
+		go func() {
+			// 以前是 coordinator 的话，把自己从 coordinator Service 里摘掉
+			setRoleLabel(roleCtx, clientset, namespace, env.PodName, cacheinfo.RoleFollower)
+			if topologyMode == "zone" {
+				runZoneFollower(roleCtx, clientset, coord, namespace, leaseName, modelPath, nodeName, preference)
+			} else {
+				runFollower(roleCtx, coord, modelPath, nil)
+			}
+		}()
+	}
+
+	// 启动选举循环（这个会阻塞直到 ctx 被取消）
+	log.Println("🗳️  Starting leader election...")
+	lm.Run(ctx, onElected, onLost)
+
+	// 清理
+	stopCurrentRole()
+
+	// 先摘掉 coordinator label 再让出 lease：coordinator Service 包含没 Ready 的 Pod，
+	// 不摘的话 Pod 删除之前 follower 还会连到这个正在退出的 Pod
+	labelCtx, labelCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	setRoleLabel(labelCtx, clientset, namespace, env.PodName, cacheinfo.RoleFollower)
+	labelCancel()
+
+	releaseLease(lm)
+
+	// 让出 lease 之后 model server 还在排空：正在下载的 follower 下完，新 coordinator 趁这段时间补齐副本
+	if coordinatorDone != nil {
+		<-coordinatorDone
+	}
+	log.Println("👋 Agent shut down gracefully")
+}
+
+// releaseLease 主动让出 lease，其他 pod 不用等 lease 过期就能接管
+func releaseLease(lm *coordinator.LeaseManager) {
+	releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer releaseCancel()
+	if err := lm.Release(releaseCtx); err != nil {
+		log.Printf("⚠️  Failed to release lease: %v", err)
+	}
+}
+
+// runStandalone 在不运行 model server 时（MODEL_SERVER_ENABLED=false）自己下载模型并启动推理服务，直到 ctx 取消
+//
+// 没有 follower 要供货，角色变化也不用重启推理服务。lease 仍然在后台参与选举：
+// controller 按它设置 status.cacheCoordinator、分配下载名额、做驱逐保护。
+// 下载名额只分给 lease 持有者：滚动更新时旧 Pod 还拿着 lease，新 Pod 不排队直接下载，
+// 否则旧 Pod 等新 Pod Ready 才退出，新 Pod 等旧 Pod 让出名额，两边互相等
+func runStandalone(ctx context.Context, lm *coordinator.LeaseManager, clientset *kubernetes.Clientset, env agentEnv) {
+	log.Println("🧍 Model server disabled, downloading and serving on our own")
+	admin.Agent.SetRole(roleStandalone)
+	gate := downloadGate(clientset, env)
+	acquired, err := lm.TryAcquireOrRenew(ctx)
+	if err != nil {
+		log.Printf("⚠️  Lease check failed: %v", err)
+	}
+	if !acquired && gate != nil {
+		log.Println("⚠️  Another pod holds the coordinator lease, downloading without a cluster download slot")
+		gate = nil
+	}
+
+	elected := make(chan struct{})
+	go func() {
+		defer close(elected)
+		lm.Run(ctx, func() { log.Println("👑 Holding the coordinator lease") }, nil)
+	}()
+	runCoordinator(ctx, env.ModelPath, nil, gate, "", nil)
+	<-elected
+}
+
+// roleStandalone 是不运行 model server 时 /debug/status 里的角色
+const roleStandalone = "standalone"
+
+// defaultDrainTimeout 是没有设置 DRAIN_TIMEOUT 时等待 in-flight 请求的上限
+const defaultDrainTimeout = 5 * time.Minute
+
+// healthAddr 是 agent 健康检查/metrics 的监听地址（和 admin.Port 一致）
+const healthAddr = ":8081"
+
+// serveHealth 提供：
+// - /healthz: agent 进程活着就返回 200
+// - /readyz: vLLM 已加载模型且 watchdog 没有判定卡死时返回 200
+// - /metrics: agent 的 Prometheus 指标（watchdog 等）
+// - /drain: preStop 调用，标记 NotReady 并等 in-flight 请求完成后返回
+// - /debug/: 需要 token 的 admin API（角色、lease、下载进度、清单、推理服务、最近的失败，见 agent/admin）
+func serveHealth(ctx context.Context, addr string, drain func(), debug *admin.Server) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "OK\n")
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if !runtime.Health.Ready() {
+			http.Error(w, "vLLM not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "OK\n")
+	})
+	mux.Handle("/metrics", promhttp.HandlerFor(ctrlmetrics.Registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/drain", func(w http.ResponseWriter, _ *http.Request) {
+		drain()
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "drained\n")
+	})
+	debug.Register(mux)
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	log.Printf("🩺 Health server listening on %s", addr)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("❌ Health server failed: %v", err)
+	}
+}
+
+// followerRetryInterval 是 follower 失败后重新查找 coordinator 的间隔
+const followerRetryInterval = 5 * time.Second
+
+// runFollower 以 follower 身份运行，失败后重新查找 coordinator 并重试
+//
+// 为什么要重试？
+// - coordinator 所在节点挂了，follower 下载到一半连接断开
+// - 新 coordinator 接管后 IP 变了，需要重新查询
+// 已经下载完的文件不会重复下载（见 follower.syncModel）
+//
+// 同步完成之前 coordinator 换人或者换了 IP（coord 的通知），不等连接超时，马上连新的；
+// 退避等待中收到通知也马上重试。同步完成之后推理服务已经在跑，coordinator 变化和它无关。
+// onTarget 在确定同步目录时调用（见 follower.WithOnTarget），可以为 nil
+func runFollower(ctx context.Context, coord *resolver.Resolver, modelPath string, onTarget func(dir, revision string)) {
+	changes, unsubscribe := coord.Subscribe()
+	defer unsubscribe()
+	// 重试时 Run 会再同步一次（本地文件都在，很快），peer server 只启动一个
+	var peerServer sync.Once
+	for attempt := 0; ctx.Err() == nil; attempt++ {
+		// 需要知道 coordinator 的地址：coordinator Service 的 DNS 名字，
+		// 或者从 Lease 的 HolderIdentity 获取 Pod 名称，然后查询 Pod IP
+		coordHost, err := getCoordinatorHost(ctx, coord)
+		if err != nil {
+			log.Printf("⚠️  Failed to get coordinator address: %v, will retry...", err)
+		} else {
+			runCtx, cancelRun := context.WithCancel(ctx)
+			var synced, changed atomic.Bool
+			f := follower.NewFollower(coordHost, modelPath).WithOnTarget(onTarget).WithOnSynced(func() {
+				synced.Store(true)
+				if distribution.PeerServingFromEnv() {
+					peerServer.Do(func() { go runPeerServer(ctx, coord, modelPath) })
+				}
+			}).WithOrphanCheck(func(owner string) bool { return coord.PodGone(ctx, owner) })
+			go restartOnCoordinatorChange(runCtx, coord, changes, &synced, func() {
+				changed.Store(true)
+				cancelRun()
+			})
+			err = f.Run(runCtx)
+			cancelRun()
+			if ctx.Err() != nil { // 被取消（角色切换或退出）
+				return
+			}
+			if changed.Load() {
+				attempt = -1 // 不是失败，不退避
+				continue
+			}
+			if err == nil {
+				return
+			}
+			handleRoleError("Follower", err, modelPath)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-changes:
+			log.Println("🔄 Coordinator changed, retrying now")
+		case <-time.After(retryDelay(attempt)):
+		}
+	}
+}
+
+// restartOnCoordinatorChange 在同步完成之前 coordinator 变成另一个有 IP 的 Pod（或者换了 IP）时调用 restart，
+// 直到 ctx 取消
+func restartOnCoordinatorChange(ctx context.Context, coord *resolver.Resolver, changes <-chan struct{}, synced *atomic.Bool, restart func()) {
+	start := coord.Current()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changes:
+		}
+		now := coord.Current()
+		if synced.Load() || now == start || now.IP == "" {
+			continue
+		}
+		log.Printf("🔄 Coordinator moved to %s (%s), reconnecting", now.Pod, now.IP)
+		restart()
+		return
+	}
+}
+
+// runPeerServer 在同步完成的 follower 上给其他副本提供只读副本（spec.distribution.peerServing），
+// 角色切换时随 ctx 停止，8080 端口让给 coordinator
+func runPeerServer(ctx context.Context, coord *resolver.Resolver, modelPath string) {
+	self := follower.PeerURL(os.Getenv(distribution.PodIPEnv))
+	if self == "" {
+		log.Printf("⚠️  %s is not set, not serving the model to peers", distribution.PodIPEnv)
+		return
+	}
+	coordinatorURL := func() (string, error) {
+		host, err := getCoordinatorHost(ctx, coord)
+		if err != nil {
+			return "", err
+		}
+		return follower.PeerURL(host), nil
+	}
+	if err := coordinator.ServePeer(ctx, modelPath, self, coordinatorURL); err != nil {
+		log.Printf("⚠️  Peer model server stopped: %v", err)
+	}
+}
+
+// maxRetryInterval 是连续失败时退避的上限
+const maxRetryInterval = 2 * time.Minute
+
+// retryDelay 指数退避：5s、10s、20s ... 最多 maxRetryInterval
+func retryDelay(attempt int) time.Duration {
+	d := followerRetryInterval
+	for i := 0; i < attempt && d < maxRetryInterval; i++ {
+		d *= 2
+	}
+	return min(d, maxRetryInterval)
+}
+
+// runCoordinator 以 coordinator 身份运行，可重试的错误退避后重新运行
+//
+// 以前 coordinator 出错只打一行日志，Pod 一直占着 lease 却什么都不干。
+// previous 是上一个 coordinator 的 model server（可以为空），onServing 在发布之前调用（可以为 nil），见 coordinator/handoff.go
+func runCoordinator(ctx context.Context, modelPath string, transfers *coordinator.TransferTracker, gate coordinator.DownloadGate, previous string, onServing func()) {
+	for attempt := 0; ctx.Err() == nil; attempt++ {
+		err := coordinator.NewCoordinator(modelPath).WithTransfers(transfers).WithDownloadGate(gate).
+			WithPreviousCoordinator(previous).WithOnServing(onServing).Run(ctx)
+		if err == nil || ctx.Err() != nil { // 正常退出或被取消（角色切换）
+			return
+		}
+		handleRoleError(coordinatorRole, err, modelPath)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryDelay(attempt)):
+		}
+	}
+}
+
+// terminationLogPath 是 kubelet 读取终止消息的默认路径
+const terminationLogPath = "/dev/termination-log"
+
+// podEvents 把 agent 自身的错误记到当前 Pod 上，main 里设置
+var podEvents runtime.EventSink
+
+// coordinatorRole 是 handleRoleError 里 coordinator 的角色名
+const coordinatorRole = "Coordinator"
+
+// handleRoleError 按错误类型处理 coordinator/follower 的失败
+//
+//   - ErrAuth、ErrDiskFull：重试没有意义，写终止消息后退出，
+//     Pod 进入 CrashLoopBackOff，controller 从终止消息设置 AgentError Condition
+//   - ErrModelCorrupt：删掉本地模型文件，调用方重试时重新下载。
+//     共享模型卷（MODEL_TRANSFER=sharedVolume）上只有 coordinator 能删：模型目录是所有副本共用的，
+//     follower 的推理服务报错不能把别的副本正在用的文件删掉，交给 coordinator 的校验处理
+//   - 其他（网络抖动、未分类）：调用方退避重试
+func handleRoleError(role string, err error, modelPath string) {
+	reason := agenterr.Reason(err)
+	if podEvents != nil {
+		podEvents.Event(runtime.EventTypeWarning, reason, fmt.Sprintf("%s failed: %v", role, err))
+	}
+
+	if !agenterr.Retryable(err) {
+		msg := agenterr.TerminationMessage(err)
+		if writeErr := os.WriteFile(terminationLogPath, []byte(msg), 0644); writeErr != nil {
+			log.Printf("⚠️  Failed to write termination message: %v", writeErr)
+		}
+		// 给 Event 一点时间发出去
+		time.Sleep(time.Second)
+		log.Fatalf("❌ %s error is not retryable (%s): %v", role, reason, err)
+	}
+
+	if agenterr.Kind(err) == agenterr.ErrModelCorrupt {
+		if role != coordinatorRole && distribution.SharedVolumeFromEnv() {
+			log.Printf("❌ %s found corrupt model files: %v, leaving the shared model volume to the coordinator", role, err)
+			return
+		}
+		log.Printf("🧹 %s found corrupt model files: %v, removing local copy", role, err)
+		if resetErr := coordinator.ResetModel(modelPath); resetErr != nil {
+			log.Printf("⚠️  Failed to remove model files: %v", resetErr)
+		}
+		return
+	}
+
+	log.Printf("❌ %s error (%s): %v, will retry...", role, reason, err)
+}
+
+// candidacyTimeout 是计算候选偏好（读节点、采样网卡）最多花的时间
+const candidacyTimeout = 5 * time.Second
+
+// candidacy 计算当 coordinator 的偏好值，zone seeder 的选举也用它
+func candidacy(clientset *kubernetes.Clientset, nodeName, modelPath string) float64 {
+	ctx, cancel := context.WithTimeout(context.Background(), candidacyTimeout)
+	defer cancel()
+	return coordinator.Candidacy(ctx, clientset, nodeName, modelPath)
+}
+
+// runZoneFollower 在 zone 拓扑下以 follower 身份运行
+//
+// 流程：
+//  1. 和 coordinator 在同一个 zone → 直接从 coordinator 拿（和 flat 一样）
+//  2. 否则参与本 zone 的 seeder 选举（独立的 Lease）
+//     - 当选 seeder：从 coordinator 跨 zone 拿一次，同时开 ModelServer 给本 zone 的 follower
+//     - 没当选：从本 zone 的 seeder 拿
+func runZoneFollower(ctx context.Context, clientset *kubernetes.Clientset, coord *resolver.Resolver, namespace, leaseName, modelPath, nodeName string, preference float64) {
+	myZone, err := topology.NodeZone(ctx, clientset, nodeName)
+	if err != nil || myZone == "" {
+		log.Printf("⚠️  Cannot determine zone (err: %v), falling back to flat topology", err)
+		runFollower(ctx, coord, modelPath, nil)
+		return
+	}
+
+	// 找到 coordinator 所在的 zone（coordinator 可能还没选出来，需要重试）
+	var coordZone string
+	for ctx.Err() == nil {
+		coordPod, err := coord.Holder(ctx)
+		if err == nil {
+			coordZone, err = topology.PodZone(ctx, clientset, namespace, coordPod)
+		}
+		if err == nil {
+			break
+		}
+		log.Printf("⚠️  Failed to get coordinator zone: %v, will retry...", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(followerRetryInterval):
+		}
+	}
+
+	if coordZone == myZone {
+		log.Printf("📍 Same zone as coordinator (%s), fetching from coordinator", myZone)
+		runFollower(ctx, coord, modelPath, nil)
+		return
+	}
+
+	zoneLeaseName := topology.ZoneLeaseName(leaseName, myZone)
+	zoneLM, err := coordinator.NewLeaseManager(clientset, namespace, zoneLeaseName)
+	if err != nil {
+		log.Printf("❌ Failed to create zone LeaseManager: %v, falling back to flat topology", err)
+		runFollower(ctx, coord, modelPath, nil)
+		return
+	}
+	zoneLM.WithPreference(preference)
+	// 本 zone 的 follower 从 seeder 拿：seeder 是 zone Lease 的持有者
+	zoneCoord := newCoordinatorResolver(ctx, clientset, namespace, zoneLeaseName)
+
+	var subCancel context.CancelFunc
+	stopSubRole := func() {
+		if subCancel != nil {
+			subCancel()
+			subCancel = nil
+		}
+	}
+
+	onSeeder := func() {
+		log.Printf("🌱 Elected as zone seeder for %s", myZone)
+		stopSubRole()
+		subCtx, cancel := context.WithCancel(ctx)
+		subCancel = cancel
+
+		go func() {
+			// 边下载边提供：已经 rename 完成的文件就可以给本 zone 的 follower
+			// （versioned 布局下提供正在同步的 revision 目录，而不是 current）
+			server := coordinator.NewModelServer(modelPath)
+			go func() {
+				if err := server.Start(subCtx); err != nil {
+					log.Printf("❌ Zone seeder model server failed: %v", err)
+				}
+			}()
+			runFollower(subCtx, coord, modelPath, server.SetDir)
+		}()
+	}
+
+	onZoneFollower := func() {
+		log.Printf("📍 Fetching from zone seeder in %s", myZone)
+		stopSubRole()
+		subCtx, cancel := context.WithCancel(ctx)
+		subCancel = cancel
+
+		go runFollower(subCtx, zoneCoord, modelPath, nil)
+	}
+
+	zoneLM.Run(ctx, onSeeder, onZoneFollower)
+	stopSubRole()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+)
+
+// TestHandleRoleErrorSharedVolume 测试共享模型卷上 follower 的 ErrModelCorrupt 不删模型目录
+func TestHandleRoleErrorSharedVolume(t *testing.T) {
+	corrupt := agenterr.Wrap(agenterr.ErrModelCorrupt, errors.New("SafetensorError: Error while deserializing header"))
+	tests := []struct {
+		name      string
+		role      string
+		transfer  string
+		wantReset bool
+	}{
+		{name: "follower on shared volume", role: "Follower", transfer: distribution.TransferSharedVolume, wantReset: false},
+		{name: "model fetch on shared volume", role: "Model fetch", transfer: distribution.TransferSharedVolume, wantReset: false},
+		{name: "coordinator on shared volume", role: coordinatorRole, transfer: distribution.TransferSharedVolume, wantReset: true},
+		{name: "follower with its own copy", role: "Follower", transfer: "", wantReset: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(distribution.TransferEnv, tt.transfer)
+			dir := t.TempDir()
+			weights := filepath.Join(dir, "model.safetensors")
+			if err := os.WriteFile(weights, []byte("weights"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			handleRoleError(tt.role, corrupt, dir)
+			_, err := os.Stat(weights)
+			if reset := os.IsNotExist(err); reset != tt.wantReset {
+				t.Errorf("model files removed = %v, want %v", reset, tt.wantReset)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+// TestDownloadGate 测试排队时读 -cache ConfigMap：拿到名额放行，一直读不到时报错而不是永远等下去
+func TestDownloadGate(t *testing.T) {
+	t.Setenv(cacheinfo.DownloadSchedulingEnv, "true")
+	old := downloadSlotPollInterval
+	downloadSlotPollInterval = time.Millisecond
+	defer func() { downloadSlotPollInterval = old }()
+
+	env := agentEnv{PodName: "qwen-a", Namespace: "default", ConfigMapName: "qwen-cache"}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "qwen-a", Namespace: "default"}}
+	data, err := cacheinfo.Encode(cacheinfo.CacheInfo{DownloadGrantedTo: "qwen-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "qwen-cache", Namespace: "default"}, Data: data}
+
+	t.Run("granted", func(t *testing.T) {
+		client := fake.NewSimpleClientset(pod.DeepCopy(), cm.DeepCopy())
+		release, err := downloadGate(client, env)(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := client.CoreV1().Pods("default").Get(context.Background(), "qwen-a", metav1.GetOptions{})
+		if state := got.Annotations[cacheinfo.DownloadStateAnnotation]; state != cacheinfo.DownloadActive {
+			t.Errorf("download state = %q, want %q", state, cacheinfo.DownloadActive)
+		}
+		release()
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		client := fake.NewSimpleClientset(pod.DeepCopy(), cm.DeepCopy())
+		gets := 0
+		client.PrependReactor("get", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+			gets++
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "qwen-cache", errors.New("no RBAC rule"))
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if _, err := downloadGate(client, env)(ctx); err == nil || !apierrors.IsForbidden(err) {
+			t.Fatalf("downloadGate() error = %v, want Forbidden", err)
+		}
+		if gets != maxDownloadSlotErrors {
+			t.Errorf("ConfigMap read %d times, want %d", gets, maxDownloadSlotErrors)
+		}
+		// 放弃时要让出排队位置
+		got, _ := client.CoreV1().Pods("default").Get(context.Background(), "qwen-a", metav1.GetOptions{})
+		if state, ok := got.Annotations[cacheinfo.DownloadStateAnnotation]; ok {
+			t.Errorf("download state = %q, want it removed", state)
+		}
+	})
+}
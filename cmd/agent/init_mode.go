@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/bandwidth"
+	"github.com/Moore-Z/kubeinfer/internal/agent/coordinator"
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+	"github.com/Moore-Z/kubeinfer/internal/agent/follower"
+	"github.com/Moore-Z/kubeinfer/internal/agent/resolver"
+	"github.com/Moore-Z/kubeinfer/internal/agent/startup"
+)
+
+// ============================================================================
+// initContainer 模式（spec.distribution.mode=initContainer）和单独运行的子命令
+// ============================================================================
+//
+// 主容器直接运行官方推理镜像，agent 拆成两个容器（见 internal/controller/init_mode.go）：
+//
+//   - serve-models（原生 sidecar）：参与 coordinator 选举，运行 model server，Pod 运行期间一直在
+//   - download（initContainer）：sidecar 是 coordinator 就下载模型，否则从 coordinator 同步，完成后退出
+//
+// 两个容器在同一个 Pod 里，POD_NAME 相同，所以 lease 的持有者就是"这个 Pod"。
+//
+// 调试、CI 和节点预热时不需要集群：
+//
+//	kubeinfer download --model Qwen/Qwen2.5-0.5B --dest /var/cache/models/qwen
+//	kubeinfer serve-models --standalone --model-path /tmp/models
+//	kubeinfer download --from http://127.0.0.1:8080 --model-path /tmp/replica
+// ============================================================================
+
+// 旧的命令名，controller 仍然用它们生成 Pod（兼容固定了旧版本 agent 镜像的用户）
+const (
+	fetchModelCommand = "fetch-model"
+	serveModelCommand = "serve-model"
+)
+
+// newDownloadCommand 创建 download 子命令
+func newDownloadCommand() *cobra.Command {
+	var from, model, dest, verify, publicKeyFile string
+	var keepRevisions int
+	cmd := &cobra.Command{
+		Use:     "download",
+		Aliases: []string{fetchModelCommand},
+		Short:   "Fetch the model into --model-path and exit",
+		Long: `Fetch the model into --model-path and exit.
+
+In a pod, the lease holder downloads from the upstream hub and every other pod
+syncs from it. The following modes need no Kubernetes API access:
+
+  --model <repo> --dest <dir>  download from HuggingFace (HF_ENDPOINT, HF_TOKEN)
+                               with the operator's downloader: interrupted runs
+                               resume, the result is verified and marked complete,
+                               so CI pipelines and node-prep DaemonSets can pre-seed
+                               caches the agent accepts as-is
+  --from <url>                 sync from a running model server`,
+		Args: cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			env := loadEnv()
+			if dest != "" {
+				env.ModelPath = dest
+			}
+			ctx, cancel := signalContext()
+			defer cancel()
+
+			switch {
+			case model != "":
+				return downloadStandalone(ctx, model, env.ModelPath, verify, publicKeyFile, keepRevisions)
+			case from != "":
+				log.Printf("📡 Syncing the model from %s into %s", from, env.ModelPath)
+				return follower.NewFollowerFromURL(from, env.ModelPath).Sync(ctx)
+			}
+			if err := env.requireIdentity(); err != nil {
+				return err
+			}
+			clientset, err := bootstrap(env)
+			if err != nil {
+				return err
+			}
+			return runModelFetch(ctx, clientset, env.Namespace, env.leaseName(), env.PodName, env.ModelPath,
+				downloadGate(clientset, env))
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "sync from this model server URL instead of the pod's coordinator")
+	cmd.Flags().StringVar(&model, "model", "", "download this HuggingFace repository without a coordinator election")
+	cmd.Flags().StringVar(&dest, "dest", "", "directory to download into (overrides --model-path)")
+	cmd.Flags().StringVar(&verify, "verify", "", "verification policy: size, sha256 or signature (default $"+distribution.PolicyEnv+" or size)")
+	cmd.Flags().StringVar(&publicKeyFile, "public-key-file", "", "PEM encoded ed25519 public key for --verify=signature")
+	cmd.Flags().IntVar(&keepRevisions, "keep-revisions", distribution.KeepRevisionsFromEnv(),
+		"revisions of --model kept in --dest, including the current one (older ones are archived, then deleted)")
+	cmd.MarkFlagsMutuallyExclusive("from", "model")
+	return cmd
+}
+
+// downloadStandalone 不参与选举，直接用 coordinator 的下载逻辑把 repo 下载到 modelPath
+//
+// 和 Pod 里的 coordinator 完全一样：状态文件断点续传、按策略生成清单并校验、写完整标记。
+// 目录之后挂给 LLMService 时 agent 看到完整标记就直接跳过下载。
+// 同一个目录换成别的模型时，旧模型按 keepRevisions 归档或删除。
+func downloadStandalone(ctx context.Context, repo, modelPath, policy, publicKeyFile string, keepRevisions int) error {
+	if policy == "" {
+		policy = os.Getenv(distribution.PolicyEnv)
+	}
+	publicKey := []byte(os.Getenv(distribution.PublicKeyEnv))
+	if publicKeyFile != "" {
+		data, err := os.ReadFile(publicKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read public key: %w", err)
+		}
+		publicKey = data
+	}
+	verifier, err := distribution.New(policy, publicKey)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("📦 Pre-seeding %s into %s (verify: %s)", repo, modelPath, verifier.Name())
+	if err := coordinator.NewCoordinator(modelPath).
+		WithModelRepo(repo).WithVerifier(verifier).WithKeepRevisions(keepRevisions).Fetch(ctx); err != nil {
+		return err
+	}
+	log.Println("✅ Model is ready")
+	return nil
+}
+
+// newServeModelsCommand 创建 serve-models 子命令
+func newServeModelsCommand() *cobra.Command {
+	var standalone bool
+	cmd := &cobra.Command{
+		Use:     "serve-models",
+		Aliases: []string{serveModelCommand},
+		Short:   "Serve the local model files to followers until SIGTERM",
+		Long: `Serve the local model files to followers until SIGTERM.
+
+In a pod it also takes part in the coordinator election. With --standalone it
+only serves --model-path, which is handy for testing followers locally.`,
+		Args: cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			env := loadEnv()
+			ctx, cancel := signalContext()
+			defer cancel()
+
+			if standalone {
+				log.Printf("🌐 Serving %s on :%d", env.ModelPath, follower.CoordinatorPort)
+				return startModelServer(ctx, env.ModelPath)
+			}
+			if err := env.requireIdentity(); err != nil {
+				return err
+			}
+			clientset, err := bootstrap(env)
+			if err != nil {
+				return err
+			}
+			return runModelServer(ctx, clientset, env.Namespace, env.leaseName(), env.ModelPath)
+		},
+	}
+	cmd.Flags().BoolVar(&standalone, "standalone", false, "serve without the coordinator election")
+	return cmd
+}
+
+// newElectCommand 创建 elect 子命令
+func newElectCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "elect",
+		Aliases: []string{startup.WaitCommand},
+		Short:   "Exit once this pod holds the coordinator lease or the coordinator is ready",
+		Args:    cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			env := loadEnv()
+			if err := env.requireIdentity(); err != nil {
+				return err
+			}
+			clientset, err := bootstrap(env)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := signalContext()
+			defer cancel()
+			return runStartupGate(ctx, clientset, env.Namespace, env.leaseName(), env.PodName)
+		},
+	}
+}
+
+// startModelServer 运行 model server，直到 ctx 取消
+func startModelServer(ctx context.Context, modelPath string) error {
+	server := coordinator.NewModelServer(modelPath)
+	// 上游下载在 download 进程里，没法和它分配带宽，这里只按总预算限速
+	server.SetBandwidth(bandwidth.FromEnv())
+	return server.Start(ctx)
+}
+
+// runModelServer 是 sidecar 的入口：选举 + 提供本地模型文件，直到收到 SIGTERM
+func runModelServer(ctx context.Context, clientset *kubernetes.Clientset, namespace, leaseName, modelPath string) error {
+	lm, err := coordinator.NewLeaseManager(clientset, namespace, leaseName)
+	if err != nil {
+		return fmt.Errorf("failed to create LeaseManager: %w", err)
+	}
+
+	go func() {
+		if err := startModelServer(ctx, modelPath); err != nil {
+			log.Printf("❌ Model server failed: %v", err)
+		}
+	}()
+
+	// 角色由 download 读 lease 决定，这里只记录日志
+	lm.Run(ctx,
+		func() { log.Println("👑 Elected as Coordinator, serving the model to followers") },
+		func() { log.Println("📉 Not coordinator, download syncs from the coordinator") },
+	)
+
+	releaseCtx, releaseCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer releaseCancel()
+	if err := lm.Release(releaseCtx); err != nil {
+		log.Printf("⚠️  Failed to release lease: %v", err)
+	}
+	log.Println("👋 Model server shut down")
+	return nil
+}
+
+// runModelFetch 是 initContainer 的入口：拉取完整的模型后返回
+//
+// 失败处理和主容器一样（handleRoleError）：不可重试的错误写终止消息退出，其他错误退避重试。
+// coordinator 中途换人时重新读 lease，已经下载完的文件不会重复下载。
+func runModelFetch(ctx context.Context, clientset *kubernetes.Clientset, namespace, leaseName, podName, modelPath string,
+	gate coordinator.DownloadGate) error {
+	coord := newCoordinatorResolver(ctx, clientset, namespace, leaseName)
+	for attempt := 0; ; attempt++ {
+		err := fetchModel(ctx, coord, podName, modelPath, gate)
+		if err == nil {
+			log.Println("✅ Model is ready, starting the inference container")
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("model fetch interrupted: %w", ctx.Err())
+		}
+		handleRoleError("Model fetch", err, modelPath)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("model fetch interrupted: %w", ctx.Err())
+		case <-time.After(retryDelay(attempt)):
+		}
+	}
+}
+
+// fetchModel 拉取一次模型：本 Pod 是 coordinator 就下载，否则从 coordinator 同步
+func fetchModel(ctx context.Context, coord *resolver.Resolver, podName, modelPath string, gate coordinator.DownloadGate) error {
+	// initContainer 重新运行（例如 Pod sandbox 重建）时本地已经有完整副本
+	if coordinator.ModelComplete(modelPath) {
+		return nil
+	}
+
+	// 不运行 model server 时没有 sidecar 持有 lease，直接下载（也就不排集群下载队列）
+	if !distribution.ModelServerEnabledFromEnv() {
+		log.Println("📥 Model server disabled, downloading the model")
+		return coordinator.NewCoordinator(modelPath).Fetch(ctx)
+	}
+
+	// sidecar 还没抢到/没看到 lease 时返回错误，退避后再看
+	holder, err := coord.Holder(ctx)
+	if err != nil {
+		return err
+	}
+	if holder == podName {
+		log.Println("👑 This pod is the coordinator, downloading the model")
+		return coordinator.NewCoordinator(modelPath).WithDownloadGate(gate).Fetch(ctx)
+	}
+
+	coordIP, err := coord.PodIP(ctx, holder)
+	if err != nil {
+		return err
+	}
+	log.Printf("📡 Syncing the model from coordinator %s (%s)", holder, coordIP)
+	return follower.NewFollower(coordIP, modelPath).Sync(ctx)
+}
+
+// runStartupGate 是 elect 的入口：抢到 lease 或者 coordinator 就绪后返回
+func runStartupGate(ctx context.Context, clientset *kubernetes.Clientset, namespace, leaseName, podName string) error {
+	lm, err := coordinator.NewLeaseManager(clientset, namespace, leaseName)
+	if err != nil {
+		return fmt.Errorf("failed to create LeaseManager: %w", err)
+	}
+
+	coord := newCoordinatorResolver(ctx, clientset, namespace, leaseName)
+	gate := &startup.Gate{
+		Identity:   podName,
+		TryAcquire: lm.TryAcquireOrRenew,
+		CoordinatorURL: func(ctx context.Context) (string, error) {
+			host, err := getCoordinatorHost(ctx, coord)
+			if err != nil {
+				return "", err
+			}
+			return follower.ModelServerURL(host), nil
+		},
+	}
+	gate.LoadFromEnv()
+	if _, err := gate.Wait(ctx); err != nil {
+		return fmt.Errorf("startup gate interrupted: %w", err)
+	}
+	return nil
+}
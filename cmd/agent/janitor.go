@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+)
+
+// newJanitorCommand 创建 janitor 子命令：清理下载断点 PVC 上过期的槽位（spec.storage.checkpoint）
+//
+// controller 按 spec.storage.checkpoint.ttl 创建 CronJob 运行它（见 internal/controller/checkpoint.go），
+// 不需要 Kubernetes API：只看槽位里文件的修改时间
+func newJanitorCommand() *cobra.Command {
+	var dir string
+	var ttl time.Duration
+	cmd := &cobra.Command{
+		Use:   "janitor",
+		Short: "Remove download checkpoints that have not changed for --ttl and exit",
+		Args:  cobra.NoArgs,
+		RunE: func(*cobra.Command, []string) error {
+			if dir == "" {
+				dir = distribution.CheckpointDirFromEnv()
+			}
+			if dir == "" {
+				return fmt.Errorf("--dir or %s is required", distribution.CheckpointDirEnv)
+			}
+			if ttl <= 0 {
+				return fmt.Errorf("--ttl must be positive, got %s", ttl)
+			}
+			removed, err := distribution.PruneCheckpoints(dir, ttl, time.Now())
+			for _, name := range removed {
+				log.Printf("🧹 Removed download checkpoint %s (unchanged for %s)", name, ttl)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to prune download checkpoints: %w", err)
+			}
+			log.Printf("✅ %d stale download checkpoints removed", len(removed))
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&dir, "dir", "", "mount path of the checkpoint volume (default $MODEL_CHECKPOINT_DIR)")
+	cmd.Flags().DurationVar(&ttl, "ttl", 24*time.Hour, "remove checkpoints that have not changed for this long")
+	return cmd
+}
@@ -2,200 +2,378 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 
+	"github.com/Moore-Z/kubeinfer/internal/agent/admin"
 	"github.com/Moore-Z/kubeinfer/internal/agent/coordinator"
-	"github.com/Moore-Z/kubeinfer/internal/agent/follower"
+	"github.com/Moore-Z/kubeinfer/internal/agent/resolver"
+	"github.com/Moore-Z/kubeinfer/internal/agent/runtime"
+	"github.com/Moore-Z/kubeinfer/internal/kubeclient"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
 )
 
 // ============================================================================
-// Agent 主程序
+// kubeinfer：agent 镜像里的唯一二进制（/agent）
 // ============================================================================
 //
-// 核心逻辑：
-// 1. 启动 LeaseManager，参与 coordinator 选举
-// 2. 如果抢到 Lease → 运行 Coordinator 逻辑
-// 3. 如果没抢到 → 运行 Follower 逻辑
-// 4. 如果角色变化（比如原 coordinator 挂了）→ 自动切换
+// 子命令：
 //
-// 这就是 "automatic failover" 的实现！
+//	agent         选举 + coordinator/follower + 推理服务，Deployment 主容器（不带子命令时的默认行为）
+//	download      拉取模型后退出，initContainer 模式的 model-fetch 容器
+//	serve-models  选举 + model server，initContainer 模式的 model-server sidecar
+//	elect         等待 coordinator 就绪后退出，startup gate initContainer
+//	janitor       清理下载断点 PVC 上过期的槽位后退出，spec.storage.checkpoint 的 CronJob
+//
+// 所有子命令共用环境变量读取、Kubernetes 客户端、Pod Event 和信号处理。
+// 旧的命令名（fetch-model、serve-model、wait-coordinator）作为别名保留，
+// 用户固定了旧版本 agent 镜像时 controller 生成的 Pod 仍然能启动。
 // ============================================================================
 
 func main() {
-	log.Println("🚀 KubeInfer Agent starting...")
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// newRootCommand 创建 kubeinfer 根命令
+func newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "kubeinfer",
+		Short: "KubeInfer agent: coordinator election, model distribution and inference server supervision",
+		// 镜像的 ENTRYPOINT 是 /agent，不带参数时运行 agent
+		Args:         cobra.NoArgs,
+		RunE:         runAgentCommand,
+		SilenceUsage: true,
+		PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+			log.Printf("🚀 KubeInfer %s starting...", cmd.Name())
+		},
+	}
+	root.PersistentFlags().StringVar(&modelPathFlag, "model-path", "", "directory holding the model files (default $MODEL_PATH or /models)")
+	envLimit := kubeclient.RateLimitFromEnv()
+	root.PersistentFlags().Float32Var(&kubeAPIFlags.QPS, "kube-api-qps", envLimit.QPS,
+		"QPS of the API server client (default $KUBE_API_QPS, 0 keeps the client-go default of 5)")
+	root.PersistentFlags().IntVar(&kubeAPIFlags.Burst, "kube-api-burst", envLimit.Burst,
+		"burst of the API server client (default $KUBE_API_BURST, 0 keeps the client-go default of 10)")
+	root.AddCommand(
+		newAgentCommand(),
+		newDownloadCommand(),
+		newServeModelsCommand(),
+		newElectCommand(),
+		newJanitorCommand(),
+	)
+	return root
+}
+
+// modelPathFlag 是 --model-path，优先级高于 MODEL_PATH
+var modelPathFlag string
 
-	// ========================================
-	// Step 1: 读取环境变量
-	// ========================================
-	podName := os.Getenv("POD_NAME")
-	namespace := os.Getenv("POD_NAMESPACE")
-	configMapName := os.Getenv("CONFIGMAP_NAME") // 例如 "my-llm-cache"
-	modelPath := os.Getenv("MODEL_PATH")
+// kubeAPIFlags 是 --kube-api-qps / --kube-api-burst，默认值来自 KUBE_API_QPS / KUBE_API_BURST
+var kubeAPIFlags kubeclient.RateLimit
 
-	if podName == "" || namespace == "" || configMapName == "" {
-		log.Fatalf("❌ Missing required env: POD_NAME, POD_NAMESPACE, CONFIGMAP_NAME")
+// agentEnv 是 controller 通过环境变量注入的 Pod 信息
+type agentEnv struct {
+	PodName       string
+	Namespace     string
+	ConfigMapName string // 例如 "my-llm-cache"
+	ModelPath     string
+	NodeName      string
+}
+
+// loadEnv 读取环境变量
+func loadEnv() agentEnv {
+	env := agentEnv{
+		PodName:       os.Getenv("POD_NAME"),
+		Namespace:     os.Getenv("POD_NAMESPACE"),
+		ConfigMapName: os.Getenv("CONFIGMAP_NAME"),
+		ModelPath:     os.Getenv("MODEL_PATH"),
+		NodeName:      os.Getenv("NODE_NAME"),
+	}
+	if modelPathFlag != "" {
+		env.ModelPath = modelPathFlag
 	}
-	if modelPath == "" {
-		modelPath = "/models"
+	if env.ModelPath == "" {
+		env.ModelPath = "/models"
 	}
+	return env
+}
+
+// requireIdentity 检查参与选举需要的环境变量
+func (e agentEnv) requireIdentity() error {
+	if e.PodName == "" || e.Namespace == "" || e.ConfigMapName == "" {
+		return fmt.Errorf("missing required env: POD_NAME, POD_NAMESPACE, CONFIGMAP_NAME")
+	}
+	return nil
+}
+
+// leaseName 返回 coordinator 选举用的 Lease 名称：ConfigMap 名称 + "-lease"
+func (e agentEnv) leaseName() string {
+	return e.ConfigMapName + "-lease"
+}
 
-	log.Printf("📋 Pod: %s, Namespace: %s", podName, namespace)
+// bootstrap 创建 Kubernetes 客户端，并把 Pod Event 指向当前 Pod
+func bootstrap(env agentEnv) (*kubernetes.Clientset, error) {
+	log.Printf("📋 Pod: %s, Namespace: %s", env.PodName, env.Namespace)
 
-	// ========================================
-	// Step 2: 创建 Kubernetes 客户端
-	// ========================================
 	// rest.InClusterConfig() 在 Pod 内自动获取认证信息
 	config, err := rest.InClusterConfig()
 	if err != nil {
-		log.Fatalf("❌ Failed to get in-cluster config: %v", err)
+		return nil, fmt.Errorf("failed to get in-cluster config: %w", err)
 	}
-
+	// 客户端限流：等待时间和 API server 的 429 记到 /metrics
+	if err := kubeAPIFlags.Validate(); err != nil {
+		return nil, err
+	}
+	kubeAPIFlags.Apply(config)
+	kubeclient.Instrument("agent")
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		log.Fatalf("❌ Failed to create clientset: %v", err)
+		return nil, fmt.Errorf("failed to create clientset: %w", err)
 	}
 
-	// ========================================
-	// Step 3: 创建 LeaseManager
-	// ========================================
-	// Lease 名称 = ConfigMap 名称 + "-lease"
-	// 例如：configMapName = "my-llm-cache" → leaseName = "my-llm-cache-lease"
-	// 这样每个 LLMService 有自己独立的选举
-	leaseName := configMapName + "-lease"
+	// vLLM 日志里的 OOM/崩溃/加载完成 → Pod 上的 Kubernetes Event
+	// 下载/启动失败也记到同一个 Pod 上（见 handleRoleError）
+	podEvents = newPodEventSink(clientset, env.Namespace, env.PodName, env.NodeName)
+	runtime.SetEventSink(podEvents)
+	return clientset, nil
+}
 
-	lm, err := coordinator.NewLeaseManager(clientset, namespace, leaseName)
-	if err != nil {
-		log.Fatalf("❌ Failed to create LeaseManager: %v", err)
+// signalContext 返回收到 SIGINT/SIGTERM 时取消的 context
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
+
+// podEventSink 把 vLLM 日志事件记录到当前 Pod 上
+type podEventSink struct {
+	recorder record.EventRecorder
+	ref      *corev1.ObjectReference
+}
+
+// Event 同时把 Warning 记到 admin API 的最近失败里（/debug/errors）
+func (s *podEventSink) Event(eventType, reason, message string) {
+	s.recorder.Event(s.ref, eventType, reason, message)
+	if eventType == corev1.EventTypeWarning {
+		admin.Agent.RecordError(reason, message)
 	}
+}
 
-	// ========================================
-	// Step 4: 设置 Context 和信号处理
-	// ========================================
-	ctx, cancel := context.WithCancel(context.Background())
+// newPodEventSink 创建指向当前 Pod 的 EventSink
+//
+// 需要 Pod 的 UID，kubectl describe pod 按 UID 关联 Event；
+// 读不到 Pod 时不带 UID，Event 仍然能用 kubectl get events 看到
+func newPodEventSink(clientset *kubernetes.Clientset, namespace, podName, nodeName string) *podEventSink {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: clientset.CoreV1().Events(namespace)})
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "kubeinfer-agent", Host: nodeName})
+
+	ref := &corev1.ObjectReference{Kind: "Pod", APIVersion: "v1", Namespace: namespace, Name: podName}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		sig := <-sigChan
-		log.Printf("📥 Received signal: %v, shutting down...", sig)
-		cancel()
-	}()
-
-	// ========================================
-	// Step 5: 运行选举循环
-	// ========================================
-	// LeaseManager.Run() 会：
-	// - 每 2 秒尝试获取或续约 Lease
-	// - 如果获得 Lease → 调用 onElected
-	// - 如果失去 Lease → 调用 onLost
-	//
-	// 注意：onElected 和 onLost 是回调函数，不能阻塞！
-	// 所以我们用 goroutine 来运行 coordinator/follower
-
-	// 用于控制当前运行的角色
-	var roleCancel context.CancelFunc
-
-	// 停止当前角色
-	stopCurrentRole := func() {
-		if roleCancel != nil {
-			roleCancel()
-			roleCancel = nil
-		}
+	if pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{}); err == nil {
+		ref.UID = pod.UID
+	} else {
+		log.Printf("⚠️  Failed to get own pod for events: %v", err)
 	}
 
-	// 当选为 Coordinator 时的回调
-	onElected := func() {
-		log.Println("👑 Elected as Coordinator!")
-		stopCurrentRole()
+	return &podEventSink{recorder: recorder, ref: ref}
+}
 
-		// 创建新的 context 用于 coordinator
-		roleCtx, cancel := context.WithCancel(ctx)
-		roleCancel = cancel
+// newCoordinatorResolver 创建并启动 leaseName 的 coordinator 缓存（见 agent/resolver），ctx 取消时停止
+//
+// Pod 只 watch 同一个 LLMService 的（controller 设置的 LLMSERVICE_NAME）
+func newCoordinatorResolver(ctx context.Context, clientset kubernetes.Interface, namespace, leaseName string) *resolver.Resolver {
+	r := resolver.New(clientset, namespace, leaseName, os.Getenv("LLMSERVICE_NAME"))
+	r.Start(ctx)
+	return r
+}
 
-		// 在 goroutine 中运行（不能阻塞回调）
-		go func() {
-			coord := coordinator.NewCoordinator(modelPath)
-			if err := coord.Run(roleCtx); err != nil {
-				if roleCtx.Err() == nil { // 不是被取消的
-					log.Printf("❌ Coordinator error: %v", err)
-				}
-			}
-		}()
+// getCoordinatorHost 返回访问 coordinator 用的主机名
+//
+// controller 设置了 COORDINATOR_SERVICE 时用 Service 的 DNS 名字：coordinator 换人后地址不变，
+// 网络策略和 TLS 证书只需要认这一个名字。仍然先确认 Lease 有持有者，
+// 还没选出 coordinator 时 Service 没有 endpoint，连过去只会超时。
+// 没有设置（旧 controller、initContainer 模式）时查 coordinator 的 Pod IP
+func getCoordinatorHost(ctx context.Context, coord *resolver.Resolver) (string, error) {
+	service := os.Getenv(cacheinfo.CoordinatorServiceEnv)
+	if service == "" {
+		return coord.CoordinatorIP(ctx)
 	}
+	if _, err := coord.Holder(ctx); err != nil {
+		return "", err
+	}
+	return service, nil
+}
 
-	// 失去 Coordinator 身份时的回调
-	onLost := func() {
-		log.Println("📉 Lost coordinator role, becoming Follower...")
-		stopCurrentRole()
-
-		// 需要知道新 coordinator 的 IP
-		// 从 Lease 的 HolderIdentity 获取 Pod 名称，然后查询 Pod IP
-		coordIP, err := getCoordinatorIP(clientset, namespace, leaseName)
-		if err != nil {
-			log.Printf("⚠️  Failed to get coordinator IP: %v, will retry...", err)
-			return
-		}
+// setRoleLabel 把当前 Pod 的 kubeinfer.io/role 改成 role，coordinator Service 按它选择 Pod
+//
+// 失败只打日志：follower 仍然可以在 controller 没有设置 COORDINATOR_SERVICE 时按 Pod IP 访问，
+// 下一次角色切换会再改一次
+func setRoleLabel(ctx context.Context, clientset *kubernetes.Clientset, namespace, podName, role string) {
+	if ctx.Err() != nil { // 角色已经又切换了，由新角色的 goroutine 去改
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	patch := fmt.Sprintf(`{"metadata":{"labels":{%q:%q}}}`, cacheinfo.RoleLabel, role)
+	_, err := clientset.CoreV1().Pods(namespace).Patch(ctx, podName, types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	if err != nil {
+		log.Printf("⚠️  Failed to set %s=%s on pod %s: %v", cacheinfo.RoleLabel, role, podName, err)
+	}
+}
 
-		roleCtx, cancel := context.WithCancel(ctx)
-		roleCancel = cancel
+// transferReportInterval 是把正在下载的 follower 数写到 Pod 注解上的间隔
+const transferReportInterval = 10 * time.Second
 
-		go func() {
-			f := follower.NewFollower(coordIP, modelPath)
-			if err := f.Run(roleCtx); err != nil {
-				if roleCtx.Err() == nil {
-					log.Printf("❌ Follower error: %v", err)
+// reportTransfers 定期把正在下载的 follower 数写到当前 Pod 的注解上，直到 ctx 取消
+//
+// 只在变化时 patch；第一次总是写，清掉上一次当选时留下的值。
+// 失去 coordinator 身份后不再更新，controller 只看 Status.CacheCoordinator 那个 Pod
+func reportTransfers(ctx context.Context, clientset *kubernetes.Clientset, namespace, podName string, transfers *coordinator.TransferTracker) {
+	ticker := time.NewTicker(transferReportInterval)
+	defer ticker.Stop()
+	reported := -1
+	for {
+		followers, since := transfers.Active()
+		if followers != reported {
+			if err := patchTransfers(ctx, clientset, namespace, podName, followers, since); err != nil {
+				if ctx.Err() == nil {
+					log.Printf("⚠️  Failed to report active transfers: %v", err)
 				}
+			} else {
+				reported = followers
 			}
-		}()
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 	}
-
-	// 启动选举循环（这个会阻塞直到 ctx 被取消）
-	log.Println("🗳️  Starting leader election...")
-	lm.Run(ctx, onElected, onLost)
-
-	// 清理
-	stopCurrentRole()
-	log.Println("👋 Agent shut down gracefully")
 }
 
-// getCoordinatorIP 获取当前 Coordinator 的 IP
-//
-// 流程：
-// 1. 读取 Lease，获取 HolderIdentity（Pod 名称）
-// 2. 查询该 Pod，获取 PodIP
-func getCoordinatorIP(clientset *kubernetes.Clientset, namespace, leaseName string) (string, error) {
-	ctx := context.Background()
-
-	// 读取 Lease
-	lease, err := clientset.CoordinationV1().Leases(namespace).Get(ctx, leaseName, metav1.GetOptions{})
+// patchTransfers 更新 Pod 上的传输注解，follower 数为 0 时删除注解
+func patchTransfers(ctx context.Context, clientset *kubernetes.Clientset, namespace, podName string, followers int, since time.Time) error {
+	annotations := map[string]any{
+		cacheinfo.ActiveTransfersAnnotation: nil,
+		cacheinfo.TransfersSinceAnnotation:  nil,
+	}
+	if followers > 0 {
+		annotations[cacheinfo.ActiveTransfersAnnotation] = strconv.Itoa(followers)
+		annotations[cacheinfo.TransfersSinceAnnotation] = since.UTC().Format(time.RFC3339)
+	}
+	patch, err := json.Marshal(map[string]any{"metadata": map[string]any{"annotations": annotations}})
 	if err != nil {
-		return "", fmt.Errorf("failed to get lease: %w", err)
+		return err
 	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	_, err = clientset.CoreV1().Pods(namespace).Patch(ctx, podName, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// downloadSlotPollInterval 是排队时检查 -cache ConfigMap 的间隔（变量方便测试时调小）
+var downloadSlotPollInterval = 10 * time.Second
+
+// maxDownloadSlotErrors 是排队时连续读不到（或解析不了）-cache ConfigMap 的最多次数，
+// 超过后放弃这一轮，交给角色的重试逻辑，而不是一直安静地等下去（例如 RBAC 缺少 configmaps get）
+const maxDownloadSlotErrors = 6
 
-	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
-		return "", fmt.Errorf("lease has no holder")
+// downloadGate 返回集群下载排队的逻辑，controller 没有设置 DOWNLOAD_SCHEDULING 时返回 nil（不排队）
+//
+// 在 Pod 上打 kubeinfer.io/download-state=waiting，等 controller 在 -cache ConfigMap 里把名额给自己，
+// 然后改成 downloading；下载结束（成功、失败、角色切换）后删掉注解，controller 把名额给下一个
+func downloadGate(clientset kubernetes.Interface, env agentEnv) coordinator.DownloadGate {
+	if os.Getenv(cacheinfo.DownloadSchedulingEnv) != "true" {
+		return nil
+	}
+	release := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := setDownloadState(ctx, clientset, env, ""); err != nil {
+			log.Printf("⚠️  Failed to release the download slot: %v", err)
+		}
 	}
+	return func(ctx context.Context) (func(), error) {
+		if err := setDownloadState(ctx, clientset, env, cacheinfo.DownloadWaiting); err != nil {
+			return nil, fmt.Errorf("failed to request a download slot: %w", err)
+		}
+		log.Println("⏳ Waiting for a cluster download slot...")
+		ticker := time.NewTicker(downloadSlotPollInterval)
+		defer ticker.Stop()
+		for failures := 0; ; {
+			granted, err := downloadSlotGranted(ctx, clientset, env)
+			if granted {
+				break
+			}
+			if err != nil && ctx.Err() == nil {
+				failures++
+				log.Printf("⚠️  Failed to check the download slot (%d/%d): %v", failures, maxDownloadSlotErrors, err)
+				if failures >= maxDownloadSlotErrors {
+					release()
+					return nil, fmt.Errorf("failed to check the download slot %d times in a row: %w", failures, err)
+				}
+			} else {
+				failures = 0
+			}
+			select {
+			case <-ctx.Done():
+				release()
+				return nil, ctx.Err()
+			case <-ticker.C:
+			}
+		}
 
-	coordPodName := *lease.Spec.HolderIdentity
+		if err := setDownloadState(ctx, clientset, env, cacheinfo.DownloadActive); err != nil {
+			log.Printf("⚠️  Failed to mark the download as started: %v", err)
+		}
+		log.Println("🎫 Got a download slot, starting the download")
+		return release, nil
+	}
+}
 
-	// 查询 Pod
-	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, coordPodName, metav1.GetOptions{})
+// downloadSlotGranted 读 -cache ConfigMap，判断 controller 是否把下载名额给了当前 Pod
+func downloadSlotGranted(ctx context.Context, clientset kubernetes.Interface, env agentEnv) (bool, error) {
+	cm, err := clientset.CoreV1().ConfigMaps(env.Namespace).Get(ctx, env.ConfigMapName, metav1.GetOptions{})
 	if err != nil {
-		return "", fmt.Errorf("failed to get coordinator pod: %w", err)
+		return false, fmt.Errorf("failed to get ConfigMap %s: %w", env.ConfigMapName, err)
 	}
-
-	if pod.Status.PodIP == "" {
-		return "", fmt.Errorf("coordinator pod has no IP")
+	info, err := cacheinfo.Parse(cm.Data)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse ConfigMap %s: %w", env.ConfigMapName, err)
 	}
+	return info.DownloadGrantedTo == env.PodName, nil
+}
 
-	return pod.Status.PodIP, nil
+// setDownloadState 更新当前 Pod 的下载状态注解，state 为空时删除
+func setDownloadState(ctx context.Context, clientset kubernetes.Interface, env agentEnv, state string) error {
+	var value any
+	if state != "" {
+		value = state
+	}
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{"annotations": map[string]any{cacheinfo.DownloadStateAnnotation: value}},
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	_, err = clientset.CoreV1().Pods(env.Namespace).Patch(ctx, env.PodName, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
 }
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/startup"
+)
+
+// TestLegacyCommands 测试 controller 生成的旧命令名仍然能找到对应的子命令
+func TestLegacyCommands(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{args: nil, want: "kubeinfer"},
+		{args: []string{startup.WaitCommand}, want: "elect"},
+		{args: []string{fetchModelCommand}, want: "download"},
+		{args: []string{serveModelCommand}, want: "serve-models"},
+		{args: []string{"serve-models", "--standalone"}, want: "serve-models"},
+	}
+	for _, tt := range tests {
+		cmd, _, err := newRootCommand().Find(tt.args)
+		if err != nil {
+			t.Errorf("Find(%v): %v", tt.args, err)
+			continue
+		}
+		if cmd.Name() != tt.want {
+			t.Errorf("Find(%v) = %s, want %s", tt.args, cmd.Name(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Moore-Z/kubeinfer/internal/benchmark"
+)
+
+// ============================================================================
+// BenchmarkRun 负载生成器
+// ============================================================================
+//
+// 由 BenchmarkRun controller 创建的 Job 运行（manager 镜像里的 /benchmark），
+// 参数通过环境变量传入（见 benchmark.ConfigFromEnv）。
+//
+// 结果以 JSON 写到 /dev/termination-log，controller 从 Pod 的终止消息里读取；
+// 失败时写错误信息并以非 0 退出，Job 进入 Failed。
+// ============================================================================
+
+const terminationLogPath = "/dev/termination-log"
+
+func main() {
+	log.Println("🚀 KubeInfer benchmark starting...")
+
+	cfg, err := benchmark.ConfigFromEnv()
+	if err != nil {
+		fail(err)
+	}
+	log.Printf("🎯 Target %s: concurrency=%d promptTokens=%d maxTokens=%d duration=%s",
+		cfg.Target, cfg.Concurrency, cfg.PromptTokens, cfg.MaxTokens, cfg.Duration)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	results, err := benchmark.Run(ctx, cfg)
+	if err != nil {
+		fail(err)
+	}
+	data, err := json.Marshal(results)
+	if err != nil {
+		fail(err)
+	}
+	log.Printf("✅ Benchmark finished: %s", data)
+	if err := os.WriteFile(terminationLogPath, data, 0o644); err != nil {
+		log.Printf("⚠️  Failed to write termination log: %v", err)
+	}
+}
+
+// fail 把错误写进终止消息后退出
+func fail(err error) {
+	log.Printf("❌ Benchmark failed: %v", err)
+	_ = os.WriteFile(terminationLogPath, []byte(err.Error()), 0o644)
+	os.Exit(1)
+}
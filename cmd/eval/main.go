@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Moore-Z/kubeinfer/internal/eval"
+)
+
+// ============================================================================
+// EvalRun 评测程序
+// ============================================================================
+//
+// 由 EvalRun controller 创建的 Job 运行（manager 镜像里的 /eval），
+// 参数通过环境变量传入，评测集是挂载的 ConfigMap（见 eval.ConfigFromEnv）。
+//
+// 分数以 JSON 写到 /dev/termination-log，controller 从 Pod 的终止消息里读取；
+// 失败时写错误信息并以非 0 退出，Job 进入 Failed。
+// ============================================================================
+
+const terminationLogPath = "/dev/termination-log"
+
+func main() {
+	log.Println("🚀 KubeInfer eval starting...")
+
+	cfg, err := eval.ConfigFromEnv()
+	if err != nil {
+		fail(err)
+	}
+	judge := "none"
+	if cfg.Judge != nil {
+		judge = cfg.Judge.Model + " at " + cfg.Judge.URL
+	}
+	log.Printf("🎯 Target %s: prompts=%d concurrency=%d maxTokens=%d judge=%s",
+		cfg.Target, len(cfg.Items), cfg.Concurrency, cfg.MaxTokens, judge)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	scores, err := eval.Run(ctx, cfg)
+	if err != nil {
+		fail(err)
+	}
+	data, err := json.Marshal(scores)
+	if err != nil {
+		fail(err)
+	}
+	log.Printf("✅ Eval finished: %s", data)
+	if err := os.WriteFile(terminationLogPath, data, 0o644); err != nil {
+		log.Printf("⚠️  Failed to write termination log: %v", err)
+	}
+}
+
+// fail 把错误写进终止消息后退出
+func fail(err error) {
+	log.Printf("❌ Eval failed: %v", err)
+	_ = os.WriteFile(terminationLogPath, []byte(err.Error()), 0o644)
+	os.Exit(1)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/gateway"
+)
+
+// ============================================================================
+// Gateway 主程序
+// ============================================================================
+//
+// 每个 LLMService 一个 gateway Deployment（由 controller 创建），
+// 通过环境变量知道自己服务哪个 LLMService：
+//   - LLMSERVICE_NAME: LLMService 名称
+//   - POD_NAMESPACE:   所在 namespace
+//   - UPSTREAM_URL:    后端 vLLM Service 地址
+//   - FAILOVER_CONFIG: 其他集群 gateway 列表（挂载的 Secret 文件，可选）
+//   - GUARDRAILS_CONFIG: spec.gateway.guardrails 的 JSON（可选）
+// ============================================================================
+
+func main() {
+	var activityInterval time.Duration
+	var wakeTimeout time.Duration
+	var usageFlushInterval time.Duration
+	flag.DurationVar(&activityInterval, "activity-interval", time.Minute,
+		"Minimum interval between writes of the last-request-time annotation.")
+	flag.DurationVar(&wakeTimeout, "wake-timeout", 10*time.Minute,
+		"How long a request waits for a hibernated backend to become ready.")
+	flag.DurationVar(&usageFlushInterval, "usage-flush-interval", time.Minute,
+		"How often metered token usage is added to the day's UsageReport.")
+	flag.Parse()
+
+	log.Println("🚀 KubeInfer Gateway starting...")
+
+	name := os.Getenv("LLMSERVICE_NAME")
+	namespace := os.Getenv("POD_NAMESPACE")
+	upstream := os.Getenv("UPSTREAM_URL")
+	if name == "" || namespace == "" {
+		log.Fatalf("❌ Missing required env: LLMSERVICE_NAME, POD_NAMESPACE")
+	}
+	if upstream == "" {
+		upstream = fmt.Sprintf("http://%s-vllm.%s.svc:%d", name, namespace, gateway.DefaultPort)
+	}
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(aiv1.AddToScheme(scheme))
+
+	c, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+	if err != nil {
+		log.Fatalf("❌ Failed to create client: %v", err)
+	}
+
+	// 跨集群故障转移：Secret 没有配置时文件不存在，secondaries 为空
+	failoverConfig := os.Getenv("FAILOVER_CONFIG")
+	if failoverConfig == "" {
+		failoverConfig = gateway.DefaultFailoverConfigPath
+	}
+	secondaries, err := gateway.LoadSecondaries(failoverConfig)
+	if err != nil {
+		log.Fatalf("❌ Failed to load failover config: %v", err)
+	}
+	for _, s := range secondaries {
+		log.Printf("🔀 Failover upstream %s → %s (latency penalty %s)", s.Name, s.URL, s.LatencyPenalty.Duration)
+	}
+
+	middlewares, err := gateway.LoadGuardrails()
+	if err != nil {
+		log.Fatalf("❌ Failed to load guardrails: %v", err)
+	}
+	for _, m := range middlewares {
+		log.Printf("🛡️  Guardrail enabled: %s", m.Name())
+	}
+
+	activity := gateway.NewActivityReporter(c, namespace, name, activityInterval)
+	usage := gateway.NewUsageMeter(c, namespace, name)
+	gw, err := gateway.New(gateway.Config{
+		Namespace:   namespace,
+		Name:        name,
+		UpstreamURL: upstream,
+		WakeTimeout: wakeTimeout,
+		Secondaries: secondaries,
+		Readiness:   gateway.NewStatusReadiness(c, namespace, name),
+		Usage:       usage,
+		Middlewares: middlewares,
+	}, activity)
+	if err != nil {
+		log.Fatalf("❌ Failed to create gateway: %v", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	usageDone := make(chan struct{})
+	go func() {
+		defer close(usageDone)
+		usage.Run(ctx, usageFlushInterval)
+	}()
+
+	if err := gw.Run(ctx, fmt.Sprintf(":%d", gateway.DefaultPort)); err != nil {
+		log.Fatalf("❌ Gateway failed: %v", err)
+	}
+	// 等最后一次用量写完，否则关闭前一分钟内的用量会丢
+	<-usageDone
+	log.Println("👋 Gateway shut down gracefully")
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/admin"
+	"github.com/Moore-Z/kubeinfer/internal/agent/resolver"
+)
+
+// ============================================================================
+// kubectl kubeinfer：kubectl 插件
+// ============================================================================
+//
+// 放到 PATH 里（make build-plugin 生成 bin/kubectl-kubeinfer）之后用 kubectl kubeinfer 调用：
+//
+//	kubectl kubeinfer status <llmservice> [-n namespace] [-o json]
+//
+// status 经 API server 的 Pod proxy 读每个 agent 的 /debug/status（internal/agent/admin），
+// 列出角色、推理服务是否 Ready、模型是否完整、下载进度和最近一次失败。
+// 需要的权限：secrets get（<name>-agent-debug 里的 token）、pods list、pods/proxy get。
+// ============================================================================
+
+// requestTimeout 是整个 status 命令的超时
+const requestTimeout = 30 * time.Second
+
+func main() {
+	if err := newRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// newRootCommand 创建 kubectl-kubeinfer 根命令
+func newRootCommand() *cobra.Command {
+	loading := clientcmd.NewDefaultClientConfigLoadingRules()
+	overrides := &clientcmd.ConfigOverrides{}
+	root := &cobra.Command{
+		Use:          "kubectl-kubeinfer",
+		Short:        "Inspect KubeInfer LLMServices",
+		SilenceUsage: true,
+	}
+	root.PersistentFlags().StringVar(&loading.ExplicitPath, "kubeconfig", "", "path to the kubeconfig file")
+	root.PersistentFlags().StringVar(&overrides.CurrentContext, "context", "", "kubeconfig context to use")
+	root.PersistentFlags().StringVarP(&overrides.Context.Namespace, "namespace", "n", "", "namespace of the LLMService")
+	config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loading, overrides)
+	root.AddCommand(newStatusCommand(config))
+	return root
+}
+
+// newStatusCommand 创建 status 子命令
+func newStatusCommand(config clientcmd.ClientConfig) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "status <llmservice>",
+		Short: "Show role, readiness, model and download state of every agent of an LLMService",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "" && output != "json" {
+				return fmt.Errorf("unsupported output format %q, only json is supported", output)
+			}
+			restConfig, err := config.ClientConfig()
+			if err != nil {
+				return err
+			}
+			namespace, _, err := config.Namespace()
+			if err != nil {
+				return err
+			}
+			clientset, err := kubernetes.NewForConfig(restConfig)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(cmd.Context(), requestTimeout)
+			defer cancel()
+			statuses, err := collect(ctx, clientset, namespace, args[0])
+			if err != nil {
+				return err
+			}
+			if output == "json" {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(statuses)
+			}
+			printTable(cmd.OutOrStdout(), statuses)
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "output", "o", "", "output format: json, default is a table")
+	return cmd
+}
+
+// podStatus 是一个 Pod 的 /debug/status，读不到时 Error 记录原因
+type podStatus struct {
+	Pod    string        `json:"pod"`
+	Status *admin.Status `json:"status,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// collect 读 LLMService 所有 Pod 的 /debug/status，按 Pod 名排序
+//
+// 单个 Pod 读不到（还没启动、容器在重启）不算失败，记在 Error 里继续
+func collect(ctx context.Context, clientset kubernetes.Interface, namespace, llmName string) ([]podStatus, error) {
+	token, err := admin.Token(ctx, clientset, namespace, llmName)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		// app 排除掉带同样 llm_cr label 的 janitor、benchmark Pod（controller 的 podLabels）
+		LabelSelector: "app=llm-inference," + resolver.PodLabel + "=" + llmName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods of %s: %w", llmName, err)
+	}
+	if len(pods.Items) == 0 {
+		return nil, fmt.Errorf("no pods found for LLMService %s/%s", namespace, llmName)
+	}
+	statuses := make([]podStatus, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		ps := podStatus{Pod: pod.Name}
+		if st, err := admin.FetchStatus(ctx, clientset, namespace, pod.Name, token); err != nil {
+			ps.Error = err.Error()
+		} else {
+			ps.Status = st
+		}
+		statuses = append(statuses, ps)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Pod < statuses[j].Pod })
+	return statuses, nil
+}
+
+// printTable 把状态打印成 kubectl get 风格的表格
+func printTable(out io.Writer, statuses []podStatus) {
+	w := tabwriter.NewWriter(out, 0, 4, 3, ' ', 0)
+	_, _ = fmt.Fprintln(w, "POD\tROLE\tREADY\tMODEL\tDOWNLOAD\tLAST ERROR")
+	for _, ps := range statuses {
+		if ps.Status == nil {
+			_, _ = fmt.Fprintf(w, "%s\t<unknown>\t-\t-\t-\t%s\n", ps.Pod, oneLine(ps.Error))
+			continue
+		}
+		st := ps.Status
+		lastError := "-"
+		if len(st.Errors) > 0 {
+			lastError = st.Errors[0].Reason + ": " + oneLine(st.Errors[0].Message)
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\t%s\n",
+			ps.Pod, orDash(st.Role), st.Runtime.Ready, modelState(st.Model), downloadState(st), lastError)
+	}
+	_ = w.Flush()
+}
+
+// modelState 返回模型目录的状态
+func modelState(m admin.ModelStatus) string {
+	state := "incomplete"
+	if m.Complete {
+		state = "complete"
+	}
+	if m.Revision != "" {
+		state += " (" + m.Revision + ")"
+	}
+	return state
+}
+
+// downloadState 返回下载进度，没有下载过时为 -
+func downloadState(st *admin.Status) string {
+	d := st.Download
+	if d == nil {
+		return "-"
+	}
+	if d.Finished {
+		return "done"
+	}
+	if d.Total <= 0 {
+		return fmt.Sprintf("%d B", d.Written)
+	}
+	return fmt.Sprintf("%.1f%%", float64(d.Written)*100/float64(d.Total))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// oneLine 把多行错误压成一行，表格不会被撑乱
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -20,6 +20,7 @@ import (
 	"crypto/tls"
 	"flag"
 	"os"
+	_ "time/tzdata" // UpdateWindow.TimeZone 需要时区数据，distroless 镜像里没有
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -36,7 +37,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	aiv1beta1 "github.com/Moore-Z/kubeinfer/api/v1beta1"
 	"github.com/Moore-Z/kubeinfer/internal/controller"
+	"github.com/Moore-Z/kubeinfer/internal/fleet"
+	"github.com/Moore-Z/kubeinfer/internal/kubeclient"
+	"github.com/Moore-Z/kubeinfer/internal/operatorconfig"
+	webhookaiv1 "github.com/Moore-Z/kubeinfer/internal/webhook/v1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -49,6 +55,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(aiv1.AddToScheme(scheme))
+	utilruntime.Must(aiv1beta1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -62,6 +69,17 @@ func main() {
 	var secureMetrics bool
 	var enableHTTP2 bool
 	var tlsOpts []func(*tls.Config)
+	var enableCostReport bool
+	var gpuHourlyCost float64
+	var costCurrency string
+	var prometheusURL string
+	var gatewayImage string
+	var enableFleet bool
+	var fleetNamespace string
+	var configFile string
+	var watchNamespaces string
+	var kubeAPI kubeclient.RateLimit
+	var kubeAPIQPS float64
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -79,6 +97,29 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.BoolVar(&enableCostReport, "enable-cost-report", false,
+		"If set, GPU-hours, utilization and estimated cost are accumulated into LLMService status.costReport.")
+	flag.Float64Var(&gpuHourlyCost, "gpu-hourly-cost", 0, "Estimated cost of one GPU for one hour, used by the cost report.")
+	flag.StringVar(&costCurrency, "cost-currency", "USD", "Currency label written into the cost report.")
+	flag.StringVar(&prometheusURL, "prometheus-url", "",
+		"Prometheus base URL used to read DCGM GPU utilization and gateway metrics for spec.slo. "+
+			"Leave empty to skip utilization and SLO evaluation.")
+	flag.StringVar(&gatewayImage, "gateway-image", operatorconfig.DefaultGatewayImage,
+		"Image used for the per-LLMService gateway Deployment.")
+	flag.BoolVar(&enableFleet, "enable-fleet", false,
+		"If set, LLMServices with spec.fleet are mirrored to member clusters registered as kubeconfig Secrets.")
+	flag.StringVar(&fleetNamespace, "fleet-namespace", "kubeinfer-system",
+		"Namespace holding the member cluster kubeconfig Secrets (label kubeinfer.io/member-cluster).")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", os.Getenv(controller.WatchNamespacesEnv),
+		"Comma-separated namespaces to watch. Empty watches the whole cluster. Defaults to $WATCH_NAMESPACES.")
+	flag.Float64Var(&kubeAPIQPS, "kube-api-qps", 0,
+		"QPS of the manager's API server client. 0 keeps client-side rate limiting disabled "+
+			"(controller-runtime relies on API priority and fairness).")
+	flag.IntVar(&kubeAPI.Burst, "kube-api-burst", 0,
+		"Burst of the manager's API server client, used with --kube-api-qps. 0 defaults to the QPS.")
+	flag.StringVar(&configFile, "config", "",
+		"Path to the operator config file (config.yaml of the kubeinfer-operator-config ConfigMap). "+
+			"Fields set in the file override the flags above and are reloaded without a restart.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -154,8 +195,28 @@ func main() {
 		metricsServerOptions.KeyName = metricsCertKey
 	}
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	// namespace 模式下只缓存这些 namespace 的对象，RBAC 用 make rbac-namespaced 生成的 Role
+	namespaces := controller.ParseNamespaces(watchNamespaces)
+	if len(namespaces) > 0 {
+		setupLog.Info("Running namespace-scoped", "namespaces", namespaces)
+	} else {
+		setupLog.Info("Running cluster-wide")
+	}
+
+	// 客户端限流：reconcile 变慢时看 kubeinfer_kube_client_throttled_requests_total 是客户端还是 API server 在限流
+	kubeAPI.QPS = float32(kubeAPIQPS)
+	if err := kubeAPI.Validate(); err != nil {
+		setupLog.Error(err, "invalid API client rate limit")
+		os.Exit(1)
+	}
+	restConfig := ctrl.GetConfigOrDie()
+	kubeAPI.Apply(restConfig)
+	kubeclient.Instrument("manager")
+	setupLog.Info("API client rate limit", "qps", restConfig.QPS, "burst", restConfig.Burst)
+
+	mgr, err := ctrl.NewManager(restConfig, ctrl.Options{
 		Scheme:                 scheme,
+		Cache:                  controller.CacheOptions(namespaces),
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
@@ -178,13 +239,66 @@ func main() {
 		os.Exit(1)
 	}
 
+	// 命令行参数作为基础配置，--config 文件里写了的字段覆盖参数
+	baseConfig := operatorconfig.Default()
+	baseConfig.Gateway.Image = gatewayImage
+	baseConfig.CostReport = operatorconfig.CostReportConfig{
+		Enabled:       enableCostReport,
+		GPUHourlyCost: gpuHourlyCost,
+		Currency:      costCurrency,
+		PrometheusURL: prometheusURL,
+	}
+	baseConfig.SLO.PrometheusURL = prometheusURL
+	operatorConfig, err := operatorconfig.NewStore(baseConfig, configFile)
+	if err != nil {
+		setupLog.Error(err, "unable to load operator config", "config", configFile)
+		os.Exit(1)
+	}
+	if err := mgr.Add(operatorConfig); err != nil {
+		setupLog.Error(err, "unable to watch operator config")
+		os.Exit(1)
+	}
+
+	var fleetManager *fleet.Manager
+	if enableFleet {
+		fleetManager = fleet.NewManager(mgr.GetAPIReader(), mgr.GetScheme(), fleetNamespace)
+	}
+
 	if err := (&controller.LLMServiceReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		APIReader: mgr.GetAPIReader(),
+		Config:    operatorConfig,
+		Recorder:  mgr.GetEventRecorderFor("llmservice-controller"),
+		Fleet:     fleetManager,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "LLMService")
+		os.Exit(1)
+	}
+	if err := (&controller.BenchmarkRunReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
+		Config: operatorConfig,
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "LLMService")
+		setupLog.Error(err, "unable to create controller", "controller", "BenchmarkRun")
 		os.Exit(1)
 	}
+	if err := (&controller.EvalRunReconciler{
+		Client:    mgr.GetClient(),
+		Scheme:    mgr.GetScheme(),
+		Config:    operatorConfig,
+		APIReader: mgr.GetAPIReader(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "EvalRun")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookaiv1.SetupLLMServiceWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "LLMService")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
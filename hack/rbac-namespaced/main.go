@@ -0,0 +1,152 @@
+// rbac-namespaced 把 controller-gen 生成的 ClusterRole 拆成每个 namespace 一个 Role
+//
+// manager 以 --watch-namespaces 运行时不需要集群范围的权限：
+//
+//	namespace 级别的规则（configmaps、deployments、llmservices ...） → 每个 namespace 一个 Role + RoleBinding
+//	集群级别的资源（nodes）                                          → 一个只包含这些资源的 ClusterRole + ClusterRoleBinding
+//
+// 用法（make rbac-namespaced WATCH_NAMESPACES=team-a,team-b）：
+//
+//	go run ./hack/rbac-namespaced --namespaces team-a,team-b > dist/rbac-namespaced.yaml
+//
+// 规则从 config/rbac/role.yaml 读取，新增 +kubebuilder:rbac 标记后重新 make manifests 再运行即可。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/Moore-Z/kubeinfer/internal/controller"
+)
+
+// clusterScoped 是 manager 可能用到的集群级别资源（apiGroup/resource），
+// Role 里写这些资源不会生效，必须放进 ClusterRole
+var clusterScoped = map[string]bool{
+	"/nodes":             true,
+	"/namespaces":        true,
+	"/persistentvolumes": true,
+	"apiextensions.k8s.io/customresourcedefinitions": true,
+	"storage.k8s.io/storageclasses":                  true,
+}
+
+func main() {
+	var rolePath, namespaces, serviceAccount, serviceAccountNamespace, namePrefix string
+	flag.StringVar(&rolePath, "role", "config/rbac/role.yaml", "ClusterRole generated by controller-gen.")
+	flag.StringVar(&namespaces, "namespaces", os.Getenv(controller.WatchNamespacesEnv),
+		"Comma-separated namespaces the manager watches. Defaults to $WATCH_NAMESPACES.")
+	flag.StringVar(&serviceAccount, "service-account", "kubeinfer-controller-manager", "Manager service account.")
+	flag.StringVar(&serviceAccountNamespace, "service-account-namespace", "kubeinfer-system",
+		"Namespace of the manager service account.")
+	flag.StringVar(&namePrefix, "name-prefix", "kubeinfer-", "Prefix of the generated object names (kustomize namePrefix).")
+	flag.Parse()
+
+	if err := run(rolePath, controller.ParseNamespaces(namespaces), rbacv1.Subject{
+		Kind:      rbacv1.ServiceAccountKind,
+		Name:      serviceAccount,
+		Namespace: serviceAccountNamespace,
+	}, namePrefix); err != nil {
+		fmt.Fprintln(os.Stderr, "rbac-namespaced:", err)
+		os.Exit(1)
+	}
+}
+
+func run(rolePath string, namespaces []string, subject rbacv1.Subject, prefix string) error {
+	if len(namespaces) == 0 {
+		return fmt.Errorf("no namespaces given (--namespaces or %s)", controller.WatchNamespacesEnv)
+	}
+	raw, err := os.ReadFile(rolePath)
+	if err != nil {
+		return err
+	}
+	role := &rbacv1.ClusterRole{}
+	if err := yaml.Unmarshal(raw, role); err != nil {
+		return fmt.Errorf("parse %s: %w", rolePath, err)
+	}
+	namespaced, cluster := splitRules(role.Rules)
+
+	var objects []any
+	if len(cluster) > 0 {
+		name := prefix + "manager-cluster-role"
+		objects = append(objects,
+			&rbacv1.ClusterRole{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole"},
+				ObjectMeta: metav1.ObjectMeta{Name: name},
+				Rules:      cluster,
+			},
+			&rbacv1.ClusterRoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: name + "binding"},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: name},
+				Subjects:   []rbacv1.Subject{subject},
+			})
+	}
+	name := prefix + "manager-role"
+	for _, ns := range namespaces {
+		objects = append(objects,
+			&rbacv1.Role{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+				Rules:      namespaced,
+			},
+			&rbacv1.RoleBinding{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+				ObjectMeta: metav1.ObjectMeta{Name: name + "binding", Namespace: ns},
+				RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name},
+				Subjects:   []rbacv1.Subject{subject},
+			})
+	}
+
+	var out strings.Builder
+	for _, obj := range objects {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		out.WriteString("---\n")
+		out.Write(data)
+	}
+	_, err = os.Stdout.WriteString(out.String())
+	return err
+}
+
+// splitRules 按资源是否是集群级别拆分规则
+//
+// controller-gen 会把 verbs 相同的资源合并成一条规则（比如 nodes、pods、secrets 的 get/list/watch），
+// 所以要逐个资源拆开
+func splitRules(rules []rbacv1.PolicyRule) (namespaced, cluster []rbacv1.PolicyRule) {
+	for _, rule := range rules {
+		ns, cl := rule.DeepCopy(), rule.DeepCopy()
+		ns.Resources, cl.Resources = nil, nil
+		for _, resource := range rule.Resources {
+			if isClusterScoped(rule.APIGroups, resource) {
+				cl.Resources = append(cl.Resources, resource)
+			} else {
+				ns.Resources = append(ns.Resources, resource)
+			}
+		}
+		if len(ns.Resources) > 0 {
+			namespaced = append(namespaced, *ns)
+		}
+		if len(cl.Resources) > 0 {
+			cluster = append(cluster, *cl)
+		}
+	}
+	return namespaced, cluster
+}
+
+func isClusterScoped(groups []string, resource string) bool {
+	// 子资源（nodes/status）跟着父资源走
+	resource, _, _ = strings.Cut(resource, "/")
+	for _, g := range groups {
+		if clusterScoped[g+"/"+resource] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,309 @@
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/coordinator"
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+	"github.com/Moore-Z/kubeinfer/internal/agent/runtime"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+// ============================================================================
+// agent 的 admin API（/debug/）
+// ============================================================================
+//
+// 排查"这个副本为什么还没 Ready"以前要翻日志：谁是 coordinator、下载到哪了、清单对不对、
+// 推理服务起来没有、最近失败过什么。agent 在健康检查端口（8081）上提供只读的 /debug/ 接口，
+// kubectl kubeinfer status 和 e2e 测试通过 API server 的 Pod proxy 直接读：
+//
+//	GET /debug/status    下面所有内容合在一起（Status）
+//	GET /debug/lease     coordinator lease：持有者、续约时间、切换次数、pin
+//	GET /debug/download  最近一次同步的进度
+//	GET /debug/manifest  当前模型目录的清单原文（没有清单时 404）
+//	GET /debug/runtime   推理服务是否 Ready、是否在排空
+//	GET /debug/errors    最近的失败（最多 maxErrors 条，新的在前）
+//
+// 认证：请求头 X-Kubeinfer-Debug-Token 要和 AGENT_DEBUG_TOKEN 一致，token 在 controller 为每个
+// LLMService 生成的 <name>-agent-debug Secret 里，能读这个 Secret 的人才能调试。
+// 不用 Authorization 头：经过 Pod proxy 时 API server 认证完就把它去掉了。
+// 没有设置 token（旧 controller）时不注册 /debug/，和以前一样返回 404。
+// ============================================================================
+
+const (
+	// TokenEnv 是 admin API 的 token（controller 从 <name>-agent-debug Secret 注入）
+	TokenEnv = "AGENT_DEBUG_TOKEN"
+	// TokenHeader 是带 token 的请求头
+	TokenHeader = "X-Kubeinfer-Debug-Token"
+	// PathPrefix 是 admin API 的路径前缀
+	PathPrefix = "/debug/"
+)
+
+// maxErrors 是 /debug/errors 保留的最近失败数
+const maxErrors = 20
+
+// leaseTimeout 是读 lease 的超时，API server 慢的时候 /debug/status 其他部分照样返回
+const leaseTimeout = 3 * time.Second
+
+// Status 是 GET /debug/status 的响应
+type Status struct {
+	Pod       string    `json:"pod"`
+	Role      string    `json:"role"`
+	RoleSince time.Time `json:"roleSince,omitzero"`
+	// Lease 读不到时为空，原因在 LeaseError
+	Lease      *LeaseStatus                   `json:"lease,omitempty"`
+	LeaseError string                         `json:"leaseError,omitempty"`
+	Download   *distribution.ProgressSnapshot `json:"download,omitempty"`
+	Model      ModelStatus                    `json:"model"`
+	Runtime    RuntimeStatus                  `json:"runtime"`
+	Errors     []ErrorRecord                  `json:"errors"`
+}
+
+// LeaseStatus 是 coordinator lease 的状态
+type LeaseStatus struct {
+	Name   string `json:"name"`
+	Holder string `json:"holder"`
+	// Self 表示这个 Pod 就是 coordinator
+	Self        bool      `json:"self"`
+	RenewTime   time.Time `json:"renewTime,omitzero"`
+	Duration    int32     `json:"leaseDurationSeconds"`
+	Transitions int32     `json:"leaseTransitions"`
+	// Pinned 是 spec.coordination.pinnedPod 写在 lease 上的 Pod
+	Pinned string `json:"pinned,omitempty"`
+}
+
+// ModelStatus 是当前模型目录的状态
+type ModelStatus struct {
+	Dir      string `json:"dir"`
+	Revision string `json:"revision,omitempty"`
+	Complete bool   `json:"complete"`
+	Manifest bool   `json:"manifest"`
+}
+
+// RuntimeStatus 是推理服务的状态
+type RuntimeStatus struct {
+	Name     string `json:"name"`
+	Ready    bool   `json:"ready"`
+	Draining bool   `json:"draining"`
+}
+
+// ErrorRecord 是一次失败（角色失败、推理服务的 Warning Event）
+type ErrorRecord struct {
+	Time    time.Time `json:"time"`
+	Reason  string    `json:"reason"`
+	Message string    `json:"message"`
+}
+
+// Recorder 记录 agent 的角色和最近的失败，admin API 读取。并发安全
+type Recorder struct {
+	mu        sync.Mutex
+	role      string
+	roleSince time.Time
+	errors    []ErrorRecord
+}
+
+// Agent 是 agent 进程里唯一的 Recorder
+var Agent = &Recorder{}
+
+// SetRole 记录当前角色（coordinator、follower、standalone）
+func (r *Recorder) SetRole(role string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if role != r.role {
+		r.role, r.roleSince = role, time.Now()
+	}
+}
+
+// RecordError 记一次失败，只保留最近 maxErrors 条
+func (r *Recorder) RecordError(reason, message string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, ErrorRecord{Time: time.Now(), Reason: reason, Message: message})
+	if len(r.errors) > maxErrors {
+		r.errors = r.errors[len(r.errors)-maxErrors:]
+	}
+}
+
+// snapshot 返回角色和失败记录（新的在前）
+func (r *Recorder) snapshot() (role string, since time.Time, errors []ErrorRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	errors = make([]ErrorRecord, len(r.errors))
+	for i, e := range r.errors {
+		errors[len(r.errors)-1-i] = e
+	}
+	return r.role, r.roleSince, errors
+}
+
+// Server 是 admin API
+type Server struct {
+	token    string
+	pod      string
+	recorder *Recorder
+	layout   distribution.Layout
+
+	clientset kubernetes.Interface
+	namespace string
+	leaseName string
+}
+
+// NewServer 创建 admin API，token 为空时 Register 什么都不注册
+func NewServer(token, pod string, layout distribution.Layout) *Server {
+	return &Server{token: token, pod: pod, recorder: Agent, layout: layout}
+}
+
+// WithLease 设置要展示的 coordinator lease，返回 s 方便链式调用
+func (s *Server) WithLease(clientset kubernetes.Interface, namespace, leaseName string) *Server {
+	s.clientset, s.namespace, s.leaseName = clientset, namespace, leaseName
+	return s
+}
+
+// Register 把 /debug/ 注册到 mux 上
+func (s *Server) Register(mux *http.ServeMux) {
+	if s.token == "" {
+		return
+	}
+	mux.HandleFunc(PathPrefix, s.authorize(s.handle))
+}
+
+// authorize 检查 TokenHeader
+func (s *Server) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(TokenHeader)), []byte(s.token)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch strings.TrimPrefix(r.URL.Path, PathPrefix) {
+	case "status":
+		writeJSON(w, s.status(r.Context()))
+	case "lease":
+		lease, err := s.lease(r.Context())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		writeJSON(w, lease)
+	case "download":
+		progress, ok := distribution.LatestProgress()
+		if !ok {
+			http.Error(w, "No download has started", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, progress)
+	case "manifest":
+		raw, err := os.ReadFile(filepath.Join(s.layout.Current(), distribution.ManifestFile))
+		if err != nil {
+			http.Error(w, "No manifest in the model directory", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(raw)
+	case "runtime":
+		writeJSON(w, runtimeStatus())
+	case "errors":
+		_, _, errors := s.recorder.snapshot()
+		writeJSON(w, errors)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// status 汇总所有内容，lease 读不到时记在 LeaseError 里
+func (s *Server) status(ctx context.Context) Status {
+	role, since, errors := s.recorder.snapshot()
+	st := Status{
+		Pod:       s.pod,
+		Role:      role,
+		RoleSince: since,
+		Model:     s.model(),
+		Runtime:   runtimeStatus(),
+		Errors:    errors,
+	}
+	if lease, err := s.lease(ctx); err != nil {
+		st.LeaseError = err.Error()
+	} else {
+		st.Lease = lease
+	}
+	if progress, ok := distribution.LatestProgress(); ok {
+		st.Download = &progress
+	}
+	return st
+}
+
+// lease 读 coordinator lease，没有设置（standalone 测试）时返回 nil
+func (s *Server) lease(ctx context.Context) (*LeaseStatus, error) {
+	if s.clientset == nil {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, leaseTimeout)
+	defer cancel()
+	lease, err := s.clientset.CoordinationV1().Leases(s.namespace).Get(ctx, s.leaseName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	st := &LeaseStatus{Name: lease.Name, Pinned: lease.Annotations[cacheinfo.PinnedCoordinatorAnnotation]}
+	if h := lease.Spec.HolderIdentity; h != nil {
+		st.Holder = *h
+		st.Self = *h == s.pod
+	}
+	if lease.Spec.RenewTime != nil {
+		st.RenewTime = lease.Spec.RenewTime.Time
+	}
+	if lease.Spec.LeaseDurationSeconds != nil {
+		st.Duration = *lease.Spec.LeaseDurationSeconds
+	}
+	if lease.Spec.LeaseTransitions != nil {
+		st.Transitions = *lease.Spec.LeaseTransitions
+	}
+	return st, nil
+}
+
+// model 返回当前模型目录（versioned 布局下是 current 指向的 revision）的状态
+func (s *Server) model() ModelStatus {
+	dir := s.layout.Current()
+	_, err := os.Stat(filepath.Join(dir, distribution.ManifestFile))
+	return ModelStatus{
+		Dir:      dir,
+		Revision: s.layout.CurrentRevision(),
+		Complete: coordinator.ModelComplete(dir),
+		Manifest: err == nil,
+	}
+}
+
+// runtimeStatus 返回推理服务的状态，INFERENCE_RUNTIME 为空时是 vLLM（和 runtime.New 一致）
+func runtimeStatus() RuntimeStatus {
+	name := os.Getenv("INFERENCE_RUNTIME")
+	if name == "" {
+		name = runtime.KindVLLM
+	}
+	return RuntimeStatus{
+		Name:     name,
+		Ready:    runtime.Health.Ready(),
+		Draining: runtime.Health.Draining(),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/coordinator"
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+)
+
+func TestDebugAPI(t *testing.T) {
+	dir := t.TempDir()
+	recorder := &Recorder{}
+	s := NewServer("secret", "llm-0", distribution.Layout{Root: dir})
+	s.recorder = recorder
+	mux := http.NewServeMux()
+	s.Register(mux)
+
+	get := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set(TokenHeader, token)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		if rec := get("/debug/status", token); rec.Code != http.StatusUnauthorized {
+			t.Errorf("token %q: status %d, want 401", token, rec.Code)
+		}
+	}
+	if rec := get("/debug/manifest", "secret"); rec.Code != http.StatusNotFound {
+		t.Errorf("manifest without a manifest file: status %d, want 404", rec.Code)
+	}
+
+	recorder.SetRole("follower")
+	for i := range maxErrors + 5 {
+		recorder.RecordError("SyncFailed", fmt.Sprintf("attempt %d", i))
+	}
+	if err := os.WriteFile(filepath.Join(dir, coordinator.CompleteMarker), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, distribution.ManifestFile), []byte(`{"files":[]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := get("/debug/status", "secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status: %d %s", rec.Code, rec.Body)
+	}
+	var st Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &st); err != nil {
+		t.Fatal(err)
+	}
+	if st.Pod != "llm-0" || st.Role != "follower" || !st.Model.Complete || !st.Model.Manifest {
+		t.Errorf("status = %+v, want llm-0 follower with a complete model and manifest", st)
+	}
+	// 只保留最近 maxErrors 条，新的在前
+	if len(st.Errors) != maxErrors || st.Errors[0].Message != fmt.Sprintf("attempt %d", maxErrors+4) {
+		t.Errorf("errors = %d, newest %+v, want %d newest first", len(st.Errors), st.Errors[0], maxErrors)
+	}
+
+	if rec := get("/debug/manifest", "secret"); rec.Code != http.StatusOK || rec.Body.String() != `{"files":[]}` {
+		t.Errorf("manifest: %d %s, want the raw manifest", rec.Code, rec.Body)
+	}
+	if rec := get("/debug/unknown", "secret"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown path: status %d, want 404", rec.Code)
+	}
+
+	// 没有 token 时不注册 /debug/
+	empty := http.NewServeMux()
+	NewServer("", "llm-0", distribution.Layout{Root: dir}).Register(empty)
+	req := httptest.NewRequest(http.MethodGet, "/debug/status", nil)
+	rec = httptest.NewRecorder()
+	empty.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("without a token: status %d, want 404", rec.Code)
+	}
+}
@@ -0,0 +1,52 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+// Port 是 agent 健康检查端口（cmd/agent 的 healthAddr），/debug/ 在这个端口上
+const Port = 8081
+
+// Token 从 controller 生成的 Secret 里读出 LLMService 的 admin API token
+func Token(ctx context.Context, clientset kubernetes.Interface, namespace, llmName string) (string, error) {
+	name := cacheinfo.AgentDebugSecretName(llmName)
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to read the agent debug token from secret %s: %w", name, err)
+	}
+	token := string(secret.Data[cacheinfo.AgentDebugTokenKey])
+	if token == "" {
+		return "", fmt.Errorf("secret %s has no %q key", name, cacheinfo.AgentDebugTokenKey)
+	}
+	return token, nil
+}
+
+// FetchStatus 经 API server 的 Pod proxy 读一个 agent 的 GET /debug/status
+//
+// 不需要能直接访问 Pod IP（kubectl 在集群外），调用者要有 pods/proxy 的 get 权限
+func FetchStatus(ctx context.Context, clientset kubernetes.Interface, namespace, pod, token string) (*Status, error) {
+	raw, err := clientset.CoreV1().RESTClient().Get().
+		Namespace(namespace).
+		Resource("pods").
+		Name(pod+":"+strconv.Itoa(Port)).
+		SubResource("proxy").
+		Suffix("debug", "status").
+		SetHeader(TokenHeader, token).
+		DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get /debug/status of pod %s: %w", pod, err)
+	}
+	status := &Status{}
+	if err := json.Unmarshal(raw, status); err != nil {
+		return nil, fmt.Errorf("invalid /debug/status from pod %s: %w", pod, err)
+	}
+	return status, nil
+}
@@ -0,0 +1,228 @@
+// Package agenterr 定义 agent 的错误分类
+//
+// 之前 downloader、model server、runtime 到处都是 fmt.Errorf 字符串，
+// main 只能把错误打日志然后一律重试：HF token 错了会无限重试，
+// 磁盘满了也会无限重试，谁都看不出为什么模型一直起不来。
+//
+// 现在每个错误归到一类，不同的类有不同的处理：
+//
+//	类型                   Event Reason         重试行为
+//	ErrTransientNetwork    NetworkError         退避重试
+//	ErrAuth                AuthFailed           不重试，容器退出（CrashLoopBackOff + 终止消息）
+//	ErrLicenseNotAccepted  LicenseNotAccepted   不重试，容器退出
+//	ErrDiskFull            DiskFull             不重试，容器退出
+//	ErrModelCorrupt        ModelCorrupt         删除本地模型文件后重新下载
+//
+// 用法：
+//
+//	return agenterr.Wrap(agenterr.ErrAuth, fmt.Errorf("download %s: %w", repo, err))
+//	...
+//	if errors.Is(err, agenterr.ErrAuth) { ... }
+package agenterr
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+)
+
+// 错误类型，用 errors.Is 判断
+var (
+	// ErrTransientNetwork 网络抖动、对端重启、连接被重置，等一会儿重试就好
+	ErrTransientNetwork = errors.New("transient network error")
+	// ErrAuth HuggingFace token 缺失/无效，或者 gated 模型没有权限，重试没有意义
+	ErrAuth = errors.New("authentication failed")
+	// ErrLicenseNotAccepted gated 模型的许可协议还没有接受（HF 账号没有同意，或者 spec.modelSource.acceptLicense 没打开）
+	ErrLicenseNotAccepted = errors.New("model license not accepted")
+	// ErrDiskFull 模型卷空间不够，需要人工扩容
+	ErrDiskFull = errors.New("disk full")
+	// ErrModelCorrupt 本地模型文件不完整或损坏，删掉重新下载
+	ErrModelCorrupt = errors.New("model files corrupt")
+)
+
+// Event Reason，也是写到容器终止消息里的前缀，controller 据此设置 Condition
+const (
+	ReasonNetworkError       = "NetworkError"
+	ReasonAuthFailed         = "AuthFailed"
+	ReasonLicenseNotAccepted = "LicenseNotAccepted"
+	ReasonDiskFull           = "DiskFull"
+	ReasonModelCorrupt       = "ModelCorrupt"
+	ReasonUnknown            = "AgentError"
+)
+
+// Error 给底层错误打上类型
+//
+// errors.Is 同时能匹配类型（ErrAuth 等）和原始错误（比如 syscall.ENOSPC）
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Kind.Error()
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// Wrap 给 err 打上类型 kind；err 为 nil 时返回 nil
+func Wrap(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// kinds 按判断优先级排列
+var kinds = []error{ErrLicenseNotAccepted, ErrAuth, ErrDiskFull, ErrModelCorrupt, ErrTransientNetwork}
+
+// Kind 返回 err 的类型，没有打过类型的错误会按底层错误推断，推断不出来返回 nil
+func Kind(err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, k := range kinds {
+		if errors.Is(err, k) {
+			return k
+		}
+	}
+	return infer(err)
+}
+
+// Classify 给还没有类型的错误补上推断出的类型
+func Classify(err error) error {
+	if err == nil {
+		return nil
+	}
+	var typed *Error
+	if errors.As(err, &typed) {
+		return err
+	}
+	if kind := infer(err); kind != nil {
+		return Wrap(kind, err)
+	}
+	return err
+}
+
+// infer 根据底层错误推断类型
+func infer(err error) error {
+	switch {
+	case errors.Is(err, syscall.ENOSPC), errors.Is(err, syscall.EDQUOT):
+		return ErrDiskFull
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.EPIPE), errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrTransientNetwork
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrTransientNetwork
+	}
+	return nil
+}
+
+// FromHTTPStatus 把非 200 的 HTTP 状态码转成带类型的错误
+//
+// - 401/403：认证失败
+// - 5xx、429、408：对端暂时不可用
+// - 其他（比如 404）：不打类型，按未知错误处理
+func FromHTTPStatus(code int, err error) error {
+	switch {
+	case code == http.StatusUnauthorized, code == http.StatusForbidden:
+		return Wrap(ErrAuth, err)
+	case code >= 500, code == http.StatusTooManyRequests, code == http.StatusRequestTimeout:
+		return Wrap(ErrTransientNetwork, err)
+	}
+	return err
+}
+
+// outputPatterns 是外部命令（huggingface-cli）输出里能识别出错误类型的片段
+var outputPatterns = []struct {
+	kind    error
+	needles []string
+}{
+	// 许可协议没接受和没登录都是 GatedRepoError，要先匹配
+	{ErrLicenseNotAccepted, []string{"not in the authorized list", "awaiting a review"}},
+	{ErrAuth, []string{"401 Client Error", "403 Client Error", "GatedRepoError", "Invalid user token", "Access to model", "is restricted"}},
+	{ErrDiskFull, []string{"No space left on device", "Disk quota exceeded"}},
+	{ErrModelCorrupt, []string{"Consistency check failed", "SafetensorError", "Error while deserializing header"}},
+	{ErrTransientNetwork, []string{"Connection reset", "Connection refused", "Read timed out", "ConnectTimeout", "Temporary failure in name resolution", "502 Server Error", "503 Server Error", "504 Server Error"}},
+}
+
+// FromOutput 根据命令输出推断 err 的类型，推断不出来时原样返回
+func FromOutput(output string, err error) error {
+	if err == nil {
+		return nil
+	}
+	for _, p := range outputPatterns {
+		for _, needle := range p.needles {
+			if strings.Contains(output, needle) {
+				return Wrap(p.kind, err)
+			}
+		}
+	}
+	return err
+}
+
+// Reason 返回 err 对应的 Event Reason
+func Reason(err error) string {
+	switch Kind(err) {
+	case ErrTransientNetwork:
+		return ReasonNetworkError
+	case ErrAuth:
+		return ReasonAuthFailed
+	case ErrLicenseNotAccepted:
+		return ReasonLicenseNotAccepted
+	case ErrDiskFull:
+		return ReasonDiskFull
+	case ErrModelCorrupt:
+		return ReasonModelCorrupt
+	}
+	return ReasonUnknown
+}
+
+// Retryable 判断错误是否值得原地重试
+//
+// 未分类的错误也重试，保持以前的行为；ModelCorrupt 需要先清理本地文件再重试
+func Retryable(err error) bool {
+	switch Kind(err) {
+	case ErrAuth, ErrLicenseNotAccepted, ErrDiskFull:
+		return false
+	}
+	return true
+}
+
+// TerminationMessage 生成写到 /dev/termination-log 的内容："<Reason>: <错误>"
+//
+// kubelet 把它放到 containerStatuses[].lastState.terminated.message，
+// controller 不用翻日志就知道 agent 为什么退出
+func TerminationMessage(err error) string {
+	msg := Reason(err) + ": " + err.Error()
+	// kubelet 最多保留 4096 字节
+	if len(msg) > 4096 {
+		msg = msg[:4096]
+	}
+	return msg
+}
+
+// ParseTerminationMessage 从终止消息里解析出 Reason，不是 agent 写的消息返回 false
+func ParseTerminationMessage(msg string) (reason, detail string, ok bool) {
+	reason, detail, found := strings.Cut(msg, ": ")
+	if !found {
+		return "", "", false
+	}
+	switch reason {
+	case ReasonNetworkError, ReasonAuthFailed, ReasonLicenseNotAccepted, ReasonDiskFull, ReasonModelCorrupt, ReasonUnknown:
+		return reason, detail, true
+	}
+	return "", "", false
+}
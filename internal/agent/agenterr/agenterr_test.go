@@ -0,0 +1,82 @@
+package agenterr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestKind(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"wrapped auth", fmt.Errorf("download: %w", Wrap(ErrAuth, errors.New("401"))), ErrAuth},
+		{"enospc", fmt.Errorf("write: %w", &os.PathError{Op: "write", Path: "/models/x", Err: syscall.ENOSPC}), ErrDiskFull},
+		{"connection refused", fmt.Errorf("get: %w", syscall.ECONNREFUSED), ErrTransientNetwork},
+		{"http 403", FromHTTPStatus(http.StatusForbidden, errors.New("status 403")), ErrAuth},
+		{"http 503", FromHTTPStatus(http.StatusServiceUnavailable, errors.New("status 503")), ErrTransientNetwork},
+		{"http 404", FromHTTPStatus(http.StatusNotFound, errors.New("status 404")), nil},
+		{"hf gated repo", FromOutput("huggingface_hub.errors.GatedRepoError: 403 Client Error", errors.New("exit status 1")), ErrAuth},
+		{"hf license", FromOutput("GatedRepoError: 403 Client Error. Access to model meta-llama/Llama-3.1-8B is restricted and you are not in the authorized list.", errors.New("exit status 1")), ErrLicenseNotAccepted},
+		{"hf disk full", FromOutput("OSError: [Errno 28] No space left on device", errors.New("exit status 1")), ErrDiskFull},
+		{"hf consistency", FromOutput("Consistency check failed: file should be of size 4.9G", errors.New("exit status 1")), ErrModelCorrupt},
+		{"unknown", errors.New("MODEL_REPO environment variable not set"), nil},
+	}
+
+	for _, tt := range tests {
+		if got := Kind(tt.err); got != tt.want {
+			t.Errorf("%s: Kind() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWrapKeepsCause(t *testing.T) {
+	err := Classify(fmt.Errorf("write: %w", syscall.ENOSPC))
+	if !errors.Is(err, ErrDiskFull) || !errors.Is(err, syscall.ENOSPC) {
+		t.Errorf("errors.Is should match both kind and cause, got %v", err)
+	}
+	if Wrap(ErrAuth, nil) != nil {
+		t.Error("Wrap(nil) should be nil")
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{Wrap(ErrTransientNetwork, errors.New("reset")), true},
+		{Wrap(ErrModelCorrupt, errors.New("bad header")), true},
+		{Wrap(ErrAuth, errors.New("401")), false},
+		{Wrap(ErrLicenseNotAccepted, errors.New("gated")), false},
+		{Wrap(ErrDiskFull, errors.New("enospc")), false},
+		{errors.New("something else"), true},
+	}
+
+	for _, tt := range tests {
+		if got := Retryable(tt.err); got != tt.want {
+			t.Errorf("Retryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestTerminationMessageRoundTrip(t *testing.T) {
+	msg := TerminationMessage(Wrap(ErrAuth, errors.New("download failed: exit status 1")))
+	if msg != "AuthFailed: download failed: exit status 1" {
+		t.Fatalf("unexpected message %q", msg)
+	}
+
+	reason, detail, ok := ParseTerminationMessage(msg)
+	if !ok || reason != ReasonAuthFailed || detail != "download failed: exit status 1" {
+		t.Errorf("ParseTerminationMessage() = %q, %q, %v", reason, detail, ok)
+	}
+
+	if _, _, ok := ParseTerminationMessage("Error: exit code 137"); ok {
+		t.Error("messages not written by the agent should not parse")
+	}
+}
@@ -0,0 +1,188 @@
+// Package bandwidth 在 coordinator 的上游下载和给 follower 供货之间分配网卡带宽
+//
+// coordinator 一边从 HuggingFace（或 hub 集群）下载，一边通过 model server 把已完成的文件给 follower，
+// 两边抢同一块网卡。不加限制时下载会把网卡占满，follower 拿文件很慢；反过来也一样。
+//
+// Manager 是两个共享同一个总预算的令牌桶：
+//
+//	上游下载中：serve = limit × servePercent%，upstream = 剩下的
+//	上游完成后：serve = limit（FinishUpstream 之后整块网卡都给 follower）
+//
+// limit 为 0 时不限速，Manager 为 nil 时所有方法都是直通的。
+package bandwidth
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// 环境变量，controller 根据 spec.distribution.bandwidth 设置
+const (
+	// LimitEnv 是总带宽（bytes/s），0 或不设置表示不限速
+	LimitEnv = "AGENT_BANDWIDTH_LIMIT"
+	// ServePercentEnv 是上游下载期间给 follower 的份额（1-99）
+	ServePercentEnv = "AGENT_BANDWIDTH_SERVE_PERCENT"
+)
+
+// DefaultServePercent 默认 70% 给 follower：follower 数量多，先拿到文件的 follower 可以先加载
+const DefaultServePercent = 70
+
+const (
+	minBurst = 32 << 10
+	maxBurst = 4 << 20
+)
+
+// Manager 管理 serve 和 upstream 两个方向的带宽
+type Manager struct {
+	limit        float64
+	servePercent int
+
+	mu             sync.Mutex
+	upstreamActive bool
+
+	serve    *rate.Limiter
+	upstream *rate.Limiter
+}
+
+// New 创建 Manager，limit 是总带宽（bytes/s），不大于 0 时返回 nil（不限速）
+func New(limit int64, servePercent int) *Manager {
+	if limit <= 0 {
+		return nil
+	}
+	if servePercent < 1 || servePercent > 99 {
+		servePercent = DefaultServePercent
+	}
+	// 每次等待的令牌数不超过 burst：太小了系统调用多，太大了限速不平滑
+	burst := int(min(max(limit/10, minBurst), maxBurst))
+	return &Manager{
+		limit:        float64(limit),
+		servePercent: servePercent,
+		serve:        rate.NewLimiter(rate.Limit(limit), burst),
+		upstream:     rate.NewLimiter(rate.Limit(limit), burst),
+	}
+}
+
+// FromEnv 按 AGENT_BANDWIDTH_LIMIT / AGENT_BANDWIDTH_SERVE_PERCENT 创建 Manager
+func FromEnv() *Manager {
+	v := os.Getenv(LimitEnv)
+	if v == "" {
+		return nil
+	}
+	limit, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		log.Printf("⚠️  Ignoring invalid %s=%q", LimitEnv, v)
+		return nil
+	}
+	percent := DefaultServePercent
+	if p := os.Getenv(ServePercentEnv); p != "" {
+		if percent, err = strconv.Atoi(p); err != nil {
+			log.Printf("⚠️  Ignoring invalid %s=%q", ServePercentEnv, p)
+			percent = DefaultServePercent
+		}
+	}
+	return New(limit, percent)
+}
+
+// StartUpstream 开始上游下载：按 servePercent 分配带宽
+func (m *Manager) StartUpstream() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.upstreamActive = true
+	serve := m.limit * float64(m.servePercent) / 100
+	m.serve.SetLimit(rate.Limit(serve))
+	m.upstream.SetLimit(rate.Limit(m.limit - serve))
+	log.Printf("🚦 Bandwidth split: %.0f MiB/s serving, %.0f MiB/s upstream", serve/(1<<20), (m.limit-serve)/(1<<20))
+}
+
+// FinishUpstream 上游下载结束（成功或失败）：整个预算都给 serve
+func (m *Manager) FinishUpstream() {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.upstreamActive {
+		return
+	}
+	m.upstreamActive = false
+	m.serve.SetLimit(rate.Limit(m.limit))
+	m.upstream.SetLimit(rate.Limit(m.limit))
+	log.Printf("🚦 Upstream finished, serving gets the full %.0f MiB/s", m.limit/(1<<20))
+}
+
+// Limits 返回当前 serve 和 upstream 的限速（bytes/s），不限速时都是 0
+func (m *Manager) Limits() (serve, upstream float64) {
+	if m == nil {
+		return 0, 0
+	}
+	return float64(m.serve.Limit()), float64(m.upstream.Limit())
+}
+
+// Serve 给发往 follower 的数据限速
+func (m *Manager) Serve(ctx context.Context, w io.Writer) io.Writer {
+	if m == nil {
+		return w
+	}
+	return &limitedWriter{ctx: ctx, w: w, l: m.serve}
+}
+
+// Upstream 给从上游读取的数据限速
+func (m *Manager) Upstream(ctx context.Context, r io.Reader) io.Reader {
+	if m == nil {
+		return r
+	}
+	return &limitedReader{ctx: ctx, r: r, l: m.upstream}
+}
+
+// limitedWriter 先拿令牌再写，每次最多写 burst 字节
+type limitedWriter struct {
+	ctx context.Context
+	w   io.Writer
+	l   *rate.Limiter
+}
+
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), lw.l.Burst())
+		if err := lw.l.WaitN(lw.ctx, n); err != nil {
+			return written, err
+		}
+		k, err := lw.w.Write(p[:n])
+		written += k
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// limitedReader 每次最多读 burst 字节，读到多少就等多少令牌
+type limitedReader struct {
+	ctx context.Context
+	r   io.Reader
+	l   *rate.Limiter
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > lr.l.Burst() {
+		p = p[:lr.l.Burst()]
+	}
+	n, err := lr.r.Read(p)
+	if n > 0 {
+		if werr := lr.l.WaitN(lr.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,83 @@
+package bandwidth
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	m := New(1000<<20, 70)
+
+	serve, upstream := m.Limits()
+	if serve != 1000<<20 {
+		t.Errorf("before upstream starts serving should get everything, got %v", serve)
+	}
+
+	m.StartUpstream()
+	serve, upstream = m.Limits()
+	if serve != 700<<20 || upstream != 300<<20 {
+		t.Errorf("split = %v/%v, want 70%%/30%%", serve, upstream)
+	}
+
+	m.FinishUpstream()
+	if serve, _ = m.Limits(); serve != 1000<<20 {
+		t.Errorf("after upstream finishes serving should get everything, got %v", serve)
+	}
+}
+
+func TestNilManagerIsUnlimited(t *testing.T) {
+	var m *Manager
+	if New(0, 70) != nil {
+		t.Fatal("limit 0 must disable the manager")
+	}
+	m.StartUpstream()
+	m.FinishUpstream()
+
+	var buf bytes.Buffer
+	if w := m.Serve(context.Background(), &buf); w != &buf {
+		t.Error("nil manager must return the writer unchanged")
+	}
+	r := bytes.NewReader(nil)
+	if got := m.Upstream(context.Background(), r); got != r {
+		t.Error("nil manager must return the reader unchanged")
+	}
+}
+
+func TestLimitsThroughput(t *testing.T) {
+	// 1 MiB/s，burst 是 limit/10，写 300KB 至少要等 ~0.19s
+	m := New(1<<20, 50)
+	data := make([]byte, 300_000)
+
+	start := time.Now()
+	var buf bytes.Buffer
+	if _, err := m.Serve(context.Background(), &buf).Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != len(data) {
+		t.Fatalf("wrote %d bytes, want %d", buf.Len(), len(data))
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("300KB at 1MiB/s took only %v", elapsed)
+	}
+
+	// 上游下载期间只有一半带宽
+	m.StartUpstream()
+	start = time.Now()
+	n, err := io.Copy(io.Discard, m.Upstream(context.Background(), bytes.NewReader(data)))
+	if err != nil || n != int64(len(data)) {
+		t.Fatalf("copied %d bytes, err %v", n, err)
+	}
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond {
+		t.Errorf("300KB at 512KiB/s took only %v", elapsed)
+	}
+
+	// 取消时立即返回
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := m.Serve(ctx, io.Discard).Write(data); err == nil {
+		t.Error("write with a canceled context must fail")
+	}
+}
@@ -11,6 +11,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
 )
 
 type AgentConfig struct {
@@ -63,8 +65,8 @@ func LoadConfig() (*AgentConfig, error) {
 //	│  ConfigMap (由 Controller 创建和维护)                        │
 //	│                                                             │
 //	│  data:                                                      │
-//	│    coordinator-pod: "vllm-deployment-0"   ← 谁是老大        │
-//	│    coordinator-ip: "10.0.0.5"             ← 老大的 IP       │
+//	│    schemaVersion: "1"                                       │
+//	│    cacheInfo: {"coordinator":"vllm-deployment-0"} ← 谁是老大 │
 //	└─────────────────────────────────────────────────────────────┘
 //	                          │
 //	                          │ 读取
@@ -108,7 +110,7 @@ func (c *AgentConfig) loadRoleFromConfigMap() error {
 	// Step 2: 从 Kubernetes 读取 ConfigMap
 	// ========================================
 	//
-	// ConfigMap 是 Controller 创建的，格式如下：
+	// ConfigMap 是 Controller 创建的，格式见 pkg/cacheinfo：
 	//
 	//   apiVersion: v1
 	//   kind: ConfigMap
@@ -116,9 +118,8 @@ func (c *AgentConfig) loadRoleFromConfigMap() error {
 	//     name: my-llm-cache        ← c.ConfigMapName
 	//     namespace: default        ← c.Namespace
 	//   data:
-	//     coordinator-pod: "vllm-deployment-0"   ← 我们要读的字段
-	//     coordinator-ip: "10.0.0.5"
-	//     last-heartbeat: "2024-01-20T10:00:00Z"
+	//     schemaVersion: "1"
+	//     cacheInfo: '{"coordinator":"vllm-deployment-0","coordinatorNode":"gpu-node-1"}'
 	//
 	ctx := context.Background()
 
@@ -132,15 +133,19 @@ func (c *AgentConfig) loadRoleFromConfigMap() error {
 	}
 
 	// ========================================
-	// Step 3: 读取 coordinator-pod 字段
+	// Step 3: 解析 coordinator
 	// ========================================
 	//
-	// cm.Data 是一个 map[string]string，存储 ConfigMap 的所有键值对
-	// 我们要找的是 "coordinator-pod" 这个 key
-	coordinator, exists := cm.Data["coordinator-pod"]
-	if !exists {
-		return fmt.Errorf("No coordinator-pod in ConfigMap %w", err)
+	// cacheinfo.Parse 和 controller 共用同一份格式定义，
+	// 也兼容只有 coordinator-pod 的旧格式
+	info, err := cacheinfo.Parse(cm.Data)
+	if err != nil {
+		return fmt.Errorf("Failed to parse configMap %s: %w", c.ConfigMapName, err)
+	}
+	if info.Coordinator == "" {
+		return fmt.Errorf("No coordinator in ConfigMap %s", c.ConfigMapName)
 	}
+	coordinator := info.Coordinator
 
 	// ========================================
 	// Step 4: 判断"我是不是 Coordinator"
@@ -165,8 +170,8 @@ func (c *AgentConfig) loadRoleFromConfigMap() error {
 	// → 因为 Follower 要通过 HTTP 从 Coordinator 下载模型文件
 	// → HTTP 请求需要 IP 地址：http://{CoordinatorIP}:8080/models
 	//
-	// 为什么不直接从 ConfigMap 读 coordinator-ip？
-	// → 也可以，但这里选择直接查询 Pod 获取最新的 IP（更可靠）
+	// 为什么 ConfigMap 里不存 coordinator 的 IP？
+	// → Pod 重建后 IP 会变，直接查询 Pod 拿到的才是最新的
 	if !c.IsCoordinator {
 		// 调用 Kubernetes API 查询 Coordinator Pod 的详细信息
 		coordinatorPod, err := clientSet.CoreV1().Pods(c.Namespace).Get(
@@ -0,0 +1,192 @@
+package coordinator
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// ============================================================================
+// 加权候选（谁更适合当 coordinator）
+// ============================================================================
+//
+// 以前谁先抢到 lease 谁当 coordinator（takeoverDelay 只按 identity 的哈希错开），
+// 经常落在机械盘、千兆网卡的节点上，所有 follower 都要从它那里拿模型。
+// 现在每个 pod 启动时算一个偏好值 preference ∈ [0, 1]，接管前的等待时间和它成反比：
+//
+//	delay = (1 - preference) × 2×retryPeriod + hash(identity) % (retryPeriod/4)
+//
+// 偏好高的 pod 先醒来抢，偏好低的醒来时看到 lease 已经有人了就不抢；偏好一样时仍按哈希错开。
+// 只影响 lease 过期、被让出、第一次创建时的接管顺序，不会抢走一个正常续约的 lease。
+//
+// 偏好值：
+//   - 节点 label kubeinfer.io/seed-preferred=true → 1，=false → 0（管理员说了算）
+//   - 否则看本地：模型目录所在的盘是 SSD 还是机械盘、默认路由网卡的空闲带宽，落在 [0.1, 0.9]
+//     （hostNetwork 以外 Pod 看到的是 veth，大家的网卡分数一样，只有磁盘起作用）
+// ============================================================================
+
+// SeedPreferredLabel 是节点上的偏好 label，"true" 优先当 coordinator，"false" 尽量不当
+const SeedPreferredLabel = "kubeinfer.io/seed-preferred"
+
+const (
+	// preferenceWindowRetries 是偏好最低的 pod 比最高的多等的时间（以 retryPeriod 为单位）
+	preferenceWindowRetries = 2
+	// nicSampleInterval 是估算网卡已用带宽时两次读计数器的间隔
+	nicSampleInterval = time.Second
+)
+
+// WithPreference 按偏好值决定接管前的等待时间（见 candidacy.go），不设置时只按 identity 的哈希错开
+func (lm *LeaseManager) WithPreference(preference float64) *LeaseManager {
+	p := min(max(preference, 0), 1)
+	lm.preference = &p
+	return lm
+}
+
+// takeoverDelay 返回接管过期 lease 前的等待时间
+//
+// 没有偏好值时按 identity 的哈希落在 [0, retryPeriod)：同一个 pod 每次等的时间一样，
+// 哈希小的 pod 总是先抢，不会每次都好几个 pod 一起冲突。
+// 有偏好值时偏好越高等得越短，哈希只在 [0, retryPeriod/4) 里错开偏好一样的 pod
+func (lm *LeaseManager) takeoverDelay() time.Duration {
+	if lm.retryPeriod <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(lm.identity))
+	if lm.preference == nil {
+		return time.Duration(h.Sum64() % uint64(lm.retryPeriod))
+	}
+	window := float64(preferenceWindowRetries * lm.retryPeriod)
+	tieBreak := time.Duration(h.Sum64() % uint64(max(lm.retryPeriod/4, 1)))
+	return time.Duration((1-*lm.preference)*window) + tieBreak
+}
+
+// Candidacy 计算这个 pod 当 coordinator 的偏好值，读不到的信息按中间值算
+func Candidacy(ctx context.Context, clientset kubernetes.Interface, nodeName, modelPath string) float64 {
+	if nodeName != "" {
+		node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+		if err != nil {
+			klog.Warningf("读取节点 %s 失败，不看 %s: %v", nodeName, SeedPreferredLabel, err)
+		} else {
+			switch node.Labels[SeedPreferredLabel] {
+			case "true":
+				klog.Infof("节点 %s 标记了 %s=true，优先当 coordinator", nodeName, SeedPreferredLabel)
+				return 1
+			case "false":
+				klog.Infof("节点 %s 标记了 %s=false，尽量不当 coordinator", nodeName, SeedPreferredLabel)
+				return 0
+			}
+		}
+	}
+	disk, nic := diskScore(modelPath), nicScore(ctx)
+	preference := 0.1 + 0.8*(disk+nic)/2
+	klog.Infof("coordinator 候选偏好 %.2f（磁盘 %.2f，网卡 %.2f）", preference, disk, nic)
+	return preference
+}
+
+// diskScore 返回模型目录所在的盘的分数：SSD 1，机械盘 0，看不出来（网络存储、overlay）0.5
+func diskScore(modelPath string) float64 {
+	var st syscall.Stat_t
+	if err := syscall.Stat(modelPath, &st); err != nil {
+		return 0.5
+	}
+	major, minor := (st.Dev>>8)&0xfff|(st.Dev>>32)&^0xfff, st.Dev&0xff|(st.Dev>>12)&^0xff
+	if major == 0 {
+		// 匿名设备：NFS、tmpfs、overlay
+		return 0.5
+	}
+	// 分区的 queue 在父设备下面
+	dev := fmt.Sprintf("/sys/dev/block/%d:%d", major, minor)
+	for _, p := range []string{filepath.Join(dev, "queue", "rotational"), filepath.Join(dev, "..", "queue", "rotational")} {
+		raw, err := os.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		if strings.TrimSpace(string(raw)) == "0" {
+			return 1
+		}
+		return 0
+	}
+	return 0.5
+}
+
+// nicScore 返回默认路由网卡空闲带宽的分数：按对数刻度 1Gbps 为 0、10Gbps 为 0.5、100Gbps 以上为 1，看不出来 0.5
+func nicScore(ctx context.Context) float64 {
+	iface := defaultRouteInterface()
+	if iface == "" {
+		return 0.5
+	}
+	speed, err := readSysInt(filepath.Join("/sys/class/net", iface, "speed"))
+	if err != nil || speed <= 0 {
+		return 0.5
+	}
+	freeMbps := float64(speed) - usedMbps(ctx, iface)
+	if freeMbps <= 1000 {
+		return 0
+	}
+	return min(math.Log10(freeMbps/1000)/2, 1)
+}
+
+// usedMbps 读两次网卡收发计数器，估算当前已用的带宽（收发取大的），读不到时返回 0
+func usedMbps(ctx context.Context, iface string) float64 {
+	counters := func() (rx, tx int64, err error) {
+		stats := filepath.Join("/sys/class/net", iface, "statistics")
+		if rx, err = readSysInt(filepath.Join(stats, "rx_bytes")); err != nil {
+			return 0, 0, err
+		}
+		tx, err = readSysInt(filepath.Join(stats, "tx_bytes"))
+		return rx, tx, err
+	}
+	rx0, tx0, err := counters()
+	if err != nil {
+		return 0
+	}
+	select {
+	case <-ctx.Done():
+		return 0
+	case <-time.After(nicSampleInterval):
+	}
+	rx1, tx1, err := counters()
+	if err != nil {
+		return 0
+	}
+	bytes := max(rx1-rx0, tx1-tx0, 0)
+	return float64(bytes) * 8 / 1e6 / nicSampleInterval.Seconds()
+}
+
+// defaultRouteInterface 从 /proc/net/route 找默认路由的网卡
+func defaultRouteInterface() string {
+	f, err := os.Open("/proc/net/route")
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 1 && fields[1] == "00000000" {
+			return fields[0]
+		}
+	}
+	return ""
+}
+
+func readSysInt(path string) (int64, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(raw)), 10, 64)
+}
@@ -0,0 +1,55 @@
+package coordinator
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWeightedTakeoverDelay(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	identities := []string{"qwen-7d9f-a", "qwen-7d9f-b", "qwen-7d9f-c", "qwen-7d9f-d"}
+
+	// 偏好高的 pod 不管哈希是多少，都比偏好低的先醒来
+	for _, preferred := range identities {
+		fast := NewLeaseManagerForIdentity(client, "default", "qwen-cache-lease", preferred).WithPreference(0.9)
+		for _, other := range identities {
+			slow := NewLeaseManagerForIdentity(client, "default", "qwen-cache-lease", other).WithPreference(0.6)
+			if fast.takeoverDelay() >= slow.takeoverDelay() {
+				t.Errorf("%s (0.9) waits %v, not less than %s (0.6) with %v", preferred, fast.takeoverDelay(), other, slow.takeoverDelay())
+			}
+		}
+	}
+
+	// 偏好一样时仍按哈希错开，最多多等 2.25 个 retryPeriod
+	seen := map[string]bool{}
+	for _, identity := range identities {
+		lm := NewLeaseManagerForIdentity(client, "default", "qwen-cache-lease", identity).WithPreference(0)
+		d := lm.takeoverDelay()
+		if d < 2*lm.retryPeriod || d >= 2*lm.retryPeriod+lm.retryPeriod/4 {
+			t.Errorf("%s: takeover delay %v outside [2, 2.25) retry periods", identity, d)
+		}
+		seen[d.String()] = true
+	}
+	if len(seen) < 2 {
+		t.Error("pods with the same preference should not all wait the same time")
+	}
+}
+
+func TestCandidacyLabel(t *testing.T) {
+	node := func(name, value string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{SeedPreferredLabel: value}}}
+	}
+	client := fake.NewSimpleClientset(node("gpu-nvme", "true"), node("gpu-hdd", "false"))
+	ctx := context.Background()
+
+	if p := Candidacy(ctx, client, "gpu-nvme", t.TempDir()); p != 1 {
+		t.Errorf("seed-preferred=true: preference = %v, want 1", p)
+	}
+	if p := Candidacy(ctx, client, "gpu-hdd", t.TempDir()); p != 0 {
+		t.Errorf("seed-preferred=false: preference = %v, want 0", p)
+	}
+}
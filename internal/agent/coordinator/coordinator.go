@@ -2,71 +2,485 @@ package coordinator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"time"
 
-	"github.com/Moore-Z/kubeinfer/internal/agent/vllm"
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+	"github.com/Moore-Z/kubeinfer/internal/agent/bandwidth"
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+	"github.com/Moore-Z/kubeinfer/internal/agent/follower"
+	"github.com/Moore-Z/kubeinfer/internal/agent/hfhub"
+	"github.com/Moore-Z/kubeinfer/internal/agent/modelregistry"
+	"github.com/Moore-Z/kubeinfer/internal/agent/runtime"
+	"github.com/Moore-Z/kubeinfer/internal/agent/snapshot"
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
 )
 
+// CompleteMarker 是模型完整下载后写入的标记文件
+//
+// 为什么不能用"目录非空"判断？
+// - follower 下载到一半被提升为 coordinator 时，目录里只有部分文件
+// - coordinator 下载到一半重启时也一样
+// 只有看到这个标记才说明本地有完整副本。
+const CompleteMarker = ".kubeinfer-complete"
+
 type Coordinator struct {
+	// modelPath 是下载目录：flat 布局是 MODEL_PATH，versioned 布局是 prepareRevision 选出的 revision 目录
 	modelPath   string
 	modelServer *ModelServer
+
+	// layout 是模型目录的布局（MODEL_LAYOUT，见 distribution.Layout）
+	layout distribution.Layout
+	// listed 是 prepareRevision 列出的仓库文件，downloadModel 不用再列一次
+	listed *hfhub.Repo
+	// revision 是上游确认的 revision（versioned 布局由 prepareRevision 设置），上游不可用时为空
+	revision string
+
+	// modelRepo 是 HuggingFace 仓库名（MODEL_REPO）
+	modelRepo string
+
+	// upstreamURL 是另一个集群的 model server（MODEL_UPSTREAM_URL），为空时从 HuggingFace 下载
+	upstreamURL string
+
+	// source 是列文件、下载的地方：MODEL_REGISTRY_URI 指定的 MLflow/W&B（internal/agent/modelregistry），
+	// 否则是 HuggingFace Hub
+	source hfhub.Source
+	// sourceErr 是 MODEL_REGISTRY_* 的配置错误，下载时返回（spec.model 只是名字，不能退回 HuggingFace）
+	sourceErr error
+
+	// verifier 是下载完成后的校验策略，nil 时按 MODEL_VERIFY_POLICY
+	verifier distribution.Verifier
+
+	// keepRevisions 是目录里保留的 revision 数（MODEL_KEEP_REVISIONS），见 distribution.SwitchRevision
+	keepRevisions int
+
+	// bandwidth 在上游下载和 model server 之间分配带宽（AGENT_BANDWIDTH_LIMIT），nil 表示不限速
+	bandwidth *bandwidth.Manager
+
+	// downloadGate 在从 HuggingFace 下载前排队等集群下载名额，nil 表示不排队
+	downloadGate DownloadGate
+
+	// snapshots 是模型快照所在的对象存储（MODEL_SNAPSHOT_URL），nil 表示不用快照
+	snapshots *snapshot.Store
+
+	// stub 为 true 时不下载模型，生成占位文件（INFERENCE_RUNTIME=stub，见 stub.go）
+	stub bool
+
+	// serveModels 为 false 时 Run 不启动 model server（MODEL_SERVER_ENABLED=false，只有一个副本）
+	serveModels bool
+
+	// licenseAccepted 为 true 时才下载 gated 模型（MODEL_LICENSE_ACCEPTED，来自 spec.modelSource.acceptLicense）
+	licenseAccepted bool
+
+	// previousURL 是上一个 coordinator 的 model server，handoff 时从它补齐副本，为空时不补（见 handoff.go）
+	previousURL string
+	// onServing 在副本准备好、model server 启动之前调用，可以为 nil
+	onServing func()
 }
 
+// DownloadGate 阻塞到可以开始从上游下载，返回下载结束后调用的 release
+//
+// operator 限制了集群并发下载数时由 agent 注入（见 cmd/agent 的 downloadGate）
+type DownloadGate func(ctx context.Context) (release func(), err error)
+
 // NewCoordinator 创建新的 Coordinator
 func NewCoordinator(modelPath string) *Coordinator {
-	return &Coordinator{
+	c := &Coordinator{
 		modelPath:   modelPath,
 		modelServer: NewModelServer(modelPath),
+		layout:      distribution.LayoutFromEnv(modelPath),
+		modelRepo:   os.Getenv("MODEL_REPO"),
+		upstreamURL: os.Getenv("MODEL_UPSTREAM_URL"),
+		bandwidth:   bandwidth.FromEnv(),
+
+		keepRevisions: distribution.KeepRevisionsFromEnv(),
+		stub:          os.Getenv("INFERENCE_RUNTIME") == runtime.KindStub,
+		serveModels:   distribution.ModelServerEnabledFromEnv(),
+
+		licenseAccepted: hfhub.LicenseAcceptedFromEnv(),
 	}
+	c.modelServer.SetBandwidth(c.bandwidth)
+	c.modelServer.SetDrain(HandoffGrace, HandoffDrainTimeout)
+	snapshots, err := snapshot.FromEnv()
+	if err != nil {
+		log.Printf("⚠️  Model snapshots are disabled: %v", err)
+	}
+	c.snapshots = snapshots
+	c.source = hfhub.NewFromEnv()
+	if src, err := modelregistry.FromEnv(); err != nil {
+		c.sourceErr = err
+	} else if src != nil {
+		c.source = src
+	}
+	return c
+}
+
+// WithModelRepo 指定要下载的仓库，覆盖 MODEL_REPO（kubeinfer download --model）
+func (c *Coordinator) WithModelRepo(repo string) *Coordinator {
+	c.modelRepo = repo
+	return c
+}
+
+// WithKeepRevisions 指定保留的 revision 数，覆盖 MODEL_KEEP_REVISIONS（kubeinfer download --keep-revisions）
+func (c *Coordinator) WithKeepRevisions(n int) *Coordinator {
+	c.keepRevisions = n
+	return c
+}
+
+// WithTransfers 让 model server 统计正在下载的 follower（驱逐保护）
+func (c *Coordinator) WithTransfers(t *TransferTracker) *Coordinator {
+	c.modelServer.SetTransfers(t)
+	return c
+}
+
+// WithDownloadGate 指定从上游下载前的排队逻辑
+func (c *Coordinator) WithDownloadGate(gate DownloadGate) *Coordinator {
+	c.downloadGate = gate
+	return c
+}
+
+// WithVerifier 指定校验策略，覆盖 MODEL_VERIFY_POLICY（kubeinfer download --verify）
+func (c *Coordinator) WithVerifier(v distribution.Verifier) *Coordinator {
+	c.verifier = v
+	return c
 }
 
 // Run 运行 Coordinator 的主逻辑
 // 这是 Coordinator 的入口函数，会：
-// 1. 下载模型（如果不存在）
-// 2. 启动 HTTP 服务器
-// 3. 等待关闭信号
+// 0. 交接：校验本地副本，或者从上一个 coordinator 补齐（见 handoff.go）
+// 1. 启动 HTTP 服务器（先启动，下载过程中 follower 就可以拿已完成的文件）
+// 2. 下载模型（如果本地没有完整副本，从断点继续）
+// 3. 等待关闭信号，排空 model server 之后返回
 func (c *Coordinator) Run(ctx context.Context) error {
 	log.Println("🚀 Running as Coordinator")
 
+	// 出错返回时关掉 model server，重试时才能重新绑定端口
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// versioned 布局：先确定 revision，model server 从一开始就提供这个 revision 的目录
+	if err := c.prepareRevision(ctx); err != nil {
+		return err
+	}
+
+	// Step 0: 交接，发布自己之前先校验本地副本，或者从上一个 coordinator 补齐（见 handoff.go）
+	if err := c.handoff(ctx); err != nil {
+		return fmt.Errorf("failed to take over the model copy: %w", err)
+	}
+	if c.onServing != nil {
+		c.onServing()
+	}
+
+	// Step 1: 启动 HTTP 服务器（在 goroutine 中运行，不阻塞）
+	// 交接没能补齐时手里只有部分文件；先把已有的文件提供出去，其他 follower 不用干等。
+	// 停止时先排空，Run 等排空结束才返回
+	if c.serveModels {
+		served := make(chan struct{})
+		defer func() {
+			cancel()
+			<-served
+		}()
+		go func() {
+			defer close(served)
+			if err := c.modelServer.Start(ctx); err != nil {
+				log.Printf("❌ Model server failed: %v", err)
+			}
+		}()
+	}
+
 	// 很强的模型查找（有没有？如果没有下载）
-	if err := c.ensureModel(); err != nil {
+	if err := c.ensureModel(ctx); err != nil {
 		return fmt.Errorf("failed to ensure model: %w", err)
 	}
-	// Step 2: 启动 HTTP 服务器（在 goroutine 中运行，不阻塞）
-	go func() {
-		if err := c.modelServer.Start(); err != nil {
-			log.Fatalf("❌ Model server failed: %v", err)
-		}
-	}()
+	if err := c.activate(); err != nil {
+		return err
+	}
+	// 长轮询 GET /files 的 follower 马上看到完整标记
+	c.modelServer.Notify()
 
-	// vllm 启动
-	vllmConfig := vllm.LoadConfigFromEnv(c.modelPath)
-	vllmServer := vllm.NewServer(vllmConfig)
-	if err := vllmServer.Start(); err != nil {
-		return fmt.Errorf("failed to start vLLM: %w", err)
+	// 对象存储里还没有快照时在后台上传一份，不耽误推理服务启动
+	go c.ensureSnapshot(ctx)
+
+	// 推理服务启动
+	// 推理服务（vLLM/TGI/llama.cpp，由 INFERENCE_RUNTIME 决定）
+	server, err := runtime.LoadFromEnv(c.layout.Current())
+	if err != nil {
+		return err
+	}
+	if err := server.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", server.Name(), err)
 	}
 
+	// watchdog：生成卡住时标记 NotReady 并重启推理服务
+	go runtime.NewWatchdog(server, runtime.LoadWatchdogConfigFromEnv()).Run(ctx)
+
 	// 整个server 全部close
 	<-ctx.Done()
-	vllmServer.Stop()
+	server.Stop()
 
 	log.Println("🛑 Coordinator shutting down")
 	return nil
 }
 
+// Fetch 只下载模型，不启动 model server 和推理服务
+//
+// initContainer 模式下由 `kubeinfer download`（旧名 fetch-model）调用，model server 在单独的 sidecar 里运行
+func (c *Coordinator) Fetch(ctx context.Context) error {
+	if err := c.prepareRevision(ctx); err != nil {
+		return err
+	}
+	if err := c.ensureModel(ctx); err != nil {
+		return err
+	}
+	return c.activate()
+}
+
+// prepareRevision 在 versioned 布局下确定要提供的 revision，把下载目录和 model server 切到它的目录
+//
+// revision 以上游为准：fleet 的 hub 集群，或者 HuggingFace 上的最新 commit（列出的文件留给 downloadModel）。
+// 上游不可用时沿用 current 指向的 revision，离线重启不会重新下载；都没有时用 main
+func (c *Coordinator) prepareRevision(ctx context.Context) error {
+	if !c.layout.Versioned {
+		return nil
+	}
+	if c.modelRepo == "" {
+		return fmt.Errorf("MODEL_REPO is required for the %s model layout", distribution.LayoutVersioned)
+	}
+	c.revision = c.upstreamRevision(ctx)
+	revision := c.revision
+	if revision == "" {
+		revision = c.layout.CurrentRevisionOf(c.modelRepo)
+	}
+	return c.useRevision(revision)
+}
+
+// upstreamRevision 返回上游的 revision，上游不可用时返回空
+func (c *Coordinator) upstreamRevision(ctx context.Context) string {
+	if c.stub {
+		return ""
+	}
+	if c.upstreamURL != "" {
+		revision, err := follower.RemoteRevision(ctx, c.upstreamURL)
+		if err != nil {
+			log.Printf("⚠️  Cannot get the revision from upstream %s: %v", c.upstreamURL, err)
+		}
+		return revision
+	}
+	if c.sourceErr != nil {
+		return ""
+	}
+	repo, err := c.source.ListFiles(ctx, c.modelRepo)
+	if err != nil {
+		log.Printf("⚠️  Cannot get the latest revision of %s: %v", c.modelRepo, err)
+		return ""
+	}
+	c.listed = repo
+	return repo.Revision
+}
+
+// useRevision 把下载目录和 model server 切到 revision 的目录
+func (c *Coordinator) useRevision(revision string) error {
+	dir := c.layout.Dir(c.modelRepo, revision)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return agenterr.Classify(fmt.Errorf("failed to create model directory: %w", err))
+	}
+	c.modelPath = dir
+	c.modelServer.SetDir(dir, filepath.Base(dir))
+	log.Printf("🗂️  Using revision %s of %s (%s)", filepath.Base(dir), c.modelRepo, dir)
+	return nil
+}
+
+// activate 在 versioned 布局下把 current 切到下载完成的 revision，并清理旧 revision
+//
+// 之后启动的推理服务读新 revision；旧 revision 留在磁盘上（最多 keepRevisions 个），切回去时不用下载。
+// GC 失败只打日志
+func (c *Coordinator) activate() error {
+	if !c.layout.Versioned {
+		return nil
+	}
+	if err := c.layout.Activate(c.modelPath); err != nil {
+		return agenterr.Classify(fmt.Errorf("failed to activate %s: %w", c.modelPath, err))
+	}
+	if res, err := c.layout.GCRevisions(c.keepRevisions); err != nil {
+		log.Printf("⚠️  Failed to clean up old revisions: %v", err)
+	} else if len(res.Removed)+len(res.InUse) > 0 {
+		log.Printf("🧹 %s", res)
+	}
+	return nil
+}
+
 // ensureModel 确保模型存在
-// 如果模型已存在，跳过下载；否则下载
-func (c *Coordinator) ensureModel() error {
-	if c.modelExists(c.modelPath) {
+// 如果本地已有完整副本，跳过下载；否则下载（状态文件里记录过的文件会被跳过）
+func (c *Coordinator) ensureModel(ctx context.Context) error {
+	// 目录里是别的模型（复用的 hostPath/PVC、预热过别的模型的目录）：归档或者等下载完清理
+	// （versioned 布局每个 revision 一个目录，不需要）
+	if c.modelRepo != "" && !c.layout.Versioned {
+		switched, err := distribution.SwitchRevision(c.modelPath, c.modelRepo, "", c.keepRevisions)
+		if err != nil {
+			log.Printf("⚠️  Failed to set aside the previous model: %v", err)
+		}
+		if switched {
+			log.Printf("🔄 %s holds another model, switching to %s", c.modelPath, c.modelRepo)
+			if err := os.Remove(filepath.Join(c.modelPath, CompleteMarker)); err != nil && !os.IsNotExist(err) {
+				return agenterr.Classify(err)
+			}
+		}
+	}
+
+	if ModelComplete(c.modelPath) {
 		log.Println("✅ Model already exists, skipping download")
 		return nil
 	}
-	// 模型不存在，需要下载
-	log.Println("📥 Model not found, starting download...")
-	return c.downloadModel()
+	if c.modelExists(c.modelPath) {
+		log.Println("📥 Found partial model copy, resuming download...")
+	} else {
+		log.Println("📥 Model not found, starting download...")
+	}
+
+	verifier, err := c.modelVerifier()
+	if err != nil {
+		return err
+	}
+
+	// 下载期间 model server 只能用一部分带宽，下载结束后全部还给 model server
+	c.bandwidth.StartUpstream()
+	defer c.bandwidth.FinishUpstream()
+
+	// fleet 模式：先从 hub 集群复制，跨集群的内网流量比每个集群各自从公网下载便宜
+	// （Sync 和 follower 一样会按 verifier 校验）
+	if c.upstreamURL != "" {
+		log.Printf("🌐 Replicating model from upstream %s", c.upstreamURL)
+		err := follower.NewFollowerFromURL(c.upstreamURL, c.modelPath).WithBandwidth(c.bandwidth).Sync(ctx)
+		if err == nil {
+			return MarkComplete(c.modelPath)
+		}
+		if ctx.Err() != nil || agenterr.Kind(err) == agenterr.ErrDiskFull {
+			return err
+		}
+		log.Printf("⚠️  Upstream replication failed: %v, falling back to HuggingFace", err)
+	}
+
+	// 对象存储里有快照时优先恢复：不受 HuggingFace 限流、仓库被删或改成 gated 的影响
+	if c.snapshots != nil && c.modelRepo != "" {
+		err := c.restoreSnapshot(ctx, verifier)
+		if err == nil {
+			return MarkComplete(c.modelPath)
+		}
+		if ctx.Err() != nil || agenterr.Kind(err) == agenterr.ErrDiskFull {
+			return err
+		}
+		if !errors.Is(err, snapshot.ErrNotFound) {
+			log.Printf("⚠️  Snapshot restore failed: %v, falling back to HuggingFace", err)
+		}
+	}
+
+	// 集群下载排队：很多 LLMService 同时下载会触发 HuggingFace 的限流
+	// （从 fleet 的上游集群复制不算，走的是内网）
+	if c.downloadGate != nil {
+		release, err := c.downloadGate(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	download := c.downloadModel
+	if c.stub {
+		download = c.downloadStubModel
+	}
+	if err := download(ctx); err != nil {
+		return err
+	}
+
+	// 生成清单并校验，follower 同步完成后用同一个 verifier 校验
+	if err := verifier.Seal(c.modelPath); err != nil {
+		return fmt.Errorf("failed to write model manifest: %w", err)
+	}
+	if err := verifier.Verify(c.modelPath); err != nil {
+		return err
+	}
+	log.Printf("🔏 Model verified (%s)", verifier.Name())
+	return MarkComplete(c.modelPath)
+}
+
+// modelVerifier 返回校验策略：WithVerifier 指定的，或者按 MODEL_VERIFY_POLICY
+func (c *Coordinator) modelVerifier() (distribution.Verifier, error) {
+	if c.verifier != nil {
+		return c.verifier, nil
+	}
+	return distribution.FromEnv()
+}
+
+// restoreSnapshot 从对象存储恢复上游当前 revision 的快照并校验
+//
+// 上游有了新 revision 时没有对应的快照，返回 snapshot.ErrNotFound，照常从上游下载。
+// 上游不可用时不知道当前 revision，恢复最近上传的一份（versioned 布局切到它的目录）
+func (c *Coordinator) restoreSnapshot(ctx context.Context, verifier distribution.Verifier) error {
+	revision := c.revision
+	if revision == "" && !c.layout.Versioned {
+		revision = c.upstreamRevision(ctx)
+	}
+	if revision == "" {
+		latest, err := c.snapshots.Latest(ctx, c.modelRepo)
+		if err != nil {
+			return err
+		}
+		log.Printf("🪣 Upstream revision of %s is unknown, using the latest snapshot (%s)", c.modelRepo, latest)
+		revision = latest
+		if c.layout.Versioned && filepath.Base(c.modelPath) != revision {
+			if err := c.useRevision(revision); err != nil {
+				return err
+			}
+		}
+	}
+	if err := c.snapshots.Restore(ctx, c.modelPath, c.modelRepo, revision); err != nil {
+		return err
+	}
+	if err := verifier.Verify(c.modelPath); err != nil {
+		return fmt.Errorf("restored snapshot failed verification: %w", err)
+	}
+	// 记下 revision：下一次启动判断仓库有没有更新、上传快照都按它
+	if err := distribution.LoadState(c.modelPath, c.modelRepo, revision).Save(c.modelPath); err != nil {
+		return err
+	}
+	log.Printf("🪣 Model restored from the snapshot of revision %s (%s)", revision, verifier.Name())
+	return nil
+}
+
+// ensureSnapshot 对象存储里还没有本地这个 revision 的快照时上传一份
+//
+// 在 ensureModel 之后调用，本地是校验过的完整副本。失败只打日志，下一次当选时再试。
+// 不知道本地是哪个 revision（huggingface-cli 整仓库下载）时不上传
+func (c *Coordinator) ensureSnapshot(ctx context.Context) {
+	if c.snapshots == nil || c.snapshots.ReadOnly || c.modelRepo == "" {
+		return
+	}
+	revision := distribution.StateRevision(c.modelPath)
+	if revision == "" {
+		log.Printf("⚠️  Revision of the local copy of %s is unknown, not uploading a snapshot", c.modelRepo)
+		return
+	}
+	exists, err := c.snapshots.Exists(ctx, c.modelRepo, revision)
+	if err != nil {
+		log.Printf("⚠️  Failed to check the model snapshot: %v", err)
+		return
+	}
+	if exists {
+		return
+	}
+	log.Printf("🪣 Uploading a snapshot of %s@%s", c.modelRepo, revision)
+	if err := c.snapshots.Upload(ctx, c.modelPath, c.modelRepo, revision); err != nil {
+		if ctx.Err() == nil {
+			log.Printf("⚠️  Failed to upload the model snapshot: %v", err)
+		}
+		return
+	}
+	log.Printf("✅ Snapshot of %s@%s uploaded", c.modelRepo, revision)
 }
 
 // modelExists 检查模型目录是否有文件
@@ -79,38 +493,221 @@ func (c *Coordinator) modelExists(modelPath string) bool {
 	return len(files) > 0
 }
 
-// downloadModel 从 HuggingFace 下载模型
-func (c *Coordinator) downloadModel() error {
-	// 从环境变量获取模型仓库名称
-	modelRepo := os.Getenv("MODEL_REPO")
+// ModelComplete 检查本地是否有完整的模型副本
+func ModelComplete(modelPath string) bool {
+	_, err := os.Stat(filepath.Join(modelPath, CompleteMarker))
+	return err == nil
+}
+
+// MarkComplete 写入完整标记
+func MarkComplete(modelPath string) error {
+	if err := os.WriteFile(filepath.Join(modelPath, CompleteMarker), nil, 0644); err != nil {
+		return agenterr.Classify(fmt.Errorf("failed to write complete marker: %w", err))
+	}
+	return nil
+}
+
+// ResetModel 删除本地模型目录里的所有文件（包括完整标记）
+//
+// 模型文件损坏（agenterr.ErrModelCorrupt）时调用，之后重新下载。
+// 目录本身是挂载点，不能删，只删里面的内容。
+func ResetModel(modelPath string) error {
+	entries, err := os.ReadDir(modelPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		if err := os.RemoveAll(filepath.Join(modelPath, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+// downloadModel 从 HuggingFace 下载模型
+//
+// 先通过 Hub API 列出文件，用 hfhub.Downloader 并行下载（大文件分段多连接），
+// 每个文件完成后记到状态文件（distribution.StateFile）里：
+// coordinator 下载到一半重启，只需要下载还没记过的文件。
+// 列文件失败（比如镜像站不支持 API）时退回整仓库下载，由 huggingface-cli 自己跳过已有文件。
+// gated 模型要用户在 spec.modelSource.acceptLicense 里确认过许可协议才下载。
+// 设置了 spec.modelSource.registry 时换成从 MLflow/W&B 列文件、下载，其余流程一样。
+func (c *Coordinator) downloadModel(ctx context.Context) (err error) {
+	modelRepo := c.modelRepo
 	if modelRepo == "" {
 		return fmt.Errorf("MODEL_REPO environment variable not set")
 	}
 
 	log.Printf("📦 Downloading model: %s to %s", modelRepo, c.modelPath)
+	start := time.Now()
+	defer func() {
+		status := "success"
+		if err != nil {
+			status = "failed"
+		}
+		metrics.RecordModelDownload(modelRepo, status, time.Since(start).Seconds())
+	}()
 
 	if err := os.MkdirAll(c.modelPath, 0755); err != nil {
-		return fmt.Errorf("failed to create model directory: %w", err)
+		return agenterr.Classify(fmt.Errorf("failed to create model directory: %w", err))
+	}
+
+	if c.sourceErr != nil {
+		return c.sourceErr
+	}
+	hub, isHub := c.source.(*hfhub.Client)
+	repo := c.listed
+	if repo == nil {
+		repo, err = c.source.ListFiles(ctx, modelRepo)
+	}
+	if err != nil {
+		// 模型仓库没有 huggingface-cli 可以退回，等下一次重试
+		if !isHub || !agenterr.Retryable(err) {
+			return err
+		}
+		log.Printf("⚠️  Cannot list files of %s: %v, downloading the whole repository", modelRepo, err)
+		if err := c.runHuggingFaceCLI(ctx, modelRepo); err != nil {
+			return err
+		}
+		log.Println("✅ Model download completed")
+		return nil
+	}
+
+	// 只有 HuggingFace 的仓库有 gated
+	if repo.Gated != "" && !c.licenseAccepted {
+		// 用户没确认过许可协议就不下载，即使 token 对应的账号已经接受过
+		return agenterr.Wrap(agenterr.ErrLicenseNotAccepted, fmt.Errorf(
+			"%s is a gated model (%s approval): accept its license at %s/%s and set spec.modelSource.acceptLicense=true",
+			modelRepo, repo.Gated, hub.Endpoint, modelRepo))
+	}
+
+	if c.layout.Versioned {
+		// 目录是上游不可用时选的（current 的 revision 或者 main），换到实际下载的 revision
+		if filepath.Base(c.modelPath) != repo.Revision {
+			if err := c.useRevision(repo.Revision); err != nil {
+				return err
+			}
+		}
+	} else {
+		// 仓库更新了：旧 revision 归档（或者下载完清理），之前归档过这个 revision 就搬回来
+		if switched, err := distribution.SwitchRevision(c.modelPath, modelRepo, repo.Revision, c.keepRevisions); err != nil {
+			log.Printf("⚠️  Failed to set aside the previous revision: %v", err)
+		} else if switched {
+			log.Printf("🔄 %s has a new revision %s", modelRepo, repo.Revision)
+		}
+	}
+
+	state := distribution.LoadState(c.modelPath, modelRepo, repo.Revision)
+	pending := state.Pending(c.modelPath, repo.Files)
+	if done := len(repo.Files) - len(pending); done > 0 {
+		log.Printf("📥 Resuming download: %d/%d files already complete", done, len(repo.Files))
+	}
+	if c.layout.Versioned {
+		if pending, err = c.reuseLocalFiles(state, pending); err != nil {
+			return err
+		}
+	}
+
+	// 公布完整的文件列表，每个文件下载完、校验过就可以给 follower（见 readiness.go）
+	c.modelServer.SetPlan(repo.Files, pending)
+	defer c.modelServer.ClearPlan()
+
+	downloader := hfhub.NewDownloader(c.source)
+	downloader.Bandwidth = c.bandwidth
+	log.Printf("📥 Downloading %d files with %d connections (%d MiB chunks)",
+		len(pending), downloader.Concurrency, downloader.ChunkSize>>20)
+	completed := 0
+	// 固定 revision：下载过程中仓库更新也不会拿到不同版本的文件
+	err = downloader.Download(ctx, modelRepo, repo.Revision, c.modelPath, pending, func(f distribution.RemoteFile) error {
+		if err := state.Record(c.modelPath, f); err != nil {
+			// 下载下来的文件和上游对不上，删掉这个文件，重试时重新下载
+			_ = os.Remove(filepath.Join(c.modelPath, f.Name))
+			return err
+		}
+		completed++
+		log.Printf("📥 [%d/%d] %s (%d bytes)", completed, len(pending), f.Name, f.Size)
+		c.modelServer.MarkReady(f.Name)
+		return state.Save(c.modelPath)
+	})
+	if err != nil {
+		return err
+	}
+
+	// 清单按目录里的文件生成，先删掉不属于这个 revision 的文件
+	names := make([]string, len(repo.Files))
+	for i, f := range repo.Files {
+		names[i] = f.Name
+	}
+	if res, err := distribution.Prune(c.modelPath, names); err != nil {
+		// 只是多占点磁盘，不影响服务
+		log.Printf("⚠️  Failed to clean up stale model files: %v", err)
+	} else if len(res.Removed)+len(res.InUse) > 0 {
+		log.Printf("🧹 %s", res)
 	}
 
-	// 调用 huggingface-cli 下载模型
-	// 命令格式：huggingface-cli download <repo> --local-dir <path>
-	cmd := exec.Command(
-		"huggingface-cli",
-		"download",
-		modelRepo,
+	log.Println("✅ Model download completed")
+	return nil
+}
+
+// reuseLocalFiles 把 LFS sha256 和本地旧 revision 对得上的文件硬链接过来，返回还需要下载的文件
+//
+// 仓库更新往往只改了几个文件；非 LFS 的小文件（config.json 之类）上游不给 sha256，照常下载
+func (c *Coordinator) reuseLocalFiles(state *distribution.State, pending []distribution.RemoteFile) ([]distribution.RemoteFile, error) {
+	local := c.layout.LocalFiles(c.modelPath)
+	if len(local) == 0 {
+		return pending, nil
+	}
+	var rest []distribution.RemoteFile
+	var reused distribution.ReuseResult
+	for _, f := range pending {
+		if f.SHA256 != "" && local.Link(f.SHA256, f.Size, filepath.Join(c.modelPath, f.Name)) {
+			state.Adopt(f.Name, f.Size, f.SHA256)
+			reused.Add(f.Size)
+			continue
+		}
+		rest = append(rest, f)
+	}
+	if reused.Files == 0 {
+		return pending, nil
+	}
+	log.Printf("♻️  %s", reused)
+	return rest, state.Save(c.modelPath)
+}
+
+// runHuggingFaceCLI 调用 huggingface-cli 下载
+// 命令格式：huggingface-cli download <repo> [文件...] --local-dir <path>
+func (c *Coordinator) runHuggingFaceCLI(ctx context.Context, modelRepo string, args ...string) error {
+	cmdArgs := append([]string{"download", modelRepo}, args...)
+	cmdArgs = append(cmdArgs,
 		"--local-dir", c.modelPath,
 		"--local-dir-use-symlinks", "False", // 不使用符号链接，直接复制文件
 	)
+	cmd := exec.CommandContext(ctx, "huggingface-cli", cmdArgs...)
 	// 将命令的输出连接到标准输出/错误，这样可以看到下载进度
+	// 同时保留 stderr 的末尾，失败时据此判断是 token 问题、磁盘满还是网络问题
+	tail := &tailBuffer{max: 16 * 1024}
 	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stderr = io.MultiWriter(os.Stderr, tail)
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("download failed: %w", err)
+		return agenterr.FromOutput(string(tail.buf), fmt.Errorf("download failed: %w", err))
 	}
-
-	log.Println("✅ Model download completed")
 	return nil
 }
+
+// tailBuffer 只保留最后 max 字节的输出
+type tailBuffer struct {
+	max int
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = t.buf[len(t.buf)-t.max:]
+	}
+	return len(p), nil
+}
@@ -2,18 +2,35 @@ package coordinator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"sync"
 	"time"
 
 	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	coordinationv1client "k8s.io/client-go/kubernetes/typed/coordination/v1"
 	"k8s.io/klog/v2"
+	"k8s.io/utils/clock"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/faults"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
 )
 
+// errLeaseRenewalDropped 是故障注入丢弃续约时返回的错误
+var errLeaseRenewalDropped = errors.New("lease renewal dropped by fault injection")
+
+// 避免所有 follower 同时请求 API server（anti-herd）：
+//   - 每次重试的间隔加上最多 retryJitter 比例的随机时间，同时启动的 Pod 不会一直踩在同一个 tick 上
+//   - 抢 lease 冲突（Conflict/AlreadyExists，别人先抢到了）后指数退避，最多退到 lease 有效期的一半
+//   - lease 过期后按 identity 的哈希（和候选偏好，见 candidacy.go）错开一段时间再接管，大家不会在同一时刻一起抢
+const retryJitter = 0.2
+
 type LeaseManager struct {
 	client        coordinationv1client.CoordinationV1Interface // K8s client
 	leaseName     string                                       // lease 名称
@@ -25,23 +42,80 @@ type LeaseManager struct {
 
 	mu       sync.RWMutex // 读写锁，保护 isLeader 字段
 	isLeader bool         // 当前是否是 leader
+
+	failures  int // 连续失败的选举操作次数，只在 Run 里读写
+	conflicts int // 连续抢 lease 冲突的次数，只在 Run 里读写
+
+	clock clock.Clock // 所有时间都从这里取，测试时换成 FakeClock
+
+	// preference 是当 coordinator 的偏好值（WithPreference），nil 时接管顺序只按 identity 的哈希
+	preference *float64
+
+	// observedRecord 是上一次看到的（持有者, renewTime），observedTime 是本地时钟上看到它变化的时间。
+	// 判断过期以本地时钟为准，见 isLeaseExpired
+	observedMu     sync.Mutex
+	observedRecord string
+	observedTime   time.Time
 }
 
-func NewLeaseManager(clientset *kubernetes.Clientset, namespace, leaseName string) (*LeaseManager, error) {
+func NewLeaseManager(clientset kubernetes.Interface, namespace, leaseName string) (*LeaseManager, error) {
 
 	podName := os.Getenv("POD_NAME")
 	if podName == "" {
 		return nil, fmt.Errorf("POD_NAME environment variable not set")
 	}
+	lm := NewLeaseManagerForIdentity(clientset, namespace, leaseName, podName)
+
+	// 选举参数由 operator 配置（lease.duration / lease.retryPeriod）决定，没有设置时用默认值
+	if d, err := durationEnv("AGENT_LEASE_DURATION"); err != nil {
+		return nil, err
+	} else if d > 0 {
+		lm.leaseDuration = d
+	}
+	if d, err := durationEnv("AGENT_LEASE_RETRY_PERIOD"); err != nil {
+		return nil, err
+	} else if d > 0 {
+		lm.retryPeriod = d
+	}
+	if lm.retryPeriod >= lm.leaseDuration {
+		return nil, fmt.Errorf("lease retry period %s must be shorter than lease duration %s", lm.retryPeriod, lm.leaseDuration)
+	}
+	return lm, nil
+}
+
+// durationEnv 读取时长类型的环境变量，没有设置时返回 0
+func durationEnv(name string) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return d, nil
+}
+
+// NewLeaseManagerForIdentity 用指定的身份参与选举
+//
+// 同一个进程里模拟多个 pod（test/chaos 的故障注入测试）时用它，正常运行用 NewLeaseManager
+func NewLeaseManagerForIdentity(clientset kubernetes.Interface, namespace, leaseName, identity string) *LeaseManager {
 	return &LeaseManager{
 		client:        clientset.CoordinationV1(),
 		leaseName:     leaseName,
 		namespace:     namespace,
-		identity:      podName,
+		identity:      identity,
 		leaseDuration: 15 * time.Second,
 		renewDuration: 10 * time.Second,
 		retryPeriod:   2 * time.Second,
-	}, nil
+		clock:         clock.RealClock{},
+	}
+}
+
+// WithClock 替换选举用的时钟，测试里用 k8s.io/utils/clock/testing.FakeClock 模拟时间流逝
+func (lm *LeaseManager) WithClock(c clock.Clock) *LeaseManager {
+	lm.clock = c
+	return lm
 }
 
 func (lm *LeaseManager) TryAcquireOrRenew(ctx context.Context) (bool, error) {
@@ -51,29 +125,71 @@ func (lm *LeaseManager) TryAcquireOrRenew(ctx context.Context) (bool, error) {
 	// No Lease
 	if err != nil {
 		klog.Infof("Lease 不存在，尝试创建新的 lease")
+		// 第一次部署时所有 pod 同时启动，同样错开
+		if err := lm.sleepCtx(ctx, lm.takeoverDelay()); err != nil {
+			return false, err
+		}
 		return lm.createLease(ctx)
 	}
 
+	lm.recordLeadershipAge(lease)
+
 	// Lease 存在，检查是否由当前 pod 持有, ml.identity
 	if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity == lm.identity {
 		klog.V(4).Infof("当前 pod 是 coordinator,续约 lease")
 		return lm.renewLease(ctx, lease)
 	}
+	// 被 controller 强制让出（节点 NotReady）：节点恢复之前不再参与选举，不然 lease 会在两个 Pod 之间来回切换
+	if lease.Annotations[cacheinfo.EvictedCoordinatorAnnotation] == lm.identity {
+		klog.V(4).Infof("当前 pod 被 controller 取消了 coordinator 资格，不参与选举")
+		return false, nil
+	}
+	// 人工固定的 coordinator（spec.coordination.pinnedPod）：不等过期，直接接管
+	pinned := lease.Annotations[cacheinfo.PinnedCoordinatorAnnotation]
+	if pinned == lm.identity {
+		klog.Infof("Lease 被固定到当前 pod，从 %s 接管", holderName(lease))
+		return lm.acquireLease(ctx, lease)
+	}
 	// Lease 由其他 pod 持有，检查是否过期
 	if lm.isLeaseExpired(lease) {
-		klog.Infof("检测到 lease 已过期，尝试获取")
+		delay := lm.takeoverDelay()
+		if pinned != "" {
+			// 给被固定的 pod 让路，它一直不来（controller 还没发现它不可用）才接管
+			delay += lm.leaseDuration
+		}
+		klog.Infof("检测到 lease 已过期，%s 后尝试获取", delay)
+		if err := lm.sleepCtx(ctx, delay); err != nil {
+			return false, err
+		}
+		// 等待期间别的 pod 可能已经接管了
+		lease, err = leaseClient.Get(ctx, lm.leaseName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		if !lm.isLeaseExpired(lease) {
+			klog.V(4).Infof("Lease 已被其他 pod 接管")
+			return false, nil
+		}
 		return lm.acquireLease(ctx, lease)
 	}
 	klog.V(4).Infof("Lease 由其他 pod 持有: %s", *lease.Spec.HolderIdentity)
 	return false, nil
 }
 
+// holderName 返回 lease 的持有者，没有持有者时返回 "<none>"
+func holderName(lease *coordinationv1.Lease) string {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return "<none>"
+	}
+	return *lease.Spec.HolderIdentity
+}
+
 // createLease 创建新的 lease
 func (lm *LeaseManager) createLease(ctx context.Context) (bool, error) {
 	// 实现将在下一步添加
 	leaseClient := lm.client.Leases(lm.namespace)
 
-	now := metav1.NewMicroTime(time.Now())
+	now := metav1.NewMicroTime(lm.clock.Now())
 	leaseDurationSeconds := int32(lm.leaseDuration.Seconds()) // ✅ 第 75 行
 	holderIdentity := lm.identity
 
@@ -105,12 +221,18 @@ func (lm *LeaseManager) createLease(ctx context.Context) (bool, error) {
 
 // renewLease 续约现有的 lease
 func (lm *LeaseManager) renewLease(ctx context.Context, lease *coordinationv1.Lease) (bool, error) {
+	// 故障注入：模拟和 API server 之间的网络分区，lease 会过期被其他 pod 接管
+	if faults.Hit(faults.DropLeaseRenewal) {
+		klog.Warningf("故障注入：丢弃 lease %s 的续约", lm.leaseName)
+		return false, errLeaseRenewalDropped
+	}
 
 	leaseClient := lm.client.Leases(lm.namespace)
 
-	now := metav1.NewMicroTime(time.Now())
+	now := metav1.NewMicroTime(lm.clock.Now())
 	lease.Spec.RenewTime = &now
 	_, err := leaseClient.Update(ctx, lease, metav1.UpdateOptions{})
+	metrics.RecordLeaseRenew(lm.namespace, lm.identity, err == nil, lm.clock.Since(now.Time).Seconds())
 	if err != nil {
 		klog.Errorf("续约 lease 失败: %v", err)
 		return false, err
@@ -124,7 +246,7 @@ func (lm *LeaseManager) acquireLease(ctx context.Context, lease *coordinationv1.
 	// 实现将在下一步添加
 	leaseClient := lm.client.Leases(lm.namespace)
 	// 更新 lease 的持有者为当前 pod
-	now := metav1.NewMicroTime(time.Now())
+	now := metav1.NewMicroTime(lm.clock.Now())
 	lease.Spec.HolderIdentity = &lm.identity
 	lease.Spec.AcquireTime = &now
 	lease.Spec.RenewTime = &now
@@ -141,19 +263,89 @@ func (lm *LeaseManager) acquireLease(ctx context.Context, lease *coordinationv1.
 }
 
 // isLeaseExpired 检查 lease 是否过期
+//
+// 不能直接拿 renewTime 和本地时间比：持有者所在节点的时钟慢了，follower 看到的 renewTime 总是很旧，
+// 会把一个正常续约的 lease 抢走；快了则持有者挂掉之后很久都不过期。
+// 所以和 client-go 的 leaderelection 一样，记住上一次看到的（持有者, renewTime），
+// 它在本地时钟上 leaseDuration 内没有变化才算过期。
+//
+// 刚启动的 pod 没有观察记录，renewTime 比本地时间早了 2×leaseDuration 以上（超过合理的时钟偏差）也直接算过期，
+// 不然整个 Deployment 重建后要多等一个 leaseDuration。有了观察记录之后只看本地时钟：
+// 本地时钟比持有者快了 2×leaseDuration 以上时，不能每次轮询都把正常续约的 lease 抢走。
+// renewTime 在 API server 里只保留到微秒，比较之前统一截断，本地写入的纳秒时间和读回来的才对得上
 func (lm *LeaseManager) isLeaseExpired(lease *coordinationv1.Lease) bool {
 	if lease.Spec.RenewTime == nil {
 		klog.Warningf("检测到异常 Lease (名称: %s)：缺少 RenewTime 字段，可能由其他程序创建", lm.leaseName)
 		return true
 	}
-	expirationTime := lease.Spec.RenewTime.Add(lm.leaseDuration)
-	expired := time.Now().After(expirationTime)
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		klog.V(4).Infof("Lease 没有持有者")
+		return true
+	}
+
+	now := lm.clock.Now()
+	renewTime := lease.Spec.RenewTime.Truncate(time.Microsecond)
+	record := *lease.Spec.HolderIdentity + "@" + renewTime.UTC().Format(time.RFC3339Nano)
+
+	lm.observedMu.Lock()
+	firstObservation := lm.observedRecord == ""
+	if record != lm.observedRecord {
+		lm.observedRecord = record
+		lm.observedTime = now
+	}
+	unchanged := now.Sub(lm.observedTime)
+	lm.observedMu.Unlock()
+
+	expired := unchanged >= lm.leaseDuration || (firstObservation && now.Sub(renewTime) > 2*lm.leaseDuration)
 	if expired {
-		klog.V(4).Infof("Lease 已过期，上次续约时间: %v", lease.Spec.RenewTime)
+		klog.V(4).Infof("Lease 已过期，上次续约时间: %v，%s 没有变化", lease.Spec.RenewTime, unchanged)
 	}
 	return expired
 }
 
+// recordLeadershipAge 记录当前持有者拿到 lease 多久了
+//
+// acquireTime 只在换持有者时更新（createLease/acquireLease），续约不变；被让出的 lease 没有持有者，不记录
+func (lm *LeaseManager) recordLeadershipAge(lease *coordinationv1.Lease) {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" || lease.Spec.AcquireTime == nil {
+		return
+	}
+	metrics.RecordLeadershipAge(lm.namespace, lm.identity, lm.clock.Since(lease.Spec.AcquireTime.Time).Seconds())
+}
+
+// nextDelay 返回下一次选举操作前的等待时间
+//
+// 冲突说明别的 pod 刚改过 lease，按 retryPeriod 翻倍退避；其他情况（包括网络错误）正常重试，
+// 持有者不能因为退避错过续约
+func (lm *LeaseManager) nextDelay(err error) time.Duration {
+	if apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) {
+		lm.conflicts++
+	} else {
+		lm.conflicts = 0
+	}
+	limit := max(lm.retryPeriod, lm.leaseDuration/2)
+	d := lm.retryPeriod
+	for i := 0; i < lm.conflicts && d < limit; i++ {
+		d *= 2
+	}
+	return wait.Jitter(min(d, limit), retryJitter)
+}
+
+// sleepCtx 等待 d，ctx 取消时提前返回
+func (lm *LeaseManager) sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := lm.clock.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (lm *LeaseManager) IsCoordinator() bool {
 	lm.mu.RLock()
 	defer lm.mu.RUnlock()
@@ -170,28 +362,48 @@ func (lm *LeaseManager) updateLeaderStatus(isLeader bool) {
 func (lm *LeaseManager) Run(ctx context.Context, onElected, onLost func()) {
 	klog.Info("LeaseManager 开始运行")
 
-	// 创建定时器
-	ticker := time.NewTicker(lm.retryPeriod)
-	defer ticker.Stop() // 函数退出时停止定时器
+	// 创建定时器，每次操作之后按 nextDelay 重新设置
+	timer := lm.clock.NewTimer(wait.Jitter(lm.retryPeriod, retryJitter))
+	defer timer.Stop() // 函数退出时停止定时器
+
+	// 第一次成功判断角色之前，pod 既不是 coordinator 也不是 follower；
+	// 如果第一次就没抢到，也要调用 onLost 让它作为 follower 启动
+	observed := false
 
 	// 主循环
 	for {
 		select {
-		case <-ticker.C:
+		case <-timer.C():
 			// 定时器触发：尝试获取或续约 lease
 			acquired, err := lm.TryAcquireOrRenew(ctx)
+			timer.Reset(lm.nextDelay(err))
 			if err != nil {
-				klog.Errorf("选举操作失败: %v", err)
+				lm.failures++
+				metrics.RecordLeaseFailures(lm.namespace, lm.identity, lm.failures)
+				klog.Errorf("选举操作失败（连续 %d 次）: %v", lm.failures, err)
 
 				// 更新状态为 follower
 				lm.updateLeaderStatus(false)
 				continue
 			}
 
+			if lm.failures > 0 {
+				lm.failures = 0
+				metrics.RecordLeaseFailures(lm.namespace, lm.identity, 0)
+			}
+
 			// 检查状态是否发生变化
 			wasLeader := lm.IsCoordinator() // 之前的状态
+			firstObservation := !observed
+			observed = true
 
-			if acquired && !wasLeader {
+			if !acquired && firstObservation {
+				// 初始角色：follower
+				klog.Info("初始角色: Follower")
+				if onLost != nil {
+					onLost()
+				}
+			} else if acquired && !wasLeader {
 				// 状态变化：follower → coordinator
 				klog.Info("角色变化: Follower → Coordinator")
 				lm.updateLeaderStatus(true) // 更新状态
@@ -223,3 +435,26 @@ func (lm *LeaseManager) Run(ctx context.Context, onElected, onLost func()) {
 		}
 	}
 }
+
+// Release 主动让出 lease（只在自己持有时）
+//
+// Pod 正常退出时调用：清空 HolderIdentity 和 RenewTime，
+// 其他 pod 下一次 TryAcquireOrRenew 就能接管，不用等 leaseDuration 过期
+func (lm *LeaseManager) Release(ctx context.Context) error {
+	leaseClient := lm.client.Leases(lm.namespace)
+	lease, err := leaseClient.Get(ctx, lm.leaseName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != lm.identity {
+		return nil
+	}
+
+	lease.Spec.HolderIdentity = nil
+	lease.Spec.RenewTime = nil
+	if _, err := leaseClient.Update(ctx, lease, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	klog.Infof("已让出 lease %s", lm.leaseName)
+	return nil
+}
@@ -0,0 +1,234 @@
+package coordinator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+func TestNextDelay(t *testing.T) {
+	lm := NewLeaseManagerForIdentity(fake.NewSimpleClientset(), "default", "qwen-cache-lease", "qwen-a")
+	conflict := apierrors.NewConflict(schema.GroupResource{Group: "coordination.k8s.io", Resource: "leases"}, "qwen-cache-lease", errors.New("modified"))
+	within := func(d, base time.Duration) bool {
+		return d >= base && d <= base+time.Duration(float64(base)*retryJitter)
+	}
+
+	if d := lm.nextDelay(nil); !within(d, 2*time.Second) {
+		t.Errorf("delay after success = %v, want 2s plus jitter", d)
+	}
+	// 连续冲突：2s → 4s → 上限 7.5s（lease 有效期的一半）
+	for i, want := range []time.Duration{4 * time.Second, 7500 * time.Millisecond, 7500 * time.Millisecond} {
+		if d := lm.nextDelay(conflict); !within(d, want) {
+			t.Errorf("delay after %d conflicts = %v, want %v plus jitter", i+1, d, want)
+		}
+	}
+	// 网络错误不退避，持有者要按时续约
+	if d := lm.nextDelay(errors.New("connection refused")); !within(d, 2*time.Second) {
+		t.Errorf("delay after a non-conflict error = %v, want 2s plus jitter", d)
+	}
+}
+
+func TestTakeoverDelay(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	seen := map[time.Duration]bool{}
+	for _, identity := range []string{"qwen-7d9f-a", "qwen-7d9f-b", "qwen-7d9f-c", "qwen-7d9f-d"} {
+		lm := NewLeaseManagerForIdentity(client, "default", "qwen-cache-lease", identity)
+		d := lm.takeoverDelay()
+		if d < 0 || d >= lm.retryPeriod {
+			t.Errorf("%s: takeover delay %v outside [0, %v)", identity, d, lm.retryPeriod)
+		}
+		if d != lm.takeoverDelay() {
+			t.Errorf("%s: takeover delay should be stable", identity)
+		}
+		seen[d] = true
+	}
+	if len(seen) < 2 {
+		t.Error("pods should not all wait the same time before taking over")
+	}
+}
+
+func TestIsLeaseExpired(t *testing.T) {
+	start := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	lease := func(holder string, renew *time.Time) *coordinationv1.Lease {
+		l := &coordinationv1.Lease{}
+		if holder != "" {
+			l.Spec.HolderIdentity = &holder
+		}
+		if renew != nil {
+			mt := metav1.NewMicroTime(*renew)
+			l.Spec.RenewTime = &mt
+		}
+		return l
+	}
+	at := func(d time.Duration) *time.Time {
+		ts := start.Add(d)
+		return &ts
+	}
+	// step 是过了 after 之后看到的 lease
+	type step struct {
+		after time.Duration
+		lease *coordinationv1.Lease
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+		want  bool
+	}{
+		{"missing renewTime", []step{{0, lease("qwen-a", nil)}}, true},
+		{"released lease without holder", []step{{0, lease("", at(0))}}, true},
+		{"just renewed", []step{{0, lease("qwen-a", at(-time.Second))}}, false},
+		{"stale beyond any clock skew", []step{{0, lease("qwen-a", at(-31*time.Second))}}, true},
+		{
+			// 持有者的时钟慢了 20s，renewTime 看起来已经超过 leaseDuration，但一直在变
+			name: "holder clock behind",
+			steps: []step{
+				{0, lease("qwen-a", at(-20*time.Second))},
+				{10 * time.Second, lease("qwen-a", at(-12*time.Second))},
+				{10 * time.Second, lease("qwen-a", at(-2*time.Second))},
+			},
+			want: false,
+		},
+		{
+			// 本地时钟比持有者快了 40s（超过 2×leaseDuration），有了观察记录之后 lease 一直在续约就不算过期
+			name: "local clock far ahead of a renewing holder",
+			steps: []step{
+				{0, lease("qwen-a", at(-40*time.Second))},
+				{10 * time.Second, lease("qwen-a", at(-30*time.Second))},
+				{10 * time.Second, lease("qwen-a", at(-20*time.Second))},
+			},
+			want: false,
+		},
+		{
+			// 持有者的时钟快了一小时，挂掉之后 renewTime 停在未来
+			name: "holder clock ahead and stopped renewing",
+			steps: []step{
+				{0, lease("qwen-a", at(time.Hour))},
+				{15 * time.Second, lease("qwen-a", at(time.Hour))},
+			},
+			want: true,
+		},
+		{
+			name: "holder clock ahead and still renewing",
+			steps: []step{
+				{0, lease("qwen-a", at(time.Hour))},
+				{10 * time.Second, lease("qwen-a", at(time.Hour+10*time.Second))},
+				{10 * time.Second, lease("qwen-a", at(time.Hour+20*time.Second))},
+			},
+			want: false,
+		},
+		{
+			// 本地写入时带纳秒，API server 读回来只有微秒：是同一次续约
+			name: "renewTime truncated to microseconds",
+			steps: []step{
+				{0, lease("qwen-a", at(-time.Second+123456789*time.Nanosecond))},
+				{15 * time.Second, lease("qwen-a", at(-time.Second+123456000*time.Nanosecond))},
+			},
+			want: true,
+		},
+		{
+			name: "same renewTime with a new holder",
+			steps: []step{
+				{0, lease("qwen-a", at(0))},
+				{14 * time.Second, lease("qwen-b", at(0))},
+				{2 * time.Second, lease("qwen-b", at(0))},
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		fc := clocktesting.NewFakeClock(start)
+		lm := NewLeaseManagerForIdentity(fake.NewSimpleClientset(), "default", "qwen-cache-lease", "qwen-c").WithClock(fc)
+		var got bool
+		for _, s := range tt.steps {
+			fc.Step(s.after)
+			got = lm.isLeaseExpired(s.lease)
+		}
+		if got != tt.want {
+			t.Errorf("%s: isLeaseExpired() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPinnedCoordinator(t *testing.T) {
+	start := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	holder, renew := "qwen-a", metav1.NewMicroTime(start)
+	client := fake.NewSimpleClientset(&coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "qwen-cache-lease",
+			Namespace:   "default",
+			Annotations: map[string]string{cacheinfo.PinnedCoordinatorAnnotation: "qwen-b"},
+		},
+		Spec: coordinationv1.LeaseSpec{HolderIdentity: &holder, RenewTime: &renew},
+	})
+	ctx := context.Background()
+	newLM := func(identity string) *LeaseManager {
+		return NewLeaseManagerForIdentity(client, "default", "qwen-cache-lease", identity).WithClock(clocktesting.NewFakeClock(start))
+	}
+
+	// 其他 pod 不会去抢一个正常续约的 lease
+	if acquired, err := newLM("qwen-c").TryAcquireOrRenew(ctx); err != nil || acquired {
+		t.Fatalf("unpinned pod: acquired = %v, err = %v", acquired, err)
+	}
+	// 被固定的 pod 不等过期直接接管
+	if acquired, err := newLM("qwen-b").TryAcquireOrRenew(ctx); err != nil || !acquired {
+		t.Fatalf("pinned pod: acquired = %v, err = %v", acquired, err)
+	}
+	// 原来的持有者下一次续约时发现换了人
+	if acquired, err := newLM("qwen-a").TryAcquireOrRenew(ctx); err != nil || acquired {
+		t.Fatalf("previous holder: acquired = %v, err = %v", acquired, err)
+	}
+	lease, err := client.CoordinationV1().Leases("default").Get(ctx, "qwen-cache-lease", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := holderName(lease); got != "qwen-b" {
+		t.Errorf("holder = %s, want the pinned pod qwen-b", got)
+	}
+	if lease.Annotations[cacheinfo.PinnedCoordinatorAnnotation] != "qwen-b" {
+		t.Error("taking over must keep the pin annotation")
+	}
+}
+
+func TestEvictedCoordinator(t *testing.T) {
+	// controller 因为节点 NotReady 强制让出了 qwen-a 的 lease
+	client := fake.NewSimpleClientset(&coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "qwen-cache-lease",
+			Namespace:   "default",
+			Annotations: map[string]string{cacheinfo.EvictedCoordinatorAnnotation: "qwen-a"},
+		},
+	})
+	ctx := context.Background()
+	newLM := func(identity string) *LeaseManager {
+		lm := NewLeaseManagerForIdentity(client, "default", "qwen-cache-lease", identity)
+		lm.retryPeriod = 0 // 不错开，测试不用等
+		return lm
+	}
+
+	// 容器还活着的旧持有者不能把 lease 抢回来
+	if acquired, err := newLM("qwen-a").TryAcquireOrRenew(ctx); err != nil || acquired {
+		t.Fatalf("evicted pod: acquired = %v, err = %v", acquired, err)
+	}
+	if acquired, err := newLM("qwen-b").TryAcquireOrRenew(ctx); err != nil || !acquired {
+		t.Fatalf("other pod: acquired = %v, err = %v", acquired, err)
+	}
+	lease, err := client.CoordinationV1().Leases("default").Get(ctx, "qwen-cache-lease", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := holderName(lease); got != "qwen-b" {
+		t.Errorf("holder = %s, want qwen-b", got)
+	}
+}
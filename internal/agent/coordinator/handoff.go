@@ -0,0 +1,163 @@
+package coordinator
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+	"github.com/Moore-Z/kubeinfer/internal/agent/follower"
+)
+
+// ============================================================================
+// 两阶段角色交接（handoff）
+// ============================================================================
+//
+// 以前 coordinator 换人时：
+//   - 新 coordinator 当选后马上打 label、启动 model server，本地副本可能不完整、也没校验过
+//   - 旧 coordinator 的角色一取消就 Close model server，正在下载的 follower 连接直接断掉
+//
+// 现在分两阶段：
+//  1. 新 coordinator 发布自己之前先准备副本（Coordinator.handoff）：
+//     - 本地有完整标记：按 verifier 校验清单，通过才算完整，不通过去掉完整标记
+//     - 不完整且知道上一个 coordinator：趁它还在排空，从它的 Pod IP 把缺的文件拉过来，
+//       请求带 distribution.HandoffHeader，结束时（成功或者放弃）POST /handoff/done
+//     - 拉不到（旧 coordinator 已经退出）：和以前一样，发布之后从上游下载。
+//       节点挂掉时连接建不起来，follower 的建连超时很短，不会卡在操作系统的 TCP 超时上
+//     准备完才调用 onServing（agent 在这里打 coordinator label）并启动 model server
+//  2. 旧 coordinator 停止前排空 model server（ModelServer.drain）：
+//     失去身份后至少再供货 HandoffGrace，让新 coordinator 来得及连上来；
+//     之后没有请求、新 coordinator 也报告交接结束了才关闭（交接时逐个文件请求，
+//     两个请求之间正在处理的请求数是 0，不能只看它），最多等 HandoffDrainTimeout。
+//     agent 退出时先让出 lease，再等排空结束
+// ============================================================================
+
+const (
+	// HandoffGrace 是旧 coordinator 失去身份之后至少继续供货的时间
+	//
+	// 新 coordinator 当选（下一次选举 tick 加上 takeoverDelay，带候选偏好时最多 2.25 个 retryPeriod）
+	// 到连上来通常不超过 10 秒
+	HandoffGrace = 10 * time.Second
+	// HandoffDrainTimeout 是旧 coordinator 排空的上限，超过之后还在下载的 follower 重连新 coordinator
+	HandoffDrainTimeout = 30 * time.Second
+
+	// drainPollInterval 是排空时检查正在处理的请求数的间隔
+	drainPollInterval = 200 * time.Millisecond
+	// listenRetryInterval 是端口还被上一个角色的 model server 占着时重新绑定的间隔
+	listenRetryInterval = time.Second
+)
+
+// SetDrain 让 model server 停止前先排空：至少再供货 grace，之后没有请求就关闭，最多 timeout
+func (m *ModelServer) SetDrain(grace, timeout time.Duration) {
+	m.drainGrace, m.drainTimeout = grace, timeout
+}
+
+// drain 等正在处理的请求完成，没有设置 SetDrain 时马上返回
+func (m *ModelServer) drain() {
+	if m.drainTimeout <= 0 {
+		return
+	}
+	log.Printf("⏳ Draining model server (%d requests in flight)", m.inflight.Load())
+	start := time.Now()
+	for {
+		elapsed, n := time.Since(start), m.inflight.Load()
+		if elapsed >= m.drainTimeout {
+			if n > 0 {
+				log.Printf("⏱️  Model server drain timed out after %v, closing %d requests", m.drainTimeout, n)
+			}
+			return
+		}
+		if elapsed >= m.drainGrace && n == 0 && !m.handoff.Load() {
+			log.Printf("✅ Model server drained after %v", elapsed.Round(time.Second))
+			return
+		}
+		time.Sleep(drainPollInterval)
+	}
+}
+
+// handleHandoffDone 处理 POST /handoff/done：新 coordinator 交接结束，排空不用再等它
+func (m *ModelServer) handleHandoffDone(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	m.handoff.Store(false)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listen 绑定 addr；端口被上一个角色的 model server 占着（还在排空）时等它释放，直到 ctx 取消
+func listen(ctx context.Context, addr string) (net.Listener, error) {
+	logged := false
+	for {
+		ln, err := net.Listen("tcp", addr)
+		if err == nil || !errors.Is(err, syscall.EADDRINUSE) {
+			return ln, err
+		}
+		if !logged {
+			log.Printf("⏳ %s is still in use (previous model server draining), waiting", addr)
+			logged = true
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(listenRetryInterval):
+		}
+	}
+}
+
+// WithPreviousCoordinator 指定上一个 coordinator 的 model server 地址，handoff 时从它拉缺的文件
+func (c *Coordinator) WithPreviousCoordinator(url string) *Coordinator {
+	c.previousURL = url
+	return c
+}
+
+// WithOnServing 指定副本准备好、model server 启动之前的回调（agent 在这里打 coordinator label）
+func (c *Coordinator) WithOnServing(fn func()) *Coordinator {
+	c.onServing = fn
+	return c
+}
+
+// handoff 是交接的第一阶段：发布自己之前确认本地副本完整，或者从上一个 coordinator 补齐
+//
+// 只有 ctx 取消和磁盘满返回错误；其他失败交给 ensureModel 从上游下载
+func (c *Coordinator) handoff(ctx context.Context) error {
+	if ModelComplete(c.modelPath) {
+		verifier, err := c.modelVerifier()
+		if err != nil {
+			return err
+		}
+		err = verifier.Verify(c.modelPath)
+		if err == nil {
+			log.Printf("🔏 Local model copy verified (%s), taking over", verifier.Name())
+			return nil
+		}
+		log.Printf("⚠️  Local model copy failed verification: %v, not serving it as complete", err)
+		if err := os.Remove(filepath.Join(c.modelPath, CompleteMarker)); err != nil && !os.IsNotExist(err) {
+			return agenterr.Classify(err)
+		}
+	}
+	if c.previousURL == "" {
+		return nil
+	}
+
+	// Sync 按同一个 verifier 校验，文件已经在的跳过，只拉缺的
+	log.Printf("🤝 Pulling missing files from the previous coordinator %s", c.previousURL)
+	previous := follower.NewFollowerFromURL(c.previousURL, c.modelPath).WithBandwidth(c.bandwidth).WithHandoff()
+	err := previous.Sync(ctx)
+	previous.EndHandoff(ctx)
+	if err == nil {
+		log.Println("✅ Model copy completed from the previous coordinator")
+		return MarkComplete(c.modelPath)
+	}
+	if ctx.Err() != nil || agenterr.Kind(err) == agenterr.ErrDiskFull {
+		return err
+	}
+	log.Printf("⚠️  Handoff from the previous coordinator failed: %v, downloading from upstream", err)
+	return nil
+}
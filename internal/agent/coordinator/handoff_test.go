@@ -0,0 +1,154 @@
+package coordinator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+	"github.com/Moore-Z/kubeinfer/internal/agent/follower"
+)
+
+// sealedModel 在 dir 里写一个模型文件、清单和完整标记
+func sealedModel(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, "model.safetensors"), []byte("weights"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	v, _ := distribution.New(distribution.PolicySize, nil)
+	if err := v.Seal(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := MarkComplete(dir); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHandoff(t *testing.T) {
+	v, _ := distribution.New(distribution.PolicySize, nil)
+
+	// 上一个 coordinator 还在排空：从它补齐
+	oldDir, newDir := t.TempDir(), t.TempDir()
+	sealedModel(t, oldDir)
+	old := httptest.NewServer(NewModelServer(oldDir).Handler())
+	defer old.Close()
+	c := &Coordinator{modelPath: newDir, verifier: v, previousURL: old.URL}
+	if err := c.handoff(context.Background()); err != nil {
+		t.Fatalf("handoff from the previous coordinator: %v", err)
+	}
+	if !ModelComplete(newDir) {
+		t.Error("model not complete after pulling from the previous coordinator")
+	}
+
+	// 本地副本和清单对不上：不当作完整的
+	broken := t.TempDir()
+	sealedModel(t, broken)
+	if err := os.WriteFile(filepath.Join(broken, "model.safetensors"), []byte("trunc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c = &Coordinator{modelPath: broken, verifier: v}
+	if err := c.handoff(context.Background()); err != nil {
+		t.Fatalf("handoff with a broken copy: %v", err)
+	}
+	if ModelComplete(broken) {
+		t.Error("a copy that fails verification is still marked complete")
+	}
+
+	// 上一个 coordinator 已经退出：不报错，交给 ensureModel 从上游下载
+	old.Close()
+	empty := t.TempDir()
+	c = &Coordinator{modelPath: empty, verifier: v, previousURL: old.URL}
+	if err := c.handoff(context.Background()); err != nil {
+		t.Fatalf("handoff from a stopped coordinator: %v", err)
+	}
+	if ModelComplete(empty) {
+		t.Error("model marked complete without pulling anything")
+	}
+}
+
+func TestModelServerDrain(t *testing.T) {
+	m := NewModelServer(t.TempDir())
+	m.SetDrain(50*time.Millisecond, 5*time.Second)
+	m.inflight.Add(1)
+
+	drained := make(chan struct{})
+	go func() {
+		m.drain()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		t.Fatal("drain returned with a request in flight")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	m.inflight.Add(-1)
+	select {
+	case <-drained:
+	case <-time.After(2 * time.Second):
+		t.Fatal("drain did not return after the last request finished")
+	}
+}
+
+func TestModelServerDrainWaitsForHandoff(t *testing.T) {
+	m := NewModelServer(t.TempDir())
+	m.SetDrain(50*time.Millisecond, 5*time.Second)
+	srv := httptest.NewServer(m.Handler())
+	defer srv.Close()
+
+	// 新 coordinator 拉过一个文件，两个请求之间没有正在处理的请求
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/models", nil)
+	req.Header.Set(distribution.HandoffHeader, "true")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	drained := make(chan struct{})
+	go func() {
+		m.drain()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		t.Fatal("drain returned while the successor was still pulling files")
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	follower.NewFollowerFromURL(srv.URL, t.TempDir()).WithHandoff().EndHandoff(context.Background())
+	select {
+	case <-drained:
+	case <-time.After(2 * time.Second):
+		t.Fatal("drain did not return after the handoff was done")
+	}
+}
+
+func TestHandoffFromUnresponsiveCoordinator(t *testing.T) {
+	// 上一个 coordinator 的节点挂了：连接建得起来也收不到响应，交接要跟着 ctx 结束
+	hang := make(chan struct{})
+	old := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-hang:
+		case <-r.Context().Done():
+		}
+	}))
+	defer old.Close()
+	defer close(hang)
+
+	v, _ := distribution.New(distribution.PolicySize, nil)
+	c := &Coordinator{modelPath: t.TempDir(), verifier: v, previousURL: old.URL}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := c.handoff(ctx); err == nil {
+		t.Error("handoff succeeded without a response from the previous coordinator")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("handoff took %v after the context was cancelled", elapsed)
+	}
+}
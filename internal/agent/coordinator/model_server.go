@@ -1,39 +1,198 @@
 package coordinator
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/bandwidth"
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+	"github.com/Moore-Z/kubeinfer/internal/agent/faults"
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
 )
 
 const ServerPort = 8080
 
+// CompleteHeader 告诉 follower coordinator 是否已经有完整副本
+// "false" 表示 coordinator 还在下载，follower 需要稍后再来拿剩下的文件
+const CompleteHeader = "X-Kubeinfer-Model-Complete"
+
+// SizeHeader 是 GET /models 列出的文件的总字节数，follower 用它估算同步的剩余时间
+const SizeHeader = "X-Kubeinfer-Model-Size"
+
+// PartialSuffix 是下载中的临时文件后缀，下载完成后 rename 成正式文件名
+const PartialSuffix = ".partial"
+
 type ModelServer struct {
-	modelPath string
+	// layout 是模型目录的布局；versioned 布局默认提供 current 指向的 revision
+	layout distribution.Layout
+	// mu 保护 dir 和 revision：coordinator 确定 revision 之后、zone seeder 开始同步时会切换目录
+	mu       sync.RWMutex
+	dir      string
+	revision string
+
+	// bandwidth 给发往 follower 的数据限速，nil 表示不限速
+	bandwidth *bandwidth.Manager
+
+	// labels 是 served_bytes 指标的标签
+	labels distribution.ProgressLabels
+
+	// token 是 peer serving 的共享 token（MODEL_SERVER_TOKEN），/peers 总是要求它
+	token string
+	// protectModels 为 true 时 /models 也要求 token（follower 的 peer server）；
+	// coordinator 的 /models 不要求，老版本 follower 和 fleet 的成员集群没有 token
+	protectModels bool
+
+	// peers 是注册过的 peer（见 peers.go）
+	peers *peerRegistry
+
+	// transfers 统计正在下载的 follower，nil 表示不统计（见 transfers.go）
+	transfers *TransferTracker
+
+	// drainGrace、drainTimeout 是停止前继续供货的时间，drainTimeout 为 0 表示马上关闭（见 handoff.go）
+	drainGrace   time.Duration
+	drainTimeout time.Duration
+	// inflight 是正在处理的请求数，排空时等它降到 0
+	inflight atomic.Int64
+	// handoff 在新 coordinator 开始从这里补齐副本时设置，POST /handoff/done 时清除；排空时等它结束
+	handoff atomic.Bool
+	// closing 在开始停止时关闭，GET /files 的长轮询马上返回，不拖住排空
+	closing   chan struct{}
+	closeOnce sync.Once
+
+	// plan 是 coordinator 正在下载的文件和每个文件是否 ready（见 readiness.go）
+	plan *downloadPlan
 }
 
 // NewModelServer 创建新的模型服务器
 func NewModelServer(modelpath string) *ModelServer {
 	return &ModelServer{
-		modelPath: modelpath,
+		layout: distribution.LayoutFromEnv(modelpath),
+		labels: distribution.LabelsFromEnv(""),
+		token:  distribution.ServerTokenFromEnv(),
+		peers:  newPeerRegistry(),
+
+		closing: make(chan struct{}),
+		plan:    newDownloadPlan(),
 	}
 }
 
-func (m *ModelServer) Start() error {
-	mux := http.NewServeMux()
+// RequireToken 要求下载模型文件时也带 token
+func (m *ModelServer) RequireToken() {
+	m.protectModels = true
+}
 
-	mux.HandleFunc("/health", m.handleHealth)         // Check health
-	mux.HandleFunc("/models", m.handleListModels)     // List all model files
-	mux.HandleFunc("/models/", m.handleDownloadModel) // Download specific model
+// SetBandwidth 设置和上游下载共享的带宽预算
+func (m *ModelServer) SetBandwidth(b *bandwidth.Manager) {
+	m.bandwidth = b
+}
+
+// SetTransfers 设置统计正在下载的 follower 的 TransferTracker
+func (m *ModelServer) SetTransfers(t *TransferTracker) {
+	m.transfers = t
+}
 
+// SetDir 提供 revision 目录 dir 里的文件，而不是 current 指向的（versioned 布局下载、同步中的 revision）
+func (m *ModelServer) SetDir(dir, revision string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dir, m.revision = dir, revision
+}
+
+// servingDir 返回提供的目录和 revision（flat 布局没有 revision）
+func (m *ModelServer) servingDir() (string, string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.dir != "" {
+		return m.dir, m.revision
+	}
+	return m.layout.Current(), m.layout.CurrentRevision()
+}
+
+// Start 启动 HTTP 服务器，直到 ctx 被取消
+//
+// 角色切换时（coordinator ↔ follower/zone seeder）会取消 ctx，
+// 这里负责关闭监听，下一个角色才能重新绑定 8080 端口。
+// 设置了 drainTimeout 时先排空再关闭，Start 在关闭之后才返回
+func (m *ModelServer) Start(ctx context.Context) error {
 	// 启动服务器
 	addr := fmt.Sprintf(":%d", ServerPort)
-	fmt.Printf("🌐 Starting model server on %s", addr)
-	return http.ListenAndServe(addr, mux)
+	ln, err := listen(ctx, addr)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	return m.Serve(ctx, ln)
+}
+
+// Serve 在 ln 上提供模型文件，直到 ctx 被取消并排空
+func (m *ModelServer) Serve(ctx context.Context, ln net.Listener) error {
+	server := &http.Server{Handler: m.Handler()}
+
+	go func() {
+		<-ctx.Done()
+		m.closeOnce.Do(func() { close(m.closing) })
+		m.drain()
+		_ = server.Close()
+	}()
+
+	log.Printf("🌐 Starting model server on %s", ln.Addr())
+	if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Handler 返回 model server 的 HTTP handler
+func (m *ModelServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/health", m.handleHealth)                              // Check health
+	mux.HandleFunc("/models", m.authorize(m.handleListModels))             // List all model files
+	mux.HandleFunc("/models/", m.authorize(m.handleDownloadModel))         // Download specific model
+	mux.HandleFunc("/peers", m.handlePeers)                                // Register/list peers
+	mux.HandleFunc(distribution.DigestsPath, m.authorize(m.handleDigests)) // sha256 of served files (delta sync)
+	mux.HandleFunc(distribution.FilesPath, m.authorize(m.handleFiles))     // per-file readiness (serve while downloading)
+	mux.HandleFunc(distribution.HandoffDonePath, m.authorize(m.handleHandoffDone))
+
+	// 故障注入：模拟 coordinator 磁盘/进程故障，follower 应该退避重试
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.inflight.Add(1)
+		defer m.inflight.Add(-1)
+		if r.Header.Get(distribution.HandoffHeader) != "" {
+			m.handoff.Store(true)
+		}
+		if faults.Hit(faults.ModelServer500) {
+			http.Error(w, "injected fault", http.StatusInternalServerError)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// authorize 在 protectModels 时检查 token
+func (m *ModelServer) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if m.protectModels && !distribution.Authorized(r, m.token) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
 }
 
 func (m *ModelServer) handleHealth(w http.ResponseWriter, r *http.Request) {
@@ -57,20 +216,80 @@ func (m *ModelServer) handleListModels(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 读取模型目录
-	files, err := os.ReadDir(m.modelPath)
+	// 返回 503 而不是 500：follower 会把它归为临时错误（agenterr.ErrTransientNetwork）退避重试
+	dir, revision := m.servingDir()
+	names, sizes, err := listDir(dir)
 	if err != nil {
 		log.Printf("❌ Error reading model directory: %v", err)
-		http.Error(w, "Failed to list models", http.StatusInternalServerError)
+		http.Error(w, "Failed to list models", http.StatusServiceUnavailable)
 		return
 	}
+	var size int64
+	for _, s := range sizes {
+		size += s
+	}
+
+	// 正在下载：只列下载完、校验过的文件，大小是整个 revision 的，follower 按它估算剩余时间
+	if files, ready, _ := m.plan.snapshot(); files != nil {
+		names, size = nil, 0
+		for _, f := range files {
+			if ready[f.Name] && topLevel(f.Name) {
+				names = append(names, f.Name)
+			}
+			size += f.Size
+		}
+	}
 
 	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set(CompleteHeader, strconv.FormatBool(ModelComplete(dir)))
+	// versioned 布局的 follower 同步到同名的 revision 目录
+	if revision != "" {
+		w.Header().Set(distribution.RevisionHeader, revision)
+	}
+	w.Header().Set(SizeHeader, strconv.FormatInt(size, 10))
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\n", name)
+	}
+	listed := len(names)
+	log.Printf("📋 Listed %d model files", listed)
+}
 
+// listDir 列出 dir 里给 follower 的文件和它们的大小
+//
+// 跳过下载中的文件、标记文件和 huggingface-cli 的 .cache 目录
+func listDir(dir string) ([]string, []int64, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var names []string
+	var sizes []int64
 	for _, file := range files {
-		fmt.Fprintf(w, "%s\n", file.Name())
+		if strings.HasPrefix(file.Name(), ".") || strings.HasSuffix(file.Name(), PartialSuffix) {
+			continue
+		}
+		var size int64
+		if info, err := file.Info(); err == nil && !info.IsDir() {
+			size = info.Size()
+		}
+		names = append(names, file.Name())
+		sizes = append(sizes, size)
+	}
+	return names, sizes, nil
+}
+
+// handleDigests 返回已经下载完成的文件的大小和 sha256（状态文件里的记录）
+// GET /digests → {"model-00001-of-00002.safetensors": {"size": ..., "sha256": "..."}}
+//
+// follower 据此把内容没变的文件从本地旧 revision 硬链接过来，不用重新下载（见 distribution/delta.go）
+func (m *ModelServer) handleDigests(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
-	log.Printf("📋 Listed %d model files", len(files))
-	return
+	dir, _ := m.servingDir()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(distribution.ReadDigests(dir))
 }
 
 // handleDownloadModel 处理文件下载请求
@@ -91,9 +310,10 @@ func (ms *ModelServer) handleDownloadModel(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	fullPath := filepath.Join(ms.modelPath, relativePath)
+	dir, _ := ms.servingDir()
+	fullPath := filepath.Join(dir, relativePath)
 
-	if !strings.HasPrefix(fullPath, ms.modelPath) {
+	if !strings.HasPrefix(fullPath, dir) {
 		log.Printf("⚠️  Blocked path traversal attempt: %s", relativePath)
 		http.Error(w, "Invalid path", http.StatusBadRequest)
 		return
@@ -109,22 +329,92 @@ func (ms *ModelServer) handleDownloadModel(w http.ResponseWriter, r *http.Reques
 	fileInfo, err := file.Stat()
 	if err != nil {
 		log.Printf("❌ Error getting file info: %v", err)
-		http.Error(w, "Failed to stat file", http.StatusInternalServerError)
+		http.Error(w, "Failed to stat file", http.StatusServiceUnavailable)
+		return
+	}
+
+	// 断点续传（follower 的下载断点，见 distribution/checkpoint.go）：只支持 "bytes=N-"，其他 Range 返回整个文件。
+	// 带了 If-Range 但和现在的 ETag 不一致（follower 的 .partial 是另一份内容）时也返回整个文件
+	size, status := fileInfo.Size(), http.StatusOK
+	etag := fileETag(dir, relativePath, fileInfo)
+	offset, ok := resumeOffset(r.Header.Get("Range"))
+	if ifRange := r.Header.Get("If-Range"); ifRange != "" && ifRange != etag {
+		ok = false
+	}
+	if ok && offset >= size {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		http.Error(w, "Range not satisfiable", http.StatusRequestedRangeNotSatisfiable)
 		return
 	}
+	if ok && offset > 0 {
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			http.Error(w, "Failed to seek file", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, size-1, size))
+		status = http.StatusPartialContent
+	} else {
+		offset = 0
+	}
 
 	// 设置响应头
 	w.Header().Set("Content-Type", "application/octet-stream")                   // 二进制流
-	w.Header().Set("Content-Length", fmt.Sprintf("%d", fileInfo.Size()))         // 文件大小
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", size-offset))             // 文件大小（续传时是剩下的部分）
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", // 下载文件名
 		filepath.Base(fullPath)))
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("ETag", etag)
+	w.WriteHeader(status)
 	// 流式传输文件内容
 	// io.Copy 会自动处理大文件，边读边写，不会占用大量内存
-	log.Printf("📤 Serving file: %s (size: %d bytes)", relativePath, fileInfo.Size())
-	written, err := io.Copy(w, file)
+	log.Printf("📤 Serving file: %s (size: %d bytes, from byte %d)", relativePath, size, offset)
+	done := ms.transfers.begin(r)
+	defer done()
+	// 传输中断时 follower 收到的字节数和 Content-Length 对不上，会丢弃这个文件重新下载（配置了下载断点时从断开的地方接着下载）
+	written, err := io.Copy(ms.bandwidth.Serve(r.Context(), w), &servedReader{r: file, labels: ms.labels})
 	if err != nil {
-		fmt.Printf("Error Stream file %v", err)
+		log.Printf("❌ Error streaming %s: %v", relativePath, err)
 		return
 	}
 	log.Printf("✅ Sent %d bytes", written)
 }
+
+// fileETag 是文件的强 ETag：状态文件里有 sha256 时用 sha256（换了 coordinator 也一样，续传不受影响），
+// 否则用大小和修改时间
+func fileETag(dir, name string, info os.FileInfo) string {
+	if rec, ok := distribution.ReadDigests(dir)[name]; ok {
+		return fmt.Sprintf("%q", "sha256:"+rec.SHA256)
+	}
+	return fmt.Sprintf(`"%x-%x"`, info.Size(), info.ModTime().UnixNano())
+}
+
+// resumeOffset 解析 "bytes=N-" 形式的 Range，其他形式（多段、有结尾、后缀）返回 false
+func resumeOffset(header string) (int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0, false
+	}
+	start, ok := strings.CutSuffix(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	offset, err := strconv.ParseInt(start, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, false
+	}
+	return offset, true
+}
+
+// servedReader 边读边记 served_bytes，几十 GB 的文件传输过程中指标也在涨
+type servedReader struct {
+	r      io.Reader
+	labels distribution.ProgressLabels
+}
+
+func (s *servedReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if n > 0 {
+		metrics.RecordModelServedBytes(s.labels.Namespace, s.labels.LLMService, s.labels.Model, int64(n))
+	}
+	return n, err
+}
@@ -0,0 +1,70 @@
+package coordinator
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResumeDownload(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "model.safetensors"), []byte("0123456789"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(NewModelServer(dir).Handler())
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/models/model.safetensors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("model server sent no ETag")
+	}
+
+	tests := []struct {
+		rangeHeader string
+		ifRange     string
+		wantStatus  int
+		wantBody    string
+	}{
+		{rangeHeader: "", wantStatus: http.StatusOK, wantBody: "0123456789"},
+		{rangeHeader: "bytes=4-", wantStatus: http.StatusPartialContent, wantBody: "456789"},
+		{rangeHeader: "bytes=0-", wantStatus: http.StatusOK, wantBody: "0123456789"},
+		// 只支持续传用的 bytes=N-，其他形式返回整个文件
+		{rangeHeader: "bytes=2-5", wantStatus: http.StatusOK, wantBody: "0123456789"},
+		{rangeHeader: "bytes=10-", wantStatus: http.StatusRequestedRangeNotSatisfiable},
+		// .partial 是按同一份内容下载的才续传，否则返回整个文件
+		{rangeHeader: "bytes=4-", ifRange: etag, wantStatus: http.StatusPartialContent, wantBody: "456789"},
+		{rangeHeader: "bytes=4-", ifRange: `"a-1"`, wantStatus: http.StatusOK, wantBody: "0123456789"},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+"/models/model.safetensors", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.rangeHeader != "" {
+			req.Header.Set("Range", tt.rangeHeader)
+		}
+		if tt.ifRange != "" {
+			req.Header.Set("If-Range", tt.ifRange)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus {
+			t.Errorf("Range %q: status = %d, want %d", tt.rangeHeader, resp.StatusCode, tt.wantStatus)
+			continue
+		}
+		if tt.wantStatus != http.StatusRequestedRangeNotSatisfiable && string(body) != tt.wantBody {
+			t.Errorf("Range %q: body = %q, want %q", tt.rangeHeader, body, tt.wantBody)
+		}
+	}
+}
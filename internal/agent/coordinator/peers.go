@@ -0,0 +1,167 @@
+package coordinator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/bandwidth"
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+)
+
+// PeerTTL 是 peer 注册的有效期，peer 每 PeerAdvertiseInterval 续一次
+const (
+	PeerTTL               = 90 * time.Second
+	PeerAdvertiseInterval = 30 * time.Second
+)
+
+// peerRegistry 记录向 coordinator 注册过的 peer，超过 PeerTTL 没有续约的自动过期
+//
+// 只在内存里：coordinator 换人后 peer 在下一个 PeerAdvertiseInterval 内向新的 coordinator 重新注册
+type peerRegistry struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+
+	// now 方便测试时注入时间
+	now func() time.Time
+}
+
+func newPeerRegistry() *peerRegistry {
+	return &peerRegistry{seen: map[string]time.Time{}, now: time.Now}
+}
+
+func (p *peerRegistry) add(peerURL string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.seen[peerURL] = p.now()
+}
+
+// list 返回还在有效期内的 peer，顺便删掉过期的
+func (p *peerRegistry) list() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	var peers []string
+	for u, at := range p.seen {
+		if now.Sub(at) > PeerTTL {
+			delete(p.seen, u)
+			continue
+		}
+		peers = append(peers, u)
+	}
+	sort.Strings(peers)
+	return peers
+}
+
+// handlePeers 处理 peer 注册和查询
+// GET /peers → 有效期内的 peer 地址（每行一个）
+// POST /peers，body 是 peer 自己的地址 → 注册/续约
+//
+// 两个都要求 token：没有 token 时任何人都能注册一个假的 peer
+func (m *ModelServer) handlePeers(w http.ResponseWriter, r *http.Request) {
+	if m.token == "" {
+		http.Error(w, "Peer serving is not enabled", http.StatusNotFound)
+		return
+	}
+	if !distribution.Authorized(r, m.token) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/plain")
+		for _, peer := range m.peers.list() {
+			fmt.Fprintf(w, "%s\n", peer)
+		}
+	case http.MethodPost:
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1024))
+		if err != nil {
+			http.Error(w, "Failed to read body", http.StatusBadRequest)
+			return
+		}
+		peerURL := strings.TrimSpace(string(body))
+		if u, err := url.Parse(peerURL); err != nil || u.Scheme != "http" || u.Host == "" {
+			http.Error(w, "Invalid peer URL", http.StatusBadRequest)
+			return
+		}
+		m.peers.add(peerURL)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// ServePeer 在 follower 上运行只读 model server，并定期向 coordinator 注册，直到 ctx 取消
+//
+// 只在同步完成（本地有完整副本）之后调用；没有 token 时不提供服务。
+// coordinatorURL 每次注册前都调用一次：coordinator 换人后向新的 coordinator 注册
+func ServePeer(ctx context.Context, modelPath, selfURL string, coordinatorURL func() (string, error)) error {
+	token := distribution.ServerTokenFromEnv()
+	if token == "" {
+		return fmt.Errorf("%s is not set, refusing to serve the model without authentication", distribution.ServerTokenEnv)
+	}
+
+	server := NewModelServer(modelPath)
+	server.RequireToken()
+	// peer 的推理服务在同一张网卡上，同样按 AGENT_BANDWIDTH_LIMIT 限速
+	server.SetBandwidth(bandwidth.FromEnv())
+
+	go advertise(ctx, coordinatorURL, selfURL, token)
+	log.Printf("🌱 Serving the model to peers as %s", selfURL)
+	return server.Start(ctx)
+}
+
+// advertise 每 PeerAdvertiseInterval 向 coordinator 注册一次
+func advertise(ctx context.Context, coordinatorURL func() (string, error), selfURL, token string) {
+	ticker := time.NewTicker(PeerAdvertiseInterval)
+	defer ticker.Stop()
+	failing := false
+	for {
+		target, err := coordinatorURL()
+		if err == nil {
+			err = register(ctx, target, selfURL, token)
+		}
+		// 只在状态变化时打日志，coordinator 挂掉期间不刷屏
+		if err != nil && !failing {
+			log.Printf("⚠️  Failed to advertise to the coordinator: %v", err)
+		} else if err == nil && failing {
+			log.Printf("🌱 Advertising to coordinator %s again", target)
+		}
+		failing = err != nil
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func register(ctx context.Context, coordinatorURL, selfURL, token string) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(coordinatorURL, "/")+"/peers",
+		bytes.NewReader([]byte(selfURL)))
+	if err != nil {
+		return err
+	}
+	distribution.SetToken(req, token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package coordinator
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+)
+
+// downloadPlan 是 coordinator 正在下载的完整文件列表和每个文件是否 ready（见 distribution/readiness.go）
+type downloadPlan struct {
+	mu sync.Mutex
+	// files 为 nil 表示没有下载计划，按目录里已有的文件回答
+	files []distribution.RemoteFile
+	ready map[string]bool
+	// changed 在计划变化（开始、结束、有文件 ready）时关闭并换成新的，长轮询等它
+	changed chan struct{}
+}
+
+func newDownloadPlan() *downloadPlan {
+	return &downloadPlan{changed: make(chan struct{})}
+}
+
+// notifyLocked 叫醒所有长轮询，调用方持有 mu
+func (p *downloadPlan) notifyLocked() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// snapshot 返回计划的副本和下一次变化的 channel
+func (p *downloadPlan) snapshot() ([]distribution.RemoteFile, map[string]bool, <-chan struct{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ready := make(map[string]bool, len(p.ready))
+	for name := range p.ready {
+		ready[name] = true
+	}
+	return p.files, ready, p.changed
+}
+
+// SetPlan 公布要下载的完整文件列表；pending 以外的文件已经在目录里（断点续传、硬链接复用），可以下载
+func (m *ModelServer) SetPlan(files, pending []distribution.RemoteFile) {
+	waiting := make(map[string]bool, len(pending))
+	for _, f := range pending {
+		waiting[f.Name] = true
+	}
+	p := m.plan
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files = files
+	p.ready = map[string]bool{}
+	for _, f := range files {
+		if !waiting[f.Name] {
+			p.ready[f.Name] = true
+		}
+	}
+	p.notifyLocked()
+}
+
+// MarkReady 标记一个文件已经下载完、校验过
+func (m *ModelServer) MarkReady(name string) {
+	p := m.plan
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.files == nil {
+		return
+	}
+	p.ready[name] = true
+	p.notifyLocked()
+}
+
+// ClearPlan 在下载结束（完成或者失败）时调用，之后按目录里的文件回答
+func (m *ModelServer) ClearPlan() {
+	p := m.plan
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files, p.ready = nil, nil
+	p.notifyLocked()
+}
+
+// Notify 让长轮询的 follower 重新看一次（写了完整标记之后）
+func (m *ModelServer) Notify() {
+	p := m.plan
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.notifyLocked()
+}
+
+// fileIndex 返回当前的 FileIndex、是否有下载计划和下一次变化的 channel
+func (m *ModelServer) fileIndex() (distribution.FileIndex, bool, <-chan struct{}, error) {
+	dir, revision := m.servingDir()
+	files, ready, changed := m.plan.snapshot()
+	index := distribution.FileIndex{Revision: revision, Complete: ModelComplete(dir)}
+	if files != nil {
+		for _, f := range files {
+			if !topLevel(f.Name) {
+				continue
+			}
+			index.Files = append(index.Files, distribution.FileStatus{Name: f.Name, Size: f.Size, SHA256: f.SHA256, Ready: ready[f.Name]})
+		}
+		return index, true, changed, nil
+	}
+	names, sizes, err := listDir(dir)
+	if err != nil {
+		return index, false, changed, err
+	}
+	for i, name := range names {
+		index.Files = append(index.Files, distribution.FileStatus{Name: name, Size: sizes[i], Ready: true})
+	}
+	return index, false, changed, nil
+}
+
+// topLevel 判断上游的文件是不是模型目录顶层的文件，model server 只分发顶层文件（和 GET /models、清单一致）
+func topLevel(name string) bool {
+	return !strings.Contains(name, "/")
+}
+
+// handleFiles 返回每个文件是否可以下载
+// GET /files?ready=3 → ready 的文件超过 3 个、下载结束或者等了 FilesWaitTimeout 之后返回 FileIndex
+//
+// 没有 ready 参数、没有下载计划（不会有变化通知）时马上返回
+func (m *ModelServer) handleFiles(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	seen := -1
+	if v := r.URL.Query().Get(distribution.FilesReadyParam); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			http.Error(w, "invalid ready count", http.StatusBadRequest)
+			return
+		}
+		seen = n
+	}
+
+	timeout := time.NewTimer(distribution.FilesWaitTimeout)
+	defer timeout.Stop()
+	for {
+		index, planned, changed, err := m.fileIndex()
+		if err != nil {
+			http.Error(w, "Failed to list models", http.StatusServiceUnavailable)
+			return
+		}
+		if seen < 0 || !planned || index.Complete || index.ReadyCount() > seen {
+			writeIndex(w, index)
+			return
+		}
+		select {
+		case <-changed:
+		case <-timeout.C:
+			writeIndex(w, index)
+			return
+		case <-m.closing:
+			writeIndex(w, index)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeIndex(w http.ResponseWriter, index distribution.FileIndex) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(index)
+}
@@ -0,0 +1,89 @@
+package coordinator
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+)
+
+func TestServeWhileDownloading(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"config.json", "model-00001.safetensors", "model-00002.safetensors.partial"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := NewModelServer(dir)
+	srv := httptest.NewServer(m.Handler())
+	defer srv.Close()
+
+	files := []distribution.RemoteFile{
+		{Name: "config.json", Size: 4},
+		{Name: "model-00001.safetensors", Size: 4},
+		{Name: "model-00002.safetensors", Size: 4},
+	}
+	// config.json 之前就下载完了；model-00001 已经在目录里，但还没校验完，不能给出去
+	m.SetPlan(files, files[1:])
+
+	resp, err := http.Get(srv.URL + "/models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if got := strings.Fields(string(body)); len(got) != 1 || got[0] != "config.json" {
+		t.Errorf("GET /models during download = %v, want only config.json", got)
+	}
+	if got := resp.Header.Get(SizeHeader); got != "12" {
+		t.Errorf("%s = %s, want the size of the whole revision", SizeHeader, got)
+	}
+
+	// 长轮询：下一个文件 ready 时返回
+	got := make(chan distribution.FileIndex, 1)
+	go func() {
+		resp, err := http.Get(srv.URL + distribution.FilesPath + "?ready=1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer resp.Body.Close()
+		var index distribution.FileIndex
+		_ = json.NewDecoder(resp.Body).Decode(&index)
+		got <- index
+	}()
+	select {
+	case <-got:
+		t.Fatal("long poll returned before a new file was ready")
+	case <-time.After(200 * time.Millisecond):
+	}
+	m.MarkReady("model-00001.safetensors")
+	select {
+	case index := <-got:
+		if index.ReadyCount() != 2 || len(index.Files) != 3 || index.Complete {
+			t.Errorf("index after MarkReady = %+v", index)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("long poll did not return after MarkReady")
+	}
+
+	// 下载结束：按目录回答，不再等
+	m.ClearPlan()
+	resp, err = http.Get(srv.URL + distribution.FilesPath + "?ready=5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index distribution.FileIndex
+	_ = json.NewDecoder(resp.Body).Decode(&index)
+	resp.Body.Close()
+	if index.ReadyCount() != 2 {
+		t.Errorf("index without a plan = %+v, want the two finished files on disk", index)
+	}
+}
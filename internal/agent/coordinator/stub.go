@@ -0,0 +1,95 @@
+package coordinator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+)
+
+// stub 推理后端（INFERENCE_RUNTIME=stub）不下载模型，生成同样大小的占位文件，
+// 分发、校验、follower 同步都走真实的代码路径
+const (
+	// StubModelSizeEnv 是占位模型的总字节数，默认 defaultStubModelSize
+	StubModelSizeEnv = "STUB_MODEL_SIZE"
+	// StubModelFilesEnv 是权重分片数，默认 2（验证多文件的分发）
+	StubModelFilesEnv = "STUB_MODEL_FILES"
+
+	defaultStubModelSize  = 64 << 20
+	defaultStubModelFiles = 2
+)
+
+// stubModelFromEnv 读取占位模型的大小和分片数，无效的值用默认值
+func stubModelFromEnv() (size int64, files int) {
+	size, files = defaultStubModelSize, defaultStubModelFiles
+	if v := os.Getenv(StubModelSizeEnv); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n >= 0 {
+			size = n
+		} else {
+			log.Printf("⚠️  Ignoring invalid %s=%q", StubModelSizeEnv, v)
+		}
+	}
+	if v := os.Getenv(StubModelFilesEnv); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			files = n
+		} else {
+			log.Printf("⚠️  Ignoring invalid %s=%q", StubModelFilesEnv, v)
+		}
+	}
+	return size, files
+}
+
+// downloadStubModel 代替 downloadModel：按环境变量生成占位模型
+func (c *Coordinator) downloadStubModel(ctx context.Context) error {
+	size, files := stubModelFromEnv()
+	return writeStubModel(c.modelPath, size, files)
+}
+
+// writeStubModel 在 modelPath 下生成 config.json 和 files 个权重分片，总大小 size
+//
+// 分片是全 0 的稀疏文件，生成几乎不花时间；先写 .partial 再 rename，model server 不会提供写了一半的文件
+func writeStubModel(modelPath string, size int64, files int) error {
+	if err := os.MkdirAll(modelPath, 0755); err != nil {
+		return agenterr.Classify(fmt.Errorf("failed to create model directory: %w", err))
+	}
+	config, err := json.Marshal(map[string]any{"model_type": "stub", "architectures": []string{"Stub"}})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(modelPath, "config.json"), config, 0644); err != nil {
+		return agenterr.Classify(err)
+	}
+
+	for i := range files {
+		shard := size / int64(files)
+		if i == files-1 {
+			shard += size % int64(files)
+		}
+		name := fmt.Sprintf("model-%05d-of-%05d.safetensors", i+1, files)
+		path := filepath.Join(modelPath, name)
+		if info, err := os.Stat(path); err == nil && info.Size() == shard {
+			continue
+		}
+		f, err := os.Create(path + ".partial")
+		if err != nil {
+			return agenterr.Classify(err)
+		}
+		err = f.Truncate(shard)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return agenterr.Classify(err)
+		}
+		if err := os.Rename(path+".partial", path); err != nil {
+			return agenterr.Classify(err)
+		}
+	}
+	log.Printf("🧪 Stub model written: %d files, %d bytes", files, size)
+	return nil
+}
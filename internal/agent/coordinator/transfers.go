@@ -0,0 +1,63 @@
+package coordinator
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// TransferTracker 统计正在从 model server 下载文件的 follower
+//
+// 一个 follower 会并发下载多个文件，按来源 IP 去重；agent 定期把结果写到 Pod 注解上，
+// controller 据此决定是否阻止驱逐 coordinator（见 internal/controller/eviction_protection.go）
+type TransferTracker struct {
+	mu     sync.Mutex
+	active map[string]int
+	// since 是 follower 数从 0 变成非 0 的时间，降回 0 时清零
+	since time.Time
+
+	// now 方便测试时注入时间
+	now func() time.Time
+}
+
+// NewTransferTracker 创建空的 TransferTracker
+func NewTransferTracker() *TransferTracker {
+	return &TransferTracker{active: map[string]int{}, now: time.Now}
+}
+
+// begin 记录一次传输开始，返回结束时调用的函数；t 为 nil 时不统计
+func (t *TransferTracker) begin(r *http.Request) func() {
+	if t == nil {
+		return func() {}
+	}
+	peer, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		peer = r.RemoteAddr
+	}
+
+	t.mu.Lock()
+	if len(t.active) == 0 {
+		t.since = t.now()
+	}
+	t.active[peer]++
+	t.mu.Unlock()
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.active[peer]--; t.active[peer] <= 0 {
+			delete(t.active, peer)
+		}
+		if len(t.active) == 0 {
+			t.since = time.Time{}
+		}
+	}
+}
+
+// Active 返回正在下载的 follower 数和这一轮供货开始的时间
+func (t *TransferTracker) Active() (followers int, since time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.active), t.since
+}
@@ -0,0 +1,239 @@
+package distribution
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+)
+
+// ============================================================================
+// 下载断点（spec.storage.checkpoint）
+// ============================================================================
+//
+// follower 的模型目录是 emptyDir，Pod 被驱逐、重新调度到别的节点之后，同步了一半的文件全部丢掉，从头再来。
+// 配置了 checkpoint PVC 时（挂载路径在 MODEL_CHECKPOINT_DIR），follower 先同步到 PVC 上自己的槽位
+// <checkpoint>/<Pod 名>/，完成、校验通过之后才搬到模型目录（PublishSlot）：
+//   - 槽位里的状态文件（StateFile）记录 repo 和 revision，换了模型的槽位不会被复用
+//   - 下载中的 <file>.partial 留在槽位里，重新开始时用 Range 请求从已有的长度接着下载。
+//     状态文件同时记下 .partial 是按 model server 的哪个 ETag 下载的，续传时带 If-Range：
+//     coordinator 上的文件变了（flat 布局没有 revision 可比）就返回整个文件，不会拼出两份内容混在一起的文件
+//   - Deployment 重建的 Pod 名字是新的：没有自己的槽位时，认领一个主人已经不在了、同一个 repo 的槽位（ClaimSlot）
+//   - 同步完成后删除槽位；Pod 没等到完成就永远消失留下的槽位，由 controller 创建的 janitor CronJob
+//     按 TTL 清理（kubeinfer janitor，见 PruneCheckpoints）
+// ============================================================================
+
+// CheckpointDirEnv 是 checkpoint PVC 的挂载路径（controller 根据 spec.storage.checkpoint 设置）
+const CheckpointDirEnv = "MODEL_CHECKPOINT_DIR"
+
+// CheckpointDirFromEnv 返回 checkpoint PVC 的挂载路径，没有配置时返回空
+func CheckpointDirFromEnv() string {
+	return os.Getenv(CheckpointDirEnv)
+}
+
+// ClaimSlot 返回 self 在 root 下的槽位，adopted 是认领来的槽位原来的主人
+//
+// self 已经有槽位（容器重启）时直接用；否则找一个 repo 相同、orphan(主人) 为 true 的槽位，
+// rename 成自己的名字（rename 是原子的，两个新 Pod 抢同一个槽位只有一个成功）；都没有时创建一个空槽位。
+// orphan 为 nil 时不认领别人的槽位
+func ClaimSlot(root, self, repo string, orphan func(owner string) bool) (dir, adopted string, err error) {
+	dir = filepath.Join(root, self)
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		return dir, "", nil
+	}
+	if orphan != nil {
+		entries, err := os.ReadDir(root)
+		if err != nil {
+			return "", "", agenterr.Classify(fmt.Errorf("failed to list download checkpoints: %w", err))
+		}
+		for _, e := range entries {
+			if !e.IsDir() || e.Name() == self {
+				continue
+			}
+			candidate := filepath.Join(root, e.Name())
+			if slotRepo, _ := slotState(candidate); slotRepo != repo || !orphan(e.Name()) {
+				continue
+			}
+			if err := os.Rename(candidate, dir); err == nil {
+				return dir, e.Name(), nil
+			}
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", agenterr.Classify(fmt.Errorf("failed to create download checkpoint: %w", err))
+	}
+	state := &State{Version: StateVersion, Repo: repo, Files: map[string]FileState{}}
+	return dir, "", state.Save(dir)
+}
+
+// RetargetSlot 记录槽位正在同步的 revision；revision 变了时删掉下载到一半的文件（Range 续传会拼出错误的内容）
+//
+// 完整的文件留着，follower 按 coordinator 的清单判断能不能复用。
+// revision 没变时保留 .partial 的 ETag 记录，续传时 model server 还会再按 If-Range 核对一次
+func RetargetSlot(dir, repo, revision string) error {
+	previous := loadSlot(dir)
+	state := &State{Version: StateVersion, Repo: repo, Revision: revision, Files: map[string]FileState{}}
+	if previous.Revision != "" && revision != "" && previous.Revision != revision {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return agenterr.Classify(err)
+		}
+		for _, e := range entries {
+			if strings.HasSuffix(e.Name(), partialSuffix) {
+				_ = os.Remove(filepath.Join(dir, e.Name()))
+			}
+		}
+	} else {
+		state.Partials = previous.Partials
+	}
+	return state.Save(dir)
+}
+
+// PartialETag 返回槽位里 name 的 .partial 是按哪个 ETag 下载的，没有记录时为空（不能续传）
+func PartialETag(dir, name string) string {
+	return loadSlot(dir).Partials[name]
+}
+
+// SetPartialETag 记录槽位里 name 的 .partial 对应的 ETag，etag 为空时删掉记录（文件下载完成、model server 没有给 ETag）
+func SetPartialETag(dir, name, etag string) error {
+	state := loadSlot(dir)
+	if state.Partials[name] == etag {
+		return nil
+	}
+	if etag == "" {
+		delete(state.Partials, name)
+	} else {
+		if state.Partials == nil {
+			state.Partials = map[string]string{}
+		}
+		state.Partials[name] = etag
+	}
+	return state.Save(dir)
+}
+
+// slotState 返回槽位记录的 repo 和 revision，读不到时为空
+func slotState(dir string) (repo, revision string) {
+	s := loadSlot(dir)
+	return s.Repo, s.Revision
+}
+
+// loadSlot 读取槽位的状态文件，读不到、格式不认识时返回一个空状态
+func loadSlot(dir string) *State {
+	data, err := os.ReadFile(filepath.Join(dir, StateFile))
+	if err == nil {
+		s := &State{}
+		if err := json.Unmarshal(data, s); err == nil && s.Version == StateVersion {
+			if s.Files == nil {
+				s.Files = map[string]FileState{}
+			}
+			return s
+		}
+	}
+	return &State{Version: StateVersion, Files: map[string]FileState{}}
+}
+
+// PublishSlot 把槽位里同步完成的文件搬到 dst，然后删除槽位
+//
+// 槽位在 PVC 上、模型目录在 emptyDir 上，一般不是同一个文件系统，rename 失败（EXDEV）时复制。
+// 状态文件和 .partial 不搬
+func PublishSlot(dir, dst string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return agenterr.Classify(fmt.Errorf("failed to read download checkpoint: %w", err))
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if !e.Type().IsRegular() || name == StateFile || strings.HasSuffix(name, partialSuffix) {
+			continue
+		}
+		if err := moveFile(filepath.Join(dir, name), filepath.Join(dst, name)); err != nil {
+			return agenterr.Classify(fmt.Errorf("failed to move %s out of the download checkpoint: %w", name, err))
+		}
+	}
+	return os.RemoveAll(dir)
+}
+
+// moveFile 先试 rename，跨文件系统时复制到 dst.partial 再 rename，最后删掉 src
+func moveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.Create(dst + partialSuffix)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(dst + partialSuffix)
+		return err
+	}
+	if err := os.Rename(dst+partialSuffix, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
+
+// PruneCheckpoints 删除 root 下 ttl 内没有任何变化的槽位，返回删掉的槽位名
+//
+// 槽位的修改时间取里面最新的文件（正在写的 .partial 也算），还在同步的槽位不会被删
+func PruneCheckpoints(root string, ttl time.Duration, now time.Time) ([]string, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		dir := filepath.Join(root, e.Name())
+		newest, err := newestModTime(dir)
+		if err != nil {
+			return removed, err
+		}
+		if now.Sub(newest) < ttl {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return removed, err
+		}
+		removed = append(removed, e.Name())
+	}
+	return removed, nil
+}
+
+// newestModTime 返回目录（包括它自己）里最新的修改时间
+func newestModTime(dir string) (time.Time, error) {
+	var newest time.Time
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		return nil
+	})
+	return newest, err
+}
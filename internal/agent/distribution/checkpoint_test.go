@@ -0,0 +1,115 @@
+package distribution
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestClaimSlot(t *testing.T) {
+	root := t.TempDir()
+	gone := map[string]bool{"llm-old": true}
+	orphan := func(owner string) bool { return gone[owner] }
+
+	// 被驱逐的 Pod 留下的槽位：下载到一半
+	old, _, err := ClaimSlot(root, "llm-old", "Qwen/Qwen2.5-0.5B", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, old, "config.json", "model.safetensors.partial")
+	// 还在运行的 Pod 的槽位、另一个模型的槽位，都不能认领
+	if _, _, err := ClaimSlot(root, "llm-running", "Qwen/Qwen2.5-0.5B", nil); err != nil {
+		t.Fatal(err)
+	}
+	gone["llm-other"] = true
+	if _, _, err := ClaimSlot(root, "llm-other", "meta-llama/Llama-3.1-8B", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, adopted, err := ClaimSlot(root, "llm-new", "Qwen/Qwen2.5-0.5B", orphan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adopted != "llm-old" || dir != filepath.Join(root, "llm-new") {
+		t.Fatalf("ClaimSlot() = %s, adopted %q, want llm-old's slot renamed to llm-new", dir, adopted)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "model.safetensors.partial")); err != nil {
+		t.Errorf("adopted slot lost the partial download: %v", err)
+	}
+
+	// 容器重启：自己的槽位还在，直接用
+	again, adopted, err := ClaimSlot(root, "llm-new", "Qwen/Qwen2.5-0.5B", orphan)
+	if err != nil || again != dir || adopted != "" {
+		t.Errorf("ClaimSlot() again = %s, %q, %v, want the own slot", again, adopted, err)
+	}
+
+	// .partial 的 ETag 记在状态文件里，同一个 revision 重新开始时还在（flat 布局 revision 为空）
+	if err := SetPartialETag(dir, "model.safetensors", `"sha256:abc"`); err != nil {
+		t.Fatal(err)
+	}
+	if err := RetargetSlot(dir, "Qwen/Qwen2.5-0.5B", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := PartialETag(dir, "model.safetensors"); got != `"sha256:abc"` {
+		t.Errorf("PartialETag() = %q after retargeting to the same revision", got)
+	}
+
+	// 换了 revision：下载到一半的文件作废，完整的文件留给清单比对
+	if err := RetargetSlot(dir, "Qwen/Qwen2.5-0.5B", "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := RetargetSlot(dir, "Qwen/Qwen2.5-0.5B", "def"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "model.safetensors.partial")); !os.IsNotExist(err) {
+		t.Errorf("partial download of the previous revision kept: %v", err)
+	}
+	if got := PartialETag(dir, "model.safetensors"); got != "" {
+		t.Errorf("PartialETag() = %q after the revision changed, want none", got)
+	}
+
+	// 搬到模型目录：状态文件不搬，槽位删掉
+	models := t.TempDir()
+	if err := PublishSlot(dir, models); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(models, "config.json")); err != nil {
+		t.Errorf("config.json not published: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(models, StateFile)); !os.IsNotExist(err) {
+		t.Errorf("slot state file published: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("slot not removed after publishing: %v", err)
+	}
+}
+
+func TestPruneCheckpoints(t *testing.T) {
+	root := t.TempDir()
+	now := time.Now()
+	for name, age := range map[string]time.Duration{"stale": 48 * time.Hour, "active": time.Hour} {
+		dir := filepath.Join(root, name)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, dir, "model.safetensors.partial")
+		for _, path := range []string{filepath.Join(dir, "model.safetensors.partial"), dir} {
+			if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	removed, err := PruneCheckpoints(root, 24*time.Hour, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(removed, []string{"stale"}) {
+		t.Errorf("PruneCheckpoints() removed %v, want [stale]", removed)
+	}
+	if _, err := os.Stat(filepath.Join(root, "active")); err != nil {
+		t.Errorf("active slot removed: %v", err)
+	}
+}
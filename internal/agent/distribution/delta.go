@@ -0,0 +1,130 @@
+package distribution
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// 增量同步：新 revision 里内容没变的文件从本地旧 revision 硬链接过来
+// ============================================================================
+//
+// 仓库更新往往只改了 tokenizer 或者几个分片，几百 GB 里大部分文件和旧 revision 一模一样。
+// versioned 布局下旧 revision 还在磁盘上，按 sha256 对上的文件直接硬链接，不用重新下载：
+//
+//	coordinator  HuggingFace 列出的 LFS sha256  ←→  本地各 revision 状态文件里的 sha256
+//	follower     model server GET /digests      ←→  本地各 revision 状态文件里的 sha256
+//
+// 硬链接是安全的：模型文件只会先写 .partial 再 rename，不会原地修改；GC 删掉旧 revision 目录
+// 只是少一个链接。最后照常按 verifier 校验。
+// follower 同步完成后也写一份状态文件（DigestsPath 的内容），下一次升级时它的目录同样可以复用。
+// ============================================================================
+
+// DigestsPath 是 model server 返回当前 revision 文件 sha256 的接口
+const DigestsPath = "/digests"
+
+// Digests 是一个 revision 目录里文件的大小和 sha256（状态文件里的记录）
+type Digests map[string]FileState
+
+// ReadDigests 读取 dir 的状态文件里大小和磁盘上一致的记录，没有状态文件时返回空
+func ReadDigests(dir string) Digests {
+	data, err := os.ReadFile(filepath.Join(dir, StateFile))
+	if err != nil {
+		return Digests{}
+	}
+	s := &State{}
+	if err := json.Unmarshal(data, s); err != nil || s.Version != StateVersion {
+		return Digests{}
+	}
+	digests := Digests{}
+	for name, rec := range s.Files {
+		if rec.SHA256 == "" {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.Size() == rec.Size {
+			digests[name] = rec
+		}
+	}
+	return digests
+}
+
+// Adopt 把 sha256 已知的文件直接记入状态（硬链接过来的文件、follower 从 model server 拿到的 sha256），
+// 不重新计算 sha256；调用方负责 Save
+func (s *State) Adopt(name string, size int64, sha256 string) {
+	s.Files[name] = FileState{Size: size, SHA256: sha256, CompleteAt: time.Now().UTC()}
+}
+
+// LocalFiles 是本地各 revision 目录里 sha256 已知的文件，key 是 sha256
+type LocalFiles map[string]localFile
+
+type localFile struct {
+	path string
+	size int64
+}
+
+// LocalFiles 收集除 exclude 以外所有 revision 目录里 sha256 已知的文件，flat 布局返回空
+func (l Layout) LocalFiles(exclude string) LocalFiles {
+	files := LocalFiles{}
+	if !l.Versioned {
+		return files
+	}
+	repos, err := os.ReadDir(l.Root)
+	if err != nil {
+		return files
+	}
+	for _, repo := range repos {
+		// 不同仓库之间也可以复用（同一个基座的微调版本 tokenizer 一样），sha256 对上就是同一个文件
+		if !repo.IsDir() || strings.HasPrefix(repo.Name(), ".") || !strings.Contains(repo.Name(), "--") {
+			continue
+		}
+		revisions, err := os.ReadDir(filepath.Join(l.Root, repo.Name()))
+		if err != nil {
+			continue
+		}
+		for _, rev := range revisions {
+			dir := filepath.Join(l.Root, repo.Name(), rev.Name())
+			if !rev.IsDir() || dir == filepath.Clean(exclude) {
+				continue
+			}
+			for name, rec := range ReadDigests(dir) {
+				files[rec.SHA256] = localFile{path: filepath.Join(dir, name), size: rec.Size}
+			}
+		}
+	}
+	return files
+}
+
+// Link 把 sha256 和大小都对得上的本地文件硬链接到 dst，没有这样的文件或者链接失败时返回 false
+func (f LocalFiles) Link(sha256 string, size int64, dst string) bool {
+	src, ok := f[sha256]
+	if !ok || sha256 == "" || src.size != size {
+		return false
+	}
+	// 链接之前再确认一次，GC 可能刚删掉这个 revision
+	if info, err := os.Stat(src.path); err != nil || info.Size() != size {
+		return false
+	}
+	_ = os.Remove(dst)
+	return os.Link(src.path, dst) == nil
+}
+
+// ReuseResult 是一次增量同步复用的文件
+type ReuseResult struct {
+	Files int
+	Bytes int64
+}
+
+// Add 记一个复用的文件
+func (r *ReuseResult) Add(size int64) {
+	r.Files++
+	r.Bytes += size
+}
+
+// String 是给日志用的摘要
+func (r ReuseResult) String() string {
+	return fmt.Sprintf("reused %d unchanged files (%d MiB) from older revisions", r.Files, r.Bytes>>20)
+}
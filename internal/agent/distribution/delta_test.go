@@ -0,0 +1,84 @@
+package distribution
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeRevision 在 versioned 布局下写一个 revision，状态文件里记下每个文件的 sha256
+func writeRevision(t *testing.T, l Layout, repo, revision string, names ...string) string {
+	t.Helper()
+	dir := l.Dir(repo, revision)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, names...)
+	state := LoadState(dir, repo, revision)
+	for _, name := range names {
+		if err := state.Record(dir, RemoteFile{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := state.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestReadDigests(t *testing.T) {
+	l := Layout{Root: t.TempDir(), Versioned: true}
+	dir := writeRevision(t, l, "Qwen/Qwen2.5-7B", "abc123", "config.json", "model.safetensors")
+	// 记录之后被改过的文件不算
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	digests := ReadDigests(dir)
+	if len(digests) != 1 || digests["model.safetensors"].SHA256 == "" {
+		t.Errorf("ReadDigests = %v, want only model.safetensors", digests)
+	}
+	if got := ReadDigests(t.TempDir()); len(got) != 0 {
+		t.Errorf("ReadDigests without state = %v, want empty", got)
+	}
+}
+
+func TestLocalFilesLink(t *testing.T) {
+	l := Layout{Root: t.TempDir(), Versioned: true}
+	old := writeRevision(t, l, "Qwen/Qwen2.5-7B", "abc123", "tokenizer.json", "model.safetensors")
+	digests := ReadDigests(old)
+
+	next := l.Dir("Qwen/Qwen2.5-7B", "def456")
+	if err := os.MkdirAll(next, 0755); err != nil {
+		t.Fatal(err)
+	}
+	local := l.LocalFiles(next)
+	if len(local) != 2 {
+		t.Fatalf("LocalFiles = %v, want 2 files", local)
+	}
+
+	shard := digests["model.safetensors"]
+	dst := filepath.Join(next, "model.safetensors")
+	if !local.Link(shard.SHA256, shard.Size, dst) {
+		t.Fatal("Link of an unchanged shard failed")
+	}
+	data, err := os.ReadFile(dst)
+	if err != nil || string(data) != "model.safetensors" {
+		t.Errorf("linked file = %q, %v", data, err)
+	}
+	// 大小对不上、没见过的 sha256 都不链接
+	if local.Link(shard.SHA256, shard.Size+1, filepath.Join(next, "other.safetensors")) {
+		t.Error("Link with a different size succeeded")
+	}
+	if local.Link("0000", 1, filepath.Join(next, "tokenizer.json")) {
+		t.Error("Link of an unknown sha256 succeeded")
+	}
+
+	// 目标目录自己的文件不算本地缓存
+	if got := l.LocalFiles(old); len(got) != 0 {
+		t.Errorf("LocalFiles excluding the only revision = %v, want empty", got)
+	}
+	if got := (Layout{Root: l.Root}).LocalFiles(next); len(got) != 0 {
+		t.Errorf("flat LocalFiles = %v, want empty", got)
+	}
+}
@@ -0,0 +1,329 @@
+package distribution
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// 模型目录垃圾回收
+// ============================================================================
+//
+// 模型目录是平铺的（vLLM 直接读 /models），换模型、上游仓库更新、follower 被提升为 coordinator 之后
+// 目录里会留下不再属于当前模型的文件：
+//   - 旧 revision 里有、新 revision 里没有的文件（还会被 Seal 写进新清单，分发给所有 follower）
+//   - 中断的下载留下的 .partial
+//
+// 两个操作：
+//   - SwitchRevision：下载另一个 repo/revision 之前，把当前副本归档到 .kubeinfer-revisions/<repo>@<revision>/，
+//     只保留最近 keepRevisions-1 个归档；要下载的版本正好在归档里时先搬回来
+//   - Prune：下载/同步完成后、写清单和校验之前，删掉不在当前文件列表里的顶层文件
+//
+// 安全检查：任何进程（推理服务）打开或 mmap 着的文件都不动，见 filesInUse。
+// 只处理顶层文件，和清单、model server 的范围一致；子目录（例如 huggingface-cli 的 .cache）不动。
+// LLMService 删除时模型卷（emptyDir）随 Pod 一起被 kubelet 回收，这里只管 Pod 活着期间的目录。
+// ============================================================================
+
+const (
+	// KeepRevisionsEnv 是保留的 revision 数（包括当前的），controller 根据 spec.distribution.retention 设置
+	KeepRevisionsEnv = "MODEL_KEEP_REVISIONS"
+	// DefaultKeepRevisions 只保留当前 revision
+	DefaultKeepRevisions = 1
+
+	// RevisionsDir 是归档旧 revision 的隐藏目录，model server 不分发，清单也不包含
+	RevisionsDir = ".kubeinfer-revisions"
+)
+
+// procRoot 是 /proc，测试时替换
+var procRoot = "/proc"
+
+// KeepRevisionsFromEnv 读取 MODEL_KEEP_REVISIONS，没有设置或不合法时返回 DefaultKeepRevisions
+func KeepRevisionsFromEnv() int {
+	if n, err := strconv.Atoi(os.Getenv(KeepRevisionsEnv)); err == nil && n >= 1 {
+		return n
+	}
+	return DefaultKeepRevisions
+}
+
+// PruneResult 是一次 Prune 的结果
+type PruneResult struct {
+	// Removed 是删掉的文件
+	Removed []string
+	// Freed 是释放的字节数
+	Freed int64
+	// InUse 是本来要删、但有进程正在使用的文件
+	InUse []string
+}
+
+// String 是给日志用的摘要
+func (r PruneResult) String() string {
+	s := fmt.Sprintf("removed %d stale files (%d MiB)", len(r.Removed), r.Freed>>20)
+	if len(r.InUse) > 0 {
+		s += fmt.Sprintf(", kept %d in use: %s", len(r.InUse), strings.Join(r.InUse, ", "))
+	}
+	return s
+}
+
+// Prune 删除 modelPath 顶层不在 keep 里的文件和残留的 .partial
+//
+// 清单、签名和隐藏的状态文件不删；有进程打开或 mmap 着的文件跳过，记在 InUse 里。
+// 读不了 /proc（判断不了文件是否在用）时什么都不删，返回错误。
+func Prune(modelPath string, keep []string) (PruneResult, error) {
+	var res PruneResult
+	entries, err := os.ReadDir(modelPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return res, nil
+		}
+		return res, err
+	}
+	wanted := make(map[string]bool, len(keep))
+	for _, name := range keep {
+		wanted[name] = true
+	}
+
+	var stale []os.DirEntry
+	for _, e := range entries {
+		name := e.Name()
+		if !e.Type().IsRegular() || wanted[name] || name == ManifestFile || name == SignatureFile {
+			continue
+		}
+		// 隐藏文件是 kubeinfer 自己的状态，只有写到一半的临时文件可以删
+		if strings.HasPrefix(name, ".") && !strings.HasSuffix(name, partialSuffix) {
+			continue
+		}
+		stale = append(stale, e)
+	}
+	if len(stale) == 0 {
+		return res, nil
+	}
+
+	inUse, err := filesInUse(modelPath)
+	if err != nil {
+		return res, fmt.Errorf("cannot tell which model files are in use: %w", err)
+	}
+	for _, e := range stale {
+		path := filepath.Join(modelPath, e.Name())
+		if inUse[e.Name()] {
+			res.InUse = append(res.InUse, e.Name())
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return res, err
+		}
+		res.Removed = append(res.Removed, e.Name())
+		res.Freed += info.Size()
+	}
+	return res, nil
+}
+
+// SwitchRevision 在下载 repo@revision 之前调用，处理目录里的其他 revision
+//
+// 目录里是另一个 repo/revision（按状态文件判断）时把它归档，只保留最近 keepRevisions-1 个归档
+// （keepRevisions 为 1 时归档马上被删掉，先腾出磁盘再下载）。旧 revision 的状态已经作废，
+// 所有文件都要重新下载，归档不会多下载什么。要下载的版本在归档里时把它搬回来，状态文件一起搬回来，
+// 只需要补下载差异。revision 为空时只比较 repo。返回 true 表示目录里原来是另一个版本。
+func SwitchRevision(modelPath, repo, revision string, keepRevisions int) (bool, error) {
+	current := readState(modelPath)
+	switched := current != nil && !current.sameRevision(repo, revision)
+
+	if switched {
+		if err := archive(modelPath, revisionKey(current.Repo, current.Revision)); err != nil {
+			return switched, err
+		}
+	}
+	if err := restore(modelPath, revisionKey(repo, revision)); err != nil {
+		return switched, err
+	}
+	return switched, trimArchives(modelPath, keepRevisions-1)
+}
+
+// readState 读取状态文件，不存在或者不认识时返回 nil
+func readState(modelPath string) *State {
+	data, err := os.ReadFile(filepath.Join(modelPath, StateFile))
+	if err != nil {
+		return nil
+	}
+	s := &State{}
+	if err := json.Unmarshal(data, s); err != nil || s.Version != StateVersion || s.Repo == "" {
+		return nil
+	}
+	return s
+}
+
+// sameRevision 和 LoadState 判断状态是否作废的规则一致
+func (s *State) sameRevision(repo, revision string) bool {
+	return s.Repo == repo && (revision == "" || s.Revision == "" || s.Revision == revision)
+}
+
+// StateRepo 返回状态文件记录的 repo，没有状态文件时返回空
+//
+// coordinator 用它判断带完整标记的目录是不是当前模型（复用的 hostPath/PVC、预热过别的模型的目录）
+func StateRepo(modelPath string) string {
+	if s := readState(modelPath); s != nil {
+		return s.Repo
+	}
+	return ""
+}
+
+// StateRevision 返回状态文件记录的 revision，没有状态文件、没有记录（huggingface-cli 整仓库下载）时返回空
+//
+// coordinator 按它决定上传的快照属于哪个 revision
+func StateRevision(modelPath string) string {
+	if s := readState(modelPath); s != nil {
+		return s.Revision
+	}
+	return ""
+}
+
+// revisionKey 是归档目录名：Qwen/Qwen2.5-7B@abc123 → Qwen--Qwen2.5-7B@abc123
+func revisionKey(repo, revision string) string {
+	key := strings.ReplaceAll(repo, "/", "--")
+	if revision != "" {
+		key += "@" + revision
+	}
+	return key
+}
+
+// archive 把顶层的模型文件、清单和状态文件搬到 RevisionsDir/key（同一个文件系统，rename 不拷贝数据）
+func archive(modelPath, key string) error {
+	files, err := modelFiles(modelPath)
+	if err != nil {
+		return err
+	}
+	inUse, err := filesInUse(modelPath)
+	if err != nil {
+		return fmt.Errorf("cannot tell which model files are in use: %w", err)
+	}
+	dir := filepath.Join(modelPath, RevisionsDir, key)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	names := []string{ManifestFile, SignatureFile, StateFile}
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	for _, name := range names {
+		if inUse[name] {
+			continue
+		}
+		src := filepath.Join(modelPath, name)
+		if err := os.Rename(src, filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// restore 把归档 key 搬回顶层，顶层已经有同名文件时保留顶层的
+func restore(modelPath, key string) error {
+	dir := filepath.Join(modelPath, RevisionsDir, key)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, e := range entries {
+		dst := filepath.Join(modelPath, e.Name())
+		if _, err := os.Lstat(dst); err == nil {
+			continue
+		}
+		if err := os.Rename(filepath.Join(dir, e.Name()), dst); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(dir)
+}
+
+// trimArchives 只保留最近 keep 个归档（按归档时间）
+func trimArchives(modelPath string, keep int) error {
+	root := filepath.Join(modelPath, RevisionsDir)
+	if keep <= 0 {
+		return os.RemoveAll(root)
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	type archived struct {
+		name string
+		mod  int64
+	}
+	var dirs []archived
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && e.IsDir() {
+			dirs = append(dirs, archived{e.Name(), info.ModTime().UnixNano()})
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].mod > dirs[j].mod })
+	for _, d := range dirs[min(keep, len(dirs)):] {
+		if err := os.RemoveAll(filepath.Join(root, d.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filesInUse 返回 dir 顶层被任何进程打开（/proc/<pid>/fd）或 mmap（/proc/<pid>/maps）的文件名
+//
+// vLLM 用 mmap 读 safetensors，加载完之后 fd 可能已经关了，所以两个都要看。
+// 只能看到同一个 PID namespace 的进程：agent 模式下推理服务是 agent 的子进程；
+// initContainer 模式下 download 在推理容器启动之前运行。
+func filesInUse(dir string) (map[string]bool, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	procs, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+	prefix := abs + string(filepath.Separator)
+	inUse := map[string]bool{}
+	add := func(path string) {
+		// 只关心顶层文件，子目录里的（包括归档）不算
+		if name := strings.TrimPrefix(path, prefix); !strings.Contains(name, string(filepath.Separator)) {
+			inUse[name] = true
+		}
+	}
+	for _, p := range procs {
+		if _, err := strconv.Atoi(p.Name()); err != nil {
+			continue
+		}
+		pid := filepath.Join(procRoot, p.Name())
+		// 进程可能已经退出，或者没有权限读，跳过
+		if fds, err := os.ReadDir(filepath.Join(pid, "fd")); err == nil {
+			for _, fd := range fds {
+				if target, err := os.Readlink(filepath.Join(pid, "fd", fd.Name())); err == nil && strings.HasPrefix(target, prefix) {
+					add(target)
+				}
+			}
+		}
+		if maps, err := os.ReadFile(filepath.Join(pid, "maps")); err == nil {
+			for _, line := range strings.Split(string(maps), "\n") {
+				// 格式：地址 权限 偏移 设备 inode 路径
+				if i := strings.Index(line, prefix); i >= 0 {
+					add(strings.TrimSuffix(line[i:], " (deleted)"))
+				}
+			}
+		}
+	}
+	return inUse, nil
+}
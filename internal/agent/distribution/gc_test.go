@@ -0,0 +1,151 @@
+package distribution
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// fakeProc 把 procRoot 换成一个临时目录，pid 1 打开了 openFiles、mmap 了 mapped
+func fakeProc(t *testing.T, openFiles []string, mapped string) {
+	t.Helper()
+	root := t.TempDir()
+	fdDir := filepath.Join(root, "1", "fd")
+	if err := os.MkdirAll(fdDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i, path := range openFiles {
+		if err := os.Symlink(path, filepath.Join(fdDir, string(rune('3'+i)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	maps := "7f0000000000-7f0000001000 r--p 00000000 08:01 42 /usr/lib/libc.so.6\n"
+	if mapped != "" {
+		maps += "7f0000002000-7f0000003000 r--s 00000000 08:01 43 " + mapped + "\n"
+	}
+	if err := os.WriteFile(filepath.Join(root, "1", "maps"), []byte(maps), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := procRoot
+	procRoot = root
+	t.Cleanup(func() { procRoot = old })
+}
+
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir,
+		"config.json", "model.safetensors", ManifestFile, StateFile, // 当前 revision
+		"old.safetensors", "tokenizer.model.partial", // 旧 revision 和中断的下载
+		"open.safetensors", "mapped.safetensors", // 旧 revision，但推理服务还在用
+	)
+	if err := os.Mkdir(filepath.Join(dir, ".cache"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	fakeProc(t, []string{filepath.Join(dir, "open.safetensors")}, filepath.Join(dir, "mapped.safetensors"))
+
+	res, err := Prune(dir, []string{"config.json", "model.safetensors"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(res.Removed)
+	if want := []string{"old.safetensors", "tokenizer.model.partial"}; !slices.Equal(res.Removed, want) {
+		t.Errorf("Removed = %v, want %v", res.Removed, want)
+	}
+	slices.Sort(res.InUse)
+	if want := []string{"mapped.safetensors", "open.safetensors"}; !slices.Equal(res.InUse, want) {
+		t.Errorf("InUse = %v, want %v", res.InUse, want)
+	}
+	for _, name := range []string{"config.json", "model.safetensors", ManifestFile, StateFile, ".cache", "open.safetensors"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s was removed", name)
+		}
+	}
+}
+
+func TestPruneWithoutProc(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "old.safetensors")
+	old := procRoot
+	procRoot = filepath.Join(dir, "missing")
+	defer func() { procRoot = old }()
+
+	if _, err := Prune(dir, nil); err == nil {
+		t.Error("Prune without /proc should fail")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.safetensors")); err != nil {
+		t.Error("Prune without /proc must not remove files")
+	}
+}
+
+// TestSwitchRevision 测试换 revision 时归档旧版本、切回来时恢复
+func TestSwitchRevision(t *testing.T) {
+	fakeProc(t, nil, "")
+	dir := t.TempDir()
+	saveState := func(repo, revision string) {
+		t.Helper()
+		if err := LoadState(dir, repo, revision).Save(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exists := func(path string) bool {
+		_, err := os.Stat(filepath.Join(dir, path))
+		return err == nil
+	}
+
+	// v1 下载完成
+	saveState("qwen/Qwen2-7B", "v1")
+	writeFiles(t, dir, "v1.safetensors")
+
+	// 同一个 revision：什么都不做
+	if switched, err := SwitchRevision(dir, "qwen/Qwen2-7B", "v1", 2); err != nil || switched {
+		t.Fatalf("SwitchRevision(v1) = %v, %v, want false", switched, err)
+	}
+
+	// 仓库更新到 v2：v1 归档
+	if switched, err := SwitchRevision(dir, "qwen/Qwen2-7B", "v2", 2); err != nil || !switched {
+		t.Fatalf("SwitchRevision(v2) = %v, %v, want true", switched, err)
+	}
+	if exists("v1.safetensors") || !exists(filepath.Join(RevisionsDir, "qwen--Qwen2-7B@v1", "v1.safetensors")) {
+		t.Error("v1 was not archived")
+	}
+	saveState("qwen/Qwen2-7B", "v2")
+	writeFiles(t, dir, "v2.safetensors")
+
+	// 回滚到 v1：v1 搬回来（连同状态文件），v2 归档
+	if _, err := SwitchRevision(dir, "qwen/Qwen2-7B", "v1", 2); err != nil {
+		t.Fatal(err)
+	}
+	if !exists("v1.safetensors") || exists("v2.safetensors") {
+		t.Error("v1 was not restored")
+	}
+	if s := readState(dir); s == nil || s.Revision != "v1" {
+		t.Errorf("state after restore = %+v, want revision v1", s)
+	}
+
+	// 只保留当前 revision：换模型时旧模型直接删掉，归档也删掉
+	if _, err := SwitchRevision(dir, "meta-llama/Llama-3-8B", "main", 1); err != nil {
+		t.Fatal(err)
+	}
+	if exists("v1.safetensors") || exists(StateFile) || exists(RevisionsDir) {
+		t.Error("previous model was not removed with keepRevisions=1")
+	}
+}
+
+func TestKeepRevisionsFromEnv(t *testing.T) {
+	for value, want := range map[string]int{"": 1, "3": 3, "0": 1, "abc": 1} {
+		t.Setenv(KeepRevisionsEnv, value)
+		if got := KeepRevisionsFromEnv(); got != want {
+			t.Errorf("KeepRevisionsFromEnv(%q) = %d, want %d", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package distribution
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ============================================================================
+// 模型目录布局：flat 或 versioned（MODEL_LAYOUT）
+// ============================================================================
+//
+// flat（默认）：模型文件直接放在 MODEL_PATH 下，换 revision 时旧文件归档到 .kubeinfer-revisions（见 gc.go）。
+//
+// versioned：每个 revision 一个目录，推理服务读 current 软链接：
+//
+//	/models/Qwen--Qwen2.5-7B/abc123/        旧 revision，回滚时直接切回来
+//	/models/Qwen--Qwen2.5-7B/def456/        当前 revision
+//	/models/current -> Qwen--Qwen2.5-7B/def456
+//
+//   - 新 revision 下载/同步到自己的目录，校验通过之后才切换 current（rename 一个临时软链接，原子操作），
+//     推理服务在切换之前一直读旧 revision，切换之后下一次启动读新的
+//   - 回滚到还在磁盘上的 revision 不需要下载，只切软链接
+//   - GC 只删不是 current、没有进程在用、超出 keepRevisions 的 revision 目录
+//
+// model server 通过 RevisionHeader 告诉 follower 自己的 revision，follower 同步到同名目录。
+// 适合复用 hostPath/PVC 的部署；emptyDir 每次都是空的，flat 就够了。
+// ============================================================================
+
+const (
+	// LayoutEnv 是模型目录布局，controller 根据 spec.distribution.layout 设置
+	LayoutEnv = "MODEL_LAYOUT"
+
+	LayoutFlat      = "flat"
+	LayoutVersioned = "versioned"
+
+	// CurrentLink 是 versioned 布局下指向当前 revision 的软链接
+	CurrentLink = "current"
+
+	// RevisionHeader 是 model server 的 GET /models 响应里的 revision（versioned 布局才有）
+	RevisionHeader = "X-Kubeinfer-Model-Revision"
+
+	// defaultRevision 是不知道 revision 时的目录名（上游 API 不可用），和 HuggingFace 的默认分支同名
+	defaultRevision = "main"
+)
+
+// Layout 是 MODEL_PATH 下的目录布局
+type Layout struct {
+	// Root 是 MODEL_PATH
+	Root string
+	// Versioned 为 true 时每个 revision 一个目录
+	Versioned bool
+}
+
+// LayoutFromEnv 按 MODEL_LAYOUT 返回 root 的布局，没有设置或不认识时是 flat
+func LayoutFromEnv(root string) Layout {
+	return Layout{Root: root, Versioned: os.Getenv(LayoutEnv) == LayoutVersioned}
+}
+
+// Dir 返回 repo@revision 的模型目录，flat 布局总是 Root
+func (l Layout) Dir(repo, revision string) string {
+	if !l.Versioned {
+		return l.Root
+	}
+	if revision == "" {
+		revision = defaultRevision
+	}
+	return filepath.Join(l.Root, revisionKey(repo, ""), revision)
+}
+
+// Current 返回推理服务读取的目录，flat 布局是 Root
+func (l Layout) Current() string {
+	if !l.Versioned {
+		return l.Root
+	}
+	return filepath.Join(l.Root, CurrentLink)
+}
+
+// CurrentRevision 返回 current 指向的 revision，没有 current 时返回空
+func (l Layout) CurrentRevision() string {
+	_, revision := l.currentTarget()
+	return revision
+}
+
+// CurrentRevisionOf 返回 current 指向的 repo 的 revision，current 是别的模型或者不存在时返回空
+func (l Layout) CurrentRevisionOf(repo string) string {
+	repoDir, revision := l.currentTarget()
+	if repoDir != revisionKey(repo, "") {
+		return ""
+	}
+	return revision
+}
+
+// currentTarget 返回 current 指向的 repo 目录名和 revision
+func (l Layout) currentTarget() (repoDir, revision string) {
+	if !l.Versioned {
+		return "", ""
+	}
+	target, err := os.Readlink(filepath.Join(l.Root, CurrentLink))
+	if err != nil {
+		return "", ""
+	}
+	return filepath.Base(filepath.Dir(target)), filepath.Base(target)
+}
+
+// Activate 把 current 切换到 dir（Dir 返回的目录），flat 布局什么都不做
+//
+// 先建临时软链接再 rename 覆盖，任何时候读 current 都能拿到旧的或新的一个
+func (l Layout) Activate(dir string) error {
+	if !l.Versioned {
+		return nil
+	}
+	rel, err := filepath.Rel(l.Root, dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("%s is not under %s", dir, l.Root)
+	}
+	tmp := filepath.Join(l.Root, "."+CurrentLink+partialSuffix)
+	_ = os.Remove(tmp)
+	if err := os.Symlink(rel, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(l.Root, CurrentLink))
+}
+
+// RevisionGCResult 是一次 GCRevisions 的结果
+type RevisionGCResult struct {
+	// Removed 是删掉的 revision（<repo>/<revision>）
+	Removed []string
+	// InUse 是超出保留数、但有进程在用的 revision
+	InUse []string
+}
+
+// GCRevisions 只保留最近 keep 个 revision 目录（包括 current，按修改时间），flat 布局什么都不做
+//
+// current 指向的目录和有进程打开或 mmap 着文件的目录不删；读不了 /proc 时什么都不删，返回错误
+func (l Layout) GCRevisions(keep int) (RevisionGCResult, error) {
+	var res RevisionGCResult
+	if !l.Versioned {
+		return res, nil
+	}
+	currentRepo, currentRev := l.currentTarget()
+
+	type revisionDir struct {
+		name string
+		mod  int64
+	}
+	var dirs []revisionDir
+	repos, err := os.ReadDir(l.Root)
+	if err != nil {
+		return res, err
+	}
+	for _, repo := range repos {
+		// 只看 <owner>--<name> 形式的 repo 目录：current 软链接、隐藏目录（.cache、归档）、
+		// PVC 上的 lost+found 都不是
+		if !repo.IsDir() || strings.HasPrefix(repo.Name(), ".") || !strings.Contains(repo.Name(), "--") {
+			continue
+		}
+		revisions, err := os.ReadDir(filepath.Join(l.Root, repo.Name()))
+		if err != nil {
+			return res, err
+		}
+		for _, rev := range revisions {
+			info, err := rev.Info()
+			if err != nil || !rev.IsDir() || (repo.Name() == currentRepo && rev.Name() == currentRev) {
+				continue
+			}
+			dirs = append(dirs, revisionDir{filepath.Join(repo.Name(), rev.Name()), info.ModTime().UnixNano()})
+		}
+	}
+	// current 占一个名额
+	keep--
+	if keep >= len(dirs) {
+		return res, nil
+	}
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].mod > dirs[j].mod })
+	for _, d := range dirs[max(keep, 0):] {
+		path := filepath.Join(l.Root, d.name)
+		inUse, err := filesInUse(path)
+		if err != nil {
+			return res, fmt.Errorf("cannot tell which model files are in use: %w", err)
+		}
+		if len(inUse) > 0 {
+			res.InUse = append(res.InUse, d.name)
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return res, err
+		}
+		res.Removed = append(res.Removed, d.name)
+		// 最后一个 revision 删掉之后 repo 目录也删掉（不是空的时候 Remove 会失败，忽略）
+		_ = os.Remove(filepath.Dir(path))
+	}
+	return res, nil
+}
+
+// String 是给日志用的摘要
+func (r RevisionGCResult) String() string {
+	s := fmt.Sprintf("removed %d old revisions", len(r.Removed))
+	if len(r.Removed) > 0 {
+		s += ": " + strings.Join(r.Removed, ", ")
+	}
+	if len(r.InUse) > 0 {
+		s += fmt.Sprintf(", kept %d in use: %s", len(r.InUse), strings.Join(r.InUse, ", "))
+	}
+	return s
+}
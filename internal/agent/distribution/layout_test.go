@@ -0,0 +1,111 @@
+package distribution
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLayoutDir(t *testing.T) {
+	flat := Layout{Root: "/models"}
+	if got := flat.Dir("Qwen/Qwen2.5-7B", "abc123"); got != "/models" {
+		t.Errorf("flat Dir = %s, want /models", got)
+	}
+	if got := flat.Current(); got != "/models" {
+		t.Errorf("flat Current = %s, want /models", got)
+	}
+
+	versioned := Layout{Root: "/models", Versioned: true}
+	if got, want := versioned.Dir("Qwen/Qwen2.5-7B", "abc123"), "/models/Qwen--Qwen2.5-7B/abc123"; got != want {
+		t.Errorf("Dir = %s, want %s", got, want)
+	}
+	if got, want := versioned.Dir("Qwen/Qwen2.5-7B", ""), "/models/Qwen--Qwen2.5-7B/main"; got != want {
+		t.Errorf("Dir without revision = %s, want %s", got, want)
+	}
+	if got, want := versioned.Current(), "/models/current"; got != want {
+		t.Errorf("Current = %s, want %s", got, want)
+	}
+}
+
+func TestLayoutActivate(t *testing.T) {
+	l := Layout{Root: t.TempDir(), Versioned: true}
+	if got := l.CurrentRevision(); got != "" {
+		t.Errorf("CurrentRevision before Activate = %q, want empty", got)
+	}
+
+	for _, revision := range []string{"abc123", "def456", "abc123"} {
+		dir := l.Dir("Qwen/Qwen2.5-7B", revision)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, dir, revision+".safetensors")
+		if err := l.Activate(dir); err != nil {
+			t.Fatal(err)
+		}
+		if got := l.CurrentRevision(); got != revision {
+			t.Errorf("CurrentRevision = %q, want %q", got, revision)
+		}
+		// 推理服务通过 current 读到的是这个 revision 的文件
+		if _, err := os.Stat(filepath.Join(l.Current(), revision+".safetensors")); err != nil {
+			t.Errorf("current does not point at %s: %v", revision, err)
+		}
+	}
+	if got := l.CurrentRevisionOf("Qwen/Qwen2.5-7B"); got != "abc123" {
+		t.Errorf("CurrentRevisionOf = %q, want abc123", got)
+	}
+	if got := l.CurrentRevisionOf("meta-llama/Llama-3.1-8B"); got != "" {
+		t.Errorf("CurrentRevisionOf another repo = %q, want empty", got)
+	}
+
+	if err := l.Activate(t.TempDir()); err == nil {
+		t.Error("Activate outside the root succeeded")
+	}
+}
+
+func TestLayoutGCRevisions(t *testing.T) {
+	l := Layout{Root: t.TempDir(), Versioned: true}
+	// 从旧到新：a、b、c、d，current 是最旧的 a（回滚过）
+	now := time.Now()
+	for i, revision := range []string{"a", "b", "c", "d"} {
+		dir := l.Dir("Qwen/Qwen2.5-7B", revision)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, dir, "model.safetensors")
+		mod := now.Add(time.Duration(i-4) * time.Hour)
+		if err := os.Chtimes(dir, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Activate(l.Dir("Qwen/Qwen2.5-7B", "a")); err != nil {
+		t.Fatal(err)
+	}
+	// 不是 repo 目录，不能删
+	if err := os.MkdirAll(filepath.Join(l.Root, "lost+found", "x"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// b 还有进程在用
+	fakeProc(t, []string{filepath.Join(l.Dir("Qwen/Qwen2.5-7B", "b"), "model.safetensors")}, "")
+
+	res, err := l.GCRevisions(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(res.Removed)
+	if want := []string{filepath.Join("Qwen--Qwen2.5-7B", "c")}; !slices.Equal(res.Removed, want) {
+		t.Errorf("Removed = %v, want %v", res.Removed, want)
+	}
+	if want := []string{filepath.Join("Qwen--Qwen2.5-7B", "b")}; !slices.Equal(res.InUse, want) {
+		t.Errorf("InUse = %v, want %v", res.InUse, want)
+	}
+	for _, revision := range []string{"a", "b", "d"} {
+		if _, err := os.Stat(l.Dir("Qwen/Qwen2.5-7B", revision)); err != nil {
+			t.Errorf("revision %s was removed", revision)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(l.Root, "lost+found", "x")); err != nil {
+		t.Error("lost+found was removed")
+	}
+}
@@ -0,0 +1,97 @@
+package distribution
+
+import "os"
+
+// ============================================================================
+// 本地已有的模型文件（容器重启、nodeCache、PVC 上留下的副本）
+// ============================================================================
+//
+// 以前 follower 只看文件名，本地有这个文件就跳过。flat 布局下 coordinator 换过内容的同名文件会被当成完整的留下来，
+// 最后校验失败，agent 删掉整个目录从头同步，本地大部分没变的文件也跟着重新下载。
+//
+// 现在 follower 每一轮先从 model server 拿清单（kubeinfer-manifest.json，加上 /digests 的 sha256），
+// 按 MODEL_LOCAL_COPY 逐个判断本地文件：
+//
+//	size        大小和清单一致就跳过（默认）
+//	sha256      还要比 sha256，清单和 /digests 里都没有 sha256 时只比大小
+//	redownload  不复用，全部重新下载
+//
+// 只下载缺的和过期的文件（同样先写 .partial 再 rename 覆盖）。清单和签名本身总是从 model server 重新拿。
+// model server 还没有清单（coordinator 还在下载）时不知道应有的大小，本地已有的文件照旧跳过。
+// ============================================================================
+
+const (
+	// LocalCopyEnv 是 follower 对待本地文件的方式，controller 根据 spec.distribution.localCopy 设置
+	LocalCopyEnv = "MODEL_LOCAL_COPY"
+
+	// LocalCopySize 大小一致就复用（和 api/v1 的 LocalCopySize 一致）
+	LocalCopySize = "size"
+	// LocalCopySHA256 大小和 sha256 都一致才复用
+	LocalCopySHA256 = "sha256"
+	// LocalCopyRedownload 不复用本地文件
+	LocalCopyRedownload = "redownload"
+)
+
+// LocalCopyFromEnv 读取 MODEL_LOCAL_COPY，没有设置或者不认识时返回 LocalCopySize
+func LocalCopyFromEnv() string {
+	switch v := os.Getenv(LocalCopyEnv); v {
+	case LocalCopySHA256, LocalCopyRedownload:
+		return v
+	default:
+		return LocalCopySize
+	}
+}
+
+// IsManifestFile 判断 name 是不是清单或者签名，这两个文件不复用本地的
+func IsManifestFile(name string) bool {
+	return name == ManifestFile || name == SignatureFile
+}
+
+// Expected 是 model server 上文件应有的大小和 sha256，key 是文件名
+type Expected map[string]FileEntry
+
+// NewExpected 合并清单和 /digests：清单没有 sha256 时用 /digests 里大小一致的；m 为 nil 时只有 /digests 里的
+func NewExpected(m *Manifest, digests Digests) Expected {
+	e := Expected{}
+	if m != nil {
+		for _, f := range m.Files {
+			e[f.Name] = f
+		}
+	}
+	for name, rec := range digests {
+		entry, ok := e[name]
+		if !ok {
+			entry = FileEntry{Name: name, Size: rec.Size}
+		}
+		if entry.SHA256 == "" && entry.Size == rec.Size {
+			entry.SHA256 = rec.SHA256
+		}
+		e[name] = entry
+	}
+	return e
+}
+
+// Reusable 判断本地的 path 能不能代替 model server 上的 name，不用重新下载
+//
+// 不知道 name 应有的大小时只看本地存不存在
+func (e Expected) Reusable(path, name, policy string) bool {
+	if policy == LocalCopyRedownload {
+		return false
+	}
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	want, ok := e[name]
+	if !ok {
+		return true
+	}
+	if info.Size() != want.Size {
+		return false
+	}
+	if policy != LocalCopySHA256 || want.SHA256 == "" {
+		return true
+	}
+	sum, err := fileSHA256(path)
+	return err == nil && sum == want.SHA256
+}
@@ -0,0 +1,57 @@
+package distribution
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExpectedReusable(t *testing.T) {
+	dir := writeModel(t)
+	if err := WriteManifest(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	m, _, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := NewExpected(m, nil)
+	// coordinator 上 model.safetensors 换了内容，大小不变
+	stale := expected["model.safetensors"]
+	stale.SHA256 = "0000"
+	expected["model.safetensors"] = stale
+	// coordinator 上 config.json 变大了
+	grown := expected["config.json"]
+	grown.Size++
+	expected["config.json"] = grown
+
+	tests := []struct {
+		name   string
+		policy string
+		want   bool
+	}{
+		{name: "config.json", policy: LocalCopySize, want: false},
+		{name: "model.safetensors", policy: LocalCopySize, want: true},
+		{name: "model.safetensors", policy: LocalCopySHA256, want: false},
+		{name: "model.safetensors", policy: LocalCopyRedownload, want: false},
+		// 清单里还没有：本地有就先跳过
+		{name: ".kubeinfer-complete", policy: LocalCopySHA256, want: true},
+		{name: "tokenizer.json", policy: LocalCopySize, want: false},
+	}
+	for _, tt := range tests {
+		if got := expected.Reusable(filepath.Join(dir, tt.name), tt.name, tt.policy); got != tt.want {
+			t.Errorf("Reusable(%s, %s) = %v, want %v", tt.name, tt.policy, got, tt.want)
+		}
+	}
+}
+
+func TestNewExpectedDigests(t *testing.T) {
+	m := &Manifest{Files: []FileEntry{{Name: "a", Size: 3}, {Name: "b", Size: 4}}}
+	e := NewExpected(m, Digests{
+		"a": {Size: 3, SHA256: "aaa"},
+		"b": {Size: 5, SHA256: "bbb"}, // 大小对不上：不是同一个文件，不用它的 sha256
+		"c": {Size: 6, SHA256: "ccc"},
+	})
+	if e["a"].SHA256 != "aaa" || e["b"].SHA256 != "" || e["c"].Size != 6 {
+		t.Errorf("NewExpected = %+v", e)
+	}
+}
@@ -0,0 +1,20 @@
+package distribution
+
+import "os"
+
+// ModelServerEnv 为 "false" 时不运行 model server（controller 根据 spec.distribution.modelServer 设置）
+//
+// 只有一个副本时没有 follower 来拿模型：agent 不按角色切换，自己下载后启动推理服务，不监听 8080
+const ModelServerEnv = "MODEL_SERVER_ENABLED"
+
+// ModelServerEnabledFromEnv 返回是否运行 model server，没有设置时运行
+func ModelServerEnabledFromEnv() bool {
+	return os.Getenv(ModelServerEnv) != "false"
+}
+
+// coordinator 交接（见 coordinator/handoff.go）：新 coordinator 从上一个 coordinator 补齐副本时
+// 请求带 HandoffHeader，结束时 POST HandoffDonePath；上一个 coordinator 排空时等到交接结束才关闭
+const (
+	HandoffHeader   = "X-Kubeinfer-Handoff"
+	HandoffDonePath = "/handoff/done"
+)
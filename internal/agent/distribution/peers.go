@@ -0,0 +1,51 @@
+package distribution
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ============================================================================
+// follower 的只读 model server（spec.distribution.peerServing）
+// ============================================================================
+//
+// follower 同步完成后在 8080 上提供自己的副本，并定期向 coordinator 的 POST /peers 注册。
+// 之后同步的 follower 先从 coordinator 拿一份 peer 列表，coordinator 中途挂了就换一个 peer 继续，
+// 不用等新的 coordinator 选出来再从头开始——任何一个还活着的副本都能当种子。
+//
+// peer 的 model server 和注册接口要求 Authorization: Bearer <token>，
+// token 在 controller 生成的 Secret 里（每个 LLMService 一个），通过 MODEL_SERVER_TOKEN 注入。
+// ============================================================================
+
+// 环境变量，controller 根据 spec.distribution.peerServing 设置
+const (
+	PeerServingEnv = "MODEL_PEER_SERVING"
+	ServerTokenEnv = "MODEL_SERVER_TOKEN"
+	// PodIPEnv 是 Pod IP（Downward API），peer 用它拼出自己的地址
+	PodIPEnv = "POD_IP"
+)
+
+// PeerServingFromEnv 返回是否开启了 peer serving
+func PeerServingFromEnv() bool {
+	return os.Getenv(PeerServingEnv) == "true"
+}
+
+// ServerTokenFromEnv 返回 model server 之间的 token，没有设置时为空
+func ServerTokenFromEnv() string {
+	return os.Getenv(ServerTokenEnv)
+}
+
+// SetToken 给发往 model server 的请求带上 token，token 为空时什么都不做
+func SetToken(req *http.Request, token string) {
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// Authorized 检查请求是否带了正确的 token
+func Authorized(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
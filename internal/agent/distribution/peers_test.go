@@ -0,0 +1,27 @@
+package distribution
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthorized(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{name: "matching token", token: "s3cret", want: true},
+		{name: "wrong token", token: "other"},
+		{name: "no token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/models", nil)
+			SetToken(req, tt.token)
+			if got := Authorized(req, "s3cret"); got != tt.want {
+				t.Errorf("Authorized() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package distribution
+
+import (
+	"context"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+// 模型从哪里来，指标的 source 标签
+const (
+	// SourceHub 是 HuggingFace、镜像站或者模型仓库（MLflow、W&B）
+	SourceHub = "hub"
+	// SourcePeer 是另一个 agent 的 model server（coordinator 或 fleet 的 hub 集群）
+	SourcePeer = "peer"
+)
+
+// LLMServiceEnv 是 controller 给 agent 设置的 LLMService 名称，用作指标标签
+const LLMServiceEnv = "LLMSERVICE_NAME"
+
+// ProgressLabels 是同步进度指标的标签
+type ProgressLabels struct {
+	Namespace  string
+	LLMService string
+	Model      string
+	Source     string
+}
+
+// LabelsFromEnv 从 POD_NAMESPACE / LLMSERVICE_NAME / MODEL_REPO 读取标签
+func LabelsFromEnv(source string) ProgressLabels {
+	return ProgressLabels{
+		Namespace:  os.Getenv("POD_NAMESPACE"),
+		LLMService: os.Getenv(LLMServiceEnv),
+		Model:      os.Getenv("MODEL_REPO"),
+		Source:     source,
+	}
+}
+
+// Progress 统计一次模型同步的进度：收到的字节数、带宽和预计剩余时间
+//
+// coordinator（hfhub.Downloader）和 follower 共用，读到数据时调用 Add，
+// Run 定期计算带宽和 ETA 写到指标里。并发安全。
+type Progress struct {
+	labels  ProgressLabels
+	total   atomic.Int64
+	written atomic.Int64
+
+	started  time.Time
+	finished atomic.Bool
+
+	mu     sync.Mutex
+	last   int64
+	lastAt time.Time
+	rate   float64
+}
+
+// latest 是这个进程最近一次开始的同步，agent 的 /debug/download 展示它
+var latest atomic.Pointer[Progress]
+
+// ProgressSnapshot 是同步进度的快照
+type ProgressSnapshot struct {
+	Source  string    `json:"source"`
+	Written int64     `json:"writtenBytes"`
+	Total   int64     `json:"totalBytes"`
+	Started time.Time `json:"started"`
+	// Rate 是最近一次采样的带宽（bytes/s）
+	Rate     float64 `json:"bytesPerSecond"`
+	Finished bool    `json:"finished"`
+}
+
+// LatestProgress 返回最近一次同步的进度，还没有同步过时 ok 为 false
+func LatestProgress() (snapshot ProgressSnapshot, ok bool) {
+	p := latest.Load()
+	if p == nil {
+		return ProgressSnapshot{}, false
+	}
+	p.mu.Lock()
+	rate := p.rate
+	p.mu.Unlock()
+	return ProgressSnapshot{
+		Source:   p.labels.Source,
+		Written:  p.written.Load(),
+		Total:    p.total.Load(),
+		Started:  p.started,
+		Rate:     rate,
+		Finished: p.finished.Load(),
+	}, true
+}
+
+// NewProgress 创建 Progress，total 是要同步的总字节数（未知时为 0，ETA 一直是 0）
+func NewProgress(labels ProgressLabels, total int64) *Progress {
+	now := time.Now()
+	p := &Progress{labels: labels, started: now, lastAt: now}
+	p.total.Store(total)
+	latest.Store(p)
+	return p
+}
+
+// SetTotal 更新总字节数（follower 每次拉文件列表都可能变大）
+func (p *Progress) SetTotal(total int64) {
+	p.total.Store(total)
+}
+
+// Add 记录收到的 n 个字节，p 为 nil 时什么都不做
+func (p *Progress) Add(n int) {
+	if p == nil {
+		return
+	}
+	p.written.Add(int64(n))
+	l := p.labels
+	metrics.RecordModelReceivedBytes(l.Namespace, l.LLMService, l.Model, l.Source, n)
+}
+
+// Written 返回已经收到的字节数
+func (p *Progress) Written() int64 {
+	return p.written.Load()
+}
+
+// Total 返回总字节数
+func (p *Progress) Total() int64 {
+	return p.total.Load()
+}
+
+// FileDone 记录一个文件的下载耗时，p 为 nil 时什么都不做
+func (p *Progress) FileDone(d time.Duration) {
+	if p == nil {
+		return
+	}
+	l := p.labels
+	metrics.RecordModelFileDownload(l.Namespace, l.LLMService, l.Model, l.Source, d.Seconds())
+}
+
+// Sample 计算从上一次 Sample 到 now 的带宽（bytes/s）和按这个带宽估算的剩余时间，并写到指标里
+//
+// 带宽为 0（卡住了）时 ETA 也报 0，不报无穷大：dashboard 上看带宽就知道是卡住还是快好了
+func (p *Progress) Sample(now time.Time) (rate float64, eta time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := p.written.Load()
+	if elapsed := now.Sub(p.lastAt).Seconds(); elapsed > 0 {
+		rate = float64(n-p.last) / elapsed
+	}
+	p.last, p.lastAt, p.rate = n, now, rate
+
+	if remaining := p.total.Load() - n; remaining > 0 && rate > 0 {
+		eta = time.Duration(float64(remaining) / rate * float64(time.Second))
+	}
+	l := p.labels
+	metrics.RecordModelSyncProgress(l.Namespace, l.LLMService, l.Model, l.Source, rate, eta.Seconds())
+	return rate, eta
+}
+
+// Run 每隔 interval 调用一次 Sample 和 report（可以为 nil，用来打日志），返回的函数停止汇报、把带宽和 ETA 归零并标记同步结束
+func (p *Progress) Run(ctx context.Context, interval time.Duration, report func(rate float64, eta time.Duration)) func() {
+	stop := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				rate, eta := p.Sample(now)
+				if report != nil {
+					report(rate, eta)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(stop)
+		<-exited
+		p.finished.Store(true)
+		l := p.labels
+		metrics.RecordModelSyncProgress(l.Namespace, l.LLMService, l.Model, l.Source, 0, 0)
+	}
+}
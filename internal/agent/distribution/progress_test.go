@@ -0,0 +1,41 @@
+package distribution
+
+import (
+	"testing"
+	"time"
+)
+
+// TestProgressSample 测试带宽和 ETA 的计算
+func TestProgressSample(t *testing.T) {
+	p := NewProgress(ProgressLabels{Namespace: "default", LLMService: "qwen", Model: "qwen/Qwen2-7B", Source: SourceHub}, 1000)
+	start := time.Now()
+	p.lastAt = start
+
+	p.Add(100)
+	p.Add(100)
+	rate, eta := p.Sample(start.Add(2 * time.Second))
+	if rate != 100 {
+		t.Errorf("rate = %v, want 100 bytes/s", rate)
+	}
+	// 还剩 800 字节，100 bytes/s
+	if eta != 8*time.Second {
+		t.Errorf("eta = %v, want 8s", eta)
+	}
+
+	// 没有新数据：带宽为 0，ETA 不报无穷大
+	rate, eta = p.Sample(start.Add(4 * time.Second))
+	if rate != 0 || eta != 0 {
+		t.Errorf("stalled sample = %v, %v, want 0, 0", rate, eta)
+	}
+
+	// 总大小未知时不估算
+	p.SetTotal(0)
+	p.Add(50)
+	if _, eta := p.Sample(start.Add(5 * time.Second)); eta != 0 {
+		t.Errorf("eta without total = %v, want 0", eta)
+	}
+
+	var nilProgress *Progress
+	nilProgress.Add(1)
+	nilProgress.FileDone(time.Second)
+}
@@ -0,0 +1,56 @@
+package distribution
+
+import "time"
+
+// ============================================================================
+// 边下载边分发：coordinator 公布每个文件是否可以下载
+// ============================================================================
+//
+// coordinator 列出上游的文件之后就把完整的文件列表（大小、sha256）交给 model server，
+// 每个文件下载完并且 sha256 对上之后标记为 ready：
+//
+//	GET /models              只列 ready 的文件，X-Kubeinfer-Model-Size 是整个 revision 的大小
+//	GET /files?ready=<n>     整个 revision 的 FileIndex；ready 的文件数不超过 n 时
+//	                         等到有新文件 ready、下载结束或者 FilesWaitTimeout 再返回（长轮询）
+//
+// follower 拿完已经 ready 的文件就挂在长轮询上，下一个分片一下载完马上开始拉，
+// 上游下载和集群内传输重叠，不用每隔几秒来问一次。没有下载计划时（下载完了、从 fleet 上游复制）
+// 按目录里已有的文件回答。老版本 coordinator 没有 /files，follower 退回按 pollInterval 轮询。
+// ============================================================================
+
+const (
+	// FilesPath 是 model server 返回文件下载进度的接口
+	FilesPath = "/files"
+	// FilesReadyParam 是长轮询时 follower 已经看到的 ready 文件数
+	FilesReadyParam = "ready"
+	// FilesWaitTimeout 是一次长轮询最多等待的时间
+	FilesWaitTimeout = 30 * time.Second
+)
+
+// FileStatus 是 FileIndex 里的一个文件
+type FileStatus struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+	// Ready 为 true 时文件已经下载完、校验过，可以下载
+	Ready bool `json:"ready"`
+}
+
+// FileIndex 是 GET /files 的响应
+type FileIndex struct {
+	Revision string `json:"revision,omitempty"`
+	// Complete 和 GET /models 的 X-Kubeinfer-Model-Complete 一样，表示 coordinator 已经有完整副本
+	Complete bool         `json:"complete"`
+	Files    []FileStatus `json:"files"`
+}
+
+// ReadyCount 返回 ready 的文件数
+func (i FileIndex) ReadyCount() int {
+	n := 0
+	for _, f := range i.Files {
+		if f.Ready {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,140 @@
+package distribution
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+)
+
+// StateFile 记录 coordinator 下载进度，隐藏文件不会被 model server 分发
+//
+// 为什么需要？
+// 300GB 的模型下载到一半 coordinator 重启，只看"目录非空"分不清哪些文件是完整的。
+// 每下载完一个文件就记一笔（大小 + sha256），重启后只下载没记过或者对不上的文件。
+const StateFile = ".kubeinfer-state.json"
+
+// StateVersion 是状态文件格式版本
+const StateVersion = 1
+
+// State 是下载状态
+type State struct {
+	Version int `json:"version"`
+	// Repo 和 Revision 变了（换了模型或者仓库更新）之前的记录就作废
+	Repo     string               `json:"repo"`
+	Revision string               `json:"revision,omitempty"`
+	Files    map[string]FileState `json:"files"`
+	// Partials 是下载断点槽位里每个 .partial 对应的 model server ETag（见 checkpoint.go）
+	Partials map[string]string `json:"partials,omitempty"`
+}
+
+// FileState 是一个已经下载完成的文件
+type FileState struct {
+	Size       int64     `json:"size"`
+	SHA256     string    `json:"sha256"`
+	CompleteAt time.Time `json:"completeAt"`
+}
+
+// RemoteFile 是上游仓库里的一个文件（大小、LFS sha256，非 LFS 文件 SHA256 为空）
+type RemoteFile struct {
+	Name   string
+	Size   int64
+	SHA256 string
+}
+
+// LoadState 读取 modelPath 下的状态文件
+//
+// 没有状态文件、格式不认识、或者 repo/revision 变了时返回一个空状态
+func LoadState(modelPath, repo, revision string) *State {
+	fresh := &State{Version: StateVersion, Repo: repo, Revision: revision, Files: map[string]FileState{}}
+
+	data, err := os.ReadFile(filepath.Join(modelPath, StateFile))
+	if err != nil {
+		return fresh
+	}
+	s := &State{}
+	if err := json.Unmarshal(data, s); err != nil || s.Version != StateVersion || s.Repo != repo {
+		return fresh
+	}
+	if revision != "" && s.Revision != "" && s.Revision != revision {
+		return fresh
+	}
+	if s.Files == nil {
+		s.Files = map[string]FileState{}
+	}
+	s.Revision = revision
+	return s
+}
+
+// Save 原子写入状态文件（先写临时文件再 rename，写到一半崩溃不会留下坏文件）
+func (s *State) Save(modelPath string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(modelPath, StateFile+partialSuffix)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return agenterr.Classify(fmt.Errorf("failed to write download state: %w", err))
+	}
+	return os.Rename(tmp, filepath.Join(modelPath, StateFile))
+}
+
+// Pending 返回还需要下载的文件
+//
+// 记录过、本地大小和记录一致、并且记录的 sha256 和上游一致的文件算完成。
+// 重启时只比较大小，不重新计算 sha256（300GB 读一遍也要好几分钟）。
+func (s *State) Pending(modelPath string, files []RemoteFile) []RemoteFile {
+	var pending []RemoteFile
+	for _, f := range files {
+		if !s.complete(modelPath, f) {
+			pending = append(pending, f)
+		}
+	}
+	return pending
+}
+
+func (s *State) complete(modelPath string, f RemoteFile) bool {
+	rec, ok := s.Files[f.Name]
+	if !ok {
+		return false
+	}
+	if f.Size > 0 && rec.Size != f.Size {
+		return false
+	}
+	if f.SHA256 != "" && rec.SHA256 != f.SHA256 {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(modelPath, f.Name))
+	return err == nil && info.Size() == rec.Size
+}
+
+// Record 校验刚下载完的文件并记入状态（调用方负责 Save）
+//
+// 大小或 LFS sha256 和上游对不上时返回 ErrModelCorrupt
+func (s *State) Record(modelPath string, f RemoteFile) error {
+	path := filepath.Join(modelPath, f.Name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if f.Size > 0 && info.Size() != f.Size {
+		return corrupt(fmt.Errorf("%s: downloaded %d bytes, upstream says %d", f.Name, info.Size(), f.Size))
+	}
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return err
+	}
+	if f.SHA256 != "" && sum != f.SHA256 {
+		return corrupt(fmt.Errorf("%s: sha256 %s, upstream says %s", f.Name, sum, f.SHA256))
+	}
+	s.Files[f.Name] = FileState{Size: info.Size(), SHA256: sum, CompleteAt: time.Now().UTC()}
+	return nil
+}
+
+// Completed 返回已经完成的文件数
+func (s *State) Completed(modelPath string, files []RemoteFile) int {
+	return len(files) - len(s.Pending(modelPath, files))
+}
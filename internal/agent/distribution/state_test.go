@@ -0,0 +1,88 @@
+package distribution
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+)
+
+func sha(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestStateResume(t *testing.T) {
+	dir := t.TempDir()
+	files := []RemoteFile{
+		{Name: "config.json", Size: 2},
+		{Name: "model-00001.safetensors", Size: 5, SHA256: sha("aaaaa")},
+		{Name: "model-00002.safetensors", Size: 5, SHA256: sha("bbbbb")},
+	}
+
+	// 第一次运行：下载完两个文件后崩溃
+	state := LoadState(dir, "qwen/Qwen2-7B", "abc123")
+	for name, data := range map[string]string{"config.json": "{}", "model-00001.safetensors": "aaaaa"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range files[:2] {
+		if err := state.Record(dir, f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := state.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	// 第三个文件下载了一半
+	if err := os.WriteFile(filepath.Join(dir, "model-00002.safetensors"), []byte("bb"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重启：只剩第三个文件
+	pending := LoadState(dir, "qwen/Qwen2-7B", "abc123").Pending(dir, files)
+	if len(pending) != 1 || pending[0].Name != "model-00002.safetensors" {
+		t.Errorf("Pending() after restart = %v, want only model-00002.safetensors", pending)
+	}
+
+	// 仓库更新（revision 变了）：记录作废
+	if got := LoadState(dir, "qwen/Qwen2-7B", "def456").Pending(dir, files); len(got) != 3 {
+		t.Errorf("Pending() after revision change = %d files, want 3", len(got))
+	}
+	// 换了模型：记录作废
+	if got := LoadState(dir, "meta-llama/Llama-2-7b-hf", "abc123").Pending(dir, files); len(got) != 3 {
+		t.Errorf("Pending() for another repo = %d files, want 3", len(got))
+	}
+
+	// 记录过的文件被截断：需要重新下载
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := LoadState(dir, "qwen/Qwen2-7B", "abc123").Pending(dir, files); len(got) != 2 {
+		t.Errorf("Pending() after truncation = %v, want 2 files", got)
+	}
+}
+
+func TestStateRecordRejectsMismatch(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "model.safetensors"), []byte("xxxxx"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	state := LoadState(dir, "qwen/Qwen2-7B", "")
+
+	err := state.Record(dir, RemoteFile{Name: "model.safetensors", Size: 5, SHA256: sha("aaaaa")})
+	if agenterr.Kind(err) != agenterr.ErrModelCorrupt {
+		t.Errorf("sha256 mismatch: got %v, want ErrModelCorrupt", err)
+	}
+	err = state.Record(dir, RemoteFile{Name: "model.safetensors", Size: 6})
+	if agenterr.Kind(err) != agenterr.ErrModelCorrupt {
+		t.Errorf("size mismatch: got %v, want ErrModelCorrupt", err)
+	}
+	if len(state.Files) != 0 {
+		t.Errorf("mismatched files must not be recorded: %v", state.Files)
+	}
+}
@@ -0,0 +1,21 @@
+package distribution
+
+import "os"
+
+// TransferEnv 是 follower 拿模型的方式（controller 根据 spec.storage.models 设置）
+//
+// sharedVolume：模型目录是所有副本共用的 PVC，follower 不通过 HTTP 传输，
+// 只等 coordinator 写完完整标记、按清单校验通过
+const TransferEnv = "MODEL_TRANSFER"
+
+const (
+	// TransferHTTP 是默认值：follower 从 coordinator 的 model server 下载
+	TransferHTTP = "http"
+	// TransferSharedVolume 表示模型目录在共享卷上，不用传输
+	TransferSharedVolume = "sharedVolume"
+)
+
+// SharedVolumeFromEnv 返回模型目录是否在所有副本共用的卷上
+func SharedVolumeFromEnv() bool {
+	return os.Getenv(TransferEnv) == TransferSharedVolume
+}
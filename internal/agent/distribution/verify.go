@@ -0,0 +1,324 @@
+// Package distribution 是 coordinator 和 follower 共用的模型分发逻辑：清单（manifest）和校验策略
+//
+// 清单 kubeinfer-manifest.json 和模型文件放在一起，model server 把它当普通文件分发给 follower：
+//
+//	{"version":1,"files":[{"name":"config.json","size":663,"sha256":"..."}]}
+//
+// 校验策略（Verifier）由 LLMService 的 spec.distribution.verification 决定，
+// coordinator 下载完成后、follower 同步完成后都调用同一个 Verify：
+//
+//	策略        清单来源                          Verify 检查
+//	size        coordinator 生成（只有大小）       大小
+//	sha256      coordinator 生成（大小 + sha256）  大小、sha256、没有多余文件
+//	signature   模型仓库自带，发布者签名            签名、大小、sha256、没有多余文件
+package distribution
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+)
+
+const (
+	// ManifestFile 是清单文件名
+	ManifestFile = "kubeinfer-manifest.json"
+	// SignatureFile 是清单的 ed25519 签名（base64），只有 signature 策略需要
+	SignatureFile = ManifestFile + ".sig"
+
+	// ManifestVersion 是清单格式版本
+	ManifestVersion = 1
+
+	// partialSuffix 下载中的临时文件后缀（和 coordinator/follower 一致）
+	partialSuffix = ".partial"
+)
+
+// 校验策略（和 api/v1 的 VerifySize/VerifySHA256/VerifySignature 一致）
+const (
+	PolicySize      = "size"
+	PolicySHA256    = "sha256"
+	PolicySignature = "signature"
+)
+
+// 环境变量，controller 根据 spec.distribution.verification 设置
+const (
+	PolicyEnv    = "MODEL_VERIFY_POLICY"
+	PublicKeyEnv = "MODEL_VERIFY_PUBLIC_KEY"
+)
+
+// Manifest 是模型目录的文件清单
+type Manifest struct {
+	Version int         `json:"version"`
+	Files   []FileEntry `json:"files"`
+}
+
+// FileEntry 是清单里的一个文件
+type FileEntry struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// Verifier 是模型文件的校验策略
+//
+// coordinator 下载完成后依次调用 Seal、Verify；follower 同步完成后只调用 Verify。
+// 校验失败返回 agenterr.ErrModelCorrupt，agent 会删除本地文件重新下载。
+type Verifier interface {
+	// Name 返回策略名称
+	Name() string
+	// Seal 为刚下载完的模型生成清单；清单由发布者提供的策略什么都不做
+	Seal(modelPath string) error
+	// Verify 按清单校验 modelPath 下的模型文件
+	Verify(modelPath string) error
+}
+
+// New 按策略名称创建 Verifier，空字符串等同于 size
+func New(policy string, publicKeyPEM []byte) (Verifier, error) {
+	switch policy {
+	case "", PolicySize:
+		return sizeVerifier{}, nil
+	case PolicySHA256:
+		return sha256Verifier{}, nil
+	case PolicySignature:
+		key, err := parsePublicKey(publicKeyPEM)
+		if err != nil {
+			return nil, err
+		}
+		return signatureVerifier{key: key}, nil
+	}
+	return nil, fmt.Errorf("unknown verification policy %q", policy)
+}
+
+// FromEnv 按 MODEL_VERIFY_POLICY / MODEL_VERIFY_PUBLIC_KEY 创建 Verifier
+func FromEnv() (Verifier, error) {
+	return New(os.Getenv(PolicyEnv), []byte(os.Getenv(PublicKeyEnv)))
+}
+
+func parsePublicKey(data []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("signature policy requires a PEM encoded ed25519 public key in %s", PublicKeyEnv)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	edKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is %T, want ed25519", key)
+	}
+	return edKey, nil
+}
+
+// sizeVerifier 只校验大小；没有清单时（老版本 coordinator）直接通过，传输时已经校验过 Content-Length
+type sizeVerifier struct{}
+
+func (sizeVerifier) Name() string { return PolicySize }
+
+func (sizeVerifier) Seal(modelPath string) error { return WriteManifest(modelPath, false) }
+
+func (sizeVerifier) Verify(modelPath string) error {
+	m, _, err := ReadManifest(modelPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return checkFiles(modelPath, m, false)
+}
+
+// sha256Verifier coordinator 生成带 sha256 的清单，follower 逐个校验
+type sha256Verifier struct{}
+
+func (sha256Verifier) Name() string { return PolicySHA256 }
+
+func (sha256Verifier) Seal(modelPath string) error { return WriteManifest(modelPath, true) }
+
+func (sha256Verifier) Verify(modelPath string) error {
+	m, _, err := ReadManifest(modelPath)
+	if err != nil {
+		return corrupt(err)
+	}
+	return checkFiles(modelPath, m, true)
+}
+
+// signatureVerifier 清单来自模型仓库，必须有发布者的 ed25519 签名
+type signatureVerifier struct {
+	key ed25519.PublicKey
+}
+
+func (signatureVerifier) Name() string { return PolicySignature }
+
+// Seal 什么都不做：清单由发布者签名，coordinator 不能改写
+func (signatureVerifier) Seal(string) error { return nil }
+
+func (v signatureVerifier) Verify(modelPath string) error {
+	m, raw, err := ReadManifest(modelPath)
+	if err != nil {
+		return corrupt(err)
+	}
+	encoded, err := os.ReadFile(filepath.Join(modelPath, SignatureFile))
+	if err != nil {
+		return corrupt(err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return corrupt(fmt.Errorf("invalid signature encoding: %w", err))
+	}
+	if !ed25519.Verify(v.key, raw, sig) {
+		return corrupt(fmt.Errorf("manifest signature does not match the configured public key"))
+	}
+	return checkFiles(modelPath, m, true)
+}
+
+// corrupt 把校验错误标记为 ErrModelCorrupt
+func corrupt(err error) error {
+	return agenterr.Wrap(agenterr.ErrModelCorrupt, fmt.Errorf("model verification failed: %w", err))
+}
+
+// modelFiles 列出需要进清单的文件：和 model server 分发的文件一致（顶层普通文件），
+// 去掉隐藏文件、下载中的 .partial、清单和签名本身
+func modelFiles(modelPath string) ([]os.DirEntry, error) {
+	entries, err := os.ReadDir(modelPath)
+	if err != nil {
+		return nil, err
+	}
+	var files []os.DirEntry
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, partialSuffix) ||
+			name == ManifestFile || name == SignatureFile {
+			continue
+		}
+		files = append(files, e)
+	}
+	return files, nil
+}
+
+// WriteManifest 为 modelPath 下的文件生成清单，hashes 为 true 时计算 sha256
+func WriteManifest(modelPath string, hashes bool) error {
+	files, err := modelFiles(modelPath)
+	if err != nil {
+		return err
+	}
+	m := Manifest{Version: ManifestVersion}
+	for _, f := range files {
+		info, err := f.Info()
+		if err != nil {
+			return err
+		}
+		entry := FileEntry{Name: f.Name(), Size: info.Size()}
+		if hashes {
+			if entry.SHA256, err = fileSHA256(filepath.Join(modelPath, f.Name())); err != nil {
+				return err
+			}
+		}
+		m.Files = append(m.Files, entry)
+	}
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Name < m.Files[j].Name })
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	// 和下载一样先写临时文件再 rename，follower 不会拿到写了一半的清单
+	tmp := filepath.Join(modelPath, ManifestFile+partialSuffix)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return agenterr.Classify(err)
+	}
+	return os.Rename(tmp, filepath.Join(modelPath, ManifestFile))
+}
+
+// ReadManifest 读取清单，同时返回原始字节（用于验证签名）
+func ReadManifest(modelPath string) (*Manifest, []byte, error) {
+	raw, err := os.ReadFile(filepath.Join(modelPath, ManifestFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := ParseManifest(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, raw, nil
+}
+
+// ParseManifest 解析清单内容（follower 从 model server 拿到的清单不落盘就要用）
+func ParseManifest(raw []byte) (*Manifest, error) {
+	m := &Manifest{}
+	if err := json.Unmarshal(raw, m); err != nil {
+		return nil, corrupt(fmt.Errorf("invalid manifest: %w", err))
+	}
+	if m.Version > ManifestVersion {
+		return nil, fmt.Errorf("manifest version %d is newer than supported version %d", m.Version, ManifestVersion)
+	}
+	return m, nil
+}
+
+// checkFiles 按清单校验文件；strict 时还要校验 sha256，并且不允许清单之外的文件
+func checkFiles(modelPath string, m *Manifest, strict bool) error {
+	listed := make(map[string]bool, len(m.Files))
+	for _, entry := range m.Files {
+		listed[entry.Name] = true
+		path := filepath.Join(modelPath, entry.Name)
+		info, err := os.Stat(path)
+		if err != nil {
+			return corrupt(err)
+		}
+		if info.Size() != entry.Size {
+			return corrupt(fmt.Errorf("%s: size %d, manifest says %d", entry.Name, info.Size(), entry.Size))
+		}
+		if !strict {
+			continue
+		}
+		if entry.SHA256 == "" {
+			return corrupt(fmt.Errorf("%s: manifest has no sha256", entry.Name))
+		}
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		if sum != entry.SHA256 {
+			return corrupt(fmt.Errorf("%s: sha256 %s, manifest says %s", entry.Name, sum, entry.SHA256))
+		}
+	}
+
+	if !strict {
+		return nil
+	}
+	files, err := modelFiles(modelPath)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if !listed[f.Name()] {
+			return corrupt(fmt.Errorf("%s is not listed in the manifest", f.Name()))
+		}
+	}
+	return nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,163 @@
+package distribution
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+)
+
+func writeModel(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"config.json":         `{"model_type":"qwen2"}`,
+		"model.safetensors":   "weights",
+		".kubeinfer-complete": "",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSealAndVerify(t *testing.T) {
+	tests := []struct {
+		policy  string
+		tamper  func(dir string) error
+		wantErr bool
+	}{
+		{policy: PolicySize},
+		{policy: PolicySHA256},
+		{
+			// 大小不变、内容变了：size 策略发现不了
+			policy: PolicySize,
+			tamper: func(dir string) error {
+				return os.WriteFile(filepath.Join(dir, "model.safetensors"), []byte("WEIGHTS"), 0644)
+			},
+		},
+		{
+			policy: PolicySHA256,
+			tamper: func(dir string) error {
+				return os.WriteFile(filepath.Join(dir, "model.safetensors"), []byte("WEIGHTS"), 0644)
+			},
+			wantErr: true,
+		},
+		{
+			policy: PolicySize,
+			tamper: func(dir string) error {
+				return os.WriteFile(filepath.Join(dir, "model.safetensors"), []byte("short"), 0644)
+			},
+			wantErr: true,
+		},
+		{
+			policy: PolicySHA256,
+			tamper: func(dir string) error {
+				return os.WriteFile(filepath.Join(dir, "extra.py"), []byte("import os"), 0644)
+			},
+			wantErr: true,
+		},
+		{
+			policy:  PolicySHA256,
+			tamper:  func(dir string) error { return os.Remove(filepath.Join(dir, ManifestFile)) },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		dir := writeModel(t)
+		v, err := New(tt.policy, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := v.Seal(dir); err != nil {
+			t.Fatal(err)
+		}
+		if tt.tamper != nil {
+			if err := tt.tamper(dir); err != nil {
+				t.Fatal(err)
+			}
+		}
+		err = v.Verify(dir)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: Verify() error = %v, wantErr %v", tt.policy, err, tt.wantErr)
+		}
+		if err != nil && agenterr.Kind(err) != agenterr.ErrModelCorrupt {
+			t.Errorf("%s: verification errors should be ErrModelCorrupt, got %v", tt.policy, err)
+		}
+	}
+}
+
+func TestSizeWithoutManifest(t *testing.T) {
+	// 老版本 coordinator 不生成清单，size 策略直接通过
+	v, _ := New("", nil)
+	if err := v.Verify(writeModel(t)); err != nil {
+		t.Errorf("Verify() without manifest = %v", err)
+	}
+}
+
+func TestSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	if _, err := New(PolicySignature, nil); err == nil {
+		t.Error("signature policy without a public key should fail")
+	}
+	v, err := New(PolicySignature, pubPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 发布者生成清单并签名
+	dir := writeModel(t)
+	if err := WriteManifest(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(key ed25519.PrivateKey) {
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, raw))
+		if err := os.WriteFile(filepath.Join(dir, SignatureFile), []byte(sig+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sign(priv)
+	// Seal 不能改写发布者的清单
+	if err := v.Seal(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify(dir); err != nil {
+		t.Errorf("valid signature: Verify() = %v", err)
+	}
+
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	sign(otherKey)
+	if err := v.Verify(dir); err == nil {
+		t.Error("signature from another key should be rejected")
+	}
+
+	sign(priv)
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"model_type":"evil!"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.Verify(dir); err == nil {
+		t.Error("modified file should be rejected even with a valid signature")
+	}
+}
@@ -0,0 +1,206 @@
+// Package faults 是 agent 的故障注入层，只用于测试 failover 和断点续传
+//
+// 默认完全关闭；设置环境变量 KUBEINFER_FAULTS 才会生效，例如：
+//
+//	KUBEINFER_FAULTS=drop-lease-renewal=0.5,model-server-500=3x,kill-runtime=2m
+//
+// 每一项是 名字=值，值有三种写法：
+//   - 概率：0.5 表示每次有 50% 的概率触发，1 或者不写值表示每次都触发
+//   - 次数：3x 表示前 3 次触发，之后不再触发
+//   - 时长：kill-runtime 这类按时间触发的故障用 time.ParseDuration 的格式
+//
+// 生产环境永远不要设置这个变量。
+package faults
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvVar 是开启故障注入的环境变量
+const EnvVar = "KUBEINFER_FAULTS"
+
+// 支持的故障
+const (
+	// DropLeaseRenewal coordinator 续约 lease 失败（模拟和 API server 之间网络分区）
+	DropLeaseRenewal = "drop-lease-renewal"
+	// TruncateDownload follower 下载到一半连接断开
+	TruncateDownload = "truncate-download"
+	// CorruptDownload follower 下载的文件开头一段被写成 0（模拟坏块），大小不变
+	CorruptDownload = "corrupt-download"
+	// KillRuntime 推理服务启动后过这么久被 SIGKILL
+	KillRuntime = "kill-runtime"
+	// ModelServer500 model server 对请求返回 500
+	ModelServer500 = "model-server-500"
+)
+
+var known = map[string]bool{
+	DropLeaseRenewal: true,
+	TruncateDownload: true,
+	CorruptDownload:  true,
+	KillRuntime:      true,
+	ModelServer500:   true,
+}
+
+// fault 是一项故障的配置
+type fault struct {
+	probability float64       // 概率模式
+	remaining   int           // 次数模式，-1 表示不限次数
+	duration    time.Duration // 时长模式
+}
+
+var (
+	mu     sync.Mutex
+	active map[string]*fault
+	random = rand.Float64
+)
+
+func init() {
+	spec := os.Getenv(EnvVar)
+	if spec == "" {
+		return
+	}
+	if err := Configure(spec); err != nil {
+		log.Printf("⚠️  Ignoring invalid %s: %v", EnvVar, err)
+		return
+	}
+	log.Printf("💥 Fault injection enabled: %s", spec)
+}
+
+// Configure 解析并启用故障配置，替换之前的配置
+func Configure(spec string) error {
+	parsed, err := parse(spec)
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	active = parsed
+	return nil
+}
+
+// Reset 关闭所有故障
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	active = nil
+}
+
+func parse(spec string) (map[string]*fault, error) {
+	out := map[string]*fault{}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(item, "=")
+		if !known[name] {
+			return nil, fmt.Errorf("unknown fault %q", name)
+		}
+
+		f := &fault{probability: 1, remaining: -1}
+		switch {
+		case value == "":
+		case name == KillRuntime:
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("fault %s: %w", name, err)
+			}
+			f.duration = d
+		case strings.HasSuffix(value, "x"):
+			n, err := strconv.Atoi(strings.TrimSuffix(value, "x"))
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("fault %s: invalid count %q", name, value)
+			}
+			f.remaining = n
+		default:
+			p, err := strconv.ParseFloat(value, 64)
+			if err != nil || p < 0 || p > 1 {
+				return nil, fmt.Errorf("fault %s: invalid probability %q", name, value)
+			}
+			f.probability = p
+		}
+		out[name] = f
+	}
+	return out, nil
+}
+
+// Hit 判断这一次是否触发故障 name
+func Hit(name string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	f := active[name]
+	if f == nil {
+		return false
+	}
+	if f.remaining == 0 {
+		return false
+	}
+	if random() >= f.probability {
+		return false
+	}
+	if f.remaining > 0 {
+		f.remaining--
+	}
+	log.Printf("💥 Injecting fault %s", name)
+	return true
+}
+
+// Duration 返回按时间触发的故障的时长，没有配置时返回 false
+func Duration(name string) (time.Duration, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	f := active[name]
+	if f == nil || f.duration <= 0 {
+		return 0, false
+	}
+	return f.duration, true
+}
+
+// WrapDownload 给下载的响应体注入 TruncateDownload / CorruptDownload
+//
+// size 是响应的 Content-Length，未知时传 -1（这时不截断）
+func WrapDownload(body io.Reader, size int64) io.Reader {
+	if size > 0 && Hit(TruncateDownload) {
+		return &truncatedReader{r: io.LimitReader(body, size/2)}
+	}
+	if Hit(CorruptDownload) {
+		return &zeroReader{r: body, left: 4096}
+	}
+	return body
+}
+
+// truncatedReader 读完前一半后报告连接断开
+type truncatedReader struct {
+	r io.Reader
+}
+
+func (t *truncatedReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if err == io.EOF {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// zeroReader 把前 left 个字节替换成 0
+type zeroReader struct {
+	r    io.Reader
+	left int
+}
+
+func (z *zeroReader) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	for i := 0; i < n && z.left > 0; i++ {
+		p[i] = 0
+		z.left--
+	}
+	return n, err
+}
@@ -0,0 +1,92 @@
+package faults
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{spec: "drop-lease-renewal=0.5,model-server-500=3x,kill-runtime=2m"},
+		{spec: "truncate-download"},
+		{spec: "", wantErr: false},
+		{spec: "unknown-fault=1", wantErr: true},
+		{spec: "model-server-500=1.5", wantErr: true},
+		{spec: "model-server-500=-1x", wantErr: true},
+		{spec: "kill-runtime=soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		_, err := parse(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parse(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+		}
+	}
+}
+
+func TestHit(t *testing.T) {
+	defer Reset()
+
+	if Hit(ModelServer500) {
+		t.Fatal("faults must be off by default")
+	}
+
+	if err := Configure("model-server-500=2x,drop-lease-renewal=0.5,kill-runtime=30s"); err != nil {
+		t.Fatal(err)
+	}
+	hits := 0
+	for range 5 {
+		if Hit(ModelServer500) {
+			hits++
+		}
+	}
+	if hits != 2 {
+		t.Errorf("count mode: got %d hits, want 2", hits)
+	}
+
+	orig := random
+	defer func() { random = orig }()
+	random = func() float64 { return 0.7 }
+	if Hit(DropLeaseRenewal) {
+		t.Error("0.7 >= 0.5 should not hit")
+	}
+	random = func() float64 { return 0.2 }
+	if !Hit(DropLeaseRenewal) {
+		t.Error("0.2 < 0.5 should hit")
+	}
+
+	if d, ok := Duration(KillRuntime); !ok || d != 30*time.Second {
+		t.Errorf("Duration() = %v, %v", d, ok)
+	}
+}
+
+func TestWrapDownload(t *testing.T) {
+	defer Reset()
+	data := strings.Repeat("a", 8192)
+
+	if err := Configure("truncate-download=1x"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(WrapDownload(strings.NewReader(data), int64(len(data))))
+	if !errors.Is(err, io.ErrUnexpectedEOF) || len(got) != len(data)/2 {
+		t.Errorf("truncate: read %d bytes, err %v", len(got), err)
+	}
+
+	if err := Configure("corrupt-download=1x"); err != nil {
+		t.Fatal(err)
+	}
+	got, err = io.ReadAll(WrapDownload(strings.NewReader(data), int64(len(data))))
+	if err != nil || len(got) != len(data) {
+		t.Fatalf("corrupt: read %d bytes, err %v", len(got), err)
+	}
+	if !bytes.Equal(got[:4096], make([]byte, 4096)) || got[4096] != 'a' {
+		t.Error("corrupt should zero the first 4096 bytes only")
+	}
+}
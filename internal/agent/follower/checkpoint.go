@@ -0,0 +1,76 @@
+package follower
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+)
+
+// WithOrphanCheck 设置判断槽位主人是否已经不在的函数，新 Pod 可以接着被驱逐的 Pod 下载（见 distribution/checkpoint.go）
+func (f *Follower) WithOrphanCheck(fn func(owner string) bool) *Follower {
+	f.orphan = fn
+	return f
+}
+
+// claimSlot 在配置了下载断点、模型目录还没有完整副本时认领一个槽位
+//
+// 断点只是优化：PVC 读写失败时打日志，直接同步到模型目录。
+// versioned 布局的每个 revision 目录本来就是增量同步的，不用槽位（webhook 也不允许一起配置）
+func (f *Follower) claimSlot() {
+	f.slot = ""
+	if f.checkpoint == "" || f.podName == "" || f.layout.Versioned {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(f.layout.Root, completeMarker)); err == nil {
+		return
+	}
+	slot, adopted, err := distribution.ClaimSlot(f.checkpoint, f.podName, f.repo, f.orphan)
+	if err != nil {
+		log.Printf("⚠️  Download checkpoint unavailable, syncing without it: %v", err)
+		return
+	}
+	if adopted != "" {
+		log.Printf("⏯️  Adopted the download checkpoint of pod %s", adopted)
+	}
+	f.slot = slot
+}
+
+// syncDir 返回这次同步写文件的目录：有槽位时是槽位，否则由目录布局决定
+func (f *Follower) syncDir(revision string) string {
+	if f.slot != "" {
+		return f.slot
+	}
+	return f.layout.Dir(f.repo, revision)
+}
+
+// publishSlot 把校验通过的槽位搬到模型目录，之后的完整标记写在模型目录里
+func (f *Follower) publishSlot(files []string) error {
+	if f.slot == "" {
+		return nil
+	}
+	dst := f.layout.Root
+	if err := distribution.PublishSlot(f.slot, dst); err != nil {
+		return err
+	}
+	log.Printf("📦 Moved the checkpointed download into %s", dst)
+	// 没有用槽位之前留在模型目录里的 .partial、旧文件
+	pruneStale(dst, files)
+	f.slot, f.modelPath = "", dst
+	if f.onTarget != nil {
+		f.onTarget(dst, f.revision)
+	}
+	return nil
+}
+
+// dropSlot 在槽位里的文件校验失败时删掉槽位，下一次从头下载
+func (f *Follower) dropSlot() {
+	if f.slot == "" {
+		return
+	}
+	if err := os.RemoveAll(f.slot); err != nil {
+		log.Printf("⚠️  Failed to remove the corrupt download checkpoint: %v", err)
+	}
+	f.slot = ""
+}
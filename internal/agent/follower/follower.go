@@ -2,28 +2,110 @@ package follower
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/Moore-Z/kubeinfer/internal/agent/vllm"
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+	"github.com/Moore-Z/kubeinfer/internal/agent/bandwidth"
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+	"github.com/Moore-Z/kubeinfer/internal/agent/faults"
+	"github.com/Moore-Z/kubeinfer/internal/agent/runtime"
 )
 
 // Coordinator HTTP 服务器的端口（和 model_server.go 里定义的一样）
 const CoordinatorPort = 8080
 
+// 下面几个常量和 coordinator 包保持一致
+const (
+	// completeHeader 为 "false" 表示 coordinator 还在下载
+	completeHeader = "X-Kubeinfer-Model-Complete"
+	// sizeHeader 是文件列表里所有文件的总字节数
+	sizeHeader = "X-Kubeinfer-Model-Size"
+	// completeMarker 同步完成后写入，follower 被提升为 coordinator 时据此判断是否有完整副本
+	completeMarker = ".kubeinfer-complete"
+	// partialSuffix 下载中的临时文件后缀
+	partialSuffix = ".partial"
+)
+
+// pollInterval 是 coordinator 还在下载时，重新拉取文件列表的间隔
+const pollInterval = 5 * time.Second
+
+// progressInterval 是更新同步带宽和 ETA 指标的间隔
+const progressInterval = 10 * time.Second
+
+// dialTimeout 是连接 model server 的超时。coordinator 所在节点挂掉时包直接被丢掉，
+// 不设置的话要等操作系统的 TCP 连接超时（两分钟左右）才能换 peer 或者退回上游
+const dialTimeout = 5 * time.Second
+
+// httpClient 是发往 model server 的请求用的 client：只限制建连时间，
+// 大文件下载和 GET /files 的长轮询由各自的 ctx 控制
+var httpClient = &http.Client{Transport: newTransport()}
+
+func newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.DialContext = (&net.Dialer{Timeout: dialTimeout, KeepAlive: 30 * time.Second}).DialContext
+	return t
+}
+
 // Follower 结构体
 // Follower 是"跟随者" Pod，它的任务是：
 // 1. 从 Coordinator 的 HTTP 服务器获取模型文件列表
 // 2. 下载每个模型文件到本地
 // 3. 下载完成后，等待退出信号
 type Follower struct {
-	coordinatorIP string // Coordinator 的 IP 地址，例如 "10.0.0.5"
-	modelPath     string // 模型文件存放路径，例如 "/models"
+	baseURL   string // model server 地址，例如 "http://10.0.0.5:8080"
+	modelPath string // 模型文件存放路径，例如 "/models"；versioned 布局下是正在同步的 revision 目录
+
+	// layout 是模型目录的布局（MODEL_LAYOUT），versioned 时按 model server 的 revision 同步到 <repo>/<revision>/
+	layout distribution.Layout
+	// repo 是 HuggingFace 仓库名（MODEL_REPO），versioned 布局的目录名
+	repo string
+	// keepRevisions 是 versioned 布局保留的 revision 数（MODEL_KEEP_REVISIONS）
+	keepRevisions int
+	// onTarget 在确定同步目录时调用（zone seeder 的 model server 边同步边提供这个目录）
+	onTarget func(dir, revision string)
+	// revision 是正在同步的 revision，local 是本地其他 revision 里可以复用的文件（versioned 布局）
+	revision string
+	local    distribution.LocalFiles
+
+	// bandwidth 给下载限速（coordinator 从 hub 集群复制时和 model server 共享带宽），nil 表示不限速
+	bandwidth *bandwidth.Manager
+
+	// progress 统计同步进度，syncModel 开始时创建
+	progress *distribution.Progress
+
+	// token 是 model server 之间的共享 token（MODEL_SERVER_TOKEN），peer 的 model server 要求它
+	token string
+	// peers 是同步开始时从 coordinator 拿到的 peer，coordinator 连不上时依次换过去
+	peers []string
+
+	// onSynced 在同步完成、启动推理服务之前调用（peer serving 在这里开始提供副本）
+	onSynced func()
+
+	// localCopy 决定本地已有的文件怎么复用（MODEL_LOCAL_COPY，见 distribution/local_copy.go）
+	localCopy string
+	// sharedVolume 为 true 时模型目录是所有副本共用的卷（MODEL_TRANSFER），不传输，只等 coordinator 写完（见 shared_volume.go）
+	sharedVolume bool
+
+	// checkpoint 是下载断点 PVC 的挂载路径（MODEL_CHECKPOINT_DIR），podName 是自己的槽位名（见 checkpoint.go）
+	checkpoint, podName string
+	// orphan 判断槽位的主人是不是已经不在了，nil 时不认领别人的槽位
+	orphan func(owner string) bool
+	// slot 是这次同步用的槽位，为空时直接同步到模型目录
+	slot string
+
+	// handoff 为 true 时是新 coordinator 在从上一个 coordinator 补齐副本，请求带 distribution.HandoffHeader，
+	// 上一个 coordinator 等 EndHandoff 之后才关闭（见 coordinator/handoff.go）
+	handoff bool
 }
 
 // NewFollower 创建一个新的 Follower 实例
@@ -32,81 +114,572 @@ type Follower struct {
 //   - coordinatorIP: 从 config.LoadConfig().CoordinatorIP 获得
 //   - modelPath: 从 config.LoadConfig().ModelPath 获得
 func NewFollower(coordinatorIP, modelPath string) *Follower {
+	f := NewFollowerFromURL(ModelServerURL(coordinatorIP), modelPath)
+	f.layout = distribution.LayoutFromEnv(modelPath)
+	f.repo = os.Getenv("MODEL_REPO")
+	f.keepRevisions = distribution.KeepRevisionsFromEnv()
+	f.sharedVolume = distribution.SharedVolumeFromEnv()
+	f.checkpoint, f.podName = distribution.CheckpointDirFromEnv(), os.Getenv("POD_NAME")
+	return f
+}
+
+// NewFollowerFromURL 从任意 model server 地址同步模型，直接同步到 modelPath（不管目录布局）
+//
+// fleet 模式下成员集群的 coordinator 用它从 hub 集群的 model server 复制模型
+func NewFollowerFromURL(baseURL, modelPath string) *Follower {
 	return &Follower{
-		coordinatorIP: coordinatorIP,
-		modelPath:     modelPath,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		modelPath: modelPath,
+		layout:    distribution.Layout{Root: modelPath},
+		token:     distribution.ServerTokenFromEnv(),
+		localCopy: distribution.LocalCopyFromEnv(),
+	}
+}
+
+// WithHandoff 标记这次同步是 coordinator 交接，返回 f 方便链式调用
+func (f *Follower) WithHandoff() *Follower {
+	f.handoff = true
+	return f
+}
+
+// EndHandoff 通知上一个 coordinator 交接结束（成功或者放弃），它可以关闭 model server 了
+//
+// ctx 已经取消（失去 coordinator 身份）时不通知；通知失败只打日志，上一个 coordinator 最多再等 HandoffDrainTimeout
+func (f *Follower) EndHandoff(ctx context.Context) {
+	if ctx.Err() != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	req, err := f.newRequest(ctx, http.MethodPost, f.baseURL+distribution.HandoffDonePath)
+	if err != nil {
+		return
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		log.Printf("⚠️  Failed to tell the previous coordinator the handoff is done: %v", err)
+		return
 	}
+	_ = resp.Body.Close()
+}
+
+// newRequest 创建发往 model server 的请求，带上 token 和交接标记
+func (f *Follower) newRequest(ctx context.Context, method, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	distribution.SetToken(req, f.token)
+	if f.handoff {
+		req.Header.Set(distribution.HandoffHeader, "true")
+	}
+	return req, nil
+}
+
+// WithBandwidth 设置下载限速，返回 f 方便链式调用
+func (f *Follower) WithBandwidth(b *bandwidth.Manager) *Follower {
+	f.bandwidth = b
+	return f
+}
+
+// WithOnSynced 设置同步完成时的回调
+func (f *Follower) WithOnSynced(fn func()) *Follower {
+	f.onSynced = fn
+	return f
+}
+
+// WithOnTarget 设置确定同步目录时的回调
+func (f *Follower) WithOnTarget(fn func(dir, revision string)) *Follower {
+	f.onTarget = fn
+	return f
 }
 
 // Run 是 Follower 的主函数
 //
 // 执行流程：
-//  1. 调用 getFileList() 获取文件列表
-//  2. 循环调用 downloadFile() 下载每个文件
-//  3. 全部下载完成后，等待 ctx.Done()
+//  1. 调用 syncModel() 从 Coordinator 同步所有文件
+//  2. 启动 vLLM
+//  3. 等待 ctx.Done()
 func (f *Follower) Run(ctx context.Context) error {
 	log.Println("🚀 Running as Follower")
-	log.Printf("📡 Coordinator IP: %s", f.coordinatorIP)
+	log.Printf("📡 Coordinator: %s", f.baseURL)
 
-	// Step 1: 获取文件列表
-	files, err := f.getFileList()
-	if err != nil {
-		return fmt.Errorf("failed to get file list: %w", err)
+	sync := f.syncModel
+	if f.sharedVolume {
+		sync = f.waitShared
 	}
-
-	// Step 2: 下载每个文件
-	for _, filename := range files {
-		err := f.downloadFile(filename)
-		if err != nil {
-			return fmt.Errorf("failed to download file: %s, %w", filename, err)
-		}
+	if err := sync(ctx); err != nil {
+		return err
 	}
+	if f.onSynced != nil {
+		f.onSynced()
+	}
+
 	// 启动 vLLM
-	vllmConfig := vllm.LoadConfigFromEnv(f.modelPath)
-	vllmServer := vllm.NewServer(vllmConfig)
-	if err := vllmServer.Start(); err != nil {
-		return fmt.Errorf("failed to start vLLM: %w", err)
+	// 推理服务（vLLM/TGI/llama.cpp，由 INFERENCE_RUNTIME 决定）
+	server, err := runtime.LoadFromEnv(f.layout.Current())
+	if err != nil {
+		return err
+	}
+	if err := server.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", server.Name(), err)
 	}
 
+	// watchdog：生成卡住时标记 NotReady 并重启推理服务
+	go runtime.NewWatchdog(server, runtime.LoadWatchdogConfigFromEnv()).Run(ctx)
+
 	// Step 3: 等待退出信号
 	log.Println("✅ All files downloaded, waiting for shutdown signal...")
 	<-ctx.Done()
-	vllmServer.Stop()
+	server.Stop()
+
+	return nil
+}
+
+// Sync 只同步模型，不启动推理服务
+func (f *Follower) Sync(ctx context.Context) error {
+	return f.syncModel(ctx)
+}
+
+// syncModel 从 Coordinator 同步模型，直到 Coordinator 报告自己有完整副本
+//
+// Coordinator 可能是刚刚接管的（节点故障后），手里只有部分文件，还在继续下载。
+// 这时先拿已有的文件，然后等 coordinator 下载完下一个文件再来拿（GET /files 长轮询，见 waitForFiles）。
+// 本地已经存在、和 coordinator 的清单对得上的文件直接跳过（下载是先写 .partial 再 rename，存在就说明是完整的），
+// 所以重启、换了 coordinator 之后重新 Run 只下载缺的和过期的文件（见 distribution/local_copy.go）。
+func (f *Follower) syncModel(ctx context.Context) error {
+	verifier, err := distribution.FromEnv()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.modelPath, 0755); err != nil {
+		return agenterr.Classify(fmt.Errorf("failed to create model directory: %w", err))
+	}
+
+	f.progress = distribution.NewProgress(distribution.LabelsFromEnv(distribution.SourcePeer), 0)
+	stopProgress := f.progress.Run(ctx, progressInterval, nil)
+	defer stopProgress()
+
+	if distribution.PeerServingFromEnv() {
+		f.loadPeers(ctx)
+	}
+	// 下载断点：模型目录还没有完整副本时同步到 PVC 上的槽位，完成后再搬过来
+	f.claimSlot()
+
+	target := ""
+	// checked 是这次同步里已经确认过（对上了清单或者刚下载完）的文件，之后几轮不用再看
+	checked := map[string]bool{}
+	for {
+		// Step 1: 获取文件列表
+		files, size, complete, revision, err := f.getFileList(ctx)
+		if err != nil {
+			if f.failover(err) {
+				continue
+			}
+			return fmt.Errorf("failed to get file list: %w", err)
+		}
+		// versioned 布局：同步到 coordinator 的 revision 目录，coordinator 换了 revision 就换目录
+		// （同步了一半的旧目录留给 GC）
+		if dir := f.syncDir(revision); dir != target {
+			if target != "" {
+				log.Printf("🔄 Coordinator switched to revision %s, syncing into %s", revision, dir)
+			}
+			if err := f.setTarget(dir, revision); err != nil {
+				return err
+			}
+			target = dir
+			checked = map[string]bool{}
+		}
+		f.reuseLocalFiles(ctx, files)
+		f.updateTotal(files, size)
+
+		// Step 2: 下载本地没有或者过期的文件
+		expected := f.getExpected(ctx)
+		failedOver := false
+		for _, filename := range files {
+			if checked[filename] || !f.needsDownload(filename, expected, complete, checked) {
+				continue
+			}
+			if err := f.downloadFile(ctx, filename); err != nil {
+				if f.failover(err) {
+					failedOver = true
+					break
+				}
+				return fmt.Errorf("failed to download file: %s, %w", filename, err)
+			}
+			checked[filename] = true
+		}
+		if failedOver {
+			continue
+		}
+
+		if complete {
+			// 提升为 coordinator 之前残留的 .partial、coordinator 已经没有的文件：先删掉，sha256 校验不允许多余文件
+			pruneStale(f.modelPath, files)
+
+			// 和 coordinator 下载完成后一样的校验，失败时 agent 会删掉本地文件重新同步
+			if err := verifier.Verify(f.modelPath); err != nil {
+				f.dropSlot()
+				return err
+			}
+			log.Printf("🔏 Model verified (%s)", verifier.Name())
+			if err := f.saveDigests(ctx, files); err != nil {
+				return err
+			}
+			if err := f.publishSlot(files); err != nil {
+				return err
+			}
+			if err := os.WriteFile(filepath.Join(f.modelPath, completeMarker), nil, 0644); err != nil {
+				return agenterr.Classify(err)
+			}
+			return f.activate()
+		}
+
+		if err := f.waitForFiles(ctx, len(files)); err != nil {
+			return err
+		}
+	}
+}
+
+// waitForFiles 在 coordinator 还在下载时等它有新文件 ready（见 distribution/readiness.go）
+//
+// seen 是这一轮列出的文件数。coordinator 支持 GET /files 时长轮询，下一个文件一下载完就返回；
+// 老版本 coordinator、没有下载计划（从 fleet 上游复制）时每隔 pollInterval 再来看一次
+func (f *Follower) waitForFiles(ctx context.Context, seen int) error {
+	if index, err := f.getFileIndex(ctx, seen); err == nil {
+		if index.Complete || index.ReadyCount() > seen {
+			return nil
+		}
+		if pending := len(index.Files) - index.ReadyCount(); pending > 0 {
+			// 等满了 FilesWaitTimeout 还没有新文件（大分片），接着等
+			log.Printf("⏳ Coordinator is still downloading (%d files to go)", pending)
+			return ctx.Err()
+		}
+	}
+
+	log.Printf("⏳ Coordinator is still downloading, checking again in %s", pollInterval)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(pollInterval):
+	}
+	return nil
+}
+
+// getFileIndex 长轮询 GET /files，ready 的文件超过 seen 个、下载结束或者超时之后返回
+func (f *Follower) getFileIndex(ctx context.Context, seen int) (*distribution.FileIndex, error) {
+	ctx, cancel := context.WithTimeout(ctx, distribution.FilesWaitTimeout+10*time.Second)
+	defer cancel()
+	url := fmt.Sprintf("%s%s?%s=%d", f.baseURL, distribution.FilesPath, distribution.FilesReadyParam, seen)
+	req, err := f.newRequest(ctx, http.MethodGet, url)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	index := &distribution.FileIndex{}
+	if err := json.NewDecoder(resp.Body).Decode(index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// needsDownload 判断这一轮要不要下载 filename，本地文件对上了清单时记到 checked
+//
+// 清单和签名等 coordinator 下载完（complete）再拿，总是用 model server 上的
+func (f *Follower) needsDownload(filename string, expected distribution.Expected, complete bool, checked map[string]bool) bool {
+	if distribution.IsManifestFile(filename) {
+		return complete
+	}
+	path := filepath.Join(f.modelPath, filename)
+	if expected.Reusable(path, filename, f.localCopy) {
+		// 清单里没有的只是暂时跳过，coordinator 写了清单之后再比一次
+		if _, ok := expected[filename]; ok {
+			checked[filename] = true
+		}
+		return false
+	}
+	if _, err := os.Stat(path); err == nil {
+		log.Printf("♻️  Local %s does not match the coordinator (%s), downloading it again", filename, f.localCopy)
+	}
+	return true
+}
+
+// getExpected 从 model server 拿清单和 /digests，用来判断本地文件是否过期；拿不到时为空
+func (f *Follower) getExpected(ctx context.Context) distribution.Expected {
+	if f.localCopy == distribution.LocalCopyRedownload {
+		return nil
+	}
+	var manifest *distribution.Manifest
+	if raw, err := f.getFile(ctx, distribution.ManifestFile); err == nil {
+		manifest, _ = distribution.ParseManifest(raw)
+	}
+	return distribution.NewExpected(manifest, f.getDigests(ctx))
+}
+
+// getFile 把 model server 上的一个小文件读到内存里
+func (f *Follower) getFile(ctx context.Context, filename string) ([]byte, error) {
+	req, err := f.newRequest(ctx, http.MethodGet, f.baseURL+"/models/"+filename)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s: status: %d", filename, resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
 
+// setTarget 把同步目录切换到 dir，flat 布局 dir 总是 modelPath
+func (f *Follower) setTarget(dir, revision string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return agenterr.Classify(fmt.Errorf("failed to create model directory: %w", err))
+	}
+	if dir == f.slot {
+		if err := distribution.RetargetSlot(dir, f.repo, revision); err != nil {
+			return err
+		}
+	}
+	f.modelPath = dir
+	f.revision = revision
+	f.local = f.layout.LocalFiles(dir)
+	if f.onTarget != nil {
+		f.onTarget(dir, revision)
+	}
 	return nil
 }
 
+// reuseLocalFiles 把 sha256 和本地其他 revision 对得上的文件硬链接过来（versioned 布局）
+//
+// 升级时只有改过的文件需要从 coordinator 下载；coordinator 不支持 /digests（老版本）时什么都不做
+func (f *Follower) reuseLocalFiles(ctx context.Context, files []string) {
+	if !f.layout.Versioned || len(f.local) == 0 {
+		return
+	}
+	digests := f.getDigests(ctx)
+	var reused distribution.ReuseResult
+	for _, filename := range files {
+		path := filepath.Join(f.modelPath, filename)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		if rec, ok := digests[filename]; ok && f.local.Link(rec.SHA256, rec.Size, path) {
+			reused.Add(rec.Size)
+		}
+	}
+	if reused.Files > 0 {
+		log.Printf("♻️  %s", reused)
+	}
+}
+
+// saveDigests 把 model server 给的 sha256 写进状态文件，下一次升级时这个 revision 也可以被复用
+//
+// 重新拿一次：同步开始时 coordinator 可能还在下载，那时的 sha256 不全
+func (f *Follower) saveDigests(ctx context.Context, files []string) error {
+	if !f.layout.Versioned {
+		return nil
+	}
+	digests := f.getDigests(ctx)
+	if len(digests) == 0 {
+		return nil
+	}
+	state := distribution.LoadState(f.modelPath, f.repo, f.revision)
+	for _, filename := range files {
+		if rec, ok := digests[filename]; ok {
+			state.Adopt(filename, rec.Size, rec.SHA256)
+		}
+	}
+	return state.Save(f.modelPath)
+}
+
+// getDigests 从 model server 拿文件的 sha256，失败时返回空（增量同步只是优化）
+func (f *Follower) getDigests(ctx context.Context) distribution.Digests {
+	req, err := f.newRequest(ctx, http.MethodGet, f.baseURL+distribution.DigestsPath)
+	if err != nil {
+		return nil
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+	digests := distribution.Digests{}
+	if err := json.NewDecoder(resp.Body).Decode(&digests); err != nil {
+		return nil
+	}
+	return digests
+}
+
+// activate 在 versioned 布局下把 current 切到同步完成的 revision，并清理旧 revision
+//
+// GC 失败只打日志（多占点磁盘，不影响服务）
+func (f *Follower) activate() error {
+	if !f.layout.Versioned {
+		return nil
+	}
+	if err := f.layout.Activate(f.modelPath); err != nil {
+		return agenterr.Classify(fmt.Errorf("failed to activate %s: %w", f.modelPath, err))
+	}
+	if res, err := f.layout.GCRevisions(f.keepRevisions); err != nil {
+		log.Printf("⚠️  Failed to clean up old revisions: %v", err)
+	} else if len(res.Removed)+len(res.InUse) > 0 {
+		log.Printf("🧹 %s", res)
+	}
+	return nil
+}
+
+// loadPeers 从 coordinator 拿一份 peer 列表（去掉自己），失败时没有 peer，不影响同步
+func (f *Follower) loadPeers(ctx context.Context) {
+	req, err := f.newRequest(ctx, http.MethodGet, f.baseURL+"/peers")
+	if err != nil {
+		return
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+	self := PeerURL(os.Getenv(distribution.PodIPEnv))
+	f.peers = nil
+	for _, peer := range strings.Fields(string(body)) {
+		if peer != self {
+			f.peers = append(f.peers, peer)
+		}
+	}
+	if len(f.peers) > 0 {
+		log.Printf("🌱 %d peers can take over if the coordinator goes away", len(f.peers))
+	}
+}
+
+// failover 在 coordinator 连不上时换到下一个 peer，没有 peer 或者不是网络错误时返回 false
+//
+// 已经下载完的文件不会重新下载（本地存在就跳过），peer 都有完整副本
+func (f *Follower) failover(err error) bool {
+	if len(f.peers) == 0 || agenterr.Kind(err) != agenterr.ErrTransientNetwork {
+		return false
+	}
+	log.Printf("🔀 %s is unreachable (%v), continuing from peer %s", f.baseURL, err, f.peers[0])
+	f.baseURL, f.peers = f.peers[0], f.peers[1:]
+	return true
+}
+
+// PeerURL 是 Pod IP 对应的 model server 地址，podIP 为空时返回空
+func PeerURL(podIP string) string {
+	if podIP == "" {
+		return ""
+	}
+	return ModelServerURL(podIP)
+}
+
+// ModelServerURL 是 host（Pod IP 或者 Service 的 DNS 名字）上 model server 的地址
+//
+// 用 net.JoinHostPort 拼：IPv6 的 Pod IP 要加方括号（http://[fd00::5]:8080）
+func ModelServerURL(host string) string {
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(CoordinatorPort))
+}
+
+// pruneStale 删除不在 files 里的模型文件，失败只打日志（多占点磁盘，不影响服务）
+func pruneStale(modelPath string, files []string) {
+	res, err := distribution.Prune(modelPath, files)
+	if err != nil {
+		log.Printf("⚠️  Failed to clean up stale model files: %v", err)
+		return
+	}
+	if len(res.Removed)+len(res.InUse) > 0 {
+		log.Printf("🧹 %s", res)
+	}
+}
+
+// updateTotal 按 coordinator 列出的总大小更新进度的总字节数
+//
+// 剩余字节数 = 列表总大小 - 本地已经完整的文件；Progress 按 total - written 算剩余，
+// 所以 total 要加上本次已经收到的字节数。老版本 coordinator 没有 size（为 0），ETA 一直是 0
+func (f *Follower) updateTotal(files []string, size int64) {
+	if size <= 0 {
+		return
+	}
+	var local int64
+	for _, filename := range files {
+		if info, err := os.Stat(filepath.Join(f.modelPath, filename)); err == nil && !info.IsDir() {
+			local += info.Size()
+		}
+	}
+	f.progress.SetTotal(f.progress.Written() + max(size-local, 0))
+}
+
 // getFileList 从 Coordinator 获取模型文件列表
 //
 // 调用 Coordinator 的 GET /models 接口
 // 返回值示例：["config.json", "tokenizer.json", "model.safetensors"]
-func (f *Follower) getFileList() ([]string, error) {
+// size 是所有文件的总字节数（老版本 coordinator 没有，为 0），complete 表示 Coordinator 是否已经有完整副本，
+// revision 是 versioned 布局的 coordinator 提供的 revision（flat 布局为空）
+func (f *Follower) getFileList(ctx context.Context) (files []string, size int64, complete bool, revision string, err error) {
 
 	// 构造 URL， 记得我们的coordination class 里面有个model_server 里面有的http， 通过接口调别的pod info
-	url := fmt.Sprintf("http://%s:%d/models", f.coordinatorIP, CoordinatorPort)
+	url := f.baseURL + "/models"
 	log.Printf("📋 Fetching file list from %s", url)
 
 	// Step 2: 发送 HTTP GET 请求
-	resp, err := http.Get(url)
+	req, err := f.newRequest(ctx, http.MethodGet, url)
+	if err != nil {
+		return nil, 0, false, "", err
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch file list: %w", err)
+		return nil, 0, false, "", agenterr.Wrap(agenterr.ErrTransientNetwork, fmt.Errorf("failed to fetch file list: %w", err))
 	}
 	defer resp.Body.Close()
 
 	// Step 3: 检查 HTTP 状态码
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, 0, false, "", agenterr.FromHTTPStatus(resp.StatusCode, fmt.Errorf("unexpected status code: %d", resp.StatusCode))
 	}
 
+	// 老版本 coordinator 没有这个 header，当作完整
+	complete = resp.Header.Get(completeHeader) != "false"
+	size, _ = strconv.ParseInt(resp.Header.Get(sizeHeader), 10, 64)
+	revision = resp.Header.Get(distribution.RevisionHeader)
+
 	// Step 4: 读取响应内容
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, 0, false, "", agenterr.Wrap(agenterr.ErrTransientNetwork, fmt.Errorf("failed to read response: %w", err))
 	}
 
 	// Step 5: 按行分割，返回文件列表
-	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
-	return lines, nil
+	trimmed := strings.TrimSpace(string(body))
+	if trimmed == "" {
+		return nil, size, complete, revision, nil
+	}
+	return strings.Split(trimmed, "\n"), size, complete, revision, nil
+}
+
+// RemoteRevision 返回 model server 提供的 revision，flat 布局的 model server 返回空
+//
+// versioned 布局的 fleet 成员集群用它决定从 hub 集群复制到哪个 revision 目录
+func RemoteRevision(ctx context.Context, baseURL string) (string, error) {
+	_, _, _, revision, err := NewFollowerFromURL(baseURL, "").getFileList(ctx)
+	return revision, err
 }
 
 // downloadFile 从 Coordinator 下载单个文件
@@ -114,37 +687,123 @@ func (f *Follower) getFileList() ([]string, error) {
 // 调用 Coordinator 的 GET /models/{filename} 接口
 // 参数：
 //   - filename: 文件名，比如 "config.json"
-func (f *Follower) downloadFile(filename string) error {
+func (f *Follower) downloadFile(ctx context.Context, filename string) error {
 	// Step 1: 构造 URL
-	url := fmt.Sprintf("http://%s:%d/models/%s", f.coordinatorIP, CoordinatorPort, filename)
+	url := fmt.Sprintf("%s/models/%s", f.baseURL, filename)
 	log.Printf("📥 Downloading %s", filename)
+	start := time.Now()
+
+	// 槽位里下载到一半的文件（上一个 Pod 被驱逐时留下的）从已有的长度接着下载。
+	// 带上下载时记下的 ETag（If-Range），coordinator 上的文件变了会返回整个文件；
+	// 没有 ETag 记录的 .partial 不知道是哪份内容，从头下载
+	localPath := filepath.Join(f.modelPath, filename)
+	partialPath := localPath + partialSuffix
+	var offset int64
+	var etag string
+	if f.slot != "" {
+		if info, err := os.Stat(partialPath); err == nil {
+			if etag = distribution.PartialETag(f.slot, filename); etag != "" {
+				offset = info.Size()
+			}
+		}
+	}
 
 	// Step 2: 发送 HTTP GET 请求
-	resp, err := http.Get(url)
+	req, err := f.newRequest(ctx, http.MethodGet, url)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", etag)
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to download file: %w", err)
+		return agenterr.Wrap(agenterr.ErrTransientNetwork, fmt.Errorf("failed to download file: %w", err))
 	}
 	defer resp.Body.Close()
 
 	// Step 3: 检查状态码
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download %s: status: %d", filename, resp.StatusCode)
+	// 206 接着写；200（老版本 model server 不支持 Range）从头写；
+	// 416 说明 .partial 比 coordinator 的文件还长（文件变了），206 的 ETag 对不上说明 model server 不认 If-Range（老版本），
+	// 都删掉重新下载
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent && resp.Header.Get("ETag") == etag:
+		log.Printf("⏯️  Resuming %s from byte %d", filename, offset)
+	case offset > 0 && (resp.StatusCode == http.StatusPartialContent || resp.StatusCode == http.StatusRequestedRangeNotSatisfiable):
+		_ = os.Remove(partialPath)
+		if err := distribution.SetPartialETag(f.slot, filename, ""); err != nil {
+			return err
+		}
+		return f.downloadFile(ctx, filename)
+	case resp.StatusCode == http.StatusOK:
+		offset = 0
+	default:
+		return agenterr.FromHTTPStatus(resp.StatusCode, fmt.Errorf("failed to download %s: status: %d", filename, resp.StatusCode))
+	}
+	// 从头下载时记下这份内容的 ETag，下一次续传用（model server 没给 ETag 时删掉记录，不续传）
+	if f.slot != "" && offset == 0 {
+		if err := distribution.SetPartialETag(f.slot, filename, resp.Header.Get("ETag")); err != nil {
+			return err
+		}
 	}
 
-	// Step 4: 创建本地文件
-	localPath := filepath.Join(f.modelPath, filename)
-	file, err := os.Create(localPath)
+	// Step 4: 创建本地临时文件
+	// 先写 .partial，完整写完再 rename，保证正式文件名存在时内容一定是完整的
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_WRONLY | os.O_APPEND
+	}
+	file, err := os.OpenFile(partialPath, flags, 0644)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %s, error: %w", filename, err)
+		return agenterr.Classify(fmt.Errorf("failed to create file: %s, error: %w", filename, err))
 	}
-	defer file.Close()
 
 	// Step 5: 把 HTTP 响应写入文件
-	written, err := io.Copy(file, resp.Body)
+	// 写盘失败多半是磁盘满（ENOSPC），读响应失败是连接断了，Classify 会区分
+	body := f.bandwidth.Upstream(ctx, faults.WrapDownload(resp.Body, resp.ContentLength))
+	written, err := io.Copy(file, &progressReader{r: body, progress: f.progress})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
-		return fmt.Errorf("failed to write http response: %w", err)
+		return agenterr.Classify(fmt.Errorf("failed to write http response: %w", err))
+	}
+
+	// Step 6: 校验大小，响应被截断（coordinator 中途挂了）时不能当成完整文件
+	// 槽位里的 .partial 留着，下一次接着下载
+	if resp.ContentLength >= 0 && written != resp.ContentLength {
+		if f.slot == "" {
+			_ = os.Remove(partialPath)
+		}
+		return agenterr.Wrap(agenterr.ErrTransientNetwork,
+			fmt.Errorf("truncated download of %s: got %d bytes, expected %d: %w", filename, written, resp.ContentLength, io.ErrUnexpectedEOF))
+	}
+
+	if err := os.Rename(partialPath, localPath); err != nil {
+		return fmt.Errorf("failed to finalize file: %s, error: %w", filename, err)
 	}
-	log.Printf("✅ Downloaded %s (%d bytes)", filename, written)
+	if f.slot != "" {
+		if err := distribution.SetPartialETag(f.slot, filename, ""); err != nil {
+			return err
+		}
+	}
+	f.progress.FileDone(time.Since(start))
+	log.Printf("✅ Downloaded %s (%d bytes)", filename, offset+written)
 
 	return nil
 }
+
+// progressReader 把读到的字节数记到同步进度里
+type progressReader struct {
+	r        io.Reader
+	progress *distribution.Progress
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.progress.Add(n)
+	}
+	return n, err
+}
@@ -0,0 +1,76 @@
+package follower
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+)
+
+// TestResumePartial 测试槽位里的 .partial 只在 ETag 对得上时续传
+func TestResumePartial(t *testing.T) {
+	content, etag := "0123456789", `"sha256:new"`
+	var ranges []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "model.safetensors", time.Time{}, strings.NewReader(content))
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		partialETag string
+		wantRange   string
+	}{
+		{name: "same content", partialETag: etag, wantRange: "bytes=4-"},
+		// coordinator 上的文件换了（flat 布局没有 revision 可比），If-Range 对不上返回整个文件
+		{name: "content changed", partialETag: `"sha256:old"`, wantRange: "bytes=4-"},
+		// 老版本留下的 .partial 没有 ETag 记录，从头下载
+		{name: "no etag recorded", wantRange: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slot := t.TempDir()
+			if err := distribution.RetargetSlot(slot, "Qwen/Qwen2.5-0.5B", ""); err != nil {
+				t.Fatal(err)
+			}
+			partial := "0123"
+			if tt.partialETag != etag {
+				partial = "abcd"
+			}
+			if err := os.WriteFile(filepath.Join(slot, "model.safetensors"+partialSuffix), []byte(partial), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := distribution.SetPartialETag(slot, "model.safetensors", tt.partialETag); err != nil {
+				t.Fatal(err)
+			}
+			ranges = nil
+
+			f := NewFollowerFromURL(srv.URL, slot)
+			f.slot = slot
+			if err := f.downloadFile(context.Background(), "model.safetensors"); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(filepath.Join(slot, "model.safetensors"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != content {
+				t.Errorf("downloaded %q, want %q", data, content)
+			}
+			if len(ranges) != 1 || ranges[0] != tt.wantRange {
+				t.Errorf("Range headers = %q, want [%q]", ranges, tt.wantRange)
+			}
+			if got := distribution.PartialETag(slot, "model.safetensors"); got != "" {
+				t.Errorf("PartialETag() = %q after the file completed", got)
+			}
+		})
+	}
+}
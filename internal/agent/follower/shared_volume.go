@@ -0,0 +1,45 @@
+package follower
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+)
+
+// waitShared 在共享模型卷（MODEL_TRANSFER=sharedVolume）下代替 syncModel
+//
+// 模型目录就是 coordinator 写的那个，不用传输：等 current 目录里出现完整标记、按 verifier 校验通过就返回。
+// 校验失败不返回 ErrModelCorrupt：目录归 coordinator 管，follower 删文件会影响所有副本，
+// 等 coordinator 重新下载、重新写完整标记（versioned 布局下 current 换到新 revision）
+func (f *Follower) waitShared(ctx context.Context) error {
+	verifier, err := distribution.FromEnv()
+	if err != nil {
+		return err
+	}
+	log.Printf("⏳ Waiting for the coordinator to finish the shared model copy in %s", f.layout.Root)
+	lastErr := ""
+	for {
+		dir := f.layout.Current()
+		if _, err := os.Stat(filepath.Join(dir, completeMarker)); err == nil {
+			err := verifier.Verify(dir)
+			if err == nil {
+				log.Printf("🔏 Shared model copy verified (%s)", verifier.Name())
+				return nil
+			}
+			// 同一个错误只打一次，coordinator 重新下载可能要很久
+			if err.Error() != lastErr {
+				log.Printf("⚠️  Shared model copy failed verification: %v, waiting for the coordinator", err)
+				lastErr = err.Error()
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
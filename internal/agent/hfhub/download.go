@@ -0,0 +1,370 @@
+package hfhub
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+	"github.com/Moore-Z/kubeinfer/internal/agent/bandwidth"
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+// 环境变量，controller 根据 spec.distribution.download 设置
+const (
+	ConcurrencyEnv = "MODEL_DOWNLOAD_CONCURRENCY"
+	ChunkSizeEnv   = "MODEL_DOWNLOAD_CHUNK_SIZE"
+)
+
+const (
+	// DefaultConcurrency 是默认的连接数
+	DefaultConcurrency = 8
+	// DefaultChunkSize 是默认的分段大小：safetensors 分片一般 2-10GB，切成几十段
+	DefaultChunkSize int64 = 256 << 20
+
+	// chunkAttempts 是每段的尝试次数，网络抖动只重下这一段，不用整个文件重来
+	chunkAttempts = 3
+	// progressInterval 是打印进度、更新带宽指标的间隔
+	progressInterval = 10 * time.Second
+
+	partialSuffix = ".partial"
+)
+
+// Downloader 并行下载仓库文件
+//
+// 为什么不直接用 huggingface-cli？
+// huggingface-cli 一个文件一个连接，单连接从 CDN 只能跑到几十 MB/s，
+// 几百 GB 的模型冷启动要一个多小时。这里：
+//  1. 多个文件同时下载
+//  2. 大于 ChunkSize 的文件按 HTTP Range 切段，每段一个连接，写到预分配文件的对应位置
+//
+// 文件和分段共用 Concurrency 个连接。下载中的文件带 .partial 后缀，model server 不会分发。
+type Downloader struct {
+	Source      Source
+	Concurrency int
+	ChunkSize   int64
+
+	// Bandwidth 和 model server 共享带宽预算，nil 表示不限速
+	Bandwidth *bandwidth.Manager
+
+	// Namespace/Pod 是带宽指标的标签，Namespace/LLMService 还是同步进度指标的标签
+	Namespace  string
+	Pod        string
+	LLMService string
+}
+
+// NewDownloader 按 MODEL_DOWNLOAD_CONCURRENCY / MODEL_DOWNLOAD_CHUNK_SIZE（字节）创建 Downloader
+func NewDownloader(source Source) *Downloader {
+	d := &Downloader{
+		Source:      source,
+		Concurrency: DefaultConcurrency,
+		ChunkSize:   DefaultChunkSize,
+		Namespace:   os.Getenv("POD_NAMESPACE"),
+		Pod:         os.Getenv("POD_NAME"),
+		LLMService:  os.Getenv(distribution.LLMServiceEnv),
+	}
+	if v := os.Getenv(ConcurrencyEnv); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			d.Concurrency = n
+		} else {
+			log.Printf("⚠️  Ignoring invalid %s=%q", ConcurrencyEnv, v)
+		}
+	}
+	if v := os.Getenv(ChunkSizeEnv); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			d.ChunkSize = n
+		} else {
+			log.Printf("⚠️  Ignoring invalid %s=%q", ChunkSizeEnv, v)
+		}
+	}
+	return d
+}
+
+// FileURL 返回文件的下载地址：{endpoint}/{repo}/resolve/{revision}/{name}
+//
+// LFS 文件会被重定向到 CDN，Range 请求头会跟着重定向
+func (c *Client) FileURL(repo, revision, name string) string {
+	if revision == "" {
+		revision = "main"
+	}
+	return fmt.Sprintf("%s/%s/resolve/%s/%s", c.Endpoint, repo, revision, name)
+}
+
+// fileTask 是一个正在下载的文件
+type fileTask struct {
+	file    distribution.RemoteFile
+	url     string
+	path    string
+	out     *os.File
+	pending int // 还没完成的段数，受 Downloader.Download 里的 mu 保护
+	start   time.Time
+}
+
+// chunk 是一次 HTTP 请求；length 为 -1 时不带 Range，下载整个文件
+type chunk struct {
+	task   *fileTask
+	offset int64
+	length int64
+}
+
+// Download 把 files 下载到 dir
+//
+// 每个文件下载完（已经从 .partial rename 成正式文件名）调用一次 done，
+// done 是串行调用的，调用方可以直接在里面更新下载状态。
+// 任何一个文件出错都会取消其他连接并返回第一个错误。
+func (d *Downloader) Download(ctx context.Context, repo, revision, dir string, files []distribution.RemoteFile, done func(distribution.RemoteFile) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		doneMu   sync.Mutex
+		firstErr error
+		open     = map[*fileTask]bool{}
+	)
+	fail := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+	progress := distribution.NewProgress(distribution.ProgressLabels{
+		Namespace: d.Namespace, LLMService: d.LLMService, Model: repo, Source: distribution.SourceHub,
+	}, total)
+	stopProgress := d.reportProgress(ctx, progress)
+	defer stopProgress()
+
+	chunks := make(chan chunk)
+	var wg sync.WaitGroup
+	for i := 0; i < d.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range chunks {
+				if err := d.fetchWithRetry(ctx, c, progress); err != nil {
+					fail(fmt.Errorf("failed to download %s: %w", c.task.file.Name, err))
+					continue
+				}
+
+				mu.Lock()
+				c.task.pending--
+				finished := c.task.pending == 0
+				if finished {
+					delete(open, c.task)
+				}
+				mu.Unlock()
+				if !finished {
+					continue
+				}
+
+				// 串行调用 done，sha256 校验在这里做，不占着 mu，其他连接继续下载
+				doneMu.Lock()
+				err := d.finish(c.task, progress, done)
+				doneMu.Unlock()
+				if err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+	// 一个文件一个文件地切段，chunks 没有缓冲，同时打开的文件不会比连接数多太多
+	for _, f := range files {
+		task, parts, err := d.prepare(repo, revision, dir, f)
+		if err != nil {
+			fail(err)
+			break
+		}
+		mu.Lock()
+		task.pending = len(parts)
+		open[task] = true
+		mu.Unlock()
+
+		sent := true
+		for _, c := range parts {
+			select {
+			case chunks <- c:
+			case <-ctx.Done():
+				sent = false
+			}
+			if !sent {
+				break
+			}
+		}
+		if !sent {
+			break
+		}
+	}
+	close(chunks)
+	wg.Wait()
+
+	// 出错时没下完的 .partial 留在磁盘上，重试时覆盖
+	for task := range open {
+		_ = task.out.Close()
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// prepare 创建 .partial 文件并切段
+func (d *Downloader) prepare(repo, revision, dir string, f distribution.RemoteFile) (*fileTask, []chunk, error) {
+	// 文件名来自 Hub API，不允许 ../ 之类跳出模型目录
+	if !filepath.IsLocal(f.Name) {
+		return nil, nil, fmt.Errorf("refusing to download %q outside of the model directory", f.Name)
+	}
+	path := filepath.Join(dir, f.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, nil, agenterr.Classify(err)
+	}
+	out, err := os.OpenFile(path+partialSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, nil, agenterr.Classify(err)
+	}
+	task := &fileTask{file: f, url: d.Source.FileURL(repo, revision, f.Name), path: path, out: out, start: time.Now()}
+
+	// 大小未知或者不够切两段时整个文件一个请求
+	if f.Size <= d.ChunkSize {
+		return task, []chunk{{task: task, length: -1}}, nil
+	}
+	// 先把文件撑到最终大小，各段用 WriteAt 写到自己的位置
+	if err := out.Truncate(f.Size); err != nil {
+		_ = out.Close()
+		return nil, nil, agenterr.Classify(err)
+	}
+	var parts []chunk
+	for off := int64(0); off < f.Size; off += d.ChunkSize {
+		parts = append(parts, chunk{task: task, offset: off, length: min(d.ChunkSize, f.Size-off)})
+	}
+	return task, parts, nil
+}
+
+// finish 关闭文件、rename 成正式文件名、记录耗时并调用 done
+func (d *Downloader) finish(task *fileTask, progress *distribution.Progress, done func(distribution.RemoteFile) error) error {
+	if err := task.out.Sync(); err != nil {
+		_ = task.out.Close()
+		return agenterr.Classify(err)
+	}
+	if err := task.out.Close(); err != nil {
+		return agenterr.Classify(err)
+	}
+	if err := os.Rename(task.path+partialSuffix, task.path); err != nil {
+		return err
+	}
+	progress.FileDone(time.Since(task.start))
+	return done(task.file)
+}
+
+// fetchWithRetry 下载一段，可重试的错误（网络抖动、5xx）最多尝试 chunkAttempts 次
+func (d *Downloader) fetchWithRetry(ctx context.Context, c chunk, progress *distribution.Progress) error {
+	var err error
+	for attempt := 1; attempt <= chunkAttempts; attempt++ {
+		if err = d.fetch(ctx, c, progress); err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || !agenterr.Retryable(err) || attempt == chunkAttempts {
+			break
+		}
+		log.Printf("⚠️  %s [%d+%d] attempt %d failed: %v, retrying", c.task.file.Name, c.offset, c.length, attempt, err)
+		select {
+		case <-time.After(time.Duration(attempt) * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// fetch 发一次请求，把响应写到文件的 [offset, offset+length)
+func (d *Downloader) fetch(ctx context.Context, c chunk, progress *distribution.Progress) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.task.url, nil)
+	if err != nil {
+		return err
+	}
+	want := http.StatusOK
+	if c.length >= 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", c.offset, c.offset+c.length-1))
+		want = http.StatusPartialContent
+	}
+
+	resp, err := d.Source.Do(req)
+	if err != nil {
+		return agenterr.Wrap(agenterr.ErrTransientNetwork, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != want {
+		if c.length >= 0 && resp.StatusCode == http.StatusOK {
+			// 镜像站不支持 Range，整个文件都会回来，不能写到分段的位置
+			return fmt.Errorf("server ignored the range request, set %s larger than the file to disable chunking", ChunkSizeEnv)
+		}
+		return statusError(resp, fmt.Errorf("status %d", resp.StatusCode))
+	}
+
+	if c.length < 0 {
+		// 整个文件重试时从头写
+		if err := c.task.out.Truncate(0); err != nil {
+			return agenterr.Classify(err)
+		}
+	}
+	w := io.NewOffsetWriter(c.task.out, c.offset)
+	body := d.Bandwidth.Upstream(ctx, resp.Body)
+	n, err := io.Copy(w, &countingReader{r: body, d: d, progress: progress})
+	if err != nil {
+		return agenterr.Classify(err)
+	}
+
+	expected := c.length
+	if expected < 0 {
+		expected = c.task.file.Size
+	}
+	if expected > 0 && n != expected {
+		return agenterr.Wrap(agenterr.ErrTransientNetwork,
+			fmt.Errorf("got %d bytes, expected %d: %w", n, expected, io.ErrUnexpectedEOF))
+	}
+	return nil
+}
+
+// countingReader 统计读到的字节数，用于进度和带宽指标
+type countingReader struct {
+	r        io.Reader
+	d        *Downloader
+	progress *distribution.Progress
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 {
+		c.progress.Add(n)
+		metrics.RecordModelDownloadBytes(c.d.Namespace, c.d.Pod, n)
+	}
+	return n, err
+}
+
+// reportProgress 每隔 progressInterval 打印进度并更新带宽和 ETA 指标，返回的函数停止汇报
+func (d *Downloader) reportProgress(ctx context.Context, progress *distribution.Progress) func() {
+	stop := progress.Run(ctx, progressInterval, func(rate float64, eta time.Duration) {
+		metrics.RecordModelDownloadThroughput(d.Namespace, d.Pod, rate)
+		log.Printf("📥 %d/%d MiB (%.1f MiB/s, %d connections, ETA %s)",
+			progress.Written()>>20, progress.Total()>>20, rate/(1<<20), d.Concurrency, eta.Round(time.Second))
+	})
+	return func() {
+		stop()
+		metrics.RecordModelDownloadThroughput(d.Namespace, d.Pod, 0)
+	}
+}
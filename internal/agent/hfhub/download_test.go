@@ -0,0 +1,170 @@
+package hfhub
+
+import (
+	"bytes"
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+)
+
+// hubServer 模拟 /{repo}/resolve/{revision}/{file}，用 http.ServeContent 支持 Range
+type hubServer struct {
+	files map[string][]byte
+
+	mu     sync.Mutex
+	ranged int
+	// failFirst 为 true 时每个路径的第一次请求返回 503
+	failFirst bool
+	failed    map[string]bool
+}
+
+func (h *hubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutPrefix(r.URL.Path, "/org/model/resolve/rev1/")
+	data, found := h.files[name]
+	if !ok || !found {
+		http.NotFound(w, r)
+		return
+	}
+	h.mu.Lock()
+	if r.Header.Get("Range") != "" {
+		h.ranged++
+	}
+	key := r.URL.Path + r.Header.Get("Range")
+	fail := h.failFirst && !h.failed[key]
+	if fail {
+		h.failed[key] = true
+	}
+	h.mu.Unlock()
+	if fail {
+		http.Error(w, "try again", http.StatusServiceUnavailable)
+		return
+	}
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(rand.IntN(256))
+	}
+	return b
+}
+
+func TestDownloadParallelRanges(t *testing.T) {
+	tests := []struct {
+		name       string
+		failFirst  bool
+		wantRanged bool
+	}{
+		{name: "ranged", wantRanged: true},
+		{name: "retries 5xx per chunk", failFirst: true, wantRanged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := &hubServer{
+				files: map[string][]byte{
+					"config.json":                      []byte(`{"model_type":"gpt2"}`),
+					"model-00001-of-00002.safetensors": randomBytes(10_000),
+					"model-00002-of-00002.safetensors": randomBytes(4_097),
+					"empty.txt":                        {},
+				},
+				failFirst: tt.failFirst,
+				failed:    map[string]bool{},
+			}
+			server := httptest.NewServer(hub)
+			defer server.Close()
+
+			var files []distribution.RemoteFile
+			for name, data := range hub.files {
+				files = append(files, distribution.RemoteFile{Name: name, Size: int64(len(data))})
+			}
+
+			dir := t.TempDir()
+			d := &Downloader{
+				Source:      &Client{Endpoint: server.URL, HTTP: server.Client()},
+				Concurrency: 4,
+				ChunkSize:   1024,
+			}
+			var done []string
+			err := d.Download(context.Background(), "org/model", "rev1", dir, files, func(f distribution.RemoteFile) error {
+				// done 串行调用，这里不加锁
+				done = append(done, f.Name)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(done) != len(files) {
+				t.Errorf("done called for %v, want all %d files", done, len(files))
+			}
+			for name, want := range hub.files {
+				got, err := os.ReadFile(filepath.Join(dir, name))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("%s: content differs (%d bytes, want %d)", name, len(got), len(want))
+				}
+			}
+			if partial, _ := filepath.Glob(filepath.Join(dir, "*"+partialSuffix)); len(partial) > 0 {
+				t.Errorf("partial files left behind: %v", partial)
+			}
+			if (hub.ranged > 0) != tt.wantRanged {
+				t.Errorf("ranged requests = %d, wantRanged %v", hub.ranged, tt.wantRanged)
+			}
+		})
+	}
+}
+
+func TestDownloadErrors(t *testing.T) {
+	data := randomBytes(4096)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/org/model/resolve/rev1/no-range.bin":
+			// 不支持 Range 的镜像站：总是返回 200 和整个文件
+			w.Write(data)
+		case "/org/gated/resolve/rev1/model.bin":
+			http.Error(w, "gated", http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	d := &Downloader{
+		Source:      &Client{Endpoint: server.URL, HTTP: server.Client()},
+		Concurrency: 2,
+		ChunkSize:   1024,
+	}
+	noop := func(distribution.RemoteFile) error { return nil }
+
+	err := d.Download(context.Background(), "org/model", "rev1", t.TempDir(),
+		[]distribution.RemoteFile{{Name: "no-range.bin", Size: 4096}}, noop)
+	if err == nil || !strings.Contains(err.Error(), "ignored the range request") {
+		t.Errorf("server without Range support: got %v", err)
+	}
+
+	err = d.Download(context.Background(), "org/gated", "rev1", t.TempDir(),
+		[]distribution.RemoteFile{{Name: "model.bin", Size: 10}}, noop)
+	if agenterr.Kind(err) != agenterr.ErrAuth {
+		t.Errorf("gated file: got %v, want ErrAuth", err)
+	}
+
+	err = d.Download(context.Background(), "org/model", "rev1", t.TempDir(),
+		[]distribution.RemoteFile{{Name: "../escape.bin", Size: 10}}, noop)
+	if err == nil {
+		t.Error("file names outside of the model directory must be rejected")
+	}
+}
@@ -0,0 +1,152 @@
+// Package hfhub 是访问 HuggingFace Hub HTTP API 的最小客户端
+//
+// 用到两个接口：
+//   - 列文件：GET {endpoint}/api/models/{repo}?blobs=true（大小、LFS sha256）
+//   - 下载：GET {endpoint}/{repo}/resolve/{revision}/{file}（支持 Range，见 Downloader）
+//
+// 环境变量和 huggingface-cli 保持一致：HF_ENDPOINT（镜像站）、HF_TOKEN（gated/私有模型）
+package hfhub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+)
+
+// DefaultEndpoint 是没有设置 HF_ENDPOINT 时的地址
+const DefaultEndpoint = "https://huggingface.co"
+
+// Client 是 HuggingFace Hub 客户端
+type Client struct {
+	Endpoint string
+	Token    string
+	HTTP     *http.Client
+}
+
+// Source 是 Downloader 下载文件的地方：HuggingFace Hub（Client），
+// 或者 MLflow、W&B 这样的模型仓库（internal/agent/modelregistry）
+type Source interface {
+	// ListFiles 列出 repo 当前版本的文件，Repo.Revision 固定这个版本
+	ListFiles(ctx context.Context, repo string) (*Repo, error)
+	// FileURL 返回 revision 里一个文件的下载地址
+	FileURL(repo, revision, name string) string
+	// Do 带上认证发送下载请求
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// NewFromEnv 按 HF_ENDPOINT / HF_TOKEN 创建客户端
+func NewFromEnv() *Client {
+	endpoint := os.Getenv("HF_ENDPOINT")
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+	return &Client{
+		Endpoint: strings.TrimSuffix(endpoint, "/"),
+		Token:    os.Getenv("HF_TOKEN"),
+		HTTP:     http.DefaultClient,
+	}
+}
+
+// LicenseAcceptedEnv 为 "true" 表示用户确认接受了 gated 模型的许可协议（controller 根据 spec.modelSource.acceptLicense 设置）
+const LicenseAcceptedEnv = "MODEL_LICENSE_ACCEPTED"
+
+// LicenseAcceptedFromEnv 返回是否已经确认接受许可协议
+func LicenseAcceptedFromEnv() bool {
+	return os.Getenv(LicenseAcceptedEnv) == "true"
+}
+
+// Repo 是一次列文件的结果
+type Repo struct {
+	// Revision 是当前的 commit sha，下载时固定用它，避免下载过程中仓库更新导致文件不一致
+	Revision string
+	// Gated 是仓库的访问限制：空表示不限制，auto（接受协议后自动批准）或 manual（作者人工审核）
+	Gated string
+	Files []distribution.RemoteFile
+}
+
+type modelInfo struct {
+	SHA string `json:"sha"`
+	// Gated 不限制时是 false，否则是 "auto"/"manual"
+	Gated    json.RawMessage `json:"gated"`
+	Siblings []struct {
+		RFilename string `json:"rfilename"`
+		Size      int64  `json:"size"`
+		LFS       *struct {
+			SHA256 string `json:"sha256"`
+			Size   int64  `json:"size"`
+		} `json:"lfs"`
+	} `json:"siblings"`
+}
+
+// ListFiles 列出仓库的文件
+func (c *Client) ListFiles(ctx context.Context, repo string) (*Repo, error) {
+	u := fmt.Sprintf("%s/api/models/%s?blobs=true", c.Endpoint, repo)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.authorize(req)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, agenterr.Wrap(agenterr.ErrTransientNetwork, fmt.Errorf("failed to list files of %s: %w", repo, err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(resp, fmt.Errorf("failed to list files of %s: status %d", repo, resp.StatusCode))
+	}
+
+	info := &modelInfo{}
+	if err := json.NewDecoder(resp.Body).Decode(info); err != nil {
+		return nil, fmt.Errorf("failed to decode model info of %s: %w", repo, err)
+	}
+
+	out := &Repo{Revision: info.SHA}
+	var gated string
+	if json.Unmarshal(info.Gated, &gated) == nil {
+		out.Gated = gated
+	}
+	for _, s := range info.Siblings {
+		f := distribution.RemoteFile{Name: s.RFilename, Size: s.Size}
+		if s.LFS != nil {
+			f.SHA256 = s.LFS.SHA256
+			if f.Size == 0 {
+				f.Size = s.LFS.Size
+			}
+		}
+		out.Files = append(out.Files, f)
+	}
+	return out, nil
+}
+
+// statusError 把非 200 的响应转成带类型的错误
+//
+// gated 仓库没有接受许可协议（或者还在等作者审核）时 Hub 返回 403 + X-Error-Code: GatedRepo，
+// X-Error-Message 里是要去哪个页面申请，原样带上
+func statusError(resp *http.Response, err error) error {
+	if resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-Error-Code") == "GatedRepo" {
+		if msg := resp.Header.Get("X-Error-Message"); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return agenterr.Wrap(agenterr.ErrLicenseNotAccepted, err)
+	}
+	return agenterr.FromHTTPStatus(resp.StatusCode, err)
+}
+
+// Do 带上 HF_TOKEN 发送请求
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	c.authorize(req)
+	return c.HTTP.Do(req)
+}
+
+func (c *Client) authorize(req *http.Request) {
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+}
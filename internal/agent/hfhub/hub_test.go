@@ -0,0 +1,72 @@
+package hfhub
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+)
+
+func TestListFiles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/models/qwen/Qwen2-0.5B":
+			if r.URL.Query().Get("blobs") != "true" {
+				t.Errorf("blobs=true is required to get file sizes")
+			}
+			w.Write([]byte(`{"sha":"abc123","siblings":[
+				{"rfilename":"config.json","size":661},
+				{"rfilename":"model.safetensors","size":988097824,"lfs":{"sha256":"deadbeef","size":988097824}}]}`))
+		case "/api/models/meta-llama/Llama-3.1-8B":
+			if r.Header.Get("Authorization") != "Bearer hf_secret" {
+				http.Error(w, "gated", http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"sha":"def456","gated":"manual","siblings":[]}`))
+		case "/api/models/google/gemma-2-9b":
+			w.Header().Set("X-Error-Code", "GatedRepo")
+			w.Header().Set("X-Error-Message", "Access to model google/gemma-2-9b is restricted and you are not in the authorized list.")
+			http.Error(w, "gated", http.StatusForbidden)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := &Client{Endpoint: server.URL, HTTP: server.Client()}
+	repo, err := c.ListFiles(context.Background(), "qwen/Qwen2-0.5B")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Revision != "abc123" || len(repo.Files) != 2 {
+		t.Fatalf("unexpected repo %+v", repo)
+	}
+	if f := repo.Files[1]; f.Name != "model.safetensors" || f.Size != 988097824 || f.SHA256 != "deadbeef" {
+		t.Errorf("unexpected LFS file %+v", f)
+	}
+	if repo.Gated != "" {
+		t.Errorf("gated = false should parse as empty, got %q", repo.Gated)
+	}
+	if repo.Files[0].SHA256 != "" {
+		t.Errorf("non-LFS files have no sha256, got %q", repo.Files[0].SHA256)
+	}
+
+	_, err = c.ListFiles(context.Background(), "meta-llama/Llama-3.1-8B")
+	if agenterr.Kind(err) != agenterr.ErrAuth {
+		t.Errorf("gated repo without token: got %v, want ErrAuth", err)
+	}
+	c.Token = "hf_secret"
+	if repo, err := c.ListFiles(context.Background(), "meta-llama/Llama-3.1-8B"); err != nil {
+		t.Errorf("gated repo with token: %v", err)
+	} else if repo.Gated != "manual" {
+		t.Errorf("gated = %q, want manual", repo.Gated)
+	}
+
+	// 有 token 但没接受许可协议：403 + X-Error-Code: GatedRepo
+	_, err = c.ListFiles(context.Background(), "google/gemma-2-9b")
+	if agenterr.Kind(err) != agenterr.ErrLicenseNotAccepted {
+		t.Errorf("license not accepted: got %v, want ErrLicenseNotAccepted", err)
+	}
+}
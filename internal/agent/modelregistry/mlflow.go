@@ -0,0 +1,198 @@
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+	"github.com/Moore-Z/kubeinfer/internal/agent/hfhub"
+)
+
+// mlflowArtifactsScheme 是 tracking server 代理的 artifact 地址（mlflow server --serve-artifacts，2.0 之后的默认）
+const mlflowArtifactsScheme = "mlflow-artifacts:"
+
+// MLflow 是 MLflow Model Registry 里的一个模型版本
+//
+// 用到 tracking server 的 REST API：
+//   - GET /api/2.0/mlflow/registered-models/alias            alias → version
+//   - GET /api/2.0/mlflow/model-versions/get-download-uri    version → artifact 地址
+//   - GET /api/2.0/mlflow-artifacts/artifacts?path=...       列目录
+//   - GET /api/2.0/mlflow-artifacts/artifacts/<path>         下载（支持 Range）
+//
+// artifact 直接放在 S3、GCS 上（没有开 --serve-artifacts）的版本不支持，ListFiles 返回错误
+type MLflow struct {
+	Endpoint string
+	Name     string
+	// Version 和 Alias 二选一
+	Version string
+	Alias   string
+	// Path 是版本里模型目录的相对路径（transformers flavor 是 model），为空时是根目录
+	Path string
+
+	// Token 是 Bearer token；没有时用 Username/Password 做 basic auth
+	Token    string
+	Username string
+	Password string
+	HTTP     *http.Client
+
+	// dir 是 ListFiles 解析出来的模型目录（mlflow-artifacts 下的路径），FileURL 在它下面
+	dir string
+}
+
+// NewMLflow 解析 models:/<name>/<version> 或 models:/<name>@<alias>，endpoint 是 tracking server
+func NewMLflow(endpoint, uri string) (*MLflow, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("the MLflow tracking server endpoint is required for %s", uri)
+	}
+	endpoint, err := checkEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	m := &MLflow{Endpoint: endpoint}
+	rest := strings.TrimPrefix(uri, MLflowScheme)
+	if name, alias, ok := strings.Cut(rest, "@"); ok {
+		m.Name, m.Alias = name, alias
+	} else if name, version, ok := strings.Cut(rest, "/"); ok {
+		if _, err := strconv.Atoi(version); err != nil {
+			return nil, fmt.Errorf("invalid MLflow model uri %q: version must be a number, use @<alias> for aliases", uri)
+		}
+		m.Name, m.Version = name, version
+	}
+	if m.Name == "" || (m.Version == "" && m.Alias == "") || strings.Contains(m.Name, "/") {
+		return nil, fmt.Errorf("invalid MLflow model uri %q: must be %s<name>/<version> or %s<name>@<alias>", uri, MLflowScheme, MLflowScheme)
+	}
+	return m, nil
+}
+
+// ListFiles 解析版本、列出模型目录下的所有文件（repo 只是本地目录的名字，不用于查询）
+func (m *MLflow) ListFiles(ctx context.Context, repo string) (*hfhub.Repo, error) {
+	version := m.Version
+	if m.Alias != "" {
+		var resp struct {
+			ModelVersion struct {
+				Version string `json:"version"`
+			} `json:"model_version"`
+		}
+		q := url.Values{"name": {m.Name}, "alias": {m.Alias}}
+		if err := m.get(ctx, "/api/2.0/mlflow/registered-models/alias?"+q.Encode(), &resp); err != nil {
+			return nil, fmt.Errorf("failed to resolve %s@%s: %w", m.Name, m.Alias, err)
+		}
+		version = resp.ModelVersion.Version
+	}
+
+	var download struct {
+		ArtifactURI string `json:"artifact_uri"`
+	}
+	q := url.Values{"name": {m.Name}, "version": {version}}
+	if err := m.get(ctx, "/api/2.0/mlflow/model-versions/get-download-uri?"+q.Encode(), &download); err != nil {
+		return nil, fmt.Errorf("failed to get the artifacts of %s version %s: %w", m.Name, version, err)
+	}
+	if !strings.HasPrefix(download.ArtifactURI, mlflowArtifactsScheme) {
+		return nil, fmt.Errorf("artifacts of %s version %s are at %q, not served by the tracking server: start it with --serve-artifacts",
+			m.Name, version, download.ArtifactURI)
+	}
+	u, err := url.Parse(download.ArtifactURI)
+	if err != nil {
+		return nil, fmt.Errorf("invalid artifact uri %q: %w", download.ArtifactURI, err)
+	}
+	m.dir = strings.Trim(path.Join(u.Path, m.Path), "/")
+
+	files, err := m.list(ctx, m.dir, "")
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s version %s has no files under %q", m.Name, version, "/"+m.Path)
+	}
+	return &hfhub.Repo{Revision: version, Files: files}, nil
+}
+
+// list 递归列出 dir 下的文件，rel 是 dir 相对模型目录的路径
+func (m *MLflow) list(ctx context.Context, dir, rel string) ([]distribution.RemoteFile, error) {
+	var resp struct {
+		Files []struct {
+			Path  string    `json:"path"`
+			IsDir bool      `json:"is_dir"`
+			Size  flexInt64 `json:"file_size"`
+		} `json:"files"`
+	}
+	if err := m.get(ctx, "/api/2.0/mlflow-artifacts/artifacts?"+url.Values{"path": {dir}}.Encode(), &resp); err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	var files []distribution.RemoteFile
+	for _, f := range resp.Files {
+		// 返回的 path 是文件名（旧版本是相对 artifact 根目录的路径），只取最后一段
+		base := path.Base(f.Path)
+		if !f.IsDir {
+			files = append(files, distribution.RemoteFile{Name: path.Join(rel, base), Size: int64(f.Size)})
+			continue
+		}
+		sub, err := m.list(ctx, path.Join(dir, base), path.Join(rel, base))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, sub...)
+	}
+	return files, nil
+}
+
+// FileURL 返回模型目录下文件的下载地址，ListFiles 之后才能调用
+func (m *MLflow) FileURL(repo, revision, name string) string {
+	return m.Endpoint + "/api/2.0/mlflow-artifacts/artifacts/" + escapePath(path.Join(m.dir, name))
+}
+
+// Do 带上 token（或用户名密码）发送请求
+func (m *MLflow) Do(req *http.Request) (*http.Response, error) {
+	switch {
+	case m.Token != "":
+		req.Header.Set("Authorization", "Bearer "+m.Token)
+	case m.Username != "":
+		req.SetBasicAuth(m.Username, m.Password)
+	}
+	return httpClient(m.HTTP).Do(req)
+}
+
+// get 调用 REST API，把 JSON 响应解到 out
+func (m *MLflow) get(ctx context.Context, api string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.Endpoint+api, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := m.Do(req)
+	if err != nil {
+		return agenterr.Wrap(agenterr.ErrTransientNetwork, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return agenterr.FromHTTPStatus(resp.StatusCode, fmt.Errorf("status %d", resp.StatusCode))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// flexInt64 兼容 int64 被编码成字符串（protobuf 的 JSON 编码）和数字两种格式
+type flexInt64 int64
+
+func (n *flexInt64) UnmarshalJSON(data []byte) error {
+	v, err := strconv.ParseInt(strings.Trim(string(data), `"`), 10, 64)
+	if err != nil {
+		return err
+	}
+	*n = flexInt64(v)
+	return nil
+}
+
+// escapePath 逐段转义路径，保留 /
+func escapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, s := range parts {
+		parts[i] = url.PathEscape(s)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,247 @@
+package modelregistry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+	"github.com/Moore-Z/kubeinfer/internal/agent/hfhub"
+)
+
+// download 用 hfhub.Downloader 把 source 列出的文件下载到临时目录（4 字节一段，大文件会分段）
+func download(t *testing.T, source hfhub.Source) (string, *hfhub.Repo) {
+	t.Helper()
+	repo, err := source.ListFiles(context.Background(), "acme/support-bot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	d := &hfhub.Downloader{Source: source, Concurrency: 2, ChunkSize: 4}
+	if err := d.Download(context.Background(), "acme/support-bot", repo.Revision, dir, repo.Files,
+		func(distribution.RemoteFile) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	return dir, repo
+}
+
+func fileNames(repo *hfhub.Repo) []string {
+	var names []string
+	for _, f := range repo.Files {
+		names = append(names, f.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestMLflow(t *testing.T) {
+	files := map[string]string{
+		"1/abc/artifacts/MLmodel":                        "flavors: {}",
+		"1/abc/artifacts/model/config.json":              `{"model_type":"qwen2"}`,
+		"1/abc/artifacts/model/model.safetensors":        "0123456789",
+		"1/abc/artifacts/model/tokenizer/tokenizer.json": "{}",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer mlflow-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/api/2.0/mlflow/registered-models/alias":
+			if q.Get("name") != "support-bot" || q.Get("alias") != "champion" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(`{"model_version":{"name":"support-bot","version":"3"}}`))
+		case "/api/2.0/mlflow/model-versions/get-download-uri":
+			switch q.Get("version") {
+			case "3":
+				_, _ = w.Write([]byte(`{"artifact_uri":"mlflow-artifacts:/1/abc/artifacts"}`))
+			case "4":
+				_, _ = w.Write([]byte(`{"artifact_uri":"s3://mlflow/1/def/artifacts"}`))
+			default:
+				http.NotFound(w, r)
+			}
+		case "/api/2.0/mlflow-artifacts/artifacts":
+			// 只返回这一层，目录要再列一次；大小是字符串（protobuf 的 int64）
+			type entry struct {
+				Path  string `json:"path"`
+				IsDir bool   `json:"is_dir,omitempty"`
+				Size  string `json:"file_size,omitempty"`
+			}
+			seen := map[string]bool{}
+			out := []entry{}
+			for name, content := range files {
+				rest, ok := strings.CutPrefix(name, q.Get("path")+"/")
+				if !ok {
+					continue
+				}
+				first, _, isDir := strings.Cut(rest, "/")
+				if seen[first] {
+					continue
+				}
+				seen[first] = true
+				e := entry{Path: first, IsDir: isDir}
+				if !isDir {
+					e.Size = strconv.Itoa(len(content))
+				}
+				out = append(out, e)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"files": out})
+		default:
+			name, _ := strings.CutPrefix(r.URL.Path, "/api/2.0/mlflow-artifacts/artifacts/")
+			content, ok := files[name]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			http.ServeContent(w, r, name, time.Time{}, strings.NewReader(content))
+		}
+	}))
+	defer server.Close()
+
+	m, err := NewMLflow(server.URL, "models:/support-bot@champion")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Path, m.Token, m.HTTP = "model", "mlflow-token", server.Client()
+
+	dir, repo := download(t, m)
+	if repo.Revision != "3" {
+		t.Errorf("revision = %q, want the version the alias points to", repo.Revision)
+	}
+	// 相对 model 目录，MLmodel 不在里面
+	if got, want := fileNames(repo), []string{"config.json", "model.safetensors", "tokenizer/tokenizer.json"}; !slices.Equal(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+	if got := readFile(t, filepath.Join(dir, "model.safetensors")); got != "0123456789" {
+		t.Errorf("model.safetensors = %q", got)
+	}
+	if got := readFile(t, filepath.Join(dir, "tokenizer", "tokenizer.json")); got != "{}" {
+		t.Errorf("tokenizer.json = %q", got)
+	}
+
+	// artifact 直接在 S3 上，tracking server 没有代理
+	m, _ = NewMLflow(server.URL, "models:/support-bot/4")
+	m.Token, m.HTTP = "mlflow-token", server.Client()
+	if _, err := m.ListFiles(context.Background(), "acme/support-bot"); err == nil || !strings.Contains(err.Error(), "--serve-artifacts") {
+		t.Errorf("ListFiles() error = %v, want a hint about --serve-artifacts", err)
+	}
+}
+
+func TestWandb(t *testing.T) {
+	var storage *httptest.Server
+	contents := map[string]string{"config.json": "{}", "model.safetensors": "abcdefghij", "README.md": "# bot"}
+	// 签名地址：带上 API key 的请求会被拒绝
+	storage = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" || r.URL.Query().Get("sig") != "ok" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		name := strings.TrimPrefix(r.URL.Path, "/bucket/")
+		http.ServeContent(w, r, name, time.Time{}, strings.NewReader(contents[name]))
+	}))
+	defer storage.Close()
+
+	// 两页文件
+	pages := [][]string{{"model/config.json", "README.md"}, {"model/model.safetensors"}}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, key, _ := r.BasicAuth(); r.URL.Path != "/graphql" || user != "api" || key != "wandb-key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var req struct {
+			Variables map[string]any `json:"variables"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.Variables["name"] != "support-bot:latest" {
+			_, _ = w.Write([]byte(`{"data":{"project":{"artifact":null}}}`))
+			return
+		}
+		page := 0
+		if req.Variables["after"] == "cursor-1" {
+			page = 1
+		}
+		var edges []map[string]any
+		for _, name := range pages[page] {
+			edges = append(edges, map[string]any{"node": map[string]any{
+				"name":      name,
+				"sizeBytes": len(contents[filepath.Base(name)]),
+				"directUrl": storage.URL + "/bucket/" + filepath.Base(name) + "?sig=ok",
+			}})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"project": map[string]any{"artifact": map[string]any{
+			"digest": "d41d8cd98f00b204",
+			"files": map[string]any{
+				"edges":    edges,
+				"pageInfo": map[string]any{"hasNextPage": page == 0, "endCursor": "cursor-1"},
+			},
+		}}}})
+	}))
+	defer api.Close()
+
+	w, err := NewWandb(api.URL, "wandb-artifact://acme/llm/support-bot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Path, w.APIKey = "model", "wandb-key"
+
+	dir, repo := download(t, w)
+	if repo.Revision != "d41d8cd98f00b204" {
+		t.Errorf("revision = %q, want the artifact digest", repo.Revision)
+	}
+	if got, want := fileNames(repo), []string{"config.json", "model.safetensors"}; !slices.Equal(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+	if got := readFile(t, filepath.Join(dir, "model.safetensors")); got != "abcdefghij" {
+		t.Errorf("model.safetensors = %q", got)
+	}
+
+	w, _ = NewWandb(api.URL, "wandb-artifact://acme/llm/other:v1")
+	w.APIKey = "wandb-key"
+	if _, err := w.ListFiles(context.Background(), "acme/other"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("ListFiles() of a missing artifact error = %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		uri, endpoint string
+		valid         bool
+	}{
+		{"models:/support-bot/3", "http://mlflow:5000", true},
+		{"models:/support-bot@champion", "https://mlflow.example.com/", true},
+		{"models:/support-bot/3", "", false},
+		{"models:/support-bot/Production", "http://mlflow:5000", false},
+		{"models:/support-bot", "http://mlflow:5000", false},
+		{"models:/support-bot@champion", "mlflow:5000", false},
+		{"wandb-artifact://acme/llm/support-bot", "", true},
+		{"wandb-artifact://acme/llm/support-bot:v7", "https://wandb.internal", true},
+		{"wandb-artifact://acme/support-bot", "", false},
+		{"wandb-artifact://acme/llm/:v7", "", false},
+		{"s3://models/support-bot", "", false},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.uri, tt.endpoint); (err == nil) != tt.valid {
+			t.Errorf("Validate(%q, %q) = %v, want valid %v", tt.uri, tt.endpoint, err, tt.valid)
+		}
+	}
+}
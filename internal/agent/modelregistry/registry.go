@@ -0,0 +1,102 @@
+// Package modelregistry 从 HuggingFace 之外的模型仓库下载模型：MLflow Model Registry 和 Weights & Biases artifact
+//
+// 内部微调出来的模型登记在 MLflow 或 W&B 里，以前要先手工上传到 HuggingFace 或 S3 才能部署。
+// 设置了 spec.modelSource.registry 时，controller 把地址和凭证传给 agent（MODEL_REGISTRY_*），
+// coordinator 用这里的 Source 代替 HuggingFace Hub 列文件、下载：
+//
+//	models:/<name>/<version>、models:/<name>@<alias>          MLflow（模型文件由 tracking server 代理，--serve-artifacts）
+//	wandb-artifact://<entity>/<project>/<name>[:<version|alias>]   W&B（默认 latest）
+//
+// 两者都实现 hfhub.Source：下载复用 hfhub.Downloader 的并行、分段、断点续传。
+// Revision 是解析出来的版本（MLflow 的 version、W&B 的 digest），alias 指向新版本后按换了 revision 处理。
+package modelregistry
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/hfhub"
+)
+
+// 环境变量，controller 根据 spec.modelSource.registry 设置；凭证的变量名和两家的客户端一致
+const (
+	URIEnv      = "MODEL_REGISTRY_URI"
+	EndpointEnv = "MODEL_REGISTRY_ENDPOINT"
+	// PathEnv 是版本里模型目录的相对路径
+	PathEnv = "MODEL_REGISTRY_PATH"
+
+	MLflowTokenEnv    = "MLFLOW_TRACKING_TOKEN"
+	MLflowUsernameEnv = "MLFLOW_TRACKING_USERNAME"
+	MLflowPasswordEnv = "MLFLOW_TRACKING_PASSWORD"
+	WandbAPIKeyEnv    = "WANDB_API_KEY"
+)
+
+// URI 的前缀
+const (
+	MLflowScheme = "models:/"
+	WandbScheme  = "wandb-artifact://"
+)
+
+// DefaultWandbEndpoint 是没有设置 endpoint 时 W&B 的地址
+const DefaultWandbEndpoint = "https://api.wandb.ai"
+
+// FromEnv 按 MODEL_REGISTRY_* 创建 Source，没有设置 MODEL_REGISTRY_URI 时返回 nil
+func FromEnv() (hfhub.Source, error) {
+	uri := os.Getenv(URIEnv)
+	if uri == "" {
+		return nil, nil
+	}
+	endpoint := os.Getenv(EndpointEnv)
+	switch {
+	case strings.HasPrefix(uri, MLflowScheme):
+		m, err := NewMLflow(endpoint, uri)
+		if err != nil {
+			return nil, err
+		}
+		m.Path = os.Getenv(PathEnv)
+		m.Token = os.Getenv(MLflowTokenEnv)
+		m.Username = os.Getenv(MLflowUsernameEnv)
+		m.Password = os.Getenv(MLflowPasswordEnv)
+		return m, nil
+	case strings.HasPrefix(uri, WandbScheme):
+		w, err := NewWandb(endpoint, uri)
+		if err != nil {
+			return nil, err
+		}
+		w.Path = os.Getenv(PathEnv)
+		w.APIKey = os.Getenv(WandbAPIKeyEnv)
+		return w, nil
+	}
+	return nil, fmt.Errorf("unsupported model registry uri %q: must start with %s or %s", uri, MLflowScheme, WandbScheme)
+}
+
+// Validate 检查 URI 和 endpoint，webhook 在创建 LLMService 时调用，不发请求
+func Validate(uri, endpoint string) error {
+	switch {
+	case strings.HasPrefix(uri, MLflowScheme):
+		_, err := NewMLflow(endpoint, uri)
+		return err
+	case strings.HasPrefix(uri, WandbScheme):
+		_, err := NewWandb(endpoint, uri)
+		return err
+	}
+	return fmt.Errorf("must start with %s or %s", MLflowScheme, WandbScheme)
+}
+
+// checkEndpoint 检查 endpoint 是 http(s) 地址，去掉末尾的 /
+func checkEndpoint(endpoint string) (string, error) {
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		return "", fmt.Errorf("invalid endpoint %q: must be an http(s) URL", endpoint)
+	}
+	return strings.TrimSuffix(endpoint, "/"), nil
+}
+
+// httpClient 是没有设置 HTTP 时用的 client
+func httpClient(c *http.Client) *http.Client {
+	if c == nil {
+		return http.DefaultClient
+	}
+	return c
+}
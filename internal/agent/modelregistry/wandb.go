@@ -0,0 +1,183 @@
+package modelregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+	"github.com/Moore-Z/kubeinfer/internal/agent/hfhub"
+)
+
+// wandbPageSize 是每次 GraphQL 查询返回的文件数
+const wandbPageSize = 1000
+
+// wandbFilesQuery 查 artifact 的 digest 和文件列表（directUrl 是存储的签名地址）
+const wandbFilesQuery = `query ArtifactFiles($entity: String!, $project: String!, $name: String!, $first: Int, $after: String) {
+  project(name: $project, entityName: $entity) {
+    artifact(name: $name) {
+      digest
+      files(first: $first, after: $after) {
+        edges { node { name sizeBytes directUrl } }
+        pageInfo { hasNextPage endCursor }
+      }
+    }
+  }
+}`
+
+// Wandb 是 Weights & Biases 的一个 artifact 版本
+//
+// 文件列表来自 GraphQL API（/graphql，basic auth api:<WANDB_API_KEY>），下载用每个文件的 directUrl：
+// 一般是对象存储的签名地址，不需要再带 API key，也支持 Range。签名过期后下载返回 403，
+// agent 退出重启时重新列文件拿到新地址，下载过的文件按状态文件跳过
+type Wandb struct {
+	Endpoint string
+	Entity   string
+	Project  string
+	// Name 是 <artifact>:<version|alias>
+	Name string
+	// Path 是 artifact 里模型目录的相对路径，为空时是根目录
+	Path string
+
+	APIKey string
+	HTTP   *http.Client
+
+	// urls 是 ListFiles 拿到的下载地址，key 是相对模型目录的文件名
+	urls map[string]string
+}
+
+// NewWandb 解析 wandb-artifact://<entity>/<project>/<artifact>[:<version|alias>]，endpoint 为空时是 api.wandb.ai
+func NewWandb(endpoint, uri string) (*Wandb, error) {
+	if endpoint == "" {
+		endpoint = DefaultWandbEndpoint
+	}
+	endpoint, err := checkEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(strings.TrimPrefix(uri, WandbScheme), "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" || strings.HasPrefix(parts[2], ":") {
+		return nil, fmt.Errorf("invalid W&B artifact uri %q: must be %s<entity>/<project>/<artifact>[:<version|alias>]", uri, WandbScheme)
+	}
+	name := parts[2]
+	if !strings.Contains(name, ":") {
+		name += ":latest"
+	}
+	return &Wandb{Endpoint: endpoint, Entity: parts[0], Project: parts[1], Name: name}, nil
+}
+
+// ListFiles 列出 artifact 的文件，Revision 是 artifact 的 digest（alias 指向新版本时会变）
+func (w *Wandb) ListFiles(ctx context.Context, repo string) (*hfhub.Repo, error) {
+	out := &hfhub.Repo{}
+	w.urls = map[string]string{}
+	prefix := strings.Trim(w.Path, "/")
+	after := ""
+	for {
+		var data struct {
+			Project *struct {
+				Artifact *struct {
+					Digest string `json:"digest"`
+					Files  struct {
+						Edges []struct {
+							Node struct {
+								Name      string    `json:"name"`
+								SizeBytes flexInt64 `json:"sizeBytes"`
+								DirectURL string    `json:"directUrl"`
+							} `json:"node"`
+						} `json:"edges"`
+						PageInfo struct {
+							HasNextPage bool   `json:"hasNextPage"`
+							EndCursor   string `json:"endCursor"`
+						} `json:"pageInfo"`
+					} `json:"files"`
+				} `json:"artifact"`
+			} `json:"project"`
+		}
+		vars := map[string]any{"entity": w.Entity, "project": w.Project, "name": w.Name, "first": wandbPageSize}
+		if after != "" {
+			vars["after"] = after
+		}
+		if err := w.query(ctx, vars, &data); err != nil {
+			return nil, fmt.Errorf("failed to list files of %s/%s/%s: %w", w.Entity, w.Project, w.Name, err)
+		}
+		if data.Project == nil || data.Project.Artifact == nil {
+			return nil, fmt.Errorf("artifact %s/%s/%s not found", w.Entity, w.Project, w.Name)
+		}
+		a := data.Project.Artifact
+		out.Revision = a.Digest
+		for _, e := range a.Files.Edges {
+			name := e.Node.Name
+			if prefix != "" {
+				if !strings.HasPrefix(name, prefix+"/") {
+					continue
+				}
+				name = strings.TrimPrefix(name, prefix+"/")
+			}
+			w.urls[name] = e.Node.DirectURL
+			out.Files = append(out.Files, distribution.RemoteFile{Name: name, Size: int64(e.Node.SizeBytes)})
+		}
+		if !a.Files.PageInfo.HasNextPage {
+			break
+		}
+		after = a.Files.PageInfo.EndCursor
+	}
+	if len(out.Files) == 0 {
+		return nil, fmt.Errorf("artifact %s/%s/%s has no files under %q", w.Entity, w.Project, w.Name, "/"+prefix)
+	}
+	return out, nil
+}
+
+// FileURL 返回 ListFiles 拿到的 directUrl
+func (w *Wandb) FileURL(repo, revision, name string) string {
+	return w.urls[path.Clean(name)]
+}
+
+// Do 发送请求；只有发给 W&B 自己（本地部署的文件存储）的请求带 API key，签名地址带了反而会被存储拒绝
+func (w *Wandb) Do(req *http.Request) (*http.Response, error) {
+	if w.APIKey != "" {
+		if u, err := url.Parse(w.Endpoint); err == nil && req.URL.Host == u.Host {
+			req.SetBasicAuth("api", w.APIKey)
+		}
+	}
+	return httpClient(w.HTTP).Do(req)
+}
+
+// query 发一个 GraphQL 查询，把 data 解到 out
+func (w *Wandb) query(ctx context.Context, vars map[string]any, out any) error {
+	body, err := json.Marshal(map[string]any{"query": wandbFilesQuery, "variables": vars})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.Endpoint+"/graphql", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.Do(req)
+	if err != nil {
+		return agenterr.Wrap(agenterr.ErrTransientNetwork, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return agenterr.FromHTTPStatus(resp.StatusCode, fmt.Errorf("status %d", resp.StatusCode))
+	}
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode the GraphQL response: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return fmt.Errorf("graphql: %s", result.Errors[0].Message)
+	}
+	return json.Unmarshal(result.Data, out)
+}
@@ -0,0 +1,272 @@
+// Package resolver 用 informer 缓存 coordinator 的 Lease 和 Pod，给 agent 查询 coordinator 地址
+//
+// 以前 follower 每次重试、startup gate 每次轮询、peer server 每次回源都要 GET 一次 Lease 再 GET 一次 Pod。
+// 副本多的时候这些请求都打到 API server 上，而且 coordinator 换人之后 follower 要等到下载失败、
+// 退避之后才会去查新的地址。
+//
+// Resolver 只 watch 一个 Lease（按名字过滤）和同一个 LLMService 的 Pod（按 llm_cr label 过滤）：
+//
+//	Holder / PodIP   读本地缓存，不发请求
+//	Subscribe        Lease 持有者或者它的 Pod IP 变化时通知
+//
+// 缓存还没同步完（刚启动、RBAC 不允许 list/watch）时退回直接 GET，和以前的行为一样。
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	coordinationv1listers "k8s.io/client-go/listers/coordination/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// PodLabel 是 controller 给推理 Pod 打的 LLMService 名字 label，用来缩小 Pod 的 watch 范围
+const PodLabel = "llm_cr"
+
+// syncTimeout 是 Start 等待缓存同步的上限，超时后查询退回直接 GET
+const syncTimeout = 30 * time.Second
+
+// Coordinator 是 Lease 的持有者和它的 Pod IP（Pod 还没分到 IP 时为空）
+type Coordinator struct {
+	Pod string
+	IP  string
+}
+
+// Resolver 缓存 coordinator 的 Lease 和 Pod
+type Resolver struct {
+	clientset kubernetes.Interface
+	namespace string
+	leaseName string
+
+	leaseFactory informers.SharedInformerFactory
+	podFactory   informers.SharedInformerFactory
+	leases       coordinationv1listers.LeaseLister
+	pods         corev1listers.PodLister
+
+	mu      sync.Mutex
+	synced  bool
+	current Coordinator
+	// last 是最近一个有 IP 的 coordinator，before 是 last 之前的另一个（Lease 让出之后 current 为空，它们还在）
+	last, before Coordinator
+	subs         map[chan struct{}]struct{}
+}
+
+// New 创建 Resolver，llmName 为空时 watch namespace 里所有的 Pod；调用 Start 之后才有缓存
+func New(clientset kubernetes.Interface, namespace, leaseName, llmName string) *Resolver {
+	r := &Resolver{
+		clientset: clientset,
+		namespace: namespace,
+		leaseName: leaseName,
+		subs:      map[chan struct{}]struct{}{},
+	}
+	r.leaseFactory = informers.NewSharedInformerFactoryWithOptions(clientset, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.FieldSelector = fields.OneTermEqualSelector("metadata.name", leaseName).String()
+		}))
+	r.podFactory = informers.NewSharedInformerFactoryWithOptions(clientset, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			if llmName != "" {
+				o.LabelSelector = labels.Set{PodLabel: llmName}.String()
+			}
+		}))
+
+	leaseInformer := r.leaseFactory.Coordination().V1().Leases()
+	podInformer := r.podFactory.Core().V1().Pods()
+	r.leases, r.pods = leaseInformer.Lister(), podInformer.Lister()
+	// 任何变化都重新算一遍，变了才通知
+	refresh := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(any) { r.refresh() },
+		UpdateFunc: func(any, any) { r.refresh() },
+		DeleteFunc: func(any) { r.refresh() },
+	}
+	_, _ = leaseInformer.Informer().AddEventHandler(refresh)
+	_, _ = podInformer.Informer().AddEventHandler(refresh)
+	return r
+}
+
+// Start 启动 informer 并等待缓存同步，ctx 取消时停止
+//
+// 超时不返回错误：查询退回直接 GET，informer 在后台继续同步，同步完之后自动改用缓存
+func (r *Resolver) Start(ctx context.Context) {
+	r.leaseFactory.Start(ctx.Done())
+	r.podFactory.Start(ctx.Done())
+
+	syncCtx, cancel := context.WithTimeout(ctx, syncTimeout)
+	defer cancel()
+	if !r.waitForSync(syncCtx) {
+		log.Printf("⚠️  Coordinator cache not synced after %v, querying the API server directly until it is", syncTimeout)
+		go func() {
+			if r.waitForSync(ctx) {
+				log.Println("✅ Coordinator cache synced")
+			}
+		}()
+	}
+}
+
+// waitForSync 等两个 informer 都同步完，成功时标记 synced
+func (r *Resolver) waitForSync(ctx context.Context) bool {
+	for _, synced := range r.leaseFactory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return false
+		}
+	}
+	for _, synced := range r.podFactory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return false
+		}
+	}
+	r.mu.Lock()
+	r.synced = true
+	r.mu.Unlock()
+	r.refresh()
+	return true
+}
+
+func (r *Resolver) isSynced() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.synced
+}
+
+// Holder 返回 Lease 的持有者（Pod 名称）
+func (r *Resolver) Holder(ctx context.Context) (string, error) {
+	var lease *coordinationv1.Lease
+	var err error
+	if r.isSynced() {
+		lease, err = r.leases.Leases(r.namespace).Get(r.leaseName)
+	} else {
+		lease, err = r.clientset.CoordinationV1().Leases(r.namespace).Get(ctx, r.leaseName, metav1.GetOptions{})
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get lease: %w", err)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		return "", fmt.Errorf("lease has no holder")
+	}
+	return *lease.Spec.HolderIdentity, nil
+}
+
+// PodIP 返回 Pod 的 IP
+//
+// 缓存里没有这个 Pod（没有 llm_cr label）时也直接 GET
+func (r *Resolver) PodIP(ctx context.Context, name string) (string, error) {
+	var pod *corev1.Pod
+	var err error
+	if r.isSynced() {
+		pod, err = r.pods.Pods(r.namespace).Get(name)
+	}
+	if !r.isSynced() || apierrors.IsNotFound(err) {
+		pod, err = r.clientset.CoreV1().Pods(r.namespace).Get(ctx, name, metav1.GetOptions{})
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get coordinator pod: %w", err)
+	}
+	if pod.Status.PodIP == "" {
+		return "", fmt.Errorf("coordinator pod has no IP")
+	}
+	return pod.Status.PodIP, nil
+}
+
+// PodGone 判断 Pod 是不是已经不在了（不存在或者已经终止），查询失败时当作还在
+//
+// 直接 GET，不看缓存：缓存同步之前、Pod 没有 llm_cr label 时缓存里都没有它，不能当成不存在
+func (r *Resolver) PodGone(ctx context.Context, name string) bool {
+	pod, err := r.clientset.CoreV1().Pods(r.namespace).Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+	return pod.Status.Phase == corev1.PodFailed || pod.Status.Phase == corev1.PodSucceeded
+}
+
+// CoordinatorIP 返回 Lease 持有者的 Pod IP
+func (r *Resolver) CoordinatorIP(ctx context.Context) (string, error) {
+	holder, err := r.Holder(ctx)
+	if err != nil {
+		return "", err
+	}
+	return r.PodIP(ctx, holder)
+}
+
+// Current 返回缓存里当前的 coordinator，缓存没同步或者没有持有者时为空
+func (r *Resolver) Current() Coordinator {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Previous 返回 self 之前的 coordinator：刚当选的 Pod 用它找上一个 coordinator 交接，不知道时为空
+//
+// 上一个 coordinator 主动让出 Lease 时持有者被清空，所以记的是最近见过的，不是 Lease 上的
+func (r *Resolver) Previous(self string) Coordinator {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.last.Pod != self {
+		return r.last
+	}
+	return r.before
+}
+
+// Subscribe 返回一个 channel，coordinator（持有者或者 Pod IP）变化时收到通知；调用 cancel 取消订阅
+//
+// 通知不排队：连续变化多次只保证收到一次，收到之后用 Current 读最新的值
+func (r *Resolver) Subscribe() (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	r.mu.Lock()
+	r.subs[ch] = struct{}{}
+	r.mu.Unlock()
+	return ch, func() {
+		r.mu.Lock()
+		delete(r.subs, ch)
+		r.mu.Unlock()
+	}
+}
+
+// refresh 从缓存重新计算 coordinator，变化时通知订阅者
+func (r *Resolver) refresh() {
+	if !r.isSynced() {
+		return
+	}
+	var next Coordinator
+	if lease, err := r.leases.Leases(r.namespace).Get(r.leaseName); err == nil && lease.Spec.HolderIdentity != nil {
+		next.Pod = *lease.Spec.HolderIdentity
+		if pod, err := r.pods.Pods(r.namespace).Get(next.Pod); err == nil {
+			next.IP = pod.Status.PodIP
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if next == r.current {
+		return
+	}
+	r.current = next
+	if next.IP != "" {
+		if next.Pod != r.last.Pod {
+			r.before = r.last
+		}
+		r.last = next
+	}
+	for ch := range r.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
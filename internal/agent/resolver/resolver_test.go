@@ -0,0 +1,105 @@
+package resolver
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func lease(holder string) *coordinationv1.Lease {
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: "qwen-cache-lease", Namespace: "default"},
+		Spec:       coordinationv1.LeaseSpec{HolderIdentity: &holder},
+	}
+}
+
+func pod(name, ip string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{PodLabel: "qwen"}},
+		Status:     corev1.PodStatus{PodIP: ip},
+	}
+}
+
+// waitNotified 等一次变化通知
+func waitNotified(t *testing.T, changes <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no change notification")
+	}
+}
+
+func TestResolver(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clientset := fake.NewClientset(lease("qwen-a"), pod("qwen-a", "10.0.0.1"), pod("qwen-b", ""))
+	r := New(clientset, "default", "qwen-cache-lease", "qwen")
+	r.Start(ctx)
+
+	if ip, err := r.CoordinatorIP(ctx); err != nil || ip != "10.0.0.1" {
+		t.Fatalf("CoordinatorIP = %q, %v, want 10.0.0.1", ip, err)
+	}
+	if got := r.Current(); got != (Coordinator{Pod: "qwen-a", IP: "10.0.0.1"}) {
+		t.Errorf("Current = %+v", got)
+	}
+
+	changes, unsubscribe := r.Subscribe()
+	defer unsubscribe()
+
+	// 换人：新 coordinator 还没分到 IP
+	if _, err := clientset.CoordinationV1().Leases("default").Update(ctx, lease("qwen-b"), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitNotified(t, changes)
+	if _, err := r.CoordinatorIP(ctx); err == nil {
+		t.Error("CoordinatorIP of a pod without an IP succeeded")
+	}
+
+	// 分到 IP 之后再通知一次
+	if _, err := clientset.CoreV1().Pods("default").Update(ctx, pod("qwen-b", "fd00::2"), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	waitNotified(t, changes)
+	if got := r.Current(); got != (Coordinator{Pod: "qwen-b", IP: "fd00::2"}) {
+		t.Errorf("Current after the new coordinator got an IP = %+v", got)
+	}
+	if ip, err := r.CoordinatorIP(ctx); err != nil || ip != "fd00::2" {
+		t.Errorf("CoordinatorIP = %q, %v, want fd00::2", ip, err)
+	}
+
+	// 刚当选的 qwen-b 找上一个 coordinator 交接
+	if got := r.Previous("qwen-b"); got != (Coordinator{Pod: "qwen-a", IP: "10.0.0.1"}) {
+		t.Errorf("Previous(qwen-b) = %+v, want qwen-a", got)
+	}
+	if got := r.Previous("qwen-c"); got.Pod != "qwen-b" {
+		t.Errorf("Previous(qwen-c) = %+v, want qwen-b", got)
+	}
+
+	// 和 coordinator 无关的 Pod 变化不通知
+	if _, err := clientset.CoreV1().Pods("default").Update(ctx, pod("qwen-a", "10.0.0.9"), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+		t.Error("notified about a pod that is not the coordinator")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestResolverBeforeSync(t *testing.T) {
+	// 没有 Start：直接 GET
+	clientset := fake.NewClientset(lease(""), pod("other", "10.0.0.3"))
+	r := New(clientset, "default", "qwen-cache-lease", "")
+	if _, err := r.Holder(context.Background()); err == nil {
+		t.Error("Holder of a lease without a holder succeeded")
+	}
+	if ip, err := r.PodIP(context.Background(), "other"); err != nil || ip != "10.0.0.3" {
+		t.Errorf("PodIP = %q, %v, want 10.0.0.3", ip, err)
+	}
+}
@@ -0,0 +1,117 @@
+package runtime
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// 优雅下线（连接排空）
+// ============================================================================
+//
+// Pod 被删除时的顺序：
+//  1. kubelet 调用 preStop（GET /drain）→ agent 标记 NotReady
+//  2. Endpoints 把 Pod 摘掉，新请求不再进来
+//  3. 等正在生成的请求全部完成（最多 timeout）
+//  4. preStop 返回，kubelet 发 SIGTERM → agent 停 vLLM、让出 lease
+//
+// 整个过程受 terminationGracePeriodSeconds 限制，controller 会根据
+// Spec.MaxGenerationTime 设置它。
+// ============================================================================
+
+// drainPollInterval 是排空时检查 in-flight 请求数的间隔
+const drainPollInterval = 2 * time.Second
+
+// Drain 标记 NotReady，然后等待本地推理服务的 in-flight 请求全部完成
+//
+// in-flight 请求数从 /metrics 读，各后端的指标名由 Runtime.InflightMetrics 提供。
+// 超时或者服务已经不可达都直接返回（不可达说明已经没有请求可等了）
+func Drain(ctx context.Context, rt Runtime, timeout time.Duration) {
+	Health.SetDraining()
+	log.Printf("🚰 Draining %s (timeout: %v)...", rt.Name(), timeout)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client, metricsURL := httpClient(rt), rt.Endpoint()+"/metrics"
+	names := rt.InflightMetrics()
+	for {
+		n, err := inflightRequests(ctx, client, metricsURL, names)
+		if err != nil {
+			log.Printf("⚠️  Cannot read in-flight requests (%v), stop draining", err)
+			return
+		}
+		if n == 0 {
+			log.Println("✅ No in-flight requests, drain complete")
+			return
+		}
+		log.Printf("⏳ Waiting for %d in-flight requests...", n)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("⚠️  Drain timeout with %d requests still in flight", n)
+			return
+		case <-time.After(drainPollInterval):
+		}
+	}
+}
+
+// inflightRequests 读取 /metrics，返回运行中 + 排队中的请求数
+func inflightRequests(ctx context.Context, client *http.Client, metricsURL string, names []string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metricsURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("metrics returned status %d", resp.StatusCode)
+	}
+	return parseInflight(resp.Body, names)
+}
+
+// parseInflight 从 Prometheus 文本格式里累加 names 中的指标
+//
+// 一行的格式：vllm:num_requests_running{model_name="x"} 3.0
+func parseInflight(r io.Reader, names []string) (int, error) {
+	total := 0.0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, name := range names {
+			if !strings.HasPrefix(line, name+"{") && !strings.HasPrefix(line, name+" ") {
+				continue
+			}
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			// 标签里可能有空格，值总是倒数第一个（没有时间戳时）或倒数第二个字段
+			v, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+			if err != nil && len(fields) >= 3 {
+				v, err = strconv.ParseFloat(fields[len(fields)-2], 64)
+			}
+			if err == nil {
+				total += v
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return int(total), nil
+}
@@ -0,0 +1,24 @@
+package runtime
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseInflight(t *testing.T) {
+	metrics := `# HELP vllm:num_requests_running Number of requests currently running on GPU.
+# TYPE vllm:num_requests_running gauge
+vllm:num_requests_running{model_name="opt-125m"} 2.0
+# HELP vllm:num_requests_waiting Number of requests waiting to be processed.
+vllm:num_requests_waiting{model_name="opt-125m"} 1.0
+vllm:num_requests_swapped{model_name="opt-125m"} 4.0
+vllm:gpu_cache_usage_perc{model_name="opt-125m"} 0.5
+`
+	n, err := parseInflight(strings.NewReader(metrics), NewVLLM(DefaultConfig("/models")).InflightMetrics())
+	if err != nil {
+		t.Fatalf("parseInflight: %v", err)
+	}
+	if n != 7 {
+		t.Errorf("parseInflight = %d, want 7", n)
+	}
+}
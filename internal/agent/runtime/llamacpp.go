@@ -0,0 +1,104 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+)
+
+// LlamaCpp 是 llama.cpp 的 llama-server，适合没有 GPU 的节点（GGUF 量化模型）
+type LlamaCpp struct {
+	*process
+	config *Config
+}
+
+func NewLlamaCpp(config *Config) *LlamaCpp {
+	l := &LlamaCpp{config: config}
+	l.process = &process{
+		name:   "llama.cpp",
+		binary: "llama-server",
+		buildArgs: func() ([]string, error) {
+			// llama-server 需要具体的 .gguf 文件，启动时模型已经下载好了
+			if _, err := l.modelFile(); err != nil {
+				return nil, err
+			}
+			return l.BuildArgs(), nil
+		},
+	}
+	return l
+}
+
+func (l *LlamaCpp) Name() string { return KindLlamaCpp }
+
+// BuildArgs 参数映射：
+// - ModelPath 目录下的第一个 .gguf 文件 → --model
+// - MaxModelLen → --ctx-size
+// - Socket → --host（llama-server 把 .sock 结尾的 host 当成 unix socket）
+// - 总是加 --metrics，排空时要读 in-flight 请求数
+// TensorParallelSize/GPUMemoryUtilization/Dtype 对 llama.cpp 没有意义，忽略
+func (l *LlamaCpp) BuildArgs() []string {
+	model, err := l.modelFile()
+	if err != nil {
+		model = l.config.ModelPath
+	}
+
+	args := []string{"--model", model}
+	if l.config.Socket != "" {
+		// host 以 .sock 结尾时 llama-server 监听 unix socket，忽略 --port
+		args = append(args, "--host", l.config.Socket)
+	} else {
+		args = append(args, "--host", l.config.Host, "--port", strconv.Itoa(l.config.Port))
+	}
+	args = append(args, "--metrics")
+
+	if l.config.MaxModelLen > 0 {
+		args = append(args, "--ctx-size", strconv.Itoa(l.config.MaxModelLen))
+	}
+	if len(l.config.ExtraArgs) > 0 {
+		args = append(args, l.config.ExtraArgs...)
+	}
+
+	return args
+}
+
+// modelFile 找到模型目录下的 .gguf 文件（按文件名排序取第一个，分片模型从第一片加载）
+// ModelPath 本身就是 .gguf 文件时直接使用
+func (l *LlamaCpp) modelFile() (string, error) {
+	if strings.HasSuffix(l.config.ModelPath, ".gguf") {
+		return l.config.ModelPath, nil
+	}
+
+	var files []string
+	err := filepath.WalkDir(l.config.ModelPath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".gguf") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		// 模型目录标记为完整却没有 gguf 文件，重新下载
+		return "", agenterr.Wrap(agenterr.ErrModelCorrupt, fmt.Errorf("no .gguf file found in %s", l.config.ModelPath))
+	}
+	sort.Strings(files)
+	return files[0], nil
+}
+
+func (l *LlamaCpp) Ready(ctx context.Context) bool { return httpOK(ctx, l.Endpoint()+"/health") }
+
+func (l *LlamaCpp) Endpoint() string { return localEndpoint(l.config) }
+
+func (l *LlamaCpp) InflightMetrics() []string {
+	return []string{"llamacpp:requests_processing", "llamacpp:requests_deferred"}
+}
@@ -0,0 +1,247 @@
+package runtime
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+// ============================================================================
+// vLLM 日志解析
+// ============================================================================
+//
+// vLLM 的 stdout/stderr 原样输出到容器日志之外，还逐行匹配已知的模式：
+// - CUDA OOM、引擎崩溃 → Warning Event + agent 日志
+// - 模型加载完成、服务启动 → Normal Event
+// - 吞吐量日志 → Prometheus 指标
+//
+// 这样出问题时 kubectl describe pod 就能看到原因，不用翻几万行日志。
+// ============================================================================
+
+// Kubernetes Event 类型（和 corev1.EventTypeNormal/EventTypeWarning 一致）
+const (
+	EventTypeNormal  = "Normal"
+	EventTypeWarning = "Warning"
+)
+
+// LogEvent 是从一行日志中解析出的事件
+type LogEvent struct {
+	Type    string
+	Reason  string
+	Message string
+}
+
+// EventSink 接收解析出的事件，agent 用它发 Kubernetes Event
+type EventSink interface {
+	Event(eventType, reason, message string)
+}
+
+var (
+	sinkMu    sync.RWMutex
+	eventSink EventSink
+)
+
+// SetEventSink 设置事件接收者；不设置时只打日志和记指标
+func SetEventSink(sink EventSink) {
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+	eventSink = sink
+}
+
+func emit(ev *LogEvent) {
+	sinkMu.RLock()
+	sink := eventSink
+	sinkMu.RUnlock()
+	if sink != nil {
+		sink.Event(ev.Type, ev.Reason, ev.Message)
+	}
+}
+
+// logPattern 把一个正则映射成事件
+type logPattern struct {
+	re     *regexp.Regexp
+	typ    string
+	reason string
+	// message 根据匹配结果生成事件消息，为 nil 时用整行日志
+	message func(line string, m []string) string
+}
+
+// ReasonCUDAOutOfMemory 是显存不够时的 Event Reason，也是写到终止消息里的前缀
+const ReasonCUDAOutOfMemory = "CUDAOutOfMemory"
+
+// cudaOOMPattern 匹配 PyTorch/vLLM 的显存不足错误
+var cudaOOMPattern = regexp.MustCompile(`(CUDA out of memory|torch\.OutOfMemoryError|OutOfMemoryError: CUDA)`)
+
+// cudaOOMHint 是显存不足时的处理建议，跟在 Event 消息后面
+const cudaOOMHint = "try lowering VLLM_GPU_MEMORY_UTILIZATION or VLLM_MAX_MODEL_LEN, " +
+	"using a quantized model (AWQ/GPTQ/FP8), or increasing gpuPerReplica for a larger tensor parallel size"
+
+// IsCUDAOutOfMemory 判断一段输出（日志、容器终止消息）里有没有显存不足的错误
+func IsCUDAOutOfMemory(text string) bool {
+	return cudaOOMPattern.MatchString(text)
+}
+
+var logPatterns = []logPattern{
+	{
+		re:     cudaOOMPattern,
+		typ:    EventTypeWarning,
+		reason: ReasonCUDAOutOfMemory,
+		message: func(line string, _ []string) string {
+			// 建议放前面，原始日志太长会被截断
+			return cudaOOMHint + ": " + line
+		},
+	},
+	{
+		re:     regexp.MustCompile(`(AsyncEngineDeadError|EngineDeadError|Engine core .*died|Engine loop has died)`),
+		typ:    EventTypeWarning,
+		reason: "EngineCrashed",
+	},
+	{
+		// 权重文件损坏：safetensors 头解析失败、gguf magic 不对
+		re:     regexp.MustCompile(`(SafetensorError|Error while deserializing header|gguf_init_from_file: invalid magic|failed to load model from)`),
+		typ:    EventTypeWarning,
+		reason: agenterr.ReasonModelCorrupt,
+	},
+	{
+		re:     regexp.MustCompile(`(?:Loading model weights|Model loading) took ([\d.]+) ?(?:GB|GiB)(?: and ([\d.]+) seconds)?`),
+		typ:    EventTypeNormal,
+		reason: "ModelLoaded",
+		message: func(_ string, m []string) string {
+			msg := "model weights loaded, " + m[1] + " GiB GPU memory"
+			if m[2] != "" {
+				msg += " in " + m[2] + "s"
+			}
+			return msg
+		},
+	},
+	{
+		re:     regexp.MustCompile(`Application startup complete`),
+		typ:    EventTypeNormal,
+		reason: "ServerStarted",
+		message: func(string, []string) string {
+			return "vLLM OpenAI API server is accepting requests"
+		},
+	},
+}
+
+// throughputPattern 匹配 vLLM 周期性输出的吞吐量日志：
+// Avg prompt throughput: 12.3 tokens/s, Avg generation throughput: 45.6 tokens/s, ...
+var throughputPattern = regexp.MustCompile(`Avg prompt throughput: ([\d.]+) tokens/s, Avg generation throughput: ([\d.]+) tokens/s`)
+
+// ParseLogLine 解析一行日志，没有匹配到已知模式时返回 nil
+func ParseLogLine(line string) *LogEvent {
+	for _, p := range logPatterns {
+		m := p.re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		msg := line
+		if p.message != nil {
+			msg = p.message(line, m)
+		}
+		// Event 消息太长 API server 会截断，这里主动截一下
+		if len(msg) > 512 {
+			msg = msg[:512]
+		}
+		return &LogEvent{Type: p.typ, Reason: p.reason, Message: msg}
+	}
+	return nil
+}
+
+// parseThroughput 解析吞吐量日志
+func parseThroughput(line string) (prompt, generation float64, ok bool) {
+	m := throughputPattern.FindStringSubmatch(line)
+	if m == nil {
+		return 0, 0, false
+	}
+	prompt, err1 := strconv.ParseFloat(m[1], 64)
+	generation, err2 := strconv.ParseFloat(m[2], 64)
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return prompt, generation, true
+}
+
+// logWriter 把 vLLM 的输出原样写到 out，同时按行解析
+type logWriter struct {
+	out       io.Writer
+	namespace string
+	pod       string
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func newLogWriter(out io.Writer) *logWriter {
+	return &logWriter{
+		out:       out,
+		namespace: os.Getenv("POD_NAMESPACE"),
+		pod:       os.Getenv("POD_NAME"),
+	}
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	n, err := w.out.Write(p)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.handleLine(string(bytes.TrimRight(w.buf[:i], "\r")))
+		w.buf = w.buf[i+1:]
+	}
+	// 防止没有换行的超长输出（进度条）一直占内存
+	if len(w.buf) > 64*1024 {
+		w.buf = w.buf[:0]
+	}
+	return n, err
+}
+
+func (w *logWriter) handleLine(line string) {
+	if prompt, generation, ok := parseThroughput(line); ok {
+		metrics.RecordVLLMThroughput(w.namespace, w.pod, prompt, generation)
+		return
+	}
+
+	ev := ParseLogLine(line)
+	if ev == nil {
+		return
+	}
+	if ev.Type == EventTypeWarning {
+		log.Printf("🚨 vLLM %s: %s", ev.Reason, ev.Message)
+	} else {
+		log.Printf("📣 vLLM %s: %s", ev.Reason, ev.Message)
+	}
+	metrics.RecordVLLMLogEvent(w.namespace, w.pod, ev.Reason)
+	emit(ev)
+
+	// 显存不足时 vLLM 接着就会退出，agent 所在容器之后被探针重启时，
+	// kubelet 把这个文件的内容放到 lastState.terminated.message，controller 据此设置 Condition
+	if ev.Reason == ReasonCUDAOutOfMemory {
+		writeTerminationMessage(ReasonCUDAOutOfMemory + ": " + line)
+	}
+}
+
+// terminationLogPath 是 kubelet 读取终止消息的默认路径，测试时替换
+var terminationLogPath = "/dev/termination-log"
+
+// writeTerminationMessage 覆盖终止消息（kubelet 最多保留 4096 字节）
+func writeTerminationMessage(msg string) {
+	if len(msg) > 4096 {
+		msg = msg[:4096]
+	}
+	if err := os.WriteFile(terminationLogPath, []byte(msg), 0644); err != nil {
+		log.Printf("⚠️  Failed to write termination message: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package runtime
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLogLine(t *testing.T) {
+	tests := []struct {
+		line       string
+		wantReason string
+	}{
+		{
+			line:       "torch.OutOfMemoryError: CUDA out of memory. Tried to allocate 2.00 GiB.",
+			wantReason: "CUDAOutOfMemory",
+		},
+		{
+			line:       "ERROR 01-10 12:00:00 async_llm_engine.py:61] vllm.engine.async_llm_engine.AsyncEngineDeadError: Background loop has errored already.",
+			wantReason: "EngineCrashed",
+		},
+		{
+			line:       "safetensors_rust.SafetensorError: Error while deserializing header: MetadataIncompleteBuffer",
+			wantReason: "ModelCorrupt",
+		},
+		{
+			line:       "INFO 01-10 12:00:00 model_runner.py:1008] Loading model weights took 12.5523 GB",
+			wantReason: "ModelLoaded",
+		},
+		{
+			line:       "INFO 01-10 12:00:00 gpu_model_runner.py:1451] Model loading took 0.2389 GiB and 1.234567 seconds",
+			wantReason: "ModelLoaded",
+		},
+		{
+			line:       "INFO:     Application startup complete.",
+			wantReason: "ServerStarted",
+		},
+		{
+			line: "INFO 01-10 12:00:00 metrics.py:351] Avg prompt throughput: 0.0 tokens/s",
+		},
+	}
+
+	for _, tt := range tests {
+		ev := ParseLogLine(tt.line)
+		switch {
+		case tt.wantReason == "" && ev != nil:
+			t.Errorf("ParseLogLine(%q) = %+v, want nil", tt.line, ev)
+		case tt.wantReason != "" && (ev == nil || ev.Reason != tt.wantReason):
+			t.Errorf("ParseLogLine(%q) = %+v, want reason %s", tt.line, ev, tt.wantReason)
+		}
+	}
+}
+
+func TestParseThroughput(t *testing.T) {
+	line := "INFO 01-10 12:00:00 metrics.py:351] Avg prompt throughput: 12.5 tokens/s, Avg generation throughput: 48.0 tokens/s, Running: 2 reqs"
+	prompt, generation, ok := parseThroughput(line)
+	if !ok || prompt != 12.5 || generation != 48.0 {
+		t.Errorf("parseThroughput = (%v, %v, %v), want (12.5, 48, true)", prompt, generation, ok)
+	}
+}
+
+func TestLogWriter_CUDAOutOfMemory(t *testing.T) {
+	terminationLogPath = filepath.Join(t.TempDir(), "termination-log")
+	defer func() { terminationLogPath = "/dev/termination-log" }()
+
+	w := newLogWriter(io.Discard)
+	_, _ = w.Write([]byte("INFO loading weights\ntorch.OutOfMemoryError: CUDA out of memory. Tried to allocate 2.00 GiB.\n"))
+
+	msg, err := os.ReadFile(terminationLogPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(msg), ReasonCUDAOutOfMemory+": ") || !IsCUDAOutOfMemory(string(msg)) {
+		t.Errorf("termination message = %q", msg)
+	}
+	if ev := ParseLogLine("torch.OutOfMemoryError: CUDA out of memory."); !strings.Contains(ev.Message, "quantized") {
+		t.Errorf("OOM event message = %q, want remediation hints", ev.Message)
+	}
+}
@@ -0,0 +1,117 @@
+package runtime
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/faults"
+)
+
+// process 管理一个推理服务子进程，所有后端共用
+//
+// 后端只需要提供可执行文件和参数（buildArgs），启动/停止/重启都在这里
+type process struct {
+	name      string
+	binary    string
+	buildArgs func() ([]string, error)
+
+	// mu 保护 cmd：watchdog 重启和主流程 Stop 可能同时发生
+	mu  sync.Mutex
+	cmd *exec.Cmd
+}
+
+// Binary 返回可执行文件名
+//
+// initContainer 模式下推理服务不由 agent 启动，controller 用 Binary + BuildArgs 拼出容器的 command/args
+func (p *process) Binary() string { return p.binary }
+
+// 整体逻辑，给 server 的 cmd 补全
+func (p *process) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.startLocked()
+}
+
+func (p *process) startLocked() error {
+	args, err := p.buildArgs()
+	if err != nil {
+		return fmt.Errorf("failed to build %s args: %w", p.name, err)
+	}
+	log.Printf("🚀 Starting %s: %s %s", p.name, p.binary, strings.Join(args, " "))
+
+	p.cmd = exec.Command(p.binary, args...)
+	// 输出照常写到容器日志，同时解析 OOM/崩溃/加载完成等关键日志
+	p.cmd.Stdout = newLogWriter(os.Stdout)
+	p.cmd.Stderr = newLogWriter(os.Stderr)
+
+	if err := p.cmd.Start(); err != nil {
+		return fmt.Errorf("Failed to start %s: %w", p.name, err)
+	}
+	log.Printf("✅ %s started with PID %d", p.name, p.cmd.Process.Pid)
+
+	// 故障注入：过一段时间杀掉推理服务，验证 watchdog 重启
+	if d, ok := faults.Duration(faults.KillRuntime); ok {
+		go killAfter(p.name, p.cmd.Process, d)
+	}
+	return nil
+}
+
+// killAfter 在 d 之后 SIGKILL 进程（只用于故障注入）
+func killAfter(name string, proc *os.Process, d time.Duration) {
+	time.Sleep(d)
+	log.Printf("💥 Fault injection: killing %s PID %d", name, proc.Pid)
+	_ = proc.Kill()
+}
+
+// 两个辅助函数，一个停止，一个补全
+func (p *process) Wait() error {
+	p.mu.Lock()
+	cmd := p.cmd
+	p.mu.Unlock()
+	if cmd == nil {
+		return fmt.Errorf("%s not started", p.name)
+	}
+	return cmd.Wait()
+}
+func (p *process) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cmd == nil || p.cmd.Process == nil {
+		return nil
+	}
+	return p.cmd.Process.Signal(syscall.SIGTERM)
+}
+
+// stopTimeout 是重启时等待进程退出的时间，超时就 SIGKILL
+const stopTimeout = 30 * time.Second
+
+// Restart 停掉当前进程并重新启动
+//
+// 先 SIGTERM，等 stopTimeout；卡住的进程往往不响应 SIGTERM，超时就 SIGKILL。
+// 必须等旧进程退出，否则新进程会因为端口和显存被占用而启动失败。
+func (p *process) Restart() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd != nil && p.cmd.Process != nil {
+		done := make(chan error, 1)
+		go func(cmd *exec.Cmd) { done <- cmd.Wait() }(p.cmd)
+
+		_ = p.cmd.Process.Signal(syscall.SIGTERM)
+		select {
+		case <-done:
+		case <-time.After(stopTimeout):
+			log.Printf("⚠️  %s did not exit after %v, killing PID %d", p.name, stopTimeout, p.cmd.Process.Pid)
+			_ = p.cmd.Process.Kill()
+			<-done
+		}
+	}
+
+	return p.startLocked()
+}
@@ -0,0 +1,239 @@
+// Package runtime 管理 agent 里的推理服务进程
+//
+// 不同的推理后端（vLLM、TGI、llama.cpp）启动参数不同，但 agent 需要的能力一样：
+// 启动、停止、重启、判断是否就绪、知道服务地址。
+// Runtime 接口把这些抽象出来，coordinator/follower、watchdog、排空逻辑
+// 都只依赖接口，不关心后面跑的是哪个后端。
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 支持的推理后端（和 LLMService Spec.Runtime 一致）
+const (
+	KindVLLM     = "vllm"
+	KindTGI      = "tgi"
+	KindLlamaCpp = "llamacpp"
+	// KindStub 是测试控制面用的假推理服务，见 stub.go
+	KindStub = "stub"
+)
+
+// 额外参数的环境变量
+const (
+	// ExtraArgsJSONEnv 是 controller 根据 spec.vllm.extraArgs 设置的 JSON 字符串数组
+	ExtraArgsJSONEnv = "VLLM_EXTRA_ARGS_JSON"
+	// ExtraArgsEnv 是自由格式的参数，按空格拆分，没有 controller 时（只跑 agent）也能用
+	ExtraArgsEnv = "VLLM_EXTRA_ARGS"
+)
+
+// Runtime 是一个推理服务进程
+type Runtime interface {
+	// Name 返回后端名称，用于日志
+	Name() string
+	// Binary 返回推理服务的可执行文件
+	Binary() string
+	// BuildArgs 把 Config 转成命令行参数
+	BuildArgs() []string
+	// Start 启动进程（不阻塞）
+	Start() error
+	// Stop 发送 SIGTERM
+	Stop() error
+	// Restart 停掉当前进程（必要时 SIGKILL）并重新启动
+	Restart() error
+	// Ready 检查服务的健康检查端点是否返回 200
+	Ready(ctx context.Context) bool
+	// Endpoint 返回本地访问地址，例如 http://127.0.0.1:8000
+	Endpoint() string
+	// InflightMetrics 返回 /metrics 里表示 in-flight 请求数的指标名，排空时累加
+	InflightMetrics() []string
+}
+
+// Config 是所有后端共用的配置
+//
+// 环境变量沿用 VLLM_* 前缀（历史原因），对所有后端都生效；
+// 某个后端不支持的字段会被忽略。
+type Config struct {
+	// 模型文件path
+	ModelPath string
+	// building地址 → 监听地址， 默认0.0。0.0
+	Host string
+	// 部门地址 → 监听端口
+	Port int
+	// Socket 不为空时推理服务只监听这个 unix socket，Host:Port 由 agent 转发（见 socket.go）
+	Socket string
+
+	// GPU 并行的数量 --tensor-parallel-size
+	TensorParallelSize int
+	// 显卡利用率（0-1.0） --gpu-memory-utilization
+	GPUMemoryUtilization float64
+	// 最大上下文长度， 0 就是默认； --max-model-len
+	MaxModelLen int
+	// data type，
+	Dtype string
+	// Jinja chat template 文件路径 --chat-template（只有 vLLM 支持）
+	ChatTemplate string
+	// 覆盖模型自带的 tokenizer --tokenizer（只有 vLLM 支持）
+	Tokenizer string
+	// 兜底函数，用于传递任意其他参数
+	ExtraArgs []string
+}
+
+// 初始化config， 填写default值
+func DefaultConfig(modelPath string) *Config {
+	return &Config{
+		ModelPath:            modelPath,
+		Host:                 defaultHost(),
+		Port:                 8000,
+		TensorParallelSize:   1,
+		GPUMemoryUtilization: 0.9,
+		Dtype:                "auto",
+	}
+}
+
+// 很简单就是往 config 里面填写data 的
+func LoadConfigFromEnv(modelPath string) *Config {
+	config := DefaultConfig(modelPath)
+
+	if v := os.Getenv("VLLM_HOST"); v != "" {
+		config.Host = v
+	}
+	if v := os.Getenv("VLLM_PORT"); v != "" {
+		if port, err := strconv.Atoi(v); err == nil {
+			config.Port = port
+		}
+	}
+	if v := os.Getenv("VLLM_TENSOR_PARALLEL_SIZE"); v != "" {
+		if tp, err := strconv.Atoi(v); err == nil {
+			config.TensorParallelSize = tp
+		}
+	}
+	if v := os.Getenv("VLLM_GPU_MEMORY_UTILIZATION"); v != "" {
+		if gpu, err := strconv.ParseFloat(v, 64); err == nil {
+			config.GPUMemoryUtilization = gpu
+		}
+	}
+	if v := os.Getenv("VLLM_MAX_MODEL_LEN"); v != "" {
+		if maxLen, err := strconv.Atoi(v); err == nil {
+			config.MaxModelLen = maxLen
+		}
+	}
+	if v := os.Getenv("VLLM_DTYPE"); v != "" {
+		config.Dtype = v
+	}
+	config.Socket = os.Getenv(SocketEnv)
+	config.ChatTemplate = os.Getenv("VLLM_CHAT_TEMPLATE")
+	config.Tokenizer = os.Getenv("VLLM_TOKENIZER")
+	// spec.vllm.extraArgs：controller 校验过、按参数拆好的 JSON 数组
+	if v := os.Getenv(ExtraArgsJSONEnv); v != "" {
+		var args []string
+		if err := json.Unmarshal([]byte(v), &args); err != nil {
+			log.Printf("⚠️  Ignoring invalid %s: %v", ExtraArgsJSONEnv, err)
+		} else {
+			config.ExtraArgs = args
+		}
+	}
+	// 兜底：spec.env 里手写的 VLLM_EXTRA_ARGS（按空格拆分，不做校验），追加在后面
+	if v := os.Getenv(ExtraArgsEnv); v != "" {
+		fields := strings.Fields(v)
+		for _, f := range fields {
+			if IsManagedVLLMFlag(f) {
+				log.Printf("⚠️  %s sets %s, which is generated by the agent and may conflict", ExtraArgsEnv, f)
+			}
+		}
+		config.ExtraArgs = append(config.ExtraArgs, fields...)
+	}
+
+	return config
+}
+
+// New 根据后端类型创建 Runtime，kind 为空时使用 vLLM
+//
+// 设置了 Config.Socket 并且后端支持时，推理服务只监听 unix socket，agent 通过 socket 访问它
+func New(kind string, config *Config) (Runtime, error) {
+	if config.Socket != "" && !SupportsSocket(kind) {
+		log.Printf("⚠️  %s does not support unix sockets, ignoring %s", kind, SocketEnv)
+		config.Socket = ""
+	}
+	var rt Runtime
+	switch kind {
+	case "", KindVLLM:
+		rt = NewVLLM(config)
+	case KindTGI:
+		rt = NewTGI(config)
+	case KindLlamaCpp:
+		rt = NewLlamaCpp(config)
+	case KindStub:
+		rt = NewStub(config)
+	default:
+		return nil, fmt.Errorf("unknown inference runtime %q", kind)
+	}
+	if config.Socket != "" {
+		return newSocketRuntime(rt, config), nil
+	}
+	return rt, nil
+}
+
+// LoadFromEnv 根据 INFERENCE_RUNTIME 和 VLLM_* 环境变量创建 Runtime
+func LoadFromEnv(modelPath string) (Runtime, error) {
+	return New(os.Getenv("INFERENCE_RUNTIME"), LoadConfigFromEnv(modelPath))
+}
+
+// defaultHost 是推理服务默认监听的地址
+//
+// 能用 IPv6 时监听 "::"：Linux 上默认同时接受 IPv4（双栈），IPv6-only 集群的 Pod IP 也能访问；
+// 内核关掉了 IPv6 时退回 0.0.0.0。
+// 只检测一次：controller 生成 initContainer 模式的启动参数时也会调用，结果不能每次 reconcile 都变
+var defaultHost = sync.OnceValue(func() string {
+	ln, err := net.Listen("tcp6", "[::]:0")
+	if err != nil {
+		return "0.0.0.0"
+	}
+	_ = ln.Close()
+	return "::"
+})
+
+// localEndpoint 返回本地访问地址
+//
+// 监听 0.0.0.0 或 "::"（双栈）时都用 127.0.0.1 访问；只监听某个 IPv6 地址时用 [::1]
+func localEndpoint(config *Config) string {
+	host := "127.0.0.1"
+	if ip := net.ParseIP(config.Host); ip != nil && ip.To4() == nil && !ip.IsUnspecified() {
+		host = "::1"
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(config.Port))
+}
+
+// httpOK 检查 url 是否返回 200
+func httpOK(ctx context.Context, url string) bool {
+	return httpOKWith(ctx, http.DefaultClient, url)
+}
+
+// httpOKWith 和 httpOK 一样，通过 client 发请求（unix socket）
+func httpOKWith(ctx context.Context, client *http.Client, url string) bool {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode == http.StatusOK
+}
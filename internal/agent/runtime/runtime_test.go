@@ -0,0 +1,111 @@
+package runtime
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestNewRuntime(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "model-q4_k_m.gguf"), []byte("gguf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		kind     string
+		wantName string
+		wantFlag string
+		wantErr  bool
+	}{
+		{kind: "", wantName: KindVLLM, wantFlag: "--tensor-parallel-size"},
+		{kind: KindVLLM, wantName: KindVLLM, wantFlag: "--gpu-memory-utilization"},
+		{kind: KindTGI, wantName: KindTGI, wantFlag: "--model-id"},
+		{kind: KindLlamaCpp, wantName: KindLlamaCpp, wantFlag: filepath.Join(dir, "model-q4_k_m.gguf")},
+		{kind: "triton", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		rt, err := New(tt.kind, DefaultConfig(dir))
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("New(%q) error = %v, wantErr %v", tt.kind, err, tt.wantErr)
+		}
+		if tt.wantErr {
+			continue
+		}
+		if rt.Name() != tt.wantName {
+			t.Errorf("New(%q).Name() = %s, want %s", tt.kind, rt.Name(), tt.wantName)
+		}
+		if args := rt.BuildArgs(); !slices.Contains(args, tt.wantFlag) {
+			t.Errorf("New(%q).BuildArgs() = %v, missing %s", tt.kind, args, tt.wantFlag)
+		}
+		if rt.Endpoint() != "http://127.0.0.1:8000" {
+			t.Errorf("New(%q).Endpoint() = %s", tt.kind, rt.Endpoint())
+		}
+	}
+}
+
+func TestLoadConfigFromEnv_ChatTemplate(t *testing.T) {
+	t.Setenv("VLLM_CHAT_TEMPLATE", "/etc/kubeinfer/chat-template/chat_template.jinja")
+	t.Setenv("VLLM_TOKENIZER", "Qwen/Qwen2.5-7B-Instruct")
+
+	args := NewVLLM(LoadConfigFromEnv("/models")).BuildArgs()
+	for _, want := range [][]string{
+		{"--chat-template", "/etc/kubeinfer/chat-template/chat_template.jinja"},
+		{"--tokenizer", "Qwen/Qwen2.5-7B-Instruct"},
+	} {
+		i := slices.Index(args, want[0])
+		if i < 0 || i+1 >= len(args) || args[i+1] != want[1] {
+			t.Errorf("BuildArgs() = %v, want %s %s", args, want[0], want[1])
+		}
+	}
+}
+
+func TestLoadConfigFromEnv_ExtraArgs(t *testing.T) {
+	t.Setenv(ExtraArgsJSONEnv, `["--override-generation-config","{\"temperature\": 0.6}"]`)
+	t.Setenv(ExtraArgsEnv, "--enable-prefix-caching --max-num-seqs 64")
+
+	want := []string{"--override-generation-config", `{"temperature": 0.6}`, "--enable-prefix-caching", "--max-num-seqs", "64"}
+	if got := LoadConfigFromEnv("/models").ExtraArgs; !slices.Equal(got, want) {
+		t.Errorf("ExtraArgs = %q, want %q", got, want)
+	}
+}
+
+func TestIsManagedVLLMFlag(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want bool
+	}{
+		{arg: "--port", want: true},
+		{arg: "--max_model_len", want: true},
+		{arg: "--dtype=bfloat16", want: true},
+		{arg: "--enable-prefix-caching"},
+		{arg: "--served-model-name"},
+		{arg: "bfloat16"},
+	}
+
+	for _, tt := range tests {
+		if got := IsManagedVLLMFlag(tt.arg); got != tt.want {
+			t.Errorf("IsManagedVLLMFlag(%q) = %v, want %v", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestLocalEndpoint(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{host: "0.0.0.0", want: "http://127.0.0.1:8000"},
+		{host: "::", want: "http://127.0.0.1:8000"},
+		{host: "127.0.0.1", want: "http://127.0.0.1:8000"},
+		{host: "::1", want: "http://[::1]:8000"},
+		{host: "fd00::5", want: "http://[::1]:8000"},
+	}
+	for _, tt := range tests {
+		if got := localEndpoint(&Config{Host: tt.host, Port: 8000}); got != tt.want {
+			t.Errorf("localEndpoint(%q) = %s, want %s", tt.host, got, tt.want)
+		}
+	}
+}
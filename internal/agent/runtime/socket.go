@@ -0,0 +1,169 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Unix socket：agent 和推理服务之间不走 localhost TCP（RUNTIME_SOCKET）
+// ============================================================================
+//
+// 默认推理服务自己监听 Host:Port，agent 的健康检查、排空读 /metrics、watchdog 都走 127.0.0.1:Port。
+// 用户在 extraArgs 或者 sidecar 里占了同一个端口时，agent 以为是推理服务在回应，结果是错的。
+//
+// 设置了 RUNTIME_SOCKET 之后：
+//
+//	推理服务  只监听 unix socket（vLLM --uds、llama-server --host xxx.sock、stub 直接 Listen("unix")）
+//	agent     健康检查、/metrics、watchdog 都通过 socket 访问（Client + Endpoint）
+//	Host:Port 由 agent 监听，原样转发到 socket，Service、gateway、探针看到的端口不变
+//
+// TGI 不支持 unix socket，设置了也照常走 TCP（webhook 会拒绝这种组合，只跑 agent 时打一条日志）。
+// ============================================================================
+
+const (
+	// SocketEnv 是推理服务监听的 unix socket 路径，controller 根据 spec.runtimeSocket 设置
+	SocketEnv = "RUNTIME_SOCKET"
+	// DefaultSocket 是 controller 使用的路径；llama-server 只有 host 以 .sock 结尾时才当成 socket
+	DefaultSocket = "/tmp/kubeinfer/runtime.sock"
+
+	// socketHost 是通过 socket 访问时 URL 里的主机名，只用来拼 URL，不会被解析
+	socketHost = "runtime.sock"
+)
+
+// SupportsSocket 判断后端能不能只监听 unix socket
+func SupportsSocket(kind string) bool {
+	return kind != KindTGI
+}
+
+// socketRuntime 包装一个监听 unix socket 的推理服务，agent 在 Host:Port 上转发
+type socketRuntime struct {
+	Runtime
+	config *Config
+	client *http.Client
+
+	// mu 保护 proxy：Stop 和 watchdog 重启可能同时发生
+	mu    sync.Mutex
+	proxy *http.Server
+}
+
+func newSocketRuntime(rt Runtime, config *Config) *socketRuntime {
+	return &socketRuntime{Runtime: rt, config: config, client: socketClient(config.Socket)}
+}
+
+// socketClient 返回所有请求都发到 path 的 HTTP client
+func socketClient(path string) *http.Client {
+	var d net.Dialer
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, "unix", path)
+		},
+		// 推理请求可能很慢，转发不设整体超时；空闲连接保留几个给健康检查复用
+		MaxIdleConnsPerHost: 16,
+		IdleConnTimeout:     90 * time.Second,
+	}}
+}
+
+// Start 先清掉上次留下的 socket 文件，再启动推理服务和转发
+func (s *socketRuntime) Start() error {
+	if err := prepareSocket(s.config.Socket); err != nil {
+		return err
+	}
+	if err := s.Runtime.Start(); err != nil {
+		return err
+	}
+	return s.startProxy()
+}
+
+// Restart 只重启推理服务，转发一直在：重启期间请求收到 502，和直接连一个没启动的端口一样失败
+func (s *socketRuntime) Restart() error {
+	if err := prepareSocket(s.config.Socket); err != nil {
+		return err
+	}
+	if err := s.Runtime.Restart(); err != nil {
+		return err
+	}
+	return s.startProxy()
+}
+
+func (s *socketRuntime) Stop() error {
+	s.mu.Lock()
+	if s.proxy != nil {
+		_ = s.proxy.Close()
+		s.proxy = nil
+	}
+	s.mu.Unlock()
+	return s.Runtime.Stop()
+}
+
+func (s *socketRuntime) Ready(ctx context.Context) bool {
+	return httpOKWith(ctx, s.client, s.Endpoint()+"/health")
+}
+
+// Endpoint 是通过 socket 访问的地址，要配合 Client 使用
+func (s *socketRuntime) Endpoint() string { return "http://" + socketHost }
+
+// Client 返回访问推理服务的 HTTP client，所有连接都走 socket
+func (s *socketRuntime) Client() *http.Client { return s.client }
+
+// startProxy 在 Host:Port 上把请求转发到 socket，已经在运行时什么都不做
+func (s *socketRuntime) startProxy() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.proxy != nil {
+		return nil
+	}
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s for %s: %w", addr, s.config.Socket, err)
+	}
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.Out.URL.Scheme = "http"
+			r.Out.URL.Host = socketHost
+			// 保留客户端的 Host，推理服务的日志里看到的和直接监听 TCP 时一样
+			r.Out.Host = r.In.Host
+		},
+		Transport: s.client.Transport,
+		// 流式输出（SSE）每个 token 都立即转发
+		FlushInterval: -1,
+	}
+	s.proxy = &http.Server{Handler: proxy, ReadHeaderTimeout: 10 * time.Second}
+	log.Printf("🔌 Forwarding %s to %s at %s", ln.Addr(), s.Name(), s.config.Socket)
+	go func(server *http.Server) {
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("❌ Runtime socket proxy failed: %v", err)
+		}
+	}(s.proxy)
+	return nil
+}
+
+// prepareSocket 创建 socket 所在目录，删掉旧进程留下的 socket 文件（否则 bind 会失败）
+func prepareSocket(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create runtime socket directory: %w", err)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale runtime socket: %w", err)
+	}
+	return nil
+}
+
+// httpClient 返回访问 rt 的 HTTP client：监听 unix socket 时走 socket，否则是 http.DefaultClient
+func httpClient(rt any) *http.Client {
+	if c, ok := rt.(interface{ Client() *http.Client }); ok {
+		return c.Client()
+	}
+	return http.DefaultClient
+}
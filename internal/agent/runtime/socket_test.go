@@ -0,0 +1,116 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSocketRuntime(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	cfg := DefaultConfig("/models")
+	cfg.Host, cfg.Port = "127.0.0.1", port
+	cfg.Socket = filepath.Join(t.TempDir(), "run", "runtime.sock")
+	// 上次崩溃留下的 socket 文件不影响启动
+	if err := os.MkdirAll(filepath.Dir(cfg.Socket), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cfg.Socket, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rt, err := New(KindStub, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Stop()
+
+	// agent 自己的请求走 socket
+	if !rt.Ready(context.Background()) {
+		t.Fatal("stub runtime is not ready over the socket")
+	}
+	if httpClient(rt) == http.DefaultClient {
+		t.Error("httpClient of a socket runtime is http.DefaultClient")
+	}
+	n, err := inflightRequests(context.Background(), httpClient(rt), rt.Endpoint()+"/metrics", rt.InflightMetrics())
+	if err != nil || n != 0 {
+		t.Errorf("in-flight requests over the socket = %d, %v, want 0", n, err)
+	}
+
+	// 外部请求照常发到 Host:Port，由 agent 转发
+	tcp := "http://" + net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	body := `{"model":"qwen","messages":[{"role":"user","content":"over the socket"}]}`
+	resp, err := http.Post(tcp+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Choices) != 1 || out.Choices[0].Message.Content != "over the socket" {
+		t.Errorf("proxied chat completion = %+v, want the message echoed", out)
+	}
+
+	if err := rt.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	if !rt.Ready(context.Background()) {
+		t.Error("stub runtime is not ready over the socket after restart")
+	}
+}
+
+func TestSocketBuildArgs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "model-q4_k_m.gguf"), []byte("gguf"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		kind    string
+		want    []string
+		notWant string
+	}{
+		{kind: KindVLLM, want: []string{"--uds", DefaultSocket}, notWant: "--port"},
+		{kind: KindLlamaCpp, want: []string{"--host", DefaultSocket}, notWant: "--port"},
+		// TGI 不支持 socket，照常监听 TCP
+		{kind: KindTGI, want: []string{"--port", "8000"}, notWant: DefaultSocket},
+	}
+	for _, tt := range tests {
+		cfg := DefaultConfig(dir)
+		cfg.Socket = DefaultSocket
+		rt, err := New(tt.kind, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := rt.BuildArgs()
+		if i := slices.Index(args, tt.want[0]); i < 0 || i+1 >= len(args) || args[i+1] != tt.want[1] {
+			t.Errorf("%s BuildArgs() = %v, want %s %s", tt.kind, args, tt.want[0], tt.want[1])
+		}
+		if slices.Contains(args, tt.notWant) {
+			t.Errorf("%s BuildArgs() = %v, should not contain %s", tt.kind, args, tt.notWant)
+		}
+	}
+}
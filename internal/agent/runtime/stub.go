@@ -0,0 +1,271 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============================================================================
+// Stub：测试控制面用的假推理服务（INFERENCE_RUNTIME=stub）
+// ============================================================================
+//
+// 选举、模型分发、gateway、休眠这些逻辑和真正的推理无关，但 e2e 测试以前要么等真实模型下载加载，
+// 要么自己构建一个 mock vLLM 镜像（test/testdata/vllm-mock）。stub 直接跑在 agent 进程里：
+//
+//   - coordinator 不下载模型，按 STUB_MODEL_SIZE/STUB_MODEL_FILES 生成占位文件
+//     （之后照常生成清单、校验，follower 照常从 coordinator 同步）
+//   - OpenAI 兼容接口把最后一条消息原样返回，/health、/v1/models、/metrics 和 vLLM 一样
+//
+// 只需要 agent 二进制，manager 镜像（里面带 /agent）就能跑，CI 不需要 GPU、不需要 HuggingFace。
+// 推理服务不是单独的进程，所以不支持 initContainer 模式。
+// ============================================================================
+
+// stubRunningMetric 是 stub 暴露的 in-flight 请求数，排空逻辑读它
+const stubRunningMetric = "stub:num_requests_running"
+
+// Stub 是进程内的 echo OpenAI 服务
+type Stub struct {
+	config *Config
+
+	// mu 保护 server：watchdog 重启和主流程 Stop 可能同时发生
+	mu     sync.Mutex
+	server *http.Server
+
+	running atomic.Int64
+}
+
+func NewStub(config *Config) *Stub {
+	return &Stub{config: config}
+}
+
+func (s *Stub) Name() string { return KindStub }
+
+// Binary stub 在 agent 进程里运行，没有可执行文件
+func (s *Stub) Binary() string { return "" }
+
+func (s *Stub) BuildArgs() []string { return nil }
+
+// Start 监听 Host:Port（设置了 Socket 时监听 socket），端口被占用时返回错误
+func (s *Stub) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.startLocked()
+}
+
+func (s *Stub) startLocked() error {
+	network, addr := "tcp", net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	if s.config.Socket != "" {
+		network, addr = "unix", s.config.Socket
+	}
+	ln, err := net.Listen(network, addr)
+	if err != nil {
+		return fmt.Errorf("failed to start stub runtime: %w", err)
+	}
+	s.server = &http.Server{Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+	log.Printf("🧪 Stub runtime serving %s on %s", s.modelName(), ln.Addr())
+	go func(server *http.Server) {
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("❌ Stub runtime failed: %v", err)
+		}
+	}(s.server)
+	return nil
+}
+
+// Stop 和 SIGTERM 一样不等请求结束（排空在 Stop 之前已经做完）
+func (s *Stub) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Close()
+}
+
+func (s *Stub) Restart() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.server != nil {
+		_ = s.server.Close()
+	}
+	return s.startLocked()
+}
+
+func (s *Stub) Ready(ctx context.Context) bool { return httpOK(ctx, s.Endpoint()+"/health") }
+
+func (s *Stub) Endpoint() string { return localEndpoint(s.config) }
+
+func (s *Stub) InflightMetrics() []string { return []string{stubRunningMetric} }
+
+// modelName 和 vLLM 的默认值一样是 --model 的值（模型目录）
+func (s *Stub) modelName() string { return s.config.ModelPath }
+
+func (s *Stub) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		fmt.Fprintf(w, "# TYPE %s gauge\n%s %d\n", stubRunningMetric, stubRunningMetric, s.running.Load())
+	})
+	mux.HandleFunc("GET /v1/models", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"object": "list",
+			"data": []map[string]any{{
+				"id":       s.modelName(),
+				"object":   "model",
+				"owned_by": "kubeinfer-stub",
+			}},
+		})
+	})
+	mux.HandleFunc("POST /v1/chat/completions", s.tracked(s.chatCompletions))
+	mux.HandleFunc("POST /v1/completions", s.tracked(s.completions))
+	return mux
+}
+
+// tracked 统计 in-flight 请求
+func (s *Stub) tracked(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.running.Add(1)
+		defer s.running.Add(-1)
+		h(w, r)
+	}
+}
+
+// stubRequest 是 chat/completions 和 completions 请求里 stub 用到的字段
+type stubRequest struct {
+	Model    string `json:"model"`
+	Prompt   any    `json:"prompt"`
+	Messages []struct {
+		Role    string `json:"role"`
+		Content any    `json:"content"`
+	} `json:"messages"`
+	Stream bool `json:"stream"`
+}
+
+func (s *Stub) chatCompletions(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeStubRequest(w, r)
+	if !ok {
+		return
+	}
+	// 回显最后一条消息
+	text := ""
+	if n := len(req.Messages); n > 0 {
+		text = contentText(req.Messages[n-1].Content)
+	}
+	id := fmt.Sprintf("chatcmpl-stub-%d", time.Now().UnixNano())
+	model := s.responseModel(req)
+	if req.Stream {
+		writeSSE(w, []any{
+			map[string]any{"id": id, "object": "chat.completion.chunk", "created": time.Now().Unix(), "model": model,
+				"choices": []map[string]any{{"index": 0, "delta": map[string]any{"role": "assistant", "content": text}}}},
+			map[string]any{"id": id, "object": "chat.completion.chunk", "created": time.Now().Unix(), "model": model,
+				"choices": []map[string]any{{"index": 0, "delta": map[string]any{}, "finish_reason": "stop"}}},
+		})
+		return
+	}
+	writeJSON(w, map[string]any{
+		"id":      id,
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   model,
+		"choices": []map[string]any{{
+			"index":         0,
+			"message":       map[string]any{"role": "assistant", "content": text},
+			"finish_reason": "stop",
+		}},
+		"usage": stubUsage(text),
+	})
+}
+
+func (s *Stub) completions(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeStubRequest(w, r)
+	if !ok {
+		return
+	}
+	text := contentText(req.Prompt)
+	id := fmt.Sprintf("cmpl-stub-%d", time.Now().UnixNano())
+	model := s.responseModel(req)
+	if req.Stream {
+		writeSSE(w, []any{map[string]any{"id": id, "object": "text_completion", "created": time.Now().Unix(), "model": model,
+			"choices": []map[string]any{{"index": 0, "text": text, "finish_reason": "stop"}}}})
+		return
+	}
+	writeJSON(w, map[string]any{
+		"id":      id,
+		"object":  "text_completion",
+		"created": time.Now().Unix(),
+		"model":   model,
+		"choices": []map[string]any{{"index": 0, "text": text, "finish_reason": "stop"}},
+		"usage":   stubUsage(text),
+	})
+}
+
+// responseModel 请求里写了模型名就原样返回，和 vLLM 一样
+func (s *Stub) responseModel(req *stubRequest) string {
+	if req.Model != "" {
+		return req.Model
+	}
+	return s.modelName()
+}
+
+func decodeStubRequest(w http.ResponseWriter, r *http.Request) (*stubRequest, bool) {
+	req := &stubRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, fmt.Sprintf(`{"error":{"message":%q,"type":"invalid_request_error"}}`, err.Error()), http.StatusBadRequest)
+		return nil, false
+	}
+	return req, true
+}
+
+// contentText 取出 prompt/content 里的文本：字符串、字符串数组或 [{type: text, text: ...}]
+func contentText(v any) string {
+	switch c := v.(type) {
+	case string:
+		return c
+	case []any:
+		var parts []string
+		for _, p := range c {
+			switch part := p.(type) {
+			case string:
+				parts = append(parts, part)
+			case map[string]any:
+				if t, ok := part["text"].(string); ok {
+					parts = append(parts, t)
+				}
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// stubUsage 按空格数估算 token 数，够 gateway 统计用
+func stubUsage(text string) map[string]int {
+	n := len(strings.Fields(text))
+	return map[string]int{"prompt_tokens": n, "completion_tokens": n, "total_tokens": 2 * n}
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeSSE 按 OpenAI 的流式格式输出 chunks，最后是 data: [DONE]
+func writeSSE(w http.ResponseWriter, chunks []any) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, c := range chunks {
+		data, _ := json.Marshal(c)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+}
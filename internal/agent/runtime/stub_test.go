@@ -0,0 +1,87 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStubRuntime(t *testing.T) {
+	// 找一个空闲端口
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	cfg := DefaultConfig("/models")
+	cfg.Host, cfg.Port = "127.0.0.1", port
+	rt, err := New(KindStub, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer rt.Stop()
+
+	if !rt.Ready(context.Background()) {
+		t.Fatal("stub runtime is not ready")
+	}
+
+	body := `{"model":"qwen","messages":[{"role":"system","content":"be brief"},{"role":"user","content":"hello stub"}]}`
+	resp, err := http.Post(rt.Endpoint()+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var out struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Model != "qwen" || len(out.Choices) != 1 || out.Choices[0].Message.Content != "hello stub" {
+		t.Errorf("chat completion = %+v, want the last message echoed", out)
+	}
+
+	n, err := inflightRequests(context.Background(), http.DefaultClient, rt.Endpoint()+"/metrics", rt.InflightMetrics())
+	if err != nil || n != 0 {
+		t.Errorf("in-flight requests = %d, %v, want 0", n, err)
+	}
+
+	// watchdog 重启后继续提供服务
+	if err := rt.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	if !rt.Ready(context.Background()) {
+		t.Error("stub runtime is not ready after restart")
+	}
+}
+
+func TestContentText(t *testing.T) {
+	tests := []struct {
+		content any
+		want    string
+	}{
+		{content: "hi", want: "hi"},
+		{content: []any{"a", "b"}, want: "a\nb"},
+		{content: []any{map[string]any{"type": "text", "text": "hi"}, map[string]any{"type": "image_url"}}, want: "hi"},
+		{content: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := contentText(tt.content); got != tt.want {
+			t.Errorf("contentText(%v) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// TGI 是 HuggingFace text-generation-inference（text-generation-launcher）
+//
+// TGI 2.x 提供 OpenAI 兼容的 /v1/completions 和 /v1/models，
+// 所以 gateway 和 watchdog 不需要区分后端。
+type TGI struct {
+	*process
+	config *Config
+}
+
+func NewTGI(config *Config) *TGI {
+	t := &TGI{config: config}
+	t.process = &process{
+		name:      "TGI",
+		binary:    "text-generation-launcher",
+		buildArgs: func() ([]string, error) { return t.BuildArgs(), nil },
+	}
+	return t
+}
+
+func (t *TGI) Name() string { return KindTGI }
+
+// BuildArgs 参数映射：
+// - TensorParallelSize → --num-shard
+// - GPUMemoryUtilization → --cuda-memory-fraction
+// - MaxModelLen → --max-total-tokens
+// - Dtype 为 auto 时交给 TGI 自己决定
+func (t *TGI) BuildArgs() []string {
+	args := []string{
+		"--model-id", t.config.ModelPath,
+		"--hostname", t.config.Host,
+		"--port", strconv.Itoa(t.config.Port),
+		"--num-shard", strconv.Itoa(t.config.TensorParallelSize),
+		"--cuda-memory-fraction", fmt.Sprintf("%.2f", t.config.GPUMemoryUtilization),
+	}
+
+	if t.config.Dtype != "" && t.config.Dtype != "auto" {
+		args = append(args, "--dtype", t.config.Dtype)
+	}
+	if t.config.MaxModelLen > 0 {
+		args = append(args, "--max-total-tokens", strconv.Itoa(t.config.MaxModelLen))
+	}
+	if len(t.config.ExtraArgs) > 0 {
+		args = append(args, t.config.ExtraArgs...)
+	}
+
+	return args
+}
+
+func (t *TGI) Ready(ctx context.Context) bool { return httpOK(ctx, t.Endpoint()+"/health") }
+
+func (t *TGI) Endpoint() string { return localEndpoint(t.config) }
+
+func (t *TGI) InflightMetrics() []string {
+	return []string{"tgi_batch_current_size", "tgi_queue_size"}
+}
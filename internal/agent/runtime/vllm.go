@@ -0,0 +1,94 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ManagedVLLMFlags 是 BuildArgs 自己生成的参数，额外参数里再设置一次会冲突
+// （argparse 取最后一个，和 controller 以为的配置不一致），webhook 据此拒绝
+var ManagedVLLMFlags = []string{
+	"--model",
+	"--host",
+	"--port",
+	"--uds",
+	"--tensor-parallel-size",
+	"--gpu-memory-utilization",
+	"--dtype",
+	"--max-model-len",
+	"--chat-template",
+	"--tokenizer",
+}
+
+// IsManagedVLLMFlag 判断参数是否是 BuildArgs 生成的，--flag=value 的写法也算；
+// vLLM 同时接受下划线的写法（--max_model_len）
+func IsManagedVLLMFlag(arg string) bool {
+	name, _, _ := strings.Cut(arg, "=")
+	return slices.Contains(ManagedVLLMFlags, strings.ReplaceAll(name, "_", "-"))
+}
+
+// VLLM 是默认的推理后端：python -m vllm.entrypoints.openai.api_server
+type VLLM struct {
+	*process
+	config *Config
+}
+
+// 这里用config 就可以initalized vLLM
+func NewVLLM(config *Config) *VLLM {
+	v := &VLLM{config: config}
+	v.process = &process{
+		name:      "vLLM",
+		binary:    "python",
+		buildArgs: func() ([]string, error) { return v.BuildArgs(), nil },
+	}
+	return v
+}
+
+func (v *VLLM) Name() string { return KindVLLM }
+
+// 把 config 里面的东西转化成 cmd 给vllm
+// 设置了 Socket 时用 --uds 代替 --host/--port（vLLM 只监听 socket）
+func (v *VLLM) BuildArgs() []string {
+	args := []string{
+		"-m", "vllm.entrypoints.openai.api_server",
+		"--model", v.config.ModelPath,
+	}
+	if v.config.Socket != "" {
+		args = append(args, "--uds", v.config.Socket)
+	} else {
+		args = append(args, "--host", v.config.Host, "--port", strconv.Itoa(v.config.Port))
+	}
+	args = append(args,
+		"--tensor-parallel-size", strconv.Itoa(v.config.TensorParallelSize),
+		"--gpu-memory-utilization", fmt.Sprintf("%.2f", v.config.GPUMemoryUtilization),
+		"--dtype", v.config.Dtype,
+	)
+
+	if v.config.MaxModelLen > 0 {
+		args = append(args, "--max-model-len", strconv.Itoa(v.config.MaxModelLen))
+	}
+	if v.config.ChatTemplate != "" {
+		args = append(args, "--chat-template", v.config.ChatTemplate)
+	}
+	if v.config.Tokenizer != "" {
+		args = append(args, "--tokenizer", v.config.Tokenizer)
+	}
+	if len(v.config.ExtraArgs) > 0 {
+		args = append(args, v.config.ExtraArgs...)
+	}
+
+	return args
+}
+
+func (v *VLLM) Ready(ctx context.Context) bool { return httpOK(ctx, v.Endpoint()+"/health") }
+
+func (v *VLLM) Endpoint() string { return localEndpoint(v.config) }
+
+// InflightMetrics 包括被换出到 CPU 的请求（num_requests_swapped），它们之后还会接着生成；
+// 新版 vLLM 去掉了这个指标，读不到时按 0 算
+func (v *VLLM) InflightMetrics() []string {
+	return []string{"vllm:num_requests_running", "vllm:num_requests_waiting", "vllm:num_requests_swapped"}
+}
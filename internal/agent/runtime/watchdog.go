@@ -0,0 +1,353 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+// ============================================================================
+// vLLM 卡死检测（watchdog）
+// ============================================================================
+//
+// 问题：vLLM 有时进程还活着、/health 也返回 200，但生成已经卡住了，
+// 请求一直挂着不返回。这种情况 liveness probe 发现不了。
+//
+// 解决：定期在本地发一个极小的生成请求（max_tokens=1），
+// 连续 FailureThreshold 次超时或失败 → 标记 NotReady 并重启 vLLM。
+//
+// 满载时探测请求要排队，也可能超时。所以探测失败后先看 /metrics：
+// 有请求在跑或排队、并且生成的 token 数比上次检查多，说明只是忙，不算失败。
+// ============================================================================
+
+// busyMetrics 是 vLLM 运行中 + 排队中的请求数，其他后端没有这些指标，读到 0，行为不变
+var busyMetrics = []string{"vllm:num_requests_running", "vllm:num_requests_waiting"}
+
+// generatedTokensMetric 是 vLLM 累计生成的 token 数（counter），用来判断是否还在生成
+const generatedTokensMetric = "vllm:generation_tokens_total"
+
+// WatchdogConfig 是 watchdog 的配置
+type WatchdogConfig struct {
+	// Enabled 为 false 时只跟踪 /health，不发生成请求、不重启
+	Enabled bool
+	// Interval 两次检查之间的间隔
+	Interval time.Duration
+	// Timeout 单次生成请求的最长耗时，超过算一次失败
+	Timeout time.Duration
+	// FailureThreshold 连续失败多少次触发重启
+	FailureThreshold int
+
+	// Namespace/PodName 只用于 metrics 标签
+	Namespace string
+	PodName   string
+}
+
+// LoadWatchdogConfigFromEnv 从环境变量读取 watchdog 配置
+func LoadWatchdogConfigFromEnv() WatchdogConfig {
+	config := WatchdogConfig{
+		Enabled:          true,
+		Interval:         30 * time.Second,
+		Timeout:          30 * time.Second,
+		FailureThreshold: 3,
+		Namespace:        os.Getenv("POD_NAMESPACE"),
+		PodName:          os.Getenv("POD_NAME"),
+	}
+
+	if v := os.Getenv("VLLM_WATCHDOG_ENABLED"); v != "" {
+		if enabled, err := strconv.ParseBool(v); err == nil {
+			config.Enabled = enabled
+		}
+	}
+	if v := os.Getenv("VLLM_WATCHDOG_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			config.Interval = d
+		}
+	}
+	if v := os.Getenv("VLLM_WATCHDOG_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			config.Timeout = d
+		}
+	}
+	if v := os.Getenv("VLLM_WATCHDOG_FAILURE_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			config.FailureThreshold = n
+		}
+	}
+
+	return config
+}
+
+// HealthState 是整个 agent 进程共享的 vLLM 就绪状态
+// watchdog 写入，agent 的 /readyz 读取
+type HealthState struct {
+	ready    atomic.Bool
+	draining atomic.Bool
+}
+
+// Health 是 agent 进程里唯一的 HealthState
+var Health = &HealthState{}
+
+// Ready 在 vLLM 可用且没有在排空连接时返回 true
+func (h *HealthState) Ready() bool     { return h.ready.Load() && !h.draining.Load() }
+func (h *HealthState) SetReady(r bool) { h.ready.Store(r) }
+
+// Draining 返回是否正在排空（preStop 或者 SIGTERM 之后）
+func (h *HealthState) Draining() bool { return h.draining.Load() }
+
+// SetDraining 标记正在排空：之后 Ready 一直返回 false，Service 不再转发新请求
+func (h *HealthState) SetDraining() { h.draining.Store(true) }
+
+// supervised 是 watchdog 需要的 Runtime 能力，测试时可以替换
+type supervised interface {
+	Restart() error
+	Ready(ctx context.Context) bool
+	Endpoint() string
+}
+
+// Watchdog 定期检查推理服务是否还能生成
+type Watchdog struct {
+	server  supervised
+	config  WatchdogConfig
+	baseURL string
+	client  *http.Client
+
+	// loaded 表示已经加载完模型（健康检查返回过 200）
+	// 加载模型可能要十几分钟，这期间不做生成检查
+	loaded   bool
+	model    string
+	failures int
+
+	// generated 是上一次检查时读到的 generatedTokensMetric，sampled=false 表示还没有读到过
+	generated int
+	sampled   bool
+}
+
+// NewWatchdog 创建 watchdog，检查本地推理服务（Runtime.Endpoint）
+// 所有后端都提供 OpenAI 兼容的 /v1/models 和 /v1/completions
+func NewWatchdog(server Runtime, config WatchdogConfig) *Watchdog {
+	return newWatchdog(server, config)
+}
+
+func newWatchdog(server supervised, config WatchdogConfig) *Watchdog {
+	return &Watchdog{
+		server:  server,
+		config:  config,
+		baseURL: server.Endpoint(),
+		client:  watchdogClient(server),
+	}
+}
+
+// watchdogClient 返回访问 server 的 client：监听 unix socket 时走 socket，
+// 否则用单独的 client（生成检查有自己的超时，不和 http.DefaultClient 共享连接）
+func watchdogClient(server supervised) *http.Client {
+	if c := httpClient(server); c != http.DefaultClient {
+		return c
+	}
+	return &http.Client{}
+}
+
+// Run 循环检查，直到 ctx 被取消
+func (w *Watchdog) Run(ctx context.Context) {
+	log.Printf("🐶 Inference watchdog started (enabled: %v, interval: %v, timeout: %v, threshold: %d)",
+		w.config.Enabled, w.config.Interval, w.config.Timeout, w.config.FailureThreshold)
+	defer Health.SetReady(false)
+
+	ticker := time.NewTicker(w.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.check(ctx)
+		}
+	}
+}
+
+// check 执行一次检查
+func (w *Watchdog) check(ctx context.Context) {
+	// 阶段 1：等待模型加载完成
+	if !w.loaded {
+		if w.healthy(ctx) {
+			log.Println("✅ Inference server is healthy, marking Ready")
+			w.loaded = true
+			Health.SetReady(true)
+		}
+		return
+	}
+
+	if !w.config.Enabled {
+		Health.SetReady(w.healthy(ctx))
+		return
+	}
+
+	// 阶段 2：生成检查
+	start := time.Now()
+	err := w.probe(ctx)
+	metrics.RecordWatchdogProbe(w.config.Namespace, w.config.PodName, err == nil, time.Since(start).Seconds())
+	// 每次都读，这样"比上次多"总是和上一次检查比
+	queued, progressing := w.progressing(ctx)
+
+	if err == nil {
+		if w.failures > 0 {
+			log.Printf("✅ Generation recovered after %d failed probes", w.failures)
+		}
+		w.failures = 0
+		Health.SetReady(true)
+		return
+	}
+
+	if queued > 0 && progressing {
+		log.Printf("⏳ Generation probe failed but server is busy (%d requests) and still generating: %v", queued, err)
+		w.failures = 0
+		return
+	}
+
+	w.failures++
+	log.Printf("⚠️  Generation probe failed (%d/%d): %v", w.failures, w.config.FailureThreshold, err)
+	if w.failures < w.config.FailureThreshold {
+		return
+	}
+
+	// 阶段 3：连续失败，重启 vLLM
+	log.Printf("🚨 Generation stalled, restarting inference server...")
+	Health.SetReady(false)
+	metrics.RecordWatchdogTrip(w.config.Namespace, w.config.PodName)
+
+	if err := w.server.Restart(); err != nil {
+		log.Printf("❌ Failed to restart inference server: %v", err)
+	}
+
+	// 重启后重新等模型加载
+	w.failures = 0
+	w.loaded = false
+	w.model = ""
+	w.sampled = false
+}
+
+// progressing 读 /metrics，返回运行中 + 排队中的请求数，以及生成的 token 数是否比上次检查多
+//
+// 读不到指标时返回 0, false，探测失败照常计数
+func (w *Watchdog) progressing(ctx context.Context) (int, bool) {
+	reqCtx, cancel := context.WithTimeout(ctx, w.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, w.baseURL+"/metrics", nil)
+	if err != nil {
+		return 0, false
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		w.sampled = false
+		return 0, false
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		w.sampled = false
+		return 0, false
+	}
+
+	queued, err := parseInflight(bytes.NewReader(body), busyMetrics)
+	if err != nil {
+		return 0, false
+	}
+	generated, err := parseInflight(bytes.NewReader(body), []string{generatedTokensMetric})
+	if err != nil {
+		return 0, false
+	}
+
+	progressing := w.sampled && generated > w.generated
+	w.generated, w.sampled = generated, true
+	return queued, progressing
+}
+
+// healthy 检查推理服务的健康检查端点
+func (w *Watchdog) healthy(ctx context.Context) bool {
+	reqCtx, cancel := context.WithTimeout(ctx, w.config.Timeout)
+	defer cancel()
+	return w.server.Ready(reqCtx)
+}
+
+// probe 发一个 max_tokens=1 的生成请求，超时或非 200 都算失败
+func (w *Watchdog) probe(ctx context.Context) error {
+	reqCtx, cancel := context.WithTimeout(ctx, w.config.Timeout)
+	defer cancel()
+
+	// 模型名可能被 --served-model-name 改过（各后端也不一样），从 /v1/models 读
+	if w.model == "" {
+		model, err := w.servedModel(reqCtx)
+		if err != nil {
+			return err
+		}
+		w.model = model
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"model":      w.model,
+		"prompt":     "ping",
+		"max_tokens": 1,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, w.baseURL+"/v1/completions", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("completion returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// servedModel 返回推理服务提供的第一个模型名
+func (w *Watchdog) servedModel(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.baseURL+"/v1/models", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("list models returned status %d", resp.StatusCode)
+	}
+
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return "", fmt.Errorf("failed to decode model list: %w", err)
+	}
+	if len(list.Data) == 0 {
+		return "", fmt.Errorf("inference server reports no models")
+	}
+	return list.Data[0].ID, nil
+}
@@ -0,0 +1,153 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeServer struct {
+	endpoint string
+	restarts int
+}
+
+func (f *fakeServer) Restart() error {
+	f.restarts++
+	return nil
+}
+
+func (f *fakeServer) Ready(ctx context.Context) bool { return httpOK(ctx, f.endpoint+"/health") }
+
+func (f *fakeServer) Endpoint() string { return f.endpoint }
+
+func TestWatchdogRestartsAfterConsecutiveStalls(t *testing.T) {
+	var stalled atomic.Bool
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/v1/models", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":"test-model"}]}`)
+	})
+	mux.HandleFunc("/v1/completions", func(w http.ResponseWriter, r *http.Request) {
+		if stalled.Load() {
+			// 模拟生成卡住：远超 watchdog 的超时时间
+			select {
+			case <-r.Context().Done():
+			case <-time.After(500 * time.Millisecond):
+			}
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"text":"pong"}]}`)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	server := &fakeServer{endpoint: ts.URL}
+	w := newWatchdog(server, WatchdogConfig{
+		Enabled:          true,
+		Interval:         time.Second,
+		Timeout:          50 * time.Millisecond,
+		FailureThreshold: 2,
+	})
+	ctx := context.Background()
+
+	// 第一次检查：模型加载完成 → Ready
+	w.check(ctx)
+	if !Health.Ready() {
+		t.Fatalf("expected Ready after /health returns 200")
+	}
+
+	// 正常生成
+	w.check(ctx)
+	if server.restarts != 0 || !Health.Ready() {
+		t.Fatalf("healthy probe should not restart (restarts=%d)", server.restarts)
+	}
+
+	// 卡住一次：还没到阈值
+	stalled.Store(true)
+	w.check(ctx)
+	if server.restarts != 0 {
+		t.Fatalf("restarted before reaching threshold")
+	}
+
+	// 卡住第二次：触发重启并标记 NotReady
+	w.check(ctx)
+	if server.restarts != 1 {
+		t.Fatalf("restarts = %d, want 1", server.restarts)
+	}
+	if Health.Ready() {
+		t.Fatalf("expected NotReady after watchdog trip")
+	}
+
+	// 重启后重新等 /health
+	stalled.Store(false)
+	w.check(ctx)
+	if !Health.Ready() {
+		t.Fatalf("expected Ready again after restart")
+	}
+}
+
+func TestWatchdogBusyButProgressing(t *testing.T) {
+	var generated, frozen atomic.Int64
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/v1/models", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"data":[{"id":"test-model"}]}`)
+	})
+	mux.HandleFunc("/v1/completions", func(_ http.ResponseWriter, r *http.Request) {
+		// 满载：探测请求一直在排队，超过 watchdog 的超时时间
+		select {
+		case <-r.Context().Done():
+		case <-time.After(500 * time.Millisecond):
+		}
+	})
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		if frozen.Load() == 0 {
+			generated.Add(100)
+		}
+		fmt.Fprintf(w, "vllm:num_requests_running{model_name=\"test-model\"} 8.0\n")
+		fmt.Fprintf(w, "vllm:num_requests_waiting{model_name=\"test-model\"} 20.0\n")
+		fmt.Fprintf(w, "vllm:generation_tokens_total{model_name=\"test-model\"} %d.0\n", generated.Load())
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	server := &fakeServer{endpoint: ts.URL}
+	w := newWatchdog(server, WatchdogConfig{
+		Enabled:          true,
+		Interval:         time.Second,
+		Timeout:          50 * time.Millisecond,
+		FailureThreshold: 2,
+	})
+	ctx := context.Background()
+
+	w.check(ctx)
+	if !Health.Ready() {
+		t.Fatalf("expected Ready after /health returns 200")
+	}
+
+	// 第一次探测失败时还没有基准值，算一次失败；之后 token 数一直在涨，不算失败
+	for i := 0; i < 4; i++ {
+		w.check(ctx)
+	}
+	if server.restarts != 0 {
+		t.Fatalf("busy but progressing server was restarted (restarts=%d)", server.restarts)
+	}
+
+	// token 数不再增长：真的卡住了，连续两次失败后重启
+	frozen.Store(1)
+	w.check(ctx)
+	w.check(ctx)
+	if server.restarts != 1 {
+		t.Fatalf("restarts = %d, want 1", server.restarts)
+	}
+}
@@ -0,0 +1,297 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// S3 兼容对象存储的最小客户端（AWS Signature Version 4）
+// ============================================================================
+//
+// 只用到 PUT/GET/HEAD 对象和分段上传，不值得为此引入 AWS SDK。
+// GCS 通过 XML API 的 S3 兼容模式访问（HMAC key），MinIO、Ceph RGW 直接兼容。
+//
+//   - 没有设置 endpoint（AWS）：virtual-hosted 风格 https://<bucket>.s3.<region>.amazonaws.com/<key>
+//   - 设置了 endpoint（GCS、MinIO）：path 风格 <endpoint>/<bucket>/<key>
+// ============================================================================
+
+// unsignedPayload 表示请求体不参与签名：模型文件几十 GB，不能为了签名先把整个文件读一遍
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// emptyPayloadHash 是空请求体的 sha256
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// client 是 S3 兼容对象存储的客户端
+type client struct {
+	endpoint string // 为空时用 AWS 的 virtual-hosted 地址
+	region   string
+	bucket   string
+
+	accessKey    string
+	secretKey    string
+	sessionToken string
+
+	http *http.Client
+	// now 方便测试时固定签名时间
+	now func() time.Time
+}
+
+// objectURL 返回对象的地址，query 是子资源参数（uploads、partNumber 等）
+func (c *client) objectURL(key string, query url.Values) *url.URL {
+	u := &url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", c.bucket, c.region)}
+	path := "/" + key
+	if c.endpoint != "" {
+		base, _ := url.Parse(c.endpoint) // FromEnv 已经校验过
+		u.Scheme, u.Host = base.Scheme, base.Host
+		path = strings.TrimSuffix(base.Path, "/") + "/" + c.bucket + "/" + key
+	}
+	u.Path = path
+	u.RawPath = uriEncode(path, false)
+	u.RawQuery = canonicalQuery(query)
+	return u
+}
+
+// do 签名并发送请求，返回 2xx 以外的状态码时把响应体放进错误
+func (c *client) do(ctx context.Context, method, key string, query url.Values, body io.Reader, size int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.objectURL(key, query).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		payloadHash = unsignedPayload
+	}
+	c.sign(req, payloadHash)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &statusError{code: resp.StatusCode, msg: strings.TrimSpace(string(msg))}
+	}
+	return resp, nil
+}
+
+// statusError 是对象存储返回的非 2xx 响应
+type statusError struct {
+	code int
+	msg  string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("object storage returned %d: %s", e.code, e.msg)
+}
+
+// isNotFound 判断错误是不是对象不存在
+func isNotFound(err error) bool {
+	se, ok := err.(*statusError)
+	return ok && se.code == http.StatusNotFound
+}
+
+// sign 按 Signature Version 4 给请求签名
+//
+// 签名的 header 是 host 加上请求里已经设置的所有 header（签名前设置的 Range、x-amz-* 等）
+func (c *client) sign(req *http.Request, payloadHash string) {
+	now := c.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	req.Header.Set("x-amz-date", amzDate)
+	if c.sessionToken != "" {
+		req.Header.Set("x-amz-security-token", c.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + c.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.secretKey), date)
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// uriEncode 按 SigV4 的规则编码：只保留 A-Z a-z 0-9 - _ . ~，encodeSlash 为 false 时保留 /
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case 'A' <= ch && ch <= 'Z', 'a' <= ch && ch <= 'z', '0' <= ch && ch <= '9',
+			ch == '-', ch == '_', ch == '.', ch == '~':
+			b.WriteByte(ch)
+		case ch == '/' && !encodeSlash:
+			b.WriteByte(ch)
+		default:
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+// canonicalQuery 按 key 排序并编码 query（SigV4 要求，也直接用作请求的 RawQuery）
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// putObject 上传一个对象
+func (c *client) putObject(ctx context.Context, key string, body io.Reader, size int64) error {
+	resp, err := c.do(ctx, http.MethodPut, key, nil, body, size)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// getObject 下载一个对象，调用方负责关闭 Body
+func (c *client) getObject(ctx context.Context, key string) (*http.Response, error) {
+	return c.do(ctx, http.MethodGet, key, nil, nil, 0)
+}
+
+// headObject 判断对象是否存在
+func (c *client) headObject(ctx context.Context, key string) (bool, error) {
+	resp, err := c.do(ctx, http.MethodHead, key, nil, nil, 0)
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, resp.Body.Close()
+}
+
+// 分段上传：单次 PUT 最大 5GB，大于 multipartThreshold 的文件按 partSize 分段
+const (
+	multipartThreshold = 1 << 30
+	partSize           = 512 << 20
+)
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+// putLargeObject 分段上传，失败时放弃这次上传（否则未完成的分段一直占着存储）
+func (c *client) putLargeObject(ctx context.Context, key string, body io.ReaderAt, size int64) error {
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, 0)
+	if err != nil {
+		return fmt.Errorf("failed to start multipart upload: %w", err)
+	}
+	var initiated initiateMultipartUploadResult
+	err = xml.NewDecoder(resp.Body).Decode(&initiated)
+	resp.Body.Close()
+	if err != nil {
+		return fmt.Errorf("invalid multipart upload response: %w", err)
+	}
+	uploadID := initiated.UploadID
+
+	complete := completeMultipartUpload{}
+	for offset, number := int64(0), 1; offset < size; offset, number = offset+partSize, number+1 {
+		n := min(partSize, size-offset)
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+		resp, err := c.do(ctx, http.MethodPut, key, query, io.NewSectionReader(body, offset, n), n)
+		if err != nil {
+			c.abortUpload(key, uploadID)
+			return fmt.Errorf("failed to upload part %d: %w", number, err)
+		}
+		resp.Body.Close()
+		complete.Parts = append(complete.Parts, completedPart{PartNumber: number, ETag: resp.Header.Get("ETag")})
+	}
+
+	data, err := xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+	resp, err = c.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		c.abortUpload(key, uploadID)
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	return resp.Body.Close()
+}
+
+// abortUpload 放弃分段上传，ctx 可能已经取消，用单独的超时
+func (c *client) abortUpload(key, uploadID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if resp, err := c.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, 0); err == nil {
+		resp.Body.Close()
+	}
+}
@@ -0,0 +1,284 @@
+// Package snapshot 把模型目录备份到对象存储（S3/GCS），冷启动时优先从备份恢复
+//
+// HuggingFace 上的仓库可能被作者删除、改成 gated，很多 LLMService 一起冷启动时还会被限流。
+// coordinator 从上游下载一个 revision 并校验通过后，把模型文件上传到 spec.distribution.snapshot.url 下：
+//
+//	<prefix>/<repo>/<revision>/config.json
+//	<prefix>/<repo>/<revision>/model-00001-of-00002.safetensors
+//	<prefix>/<repo>/<revision>/kubeinfer-manifest.json   校验清单（distribution.ManifestFile）
+//	<prefix>/<repo>/<revision>/kubeinfer-snapshot.json   最后上传，有它才算一份完整的快照
+//	<prefix>/<repo>/kubeinfer-latest.json                最近上传的 revision
+//
+// 之后的冷启动先找上游当前 revision 的 kubeinfer-snapshot.json，有的话从对象存储恢复，再按 verifier 校验；
+// 上游有了新 revision 就没有对应的快照，照常从上游下载，再上传一份新的。
+// 上游不可用（被限流、仓库被删）时不知道当前 revision，恢复 kubeinfer-latest.json 指向的那一份。
+// 清单和文件在同一份快照里，校验只能发现传输损坏，分辨不出旧版本，所以 revision 一定要对上才恢复。
+//
+// 环境变量由 controller 根据 spec.distribution.snapshot 设置，凭证用 S3 的 HMAC key
+// （AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY，GCS 在 Interoperability 里创建）。
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+	"github.com/Moore-Z/kubeinfer/internal/agent/distribution"
+)
+
+// 环境变量，controller 根据 spec.distribution.snapshot 设置
+const (
+	URLEnv      = "MODEL_SNAPSHOT_URL"
+	EndpointEnv = "MODEL_SNAPSHOT_ENDPOINT"
+	RegionEnv   = "MODEL_SNAPSHOT_REGION"
+	// ReadOnlyEnv 为 "true" 时只恢复不上传
+	ReadOnlyEnv = "MODEL_SNAPSHOT_READ_ONLY"
+
+	AccessKeyEnv    = "AWS_ACCESS_KEY_ID"
+	SecretKeyEnv    = "AWS_SECRET_ACCESS_KEY"
+	SessionTokenEnv = "AWS_SESSION_TOKEN"
+)
+
+// IndexFile 是快照的索引，所有文件上传完之后才上传它
+const IndexFile = "kubeinfer-snapshot.json"
+
+// LatestFile 在 repo 目录下，记录最近上传的快照（内容和它的 IndexFile 一样）
+const LatestFile = "kubeinfer-latest.json"
+
+// gcsEndpoint 是 gs:// 地址默认的 S3 兼容 endpoint
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// ErrNotFound 表示对象存储里还没有这个模型的快照
+var ErrNotFound = errors.New("snapshot not found")
+
+// Index 是快照里的文件列表
+type Index struct {
+	Repo string `json:"repo"`
+	// Revision 是快照对应的上游 revision，没有它的索引（早期版本上传的）不会被恢复
+	Revision  string                   `json:"revision"`
+	CreatedAt time.Time                `json:"createdAt"`
+	Files     []distribution.FileEntry `json:"files"`
+}
+
+// Store 是模型快照所在的对象存储位置
+type Store struct {
+	client *client
+	prefix string
+
+	// ReadOnly 为 true 时不上传快照
+	ReadOnly bool
+}
+
+// FromEnv 按 MODEL_SNAPSHOT_* 创建 Store，没有设置 MODEL_SNAPSHOT_URL 时返回 nil
+func FromEnv() (*Store, error) {
+	raw := os.Getenv(URLEnv)
+	if raw == "" {
+		return nil, nil
+	}
+	store, err := New(raw, os.Getenv(EndpointEnv), os.Getenv(RegionEnv))
+	if err != nil {
+		return nil, err
+	}
+	store.client.accessKey = os.Getenv(AccessKeyEnv)
+	store.client.secretKey = os.Getenv(SecretKeyEnv)
+	store.client.sessionToken = os.Getenv(SessionTokenEnv)
+	if store.client.accessKey == "" || store.client.secretKey == "" {
+		return nil, fmt.Errorf("%s and %s are required for %s", AccessKeyEnv, SecretKeyEnv, URLEnv)
+	}
+	store.ReadOnly = os.Getenv(ReadOnlyEnv) == "true"
+	return store, nil
+}
+
+// New 解析 s3://bucket/prefix 或 gs://bucket/prefix
+//
+// gs:// 没有指定 endpoint 时用 GCS 的 S3 兼容 endpoint；region 为空时是 us-east-1（GCS 不检查 region）
+func New(rawURL, endpoint, region string) (*Store, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot url %q: %w", rawURL, err)
+	}
+	if u.Host == "" || (u.Scheme != "s3" && u.Scheme != "gs") {
+		return nil, fmt.Errorf("invalid snapshot url %q: must be s3://<bucket>/<prefix> or gs://<bucket>/<prefix>", rawURL)
+	}
+	if endpoint == "" && u.Scheme == "gs" {
+		endpoint = gcsEndpoint
+	}
+	if endpoint != "" {
+		if e, err := url.Parse(endpoint); err != nil || (e.Scheme != "http" && e.Scheme != "https") || e.Host == "" {
+			return nil, fmt.Errorf("invalid snapshot endpoint %q", endpoint)
+		}
+	}
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &Store{
+		client: &client{
+			endpoint: strings.TrimSuffix(endpoint, "/"),
+			region:   region,
+			bucket:   u.Host,
+			http:     http.DefaultClient,
+			now:      time.Now,
+		},
+		prefix: strings.Trim(u.Path, "/"),
+	}, nil
+}
+
+// key 返回 repo 下 name 的对象 key
+func (s *Store) key(repo, name string) string {
+	if s.prefix == "" {
+		return repo + "/" + name
+	}
+	return s.prefix + "/" + repo + "/" + name
+}
+
+// revisionKey 返回 repo 的 revision 快照里 name 的对象 key
+func (s *Store) revisionKey(repo, revision, name string) string {
+	return s.key(repo, revision+"/"+name)
+}
+
+// Exists 判断 repo 的 revision 是否已经有完整的快照
+func (s *Store) Exists(ctx context.Context, repo, revision string) (bool, error) {
+	return s.client.headObject(ctx, s.revisionKey(repo, revision, IndexFile))
+}
+
+// Latest 返回 repo 最近上传的快照的 revision，没有快照时返回 ErrNotFound
+func (s *Store) Latest(ctx context.Context, repo string) (string, error) {
+	index, err := s.readIndex(ctx, s.key(repo, LatestFile))
+	if err != nil {
+		return "", err
+	}
+	if index.Revision == "" {
+		return "", ErrNotFound
+	}
+	return index.Revision, nil
+}
+
+// Upload 把 modelPath 下的模型文件上传成 repo 的 revision 的快照
+//
+// 只在本地模型校验通过之后调用。索引最后上传，中途失败留下的对象不会被当成快照；
+// 之后更新 LatestFile，它失败时快照本身仍然可用
+func (s *Store) Upload(ctx context.Context, modelPath, repo, revision string) error {
+	if revision == "" {
+		return errors.New("snapshot revision is required")
+	}
+	entries, err := os.ReadDir(modelPath)
+	if err != nil {
+		return err
+	}
+	index := Index{Repo: repo, Revision: revision, CreatedAt: time.Now().UTC()}
+	for _, e := range entries {
+		// 和 model server 分发的文件一致：顶层普通文件，去掉隐藏文件和下载中的文件
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".partial") {
+			continue
+		}
+		size, err := s.uploadFile(ctx, filepath.Join(modelPath, name), s.revisionKey(repo, revision, name))
+		if err != nil {
+			return fmt.Errorf("failed to upload %s: %w", name, err)
+		}
+		index.Files = append(index.Files, distribution.FileEntry{Name: name, Size: size})
+	}
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := s.client.putObject(ctx, s.revisionKey(repo, revision, IndexFile), strings.NewReader(string(data)), int64(len(data))); err != nil {
+		return err
+	}
+	return s.client.putObject(ctx, s.key(repo, LatestFile), strings.NewReader(string(data)), int64(len(data)))
+}
+
+func (s *Store) uploadFile(ctx context.Context, path, key string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if info.Size() > multipartThreshold {
+		return info.Size(), s.client.putLargeObject(ctx, key, f, info.Size())
+	}
+	return info.Size(), s.client.putObject(ctx, key, f, info.Size())
+}
+
+// Restore 把 repo 的 revision 的快照下载到 modelPath，没有这个 revision 的快照时返回 ErrNotFound
+//
+// 大小和本地一致的文件跳过（上一次恢复到一半）；完整性由调用方用 verifier 校验
+func (s *Store) Restore(ctx context.Context, modelPath, repo, revision string) error {
+	index, err := s.readIndex(ctx, s.revisionKey(repo, revision, IndexFile))
+	if err != nil {
+		return err
+	}
+	if index.Revision != revision {
+		return ErrNotFound
+	}
+
+	log.Printf("🪣 Restoring %d files of revision %s from the snapshot taken at %s", len(index.Files), revision, index.CreatedAt.Format(time.RFC3339))
+	for _, f := range index.Files {
+		path := filepath.Join(modelPath, f.Name)
+		if info, err := os.Stat(path); err == nil && info.Size() == f.Size {
+			continue
+		}
+		if err := s.downloadFile(ctx, s.revisionKey(repo, revision, f.Name), path, f.Size); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// readIndex 读取 key 上的快照索引，不存在时返回 ErrNotFound
+func (s *Store) readIndex(ctx context.Context, key string) (*Index, error) {
+	resp, err := s.client.getObject(ctx, key)
+	if isNotFound(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	index := &Index{}
+	if err := json.NewDecoder(resp.Body).Decode(index); err != nil {
+		return nil, fmt.Errorf("invalid snapshot index: %w", err)
+	}
+	return index, nil
+}
+
+// downloadFile 先写 .partial 再 rename，和上游下载一样，follower 不会拿到写了一半的文件
+func (s *Store) downloadFile(ctx context.Context, key, path string, size int64) error {
+	resp, err := s.client.getObject(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	tmp := path + ".partial"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return agenterr.Classify(err)
+	}
+	written, err := io.Copy(out, resp.Body)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return agenterr.Classify(err)
+	}
+	if written != size {
+		return fmt.Errorf("got %d bytes, want %d", written, size)
+	}
+	return os.Rename(tmp, path)
+}
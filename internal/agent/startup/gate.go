@@ -0,0 +1,167 @@
+// Package startup 是 agent 的启动闸门（initContainer wait-coordinator）
+//
+// replicas>1 时所有 Pod 同时启动，follower 立刻去连还没选出来/还没启动 model server 的 coordinator，
+// 连不上就报错、退避重试，日志和 Event 全是噪音，还白白浪费重启次数。
+//
+// controller 给每个 Pod 加一个 initContainer，运行 `agent wait-coordinator`：
+//
+//  1. 先尝试抢 coordinator lease：抢到了说明自己就是 coordinator，立刻放行
+//     （主容器的 LeaseManager 用同一个身份，会接着续约）
+//  2. 没抢到 → 等 lease 持有者的 model server /health 返回 200
+//  3. coordinator 就绪后再按 Pod 名称错开一段时间（0 ~ StaggerWindow），
+//     避免所有 follower 同一秒去拉文件
+//
+// 闸门只是优化：超过 Timeout 仍然放行，follower 自己的重试逻辑兜底，Pod 不会卡在 Init 状态。
+package startup
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// WaitCommand 是 initContainer 传给 agent 的参数（kubeinfer elect 的别名）
+const WaitCommand = "wait-coordinator"
+
+// 环境变量
+const (
+	// TimeoutEnv 是最长等待时间，超过后放行
+	TimeoutEnv = "STARTUP_GATE_TIMEOUT"
+	// StaggerWindowEnv 是 follower 错开启动的窗口，0 表示不错开
+	StaggerWindowEnv = "STARTUP_STAGGER_WINDOW"
+)
+
+const (
+	// DefaultTimeout 默认最多等 10 分钟
+	DefaultTimeout = 10 * time.Minute
+	// DefaultStaggerWindow 默认在 20 秒内错开
+	DefaultStaggerWindow = 20 * time.Second
+	// DefaultPollInterval 是重新检查 coordinator 的间隔
+	DefaultPollInterval = 2 * time.Second
+)
+
+// Gate 等待 coordinator 就绪
+type Gate struct {
+	// Identity 是当前 Pod 名称，用来计算错开的时间
+	Identity string
+	// TryAcquire 尝试抢 coordinator lease（LeaseManager.TryAcquireOrRenew）
+	TryAcquire func(ctx context.Context) (bool, error)
+	// CoordinatorURL 返回 lease 持有者的 model server 地址，例如 "http://10.0.0.5:8080"
+	CoordinatorURL func(ctx context.Context) (string, error)
+
+	HTTPClient    *http.Client
+	PollInterval  time.Duration
+	Timeout       time.Duration
+	StaggerWindow time.Duration
+}
+
+// LoadFromEnv 按环境变量设置 Timeout 和 StaggerWindow，无效的值用默认值
+func (g *Gate) LoadFromEnv() {
+	g.Timeout = durationEnv(TimeoutEnv, DefaultTimeout)
+	g.StaggerWindow = durationEnv(StaggerWindowEnv, DefaultStaggerWindow)
+}
+
+func durationEnv(name string, def time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		log.Printf("⚠️  Ignoring invalid %s=%q", name, v)
+		return def
+	}
+	return d
+}
+
+// Wait 阻塞到当前 Pod 可以启动
+//
+// 返回 true 表示自己抢到了 coordinator lease；超时也返回 nil（放行），只有 ctx 被取消时返回错误
+func (g *Gate) Wait(ctx context.Context) (elected bool, err error) {
+	poll := g.PollInterval
+	if poll <= 0 {
+		poll = DefaultPollInterval
+	}
+	client := g.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	var deadline <-chan time.Time
+	if g.Timeout > 0 {
+		timer := time.NewTimer(g.Timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	for {
+		acquired, err := g.TryAcquire(ctx)
+		if err != nil {
+			log.Printf("⚠️  Startup gate: lease check failed: %v", err)
+		}
+		if acquired {
+			log.Println("👑 Startup gate: acquired the coordinator lease, starting first")
+			return true, nil
+		}
+
+		url, err := g.CoordinatorURL(ctx)
+		if err == nil {
+			err = checkHealth(ctx, client, url)
+		}
+		if err == nil {
+			break
+		}
+		log.Printf("⏳ Startup gate: waiting for coordinator: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-deadline:
+			log.Printf("⚠️  Startup gate: coordinator not ready after %s, starting anyway", g.Timeout)
+			return false, nil
+		case <-time.After(poll):
+		}
+	}
+
+	delay := StaggerDelay(g.Identity, g.StaggerWindow)
+	log.Printf("✅ Startup gate: coordinator is ready, starting in %s", delay.Round(time.Millisecond))
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case <-time.After(delay):
+	}
+	return false, nil
+}
+
+// checkHealth 请求 model server 的 /health
+func checkHealth(ctx context.Context, client *http.Client, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/health", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("coordinator /health returned %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// StaggerDelay 按 identity 的哈希在 [0, window) 里取一个固定的延迟
+//
+// 为什么用哈希而不是随机数？同一个 Pod 重启后延迟不变，日志里好对照；
+// Deployment 的 Pod 名称后缀是随机的，哈希之后分布足够均匀
+func StaggerDelay(identity string, window time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(identity))
+	return time.Duration(h.Sum64() % uint64(window))
+}
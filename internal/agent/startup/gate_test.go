@@ -0,0 +1,102 @@
+package startup
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitElected(t *testing.T) {
+	g := &Gate{
+		Identity:   "pod-a",
+		TryAcquire: func(context.Context) (bool, error) { return true, nil },
+		CoordinatorURL: func(context.Context) (string, error) {
+			t.Fatal("elected pod must not look for a coordinator")
+			return "", nil
+		},
+		StaggerWindow: time.Hour,
+	}
+	elected, err := g.Wait(context.Background())
+	if err != nil || !elected {
+		t.Fatalf("Wait() = %v, %v, want elected", elected, err)
+	}
+}
+
+func TestWaitForCoordinator(t *testing.T) {
+	var ready atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" || !ready.Load() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var polls atomic.Int32
+	g := &Gate{
+		Identity:   "pod-b",
+		TryAcquire: func(context.Context) (bool, error) { return false, nil },
+		CoordinatorURL: func(context.Context) (string, error) {
+			// 前两次 lease 还没有持有者，第三次 model server 起来了但还没就绪，之后就绪
+			switch polls.Add(1) {
+			case 1, 2:
+				return "", errors.New("lease has no holder")
+			case 3:
+			default:
+				ready.Store(true)
+			}
+			return server.URL, nil
+		},
+		PollInterval: time.Millisecond,
+	}
+	elected, err := g.Wait(context.Background())
+	if err != nil || elected {
+		t.Fatalf("Wait() = %v, %v, want follower released", elected, err)
+	}
+	if n := polls.Load(); n != 4 {
+		t.Errorf("polled %d times, want 4", n)
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	g := &Gate{
+		Identity:       "pod-c",
+		TryAcquire:     func(context.Context) (bool, error) { return false, errors.New("apiserver down") },
+		CoordinatorURL: func(context.Context) (string, error) { return "", errors.New("no holder") },
+		PollInterval:   time.Millisecond,
+		Timeout:        20 * time.Millisecond,
+	}
+	// 超时放行，不返回错误
+	if _, err := g.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() after timeout = %v, want nil", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	g.Timeout = 0
+	if _, err := g.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() with canceled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestStaggerDelay(t *testing.T) {
+	if d := StaggerDelay("pod-a", 0); d != 0 {
+		t.Errorf("window 0 must not delay, got %v", d)
+	}
+	window := 20 * time.Second
+	a := StaggerDelay("llm-deployment-7d4b9-abcde", window)
+	if a < 0 || a >= window {
+		t.Errorf("delay %v outside [0, %v)", a, window)
+	}
+	if b := StaggerDelay("llm-deployment-7d4b9-abcde", window); b != a {
+		t.Errorf("delay must be stable for the same pod, got %v and %v", a, b)
+	}
+	if c := StaggerDelay("llm-deployment-7d4b9-fghij", window); c == a {
+		t.Errorf("different pods should get different delays, both got %v", a)
+	}
+}
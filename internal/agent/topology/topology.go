@@ -0,0 +1,52 @@
+// Package topology 负责 zone 感知的模型分发
+//
+// 多 zone 集群里，跨 zone 流量要收费（AWS/GCP 的 inter-AZ egress）。
+// zone 拓扑下：
+//
+//	zone-a: coordinator ──▶ follower, follower          （同 zone 直接拿）
+//	zone-b: seeder(从 coordinator 跨 zone 拿一次) ──▶ follower, follower
+//	zone-c: seeder(从 coordinator 跨 zone 拿一次) ──▶ follower, follower
+//
+// 每个 zone 的 seeder 通过一个独立的 Lease 选出来。
+package topology
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ZoneLabel 是 Kubernetes 标准的 zone 节点 label
+const ZoneLabel = "topology.kubernetes.io/zone"
+
+// NodeZone 读取节点的 zone label，节点没有 label 时返回空字符串
+func NodeZone(ctx context.Context, clientset kubernetes.Interface, nodeName string) (string, error) {
+	if nodeName == "" {
+		return "", fmt.Errorf("node name is empty")
+	}
+	node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	return node.Labels[ZoneLabel], nil
+}
+
+// PodZone 读取 Pod 所在节点的 zone
+func PodZone(ctx context.Context, clientset kubernetes.Interface, namespace, podName string) (string, error) {
+	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get pod %s: %w", podName, err)
+	}
+	return NodeZone(ctx, clientset, pod.Spec.NodeName)
+}
+
+// ZoneLeaseName 是某个 zone 的 seeder 选举使用的 Lease 名称
+//
+// 例如：leaseName = "my-llm-cache-lease", zone = "us-east-1a"
+// → "my-llm-cache-lease-us-east-1a"
+func ZoneLeaseName(leaseName, zone string) string {
+	return leaseName + "-" + strings.ToLower(zone)
+}
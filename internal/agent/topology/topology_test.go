@@ -0,0 +1,73 @@
+package topology
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNodeZone(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-a", Labels: map[string]string{ZoneLabel: "us-east-1a"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-bare"}},
+	)
+	tests := []struct {
+		name    string
+		node    string
+		want    string
+		wantErr bool
+	}{
+		{name: "labeled", node: "gpu-a", want: "us-east-1a"},
+		{name: "no zone label", node: "gpu-bare"},
+		{name: "not scheduled yet", node: "", wantErr: true},
+		{name: "missing node", node: "gpu-gone", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NodeZone(context.Background(), client, tt.node)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: NodeZone(%q) = %q, %v, want %q (error: %v)", tt.name, tt.node, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPodZone(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-b", Labels: map[string]string{ZoneLabel: "us-east-1b"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "qwen-a", Namespace: "default"}, Spec: corev1.PodSpec{NodeName: "gpu-b"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "qwen-pending", Namespace: "default"}},
+	)
+	tests := []struct {
+		name    string
+		pod     string
+		want    string
+		wantErr bool
+	}{
+		{name: "scheduled", pod: "qwen-a", want: "us-east-1b"},
+		{name: "pending pod has no node", pod: "qwen-pending", wantErr: true},
+		{name: "missing pod", pod: "qwen-gone", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := PodZone(context.Background(), client, "default", tt.pod)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: PodZone(%q) = %q, %v, want %q (error: %v)", tt.name, tt.pod, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestZoneLeaseName(t *testing.T) {
+	tests := []struct {
+		lease, zone, want string
+	}{
+		{"qwen-cache-lease", "us-east-1a", "qwen-cache-lease-us-east-1a"},
+		// Lease 名称必须是小写的 DNS 子域名
+		{"qwen-cache-lease", "EU-West-1B", "qwen-cache-lease-eu-west-1b"},
+	}
+	for _, tt := range tests {
+		if got := ZoneLeaseName(tt.lease, tt.zone); got != tt.want {
+			t.Errorf("ZoneLeaseName(%q, %q) = %q, want %q", tt.lease, tt.zone, got, tt.want)
+		}
+	}
+}
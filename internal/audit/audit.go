@@ -0,0 +1,236 @@
+// Package audit 记录 controller 对 LLMService 做的重要操作：谁（或什么）触发了什么变化
+//
+// 受监管的环境（金融、医疗）要求能回答"这个模型什么时候换的、谁改的、为什么扩容"。
+// Event 一小时就过期，controller 日志混在一起也不好查，所以单独记一条审计流：
+//
+//  1. 每条记录都以结构化日志输出（logger 名 audit，带 audit=true），日志平台按这个字段过滤
+//  2. operator 配置里 audit.sink 可以再选一个落到集群里的地方：
+//     - configMap: 每个 LLMService 一个 <name>-audit ConfigMap，保留最近 maxEntries 条（JSON Lines）
+//     - event: 在 LLMService 上发 Normal Event（reason 是 Audit<Action>），交给集群的事件采集
+//
+// 记录失败只打日志，不影响 reconcile。
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Action 是被审计的操作类型
+type Action string
+
+const (
+	// ActionSpecApplied 是 spec 变化导致 Pod 模板滚动更新
+	ActionSpecApplied Action = "SpecApplied"
+	// ActionScaled 是副本数变化（spec、SLO 扩容或休眠/唤醒）
+	ActionScaled Action = "Scaled"
+	// ActionFailover 是 controller 强制让出 coordinator Lease
+	ActionFailover Action = "CoordinatorFailover"
+	// ActionModelSwitched 是 spec.model 换成了另一个模型
+	ActionModelSwitched Action = "ModelSwitched"
+	// ActionCoordinatorPinned 是 coordinator lease 上的人工固定（spec.coordination.pinnedPod）生效或者解除
+	ActionCoordinatorPinned Action = "CoordinatorPinned"
+)
+
+// 审计 sink，operator 配置的 audit.sink
+const (
+	SinkNone      = ""
+	SinkConfigMap = "configMap"
+	SinkEvent     = "event"
+)
+
+// DefaultMaxEntries 是 ConfigMap sink 默认保留的条数
+const DefaultMaxEntries = 100
+
+// ConfigMapSuffix 是审计 ConfigMap 名称的后缀：<llmservice>-audit
+const ConfigMapSuffix = "-audit"
+
+// entriesKey 是审计 ConfigMap 里保存记录的 key
+const entriesKey = "audit.jsonl"
+
+// Entry 是一条审计记录
+type Entry struct {
+	Time      time.Time `json:"time"`
+	Action    Action    `json:"action"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	// Generation 是操作发生时 LLMService 的 metadata.generation
+	Generation int64 `json:"generation,omitempty"`
+
+	// Actor 是谁：spec 的修改者（managedFields 里的 field manager，例如 kubectl-edit、argocd-controller），
+	// controller 自己做的决定是 kubeinfer-controller
+	Actor string `json:"actor"`
+	// Trigger 是为什么，例如 spec、slo、hibernation、node-not-ready
+	Trigger string `json:"trigger"`
+	// Message 是人能看懂的描述，例如 "scaled from 2 to 4 replicas"
+	Message string `json:"message"`
+}
+
+// Sink 把审计记录写到日志以外的地方
+type Sink interface {
+	Write(ctx context.Context, obj client.Object, e Entry) error
+}
+
+// Trail 记录审计日志，sink 为 nil 时只输出日志
+type Trail struct {
+	Sink Sink
+
+	// now 方便测试时注入时间
+	now func() time.Time
+}
+
+// New 创建 Trail
+func New(sink Sink) *Trail {
+	return &Trail{Sink: sink, now: time.Now}
+}
+
+// Record 记录一条审计记录，obj 是被操作的 LLMService
+//
+// Time/Namespace/Name/Generation 为空时从 obj 和当前时间填上
+func (t *Trail) Record(ctx context.Context, obj client.Object, e Entry) {
+	if e.Time.IsZero() {
+		e.Time = t.now().UTC()
+	}
+	if e.Namespace == "" {
+		e.Namespace, e.Name = obj.GetNamespace(), obj.GetName()
+	}
+	if e.Generation == 0 {
+		e.Generation = obj.GetGeneration()
+	}
+	if e.Actor == "" {
+		e.Actor = "unknown"
+	}
+
+	l := log.FromContext(ctx).WithName("audit")
+	l.Info(e.Message, "audit", true, "action", e.Action, "llmservice", e.Namespace+"/"+e.Name,
+		"generation", e.Generation, "actor", e.Actor, "trigger", e.Trigger, "time", e.Time.Format(time.RFC3339))
+
+	if t.Sink == nil {
+		return
+	}
+	if err := t.Sink.Write(ctx, obj, e); err != nil {
+		l.Error(err, "Failed to write audit entry", "action", e.Action)
+	}
+}
+
+// SpecActor 返回最近一次修改 spec 的 field manager，找不到时返回空
+//
+// managedFields 按 manager 记录每个字段是谁设置的，Time 是这个 manager 最近一次修改的时间。
+// status 子资源的写入不算（那是 controller 自己）。
+func SpecActor(obj client.Object) string {
+	return FieldActor(obj, "spec")
+}
+
+// FieldActor 返回最近一次修改 field 的 field manager，field 是 managedFields 里的名字（例如 spec、注解的 key）
+func FieldActor(obj client.Object, field string) string {
+	var actor string
+	var latest time.Time
+	for _, mf := range obj.GetManagedFields() {
+		if mf.Subresource != "" || mf.FieldsV1 == nil || !strings.Contains(string(mf.FieldsV1.Raw), `"f:`+field+`"`) {
+			continue
+		}
+		var at time.Time
+		if mf.Time != nil {
+			at = mf.Time.Time
+		}
+		if actor == "" || at.After(latest) {
+			actor, latest = mf.Manager, at
+		}
+	}
+	return actor
+}
+
+// ConfigMapName 返回 LLMService 的审计 ConfigMap 名称
+func ConfigMapName(llmService string) string {
+	return llmService + ConfigMapSuffix
+}
+
+// ConfigMapSink 把审计记录追加到每个 LLMService 的 <name>-audit ConfigMap
+//
+// ConfigMap 的 owner 是 LLMService，删除时一起回收；只保留最近 MaxEntries 条，
+// 长期保存交给日志平台（ConfigMap 最大 1MiB）
+type ConfigMapSink struct {
+	Client     client.Client
+	MaxEntries int
+}
+
+// Write 实现 Sink
+func (s *ConfigMapSink) Write(ctx context.Context, obj client.Object, e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	maxEntries := s.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+
+	key := client.ObjectKey{Namespace: obj.GetNamespace(), Name: ConfigMapName(obj.GetName())}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm := &corev1.ConfigMap{}
+		err := s.Client.Get(ctx, key, cm)
+		if errors.IsNotFound(err) {
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+				Data:       map[string]string{entriesKey: string(line) + "\n"},
+			}
+			if err := controllerutil.SetOwnerReference(obj, cm, s.Client.Scheme()); err != nil {
+				return err
+			}
+			err = s.Client.Create(ctx, cm)
+			if errors.IsAlreadyExists(err) {
+				// 并发创建了，按冲突重试一次追加
+				return errors.NewConflict(corev1.Resource("configmaps"), key.Name, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if cm.Data == nil {
+			cm.Data = map[string]string{}
+		}
+		cm.Data[entriesKey] = appendEntry(cm.Data[entriesKey], string(line), maxEntries)
+		return s.Client.Update(ctx, cm)
+	})
+}
+
+// appendEntry 在 JSON Lines 后面追加一行，只保留最后 maxEntries 行
+func appendEntry(existing, line string, maxEntries int) string {
+	lines := strings.Split(strings.TrimRight(existing, "\n"), "\n")
+	if lines[0] == "" {
+		lines = nil
+	}
+	lines = append(lines, line)
+	if len(lines) > maxEntries {
+		lines = lines[len(lines)-maxEntries:]
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// EventSink 在 LLMService 上发 Normal Event，reason 是 Audit<Action>
+type EventSink struct {
+	Recorder record.EventRecorder
+}
+
+// Write 实现 Sink
+func (s *EventSink) Write(_ context.Context, obj client.Object, e Entry) error {
+	if s.Recorder == nil {
+		return fmt.Errorf("no event recorder")
+	}
+	s.Recorder.Eventf(obj, corev1.EventTypeNormal, "Audit"+string(e.Action),
+		"%s (actor: %s, trigger: %s, generation: %d)", e.Message, e.Actor, e.Trigger, e.Generation)
+	return nil
+}
@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestSpecActor(t *testing.T) {
+	at := func(min int) *metav1.Time {
+		tm := metav1.NewTime(time.Date(2026, 5, 1, 10, min, 0, 0, time.UTC))
+		return &tm
+	}
+	fields := func(raw string) *metav1.FieldsV1 { return &metav1.FieldsV1{Raw: []byte(raw)} }
+
+	llm := &aiv1.LLMService{ObjectMeta: metav1.ObjectMeta{ManagedFields: []metav1.ManagedFieldsEntry{
+		{Manager: "kubectl-create", Time: at(0), FieldsV1: fields(`{"f:spec":{"f:model":{}}}`)},
+		{Manager: "argocd-controller", Time: at(5), FieldsV1: fields(`{"f:spec":{"f:replicas":{}}}`)},
+		// 只改了 metadata，不算 spec 的修改者
+		{Manager: "kubectl-label", Time: at(9), FieldsV1: fields(`{"f:metadata":{"f:labels":{}}}`)},
+		// status 子资源是 controller 写的
+		{Manager: "kubeinfer-controller", Time: at(10), Subresource: "status", FieldsV1: fields(`{"f:status":{}}`)},
+	}}}
+	if got := SpecActor(llm); got != "argocd-controller" {
+		t.Errorf("SpecActor = %q, want argocd-controller", got)
+	}
+	if got := SpecActor(&aiv1.LLMService{}); got != "" {
+		t.Errorf("SpecActor without managed fields = %q, want empty", got)
+	}
+}
+
+func TestAppendEntry(t *testing.T) {
+	got := appendEntry("", "a", 3)
+	for _, line := range []string{"b", "c", "d"} {
+		got = appendEntry(got, line, 3)
+	}
+	if got != "b\nc\nd\n" {
+		t.Errorf("appendEntry = %q, want the last 3 lines", got)
+	}
+}
+
+// TestConfigMapSink 测试第一次写入创建 ConfigMap，之后追加
+func TestConfigMapSink(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = aiv1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	llm := &aiv1.LLMService{ObjectMeta: metav1.ObjectMeta{Name: "qwen", Namespace: "default", UID: "uid-1", Generation: 3}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(llm).Build()
+
+	trail := New(&ConfigMapSink{Client: c, MaxEntries: 2})
+	trail.now = func() time.Time { return time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+	for _, action := range []Action{ActionScaled, ActionFailover, ActionModelSwitched} {
+		trail.Record(ctx, llm, Entry{Action: action, Actor: "kubeinfer-controller", Trigger: "test", Message: string(action)})
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "qwen-audit"}, cm); err != nil {
+		t.Fatal(err)
+	}
+	if len(cm.OwnerReferences) != 1 || cm.OwnerReferences[0].UID != llm.UID {
+		t.Errorf("owner references = %v", cm.OwnerReferences)
+	}
+	lines := strings.Split(strings.TrimSpace(cm.Data[entriesKey]), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("entries = %q, want the last 2", cm.Data[entriesKey])
+	}
+	var e Entry
+	if err := json.Unmarshal([]byte(lines[1]), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Action != ActionModelSwitched || e.Namespace != "default" || e.Name != "qwen" || e.Generation != 3 || e.Time.IsZero() {
+		t.Errorf("last entry = %+v", e)
+	}
+}
@@ -0,0 +1,247 @@
+// Package benchmark 是 BenchmarkRun 的负载生成器
+//
+// controller 为每个 BenchmarkRun 创建一个 Job，运行 manager 镜像里的 /benchmark：
+//
+//  1. 从 /v1/models 读出模型名（和 watchdog 一样，模型名可能被 --served-model-name 改过）
+//  2. Concurrency 个 worker 循环发 /v1/completions，直到 Duration 用完
+//  3. 统计吞吐和延迟分位数，JSON 写到 /dev/termination-log，controller 读出来放进 status
+//
+// 所有后端（vLLM、TGI、llama.cpp）都提供 OpenAI 兼容接口，所以不区分 runtime。
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// 环境变量，由 controller 在 Job 里设置
+const (
+	TargetEnv       = "BENCHMARK_TARGET"
+	ConcurrencyEnv  = "BENCHMARK_CONCURRENCY"
+	PromptTokensEnv = "BENCHMARK_PROMPT_TOKENS"
+	MaxTokensEnv    = "BENCHMARK_MAX_TOKENS"
+	DurationEnv     = "BENCHMARK_DURATION"
+)
+
+// Config 是一次压测的负载
+type Config struct {
+	// Target 是推理服务（或 gateway）的地址，例如 http://qwen-gateway.default.svc:8000
+	Target       string
+	Concurrency  int
+	PromptTokens int
+	MaxTokens    int
+	Duration     time.Duration
+
+	// HTTPClient 为 nil 时使用不带超时的默认 client（生成请求可能很慢，由 ctx 控制）
+	HTTPClient *http.Client
+}
+
+// ConfigFromEnv 从环境变量读取 Config
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Target: strings.TrimRight(os.Getenv(TargetEnv), "/")}
+	if cfg.Target == "" {
+		return cfg, fmt.Errorf("%s is required", TargetEnv)
+	}
+	var err error
+	for _, f := range []struct {
+		name string
+		dst  *int
+		def  int
+	}{
+		{ConcurrencyEnv, &cfg.Concurrency, 8},
+		{PromptTokensEnv, &cfg.PromptTokens, 512},
+		{MaxTokensEnv, &cfg.MaxTokens, 128},
+	} {
+		if *f.dst, err = intEnv(f.name, f.def); err != nil {
+			return cfg, err
+		}
+	}
+	cfg.Duration = time.Minute
+	if v := os.Getenv(DurationEnv); v != "" {
+		if cfg.Duration, err = time.ParseDuration(v); err != nil || cfg.Duration <= 0 {
+			return cfg, fmt.Errorf("invalid %s=%q", DurationEnv, v)
+		}
+	}
+	return cfg, nil
+}
+
+func intEnv(name string, def int) (int, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s=%q", name, v)
+	}
+	return n, nil
+}
+
+// sample 是一个请求的结果
+type sample struct {
+	latency      time.Duration
+	outputTokens int
+	err          error
+}
+
+// Run 按 cfg 压测，返回统计结果
+//
+// 找不到模型（服务没起来）直接返回错误；单个请求失败只计入 Errors
+func Run(ctx context.Context, cfg Config) (aiv1.BenchmarkResults, error) {
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
+	model, err := ServedModel(ctx, client, cfg.Target)
+	if err != nil {
+		return aiv1.BenchmarkResults{}, err
+	}
+	body, err := json.Marshal(map[string]any{
+		"model":      model,
+		"prompt":     prompt(cfg.PromptTokens),
+		"max_tokens": cfg.MaxTokens,
+	})
+	if err != nil {
+		return aiv1.BenchmarkResults{}, err
+	}
+
+	start := time.Now()
+	deadline := start.Add(cfg.Duration)
+	samples := make(chan sample, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for range max(cfg.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 到时间后不再发新请求，在途请求跑完（吞吐按实际耗时计算）
+			for time.Now().Before(deadline) && ctx.Err() == nil {
+				samples <- complete(ctx, client, cfg.Target, body)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(samples)
+	}()
+
+	var all []sample
+	for s := range samples {
+		all = append(all, s)
+	}
+	if err := ctx.Err(); err != nil {
+		return aiv1.BenchmarkResults{}, err
+	}
+	return summarize(all, time.Since(start)), nil
+}
+
+// complete 发一个生成请求，记录延迟和生成的 token 数
+func complete(ctx context.Context, client *http.Client, target string, body []byte) sample {
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target+"/v1/completions", bytes.NewReader(body))
+	if err != nil {
+		return sample{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return sample{err: err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return sample{err: fmt.Errorf("completion returned status %d", resp.StatusCode)}
+	}
+	var out struct {
+		Usage struct {
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return sample{err: fmt.Errorf("failed to decode completion: %w", err)}
+	}
+	return sample{latency: time.Since(start), outputTokens: out.Usage.CompletionTokens}
+}
+
+// summarize 计算吞吐和延迟分位数，失败的请求不参与延迟统计
+func summarize(samples []sample, elapsed time.Duration) aiv1.BenchmarkResults {
+	res := aiv1.BenchmarkResults{Requests: int64(len(samples))}
+	var latencies []time.Duration
+	tokens := 0
+	for _, s := range samples {
+		if s.err != nil {
+			res.Errors++
+			continue
+		}
+		latencies = append(latencies, s.latency)
+		tokens += s.outputTokens
+	}
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		seconds = 1
+	}
+	res.RequestsPerSecond = strconv.FormatFloat(float64(len(latencies))/seconds, 'f', 2, 64)
+	res.OutputTokensPerSecond = strconv.FormatFloat(float64(tokens)/seconds, 'f', 2, 64)
+
+	slices.Sort(latencies)
+	res.LatencyP50Ms = percentile(latencies, 0.50).Milliseconds()
+	res.LatencyP90Ms = percentile(latencies, 0.90).Milliseconds()
+	res.LatencyP99Ms = percentile(latencies, 0.99).Milliseconds()
+	return res
+}
+
+// percentile 用最近秩法取分位数，sorted 必须已排序
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[max(idx, 0)]
+}
+
+// prompt 生成大约 n 个 token 的 prompt
+//
+// " hello" 在常见的 BPE tokenizer 里都是一个 token，长度不需要很精确
+func prompt(n int) string {
+	return strings.TrimSpace(strings.Repeat("hello ", n))
+}
+
+// ServedModel 返回推理服务提供的第一个模型名（EvalRun 的评测程序也用）
+func ServedModel(ctx context.Context, client *http.Client, target string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target+"/v1/models", nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("list models returned status %d", resp.StatusCode)
+	}
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return "", fmt.Errorf("failed to decode model list: %w", err)
+	}
+	if len(list.Data) == 0 {
+		return "", fmt.Errorf("inference server reports no models")
+	}
+	return list.Data[0].ID, nil
+}
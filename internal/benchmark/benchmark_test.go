@@ -0,0 +1,93 @@
+package benchmark
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			_, _ = w.Write([]byte(`{"data":[{"id":"qwen"}]}`))
+		case "/v1/completions":
+			var req struct {
+				Model     string `json:"model"`
+				MaxTokens int    `json:"max_tokens"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "qwen" || req.MaxTokens != 16 {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			// 每 5 个请求失败一个
+			if calls.Add(1)%5 == 0 {
+				http.Error(w, "overloaded", http.StatusServiceUnavailable)
+				return
+			}
+			time.Sleep(time.Millisecond)
+			_, _ = w.Write([]byte(`{"usage":{"completion_tokens":16}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	res, err := Run(context.Background(), Config{
+		Target:       server.URL,
+		Concurrency:  4,
+		PromptTokens: 8,
+		MaxTokens:    16,
+		Duration:     100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Requests == 0 || res.Requests != calls.Load() {
+		t.Errorf("requests = %d, server saw %d", res.Requests, calls.Load())
+	}
+	if want := res.Requests / 5; res.Errors != want {
+		t.Errorf("errors = %d, want %d", res.Errors, want)
+	}
+	if res.RequestsPerSecond == "0.00" || res.OutputTokensPerSecond == "0.00" {
+		t.Errorf("throughput = %s rps, %s tok/s", res.RequestsPerSecond, res.OutputTokensPerSecond)
+	}
+	if res.LatencyP50Ms > res.LatencyP90Ms || res.LatencyP90Ms > res.LatencyP99Ms {
+		t.Errorf("percentiles not ordered: %d %d %d", res.LatencyP50Ms, res.LatencyP90Ms, res.LatencyP99Ms)
+	}
+}
+
+func TestRunNoModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "loading", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if _, err := Run(context.Background(), Config{Target: server.URL, Concurrency: 1, Duration: time.Second}); err == nil {
+		t.Fatal("Run must fail when the model list is unavailable")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	var samples []sample
+	for i := 1; i <= 100; i++ {
+		samples = append(samples, sample{latency: time.Duration(i) * time.Millisecond, outputTokens: 10})
+	}
+	samples = append(samples, sample{err: context.DeadlineExceeded})
+
+	res := summarize(samples, 10*time.Second)
+	if res.Requests != 101 || res.Errors != 1 {
+		t.Errorf("requests/errors = %d/%d, want 101/1", res.Requests, res.Errors)
+	}
+	if res.RequestsPerSecond != "10.00" || res.OutputTokensPerSecond != "100.00" {
+		t.Errorf("throughput = %s rps, %s tok/s", res.RequestsPerSecond, res.OutputTokensPerSecond)
+	}
+	if res.LatencyP50Ms != 50 || res.LatencyP90Ms != 90 || res.LatencyP99Ms != 99 {
+		t.Errorf("percentiles = %d/%d/%d, want 50/90/99", res.LatencyP50Ms, res.LatencyP90Ms, res.LatencyP99Ms)
+	}
+}
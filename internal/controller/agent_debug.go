@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+// ============================================================================
+// agent 的 admin API（/debug/，见 internal/agent/admin）
+// ============================================================================
+//
+// agent 在健康检查端口上提供只读的排障接口：角色、lease、下载进度、清单、推理服务状态、最近的失败。
+// 请求要带 <name>-agent-debug Secret 里的 token，controller 为每个 LLMService 生成一个，
+// 通过 AGENT_DEBUG_TOKEN 注入。kubectl kubeinfer status 读这个 Secret，经 Pod proxy 访问 /debug/status，
+// 所以能不能调试由"能不能读这个 Secret、能不能用 pods/proxy"的 RBAC 决定。
+// ============================================================================
+
+// agentDebugSecretName 是 admin API token 的 Secret 名称
+func agentDebugSecretName(llm *aiv1.LLMService) string {
+	return cacheinfo.AgentDebugSecretName(llm.Name)
+}
+
+// ensureAgentDebugToken 创建 admin API 的 token Secret，要在 Deployment 之前创建
+func (r *LLMServiceReconciler) ensureAgentDebugToken(ctx context.Context, llm *aiv1.LLMService) error {
+	return r.ensureTokenSecret(ctx, llm, agentDebugSecretName(llm))
+}
+
+// agentDebugEnv 把 admin API 的 token 传给 agent
+func agentDebugEnv(llm *aiv1.LLMService) []corev1.EnvVar {
+	return []corev1.EnvVar{{
+		Name: "AGENT_DEBUG_TOKEN",
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: agentDebugSecretName(llm)},
+				Key:                  cacheinfo.AgentDebugTokenKey,
+			},
+		},
+	}}
+}
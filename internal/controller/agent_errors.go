@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+	"github.com/Moore-Z/kubeinfer/internal/notify"
+)
+
+// agentContainerName 是 Deployment 里 agent 容器的名字
+const agentContainerName = "agent"
+
+// agentFailure 从 Pod 列表里找出 agent 因为不可重试的错误退出的 Pod
+//
+// agent 退出前把 "<Reason>: <错误>" 写到 /dev/termination-log（见 agenterr.TerminationMessage），
+// kubelet 放到 containerStatuses[].lastState.terminated.message。
+// 容器已经重新 Ready 的 Pod 不算（lastState 会一直保留到下次重启）。
+// initContainer 模式下拉取模型的是 model-fetch，它的状态在 initContainerStatuses 里。
+func agentFailure(pods []corev1.Pod) (reason, message string, found bool) {
+	for i := range pods {
+		statuses := append(slices.Clone(pods[i].Status.InitContainerStatuses), pods[i].Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			if (cs.Name != agentContainerName && cs.Name != modelFetchContainerName) || cs.Ready {
+				continue
+			}
+			term := cs.State.Terminated
+			if term == nil {
+				term = cs.LastTerminationState.Terminated
+			}
+			if term == nil {
+				continue
+			}
+			if reason, detail, ok := agenterr.ParseTerminationMessage(term.Message); ok {
+				return reason, fmt.Sprintf("pod %s: %s", pods[i].Name, detail), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// checkAgentErrors 把 agent 的终止原因同步到 AgentError Condition，推理服务显存不足同步到 CUDAOutOfMemory
+//
+// 没出过错的 LLMService 不加这个 Condition；出过错、现在恢复了设为 False
+func (r *LLMServiceReconciler) checkAgentErrors(ctx context.Context, llm *aiv1.LLMService) error {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(llm.Namespace), client.MatchingLabels(podLabels(llm))); err != nil {
+		return err
+	}
+	r.checkCUDAOOM(llm, pods.Items)
+	r.notifyCrashLoop(ctx, llm, pods.Items)
+
+	reason, message, found := agentFailure(pods.Items)
+	if found && !isConditionTrue(llm, agentFailureCondition(reason)) {
+		r.sendNotification(ctx, llm, notify.EventDownloadFailed, reason+": "+message)
+	}
+	syncAgentFailure(llm, reason, message, found)
+	return nil
+}
+
+// agentFailureCondition 返回 agent 终止原因对应的 Condition
+//
+// gated 模型的许可协议没接受单独用 LicenseNotAccepted：要做的是去 HF 页面同意协议、
+// 设置 spec.modelSource.acceptLicense，和排查下载失败是两回事
+func agentFailureCondition(reason string) string {
+	if reason == agenterr.ReasonLicenseNotAccepted {
+		return aiv1.ConditionLicenseNotAccepted
+	}
+	return aiv1.ConditionAgentError
+}
+
+// syncAgentFailure 把 agentFailure 的结果写到 AgentError、LicenseNotAccepted Condition
+//
+// 当前原因对应的 Condition 设为 True，另一个出现过的设为 False
+func syncAgentFailure(llm *aiv1.LLMService, reason, message string, found bool) {
+	current := ""
+	if found {
+		current = agentFailureCondition(reason)
+		setCondition(llm, current, metav1.ConditionTrue, reason, message)
+	}
+	for _, c := range []struct{ condition, reason string }{
+		{aiv1.ConditionAgentError, "Recovered"},
+		{aiv1.ConditionLicenseNotAccepted, "LicenseAccepted"},
+	} {
+		if c.condition != current && findCondition(llm, c.condition) != nil {
+			setCondition(llm, c.condition, metav1.ConditionFalse, c.reason, "")
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestAgentFailure(t *testing.T) {
+	pod := func(name string, ready bool, state, last *corev1.ContainerStateTerminated) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				Name:                 agentContainerName,
+				Ready:                ready,
+				State:                corev1.ContainerState{Terminated: state},
+				LastTerminationState: corev1.ContainerState{Terminated: last},
+			}}},
+		}
+	}
+	// initContainer 模式下 model-fetch 失败，主容器还没启动
+	fetchFailed := func(name string, last *corev1.ContainerStateTerminated) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{{
+				Name:                 modelFetchContainerName,
+				LastTerminationState: corev1.ContainerState{Terminated: last},
+			}}},
+		}
+	}
+	authFailed := &corev1.ContainerStateTerminated{ExitCode: 1, Message: "AuthFailed: download failed: exit status 1"}
+	oomKilled := &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}
+
+	tests := []struct {
+		name       string
+		pods       []corev1.Pod
+		wantReason string
+	}{
+		{
+			name:       "crash looping with auth failure",
+			pods:       []corev1.Pod{pod("qwen-a", true, nil, nil), pod("qwen-b", false, nil, authFailed)},
+			wantReason: "AuthFailed",
+		},
+		{
+			name:       "currently terminated",
+			pods:       []corev1.Pod{pod("qwen-a", false, authFailed, nil)},
+			wantReason: "AuthFailed",
+		},
+		{
+			name: "recovered after restart",
+			pods: []corev1.Pod{pod("qwen-a", true, nil, authFailed)},
+		},
+		{
+			name:       "model-fetch init container failed",
+			pods:       []corev1.Pod{fetchFailed("qwen-a", authFailed)},
+			wantReason: "AuthFailed",
+		},
+		{
+			name: "not an agent termination message",
+			pods: []corev1.Pod{pod("qwen-a", false, nil, oomKilled)},
+		},
+	}
+
+	for _, tt := range tests {
+		reason, message, found := agentFailure(tt.pods)
+		if found != (tt.wantReason != "") || reason != tt.wantReason {
+			t.Errorf("%s: agentFailure() = %q, %q, %v; want reason %q", tt.name, reason, message, found, tt.wantReason)
+		}
+	}
+}
+
+func TestSyncAgentFailure(t *testing.T) {
+	llm := testLLMService()
+	llm.Spec.Model = "meta-llama/Llama-3.1-8B-Instruct"
+	status := func(condition string) string {
+		if c := findCondition(llm, condition); c != nil {
+			return c.Status
+		}
+		return ""
+	}
+
+	// 许可协议没接受：只设置 LicenseNotAccepted，不当成普通的 agent 错误
+	syncAgentFailure(llm, "LicenseNotAccepted", "pod llama-a: meta-llama/Llama-3.1-8B-Instruct is a gated model", true)
+	if status(aiv1.ConditionLicenseNotAccepted) != string(metav1.ConditionTrue) || status(aiv1.ConditionAgentError) != "" {
+		t.Fatalf("LicenseNotAccepted = %q, AgentError = %q", status(aiv1.ConditionLicenseNotAccepted), status(aiv1.ConditionAgentError))
+	}
+
+	// 接受之后下载又因为别的原因失败
+	syncAgentFailure(llm, "DiskFull", "pod llama-a: no space left on device", true)
+	if status(aiv1.ConditionLicenseNotAccepted) != string(metav1.ConditionFalse) || status(aiv1.ConditionAgentError) != string(metav1.ConditionTrue) {
+		t.Errorf("LicenseNotAccepted = %q, AgentError = %q", status(aiv1.ConditionLicenseNotAccepted), status(aiv1.ConditionAgentError))
+	}
+
+	syncAgentFailure(llm, "", "", false)
+	if c := findCondition(llm, aiv1.ConditionAgentError); c.Status != string(metav1.ConditionFalse) || c.Reason != "Recovered" {
+		t.Errorf("AgentError after recovery = %+v", c)
+	}
+}
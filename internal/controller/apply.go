@@ -0,0 +1,176 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	metav1ac "k8s.io/client-go/applyconfigurations/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// Server-side apply
+// ============================================================================
+//
+// 所有子资源都用 server-side apply 写入，而不是 Create/Update：
+// - 幂等：每次 reconcile 都 apply 完整的期望状态，没有变化时 API server 不会改对象
+// - 和别的 controller 共存：只管理我们自己设置的字段（HPA 改 replicas、
+//   webhook 注入 sidecar 都不会被覆盖，除非和我们的字段冲突）
+// - 不需要先 Get 再 Update，也不会因为 resourceVersion 过期而冲突
+//
+// desired* 函数仍然生成 typed 对象（plan、drift 检测和测试都在用），
+// 这里把它们转成对应的 typed apply configuration。
+// ============================================================================
+
+// fieldManager 是 controller 做 server-side apply 时使用的 field manager
+const fieldManager = "kubeinfer-controller"
+
+// ownerReference 生成指向 LLMService 的 controller OwnerReference
+//
+// LLMService 删除时子资源被级联删除，Owns() 也靠它把子资源的变化映射回来
+func ownerReference(llm *aiv1.LLMService) *metav1ac.OwnerReferenceApplyConfiguration {
+	return metav1ac.OwnerReference().
+		WithAPIVersion(aiv1.GroupVersion.String()).
+		WithKind("LLMService").
+		WithName(llm.Name).
+		WithUID(llm.UID).
+		WithController(true).
+		WithBlockOwnerDeletion(true)
+}
+
+// convertToApply 把 typed 对象转成 apply configuration（两者的 JSON 结构一致）
+//
+// 去掉 status 和所有 null 值（比如 creationTimestamp: null），
+// 否则 apply 会把这些字段也算成我们管理的字段
+func convertToApply(obj any, ac any) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	delete(m, "status")
+	pruneNulls(m)
+
+	data, err = json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, ac)
+}
+
+// pruneNulls 递归删除值为 null 的字段
+//
+// 空对象要保留：emptyDir: {} 这种字段本身就有意义
+func pruneNulls(m map[string]any) {
+	for k, v := range m {
+		switch val := v.(type) {
+		case nil:
+			delete(m, k)
+		case map[string]any:
+			pruneNulls(val)
+		case []any:
+			for _, item := range val {
+				if child, ok := item.(map[string]any); ok {
+					pruneNulls(child)
+				}
+			}
+		}
+	}
+}
+
+// deploymentApplyConfiguration 把期望的 Deployment 转成 apply configuration
+func deploymentApplyConfiguration(llm *aiv1.LLMService, d *appsv1.Deployment) (*appsv1ac.DeploymentApplyConfiguration, error) {
+	ac := &appsv1ac.DeploymentApplyConfiguration{}
+	if err := convertToApply(d, ac); err != nil {
+		return nil, err
+	}
+	ac.WithAPIVersion("apps/v1").WithKind("Deployment").WithOwnerReferences(ownerReference(llm))
+	return ac, nil
+}
+
+// serviceApplyConfiguration 把期望的 Service 转成 apply configuration
+//
+// 所有 Service 都是 PreferDualStack：双栈集群上同时分配 IPv4 和 IPv6 的 ClusterIP，
+// 单栈集群（包括 IPv6-only）上退化成集群唯一的协议族
+func serviceApplyConfiguration(llm *aiv1.LLMService, s *corev1.Service) (*corev1ac.ServiceApplyConfiguration, error) {
+	ac := &corev1ac.ServiceApplyConfiguration{}
+	if err := convertToApply(s, ac); err != nil {
+		return nil, err
+	}
+	ac.WithAPIVersion("v1").WithKind("Service").WithOwnerReferences(ownerReference(llm))
+	if ac.Spec == nil {
+		ac.WithSpec(corev1ac.ServiceSpec())
+	}
+	if ac.Spec.IPFamilyPolicy == nil {
+		ac.Spec.WithIPFamilyPolicy(corev1.IPFamilyPolicyPreferDualStack)
+	}
+	return ac, nil
+}
+
+// configMapApplyConfiguration 把期望的 ConfigMap 转成 apply configuration
+func configMapApplyConfiguration(llm *aiv1.LLMService, cm *corev1.ConfigMap) (*corev1ac.ConfigMapApplyConfiguration, error) {
+	ac := &corev1ac.ConfigMapApplyConfiguration{}
+	if err := convertToApply(cm, ac); err != nil {
+		return nil, err
+	}
+	ac.WithAPIVersion("v1").WithKind("ConfigMap").WithOwnerReferences(ownerReference(llm))
+	return ac, nil
+}
+
+// keepAppliedTemplate 让 apply 沿用上一次应用的 Pod 模板（UpdateWindow 外不能改模板）
+//
+// 不能直接去掉 Spec.Template：apply 里缺少的字段如果之前归我们管理，会被 API server 删除。
+// 所以从 managedFields 里取出我们上次 apply 的模板原样再 apply 一次。
+// 旧版本用 Create 创建的 Deployment 里没有我们的字段，取出来是空的，去掉也不会删任何东西。
+func keepAppliedTemplate(ac *appsv1ac.DeploymentApplyConfiguration, found *appsv1.Deployment) error {
+	applied, err := appsv1ac.ExtractDeployment(found, fieldManager)
+	if err != nil {
+		return err
+	}
+	ac.Spec.Template = nil
+	if applied.Spec != nil {
+		ac.Spec.Template = applied.Spec.Template
+	}
+
+	if hash, ok := found.Annotations[templateHashAnnotation]; ok {
+		ac.Annotations[templateHashAnnotation] = hash
+	} else {
+		delete(ac.Annotations, templateHashAnnotation)
+	}
+	return nil
+}
+
+// apply 用 controller 的 field manager 做 server-side apply
+//
+// ForceOwnership：字段被别人（比如 kubectl edit）改过时以我们为准
+func (r *LLMServiceReconciler) apply(ctx context.Context, ac runtime.ApplyConfiguration) error {
+	return r.Apply(ctx, ac, client.FieldOwner(fieldManager), client.ForceOwnership)
+}
+
+// applyDeployment 把期望的 Deployment 完整 apply 到集群（不存在时创建）
+func (r *LLMServiceReconciler) applyDeployment(ctx context.Context, llm *aiv1.LLMService, d *appsv1.Deployment) error {
+	ac, err := deploymentApplyConfiguration(llm, d)
+	if err != nil {
+		return err
+	}
+	return r.apply(ctx, ac)
+}
+
+// applyService 把期望的 Service apply 到集群（不存在时创建）
+func (r *LLMServiceReconciler) applyService(ctx context.Context, llm *aiv1.LLMService, s *corev1.Service) error {
+	ac, err := serviceApplyConfiguration(llm, s)
+	if err != nil {
+		return err
+	}
+	return r.apply(ctx, ac)
+}
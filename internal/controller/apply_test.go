@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// appliedFields 把 apply configuration 展开成字段路径集合
+//
+// 带 name 的列表元素用 [name] 表示，例如 spec.template.spec.containers[agent].image
+func appliedFields(t *testing.T, ac any) map[string]bool {
+	t.Helper()
+	data, err := json.Marshal(ac)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	fields := map[string]bool{}
+	var walk func(prefix string, v any)
+	walk = func(prefix string, v any) {
+		fields[prefix] = true
+		switch val := v.(type) {
+		case map[string]any:
+			for k, child := range val {
+				walk(prefix+"."+k, child)
+			}
+		case []any:
+			for i, item := range val {
+				key := fmt.Sprint(i)
+				if child, ok := item.(map[string]any); ok {
+					if name, ok := child["name"].(string); ok {
+						key = name
+					}
+				}
+				walk(fmt.Sprintf("%s[%s]", prefix, key), item)
+			}
+		}
+	}
+	for k, v := range m {
+		walk(k, v)
+	}
+	return fields
+}
+
+func testLLMService() *aiv1.LLMService {
+	return &aiv1.LLMService{
+		ObjectMeta: metav1.ObjectMeta{Name: "qwen", Namespace: "default", UID: "uid-1"},
+		Spec:       aiv1.LLMServiceSpec{Model: "Qwen/Qwen2.5-0.5B", Replicas: 2, Image: aiv1.DefaultImage},
+	}
+}
+
+func TestDeploymentApplyConfiguration(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+
+	ac, err := deploymentApplyConfiguration(llm, r.desiredDeployment(llm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := appliedFields(t, ac)
+
+	for _, f := range []string{
+		"apiVersion",
+		"kind",
+		"metadata.name",
+		"metadata.namespace",
+		"metadata.annotations." + templateHashAnnotation,
+		"metadata.ownerReferences[qwen].controller",
+		"spec.replicas",
+		"spec.selector.matchLabels",
+		"spec.template.spec.containers[agent].image",
+		"spec.template.spec.containers[agent].env[MODEL_REPO].value",
+		// emptyDir: {} 本身有意义，不能被去掉
+		"spec.template.spec.volumes[model-storage].emptyDir",
+	} {
+		if !fields[f] {
+			t.Errorf("expected applied field %s", f)
+		}
+	}
+	for _, f := range []string{
+		"status",
+		"metadata.creationTimestamp",
+		"spec.template.metadata.creationTimestamp",
+	} {
+		if fields[f] {
+			t.Errorf("unexpected applied field %s", f)
+		}
+	}
+
+	if *ac.Kind != "Deployment" || *ac.APIVersion != "apps/v1" {
+		t.Errorf("unexpected type meta %s/%s", *ac.APIVersion, *ac.Kind)
+	}
+	if *ac.OwnerReferences[0].UID != llm.UID {
+		t.Errorf("owner reference UID = %s, want %s", *ac.OwnerReferences[0].UID, llm.UID)
+	}
+}
+
+func TestServiceApplyConfiguration(t *testing.T) {
+	llm := testLLMService()
+
+	ac, err := serviceApplyConfiguration(llm, desiredVLLMService(llm))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := appliedFields(t, ac)
+
+	for _, f := range []string{
+		"metadata.name",
+		"metadata.ownerReferences[qwen].kind",
+		"spec.selector.llm_cr",
+		"spec.ports[http].port",
+		"spec.ports[http].targetPort",
+		"spec.ipFamilyPolicy",
+	} {
+		if !fields[f] {
+			t.Errorf("expected applied field %s", f)
+		}
+	}
+	// clusterIP 由 API server 分配，不能出现在我们的字段里
+	for _, f := range []string{"status", "spec.clusterIP", "spec.type"} {
+		if fields[f] {
+			t.Errorf("unexpected applied field %s", f)
+		}
+	}
+}
+
+func TestKeepAppliedTemplate(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+
+	ac, err := deploymentApplyConfiguration(llm, r.desiredDeployment(llm))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 旧版本用 Create 创建的 Deployment：没有我们的 managedFields
+	found := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        *ac.GetName(),
+			Namespace:   "default",
+			Annotations: map[string]string{templateHashAnnotation: "old"},
+		},
+	}
+	if err := keepAppliedTemplate(ac, found); err != nil {
+		t.Fatal(err)
+	}
+
+	fields := appliedFields(t, ac)
+	if fields["spec.template"] {
+		t.Error("template should not be applied outside the update window")
+	}
+	if !fields["spec.replicas"] {
+		t.Error("replicas should still be applied")
+	}
+	if got := ac.Annotations[templateHashAnnotation]; got != "old" {
+		t.Errorf("template hash = %s, want old", got)
+	}
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/audit"
+)
+
+// ============================================================================
+// 审计记录：spec 变更、扩缩容、coordinator 故障切换、换模型
+// ============================================================================
+//
+// 每条记录回答"谁、为什么"：
+//   - 用户改 spec 导致的变化：actor 是最近修改 spec 的 field manager（kubectl、Argo CD……），trigger 是 spec
+//   - controller 自己的决定（SLO 扩容、休眠、故障切换）：actor 是 kubeinfer-controller，trigger 是原因
+//
+// 落地位置由 operator 配置的 audit.sink 决定，见 internal/audit
+// ============================================================================
+
+// 审计记录的 trigger
+const (
+	triggerSpec        = "spec"
+	triggerSLO         = "slo"
+	triggerHibernation = "hibernation"
+	triggerWake        = "wake"
+	triggerRestart     = "restart"
+)
+
+// auditTrail 按当前配置创建 Trail（配置会热加载，每次都重新读）
+func (r *LLMServiceReconciler) auditTrail() *audit.Trail {
+	cfg := r.config().Audit
+	switch cfg.Sink {
+	case audit.SinkConfigMap:
+		return audit.New(&audit.ConfigMapSink{Client: r.Client, MaxEntries: cfg.MaxEntries})
+	case audit.SinkEvent:
+		return audit.New(&audit.EventSink{Recorder: r.Recorder})
+	}
+	return audit.New(nil)
+}
+
+// recordAudit 记录一条审计记录，trigger 是 spec 时 actor 取最近修改 spec 的 field manager，
+// 是 restart 时取最近修改 restartedAt 注解的 field manager
+func (r *LLMServiceReconciler) recordAudit(ctx context.Context, llm *aiv1.LLMService, action audit.Action, trigger, msg string) {
+	actor := fieldManager
+	switch trigger {
+	case triggerSpec:
+		actor = audit.SpecActor(llm)
+	case triggerRestart:
+		actor = audit.FieldActor(llm, aiv1.RestartedAtAnnotation)
+	}
+	r.auditTrail().Record(ctx, llm, audit.Entry{Action: action, Actor: actor, Trigger: trigger, Message: msg})
+}
+
+// scaleTrigger 判断副本数从 from 变成 effectiveReplicas 的原因
+func scaleTrigger(llm *aiv1.LLMService, from int32) string {
+	if isConditionTrue(llm, aiv1.ConditionHibernated) {
+		return triggerHibernation
+	}
+	if sloReplicas(llm) > llm.Spec.Replicas {
+		return triggerSLO
+	}
+	if cond := findCondition(llm, aiv1.ConditionHibernated); cond != nil && cond.Reason == "RequestReceived" && from == idleReplicas(llm) {
+		return triggerWake
+	}
+	return triggerSpec
+}
+
+// auditModelSwitch 在 spec.model 和上次记录的 Status.Model 不一样时记一条审计记录
+func (r *LLMServiceReconciler) auditModelSwitch(ctx context.Context, llm *aiv1.LLMService) {
+	old := llm.Status.Model
+	if old == nil || old.Name == llm.Spec.Model {
+		return
+	}
+	r.recordAudit(ctx, llm, audit.ActionModelSwitched, triggerSpec,
+		fmt.Sprintf("model switched from %s to %s", old.Name, llm.Spec.Model))
+}
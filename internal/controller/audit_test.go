@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// TestScaleTrigger 测试扩缩容审计记录的原因分类
+func TestScaleTrigger(t *testing.T) {
+	maxReplicas := int32(6)
+	tests := []struct {
+		name  string
+		setup func(llm *aiv1.LLMService)
+		from  int32
+		want  string
+	}{
+		{name: "spec change", setup: func(*aiv1.LLMService) {}, from: 1, want: triggerSpec},
+		{
+			name: "hibernated",
+			setup: func(llm *aiv1.LLMService) {
+				setCondition(llm, aiv1.ConditionHibernated, metav1.ConditionTrue, "Idle", "idle")
+			},
+			from: 2,
+			want: triggerHibernation,
+		},
+		{
+			name: "woke up on request",
+			setup: func(llm *aiv1.LLMService) {
+				setCondition(llm, aiv1.ConditionHibernated, metav1.ConditionFalse, "RequestReceived", "woke up")
+			},
+			from: defaultIdleReplicas,
+			want: triggerWake,
+		},
+		{
+			name: "slo scale up",
+			setup: func(llm *aiv1.LLMService) {
+				llm.Spec.SLO = &aiv1.SLOSpec{MaxReplicas: &maxReplicas}
+				llm.Status.SLO = &aiv1.SLOStatus{Replicas: 4}
+			},
+			from: 2,
+			want: triggerSLO,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := testLLMService()
+			tt.setup(llm)
+			if got := scaleTrigger(llm, tt.from); got != tt.want {
+				t.Errorf("scaleTrigger = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
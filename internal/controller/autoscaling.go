@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// 外部扩缩容（spec.autoscaling.mode=External / KEDA）
+// ============================================================================
+//
+// 有的团队已经在用 HPA / KEDA 管理所有服务的副本数，不想再用 controller 内置的休眠和 SLO 扩容。
+// External 和 KEDA 模式下：
+//  1. desiredDeployment 不设置 replicas，server-side apply 就不会管理这个字段，HPA/KEDA 改了也不会被改回去
+//  2. gateway 一定会部署，指标有两个来源：
+//     - Prometheus 里的 kubeinfer_gateway_* 指标，通过 prometheus-adapter 暴露给 HPA（config/autoscaling）
+//     - gateway 的 /scaler/metrics，KEDA 的 metrics-api scaler 可以直接读，不需要 Prometheus
+//
+// External 模式的 HPA/ScaledObject 由用户自己创建；KEDA 模式由 controller 生成 ScaledObject：
+//
+//	queue depth    sum(kubeinfer_gateway_inflight_requests{...})
+//	tokens/second  sum(rate(kubeinfer_gateway_tokens_total{...}[2m]))
+//
+// 两个 trigger 都是 AverageValue，KEDA 按副本数平均后和目标比较。
+// 没有装 KEDA 的集群用 unstructured 对象也能编译和运行，只是 apply 时会报 CRD 不存在。
+// ============================================================================
+
+// defaultAutoscalingCooldown 是 spec.autoscaling.cooldownPeriod 的默认值
+const defaultAutoscalingCooldown = 10 * time.Minute
+
+// scaledObjectGVK 是 KEDA ScaledObject 的类型（不引入 KEDA 的 Go 依赖）
+var scaledObjectGVK = schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledObject"}
+
+// externalAutoscaling 判断副本数是否交给外部的 HPA/KEDA 管理
+func externalAutoscaling(llm *aiv1.LLMService) bool {
+	a := llm.Spec.Autoscaling
+	return a != nil && (a.Mode == aiv1.AutoscalingModeExternal || a.Mode == aiv1.AutoscalingModeKEDA)
+}
+
+// kedaAutoscaling 判断是否需要 controller 生成 ScaledObject
+func kedaAutoscaling(llm *aiv1.LLMService) bool {
+	return llm.Spec.Autoscaling != nil && llm.Spec.Autoscaling.Mode == aiv1.AutoscalingModeKEDA
+}
+
+// replicasString 用于日志和 plan 输出，外部管理副本数时 replicas 为 nil
+func replicasString(replicas *int32) string {
+	if replicas == nil {
+		return "externally managed"
+	}
+	return fmt.Sprintf("%d", *replicas)
+}
+
+// autoscalingPrometheusURL 返回写进 ScaledObject 的 Prometheus 地址
+func (r *LLMServiceReconciler) autoscalingPrometheusURL() string {
+	cfg := r.config()
+	if cfg.Autoscaling.PrometheusURL != "" {
+		return cfg.Autoscaling.PrometheusURL
+	}
+	return cfg.SLO.PrometheusURL
+}
+
+// emptyScaledObject 返回只有类型和名称的 ScaledObject，用于删除
+func emptyScaledObject(llm *aiv1.LLMService) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(scaledObjectGVK)
+	u.SetName(llm.Name)
+	u.SetNamespace(llm.Namespace)
+	return u
+}
+
+// desiredScaledObject 生成 KEDA ScaledObject，目标是推理 Deployment
+func desiredScaledObject(llm *aiv1.LLMService, prometheusURL string) *unstructured.Unstructured {
+	a := llm.Spec.Autoscaling
+	minReplicas := llm.Spec.Replicas
+	if a.MinReplicas != nil {
+		minReplicas = *a.MinReplicas
+	}
+	cooldown := defaultAutoscalingCooldown
+	if a.CooldownPeriod != nil && a.CooldownPeriod.Duration > 0 {
+		cooldown = a.CooldownPeriod.Duration
+	}
+	selector := fmt.Sprintf(`namespace=%q,name=%q`, llm.Namespace, llm.Name)
+
+	var triggers []any
+	addTrigger := func(name, query string, target int32) {
+		triggers = append(triggers, map[string]any{
+			"type":       "prometheus",
+			"name":       name,
+			"metricType": "AverageValue",
+			"metadata": map[string]any{
+				"serverAddress": prometheusURL,
+				"query":         query,
+				"threshold":     fmt.Sprintf("%d", target),
+			},
+		})
+	}
+	if a.TargetQueueDepth != nil {
+		addTrigger("queue-depth",
+			fmt.Sprintf("sum(kubeinfer_gateway_inflight_requests{%s})", selector), *a.TargetQueueDepth)
+	}
+	if a.TargetTokensPerSecond != nil {
+		addTrigger("tokens-per-second",
+			fmt.Sprintf("sum(rate(kubeinfer_gateway_tokens_total{%s}[2m]))", selector), *a.TargetTokensPerSecond)
+	}
+
+	isController := true
+	u := emptyScaledObject(llm)
+	u.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion:         aiv1.GroupVersion.String(),
+		Kind:               "LLMService",
+		Name:               llm.Name,
+		UID:                llm.UID,
+		Controller:         &isController,
+		BlockOwnerDeletion: &isController,
+	}})
+	u.Object["spec"] = map[string]any{
+		"scaleTargetRef": map[string]any{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"name":       llm.Name + "-deployment",
+		},
+		"minReplicaCount": int64(minReplicas),
+		"maxReplicaCount": int64(a.MaxReplicas),
+		// cooldownPeriod 只管缩容到 0，缩容到非 0 副本数要靠 HPA 的 stabilizationWindow
+		"cooldownPeriod": int64(cooldown.Seconds()),
+		"advanced": map[string]any{
+			"horizontalPodAutoscalerConfig": map[string]any{
+				"behavior": map[string]any{
+					"scaleDown": map[string]any{
+						"stabilizationWindowSeconds": int64(cooldown.Seconds()),
+					},
+				},
+			},
+		},
+		"triggers": triggers,
+	}
+	return u
+}
+
+// reconcileScaledObject 根据 spec.autoscaling 创建或删除 ScaledObject
+//
+// 没有配置 Prometheus 地址或者集群没有装 KEDA 时发 Warning Event 并返回错误
+func (r *LLMServiceReconciler) reconcileScaledObject(ctx context.Context, llm *aiv1.LLMService) error {
+	if !kedaAutoscaling(llm) {
+		if llm.Status.ScaledObject == "" {
+			return nil
+		}
+		// 不删的话 KEDA 会继续改副本数
+		if err := r.Delete(ctx, emptyScaledObject(llm)); err != nil &&
+			!errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return fmt.Errorf("failed to delete ScaledObject: %w", err)
+		}
+		llm.Status.ScaledObject = ""
+		return nil
+	}
+
+	url := r.autoscalingPrometheusURL()
+	if url == "" {
+		r.autoscalingFailed(llm, "PrometheusNotConfigured",
+			"spec.autoscaling.mode is KEDA but the operator config has no autoscaling.prometheusURL")
+		return fmt.Errorf("no Prometheus URL configured for KEDA autoscaling")
+	}
+	so := desiredScaledObject(llm, url)
+	if err := r.apply(ctx, client.ApplyConfigurationFromUnstructured(so)); err != nil {
+		if meta.IsNoMatchError(err) {
+			r.autoscalingFailed(llm, "KEDANotInstalled",
+				"spec.autoscaling.mode is KEDA but the ScaledObject CRD (keda.sh/v1alpha1) is not installed")
+		}
+		return fmt.Errorf("failed to apply ScaledObject: %w", err)
+	}
+	llm.Status.ScaledObject = so.GetName()
+	return nil
+}
+
+// autoscalingFailed 发 Warning Event，kubectl describe 能直接看到原因
+func (r *LLMServiceReconciler) autoscalingFailed(llm *aiv1.LLMService, reason, msg string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(llm, corev1.EventTypeWarning, reason, msg)
+	}
+}
@@ -0,0 +1,109 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// TestExternalAutoscaling 测试 External 模式下不 apply replicas，并且会部署 gateway
+func TestExternalAutoscaling(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	llm.Spec.Autoscaling = &aiv1.AutoscalingSpec{Mode: aiv1.AutoscalingModeExternal}
+
+	desired := r.desiredDeployment(llm)
+	if desired.Spec.Replicas != nil {
+		t.Errorf("replicas = %d, want nil", *desired.Spec.Replicas)
+	}
+	ac, err := deploymentApplyConfiguration(llm, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if appliedFields(t, ac)["spec.replicas"] {
+		t.Error("spec.replicas must not be applied when autoscaling is external")
+	}
+	if !r.gatewayEnabled(llm) {
+		t.Error("gateway must be enabled for external autoscaling")
+	}
+	if changes := diffDeployment(llm, r.desiredDeployment(testLLMService()), desired, time.Now()); len(changes) != 0 {
+		t.Errorf("changes = %v, want none (replicas are owned by the autoscaler)", changes)
+	}
+}
+
+func TestDesiredScaledObject(t *testing.T) {
+	llm := testLLMService()
+	queue, tokens := int32(8), int32(2000)
+	llm.Spec.Autoscaling = &aiv1.AutoscalingSpec{
+		Mode:                  aiv1.AutoscalingModeKEDA,
+		MaxReplicas:           6,
+		TargetQueueDepth:      &queue,
+		TargetTokensPerSecond: &tokens,
+	}
+
+	so := desiredScaledObject(llm, "http://prometheus:9090")
+	if so.GetName() != "qwen" || so.GetAPIVersion() != "keda.sh/v1alpha1" || so.GetKind() != "ScaledObject" {
+		t.Errorf("object = %s %s/%s", so.GetAPIVersion(), so.GetKind(), so.GetName())
+	}
+	if refs := so.GetOwnerReferences(); len(refs) != 1 || refs[0].UID != llm.UID {
+		t.Errorf("owner references = %v", refs)
+	}
+	target, _, _ := unstructured.NestedString(so.Object, "spec", "scaleTargetRef", "name")
+	minReplicas, _, _ := unstructured.NestedInt64(so.Object, "spec", "minReplicaCount")
+	maxReplicas, _, _ := unstructured.NestedInt64(so.Object, "spec", "maxReplicaCount")
+	window, _, _ := unstructured.NestedInt64(so.Object, "spec", "advanced", "horizontalPodAutoscalerConfig",
+		"behavior", "scaleDown", "stabilizationWindowSeconds")
+	if target != "qwen-deployment" || minReplicas != 2 || maxReplicas != 6 || window != 600 {
+		t.Errorf("target = %s, replicas = %d-%d, stabilization window = %ds", target, minReplicas, maxReplicas, window)
+	}
+
+	triggers, _, _ := unstructured.NestedSlice(so.Object, "spec", "triggers")
+	if len(triggers) != 2 {
+		t.Fatalf("triggers = %v, want queue depth and tokens per second", triggers)
+	}
+	for i, want := range []struct{ query, threshold string }{
+		{`sum(kubeinfer_gateway_inflight_requests{namespace="default",name="qwen"})`, "8"},
+		{`sum(rate(kubeinfer_gateway_tokens_total{namespace="default",name="qwen"}[2m]))`, "2000"},
+	} {
+		md, _, _ := unstructured.NestedStringMap(triggers[i].(map[string]any), "metadata")
+		if md["query"] != want.query || md["threshold"] != want.threshold || md["serverAddress"] != "http://prometheus:9090" {
+			t.Errorf("trigger %d metadata = %v", i, md)
+		}
+	}
+
+	// unstructured 里只能有 JSON 兼容的类型（int64 而不是 int32），否则 DeepCopy 会 panic
+	_ = so.DeepCopy()
+}
+
+// TestReconcileScaledObject_NoPrometheus 测试没有配置 Prometheus 时发 Warning Event
+func TestReconcileScaledObject_NoPrometheus(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	r := &LLMServiceReconciler{Recorder: recorder}
+	llm := testLLMService()
+	queue := int32(8)
+	llm.Spec.Autoscaling = &aiv1.AutoscalingSpec{Mode: aiv1.AutoscalingModeKEDA, MaxReplicas: 4, TargetQueueDepth: &queue}
+
+	if err := r.reconcileScaledObject(context.Background(), llm); err == nil {
+		t.Fatal("expected an error without a Prometheus URL")
+	}
+	select {
+	case e := <-recorder.Events:
+		if !strings.Contains(e, "PrometheusNotConfigured") {
+			t.Errorf("event = %q", e)
+		}
+	default:
+		t.Error("expected a Warning event")
+	}
+
+	// 不是 KEDA 模式、也没有生成过 ScaledObject 时什么都不做（不需要访问 API server）
+	llm.Spec.Autoscaling = nil
+	if err := r.reconcileScaledObject(context.Background(), llm); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,311 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/benchmark"
+	"github.com/Moore-Z/kubeinfer/internal/operatorconfig"
+)
+
+// ============================================================================
+// BenchmarkRun：声明式压测
+// ============================================================================
+//
+// 流程：
+//
+//	Pending  → 等 LLMService 存在且有可用副本（开启了 gateway 时不用等，gateway 会唤醒休眠的服务）
+//	Running  → 创建 Job 运行 /benchmark（manager 镜像），请求 gateway 或 <name>-vllm Service
+//	Succeeded/Failed → Job 结束，从 Pod 的终止消息里读出结果写进 status
+//
+// 结束之后不再调和，Job 和 Pod 保留（方便看日志），删除 BenchmarkRun 时一起被回收。
+// ============================================================================
+
+// benchmarkPodApp 是压测 Pod 的 app label，manager 的 Pod 缓存也按它过滤（CacheOptions）
+const benchmarkPodApp = "kubeinfer-benchmark"
+
+// benchmarkRunLabel 记录压测 Pod 属于哪个 BenchmarkRun
+const benchmarkRunLabel = "kubeinfer.io/benchmark-run"
+
+// benchmarkStartupGrace 是 Job 在压测时长之外额外允许的时间（等 gateway 唤醒、等在途请求完成）
+const benchmarkStartupGrace = 15 * time.Minute
+
+// BenchmarkRunReconciler 调和 BenchmarkRun
+type BenchmarkRunReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Config 提供压测 Job 的镜像（和 gateway 一样是 manager 镜像），为 nil 时用内置默认值
+	Config *operatorconfig.Store
+}
+
+//+kubebuilder:rbac:groups=ai.ruijie.io,resources=benchmarkruns,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=ai.ruijie.io,resources=benchmarkruns/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=ai.ruijie.io,resources=benchmarkruns/finalizers,verbs=update
+//+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;delete
+
+func (r *BenchmarkRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+
+	run := &aiv1.BenchmarkRun{}
+	if err := r.Get(ctx, req.NamespacedName, run); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if run.Status.Phase == aiv1.BenchmarkSucceeded || run.Status.Phase == aiv1.BenchmarkFailed {
+		return ctrl.Result{}, nil
+	}
+
+	job := &batchv1.Job{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: run.Namespace, Name: benchmarkJobName(run)}, job)
+	if errors.IsNotFound(err) {
+		return r.startBenchmark(ctx, run)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	finished, failed := jobFinished(job)
+	if !finished {
+		return ctrl.Result{}, nil
+	}
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(run.Namespace),
+		client.MatchingLabels{benchmarkRunLabel: run.Name}); err != nil {
+		return ctrl.Result{}, err
+	}
+	msg := terminationMessage(pods.Items, "benchmark")
+
+	now := metav1.Now()
+	run.Status.CompletionTime = &now
+	if failed {
+		run.Status.Phase = aiv1.BenchmarkFailed
+		run.Status.Message = "benchmark job failed"
+		if msg != "" {
+			run.Status.Message += ": " + msg
+		}
+	} else if results, err := parseBenchmarkResults(msg); err != nil {
+		run.Status.Phase = aiv1.BenchmarkFailed
+		run.Status.Message = err.Error()
+	} else {
+		run.Status.Phase = aiv1.BenchmarkSucceeded
+		run.Status.Message = ""
+		run.Status.Results = results
+	}
+	l.Info("Benchmark finished", "phase", run.Status.Phase, "message", run.Status.Message)
+	return ctrl.Result{}, r.Status().Update(ctx, run)
+}
+
+// startBenchmark 在 LLMService 就绪后创建压测 Job，否则停在 Pending 稍后重试
+func (r *BenchmarkRunReconciler) startBenchmark(ctx context.Context, run *aiv1.BenchmarkRun) (ctrl.Result, error) {
+	llm := &aiv1.LLMService{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: run.Namespace, Name: run.Spec.LLMService}, llm)
+	if errors.IsNotFound(err) {
+		return r.setPending(ctx, run, fmt.Sprintf("LLMService %q not found", run.Spec.LLMService))
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	target, err := inferenceTarget(ctx, r.Client, llm)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if target == "" {
+		return r.setPending(ctx, run, fmt.Sprintf("waiting for LLMService %q to have available replicas", llm.Name))
+	}
+
+	job := desiredBenchmarkJob(run, target, r.Config.Get().Gateway.Image)
+	if err := controllerutil.SetControllerReference(run, job, r.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+		return ctrl.Result{}, err
+	}
+	log.FromContext(ctx).Info("Benchmark job created", "job", job.Name, "target", target)
+
+	now := metav1.Now()
+	run.Status.Phase = aiv1.BenchmarkRunning
+	run.Status.JobName = job.Name
+	run.Status.Target = target
+	run.Status.StartTime = &now
+	run.Status.Message = ""
+	return ctrl.Result{}, r.Status().Update(ctx, run)
+}
+
+// setPending 更新 Pending 的原因，30 秒后重新检查
+func (r *BenchmarkRunReconciler) setPending(ctx context.Context, run *aiv1.BenchmarkRun, msg string) (ctrl.Result, error) {
+	if run.Status.Phase != aiv1.BenchmarkPending || run.Status.Message != msg {
+		run.Status.Phase = aiv1.BenchmarkPending
+		run.Status.Message = msg
+		if err := r.Status().Update(ctx, run); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+}
+
+// inferenceTarget 返回压测、评测请求的地址，还不能接请求时返回空
+//
+// 有 gateway 就走 gateway：结果包含 gateway 的开销，和真实流量一致，休眠的服务也会被唤醒；
+// 没有 gateway 时直接请求 <name>-vllm Service，要等有可用副本
+func inferenceTarget(ctx context.Context, c client.Reader, llm *aiv1.LLMService) (string, error) {
+	gw := &corev1.Service{}
+	err := c.Get(ctx, client.ObjectKey{Namespace: llm.Namespace, Name: gatewayName(llm)}, gw)
+	switch {
+	case err == nil:
+		return serviceURL(gw), nil
+	case !errors.IsNotFound(err):
+		return "", err
+	case llm.Status.AvailableReplicas == 0:
+		return "", nil
+	default:
+		return serviceURL(desiredVLLMService(llm)), nil
+	}
+}
+
+// benchmarkJobName 是 BenchmarkRun 对应的 Job 名称
+func benchmarkJobName(run *aiv1.BenchmarkRun) string {
+	return run.Name + "-benchmark"
+}
+
+// serviceURL 是 Service 第一个端口的集群内地址
+func serviceURL(svc *corev1.Service) string {
+	port := int32(8000)
+	if len(svc.Spec.Ports) > 0 {
+		port = svc.Spec.Ports[0].Port
+	}
+	return fmt.Sprintf("http://%s.%s.svc:%d", svc.Name, svc.Namespace, port)
+}
+
+// desiredBenchmarkJob 生成运行负载生成器的 Job
+//
+// 只跑一次（backoffLimit=0）：失败后重跑会让结果混进重试的请求，不如让用户看日志后新建一个 BenchmarkRun
+func desiredBenchmarkJob(run *aiv1.BenchmarkRun, target, image string) *batchv1.Job {
+	duration := time.Minute
+	if run.Spec.Duration != nil {
+		duration = run.Spec.Duration.Duration
+	}
+	labels := map[string]string{
+		"app":             benchmarkPodApp,
+		benchmarkRunLabel: run.Name,
+	}
+	env := []corev1.EnvVar{
+		{Name: benchmark.TargetEnv, Value: target},
+		{Name: benchmark.DurationEnv, Value: duration.String()},
+	}
+	// 没有经过 apiserver 默认值的对象（测试、旧版本创建的）这几个字段可能是 0，交给负载生成器的默认值
+	for name, v := range map[string]int32{
+		benchmark.ConcurrencyEnv:  run.Spec.Concurrency,
+		benchmark.PromptTokensEnv: run.Spec.PromptTokens,
+		benchmark.MaxTokensEnv:    run.Spec.MaxTokens,
+	} {
+		if v > 0 {
+			env = append(env, corev1.EnvVar{Name: name, Value: strconv.Itoa(int(v))})
+		}
+	}
+	backoffLimit := int32(0)
+	deadline := int64((duration + benchmarkStartupGrace).Seconds())
+
+	// map 遍历顺序不固定，排序后每次生成的 Job 才一样
+	slices.SortFunc(env, func(a, b corev1.EnvVar) int { return strings.Compare(a.Name, b.Name) })
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      benchmarkJobName(run),
+			Namespace: run.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &deadline,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{{
+						Name:                     "benchmark",
+						Image:                    image,
+						ImagePullPolicy:          corev1.PullIfNotPresent,
+						Command:                  []string{"/benchmark"},
+						Env:                      env,
+						TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("200m"),
+								corev1.ResourceMemory: resource.MustParse("64Mi"),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceMemory: resource.MustParse("256Mi"),
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// jobFinished 判断 Job 是否结束以及是否失败
+func jobFinished(job *batchv1.Job) (finished, failed bool) {
+	for _, c := range job.Status.Conditions {
+		if c.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch c.Type {
+		case batchv1.JobComplete:
+			return true, false
+		case batchv1.JobFailed:
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// terminationMessage 返回压测、评测容器的终止消息（成功时是结果 JSON，失败时是错误）
+func terminationMessage(pods []corev1.Pod, container string) string {
+	for _, pod := range pods {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name == container && cs.State.Terminated != nil && cs.State.Terminated.Message != "" {
+				return cs.State.Terminated.Message
+			}
+		}
+	}
+	return ""
+}
+
+// parseBenchmarkResults 解析负载生成器写的结果 JSON
+func parseBenchmarkResults(msg string) (*aiv1.BenchmarkResults, error) {
+	if msg == "" {
+		return nil, fmt.Errorf("benchmark job completed without results")
+	}
+	results := &aiv1.BenchmarkResults{}
+	if err := json.Unmarshal([]byte(msg), results); err != nil {
+		return nil, fmt.Errorf("failed to parse benchmark results: %w", err)
+	}
+	return results, nil
+}
+
+// SetupWithManager 注册 controller：Job 状态变化时重新调和所属的 BenchmarkRun
+func (r *BenchmarkRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&aiv1.BenchmarkRun{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/benchmark"
+)
+
+func testBenchmarkRun() *aiv1.BenchmarkRun {
+	return &aiv1.BenchmarkRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "qwen-load", Namespace: "default"},
+		Spec: aiv1.BenchmarkRunSpec{
+			LLMService:  "qwen",
+			Concurrency: 16,
+			MaxTokens:   64,
+			Duration:    &metav1.Duration{Duration: 2 * time.Minute},
+		},
+	}
+}
+
+func TestDesiredBenchmarkJob(t *testing.T) {
+	run := testBenchmarkRun()
+	job := desiredBenchmarkJob(run, "http://qwen-gateway.default.svc:8000", "kubeinfer:v1")
+
+	if job.Name != "qwen-load-benchmark" || job.Namespace != "default" {
+		t.Errorf("job = %s/%s", job.Namespace, job.Name)
+	}
+	if *job.Spec.BackoffLimit != 0 {
+		t.Errorf("backoffLimit = %d, want 0", *job.Spec.BackoffLimit)
+	}
+	if want := int64((2*time.Minute + benchmarkStartupGrace).Seconds()); *job.Spec.ActiveDeadlineSeconds != want {
+		t.Errorf("activeDeadlineSeconds = %d, want %d", *job.Spec.ActiveDeadlineSeconds, want)
+	}
+
+	c := job.Spec.Template.Spec.Containers[0]
+	if c.Image != "kubeinfer:v1" || c.Command[0] != "/benchmark" {
+		t.Errorf("container = %s %v", c.Image, c.Command)
+	}
+	env := map[string]string{}
+	for _, e := range c.Env {
+		env[e.Name] = e.Value
+	}
+	want := map[string]string{
+		benchmark.TargetEnv:      "http://qwen-gateway.default.svc:8000",
+		benchmark.DurationEnv:    "2m0s",
+		benchmark.ConcurrencyEnv: "16",
+		benchmark.MaxTokensEnv:   "64",
+	}
+	for k, v := range want {
+		if env[k] != v {
+			t.Errorf("env %s = %q, want %q", k, env[k], v)
+		}
+	}
+	// 没有设置的字段交给负载生成器的默认值
+	if _, ok := env[benchmark.PromptTokensEnv]; ok {
+		t.Errorf("unset promptTokens must not be passed, got %q", env[benchmark.PromptTokensEnv])
+	}
+	if job.Spec.Template.Labels[benchmarkRunLabel] != run.Name {
+		t.Errorf("pod labels = %v", job.Spec.Template.Labels)
+	}
+}
+
+func TestJobFinished(t *testing.T) {
+	tests := []struct {
+		name               string
+		conditions         []batchv1.JobCondition
+		finished, isFailed bool
+	}{
+		{"running", nil, false, false},
+		{"complete", []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}, true, false},
+		{"failed", []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}, true, true},
+		{"suspended", []batchv1.JobCondition{{Type: batchv1.JobSuspended, Status: corev1.ConditionTrue}}, false, false},
+	}
+	for _, tt := range tests {
+		job := &batchv1.Job{Status: batchv1.JobStatus{Conditions: tt.conditions}}
+		finished, failed := jobFinished(job)
+		if finished != tt.finished || failed != tt.isFailed {
+			t.Errorf("%s: jobFinished() = %v, %v, want %v, %v", tt.name, finished, failed, tt.finished, tt.isFailed)
+		}
+	}
+}
+
+func TestParseBenchmarkResults(t *testing.T) {
+	pods := []corev1.Pod{{
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name: "benchmark",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				Message: `{"requests":120,"errors":2,"requestsPerSecond":"1.97","outputTokensPerSecond":"251.20",` +
+					`"latencyP50Ms":3900,"latencyP90Ms":5100,"latencyP99Ms":6200}`,
+			}},
+		}}},
+	}}
+	results, err := parseBenchmarkResults(terminationMessage(pods, "benchmark"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results.Requests != 120 || results.Errors != 2 || results.RequestsPerSecond != "1.97" || results.LatencyP99Ms != 6200 {
+		t.Errorf("results = %+v", results)
+	}
+
+	if _, err := parseBenchmarkResults(""); err == nil {
+		t.Error("empty termination message must be an error")
+	}
+	if _, err := parseBenchmarkResults("panic: boom"); err == nil {
+		t.Error("non-JSON termination message must be an error")
+	}
+}
@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+// desiredCacheConfigMap 生成 -cache ConfigMap，内容来自 checkCoordinatorNode 观察到的 Lease 持有者
+//
+// 格式由 pkg/cacheinfo 定义，agent 用同一个包解析
+func desiredCacheConfigMap(llm *aiv1.LLMService) (*corev1.ConfigMap, error) {
+	data, err := cacheinfo.Encode(cacheinfo.CacheInfo{
+		Coordinator:     llm.Status.CacheCoordinator,
+		CoordinatorNode: llm.Status.CoordinatorNode,
+		// 集群下载名额（download_queue.go）
+		DownloadGrantedTo: downloadGrantedTo(llm),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cacheinfo.ConfigMapName(llm.Name),
+			Namespace: llm.Namespace,
+		},
+		Data: data,
+	}, nil
+}
+
+// applyCacheInfo 把协调数据 apply 到 -cache ConfigMap
+//
+// apply 的是完整的 data，旧格式留下的 coordinator-pod 等 key 如果归我们管理会被删掉。
+// 每次 reconcile 都会走到这里（Pod 注解、节点状态变化都会触发），但内容只在 coordinator 换人、
+// 下载名额变化时才变，所以先和缓存里的 ConfigMap 比较，一样就不发请求。
+// ConfigMap 里没有心跳时间戳：coordinator 是否存活看 agent 续约的 Lease，这里不需要定期写
+func (r *LLMServiceReconciler) applyCacheInfo(ctx context.Context, llm *aiv1.LLMService) error {
+	cm, err := desiredCacheConfigMap(llm)
+	if err != nil {
+		return err
+	}
+	if upToDate, err := r.cacheInfoUpToDate(ctx, llm, cm); err != nil || upToDate {
+		return err
+	}
+	ac, err := configMapApplyConfiguration(llm, cm)
+	if err != nil {
+		return err
+	}
+	return r.apply(ctx, ac)
+}
+
+// cacheInfoUpToDate 判断集群里的 -cache ConfigMap 是否已经是 want：data 完全一致（没有旧格式的 key），
+// 而且 owner 是这个 LLMService（同名 LLMService 删掉重建之后要重新 apply owner）
+func (r *LLMServiceReconciler) cacheInfoUpToDate(ctx context.Context, llm *aiv1.LLMService, want *corev1.ConfigMap) (bool, error) {
+	current := &corev1.ConfigMap{}
+	if err := r.apiReader().Get(ctx, client.ObjectKeyFromObject(want), current); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	owner := metav1.GetControllerOf(current)
+	return owner != nil && owner.UID == llm.UID && maps.Equal(current.Data, want.Data), nil
+}
+
+// modelStatus 生成 Status.Model
+func modelStatus(llm *aiv1.LLMService) *aiv1.ModelStatus {
+	return &aiv1.ModelStatus{
+		Name:                llm.Spec.Model,
+		Runtime:             inferenceRuntime(llm),
+		EstimatedParameters: fmt.Sprintf("%gB", modelSizeBillions(llm)),
+		TensorParallelSize:  tensorParallelSize(llm),
+	}
+}
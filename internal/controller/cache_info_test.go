@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+func TestDesiredCacheConfigMap(t *testing.T) {
+	llm := testLLMService()
+	llm.Status.CacheCoordinator = "qwen-deployment-abc"
+	llm.Status.CoordinatorNode = "gpu-node-1"
+
+	cm, err := desiredCacheConfigMap(llm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cm.Name != "qwen-cache" {
+		t.Errorf("name = %s, want qwen-cache", cm.Name)
+	}
+
+	// 只写 cacheinfo 定义的 key，不再有旧的 coordinator-pod/coordinator-ip
+	if len(cm.Data) != 2 {
+		t.Errorf("unexpected keys: %v", cm.Data)
+	}
+	info, err := cacheinfo.Parse(cm.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Coordinator != "qwen-deployment-abc" || info.CoordinatorNode != "gpu-node-1" {
+		t.Errorf("unexpected cache info %+v", info)
+	}
+
+	ac, err := configMapApplyConfiguration(llm, cm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := appliedFields(t, ac)
+	for _, f := range []string{"data." + cacheinfo.SchemaVersionKey, "data." + cacheinfo.DataKey, "metadata.ownerReferences[qwen].uid"} {
+		if !fields[f] {
+			t.Errorf("expected applied field %s", f)
+		}
+	}
+}
+
+func TestApplyCacheInfoSkipsUnchanged(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = aiv1.AddToScheme(scheme)
+	applies := 0
+	c := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Apply: func(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+			applies++
+			return c.Apply(ctx, obj, opts...)
+		},
+	}).Build()
+	r := &LLMServiceReconciler{Client: c, Scheme: scheme}
+	llm := testLLMService()
+	llm.Status.CacheCoordinator = "qwen-a"
+	ctx := context.Background()
+
+	steps := []struct {
+		name        string
+		coordinator string
+		uid         types.UID
+		wantApplies int
+	}{
+		{name: "create", coordinator: "qwen-a", uid: "uid-1", wantApplies: 1},
+		// 后面的 reconcile 内容没变，不发请求
+		{name: "unchanged", coordinator: "qwen-a", uid: "uid-1", wantApplies: 1},
+		{name: "new coordinator", coordinator: "qwen-b", uid: "uid-1", wantApplies: 2},
+		// 同名 LLMService 重建，owner 要更新
+		{name: "recreated", coordinator: "qwen-b", uid: "uid-2", wantApplies: 3},
+	}
+	for _, step := range steps {
+		llm.Status.CacheCoordinator, llm.UID = step.coordinator, step.uid
+		if err := r.applyCacheInfo(ctx, llm); err != nil {
+			t.Fatal(err)
+		}
+		if applies != step.wantApplies {
+			t.Errorf("%s: %d applies, want %d", step.name, applies, step.wantApplies)
+		}
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: llm.Namespace, Name: cacheinfo.ConfigMapName(llm.Name)}, cm); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := cacheinfo.Parse(cm.Data); err != nil || info.Coordinator != "qwen-b" {
+		t.Errorf("cache info = %+v, %v, want coordinator qwen-b", info, err)
+	}
+}
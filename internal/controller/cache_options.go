@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WatchNamespacesEnv 是 manager 只监听部分 namespace 时的环境变量（逗号分隔）
+const WatchNamespacesEnv = "WATCH_NAMESPACES"
+
+// ParseNamespaces 解析逗号分隔的 namespace 列表，去掉空白和重复项
+//
+// 返回 nil 表示监听整个集群
+func ParseNamespaces(s string) []string {
+	var namespaces []string
+	seen := map[string]bool{}
+	for _, ns := range strings.Split(s, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "" || seen[ns] {
+			continue
+		}
+		seen[ns] = true
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces
+}
+
+// CacheOptions 返回 manager 的 cache 配置
+//
+// 两种运行模式：
+//
+//	namespaces 为空：监听整个集群（默认，需要 ClusterRole）
+//	namespaces 非空：只缓存这些 namespace 里的对象，配合 make rbac-namespaced 生成的 Role 使用，
+//	               不同团队各自部署一个 manager，互相看不到对方的 LLMService（软隔离）
+//
+// Node 是集群级别的对象，不受 DefaultNamespaces 影响，两种模式下都需要 nodes 的 ClusterRole。
+//
+// 不管哪种模式，Pod 只缓存 controller 会读的三类：app=llm-inference（vLLM Pod：查 coordinator 所在节点、
+// 同步 agent 错误）、app=kubeinfer-benchmark（读压测结果）和 app=kubeinfer-eval（读评测分数）。
+// 不加 selector 的话 informer 会把集群里所有 Pod 都放进内存。
+//
+// ConfigMap、Secret 同理，但用户引用的对象没有统一的 label，不能加 selector：
+// controller 只 watch 它们的 metadata（builder.OnlyMetadata），内容一律通过 APIReader 直接读，
+// 不要用缓存的 client 读 ConfigMap、Secret，否则会启动一个缓存全部内容的 informer。
+func CacheOptions(namespaces []string) cache.Options {
+	opts := cache.Options{
+		ByObject: map[client.Object]cache.ByObject{
+			&corev1.Pod{}: {
+				Label: podCacheSelector(),
+			},
+		},
+	}
+	if len(namespaces) > 0 {
+		opts.DefaultNamespaces = make(map[string]cache.Config, len(namespaces))
+		for _, ns := range namespaces {
+			opts.DefaultNamespaces[ns] = cache.Config{}
+		}
+	}
+	return opts
+}
+
+// podCacheSelector 是 app in (llm-inference, kubeinfer-benchmark, kubeinfer-eval)
+func podCacheSelector() labels.Selector {
+	req, err := labels.NewRequirement("app", selection.In, []string{inferencePodApp, benchmarkPodApp, evalPodApp})
+	if err != nil {
+		panic(err)
+	}
+	return labels.NewSelector().Add(*req)
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestParseNamespaces(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{" , ", nil},
+		{"team-a", []string{"team-a"}},
+		{"team-a, team-b,,team-a ", []string{"team-a", "team-b"}},
+	}
+	for _, tt := range tests {
+		if got := ParseNamespaces(tt.in); !slices.Equal(got, tt.want) {
+			t.Errorf("ParseNamespaces(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCacheOptions(t *testing.T) {
+	opts := CacheOptions(nil)
+	if opts.DefaultNamespaces != nil {
+		t.Errorf("cluster-wide mode must not restrict namespaces, got %v", opts.DefaultNamespaces)
+	}
+
+	opts = CacheOptions([]string{"team-a", "team-b"})
+	if len(opts.DefaultNamespaces) != 2 {
+		t.Fatalf("DefaultNamespaces = %v, want team-a and team-b", opts.DefaultNamespaces)
+	}
+	if _, ok := opts.DefaultNamespaces["team-b"]; !ok {
+		t.Error("team-b missing from DefaultNamespaces")
+	}
+
+	// Pod 缓存只保留 vLLM Pod 和压测 Pod
+	var selector labels.Selector
+	for obj, byObject := range opts.ByObject {
+		if _, ok := obj.(*corev1.Pod); ok {
+			selector = byObject.Label
+		}
+	}
+	if selector == nil {
+		t.Fatal("pods must be filtered by label")
+	}
+	llm := testLLMService()
+	if !selector.Matches(labels.Set(podLabels(llm))) {
+		t.Error("selector must match vLLM pods")
+	}
+	run := testBenchmarkRun()
+	if !selector.Matches(labels.Set(desiredBenchmarkJob(run, "http://x", "img").Spec.Template.Labels)) {
+		t.Error("selector must match benchmark pods")
+	}
+	if selector.Matches(labels.Set{"app": "something-else"}) {
+		t.Error("selector must not match unrelated pods")
+	}
+}
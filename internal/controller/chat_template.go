@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/agent/runtime"
+)
+
+// ============================================================================
+// 自定义 chat template（spec.vllm.chatTemplateConfigMapRef）
+// ============================================================================
+//
+// 很多微调模型自带的 chat template 不对（或者根本没有），vLLM 支持用 --chat-template 指定一个 Jinja 文件。
+//
+//  1. ConfigMap 以 volume 挂到每个副本的 /etc/kubeinfer/chat-template/chat_template.jinja
+//  2. agent 模式通过 VLLM_CHAT_TEMPLATE 告诉 agent，initContainer 模式直接加到 vLLM 的参数里
+//  3. ConfigMap 的内容变化时 kubelet 会更新挂载的文件，但 vLLM 只在启动时读一次，
+//     所以 controller 把内容的 checksum 写到 Pod 模板的 annotation 上，内容一变就滚动重启
+// ============================================================================
+
+const (
+	chatTemplateVolumeName = "chat-template"
+	chatTemplateMountPath  = "/etc/kubeinfer/chat-template"
+	chatTemplateFileName   = "chat_template.jinja"
+
+	// chatTemplateChecksumAnnotation 是 Pod 模板上 chat template 内容的 checksum
+	chatTemplateChecksumAnnotation = "kubeinfer.io/chat-template-checksum"
+)
+
+// chatTemplateRef 返回 chat template 的 ConfigMap 引用，没有设置时返回 nil
+func chatTemplateRef(llm *aiv1.LLMService) *aiv1.ConfigMapKeyRef {
+	if llm.Spec.VLLM == nil {
+		return nil
+	}
+	return llm.Spec.VLLM.ChatTemplateConfigMapRef
+}
+
+// chatTemplateKey 返回 ConfigMap 里的 key，未设置时是 chat_template.jinja
+func chatTemplateKey(ref *aiv1.ConfigMapKeyRef) string {
+	if ref.Key == "" {
+		return aiv1.DefaultChatTemplateKey
+	}
+	return ref.Key
+}
+
+// contentChecksum 是 SHA-256 的前 16 位十六进制
+func contentChecksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// syncChatTemplate 读 ConfigMap，把 chat template 的 checksum 记到 Status.ChatTemplateChecksum
+//
+// desiredDeployment 用这个 checksum 生成 Pod 模板的 annotation。
+// ConfigMap 或 key 不存在时返回错误：Pod 挂载不上会一直卡在 ContainerCreating，不如不更新 Deployment
+func (r *LLMServiceReconciler) syncChatTemplate(ctx context.Context, llm *aiv1.LLMService) error {
+	ref := chatTemplateRef(llm)
+	if ref == nil {
+		llm.Status.ChatTemplateChecksum = ""
+		return nil
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := r.apiReader().Get(ctx, types.NamespacedName{Namespace: llm.Namespace, Name: ref.Name}, cm); err != nil {
+		if errors.IsNotFound(err) {
+			r.chatTemplateMissing(llm, fmt.Sprintf("chat template ConfigMap %s not found", ref.Name))
+		}
+		return fmt.Errorf("failed to get chat template ConfigMap %s: %w", ref.Name, err)
+	}
+	content, ok := cm.Data[chatTemplateKey(ref)]
+	if !ok {
+		msg := fmt.Sprintf("chat template ConfigMap %s has no key %s", ref.Name, chatTemplateKey(ref))
+		r.chatTemplateMissing(llm, msg)
+		return fmt.Errorf("%s", msg)
+	}
+	llm.Status.ChatTemplateChecksum = contentChecksum(content)
+	return nil
+}
+
+// chatTemplateMissing 发 Warning Event，kubectl describe 能直接看到原因
+func (r *LLMServiceReconciler) chatTemplateMissing(llm *aiv1.LLMService, msg string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(llm, corev1.EventTypeWarning, "ChatTemplateMissing", msg)
+	}
+}
+
+// applyVLLMOptions 把 spec.vllm 加到 Pod 模板上：挂载 chat template、传 tokenizer 和额外参数
+//
+// agent 模式通过环境变量交给 agent 拼参数；initContainer 模式主容器直接运行 vLLM，追加到 Args 后面
+func applyVLLMOptions(llm *aiv1.LLMService, tpl *corev1.PodTemplateSpec) {
+	v := llm.Spec.VLLM
+	if v == nil {
+		return
+	}
+	main := &tpl.Spec.Containers[0]
+
+	var args []string
+	var env []corev1.EnvVar
+	if ref := v.ChatTemplateConfigMapRef; ref != nil {
+		file := path.Join(chatTemplateMountPath, chatTemplateFileName)
+		args = append(args, "--chat-template", file)
+		env = append(env, corev1.EnvVar{Name: "VLLM_CHAT_TEMPLATE", Value: file})
+
+		tpl.Spec.Volumes = append(tpl.Spec.Volumes, corev1.Volume{
+			Name: chatTemplateVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: ref.Name},
+					// 不管 key 叫什么，挂载出来的文件名固定
+					Items: []corev1.KeyToPath{{Key: chatTemplateKey(ref), Path: chatTemplateFileName}},
+				},
+			},
+		})
+		main.VolumeMounts = append(main.VolumeMounts, corev1.VolumeMount{
+			Name:      chatTemplateVolumeName,
+			MountPath: chatTemplateMountPath,
+			ReadOnly:  true,
+		})
+
+		if llm.Status.ChatTemplateChecksum != "" {
+			if tpl.Annotations == nil {
+				tpl.Annotations = map[string]string{}
+			}
+			tpl.Annotations[chatTemplateChecksumAnnotation] = llm.Status.ChatTemplateChecksum
+		}
+	}
+	if v.Tokenizer != "" {
+		args = append(args, "--tokenizer", v.Tokenizer)
+		env = append(env, corev1.EnvVar{Name: "VLLM_TOKENIZER", Value: v.Tokenizer})
+	}
+	if extra := vllmExtraArgs(v.ExtraArgs); len(extra) > 0 {
+		args = append(args, extra...)
+		// 用 JSON 而不是 VLLM_EXTRA_ARGS：值里可能有空格
+		data, _ := json.Marshal(extra)
+		env = append(env, corev1.EnvVar{Name: runtime.ExtraArgsJSONEnv, Value: string(data)})
+	}
+
+	if initContainerMode(llm) {
+		main.Args = append(main.Args, args...)
+	} else {
+		main.Env = append(main.Env, env...)
+	}
+}
+
+// vllmExtraArgs 把 spec.vllm.extraArgs 展开成命令行参数，值单独占一个参数
+func vllmExtraArgs(extra []aiv1.VLLMArg) []string {
+	var args []string
+	for _, a := range extra {
+		args = append(args, a.Name)
+		if a.Value != "" {
+			args = append(args, a.Value)
+		}
+	}
+	return args
+}
@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func chatTemplateLLMService() *aiv1.LLMService {
+	llm := testLLMService()
+	llm.Spec.VLLM = &aiv1.VLLMSpec{
+		ChatTemplateConfigMapRef: &aiv1.ConfigMapKeyRef{Name: "qwen-template", Key: "template.jinja"},
+		Tokenizer:                "Qwen/Qwen2.5-7B-Instruct",
+	}
+	llm.Status.ChatTemplateChecksum = contentChecksum("{{ messages }}")
+	return llm
+}
+
+func TestChatTemplateAgentMode(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := chatTemplateLLMService()
+
+	d := r.desiredDeployment(llm)
+	tpl := d.Spec.Template
+	main := tpl.Spec.Containers[0]
+
+	wantEnv := map[string]string{
+		"VLLM_CHAT_TEMPLATE": "/etc/kubeinfer/chat-template/chat_template.jinja",
+		"VLLM_TOKENIZER":     "Qwen/Qwen2.5-7B-Instruct",
+	}
+	for name, value := range wantEnv {
+		if !slices.ContainsFunc(main.Env, func(e corev1.EnvVar) bool { return e.Name == name && e.Value == value }) {
+			t.Errorf("agent env is missing %s=%s", name, value)
+		}
+	}
+	i := slices.IndexFunc(tpl.Spec.Volumes, func(v corev1.Volume) bool { return v.Name == chatTemplateVolumeName })
+	if i < 0 || tpl.Spec.Volumes[i].ConfigMap == nil || tpl.Spec.Volumes[i].ConfigMap.Name != "qwen-template" ||
+		tpl.Spec.Volumes[i].ConfigMap.Items[0].Key != "template.jinja" {
+		t.Fatalf("volumes = %+v, want the chat template ConfigMap", tpl.Spec.Volumes)
+	}
+	if !slices.ContainsFunc(main.VolumeMounts, func(m corev1.VolumeMount) bool { return m.Name == chatTemplateVolumeName }) {
+		t.Error("agent container does not mount the chat template")
+	}
+	if tpl.Annotations[chatTemplateChecksumAnnotation] != llm.Status.ChatTemplateChecksum {
+		t.Errorf("pod annotations = %v, want the checksum", tpl.Annotations)
+	}
+
+	// 内容变化 → checksum 变化 → Pod 模板 hash 变化（滚动重启）
+	llm.Status.ChatTemplateChecksum = contentChecksum("{{ messages | tojson }}")
+	if r.desiredDeployment(llm).Annotations[templateHashAnnotation] == d.Annotations[templateHashAnnotation] {
+		t.Error("changing the chat template must change the pod template hash")
+	}
+}
+
+func TestChatTemplateInitContainerMode(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := chatTemplateLLMService()
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer}
+
+	spec := r.desiredDeployment(llm).Spec.Template.Spec
+	args := spec.Containers[0].Args
+	if i := slices.Index(args, "--chat-template"); i < 0 || args[i+1] != "/etc/kubeinfer/chat-template/chat_template.jinja" {
+		t.Errorf("vLLM args = %v, want --chat-template", args)
+	}
+	if !slices.Contains(args, "--tokenizer") {
+		t.Errorf("vLLM args = %v, want --tokenizer", args)
+	}
+	for _, c := range spec.InitContainers {
+		if slices.ContainsFunc(c.VolumeMounts, func(m corev1.VolumeMount) bool { return m.Name == chatTemplateVolumeName }) {
+			t.Errorf("%s must not mount the chat template", c.Name)
+		}
+	}
+}
+
+func TestVLLMExtraArgs(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	llm.Spec.VLLM = &aiv1.VLLMSpec{ExtraArgs: []aiv1.VLLMArg{
+		{Name: "--enable-prefix-caching"},
+		{Name: "--override-generation-config", Value: `{"temperature": 0.6}`},
+	}}
+	want := []string{"--enable-prefix-caching", "--override-generation-config", `{"temperature": 0.6}`}
+
+	main := r.desiredDeployment(llm).Spec.Template.Spec.Containers[0]
+	i := slices.IndexFunc(main.Env, func(e corev1.EnvVar) bool { return e.Name == "VLLM_EXTRA_ARGS_JSON" })
+	if i < 0 {
+		t.Fatalf("agent env = %v, want VLLM_EXTRA_ARGS_JSON", main.Env)
+	}
+	var got []string
+	if err := json.Unmarshal([]byte(main.Env[i].Value), &got); err != nil || !slices.Equal(got, want) {
+		t.Errorf("VLLM_EXTRA_ARGS_JSON = %s, want %v", main.Env[i].Value, want)
+	}
+
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer}
+	args := r.desiredDeployment(llm).Spec.Template.Spec.Containers[0].Args
+	if len(args) < len(want) || !slices.Equal(args[len(args)-len(want):], want) {
+		t.Errorf("vLLM args = %v, want to end with %v", args, want)
+	}
+}
+
+func TestSyncChatTemplateWithoutRef(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	llm.Status.ChatTemplateChecksum = "stale"
+	if err := r.syncChatTemplate(context.Background(), llm); err != nil {
+		t.Fatal(err)
+	}
+	if llm.Status.ChatTemplateChecksum != "" {
+		t.Errorf("checksum = %q, want cleared after removing the ref", llm.Status.ChatTemplateChecksum)
+	}
+	if _, ok := r.desiredDeployment(llm).Spec.Template.Annotations[chatTemplateChecksumAnnotation]; ok {
+		t.Error("pod template must not have a checksum without a chat template")
+	}
+}
@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	batchv1ac "k8s.io/client-go/applyconfigurations/batch/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// 下载断点（spec.storage.checkpoint）
+// ============================================================================
+//
+// follower 的模型目录是 emptyDir，Pod 被驱逐后同步了一半的文件跟着丢掉。配置了 checkpoint PVC 时：
+//   - PVC 挂到主容器的 /checkpoint，agent 收到 MODEL_CHECKPOINT_DIR，先同步到 PVC 上自己的目录，
+//     完成后再搬到模型目录；重新调度的 Pod 认领被驱逐的 Pod 留下的目录接着下载
+//     （internal/agent/distribution/checkpoint.go）
+//   - 没等到完成、也没有新 Pod 认领的目录（缩容、换了模型）由 <name>-checkpoint-janitor CronJob 清理：
+//     每小时运行一次 agent 的 janitor 子命令，删除超过 TTL 没有变化的目录
+//
+// 只用于 flat 布局、HTTP 传输的 agent 模式：共享模型卷、nodeCache 不需要传输或者本来就在节点上，
+// versioned 布局的 revision 目录本来就是增量同步的，zone topology、initContainer 模式由 webhook 拒绝。
+// ============================================================================
+
+//+kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
+
+const (
+	// checkpointMountPath 是 checkpoint PVC 在容器里的挂载路径
+	checkpointMountPath = "/checkpoint"
+	// checkpointVolumeName 是 checkpoint PVC 的卷名
+	checkpointVolumeName = "download-checkpoint"
+	// defaultCheckpointTTL 是没有设置 spec.storage.checkpoint.ttl 时断点目录的保留时间
+	defaultCheckpointTTL = 24 * time.Hour
+	// checkpointJanitorSchedule 是 janitor CronJob 的运行时间：每小时一次
+	checkpointJanitorSchedule = "17 * * * *"
+)
+
+// checkpointSpec 返回 spec.storage.checkpoint，没有配置时返回 nil
+func checkpointSpec(llm *aiv1.LLMService) *aiv1.CheckpointSpec {
+	if s := llm.Spec.Storage; s != nil && s.Checkpoint != nil && s.Checkpoint.ClaimName != "" {
+		return s.Checkpoint
+	}
+	return nil
+}
+
+// checkpointTTL 返回断点目录的保留时间
+func checkpointTTL(c *aiv1.CheckpointSpec) time.Duration {
+	if c.TTL != nil && c.TTL.Duration > 0 {
+		return c.TTL.Duration
+	}
+	return defaultCheckpointTTL
+}
+
+// checkpointJanitorName 返回清理断点目录的 CronJob 名称
+func checkpointJanitorName(llm *aiv1.LLMService) string {
+	return llm.Name + "-checkpoint-janitor"
+}
+
+// checkpointVolume 返回 checkpoint PVC 的卷
+func checkpointVolume(c *aiv1.CheckpointSpec) corev1.Volume {
+	return corev1.Volume{
+		Name: checkpointVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: c.ClaimName},
+		},
+	}
+}
+
+// applyCheckpoint 把 checkpoint PVC 挂到主容器（agent）上
+func applyCheckpoint(llm *aiv1.LLMService, podSpec *corev1.PodSpec) {
+	c := checkpointSpec(llm)
+	if c == nil {
+		return
+	}
+	podSpec.Volumes = append(podSpec.Volumes, checkpointVolume(c))
+	main := &podSpec.Containers[0]
+	main.VolumeMounts = append(main.VolumeMounts, corev1.VolumeMount{Name: checkpointVolumeName, MountPath: checkpointMountPath})
+}
+
+// checkpointEnv 告诉 agent 断点目录的位置（internal/agent/distribution.CheckpointDirFromEnv）
+func checkpointEnv(llm *aiv1.LLMService) []corev1.EnvVar {
+	if checkpointSpec(llm) == nil {
+		return nil
+	}
+	return []corev1.EnvVar{{Name: "MODEL_CHECKPOINT_DIR", Value: checkpointMountPath}}
+}
+
+// desiredCheckpointJanitor 生成按 TTL 清理断点目录的 CronJob
+//
+// 用 agent 镜像的 janitor 子命令，不需要 ServiceAccount 权限；上一次还没跑完时跳过这一次
+func (r *LLMServiceReconciler) desiredCheckpointJanitor(llm *aiv1.LLMService) *batchv1.CronJob {
+	c := checkpointSpec(llm)
+	labels := map[string]string{"app": "checkpoint-janitor", "llm_cr": llm.Name}
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      checkpointJanitorName(llm),
+			Namespace: llm.Namespace,
+			Labels:    labels,
+		},
+	}
+	if c == nil {
+		return cronJob
+	}
+
+	backoffLimit := int32(1)
+	history := int32(1)
+	cronJob.Spec = batchv1.CronJobSpec{
+		Schedule:                   checkpointJanitorSchedule,
+		ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+		SuccessfulJobsHistoryLimit: &history,
+		FailedJobsHistoryLimit:     &history,
+		JobTemplate: batchv1.JobTemplateSpec{
+			Spec: batchv1.JobSpec{
+				BackoffLimit: &backoffLimit,
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec: corev1.PodSpec{
+						RestartPolicy: corev1.RestartPolicyNever,
+						Containers: []corev1.Container{{
+							Name:            "janitor",
+							Image:           r.agentImage(llm),
+							ImagePullPolicy: corev1.PullIfNotPresent,
+							Command: []string{"/agent", "janitor",
+								"--dir=" + checkpointMountPath, "--ttl=" + checkpointTTL(c).String()},
+							VolumeMounts:             []corev1.VolumeMount{{Name: checkpointVolumeName, MountPath: checkpointMountPath}},
+							TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("50m"),
+									corev1.ResourceMemory: resource.MustParse("32Mi"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("128Mi"),
+								},
+							},
+						}},
+						Volumes: []corev1.Volume{checkpointVolume(c)},
+					},
+				},
+			},
+		},
+	}
+	return cronJob
+}
+
+// cronJobApplyConfiguration 把期望的 CronJob 转成 apply configuration
+func cronJobApplyConfiguration(llm *aiv1.LLMService, cj *batchv1.CronJob) (*batchv1ac.CronJobApplyConfiguration, error) {
+	ac := &batchv1ac.CronJobApplyConfiguration{}
+	if err := convertToApply(cj, ac); err != nil {
+		return nil, err
+	}
+	ac.WithAPIVersion("batch/v1").WithKind("CronJob").WithOwnerReferences(ownerReference(llm))
+	return ac, nil
+}
+
+// reconcileCheckpointJanitor 配置了下载断点时 apply janitor CronJob，否则删除
+//
+// 去掉 spec.storage.checkpoint 之后 PVC 上的目录留着（PVC 归用户管）
+func (r *LLMServiceReconciler) reconcileCheckpointJanitor(ctx context.Context, llm *aiv1.LLMService) error {
+	cronJob := r.desiredCheckpointJanitor(llm)
+	if checkpointSpec(llm) == nil {
+		if err := r.deleteIfExists(ctx, cronJob); err != nil {
+			return fmt.Errorf("failed to delete checkpoint janitor: %w", err)
+		}
+		return nil
+	}
+	ac, err := cronJobApplyConfiguration(llm, cronJob)
+	if err != nil {
+		return err
+	}
+	if err := r.apply(ctx, ac); err != nil {
+		return fmt.Errorf("failed to apply checkpoint janitor: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestDownloadCheckpoint(t *testing.T) {
+	r := &LLMServiceReconciler{}
+
+	// 默认：没有断点卷，janitor CronJob 不需要（reconcile 时删除）
+	llm := testLLMService()
+	spec := r.desiredDeployment(llm).Spec.Template.Spec
+	if slices.ContainsFunc(spec.Volumes, func(v corev1.Volume) bool { return v.Name == checkpointVolumeName }) {
+		t.Error("checkpoint volume added without spec.storage.checkpoint")
+	}
+	if _, n := envValue(spec.Containers[0].Env, "MODEL_CHECKPOINT_DIR"); n != 0 {
+		t.Error("MODEL_CHECKPOINT_DIR must not be set without spec.storage.checkpoint")
+	}
+
+	// 配置了断点 PVC：挂到 agent 上，janitor 按 TTL 清理
+	llm.Spec.Storage = &aiv1.StorageSpec{Checkpoint: &aiv1.CheckpointSpec{
+		ClaimName: "checkpoints",
+		TTL:       &metav1.Duration{Duration: 6 * time.Hour},
+	}}
+	spec = r.desiredDeployment(llm).Spec.Template.Spec
+	i := slices.IndexFunc(spec.Volumes, func(v corev1.Volume) bool { return v.Name == checkpointVolumeName })
+	if i < 0 || spec.Volumes[i].PersistentVolumeClaim == nil || spec.Volumes[i].PersistentVolumeClaim.ClaimName != "checkpoints" {
+		t.Fatalf("volumes = %+v, want the checkpoint PVC", spec.Volumes)
+	}
+	if !slices.ContainsFunc(spec.Containers[0].VolumeMounts, func(m corev1.VolumeMount) bool {
+		return m.Name == checkpointVolumeName && m.MountPath == checkpointMountPath
+	}) {
+		t.Errorf("agent mounts = %+v, want the checkpoint PVC at %s", spec.Containers[0].VolumeMounts, checkpointMountPath)
+	}
+	if v, _ := envValue(spec.Containers[0].Env, "MODEL_CHECKPOINT_DIR"); v != checkpointMountPath {
+		t.Errorf("MODEL_CHECKPOINT_DIR = %q, want %s", v, checkpointMountPath)
+	}
+
+	janitor := r.desiredCheckpointJanitor(llm)
+	pod := janitor.Spec.JobTemplate.Spec.Template.Spec
+	if len(pod.Containers) != 1 || !slices.Contains(pod.Containers[0].Command, "--ttl=6h0m0s") {
+		t.Fatalf("janitor containers = %+v, want the janitor with --ttl=6h0m0s", pod.Containers)
+	}
+	if len(pod.Volumes) != 1 || pod.Volumes[0].PersistentVolumeClaim.ClaimName != "checkpoints" {
+		t.Errorf("janitor volumes = %+v, want the checkpoint PVC", pod.Volumes)
+	}
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// findCondition 按类型查找 condition，找不到返回 nil
+func findCondition(llm *aiv1.LLMService, condType string) *aiv1.LLMServiceCondition {
+	for i := range llm.Status.Conditions {
+		if llm.Status.Conditions[i].Type == condType {
+			return &llm.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// isConditionTrue 判断某个 condition 是否为 True
+func isConditionTrue(llm *aiv1.LLMService, condType string) bool {
+	cond := findCondition(llm, condType)
+	return cond != nil && cond.Status == string(metav1.ConditionTrue)
+}
+
+// setCondition 设置或更新一个 condition
+//
+// 只有 Status 真正变化时才刷新 LastUpdateTime，
+// 这样 LastUpdateTime 就可以当作"状态切换时间"来用（例如休眠开始时间）。
+func setCondition(llm *aiv1.LLMService, condType string, status metav1.ConditionStatus, reason, message string) {
+	cond := findCondition(llm, condType)
+	if cond == nil {
+		llm.Status.Conditions = append(llm.Status.Conditions, aiv1.LLMServiceCondition{
+			Type:           condType,
+			Status:         string(status),
+			Reason:         reason,
+			Message:        message,
+			LastUpdateTime: metav1.Now(),
+		})
+		return
+	}
+
+	if cond.Status != string(status) {
+		cond.LastUpdateTime = metav1.Now()
+	}
+	cond.Status = string(status)
+	cond.Reason = reason
+	cond.Message = message
+}
@@ -0,0 +1,207 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// 引用的 ConfigMap/Secret 内容变化时滚动重启
+// ============================================================================
+//
+// 环境变量只在容器启动时读一次，挂载的文件 kubelet 虽然会更新，但 agent、vLLM 也只在启动时读：
+// HF_TOKEN 的 Secret 换了 token、spec.envFrom 的 ConfigMap 改了配置，已经在跑的 Pod 一直用旧的。
+// 和 chat template 一样（chat_template.go），controller 把内容的 checksum 写到 Pod 模板的 annotation 上：
+//
+//  1. 引用从生成的 Pod 模板里找：所有容器的 env valueFrom、envFrom，ConfigMap/Secret/projected 卷，
+//     用户写的（spec.env、spec.envFrom）和 controller 生成的（校验公钥、快照凭证）都算
+//  2. syncConfigChecksum 读这些对象，把内容的 checksum 记到 Status.ConfigChecksum，
+//     desiredDeployment 据此生成 annotation，内容一变模板就变，按 UpdateWindow 滚动重启
+//  3. ConfigMap、Secret 变化时把引用它的 LLMService 放回队列
+//
+// ConfigMap、Secret 只 watch metadata（builder.OnlyMetadata），内容通过 APIReader 直接读：
+// 普通的 watch 会让 informer 把集群里所有 ConfigMap、Secret 都放进内存。
+// metadata 看不出 data 有没有变，任何更新都会触发 reconcile，checksum 没变时不会改 Deployment。
+//
+// controller 自己生成的 token Secret 不算（创建之后不会变）。不存在的对象按"不存在"算进 checksum，
+// 之后创建出来也会触发重启（optional 的引用创建后才能生效）。
+// ============================================================================
+
+// configChecksumAnnotation 是 Pod 模板上引用的 ConfigMap/Secret 内容的 checksum
+const configChecksumAnnotation = "kubeinfer.io/config-checksum"
+
+// configRefs 是 Pod 模板引用的 ConfigMap 和 Secret 名称（排好序、去重）
+type configRefs struct {
+	configMaps []string
+	secrets    []string
+}
+
+// podConfigRefs 找出 Pod 模板引用的 ConfigMap 和 Secret
+func podConfigRefs(spec *corev1.PodSpec) configRefs {
+	var refs configRefs
+	containers := slices.Concat(spec.InitContainers, spec.Containers)
+	for _, c := range containers {
+		for _, e := range c.Env {
+			if e.ValueFrom == nil {
+				continue
+			}
+			if ref := e.ValueFrom.ConfigMapKeyRef; ref != nil {
+				refs.configMaps = append(refs.configMaps, ref.Name)
+			}
+			if ref := e.ValueFrom.SecretKeyRef; ref != nil {
+				refs.secrets = append(refs.secrets, ref.Name)
+			}
+		}
+		for _, from := range c.EnvFrom {
+			if from.ConfigMapRef != nil {
+				refs.configMaps = append(refs.configMaps, from.ConfigMapRef.Name)
+			}
+			if from.SecretRef != nil {
+				refs.secrets = append(refs.secrets, from.SecretRef.Name)
+			}
+		}
+	}
+	for _, v := range spec.Volumes {
+		switch {
+		case v.ConfigMap != nil:
+			refs.configMaps = append(refs.configMaps, v.ConfigMap.Name)
+		case v.Secret != nil:
+			refs.secrets = append(refs.secrets, v.Secret.SecretName)
+		case v.Projected != nil:
+			for _, src := range v.Projected.Sources {
+				if src.ConfigMap != nil {
+					refs.configMaps = append(refs.configMaps, src.ConfigMap.Name)
+				}
+				if src.Secret != nil {
+					refs.secrets = append(refs.secrets, src.Secret.Name)
+				}
+			}
+		}
+	}
+	slices.Sort(refs.configMaps)
+	slices.Sort(refs.secrets)
+	refs.configMaps = slices.Compact(refs.configMaps)
+	refs.secrets = slices.Compact(refs.secrets)
+	return refs
+}
+
+// configRefsOf 返回 LLMService 推理 Pod 引用的 ConfigMap 和 Secret
+//
+// controller 生成的 token Secret 创建之后就不会变，不算：只有它们的 LLMService 不需要 annotation
+func (r *LLMServiceReconciler) configRefsOf(llm *aiv1.LLMService) configRefs {
+	refs := podConfigRefs(&r.desiredDeployment(llm).Spec.Template.Spec)
+	refs.secrets = slices.DeleteFunc(refs.secrets, func(name string) bool {
+		return name == modelServerSecretName(llm) || name == agentDebugSecretName(llm)
+	})
+	return refs
+}
+
+// syncConfigChecksum 读引用的 ConfigMap、Secret，把内容的 checksum 记到 Status.ConfigChecksum
+func (r *LLMServiceReconciler) syncConfigChecksum(ctx context.Context, llm *aiv1.LLMService) error {
+	refs := r.configRefsOf(llm)
+	if len(refs.configMaps) == 0 && len(refs.secrets) == 0 {
+		llm.Status.ConfigChecksum = ""
+		return nil
+	}
+
+	// key 是 kind/name，值是内容；不存在的对象值为 nil。json.Marshal 按 key 排序，结果是确定的
+	contents := map[string]any{}
+	for _, name := range refs.configMaps {
+		cm := &corev1.ConfigMap{}
+		err := r.apiReader().Get(ctx, types.NamespacedName{Namespace: llm.Namespace, Name: name}, cm)
+		switch {
+		case errors.IsNotFound(err):
+			contents["ConfigMap/"+name] = nil
+		case err != nil:
+			return fmt.Errorf("failed to get ConfigMap %s: %w", name, err)
+		default:
+			contents["ConfigMap/"+name] = []any{cm.Data, cm.BinaryData}
+		}
+	}
+	for _, name := range refs.secrets {
+		secret := &corev1.Secret{}
+		err := r.apiReader().Get(ctx, types.NamespacedName{Namespace: llm.Namespace, Name: name}, secret)
+		switch {
+		case errors.IsNotFound(err):
+			contents["Secret/"+name] = nil
+		case err != nil:
+			return fmt.Errorf("failed to get Secret %s: %w", name, err)
+		default:
+			contents["Secret/"+name] = secret.Data
+		}
+	}
+	data, err := json.Marshal(contents)
+	if err != nil {
+		return err
+	}
+	llm.Status.ConfigChecksum = contentChecksum(string(data))
+	return nil
+}
+
+// applyConfigChecksum 把 Status.ConfigChecksum 写到 Pod 模板的 annotation 上
+func applyConfigChecksum(llm *aiv1.LLMService, tpl *corev1.PodTemplateSpec) {
+	if llm.Status.ConfigChecksum == "" {
+		return
+	}
+	if tpl.Annotations == nil {
+		tpl.Annotations = map[string]string{}
+	}
+	tpl.Annotations[configChecksumAnnotation] = llm.Status.ConfigChecksum
+}
+
+// configMapToLLMServices 把 ConfigMap 事件映射到引用它的 LLMService（chat template 的 ConfigMap 也是挂载的卷）
+func (r *LLMServiceReconciler) configMapToLLMServices(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.configObjectToLLMServices(ctx, obj, false)
+}
+
+// secretToLLMServices 把 Secret 事件映射到引用它的 LLMService
+func (r *LLMServiceReconciler) secretToLLMServices(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.configObjectToLLMServices(ctx, obj, true)
+}
+
+// configObjectToLLMServices 把 ConfigMap（isSecret 为 false）或 Secret 的事件映射到引用它的 LLMService
+//
+// watch 只有 metadata，obj 是 PartialObjectMetadata，所以类型由调用方传进来
+func (r *LLMServiceReconciler) configObjectToLLMServices(ctx context.Context, obj client.Object, isSecret bool) []reconcile.Request {
+	list := &aiv1.LLMServiceList{}
+	if err := r.List(ctx, list, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list LLMServices for ConfigMap/Secret event")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range list.Items {
+		llm := &list.Items[i]
+		refs := r.configRefsOf(llm)
+		names := refs.configMaps
+		if isSecret {
+			names = refs.secrets
+		}
+		if slices.Contains(names, obj.GetName()) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: llm.Name, Namespace: llm.Namespace},
+			})
+		}
+	}
+	return requests
+}
+
+// userConfigObject 跳过 controller 自己生成的对象（-cache ConfigMap、token Secret）：
+// 它们不在 configRefsOf 里，-cache ConfigMap 又更新得很频繁
+var userConfigObject = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	owner := metav1.GetControllerOf(obj)
+	return owner == nil || owner.Kind != "LLMService" || owner.APIVersion != aiv1.GroupVersion.String()
+})
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestReferencedConfigChecksum(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = aiv1.AddToScheme(scheme)
+
+	hfToken := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "hf-token", Namespace: "default"},
+		Data:       map[string][]byte{"HF_TOKEN": []byte("hf_old")},
+	}
+	proxy := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "proxy", Namespace: "default"},
+		Data:       map[string]string{"HTTPS_PROXY": "http://proxy:3128"},
+	}
+	unrelated := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "default"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(hfToken, proxy, unrelated).Build()
+	r := &LLMServiceReconciler{Client: c}
+	ctx := context.Background()
+
+	llm := testLLMService()
+	llm.Spec.Env = []corev1.EnvVar{{Name: "HF_TOKEN", ValueFrom: &corev1.EnvVarSource{
+		SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "hf-token"}, Key: "HF_TOKEN"},
+	}}}
+	llm.Spec.EnvFrom = []corev1.EnvFromSource{{ConfigMapRef: &corev1.ConfigMapEnvSource{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "proxy"},
+	}}}
+	if err := c.Create(ctx, llm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.syncConfigChecksum(ctx, llm); err != nil {
+		t.Fatal(err)
+	}
+	before := llm.Status.ConfigChecksum
+	tpl := r.desiredDeployment(llm).Spec.Template
+	if before == "" || tpl.Annotations[configChecksumAnnotation] != before {
+		t.Fatalf("pod template annotations = %v, want %s = %q", tpl.Annotations, configChecksumAnnotation, before)
+	}
+
+	// 换了 token：checksum 变，Pod 模板跟着变
+	hfToken.Data["HF_TOKEN"] = []byte("hf_new")
+	if err := c.Update(ctx, hfToken); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.syncConfigChecksum(ctx, llm); err != nil {
+		t.Fatal(err)
+	}
+	if llm.Status.ConfigChecksum == before {
+		t.Errorf("checksum unchanged after rotating the HF token")
+	}
+
+	// 事件只映射到引用它的 LLMService
+	if reqs := r.secretToLLMServices(ctx, hfToken); len(reqs) != 1 || reqs[0].Name != llm.Name {
+		t.Errorf("hf-token event mapped to %v, want %s", reqs, llm.Name)
+	}
+	if reqs := r.configMapToLLMServices(ctx, proxy); len(reqs) != 1 {
+		t.Errorf("proxy event mapped to %v, want %s", reqs, llm.Name)
+	}
+	if reqs := r.secretToLLMServices(ctx, unrelated); len(reqs) != 0 {
+		t.Errorf("unrelated Secret event mapped to %v, want none", reqs)
+	}
+
+	// 什么都不引用时不写 annotation
+	llm.Spec.Env, llm.Spec.EnvFrom = nil, nil
+	if err := r.syncConfigChecksum(ctx, llm); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.desiredDeployment(llm).Spec.Template.Annotations[configChecksumAnnotation]; ok {
+		t.Errorf("pod template without references has %s", configChecksumAnnotation)
+	}
+}
@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/audit"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+// ============================================================================
+// 人工固定 coordinator（spec.coordination.pinnedPod）
+// ============================================================================
+//
+// 排障（想在某个 Pod 上看 coordinator 的行为）或者性能调优（某个节点网卡特别快）时，
+// 需要绕过选举直接指定 coordinator。controller 不自己改 lease 的持有者（agent 的角色切换看不到），
+// 而是把 Pod 名写到 lease 的 cacheinfo.PinnedCoordinatorAnnotation 注解上，由 agent 执行：
+//   - 被固定的 Pod：lease 不在自己手里时马上接管，不等过期（原来的 coordinator 下一次续约时发现换了人）
+//   - 其他 Pod：接管过期的 lease 之前多等一个 leaseDuration，给被固定的 Pod 让路
+//
+// 只有 Pod 属于这个 LLMService、在运行、没有在删除、所在节点 Ready 时才写注解；
+// 不满足时删掉注解恢复正常选举（不能让所有 Pod 都等一个不会来的 coordinator），
+// CoordinatorPinned=False 说明原因。
+// ============================================================================
+
+// pinnedPod 返回 spec.coordination.pinnedPod，没有设置时返回空
+func pinnedPod(llm *aiv1.LLMService) string {
+	if c := llm.Spec.Coordination; c != nil {
+		return c.PinnedPod
+	}
+	return ""
+}
+
+// reconcileCoordinatorPin 把 pin 同步到 lease 注解上，更新 CoordinatorPinned
+//
+// 要在 checkCoordinatorNode 之后调用，持有者以 Status.CacheCoordinator 为准
+func (r *LLMServiceReconciler) reconcileCoordinatorPin(ctx context.Context, llm *aiv1.LLMService) error {
+	pinned := pinnedPod(llm)
+	if pinned == "" && findCondition(llm, aiv1.ConditionCoordinatorPinned) != nil {
+		setCondition(llm, aiv1.ConditionCoordinatorPinned, metav1.ConditionFalse,
+			"NotPinned", "spec.coordination.pinnedPod is not set, the coordinator is elected")
+	}
+
+	lease := &coordinationv1.Lease{}
+	err := r.Get(ctx, types.NamespacedName{Name: leaseName(llm), Namespace: llm.Namespace}, lease)
+	if errors.IsNotFound(err) {
+		// 还没有 agent 参与选举，lease 创建之后再写注解
+		if pinned != "" {
+			setCondition(llm, aiv1.ConditionCoordinatorPinned, metav1.ConditionFalse,
+				"Pending", "no agent has created the coordinator lease yet")
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get coordinator lease: %w", err)
+	}
+
+	// trigger 是审计记录里的原因分类：spec 改了，或者 Pod 不满足条件
+	want, trigger := "", triggerSpec
+	if pinned != "" {
+		reason, message, err := r.pinUnsatisfiable(ctx, llm, pinned)
+		if err != nil {
+			return err
+		}
+		if reason == "" {
+			want = pinned
+		} else {
+			trigger = reason
+			setCondition(llm, aiv1.ConditionCoordinatorPinned, metav1.ConditionFalse, reason, message)
+		}
+	}
+
+	if current := lease.Annotations[cacheinfo.PinnedCoordinatorAnnotation]; current != want {
+		if want == "" {
+			delete(lease.Annotations, cacheinfo.PinnedCoordinatorAnnotation)
+		} else {
+			if lease.Annotations == nil {
+				lease.Annotations = map[string]string{}
+			}
+			lease.Annotations[cacheinfo.PinnedCoordinatorAnnotation] = want
+		}
+		if err := r.Update(ctx, lease); err != nil {
+			return fmt.Errorf("failed to update coordinator pin: %w", err)
+		}
+		message := fmt.Sprintf("coordinator pinned to pod %s", want)
+		if want == "" {
+			message = fmt.Sprintf("coordinator pin on pod %s removed, back to election", current)
+		}
+		log.FromContext(ctx).Info("Updated coordinator pin", "pinnedPod", want, "previous", current)
+		if r.Recorder != nil {
+			r.Recorder.Event(llm, corev1.EventTypeNormal, "CoordinatorPinned", message)
+		}
+		r.recordAudit(ctx, llm, audit.ActionCoordinatorPinned, trigger, message)
+	}
+
+	if want == "" {
+		return nil
+	}
+	if llm.Status.CacheCoordinator == want {
+		setCondition(llm, aiv1.ConditionCoordinatorPinned, metav1.ConditionTrue,
+			"Pinned", fmt.Sprintf("pod %s is the coordinator", want))
+	} else {
+		setCondition(llm, aiv1.ConditionCoordinatorPinned, metav1.ConditionFalse,
+			"Pending", fmt.Sprintf("waiting for pod %s to take over the coordinator lease", want))
+	}
+	return nil
+}
+
+// pinUnsatisfiable 检查被固定的 Pod 能不能当 coordinator，能的时候 reason 为空
+func (r *LLMServiceReconciler) pinUnsatisfiable(ctx context.Context, llm *aiv1.LLMService, name string) (reason, message string, err error) {
+	pod := &corev1.Pod{}
+	err = r.Get(ctx, types.NamespacedName{Name: name, Namespace: llm.Namespace}, pod)
+	if errors.IsNotFound(err) {
+		return "PodNotFound", fmt.Sprintf("pinned pod %s does not exist", name), nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get pinned pod: %w", err)
+	}
+	if !labels.SelectorFromSet(podLabels(llm)).Matches(labels.Set(pod.Labels)) {
+		return "PodNotMember", fmt.Sprintf("pinned pod %s is not an inference pod of %s", name, llm.Name), nil
+	}
+	if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+		return "PodNotRunning", fmt.Sprintf("pinned pod %s is not running", name), nil
+	}
+	node := &corev1.Node{}
+	if err := r.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+		if !errors.IsNotFound(err) {
+			return "", "", fmt.Errorf("failed to get pinned pod node: %w", err)
+		}
+		return "NodeNotReady", fmt.Sprintf("node %s of pinned pod %s was removed", pod.Spec.NodeName, name), nil
+	}
+	if !nodeReady(node) {
+		return "NodeNotReady", fmt.Sprintf("node %s of pinned pod %s is NotReady", pod.Spec.NodeName, name), nil
+	}
+	return "", "", nil
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+func TestPinnedCoordinatorPod(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = aiv1.AddToScheme(scheme)
+	ctx := context.Background()
+
+	llm := testLLMService()
+	holder := "qwen-a"
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: leaseName(llm), Namespace: llm.Namespace},
+		Spec:       coordinationv1.LeaseSpec{HolderIdentity: &holder},
+	}
+	pod := func(name, node string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: llm.Namespace, Labels: labels},
+			Spec:       corev1.PodSpec{NodeName: node},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	node := func(name string, ready corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}},
+		}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		lease,
+		pod("qwen-b", "gpu-1", podLabels(llm)),
+		pod("qwen-c", "gpu-2", podLabels(llm)),
+		pod("other", "gpu-1", map[string]string{"app": "something-else"}),
+		node("gpu-1", corev1.ConditionTrue),
+		node("gpu-2", corev1.ConditionFalse),
+	).Build()
+	r := &LLMServiceReconciler{Client: c}
+	annotation := func() string {
+		got := &coordinationv1.Lease{}
+		if err := c.Get(ctx, types.NamespacedName{Name: leaseName(llm), Namespace: llm.Namespace}, got); err != nil {
+			t.Fatal(err)
+		}
+		return got.Annotations[cacheinfo.PinnedCoordinatorAnnotation]
+	}
+	reason := func() string {
+		if cond := findCondition(llm, aiv1.ConditionCoordinatorPinned); cond != nil {
+			return cond.Reason
+		}
+		return ""
+	}
+
+	tests := []struct {
+		name           string
+		pinned         string
+		coordinator    string
+		wantAnnotation string
+		wantReason     string
+	}{
+		{name: "pin applied, waiting for takeover", pinned: "qwen-b", coordinator: "qwen-a", wantAnnotation: "qwen-b", wantReason: "Pending"},
+		{name: "pinned pod took over", pinned: "qwen-b", coordinator: "qwen-b", wantAnnotation: "qwen-b", wantReason: "Pinned"},
+		{name: "node not ready", pinned: "qwen-c", coordinator: "qwen-b", wantReason: "NodeNotReady"},
+		{name: "not an inference pod", pinned: "other", coordinator: "qwen-b", wantReason: "PodNotMember"},
+		{name: "pod gone", pinned: "qwen-z", coordinator: "qwen-b", wantReason: "PodNotFound"},
+		{name: "pin removed", coordinator: "qwen-b", wantReason: "NotPinned"},
+	}
+	for _, tt := range tests {
+		llm.Spec.Coordination = &aiv1.CoordinationSpec{PinnedPod: tt.pinned}
+		llm.Status.CacheCoordinator = tt.coordinator
+		if err := r.reconcileCoordinatorPin(ctx, llm); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := annotation(); got != tt.wantAnnotation {
+			t.Errorf("%s: lease annotation = %q, want %q", tt.name, got, tt.wantAnnotation)
+		}
+		if got := reason(); got != tt.wantReason {
+			t.Errorf("%s: CoordinatorPinned reason = %q, want %q", tt.name, got, tt.wantReason)
+		}
+	}
+}
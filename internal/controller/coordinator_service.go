@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+// ============================================================================
+// coordinator Service：<llm>-coordinator
+// ============================================================================
+//
+// 以前 follower 每次都按 Lease 查 coordinator 的 Pod IP，coordinator 换人后地址跟着变，
+// NetworkPolicy 和 mTLS 证书只能放开整个 Pod 网段。
+//
+// 现在 agent 当选时给自己的 Pod 打上 kubeinfer.io/role=coordinator（失去身份时改回 follower），
+// controller 创建一个只选 coordinator 的 ClusterIP Service，follower 通过 COORDINATOR_SERVICE
+// 里的 DNS 名字访问它。
+//
+//   - publishNotReadyAddresses：coordinator 在 vLLM Ready 之前就要给 follower 提供模型
+//   - agent 被 SIGKILL、节点失联时来不及改回 label，Service 会同时选中新旧两个 Pod。
+//     controller 按 Status.CacheCoordinator 把其他 Pod 上的 coordinator label 去掉；
+//     只去掉不打上，label 始终由 agent 自己声明
+//   - initContainer 模式的 agent 不改 label，不创建 Service，继续按 Pod IP 访问
+//   - 不运行 model server 时（model_server.go）也不创建
+// ============================================================================
+
+// coordinatorServiceEnabled 判断是否使用 coordinator Service
+func coordinatorServiceEnabled(llm *aiv1.LLMService) bool {
+	return !initContainerMode(llm) && modelServerEnabled(llm)
+}
+
+// desiredCoordinatorService 生成只选择 coordinator Pod 的 Service
+func desiredCoordinatorService(llm *aiv1.LLMService) *corev1.Service {
+	selector := podLabels(llm)
+	selector[cacheinfo.RoleLabel] = cacheinfo.RoleCoordinator
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cacheinfo.CoordinatorServiceName(llm.Name),
+			Namespace: llm.Namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector:                 selector,
+			PublishNotReadyAddresses: true,
+			Ports: []corev1.ServicePort{{
+				Name:       "model-server",
+				Port:       8080,
+				TargetPort: intstr.FromString("model-server"),
+			}},
+		},
+	}
+}
+
+// coordinatorServiceEnv 把 coordinator Service 的地址告诉 agent
+func coordinatorServiceEnv(llm *aiv1.LLMService) []corev1.EnvVar {
+	if !coordinatorServiceEnabled(llm) {
+		return nil
+	}
+	return []corev1.EnvVar{{
+		Name:  cacheinfo.CoordinatorServiceEnv,
+		Value: fmt.Sprintf("%s.%s.svc", cacheinfo.CoordinatorServiceName(llm.Name), llm.Namespace),
+	}}
+}
+
+// staleCoordinatorLabel 判断 Pod 上是否残留了 coordinator label（它已经不是 coordinator）
+//
+// 还没记录 coordinator 时不判断：刚当选的 agent 先打 label，controller 下一次 reconcile 才看到 Lease
+func staleCoordinatorLabel(pod *corev1.Pod, coordinator string) bool {
+	return coordinator != "" && pod.Name != coordinator &&
+		pod.Labels[cacheinfo.RoleLabel] == cacheinfo.RoleCoordinator
+}
+
+// reconcileCoordinatorService 创建/删除 coordinator Service，并清理残留的 coordinator label
+func (r *LLMServiceReconciler) reconcileCoordinatorService(ctx context.Context, llm *aiv1.LLMService) error {
+	svc := desiredCoordinatorService(llm)
+	if !coordinatorServiceEnabled(llm) {
+		return r.deleteIfExists(ctx, svc)
+	}
+	if err := r.applyService(ctx, llm, svc); err != nil {
+		return err
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(llm.Namespace), client.MatchingLabels(podLabels(llm))); err != nil {
+		return err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !staleCoordinatorLabel(pod, llm.Status.CacheCoordinator) {
+			continue
+		}
+		patch := client.MergeFrom(pod.DeepCopy())
+		pod.Labels[cacheinfo.RoleLabel] = cacheinfo.RoleFollower
+		if err := client.IgnoreNotFound(r.Patch(ctx, pod, patch)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
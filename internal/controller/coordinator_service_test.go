@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+func TestCoordinatorService(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = aiv1.AddToScheme(scheme)
+	llm := testLLMService()
+	llm.Status.CacheCoordinator = "qwen-b"
+
+	pod := func(name, role string) *corev1.Pod {
+		labels := podLabels(llm)
+		if role != "" {
+			labels[cacheinfo.RoleLabel] = role
+		}
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: llm.Namespace, Labels: labels}}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		pod("qwen-a", cacheinfo.RoleCoordinator), // 以前的 coordinator，agent 来不及改回 label
+		pod("qwen-b", cacheinfo.RoleCoordinator),
+		pod("qwen-c", ""),
+	).Build()
+	r := &LLMServiceReconciler{Client: c}
+
+	env := map[string]string{}
+	for _, e := range coordinatorServiceEnv(llm) {
+		env[e.Name] = e.Value
+	}
+	if got, want := env[cacheinfo.CoordinatorServiceEnv], "qwen-coordinator."+llm.Namespace+".svc"; got != want {
+		t.Errorf("%s = %q, want %q", cacheinfo.CoordinatorServiceEnv, got, want)
+	}
+
+	changes, err := r.planChanges(context.Background(), llm, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "create Service " + cacheinfo.CoordinatorServiceName(llm.Name); !slices.Contains(changes, want) {
+		t.Errorf("plan = %v, want it to include %q", changes, want)
+	}
+
+	if err := r.reconcileCoordinatorService(context.Background(), llm); err != nil {
+		t.Fatal(err)
+	}
+	svc := &corev1.Service{}
+	key := types.NamespacedName{Namespace: llm.Namespace, Name: cacheinfo.CoordinatorServiceName(llm.Name)}
+	if err := c.Get(context.Background(), key, svc); err != nil {
+		t.Fatal(err)
+	}
+	if svc.Spec.Selector[cacheinfo.RoleLabel] != cacheinfo.RoleCoordinator || svc.Spec.Selector["llm_cr"] != llm.Name {
+		t.Errorf("selector = %v, want the coordinator pod of %s", svc.Spec.Selector, llm.Name)
+	}
+	if !svc.Spec.PublishNotReadyAddresses {
+		t.Error("the coordinator serves models before vLLM is ready, publishNotReadyAddresses must be set")
+	}
+
+	// 只去掉残留的 label，不给没有 label 的 Pod 打上
+	for name, want := range map[string]string{
+		"qwen-a": cacheinfo.RoleFollower,
+		"qwen-b": cacheinfo.RoleCoordinator,
+		"qwen-c": "",
+	} {
+		got := &corev1.Pod{}
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: llm.Namespace, Name: name}, got); err != nil {
+			t.Fatal(err)
+		}
+		if role := got.Labels[cacheinfo.RoleLabel]; role != want {
+			t.Errorf("%s: role = %q, want %q", name, role, want)
+		}
+	}
+
+	// initContainer 模式：agent 不改 label，不创建 Service
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer}
+	if env := coordinatorServiceEnv(llm); len(env) != 0 {
+		t.Errorf("initContainer mode env = %v, want none", env)
+	}
+	if err := r.reconcileCoordinatorService(context.Background(), llm); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(context.Background(), key, svc); err == nil {
+		t.Error("coordinator Service should be deleted in initContainer mode")
+	}
+}
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// coordinator 稳定性：CoordinatorStable Condition
+// ============================================================================
+//
+// API server 抖动、coordinator 所在节点网络不稳时，lease 会在几个 Pod 之间来回易手。
+// 每换一次 controller 都会按新角色重新打 deletion cost，
+// 来回几次之后所有 Pod 的注解都被改了好几遍，缩容时删哪个 Pod 也跟着随机。
+//
+// 做法：lease 的 acquireTime 只在换持有者时更新，同一个持有者持续了 lease.settlePeriod（operator 配置）
+// 才设 CoordinatorStable=True；换人、没有持有者时马上变回 False。
+// False 期间 reconcileDeletionCost 保留之前的注解，稳定下来再按新的 coordinator 调整。
+// 残留的 coordinator label 不等：Service 同时选中两个 Pod 会把 follower 引到错误的地址。
+// ============================================================================
+
+// defaultCoordinatorSettlePeriod 是 operator 没有配置 lease.settlePeriod 时的默认值
+const defaultCoordinatorSettlePeriod = 30 * time.Second
+
+// coordinatorSettlePeriod 返回 coordinator 需要连续持有 lease 多久才算稳定
+func (r *LLMServiceReconciler) coordinatorSettlePeriod() time.Duration {
+	if d := r.config().Lease.SettlePeriod.Duration; d > 0 {
+		return d
+	}
+	return defaultCoordinatorSettlePeriod
+}
+
+// coordinatorStable 判断 coordinator 是否已经稳定，没有记录过 CoordinatorStable 时也算稳定
+func coordinatorStable(llm *aiv1.LLMService) bool {
+	cond := findCondition(llm, aiv1.ConditionCoordinatorStable)
+	return cond == nil || cond.Status == string(metav1.ConditionTrue)
+}
+
+// checkCoordinatorStable 按 lease 的 acquireTime 更新 CoordinatorStable
+//
+// 要在 checkCoordinatorNode 之后调用，持有者以 Status.CacheCoordinator 为准（强制接管后缓存里还是旧的 lease）。
+// 返回值是到 settle 的剩余时间：lease 不再变化时也要按时把 Condition 设为 True
+func (r *LLMServiceReconciler) checkCoordinatorStable(ctx context.Context, llm *aiv1.LLMService, now time.Time) (time.Duration, error) {
+	lease := &coordinationv1.Lease{}
+	err := r.Get(ctx, types.NamespacedName{Name: leaseName(llm), Namespace: llm.Namespace}, lease)
+	if errors.IsNotFound(err) {
+		// 还没有 agent 参与选举
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get coordinator lease: %w", err)
+	}
+	return syncCoordinatorStable(llm, lease, r.coordinatorSettlePeriod(), now), nil
+}
+
+// syncCoordinatorStable 根据 lease 设置 CoordinatorStable，返回到 settle 的剩余时间（0 表示不需要定时检查）
+func syncCoordinatorStable(llm *aiv1.LLMService, lease *coordinationv1.Lease, settle time.Duration, now time.Time) time.Duration {
+	holder := llm.Status.CacheCoordinator
+	if holder == "" || lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != holder {
+		setCondition(llm, aiv1.ConditionCoordinatorStable, metav1.ConditionFalse,
+			"NoCoordinator", "no pod holds the coordinator lease")
+		return 0
+	}
+	if lease.Spec.AcquireTime == nil {
+		// 不是 agent 创建的 lease，看不出什么时候换的人
+		setCondition(llm, aiv1.ConditionCoordinatorStable, metav1.ConditionTrue,
+			"LeadershipSettled", fmt.Sprintf("coordinator %s holds the lease", holder))
+		return 0
+	}
+
+	held := now.Sub(lease.Spec.AcquireTime.Time)
+	if held < settle {
+		setCondition(llm, aiv1.ConditionCoordinatorStable, metav1.ConditionFalse,
+			"Settling", fmt.Sprintf("coordinator %s acquired the lease %s ago, waiting for %s",
+				holder, held.Round(time.Second), settle))
+		return settle - held
+	}
+	setCondition(llm, aiv1.ConditionCoordinatorStable, metav1.ConditionTrue,
+		"LeadershipSettled", fmt.Sprintf("coordinator %s has held the lease since %s",
+			holder, lease.Spec.AcquireTime.UTC().Format(time.RFC3339)))
+	return 0
+}
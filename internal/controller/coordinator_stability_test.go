@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestSyncCoordinatorStable(t *testing.T) {
+	now := time.Now()
+	lease := func(holder string, acquired time.Duration) *coordinationv1.Lease {
+		acquireTime := metav1.NewMicroTime(now.Add(-acquired))
+		return &coordinationv1.Lease{Spec: coordinationv1.LeaseSpec{HolderIdentity: &holder, AcquireTime: &acquireTime}}
+	}
+	settle := 30 * time.Second
+	unknown := "qwen-a"
+
+	tests := []struct {
+		name        string
+		coordinator string
+		lease       *coordinationv1.Lease
+		wantStable  bool
+		wantRecheck time.Duration
+	}{
+		{"just elected", "qwen-a", lease("qwen-a", 10*time.Second), false, 20 * time.Second},
+		{"held past the settle period", "qwen-a", lease("qwen-a", time.Minute), true, 0},
+		{"lease released", "", &coordinationv1.Lease{}, false, 0},
+		{"forced takeover not yet visible in the cache", "", lease("qwen-a", time.Minute), false, 0},
+		{"no acquire time", "qwen-a", &coordinationv1.Lease{Spec: coordinationv1.LeaseSpec{HolderIdentity: &unknown}}, true, 0},
+	}
+
+	for _, tt := range tests {
+		llm := testLLMService()
+		llm.Status.CacheCoordinator = tt.coordinator
+		recheck := syncCoordinatorStable(llm, tt.lease, settle, now)
+		if got := isConditionTrue(llm, aiv1.ConditionCoordinatorStable); got != tt.wantStable {
+			t.Errorf("%s: stable = %v, want %v (%+v)", tt.name, got, tt.wantStable, findCondition(llm, aiv1.ConditionCoordinatorStable))
+		}
+		if recheck != tt.wantRecheck {
+			t.Errorf("%s: recheck = %v, want %v", tt.name, recheck, tt.wantRecheck)
+		}
+		if coordinatorStable(llm) != tt.wantStable {
+			t.Errorf("%s: coordinatorStable() disagrees with the condition", tt.name)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/agent/runtime"
+	"github.com/Moore-Z/kubeinfer/internal/modelcatalog"
+)
+
+// ============================================================================
+// 显存不足（CUDA OOM）
+// ============================================================================
+//
+// 模型放不进显存时 vLLM 启动到一半就退出，用户只看到 CrashLoopBackOff（或者一直不 Ready）。
+//
+//   - agent 模式：agent 从 vLLM 日志里认出 OOM，写到终止消息（"CUDAOutOfMemory: <日志>"）
+//   - initContainer 模式：主容器就是 vLLM，terminationMessagePolicy=FallbackToLogsOnError，
+//     kubelet 把最后几行日志当成终止消息
+//
+// controller 在终止消息里找到 OOM 后设置 CUDAOutOfMemory Condition，并按这个 LLMService 的配置给出建议。
+// ============================================================================
+
+// cudaOOMPod 返回推理服务因为显存不足退出、而且还没恢复的 Pod
+func cudaOOMPod(pods []corev1.Pod) (string, bool) {
+	for i := range pods {
+		for _, cs := range pods[i].Status.ContainerStatuses {
+			if cs.Name != agentContainerName || cs.Ready {
+				continue
+			}
+			term := cs.State.Terminated
+			if term == nil {
+				term = cs.LastTerminationState.Terminated
+			}
+			if term != nil && runtime.IsCUDAOutOfMemory(term.Message) {
+				return pods[i].Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// oomRemediation 按 LLMService 的配置生成处理建议
+func oomRemediation(llm *aiv1.LLMService) []string {
+	var hints []string
+	gpus := max(llm.Spec.GpuPerReplica, 1)
+	tp := tensorParallelSize(llm)
+
+	if billions, ok := modelcatalog.ParameterBillions(llm.Spec.Model); ok {
+		precision := modelcatalog.DetectPrecision(llm.Spec.Model)
+		hints = append(hints, fmt.Sprintf("the %s weights alone need about %.0fGi of GPU memory, %.0fGi per GPU with tensor parallel size %d",
+			precision.Name, modelcatalog.WeightsGiB(billions, precision), modelcatalog.WeightsGiB(billions, precision)/float64(tp), tp))
+		if precision == modelcatalog.FP16 {
+			hints = append(hints, "use a quantized variant of the model (AWQ, GPTQ or FP8)")
+		}
+	}
+	switch {
+	case tp < gpus && llm.Spec.VLLM != nil && llm.Spec.VLLM.TensorParallelSize != nil:
+		hints = append(hints, fmt.Sprintf("spec.vllm.tensorParallelSize is %d but %d GPUs are allocated, raise it to use all of them", tp, gpus))
+	case tp < gpus:
+		hints = append(hints, fmt.Sprintf("the model's attention heads limit tensor parallel to %d GPUs, adding GPUs per replica will not help", tp))
+	default:
+		hints = append(hints, fmt.Sprintf("increase spec.gpuPerReplica (currently %d) to shard the model over more GPUs", llm.Spec.GpuPerReplica))
+	}
+	hints = append(hints,
+		"lower spec.vllm.gpuMemoryUtilization (default 0.9) if other processes share the GPU",
+		"lower spec.vllm.maxModelLen to shrink the KV cache")
+	return hints
+}
+
+// checkCUDAOOM 把显存不足同步到 CUDAOutOfMemory Condition，变成 True 时发 Warning Event
+//
+// 和 AgentError 一样，没出过错的 LLMService 不加这个 Condition
+func (r *LLMServiceReconciler) checkCUDAOOM(llm *aiv1.LLMService, pods []corev1.Pod) {
+	pod, found := cudaOOMPod(pods)
+	if !found {
+		if findCondition(llm, aiv1.ConditionCUDAOutOfMemory) != nil {
+			setCondition(llm, aiv1.ConditionCUDAOutOfMemory, metav1.ConditionFalse, "Recovered", "")
+		}
+		return
+	}
+
+	msg := fmt.Sprintf("pod %s: inference server ran out of GPU memory; %s",
+		pod, strings.Join(oomRemediation(llm), "; "))
+	if !isConditionTrue(llm, aiv1.ConditionCUDAOutOfMemory) && r.Recorder != nil {
+		r.Recorder.Event(llm, corev1.EventTypeWarning, runtime.ReasonCUDAOutOfMemory, msg)
+	}
+	setCondition(llm, aiv1.ConditionCUDAOutOfMemory, metav1.ConditionTrue, runtime.ReasonCUDAOutOfMemory, msg)
+}
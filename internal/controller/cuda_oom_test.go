@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func oomPod(name string, ready bool, message, reason string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  agentContainerName,
+			Ready: ready,
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				ExitCode: 1, Message: message, Reason: reason,
+			}},
+		}}},
+	}
+}
+
+func TestCUDAOOMPod(t *testing.T) {
+	agentMessage := "CUDAOutOfMemory: torch.OutOfMemoryError: CUDA out of memory. Tried to allocate 2.00 GiB."
+	// initContainer 模式：FallbackToLogsOnError，终止消息是最后几行日志
+	fallbackLogs := "INFO loading weights\nERROR worker.py] torch.OutOfMemoryError: CUDA out of memory.\n"
+
+	tests := []struct {
+		name    string
+		pods    []corev1.Pod
+		wantPod string
+	}{
+		{name: "agent termination message", pods: []corev1.Pod{oomPod("qwen-a", false, agentMessage, "Error")}, wantPod: "qwen-a"},
+		{name: "fallback logs", pods: []corev1.Pod{oomPod("qwen-a", true, "", ""), oomPod("qwen-b", false, fallbackLogs, "Error")}, wantPod: "qwen-b"},
+		{name: "recovered", pods: []corev1.Pod{oomPod("qwen-a", true, agentMessage, "Error")}},
+		// 主机内存 OOM 是另一回事（调大 resources.limits.memory）
+		{name: "host memory oom", pods: []corev1.Pod{oomPod("qwen-a", false, "", "OOMKilled")}},
+	}
+
+	for _, tt := range tests {
+		pod, found := cudaOOMPod(tt.pods)
+		if pod != tt.wantPod || found != (tt.wantPod != "") {
+			t.Errorf("%s: cudaOOMPod() = %q, %v, want %q", tt.name, pod, found, tt.wantPod)
+		}
+	}
+}
+
+func TestCheckCUDAOOM(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	r := &LLMServiceReconciler{Recorder: recorder}
+	llm := testLLMService()
+	llm.Spec.Model = "meta-llama/Llama-3.1-70B-Instruct"
+	llm.Spec.GpuPerReplica = 2
+	oom := []corev1.Pod{oomPod("llama-a", false, "CUDAOutOfMemory: CUDA out of memory", "Error")}
+
+	r.checkCUDAOOM(llm, oom)
+	cond := findCondition(llm, aiv1.ConditionCUDAOutOfMemory)
+	if cond == nil || cond.Status != string(metav1.ConditionTrue) {
+		t.Fatalf("condition = %+v, want True", cond)
+	}
+	for _, hint := range []string{"pod llama-a", "130Gi", "65Gi per GPU", "quantized", "spec.gpuPerReplica (currently 2)", "spec.vllm.maxModelLen"} {
+		if !strings.Contains(cond.Message, hint) {
+			t.Errorf("condition message %q is missing %q", cond.Message, hint)
+		}
+	}
+
+	// 同一次 OOM 只发一次 Event
+	r.checkCUDAOOM(llm, oom)
+	if len(recorder.Events) != 1 {
+		t.Errorf("got %d events, want 1", len(recorder.Events))
+	}
+
+	r.checkCUDAOOM(llm, nil)
+	if cond := findCondition(llm, aiv1.ConditionCUDAOutOfMemory); cond.Status != string(metav1.ConditionFalse) || cond.Reason != "Recovered" {
+		t.Errorf("condition after recovery = %+v", cond)
+	}
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+// ============================================================================
+// Degraded condition：区分"部分副本挂了但还在服务"和"完全不可用"
+// ============================================================================
+//
+// AvailableReplicas 只是一个数字，告警规则要自己拿它和 spec.replicas 比，
+// 还看不到 gateway：推理副本全 Ready、gateway 全挂了，用户一样访问不了。
+//
+// 按推理副本和 gateway 副本（开启 gateway 时）的 Ready 数算出一个状态：
+//
+//	状态            Degraded  Reason               说明
+//	available       False     AllReplicasReady     全部 Ready
+//	degraded        True      ReplicasUnavailable  部分推理副本没 Ready，还有副本在服务
+//	degraded        True      GatewayDegraded      部分 gateway 副本没 Ready
+//	unavailable     False     Unavailable          没有 Ready 的推理副本或 gateway 副本，请求全部失败
+//	scaled_to_zero  False     ScaledToZero         期望副本数为 0（休眠、外部 autoscaler 缩到 0）
+//
+// 完全不可用时 Degraded 为 False：它不是"降级"，是"挂了"，告警规则用 Reason 单独 page。
+// 同样的状态导出到 kubeinfer_llmservice_serving_state，不依赖 kube-state-metrics 读 condition。
+// ============================================================================
+
+// 服务状态，和 metrics.ServingStates 一致
+const (
+	servingAvailable    = "available"
+	servingDegraded     = "degraded"
+	servingUnavailable  = "unavailable"
+	servingScaledToZero = "scaled_to_zero"
+)
+
+// replicaCount 是一个 Deployment 的期望副本数和 Ready 副本数
+type replicaCount struct {
+	desired int32
+	ready   int32
+}
+
+func deploymentReplicas(d *appsv1.Deployment) replicaCount {
+	desired := int32(1)
+	if d.Spec.Replicas != nil {
+		desired = *d.Spec.Replicas
+	}
+	return replicaCount{desired: desired, ready: d.Status.ReadyReplicas}
+}
+
+// servingState 根据推理副本和 gateway 副本算出服务状态；gateway 为 nil 表示没有开启 gateway
+func servingState(model replicaCount, gateway *replicaCount) (state, reason, message string) {
+	if model.desired == 0 {
+		return servingScaledToZero, "ScaledToZero", "desired replicas is 0"
+	}
+	if model.ready == 0 {
+		return servingUnavailable, "Unavailable", fmt.Sprintf("0/%d replicas are ready", model.desired)
+	}
+	if gateway != nil && gateway.ready == 0 {
+		return servingUnavailable, "Unavailable", fmt.Sprintf("0/%d gateway replicas are ready", gateway.desired)
+	}
+	if model.ready < model.desired {
+		return servingDegraded, "ReplicasUnavailable",
+			fmt.Sprintf("%d/%d replicas are ready", model.ready, model.desired)
+	}
+	if gateway != nil && gateway.ready < gateway.desired {
+		return servingDegraded, "GatewayDegraded",
+			fmt.Sprintf("%d/%d gateway replicas are ready", gateway.ready, gateway.desired)
+	}
+	return servingAvailable, "AllReplicasReady", fmt.Sprintf("%d/%d replicas are ready", model.ready, model.desired)
+}
+
+// checkDegraded 更新 Degraded condition 和 serving_state 指标
+//
+// deploy 是集群里的推理 Deployment；gateway 刚创建还没有 Status 时按 0 个 Ready 算
+func (r *LLMServiceReconciler) checkDegraded(ctx context.Context, llm *aiv1.LLMService, deploy *appsv1.Deployment) error {
+	var gateway *replicaCount
+	if r.gatewayEnabled(llm) {
+		gw := &appsv1.Deployment{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: llm.Namespace, Name: gatewayName(llm)}, gw)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		count := deploymentReplicas(gw)
+		gateway = &count
+	}
+
+	state, reason, message := servingState(deploymentReplicas(deploy), gateway)
+	status := metav1.ConditionFalse
+	if state == servingDegraded {
+		status = metav1.ConditionTrue
+	}
+	setCondition(llm, aiv1.ConditionDegraded, status, reason, message)
+	metrics.RecordServingState(llm.Namespace, llm.Name, state)
+	return nil
+}
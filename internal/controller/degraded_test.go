@@ -0,0 +1,33 @@
+package controller
+
+import "testing"
+
+func TestServingState(t *testing.T) {
+	tests := []struct {
+		name       string
+		model      replicaCount
+		gateway    *replicaCount
+		wantState  string
+		wantReason string
+	}{
+		{name: "all ready", model: replicaCount{3, 3}, wantState: servingAvailable, wantReason: "AllReplicasReady"},
+		{name: "surge during rollout", model: replicaCount{3, 4}, wantState: servingAvailable, wantReason: "AllReplicasReady"},
+		{name: "some replicas down", model: replicaCount{3, 1}, wantState: servingDegraded, wantReason: "ReplicasUnavailable"},
+		{name: "all replicas down", model: replicaCount{3, 0}, wantState: servingUnavailable, wantReason: "Unavailable"},
+		{name: "scaled to zero", model: replicaCount{0, 0}, wantState: servingScaledToZero, wantReason: "ScaledToZero"},
+		{name: "gateway degraded", model: replicaCount{2, 2}, gateway: &replicaCount{2, 1},
+			wantState: servingDegraded, wantReason: "GatewayDegraded"},
+		{name: "gateway down", model: replicaCount{2, 2}, gateway: &replicaCount{1, 0},
+			wantState: servingUnavailable, wantReason: "Unavailable"},
+		{name: "replicas and gateway degraded", model: replicaCount{2, 1}, gateway: &replicaCount{2, 1},
+			wantState: servingDegraded, wantReason: "ReplicasUnavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, reason, _ := servingState(tt.model, tt.gateway)
+			if state != tt.wantState || reason != tt.wantReason {
+				t.Errorf("servingState() = %s/%s, want %s/%s", state, reason, tt.wantState, tt.wantReason)
+			}
+		})
+	}
+}
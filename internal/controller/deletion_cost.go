@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// 缩容时先删 follower：controller.kubernetes.io/pod-deletion-cost
+// ============================================================================
+//
+// Deployment 缩容（spec.replicas 变小、休眠、SLO 缩回来）和滚动更新时旧 ReplicaSet 缩容，
+// ReplicaSet controller 自己挑删哪个 Pod，可能正好挑中 coordinator：
+// 所有 follower 重新选举，新 coordinator 如果模型不完整还要从头下载。
+//
+// ReplicaSet 按 pod-deletion-cost 从低到高删，controller 按角色和 Ready 状态给每个 Pod 设置：
+//
+//	角色                    cost
+//	coordinator             1000   最后删
+//	zone seeder             500    zone 拓扑下给本 zone 分发模型
+//	Ready 的 follower       不设（0）
+//	没 Ready 的 follower    -100   还在同步，删了损失最小
+//
+// （ReplicaSet 本身就会先删没 Ready 的 Pod，-100 是在 Ready 状态还没更新时也能排在前面）
+// cost 只是排序依据，不阻止删除：用户缩到 0 时 coordinator 照样会被删。
+// ============================================================================
+
+//+kubebuilder:rbac:groups=core,resources=pods,verbs=patch
+
+// podDeletionCostAnnotation 是 ReplicaSet 缩容时挑选删除对象的依据，cost 低的先删
+const podDeletionCostAnnotation = "controller.kubernetes.io/pod-deletion-cost"
+
+// 各角色的 deletion cost，空字符串表示不设置注解
+const (
+	coordinatorDeletionCost = "1000"
+	seederDeletionCost      = "500"
+	unreadyDeletionCost     = "-100"
+)
+
+// podReady 判断 Pod 的 Ready condition 是否为 True
+func podReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// deletionCost 按角色和 Ready 状态返回 Pod 应有的 deletion cost
+func deletionCost(pod *corev1.Pod, coordinator string, seeders map[string]bool) string {
+	switch {
+	case pod.Name == coordinator:
+		return coordinatorDeletionCost
+	case seeders[pod.Name]:
+		return seederDeletionCost
+	case !podReady(pod):
+		return unreadyDeletionCost
+	}
+	return ""
+}
+
+// zoneSeeders 返回 zone 拓扑下各 zone 的 seeder（<lease>-<zone> 这些 Lease 的持有者）
+func (r *LLMServiceReconciler) zoneSeeders(ctx context.Context, llm *aiv1.LLMService) (map[string]bool, error) {
+	if r.topology(llm) != aiv1.TopologyZone {
+		return nil, nil
+	}
+	leases := &coordinationv1.LeaseList{}
+	if err := r.List(ctx, leases, client.InNamespace(llm.Namespace)); err != nil {
+		return nil, err
+	}
+	prefix := leaseName(llm) + "-"
+	seeders := map[string]bool{}
+	for _, lease := range leases.Items {
+		if strings.HasPrefix(lease.Name, prefix) && lease.Spec.HolderIdentity != nil {
+			seeders[*lease.Spec.HolderIdentity] = true
+		}
+	}
+	return seeders, nil
+}
+
+// reconcileDeletionCost 给推理 Pod 设置 deletion cost，角色变化后更新（以前的 coordinator 降回 follower）
+//
+// coordinator 还没稳定（CoordinatorStable=False）时保留现有的注解，见 coordinator_stability.go
+func (r *LLMServiceReconciler) reconcileDeletionCost(ctx context.Context, llm *aiv1.LLMService) error {
+	if !coordinatorStable(llm) {
+		return nil
+	}
+	seeders, err := r.zoneSeeders(ctx, llm)
+	if err != nil {
+		return err
+	}
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(llm.Namespace), client.MatchingLabels(podLabels(llm))); err != nil {
+		return err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		want := deletionCost(pod, llm.Status.CacheCoordinator, seeders)
+		if pod.Annotations[podDeletionCostAnnotation] == want || !pod.DeletionTimestamp.IsZero() {
+			continue
+		}
+		patch := client.MergeFrom(pod.DeepCopy())
+		if want == "" {
+			delete(pod.Annotations, podDeletionCostAnnotation)
+		} else {
+			if pod.Annotations == nil {
+				pod.Annotations = map[string]string{}
+			}
+			pod.Annotations[podDeletionCostAnnotation] = want
+		}
+		if err := client.IgnoreNotFound(r.Patch(ctx, pod, patch)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestReconcileDeletionCost(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	llm := testLLMService()
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Topology: aiv1.TopologyZone}
+	llm.Status.CacheCoordinator = "qwen-b"
+
+	pod := func(name string, ready bool, annotations map[string]string) *corev1.Pod {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: llm.Namespace, Labels: podLabels(llm), Annotations: annotations},
+			Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+		}
+	}
+	seeder := "qwen-d"
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		pod("qwen-a", true, map[string]string{podDeletionCostAnnotation: coordinatorDeletionCost}), // 以前的 coordinator
+		pod("qwen-b", false, nil), // coordinator 没 Ready 也最后删
+		pod("qwen-c", false, nil),
+		pod(seeder, true, nil),
+		&coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: leaseName(llm) + "-zone-a", Namespace: llm.Namespace},
+			Spec:       coordinationv1.LeaseSpec{HolderIdentity: &seeder},
+		},
+	).Build()
+	r := &LLMServiceReconciler{Client: c}
+
+	if err := r.reconcileDeletionCost(context.Background(), llm); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"qwen-a": "",
+		"qwen-b": coordinatorDeletionCost,
+		"qwen-c": unreadyDeletionCost,
+		"qwen-d": seederDeletionCost,
+	} {
+		got := &corev1.Pod{}
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: llm.Namespace, Name: name}, got); err != nil {
+			t.Fatal(err)
+		}
+		if cost := got.Annotations[podDeletionCostAnnotation]; cost != want {
+			t.Errorf("%s deletion cost = %q, want %q", name, cost, want)
+		}
+	}
+
+	// lease 刚换到 qwen-c，还没稳定：保留之前的注解
+	llm.Status.CacheCoordinator = "qwen-c"
+	setCondition(llm, aiv1.ConditionCoordinatorStable, metav1.ConditionFalse, "Settling", "")
+	if err := r.reconcileDeletionCost(context.Background(), llm); err != nil {
+		t.Fatal(err)
+	}
+	got := &corev1.Pod{}
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: llm.Namespace, Name: "qwen-b"}, got); err != nil {
+		t.Fatal(err)
+	}
+	if cost := got.Annotations[podDeletionCostAnnotation]; cost != coordinatorDeletionCost {
+		t.Errorf("deletion cost changed before the coordinator settled: qwen-b = %q", cost)
+	}
+}
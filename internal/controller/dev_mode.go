@@ -0,0 +1,44 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// 开发模式：spec.devMode，在没有 GPU 的 kind/minikube 上跑完整的控制面
+// ============================================================================
+//
+// 选举、模型分发、gateway、休眠这些逻辑和推理后端无关，但以前想在本地验证就得有 GPU。
+// devMode 把和 GPU 相关的部分换掉，其余（Lease、ConfigMap、follower 同步、gateway）完全不变：
+//
+//	推理后端   llama.cpp（CPU），忽略 spec.runtime
+//	镜像       spec.image 为空时用 operator 配置的 devImage（不是 vLLM 镜像）
+//	GPU        不申请 nvidia.com/gpu（webhook 拒绝 gpuPerReplica > 0）
+//	资源       默认 requests 500m/1Gi、limit 4Gi，kind 节点放得下好几个副本
+//	探针       检查间隔缩短到 5 秒，小模型几秒就能加载完，不用多等一个周期
+//	模型       webhook 只允许 ≤ 3B 参数的模型（例如 Qwen2.5-0.5B-Instruct-GGUF）
+// ============================================================================
+
+// devProbePeriodSeconds 是开发模式下 startup/readiness 探针的检查间隔
+const devProbePeriodSeconds = 5
+
+// devMode 判断 LLMService 是否运行在开发模式
+func devMode(llm *aiv1.LLMService) bool {
+	return llm.Spec.DevMode
+}
+
+// defaultDevResources 是开发模式下没有设置 Spec.Resources 时 agent 容器的默认资源
+func defaultDevResources() corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("4Gi"),
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestDevModeDeployment(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	llm.Spec.Model = "Qwen/Qwen2.5-0.5B-Instruct-GGUF"
+	llm.Spec.Image = ""
+	llm.Spec.Runtime = aiv1.RuntimeVLLM
+	llm.Spec.GpuPerReplica = 1
+	llm.Spec.DevMode = true
+
+	main := r.desiredDeployment(llm).Spec.Template.Spec.Containers[0]
+	if main.Image != aiv1.DefaultDevImage {
+		t.Errorf("image = %s, want %s", main.Image, aiv1.DefaultDevImage)
+	}
+	if v, _ := envValue(main.Env, "INFERENCE_RUNTIME"); v != aiv1.RuntimeLlamaCpp {
+		t.Errorf("INFERENCE_RUNTIME = %q, want %s", v, aiv1.RuntimeLlamaCpp)
+	}
+	if _, ok := main.Resources.Limits[aiv1.GPUResourceName]; ok {
+		t.Errorf("limits = %v, want no GPU", main.Resources.Limits)
+	}
+	if cpu := main.Resources.Requests[corev1.ResourceCPU]; cpu.String() != "500m" {
+		t.Errorf("cpu request = %s, want the dev default 500m", cpu.String())
+	}
+	if main.StartupProbe.PeriodSeconds != devProbePeriodSeconds || main.ReadinessProbe.PeriodSeconds != devProbePeriodSeconds {
+		t.Errorf("probe periods = %d/%d, want %d", main.StartupProbe.PeriodSeconds, main.ReadinessProbe.PeriodSeconds, devProbePeriodSeconds)
+	}
+
+	// spec.image 优先于 devImage
+	llm.Spec.Image = "registry.example.com/kubeinfer-agent:dev"
+	if got := r.desiredDeployment(llm).Spec.Template.Spec.Containers[0].Image; got != llm.Spec.Image {
+		t.Errorf("image = %s, want spec.image", got)
+	}
+}
@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// 分发策略（spec.distribution.strategy）
+// ============================================================================
+//
+// 以前只有 cacheStrategy: none|shared，而且 controller 从来没看过它：所有副本都走 coordinator。
+// 现在分发方式多了，strategy 把它们列出来，每种映射到已有的开关上：
+//
+//	direct       modelServer=false（model_server.go）
+//	coordinator  默认的 coordinator/follower，topology 决定是否经过 zone seeder
+//	p2p          peerServing=true（peer_serving.go）
+//	nodeCache    coordinator + 模型目录放在 hostPath 上（applyNodeCache）
+//
+// 旧字段 cacheStrategy=shared 当作 coordinator；none 是旧的默认值，当作没设置。
+// ============================================================================
+
+// nodeCacheRoot 是 nodeCache 在节点上存放模型的目录，每个 LLMService 一个子目录
+const nodeCacheRoot = "/var/lib/kubeinfer/models"
+
+// distributionStrategy 返回生效的分发策略，为空表示按副本数自动决定
+func distributionStrategy(llm *aiv1.LLMService) string {
+	if d := llm.Spec.Distribution; d != nil && d.Strategy != "" {
+		return d.Strategy
+	}
+	// 兼容旧字段
+	if llm.Spec.CacheStrategy == aiv1.CacheStrategyShared { // nolint:staticcheck
+		return aiv1.StrategyCoordinator
+	}
+	return ""
+}
+
+// nodeCacheDir 返回 LLMService 在节点上的模型目录
+func nodeCacheDir(llm *aiv1.LLMService) string {
+	return fmt.Sprintf("%s/%s/%s", nodeCacheRoot, llm.Namespace, llm.Name)
+}
+
+// applyNodeCache 在 nodeCache 策略下把模型卷换成 hostPath
+//
+// 同一个目录只能有一个 agent 在写：required 反亲和保证每个节点最多一个副本，
+// 滚动更新默认先删旧 Pod（maxSurge=0），新 Pod 落回这个节点时直接用上面的文件。
+// 要在 applyRollout 之前调用，用户设置的 spec.rollout 优先
+func applyNodeCache(llm *aiv1.LLMService, deployment *appsv1.Deployment) {
+	if distributionStrategy(llm) != aiv1.StrategyNodeCache {
+		return
+	}
+	podSpec := &deployment.Spec.Template.Spec
+	hostPathType := corev1.HostPathDirectoryOrCreate
+	for i := range podSpec.Volumes {
+		if podSpec.Volumes[i].Name == "model-storage" {
+			podSpec.Volumes[i].VolumeSource = corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{Path: nodeCacheDir(llm), Type: &hostPathType},
+			}
+		}
+	}
+
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &corev1.Affinity{}
+	}
+	podSpec.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: podLabels(llm)},
+			TopologyKey:   corev1.LabelHostname,
+		}},
+	}
+
+	surge, unavailable := intstr.FromInt32(0), intstr.FromInt32(1)
+	deployment.Spec.Strategy = appsv1.DeploymentStrategy{
+		Type:          appsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDeployment{MaxSurge: &surge, MaxUnavailable: &unavailable},
+	}
+}
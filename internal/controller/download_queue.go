@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+// ============================================================================
+// 集群下载排队：operator 配置 distribution.maxConcurrentDownloads
+// ============================================================================
+//
+// 一次创建几十个 LLMService 时，所有 coordinator 同时从 HuggingFace 下载，
+// 很快触发组织级别的限流，大家一起 429 退避，谁都下不完。
+//
+// 名额在 operator 里按 LLMService 分配（一个 coordinator 一个名额）：
+//
+//  1. coordinator 要从上游下载前，在自己 Pod 上打 kubeinfer.io/download-state=waiting，
+//     然后轮询 -cache ConfigMap，等 downloadGrantedTo 是自己
+//  2. controller 按 spec.distribution.downloadPriority 从高到低、同优先级先到先得分配名额，
+//     写到 ConfigMap 和 status.download（排队时带上位置）
+//  3. coordinator 开始下载时改成 downloading，结束（成功或失败）后删掉注解，名额释放
+//
+// 名额只在内存里：manager 重启后按 Pod 上的 downloading 注解恢复，
+// 恢复期间可能短暂超过上限，不会少发。排队的 LLMService 每 downloadQueueRecheck 检查一次。
+// ============================================================================
+
+// downloadQueueRecheck 是排队中的 LLMService 重新检查的间隔
+const downloadQueueRecheck = 15 * time.Second
+
+// downloadRequest 是一个排队中的 coordinator
+type downloadRequest struct {
+	pod      string
+	priority int32
+	since    time.Time
+}
+
+// downloadQueue 分配集群下载名额，零值可以直接使用
+type downloadQueue struct {
+	mu      sync.Mutex
+	granted map[types.NamespacedName]string
+	waiting map[types.NamespacedName]downloadRequest
+}
+
+// update 按 coordinator 当前的下载状态更新队列，返回这个 LLMService 的 status.download
+//
+// state 是 coordinator Pod 上的 DownloadStateAnnotation，limit <= 0 表示不限制
+func (q *downloadQueue) update(key types.NamespacedName, pod, state string, priority int32, limit int, now time.Time) *aiv1.DownloadStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.granted == nil {
+		q.granted = map[types.NamespacedName]string{}
+		q.waiting = map[types.NamespacedName]downloadRequest{}
+	}
+
+	switch state {
+	case cacheinfo.DownloadActive:
+		delete(q.waiting, key)
+		q.granted[key] = pod
+		return &aiv1.DownloadStatus{State: aiv1.DownloadStateDownloading, Pod: pod}
+	case cacheinfo.DownloadWaiting:
+	default:
+		q.forgetLocked(key)
+		return nil
+	}
+
+	// coordinator 换人了，旧 Pod 的名额和排队位置作废
+	if granted, ok := q.granted[key]; ok && granted != pod {
+		delete(q.granted, key)
+	}
+	if req, ok := q.waiting[key]; ok && req.pod != pod {
+		delete(q.waiting, key)
+	}
+	if q.granted[key] == pod {
+		return &aiv1.DownloadStatus{State: aiv1.DownloadStateGranted, Pod: pod}
+	}
+	req, ok := q.waiting[key]
+	if !ok {
+		req = downloadRequest{pod: pod, since: now}
+	}
+	req.priority = priority
+	q.waiting[key] = req
+
+	queue := q.queueLocked()
+	for len(queue) > 0 && (limit <= 0 || len(q.granted) < limit) {
+		next := queue[0]
+		queue = queue[1:]
+		q.granted[next] = q.waiting[next].pod
+		delete(q.waiting, next)
+	}
+	if q.granted[key] == pod {
+		return &aiv1.DownloadStatus{State: aiv1.DownloadStateGranted, Pod: pod}
+	}
+	position := 0
+	for i, k := range queue {
+		if k == key {
+			position = i + 1
+		}
+	}
+	return &aiv1.DownloadStatus{State: aiv1.DownloadStateQueued, Pod: pod, Position: int32(position)}
+}
+
+// forget 释放 LLMService 的名额和排队位置（LLMService 删除时调用）
+func (q *downloadQueue) forget(key types.NamespacedName) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.forgetLocked(key)
+}
+
+func (q *downloadQueue) forgetLocked(key types.NamespacedName) {
+	delete(q.granted, key)
+	delete(q.waiting, key)
+}
+
+// queueLocked 返回排队中的 LLMService：优先级高的在前，相同时先到的在前
+func (q *downloadQueue) queueLocked() []types.NamespacedName {
+	keys := make([]types.NamespacedName, 0, len(q.waiting))
+	for k := range q.waiting {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := q.waiting[keys[i]], q.waiting[keys[j]]
+		if a.priority != b.priority {
+			return a.priority > b.priority
+		}
+		if !a.since.Equal(b.since) {
+			return a.since.Before(b.since)
+		}
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
+// downloadSchedulingEnabled 判断 operator 是否限制了并发下载
+func (r *LLMServiceReconciler) downloadSchedulingEnabled() bool {
+	return r.config().Distribution.MaxConcurrentDownloads > 0
+}
+
+// reconcileDownloadQueue 按 coordinator 的下载状态分配名额，更新 status.download
+//
+// 返回值是排队时的重新检查间隔：别的 LLMService 下载完释放名额时不会触发这里
+func (r *LLMServiceReconciler) reconcileDownloadQueue(ctx context.Context, llm *aiv1.LLMService, now time.Time) (time.Duration, error) {
+	key := types.NamespacedName{Namespace: llm.Namespace, Name: llm.Name}
+	state := ""
+	if r.downloadSchedulingEnabled() && llm.Status.CacheCoordinator != "" {
+		pod := &corev1.Pod{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: llm.Namespace, Name: llm.Status.CacheCoordinator}, pod)
+		if err != nil && !errors.IsNotFound(err) {
+			return 0, err
+		}
+		if err == nil {
+			state = pod.Annotations[cacheinfo.DownloadStateAnnotation]
+		}
+	}
+
+	var priority int32
+	if d := llm.Spec.Distribution; d != nil {
+		priority = d.DownloadPriority
+	}
+	llm.Status.Download = r.downloads.update(key, llm.Status.CacheCoordinator, state, priority,
+		r.config().Distribution.MaxConcurrentDownloads, now)
+	if llm.Status.Download != nil && llm.Status.Download.State == aiv1.DownloadStateQueued {
+		return downloadQueueRecheck, nil
+	}
+	return 0, nil
+}
+
+// downloadGrantedTo 返回写到 -cache ConfigMap 里的名额持有者
+func downloadGrantedTo(llm *aiv1.LLMService) string {
+	if d := llm.Status.Download; d != nil && d.State != aiv1.DownloadStateQueued {
+		return d.Pod
+	}
+	return ""
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+func TestDownloadQueue(t *testing.T) {
+	q := &downloadQueue{}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	key := func(name string) types.NamespacedName { return types.NamespacedName{Namespace: "default", Name: name} }
+	check := func(step string, got *aiv1.DownloadStatus, state string, position int32) {
+		t.Helper()
+		if got == nil || got.State != state || got.Position != position {
+			t.Errorf("%s: status = %+v, want %s at %d", step, got, state, position)
+		}
+	}
+
+	// 上限 1：a 先到拿到名额，b、c 排队，c 优先级高排在 b 前面
+	check("a", q.update(key("a"), "a-0", cacheinfo.DownloadWaiting, 0, 1, now), aiv1.DownloadStateGranted, 0)
+	check("b", q.update(key("b"), "b-0", cacheinfo.DownloadWaiting, 0, 1, now.Add(time.Second)), aiv1.DownloadStateQueued, 1)
+	check("c", q.update(key("c"), "c-0", cacheinfo.DownloadWaiting, 10, 1, now.Add(2*time.Second)), aiv1.DownloadStateQueued, 1)
+	check("b behind c", q.update(key("b"), "b-0", cacheinfo.DownloadWaiting, 0, 1, now.Add(3*time.Second)), aiv1.DownloadStateQueued, 2)
+	check("a downloading", q.update(key("a"), "a-0", cacheinfo.DownloadActive, 0, 1, now), aiv1.DownloadStateDownloading, 0)
+
+	// a 下载完释放名额，下一个是 c
+	if got := q.update(key("a"), "a-0", "", 0, 1, now); got != nil {
+		t.Errorf("finished download status = %+v, want nil", got)
+	}
+	check("b after a", q.update(key("b"), "b-0", cacheinfo.DownloadWaiting, 0, 1, now), aiv1.DownloadStateQueued, 1)
+	check("c after a", q.update(key("c"), "c-0", cacheinfo.DownloadWaiting, 10, 1, now), aiv1.DownloadStateGranted, 0)
+
+	// c 的 coordinator 换人：旧 Pod 的名额作废，新 Pod 重新排队（名额空出来，直接拿到）
+	check("c new coordinator", q.update(key("c"), "c-1", cacheinfo.DownloadWaiting, 10, 1, now), aiv1.DownloadStateGranted, 0)
+
+	// LLMService 删除后名额给 b
+	q.forget(key("c"))
+	check("b after c deleted", q.update(key("b"), "b-0", cacheinfo.DownloadWaiting, 0, 1, now), aiv1.DownloadStateGranted, 0)
+}
@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+const (
+	// defaultMaxGenerationTime 没有设置 Spec.MaxGenerationTime 时的默认值
+	defaultMaxGenerationTime = 5 * time.Minute
+
+	// shutdownBuffer 是排空之后停 vLLM、让出 lease、把 model server 交接给新 coordinator 预留的时间
+	shutdownBuffer = 30 * time.Second
+)
+
+// maxGenerationTime 返回单个请求最长的生成时间
+func maxGenerationTime(llm *aiv1.LLMService) time.Duration {
+	if llm.Spec.MaxGenerationTime != nil && llm.Spec.MaxGenerationTime.Duration > 0 {
+		return llm.Spec.MaxGenerationTime.Duration
+	}
+	return defaultMaxGenerationTime
+}
+
+// terminationGracePeriod 计算 Pod 的 terminationGracePeriodSeconds
+//
+// preStop（排空）也算在 grace period 里，所以要比最长生成时间多留一些，
+// 否则 kubelet 会在排空过程中直接 SIGKILL。spec.rollout 里设置了就用设置的值
+func terminationGracePeriod(llm *aiv1.LLMService) *int64 {
+	if r := llm.Spec.Rollout; r != nil && r.TerminationGracePeriodSeconds != nil {
+		seconds := *r.TerminationGracePeriodSeconds
+		return &seconds
+	}
+	seconds := int64((maxGenerationTime(llm) + shutdownBuffer).Seconds())
+	return &seconds
+}
+
+// drainTimeout 是排空 in-flight 请求的上限
+//
+// 默认等最长生成时间；grace period 被 spec.rollout 改短时，要在 kubelet SIGKILL 之前给停 vLLM 留出 shutdownBuffer
+// （grace period 比 shutdownBuffer 还短时对半分）
+func drainTimeout(llm *aiv1.LLMService) time.Duration {
+	timeout := maxGenerationTime(llm)
+	if r := llm.Spec.Rollout; r != nil && r.TerminationGracePeriodSeconds != nil {
+		grace := time.Duration(*r.TerminationGracePeriodSeconds) * time.Second
+		budget := grace - shutdownBuffer
+		if budget <= 0 {
+			budget = grace / 2
+		}
+		timeout = min(timeout, budget)
+	}
+	return timeout
+}
+
+// drainLifecycle 生成 preStop：调用 agent 的 /drain，
+// agent 标记 NotReady 并等 in-flight 请求完成后才返回
+func drainLifecycle() *corev1.Lifecycle {
+	return &corev1.Lifecycle{
+		PreStop: &corev1.LifecycleHandler{
+			HTTPGet: httpProbe("/drain").HTTPGet,
+		},
+	}
+}
+
+// drainEnv 把排空上限告诉 agent
+func drainEnv(llm *aiv1.LLMService) []corev1.EnvVar {
+	return []corev1.EnvVar{{
+		Name:  "DRAIN_TIMEOUT",
+		Value: drainTimeout(llm).String(),
+	}}
+}
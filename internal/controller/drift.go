@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// 配置漂移保护
+// ============================================================================
+//
+// 有人 kubectl edit 了我们管理的 Deployment（改镜像、改环境变量），
+// template-hash 注解不会变，syncDeployment 以为一切正常。
+//
+// 这里直接比较我们关心的字段，发现漂移就发 Event 说明哪些字段被改过，
+// 同一次 reconcile 里的 server-side apply（专用的 field manager + force）会把它们改回来。
+//
+// 只比较我们设置过的字段：
+// - 别人额外加的环境变量、sidecar 注入的字段不算漂移（SSA 也不会删它们）
+// - API server 补的默认值也不会误判
+// ============================================================================
+
+// driftResyncPeriod 即使没有任何事件也定期检查一次漂移
+//
+// Owns(&appsv1.Deployment{}) 能感知大部分修改，定期检查兜底（比如 watch 断开期间的修改）
+const driftResyncPeriod = 10 * time.Minute
+
+// detectDrift 返回 found 相对 desired 被手动改动过的字段
+func detectDrift(found, desired *appsv1.Deployment) []string {
+	var drifted []string
+
+	for _, want := range desired.Spec.Template.Spec.Containers {
+		idx := slices.IndexFunc(found.Spec.Template.Spec.Containers, func(c corev1.Container) bool {
+			return c.Name == want.Name
+		})
+		if idx < 0 {
+			drifted = append(drifted, fmt.Sprintf("containers[%s]", want.Name))
+			continue
+		}
+		got := found.Spec.Template.Spec.Containers[idx]
+		prefix := fmt.Sprintf("containers[%s].", want.Name)
+
+		if got.Image != want.Image {
+			drifted = append(drifted, prefix+"image")
+		}
+		if !slices.Equal(got.Command, want.Command) {
+			drifted = append(drifted, prefix+"command")
+		}
+		if !slices.Equal(got.Args, want.Args) {
+			drifted = append(drifted, prefix+"args")
+		}
+		if !equality.Semantic.DeepEqual(got.Resources, want.Resources) {
+			drifted = append(drifted, prefix+"resources")
+		}
+		for _, e := range want.Env {
+			// ValueFrom 的变量 API server 会补默认值（fieldRef.apiVersion），只比较普通值
+			if e.ValueFrom != nil {
+				continue
+			}
+			i := slices.IndexFunc(got.Env, func(g corev1.EnvVar) bool { return g.Name == e.Name })
+			if i < 0 || got.Env[i].Value != e.Value {
+				drifted = append(drifted, prefix+"env."+e.Name)
+			}
+		}
+	}
+
+	return drifted
+}
+
+// reportDrift 记录被手动改动过的字段
+//
+// 恢复由 syncDeployment 的 server-side apply 完成：ForceOwnership 会把
+// 被 kubectl edit 改过的字段（现在归 kubectl 所有）改回期望值
+func (r *LLMServiceReconciler) reportDrift(ctx context.Context, llm *aiv1.LLMService, desired *appsv1.Deployment, drifted []string) {
+	msg := "reverted manual changes to " + strings.Join(drifted, ", ")
+	log.FromContext(ctx).Info("Reverting Deployment drift", "Deployment.Name", desired.Name, "fields", drifted)
+	if r.Recorder != nil {
+		r.Recorder.Event(llm, corev1.EventTypeWarning, "DriftReverted", msg)
+	}
+}
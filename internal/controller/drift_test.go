@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestDetectDrift(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := &aiv1.LLMService{
+		ObjectMeta: metav1.ObjectMeta{Name: "qwen", Namespace: "default"},
+		Spec:       aiv1.LLMServiceSpec{Model: "Qwen/Qwen2.5-0.5B", Replicas: 1},
+	}
+	desired := r.desiredDeployment(llm)
+
+	tests := []struct {
+		name   string
+		mutate func(c *corev1.Container)
+		want   []string
+	}{
+		{"no drift", func(c *corev1.Container) {}, nil},
+		{"image edited", func(c *corev1.Container) { c.Image = "vllm/vllm-openai:nightly" }, []string{"containers[agent].image"}},
+		{"env edited", func(c *corev1.Container) {
+			for i := range c.Env {
+				if c.Env[i].Name == "MODEL_REPO" {
+					c.Env[i].Value = "other/model"
+				}
+			}
+		}, []string{"containers[agent].env.MODEL_REPO"}},
+		// 别人额外加的环境变量不算漂移
+		{"extra env ignored", func(c *corev1.Container) {
+			c.Env = append(c.Env, corev1.EnvVar{Name: "HTTP_PROXY", Value: "http://proxy"})
+		}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found := r.desiredDeployment(llm)
+			tt.mutate(&found.Spec.Template.Spec.Containers[0])
+			if got := detectDrift(found, desired); !slices.Equal(got, tt.want) {
+				t.Errorf("detectDrift() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,424 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/eval"
+	"github.com/Moore-Z/kubeinfer/internal/operatorconfig"
+)
+
+// ============================================================================
+// EvalRun：声明式质量评测
+// ============================================================================
+//
+// BenchmarkRun 回答"够不够快"，EvalRun 回答"答得对不对"：换模型、换量化、改 vLLM 参数之后，
+// 用同一个评测集给候选版本打分，Passed condition 作为发布流程的放行条件。
+//
+// 流程和 BenchmarkRun 一样：
+//
+//	Pending  → 等 LLMService 就绪、没有等维护窗口的变更、Deployment 的滚动更新结束，
+//	           记下这时的 Pod 模板 hash（status.revision）
+//	Running  → 创建 Job 运行 /eval（manager 镜像），评测集 ConfigMap 挂进容器
+//	Succeeded/Failed → Job 结束，从终止消息里读出分数，和 spec.thresholds 比较后设置 Passed condition
+//
+// 评测期间 LLMService 又滚动更新了的话，分数说不清是哪个版本的，直接 Failed，新建一个 EvalRun 重测。
+// ============================================================================
+
+// evalPodApp 是评测 Pod 的 app label，manager 的 Pod 缓存也按它过滤（CacheOptions）
+const evalPodApp = "kubeinfer-eval"
+
+// evalRunLabel 记录评测 Pod 属于哪个 EvalRun
+const evalRunLabel = "kubeinfer.io/eval-run"
+
+// evalPromptsDir 是评测集 ConfigMap 在容器里的挂载目录
+const evalPromptsDir = "/etc/kubeinfer/eval"
+
+// defaultEvalTimeout 是没有设置 spec.timeout（没经过 apiserver 默认值）时的时间上限
+const defaultEvalTimeout = 30 * time.Minute
+
+// EvalRunReconciler 调和 EvalRun
+type EvalRunReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Config 提供评测 Job 的镜像（和 gateway 一样是 manager 镜像），为 nil 时用内置默认值
+	Config *operatorconfig.Store
+	// APIReader 读评测集 ConfigMap（ConfigMap 不进 informer 缓存，见 cache_options.go）；为 nil 时用 Client
+	APIReader client.Reader
+}
+
+//+kubebuilder:rbac:groups=ai.ruijie.io,resources=evalruns,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=ai.ruijie.io,resources=evalruns/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=ai.ruijie.io,resources=evalruns/finalizers,verbs=update
+
+func (r *EvalRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+
+	run := &aiv1.EvalRun{}
+	if err := r.Get(ctx, req.NamespacedName, run); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if run.Status.Phase == aiv1.EvalSucceeded || run.Status.Phase == aiv1.EvalFailed {
+		return ctrl.Result{}, nil
+	}
+
+	job := &batchv1.Job{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: run.Namespace, Name: evalJobName(run)}, job)
+	if errors.IsNotFound(err) {
+		return r.startEval(ctx, run)
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	finished, failed := jobFinished(job)
+	if !finished {
+		return ctrl.Result{}, nil
+	}
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(run.Namespace),
+		client.MatchingLabels{evalRunLabel: run.Name}); err != nil {
+		return ctrl.Result{}, err
+	}
+	msg := terminationMessage(pods.Items, "eval")
+
+	now := metav1.Now()
+	run.Status.CompletionTime = &now
+	scores, err := parseEvalScores(msg)
+	switch {
+	case failed:
+		run.Status.Phase = aiv1.EvalFailed
+		run.Status.Message = "eval job failed"
+		if msg != "" {
+			run.Status.Message += ": " + msg
+		}
+	case err != nil:
+		run.Status.Phase = aiv1.EvalFailed
+		run.Status.Message = err.Error()
+	default:
+		revision, err := r.currentRevision(ctx, run)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if revision != run.Status.Revision {
+			run.Status.Phase = aiv1.EvalFailed
+			run.Status.Message = fmt.Sprintf("LLMService %q changed during the eval (revision %s, now %s)",
+				run.Spec.LLMService, run.Status.Revision, revision)
+			break
+		}
+		run.Status.Phase = aiv1.EvalSucceeded
+		run.Status.Scores = scores
+		run.Status.Message = ""
+	}
+
+	cond := metav1.Condition{Type: aiv1.EvalConditionPassed, ObservedGeneration: run.Generation}
+	if run.Status.Phase == aiv1.EvalFailed {
+		cond.Status, cond.Reason, cond.Message = metav1.ConditionFalse, "EvalFailed", run.Status.Message
+	} else if unmet := unmetThresholds(run.Spec.Thresholds, scores); len(unmet) > 0 {
+		cond.Status, cond.Reason, cond.Message = metav1.ConditionFalse, "ThresholdsNotMet", strings.Join(unmet, "; ")
+		run.Status.Message = cond.Message
+	} else {
+		cond.Status, cond.Reason, cond.Message = metav1.ConditionTrue, "ThresholdsMet", "all thresholds met"
+	}
+	meta.SetStatusCondition(&run.Status.Conditions, cond)
+
+	l.Info("Eval finished", "phase", run.Status.Phase, "passed", cond.Status, "message", run.Status.Message)
+	return ctrl.Result{}, r.Status().Update(ctx, run)
+}
+
+// startEval 在 LLMService 就绪、滚动更新结束后创建评测 Job，否则停在 Pending 稍后重试
+func (r *EvalRunReconciler) startEval(ctx context.Context, run *aiv1.EvalRun) (ctrl.Result, error) {
+	llm := &aiv1.LLMService{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: run.Namespace, Name: run.Spec.LLMService}, llm)
+	if errors.IsNotFound(err) {
+		return r.setPending(ctx, run, fmt.Sprintf("LLMService %q not found", run.Spec.LLMService))
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// 变更还在等维护窗口：现在评测的是旧版本
+	if isConditionTrue(llm, aiv1.ConditionPendingUpdate) {
+		return r.setPending(ctx, run, fmt.Sprintf("waiting for the pending update of LLMService %q", llm.Name))
+	}
+	deployment := &appsv1.Deployment{}
+	err = r.Get(ctx, client.ObjectKey{Namespace: llm.Namespace, Name: llm.Name + "-deployment"}, deployment)
+	if errors.IsNotFound(err) {
+		return r.setPending(ctx, run, fmt.Sprintf("waiting for the Deployment of LLMService %q", llm.Name))
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if rolloutInProgress(deployment) {
+		return r.setPending(ctx, run, fmt.Sprintf("waiting for the rollout of LLMService %q to finish", llm.Name))
+	}
+	target, err := inferenceTarget(ctx, r.Client, llm)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if target == "" {
+		return r.setPending(ctx, run, fmt.Sprintf("waiting for LLMService %q to have available replicas", llm.Name))
+	}
+
+	// 评测集不存在时 Job 的 Pod 起不来，先等用户创建；key 写错了等也没用，直接失败
+	cm := &corev1.ConfigMap{}
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
+	}
+	err = reader.Get(ctx, client.ObjectKey{Namespace: run.Namespace, Name: run.Spec.Prompts.ConfigMapName}, cm)
+	if errors.IsNotFound(err) {
+		return r.setPending(ctx, run, fmt.Sprintf("prompt set ConfigMap %q not found", run.Spec.Prompts.ConfigMapName))
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if _, ok := cm.Data[evalPromptsKey(run)]; !ok {
+		return ctrl.Result{}, r.setFailed(ctx, run, fmt.Sprintf("prompt set ConfigMap %q has no key %q",
+			cm.Name, evalPromptsKey(run)))
+	}
+
+	job := desiredEvalJob(run, target, r.Config.Get().Gateway.Image)
+	if err := controllerutil.SetControllerReference(run, job, r.Scheme); err != nil {
+		return ctrl.Result{}, err
+	}
+	if err := r.Create(ctx, job); err != nil && !errors.IsAlreadyExists(err) {
+		return ctrl.Result{}, err
+	}
+	revision := deployment.Annotations[templateHashAnnotation]
+	log.FromContext(ctx).Info("Eval job created", "job", job.Name, "target", target, "revision", revision)
+
+	now := metav1.Now()
+	run.Status.Phase = aiv1.EvalRunning
+	run.Status.JobName = job.Name
+	run.Status.Target = target
+	run.Status.Revision = revision
+	run.Status.StartTime = &now
+	run.Status.Message = ""
+	return ctrl.Result{}, r.Status().Update(ctx, run)
+}
+
+// currentRevision 返回 LLMService 现在的 Pod 模板 hash，滚动更新还没结束、Deployment 不在了都算变了
+func (r *EvalRunReconciler) currentRevision(ctx context.Context, run *aiv1.EvalRun) (string, error) {
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: run.Namespace, Name: run.Spec.LLMService + "-deployment"}, deployment)
+	if errors.IsNotFound(err) {
+		return "<deleted>", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if rolloutInProgress(deployment) {
+		return "<rolling out>", nil
+	}
+	return deployment.Annotations[templateHashAnnotation], nil
+}
+
+// setPending 更新 Pending 的原因，30 秒后重新检查
+func (r *EvalRunReconciler) setPending(ctx context.Context, run *aiv1.EvalRun, msg string) (ctrl.Result, error) {
+	if run.Status.Phase != aiv1.EvalPending || run.Status.Message != msg {
+		run.Status.Phase = aiv1.EvalPending
+		run.Status.Message = msg
+		if err := r.Status().Update(ctx, run); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+}
+
+// setFailed 在开始之前就确定跑不了时直接结束
+func (r *EvalRunReconciler) setFailed(ctx context.Context, run *aiv1.EvalRun, msg string) error {
+	now := metav1.Now()
+	run.Status.Phase = aiv1.EvalFailed
+	run.Status.Message = msg
+	run.Status.CompletionTime = &now
+	meta.SetStatusCondition(&run.Status.Conditions, metav1.Condition{
+		Type:               aiv1.EvalConditionPassed,
+		Status:             metav1.ConditionFalse,
+		Reason:             "EvalFailed",
+		Message:            msg,
+		ObservedGeneration: run.Generation,
+	})
+	return r.Status().Update(ctx, run)
+}
+
+// rolloutInProgress 判断 Deployment 是否还在滚动更新（新的 spec 没被处理，或者还有旧模板的副本）
+func rolloutInProgress(d *appsv1.Deployment) bool {
+	return d.Status.ObservedGeneration < d.Generation || d.Status.UpdatedReplicas != d.Status.Replicas
+}
+
+// evalJobName 是 EvalRun 对应的 Job 名称
+func evalJobName(run *aiv1.EvalRun) string {
+	return run.Name + "-eval"
+}
+
+// evalPromptsKey 返回评测集在 ConfigMap 里的 key
+func evalPromptsKey(run *aiv1.EvalRun) string {
+	if run.Spec.Prompts.Key != "" {
+		return run.Spec.Prompts.Key
+	}
+	return aiv1.DefaultEvalPromptsKey
+}
+
+// desiredEvalJob 生成运行评测程序的 Job
+//
+// 和压测一样只跑一次（backoffLimit=0）：重跑会重复请求评审，分数也不会变得更可信
+func desiredEvalJob(run *aiv1.EvalRun, target, image string) *batchv1.Job {
+	timeout := defaultEvalTimeout
+	if run.Spec.Timeout != nil && run.Spec.Timeout.Duration > 0 {
+		timeout = run.Spec.Timeout.Duration
+	}
+	labels := map[string]string{
+		"app":        evalPodApp,
+		evalRunLabel: run.Name,
+	}
+	env := []corev1.EnvVar{
+		{Name: eval.TargetEnv, Value: target},
+		{Name: eval.PromptsFileEnv, Value: path.Join(evalPromptsDir, aiv1.DefaultEvalPromptsKey)},
+	}
+	// 没有经过 apiserver 默认值的对象这几个字段可能是 0，交给评测程序的默认值
+	for name, v := range map[string]int32{
+		eval.ConcurrencyEnv: run.Spec.Concurrency,
+		eval.MaxTokensEnv:   run.Spec.MaxTokens,
+	} {
+		if v > 0 {
+			env = append(env, corev1.EnvVar{Name: name, Value: strconv.Itoa(int(v))})
+		}
+	}
+	if j := run.Spec.Judge; j != nil {
+		env = append(env,
+			corev1.EnvVar{Name: eval.JudgeURLEnv, Value: j.URL},
+			corev1.EnvVar{Name: eval.JudgeModelEnv, Value: j.Model})
+		if j.APIKeySecretRef != nil {
+			env = append(env, corev1.EnvVar{Name: eval.JudgeAPIKeyEnv,
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: j.APIKeySecretRef}})
+		}
+	}
+	backoffLimit := int32(0)
+	deadline := int64(timeout.Seconds())
+
+	// map 遍历顺序不固定，排序后每次生成的 Job 才一样
+	slices.SortFunc(env, func(a, b corev1.EnvVar) int { return strings.Compare(a.Name, b.Name) })
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      evalJobName(run),
+			Namespace: run.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:          &backoffLimit,
+			ActiveDeadlineSeconds: &deadline,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{{
+						Name:                     "eval",
+						Image:                    image,
+						ImagePullPolicy:          corev1.PullIfNotPresent,
+						Command:                  []string{"/eval"},
+						Env:                      env,
+						VolumeMounts:             []corev1.VolumeMount{{Name: "prompts", MountPath: evalPromptsDir, ReadOnly: true}},
+						TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("100m"),
+								corev1.ResourceMemory: resource.MustParse("64Mi"),
+							},
+							Limits: corev1.ResourceList{
+								corev1.ResourceMemory: resource.MustParse("256Mi"),
+							},
+						},
+					}},
+					// 不管 ConfigMap 里的 key 叫什么，挂进来都是 prompts.jsonl
+					Volumes: []corev1.Volume{{
+						Name: "prompts",
+						VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+							LocalObjectReference: corev1.LocalObjectReference{Name: run.Spec.Prompts.ConfigMapName},
+							Items:                []corev1.KeyToPath{{Key: evalPromptsKey(run), Path: aiv1.DefaultEvalPromptsKey}},
+						}},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// parseEvalScores 解析评测程序写的分数 JSON
+func parseEvalScores(msg string) (*aiv1.EvalScores, error) {
+	if msg == "" {
+		return nil, fmt.Errorf("eval job completed without scores")
+	}
+	scores := &aiv1.EvalScores{}
+	if err := json.Unmarshal([]byte(msg), scores); err != nil {
+		return nil, fmt.Errorf("failed to parse eval scores: %w", err)
+	}
+	return scores, nil
+}
+
+// unmetThresholds 返回没有达到的阈值（为空表示通过）
+//
+// 错误率没有设置上限时按 0 算：评测集里有题没答出来，分数就不完整
+func unmetThresholds(t *aiv1.EvalThresholds, s *aiv1.EvalScores) []string {
+	if t == nil {
+		t = &aiv1.EvalThresholds{}
+	}
+	var unmet []string
+	maxErrorRate := 0.0
+	if t.MaxErrorRate != "" {
+		maxErrorRate, _ = strconv.ParseFloat(t.MaxErrorRate, 64)
+	}
+	if s.Prompts > 0 {
+		failed := s.Errors + s.JudgeErrors
+		if rate := float64(failed) / float64(s.Prompts); rate > maxErrorRate {
+			unmet = append(unmet, fmt.Sprintf("error rate %.4f above %g (%d of %d prompts failed)",
+				rate, maxErrorRate, failed, s.Prompts))
+		}
+	}
+	for _, c := range []struct{ name, min, score string }{
+		{"accuracy", t.MinAccuracy, s.Accuracy},
+		{"win rate", t.MinWinRate, s.WinRate},
+	} {
+		if c.min == "" {
+			continue
+		}
+		if c.score == "" {
+			unmet = append(unmet, fmt.Sprintf("%s not measured, want at least %s", c.name, c.min))
+			continue
+		}
+		score, _ := strconv.ParseFloat(c.score, 64)
+		if want, _ := strconv.ParseFloat(c.min, 64); score < want {
+			unmet = append(unmet, fmt.Sprintf("%s %s below %s", c.name, c.score, c.min))
+		}
+	}
+	return unmet
+}
+
+// SetupWithManager 注册 controller：Job 状态变化时重新调和所属的 EvalRun
+func (r *EvalRunReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&aiv1.EvalRun{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
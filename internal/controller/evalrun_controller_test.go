@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/eval"
+)
+
+func TestDesiredEvalJob(t *testing.T) {
+	run := &aiv1.EvalRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "qwen-eval", Namespace: "default"},
+		Spec: aiv1.EvalRunSpec{
+			LLMService:  "qwen",
+			Prompts:     aiv1.EvalPromptSet{ConfigMapName: "qa", Key: "qa.jsonl"},
+			Concurrency: 8,
+			Timeout:     &metav1.Duration{Duration: 10 * time.Minute},
+			Judge: &aiv1.EvalJudgeSpec{
+				URL:   "https://judge.example.com",
+				Model: "gpt-4o-mini",
+				APIKeySecretRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "openai"}, Key: "api-key",
+				},
+			},
+		},
+	}
+	job := desiredEvalJob(run, "http://qwen-gateway.default.svc:8000", "kubeinfer:v1")
+
+	if job.Name != "qwen-eval-eval" || *job.Spec.BackoffLimit != 0 || *job.Spec.ActiveDeadlineSeconds != 600 {
+		t.Errorf("job %s: backoffLimit %d, activeDeadlineSeconds %d",
+			job.Name, *job.Spec.BackoffLimit, *job.Spec.ActiveDeadlineSeconds)
+	}
+	if job.Spec.Template.Labels["app"] != evalPodApp || job.Spec.Template.Labels[evalRunLabel] != run.Name {
+		t.Errorf("pod labels = %v", job.Spec.Template.Labels)
+	}
+
+	c := job.Spec.Template.Spec.Containers[0]
+	if c.Image != "kubeinfer:v1" || c.Command[0] != "/eval" {
+		t.Errorf("container = %s %v", c.Image, c.Command)
+	}
+	env := map[string]corev1.EnvVar{}
+	for _, e := range c.Env {
+		env[e.Name] = e
+	}
+	for k, v := range map[string]string{
+		eval.TargetEnv:      "http://qwen-gateway.default.svc:8000",
+		eval.PromptsFileEnv: "/etc/kubeinfer/eval/prompts.jsonl",
+		eval.ConcurrencyEnv: "8",
+		eval.JudgeURLEnv:    "https://judge.example.com",
+		eval.JudgeModelEnv:  "gpt-4o-mini",
+	} {
+		if env[k].Value != v {
+			t.Errorf("env %s = %q, want %q", k, env[k].Value, v)
+		}
+	}
+	// 没有设置的字段交给评测程序的默认值；API key 不经过 controller
+	if _, ok := env[eval.MaxTokensEnv]; ok {
+		t.Errorf("unset maxTokens must not be passed")
+	}
+	if ref := env[eval.JudgeAPIKeyEnv].ValueFrom; ref == nil || ref.SecretKeyRef.Name != "openai" {
+		t.Errorf("judge API key = %+v, want a secretKeyRef", env[eval.JudgeAPIKeyEnv])
+	}
+
+	// ConfigMap 里的 key 挂进来都叫 prompts.jsonl
+	items := job.Spec.Template.Spec.Volumes[0].ConfigMap.Items
+	if len(items) != 1 || items[0].Key != "qa.jsonl" || items[0].Path != "prompts.jsonl" {
+		t.Errorf("prompt volume items = %+v", items)
+	}
+}
+
+func TestUnmetThresholds(t *testing.T) {
+	scores := &aiv1.EvalScores{Prompts: 100, Errors: 2, Graded: 98, Accuracy: "0.8500", Judged: 98, WinRate: "0.6000"}
+	tests := []struct {
+		name       string
+		thresholds *aiv1.EvalThresholds
+		scores     *aiv1.EvalScores
+		unmet      []string
+	}{
+		{"no thresholds, errors", nil, scores, []string{"error rate"}},
+		{"no thresholds, clean", nil, &aiv1.EvalScores{Prompts: 10, Graded: 10, Accuracy: "0.1000"}, nil},
+		{"judge errors count as failures", nil,
+			&aiv1.EvalScores{Prompts: 10, JudgeErrors: 1, Graded: 10, Accuracy: "1.0000"}, []string{"error rate 0.1000"}},
+		{"all met", &aiv1.EvalThresholds{MinAccuracy: "0.8", MinWinRate: ".5", MaxErrorRate: "0.05"}, scores, nil},
+		{"accuracy below", &aiv1.EvalThresholds{MinAccuracy: "0.9", MaxErrorRate: "0.05"}, scores, []string{"accuracy 0.8500 below 0.9"}},
+		{"win rate not measured", &aiv1.EvalThresholds{MinWinRate: "0.5", MaxErrorRate: "1"},
+			&aiv1.EvalScores{Prompts: 10, Graded: 10, Accuracy: "1.0000"}, []string{"win rate not measured"}},
+		{"several unmet", &aiv1.EvalThresholds{MinAccuracy: "0.9", MinWinRate: "0.7"}, scores,
+			[]string{"error rate", "accuracy", "win rate"}},
+	}
+	for _, tt := range tests {
+		unmet := unmetThresholds(tt.thresholds, tt.scores)
+		if len(unmet) != len(tt.unmet) {
+			t.Errorf("%s: unmetThresholds() = %q, want %d entries", tt.name, unmet, len(tt.unmet))
+			continue
+		}
+		for i, prefix := range tt.unmet {
+			if !strings.HasPrefix(unmet[i], prefix) {
+				t.Errorf("%s: unmet[%d] = %q, want prefix %q", tt.name, i, unmet[i], prefix)
+			}
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	policyv1ac "k8s.io/client-go/applyconfigurations/policy/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+// ============================================================================
+// 供货期间的驱逐保护：spec.distribution.evictionProtection
+// ============================================================================
+//
+// coordinator 正在给 N 个 follower 供货时被驱逐（节点 drain、cluster-autoscaler 缩容），
+// N 个 follower 的下载全部中断，新的 coordinator 如果模型不完整还要先从上游下载，
+// 然后 N 个 follower 再一起从头同步。
+//
+// agent 把正在下载的 follower 数写在 coordinator Pod 的注解上（pkg/cacheinfo 的 ActiveTransfersAnnotation），controller：
+//
+//	follower 数 >= minActiveTransfers，且 PDB 创建不到 maxDuration  → <name>-coordinator PDB（maxUnavailable: 0）
+//	follower 数不够，或者已经超过 maxDuration                     → PDB 放开（maxUnavailable: 1）或删除
+//
+// PDB 只选带 kubeinfer.io/role=coordinator 的 Pod（和 coordinator Service 一样），follower 照常驱逐。
+// maxDuration 是硬上限：传输卡住、follower 一直重试时也不会让节点永远 drain 不掉。
+// 计时从 controller 第一次创建 PDB 开始，记在 PDB 的 kubeinfer.io/protection-start 注解上，
+// 不用 agent 上报的 TransfersSinceAnnotation：follower 顺序下载文件的间隙里传输数会短暂降到 0，
+// agent 那边的开始时间随之重置，按它算保护可以一直延长下去。
+// 所以传输停下来后 PDB 先放开不删，开始时间保留到 2*maxDuration 才删除，
+// 也就是每次最多连续阻止 maxDuration，之后至少放开 maxDuration 才会重新计时。
+// PDB 只拦截 Eviction API，直接 delete Pod、节点失联不受影响。
+// ============================================================================
+
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+
+// defaultEvictionProtectionMaxDuration 是没有设置 maxDuration 时最长阻止驱逐的时间
+const defaultEvictionProtectionMaxDuration = 30 * time.Minute
+
+// coordinatorPDBName 返回驱逐保护 PDB 的名称（和 coordinator Service 同名）
+func coordinatorPDBName(llm *aiv1.LLMService) string {
+	return cacheinfo.CoordinatorServiceName(llm.Name)
+}
+
+// protectionStartAnnotation 是驱逐保护 PDB 上 controller 第一次创建它的时间（RFC3339）
+const protectionStartAnnotation = "kubeinfer.io/protection-start"
+
+// coordinatorSeeding 判断 coordinator Pod 是否正在给至少 minActiveTransfers 个 follower 供货
+func coordinatorSeeding(pod *corev1.Pod, p *aiv1.EvictionProtectionSpec) bool {
+	minActive := 1
+	if p.MinActiveTransfers > 0 {
+		minActive = int(p.MinActiveTransfers)
+	}
+	active, err := strconv.Atoi(pod.Annotations[cacheinfo.ActiveTransfersAnnotation])
+	return err == nil && active >= minActive
+}
+
+// evictionProtected 按 PDB 的开始时间判断现在是否应该阻止驱逐
+//
+// start 为零表示还没有 PDB（或者上一轮已经过了 2*maxDuration），seeding 时从 now 开始计时。
+// 返回值：是否阻止驱逐、这一轮的开始时间（为零时删除 PDB）、多久之后要重新检查
+func evictionProtected(seeding bool, start time.Time, maxDuration time.Duration, now time.Time) (bool, time.Time, time.Duration) {
+	if !start.IsZero() && !now.Before(start.Add(2*maxDuration)) {
+		start = time.Time{}
+	}
+	if start.IsZero() {
+		if !seeding {
+			return false, time.Time{}, 0
+		}
+		start = now
+	}
+	if remaining := start.Add(maxDuration).Sub(now); seeding && remaining > 0 {
+		return true, start, remaining
+	}
+	return false, start, start.Add(2 * maxDuration).Sub(now)
+}
+
+// desiredCoordinatorPDB 生成只选择 coordinator Pod 的 PDB，protected 为 false 时允许驱逐
+func desiredCoordinatorPDB(llm *aiv1.LLMService, protected bool, start time.Time) *policyv1.PodDisruptionBudget {
+	selector := podLabels(llm)
+	selector[cacheinfo.RoleLabel] = cacheinfo.RoleCoordinator
+	maxUnavailable := intstr.FromInt32(0)
+	if !protected {
+		maxUnavailable = intstr.FromInt32(1)
+	}
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      coordinatorPDBName(llm),
+			Namespace: llm.Namespace,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector:       &metav1.LabelSelector{MatchLabels: selector},
+			MaxUnavailable: &maxUnavailable,
+		},
+	}
+	if !start.IsZero() {
+		pdb.Annotations = map[string]string{protectionStartAnnotation: start.UTC().Format(time.RFC3339)}
+	}
+	return pdb
+}
+
+// pdbApplyConfiguration 把期望的 PDB 转成 apply configuration
+func pdbApplyConfiguration(llm *aiv1.LLMService, pdb *policyv1.PodDisruptionBudget) (*policyv1ac.PodDisruptionBudgetApplyConfiguration, error) {
+	ac := &policyv1ac.PodDisruptionBudgetApplyConfiguration{}
+	if err := convertToApply(pdb, ac); err != nil {
+		return nil, err
+	}
+	ac.WithAPIVersion("policy/v1").WithKind("PodDisruptionBudget").WithOwnerReferences(ownerReference(llm))
+	return ac, nil
+}
+
+// protectionStart 读取已有 PDB 上的开始时间，没有 PDB 或注解无效时返回零值
+func (r *LLMServiceReconciler) protectionStart(ctx context.Context, llm *aiv1.LLMService) (time.Time, error) {
+	pdb := &policyv1.PodDisruptionBudget{}
+	err := r.Get(ctx, types.NamespacedName{Namespace: llm.Namespace, Name: coordinatorPDBName(llm)}, pdb)
+	if errors.IsNotFound(err) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	start, err := time.Parse(time.RFC3339, pdb.Annotations[protectionStartAnnotation])
+	if err != nil {
+		return time.Time{}, nil
+	}
+	return start, nil
+}
+
+// desiredEvictionProtection 按 coordinator 的供货状态计算期望的 PDB
+//
+// 返回的 start 为零时应该删除 PDB；recheck 是到下一次状态变化（maxDuration 用完、PDB 该删除）的时间
+func (r *LLMServiceReconciler) desiredEvictionProtection(ctx context.Context, llm *aiv1.LLMService, now time.Time) (*policyv1.PodDisruptionBudget, time.Time, time.Duration, error) {
+	d := llm.Spec.Distribution
+	if d == nil || d.EvictionProtection == nil {
+		return desiredCoordinatorPDB(llm, false, time.Time{}), time.Time{}, 0, nil
+	}
+	maxDuration := defaultEvictionProtectionMaxDuration
+	if d.EvictionProtection.MaxDuration != nil {
+		maxDuration = d.EvictionProtection.MaxDuration.Duration
+	}
+
+	seeding := false
+	if llm.Status.CacheCoordinator != "" {
+		pod := &corev1.Pod{}
+		err := r.Get(ctx, types.NamespacedName{Namespace: llm.Namespace, Name: llm.Status.CacheCoordinator}, pod)
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, time.Time{}, 0, err
+		}
+		if err == nil && pod.DeletionTimestamp.IsZero() {
+			seeding = coordinatorSeeding(pod, d.EvictionProtection)
+		}
+	}
+	start, err := r.protectionStart(ctx, llm)
+	if err != nil {
+		return nil, time.Time{}, 0, err
+	}
+
+	protected, start, recheck := evictionProtected(seeding, start, maxDuration, now)
+	return desiredCoordinatorPDB(llm, protected, start), start, recheck, nil
+}
+
+// reconcileEvictionProtection 按 coordinator 的供货状态创建、放开或删除 PDB
+//
+// 返回值是到下一次状态变化的时间：注解不再变化时也要按时处理
+func (r *LLMServiceReconciler) reconcileEvictionProtection(ctx context.Context, llm *aiv1.LLMService, now time.Time) (time.Duration, error) {
+	pdb, start, recheck, err := r.desiredEvictionProtection(ctx, llm, now)
+	if err != nil {
+		return 0, err
+	}
+	if start.IsZero() {
+		return 0, r.deleteIfExists(ctx, pdb)
+	}
+	ac, err := pdbApplyConfiguration(llm, pdb)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.apply(ctx, ac); err != nil {
+		return 0, fmt.Errorf("failed to apply PodDisruptionBudget: %w", err)
+	}
+	return recheck, nil
+}
+
+// planEvictionProtection 对应 reconcileEvictionProtection：创建、删除 PDB，或者改变它是否阻止驱逐
+func (r *LLMServiceReconciler) planEvictionProtection(ctx context.Context, llm *aiv1.LLMService, now time.Time) ([]string, error) {
+	pdb, start, _, err := r.desiredEvictionProtection(ctx, llm, now)
+	if err != nil {
+		return nil, err
+	}
+	if start.IsZero() {
+		return r.planDelete(ctx, pdb, "PodDisruptionBudget")
+	}
+	found := &policyv1.PodDisruptionBudget{}
+	err = r.Get(ctx, client.ObjectKeyFromObject(pdb), found)
+	if errors.IsNotFound(err) {
+		return []string{fmt.Sprintf("create PodDisruptionBudget %s with maxUnavailable %s", pdb.Name, pdb.Spec.MaxUnavailable)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if found.Spec.MaxUnavailable == nil || *found.Spec.MaxUnavailable != *pdb.Spec.MaxUnavailable {
+		return []string{fmt.Sprintf("update PodDisruptionBudget %s: maxUnavailable %s -> %s",
+			pdb.Name, found.Spec.MaxUnavailable, pdb.Spec.MaxUnavailable)}, nil
+	}
+	return nil, nil
+}
+
+// agentAnnotations 是 agent 写在推理 Pod 上、controller 要及时响应的注解
+var agentAnnotations = []string{
+	cacheinfo.ActiveTransfersAnnotation,
+	cacheinfo.TransfersSinceAnnotation,
+	cacheinfo.DownloadStateAnnotation,
+}
+
+// agentAnnotationsChanged 只关心推理 Pod 上 agentAnnotations 的变化
+var agentAnnotationsChanged = predicate.Funcs{
+	CreateFunc: func(event.CreateEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.ObjectNew.GetLabels()["app"] != inferencePodApp {
+			return false
+		}
+		oldAnnotations, newAnnotations := e.ObjectOld.GetAnnotations(), e.ObjectNew.GetAnnotations()
+		for _, key := range agentAnnotations {
+			if oldAnnotations[key] != newAnnotations[key] {
+				return true
+			}
+		}
+		return false
+	},
+	DeleteFunc:  func(event.DeleteEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+}
+
+// podToLLMService 把推理 Pod 映射回它所属的 LLMService（llm_cr label）
+func (r *LLMServiceReconciler) podToLLMService(_ context.Context, obj client.Object) []reconcile.Request {
+	name := obj.GetLabels()["llm_cr"]
+	if name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: name}}}
+}
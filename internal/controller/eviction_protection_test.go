@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+func TestSeedingThreshold(t *testing.T) {
+	tests := []struct {
+		name   string
+		active string
+		spec   aiv1.EvictionProtectionSpec
+		want   bool
+	}{
+		{name: "no transfers"},
+		{name: "one follower", active: "1", want: true},
+		{name: "below threshold", active: "2", spec: aiv1.EvictionProtectionSpec{MinActiveTransfers: 3}},
+		{name: "at threshold", active: "3", spec: aiv1.EvictionProtectionSpec{MinActiveTransfers: 3}, want: true},
+		{name: "garbage", active: "many"},
+	}
+
+	for _, tt := range tests {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+		if tt.active != "" {
+			pod.Annotations[cacheinfo.ActiveTransfersAnnotation] = tt.active
+		}
+		if got := coordinatorSeeding(pod, &tt.spec); got != tt.want {
+			t.Errorf("%s: coordinatorSeeding = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEvictionProtected(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	const maxDuration = 30 * time.Minute
+	tests := []struct {
+		name          string
+		seeding       bool
+		started       time.Duration // PDB 创建于 now 之前多久，0 表示没有 PDB
+		wantProtected bool
+		wantStart     time.Duration // 期望的开始时间在 now 之前多久，-1 表示删除 PDB
+		wantRecheck   time.Duration
+	}{
+		{name: "idle", wantStart: -1},
+		{name: "first transfer", seeding: true, wantProtected: true, wantRecheck: maxDuration},
+		{name: "still seeding", seeding: true, started: 10 * time.Minute, wantProtected: true,
+			wantStart: 10 * time.Minute, wantRecheck: 20 * time.Minute},
+		{name: "hard override", seeding: true, started: 31 * time.Minute, wantStart: 31 * time.Minute, wantRecheck: 29 * time.Minute},
+		// 传输数短暂降到 0 不会重新计时
+		{name: "gap between files", started: 10 * time.Minute, wantStart: 10 * time.Minute, wantRecheck: 50 * time.Minute},
+		{name: "resumes after gap", seeding: true, started: 40 * time.Minute, wantStart: 40 * time.Minute, wantRecheck: 20 * time.Minute},
+		{name: "cooled down", started: time.Hour, wantStart: -1},
+		{name: "new round after cooldown", seeding: true, started: 2 * time.Hour, wantProtected: true, wantRecheck: maxDuration},
+	}
+
+	for _, tt := range tests {
+		var start time.Time
+		if tt.started > 0 {
+			start = now.Add(-tt.started)
+		}
+		protected, gotStart, recheck := evictionProtected(tt.seeding, start, maxDuration, now)
+		wantStart := now.Add(-tt.wantStart)
+		if tt.wantStart < 0 {
+			wantStart = time.Time{}
+		}
+		if protected != tt.wantProtected || !gotStart.Equal(wantStart) || recheck != tt.wantRecheck {
+			t.Errorf("%s: evictionProtected = %v, %v, %v, want %v, %v, %v",
+				tt.name, protected, gotStart, recheck, tt.wantProtected, wantStart, tt.wantRecheck)
+		}
+	}
+}
+
+func TestReconcileEvictionProtection(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = aiv1.AddToScheme(scheme)
+	llm := testLLMService()
+	llm.Spec.Distribution = &aiv1.DistributionSpec{EvictionProtection: &aiv1.EvictionProtectionSpec{MinActiveTransfers: 2}}
+	llm.Status.CacheCoordinator = "qwen-a"
+
+	now := time.Now().Truncate(time.Second)
+	coordinator := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "qwen-a", Namespace: llm.Namespace, Labels: podLabels(llm),
+		Annotations: map[string]string{
+			cacheinfo.ActiveTransfersAnnotation: "4",
+			cacheinfo.TransfersSinceAnnotation:  now.Add(-time.Minute).Format(time.RFC3339),
+		},
+	}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(coordinator).Build()
+	r := &LLMServiceReconciler{Client: c}
+	key := types.NamespacedName{Namespace: llm.Namespace, Name: coordinatorPDBName(llm)}
+
+	changes, err := r.planEvictionProtection(context.Background(), llm, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "create PodDisruptionBudget " + coordinatorPDBName(llm) + " with maxUnavailable 0"; !slices.Equal(changes, []string{want}) {
+		t.Errorf("plan = %v, want [%s]", changes, want)
+	}
+
+	recheck, err := r.reconcileEvictionProtection(context.Background(), llm, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pdb := &policyv1.PodDisruptionBudget{}
+	if err := c.Get(context.Background(), key, pdb); err != nil {
+		t.Fatalf("PDB should exist while 4 followers are syncing: %v", err)
+	}
+	if pdb.Spec.MaxUnavailable.IntValue() != 0 || pdb.Spec.Selector.MatchLabels[cacheinfo.RoleLabel] != cacheinfo.RoleCoordinator {
+		t.Errorf("pdb spec = %+v, want maxUnavailable 0 for the coordinator only", pdb.Spec)
+	}
+	if recheck != 30*time.Minute {
+		t.Errorf("recheck = %v, want 30m (when maxDuration runs out)", recheck)
+	}
+
+	// agent 上报的开始时间不影响计时：超过 maxDuration 后 PDB 放开
+	coordinator.Annotations[cacheinfo.TransfersSinceAnnotation] = now.Add(time.Hour).Format(time.RFC3339)
+	if err := c.Update(context.Background(), coordinator); err != nil {
+		t.Fatal(err)
+	}
+	changes, err = r.planEvictionProtection(context.Background(), llm, now.Add(31*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "update PodDisruptionBudget " + coordinatorPDBName(llm) + ": maxUnavailable 0 -> 1"; !slices.Equal(changes, []string{want}) {
+		t.Errorf("plan = %v, want [%s]", changes, want)
+	}
+	if _, err := r.reconcileEvictionProtection(context.Background(), llm, now.Add(31*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(context.Background(), key, pdb); err != nil {
+		t.Fatal(err)
+	}
+	if pdb.Spec.MaxUnavailable.IntValue() != 1 || pdb.Annotations[protectionStartAnnotation] != now.UTC().Format(time.RFC3339) {
+		t.Errorf("pdb = %v %+v, want maxUnavailable 1 and the original start", pdb.Annotations, pdb.Spec)
+	}
+
+	// 传输结束并且过了 2*maxDuration：删除 PDB
+	delete(coordinator.Annotations, cacheinfo.ActiveTransfersAnnotation)
+	if err := c.Update(context.Background(), coordinator); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.reconcileEvictionProtection(context.Background(), llm, now.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(context.Background(), key, pdb); err == nil {
+		t.Error("PDB should be deleted after 2*maxDuration without transfers")
+	}
+}
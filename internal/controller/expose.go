@@ -0,0 +1,313 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	networkingv1ac "k8s.io/client-go/applyconfigurations/networking/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// 对外发布（spec.expose）
+// ============================================================================
+//
+// 以前要拿到一个公网的 OpenAI 兼容地址，得自己写 Ingress/HTTPRoute 指向 <name>-gateway 或 <name>-vllm，
+// 开关 gateway 之后还要记得改 backend。现在声明 spec.expose，controller 生成对应的对象：
+//
+//	Ingress       networking.k8s.io/v1 Ingress <name>，host + 可选 TLS
+//	GatewayAPI    gateway.networking.k8s.io/v1 HTTPRoute <name>，挂到 spec.expose.gatewayRef
+//	LoadBalancer  Service <name>-public（type=LoadBalancer，80 → 8000）
+//
+// backend 是 gateway（开启时）或 vLLM Service，两者都是 8000 端口。
+// Ingress 和 HTTPRoute 只转发 /v1（写到 status.endpoint 的地址），gateway 的 /metrics（按 API key 统计的用量）
+// 和 /scaler/metrics 不对外；LoadBalancer 是四层转发，做不到按路径过滤，gateway 所有路径都会暴露出去。
+// 生成的对象类型记在 status.endpoint.type，类型变了或者去掉 spec.expose 时删除旧对象。
+// HTTPRoute 用 unstructured（和 KEDA 的 ScaledObject 一样不引入 Gateway API 的 Go 依赖）。
+// ============================================================================
+
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
+
+// httpRouteGVK 是 Gateway API HTTPRoute 的类型
+var httpRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}
+
+// externalDNSHostnameAnnotation 让 external-dns 给 LoadBalancer 的地址创建 DNS 记录
+const externalDNSHostnameAnnotation = "external-dns.alpha.kubernetes.io/hostname"
+
+// publicServiceName 是 LoadBalancer Service 的名称
+func publicServiceName(llm *aiv1.LLMService) string {
+	return llm.Name + "-public"
+}
+
+// exposeBackend 是对外流量的目标 Service：有 gateway 时走 gateway（休眠唤醒、限流、SLO 指标都在 gateway 上）
+func (r *LLMServiceReconciler) exposeBackend(llm *aiv1.LLMService) (name string, selector map[string]string) {
+	if r.gatewayEnabled(llm) {
+		return gatewayName(llm), gatewayLabels(llm)
+	}
+	return vllmServiceName(llm), podLabels(llm)
+}
+
+// exposeAnnotations 复制 spec.expose.annotations，nil 时返回 nil
+func exposeAnnotations(e *aiv1.ExposeSpec) map[string]string {
+	if len(e.Annotations) == 0 {
+		return nil
+	}
+	annotations := make(map[string]string, len(e.Annotations))
+	for k, v := range e.Annotations {
+		annotations[k] = v
+	}
+	return annotations
+}
+
+// exposedPath 是对外发布的路径前缀（OpenAI 兼容 API）
+const exposedPath = "/v1"
+
+// desiredIngress 生成把 host 下 /v1 转发到 backend 的 Ingress
+func (r *LLMServiceReconciler) desiredIngress(llm *aiv1.LLMService) *networkingv1.Ingress {
+	e := llm.Spec.Expose
+	backend, _ := r.exposeBackend(llm)
+	pathType := networkingv1.PathTypePrefix
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        llm.Name,
+			Namespace:   llm.Namespace,
+			Labels:      podLabels(llm),
+			Annotations: exposeAnnotations(e),
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: e.IngressClassName,
+			Rules: []networkingv1.IngressRule{{
+				Host: e.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     exposedPath,
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+							Name: backend,
+							Port: networkingv1.ServiceBackendPort{Number: 8000},
+						}},
+					}},
+				}},
+			}},
+		},
+	}
+	if e.TLSSecretRef != nil {
+		ing.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{e.Host}, SecretName: e.TLSSecretRef.Name}}
+	}
+	return ing
+}
+
+// emptyHTTPRoute 只有类型和名称，用于删除
+func emptyHTTPRoute(llm *aiv1.LLMService) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(httpRouteGVK)
+	u.SetName(llm.Name)
+	u.SetNamespace(llm.Namespace)
+	return u
+}
+
+// desiredHTTPRoute 生成挂到 gatewayRef 上、把 host 下 /v1 转发到 backend 的 HTTPRoute
+func (r *LLMServiceReconciler) desiredHTTPRoute(llm *aiv1.LLMService) *unstructured.Unstructured {
+	e := llm.Spec.Expose
+	backend, _ := r.exposeBackend(llm)
+
+	parent := map[string]any{"name": e.GatewayRef.Name}
+	if e.GatewayRef.Namespace != "" {
+		parent["namespace"] = e.GatewayRef.Namespace
+	}
+	if e.GatewayRef.SectionName != "" {
+		parent["sectionName"] = e.GatewayRef.SectionName
+	}
+
+	isController := true
+	u := emptyHTTPRoute(llm)
+	u.SetLabels(podLabels(llm))
+	u.SetAnnotations(exposeAnnotations(e))
+	u.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion:         aiv1.GroupVersion.String(),
+		Kind:               "LLMService",
+		Name:               llm.Name,
+		UID:                llm.UID,
+		Controller:         &isController,
+		BlockOwnerDeletion: &isController,
+	}})
+	u.Object["spec"] = map[string]any{
+		"parentRefs": []any{parent},
+		"hostnames":  []any{e.Host},
+		"rules": []any{map[string]any{
+			"matches":     []any{map[string]any{"path": map[string]any{"type": "PathPrefix", "value": exposedPath}}},
+			"backendRefs": []any{map[string]any{"name": backend, "port": int64(8000)}},
+		}},
+	}
+	return u
+}
+
+// desiredPublicService 生成 80 端口的 LoadBalancer Service
+func (r *LLMServiceReconciler) desiredPublicService(llm *aiv1.LLMService) *corev1.Service {
+	e := llm.Spec.Expose
+	_, selector := r.exposeBackend(llm)
+	annotations := exposeAnnotations(e)
+	if e.Host != "" {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[externalDNSHostnameAnnotation] = e.Host
+	}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        publicServiceName(llm),
+			Namespace:   llm.Namespace,
+			Labels:      podLabels(llm),
+			Annotations: annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeLoadBalancer,
+			Selector: selector,
+			Ports: []corev1.ServicePort{{
+				Name:       "http",
+				Port:       80,
+				TargetPort: intstr.FromInt32(8000),
+			}},
+		},
+	}
+}
+
+// ingressApplyConfiguration 把期望的 Ingress 转成 apply configuration
+func ingressApplyConfiguration(llm *aiv1.LLMService, ing *networkingv1.Ingress) (*networkingv1ac.IngressApplyConfiguration, error) {
+	ac := &networkingv1ac.IngressApplyConfiguration{}
+	if err := convertToApply(ing, ac); err != nil {
+		return nil, err
+	}
+	ac.WithAPIVersion("networking.k8s.io/v1").WithKind("Ingress").WithOwnerReferences(ownerReference(llm))
+	return ac, nil
+}
+
+// reconcileExpose 根据 spec.expose 创建对外发布的对象，删除不再需要的，更新 status.endpoint
+func (r *LLMServiceReconciler) reconcileExpose(ctx context.Context, llm *aiv1.LLMService) error {
+	e := llm.Spec.Expose
+	if old := llm.Status.Endpoint; old != nil && (e == nil || e.Type != old.Type) {
+		if err := r.deleteExposed(ctx, llm, old.Type); err != nil {
+			return err
+		}
+		llm.Status.Endpoint = nil
+	}
+	if e == nil {
+		return nil
+	}
+
+	endpoint := &aiv1.EndpointStatus{Type: e.Type}
+	switch e.Type {
+	case aiv1.ExposeTypeIngress:
+		ac, err := ingressApplyConfiguration(llm, r.desiredIngress(llm))
+		if err != nil {
+			return err
+		}
+		if err := r.apply(ctx, ac); err != nil {
+			return fmt.Errorf("failed to apply Ingress: %w", err)
+		}
+		scheme := "http"
+		if e.TLSSecretRef != nil {
+			scheme = "https"
+		}
+		endpoint.URL = fmt.Sprintf("%s://%s/v1", scheme, e.Host)
+
+	case aiv1.ExposeTypeGatewayAPI:
+		if e.GatewayRef == nil {
+			return fmt.Errorf("spec.expose.gatewayRef is required when type is GatewayAPI")
+		}
+		if err := r.apply(ctx, client.ApplyConfigurationFromUnstructured(r.desiredHTTPRoute(llm))); err != nil {
+			if meta.IsNoMatchError(err) && r.Recorder != nil {
+				r.Recorder.Event(llm, corev1.EventTypeWarning, "GatewayAPINotInstalled",
+					"spec.expose.type is GatewayAPI but the HTTPRoute CRD (gateway.networking.k8s.io/v1) is not installed")
+			}
+			return fmt.Errorf("failed to apply HTTPRoute: %w", err)
+		}
+		// HTTPS 还是 HTTP 取决于 Gateway 的 listener，这里看不到，按生产环境的常见配置写 https
+		endpoint.URL = fmt.Sprintf("https://%s/v1", e.Host)
+
+	case aiv1.ExposeTypeLoadBalancer:
+		svc := r.desiredPublicService(llm)
+		if err := r.applyService(ctx, llm, svc); err != nil {
+			return fmt.Errorf("failed to apply LoadBalancer Service: %w", err)
+		}
+		host := e.Host
+		if host == "" {
+			host = r.loadBalancerAddress(ctx, svc)
+		}
+		if host != "" {
+			endpoint.URL = fmt.Sprintf("http://%s/v1", urlHost(host))
+		}
+
+	default:
+		return fmt.Errorf("unknown spec.expose.type %q", e.Type)
+	}
+	llm.Status.Endpoint = endpoint
+	return nil
+}
+
+// loadBalancerAddress 读 LoadBalancer Service 分配到的 IP 或主机名，还没分配时返回空
+//
+// Service 在 Owns 里，云厂商分配地址后会触发下一次 reconcile
+func (r *LLMServiceReconciler) loadBalancerAddress(ctx context.Context, svc *corev1.Service) string {
+	found := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}, found); err != nil {
+		return ""
+	}
+	for _, ing := range found.Status.LoadBalancer.Ingress {
+		if ing.Hostname != "" {
+			return ing.Hostname
+		}
+		if ing.IP != "" {
+			return ing.IP
+		}
+	}
+	return ""
+}
+
+// urlHost 给 IPv6 地址加上方括号，才能放进 URL（http://[2001:db8::1]/v1）
+func urlHost(host string) string {
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		return "[" + host + "]"
+	}
+	return host
+}
+
+// exposedObject 返回按 exposeType 生成的对象（只有类型和名称）和它的 Kind，未知类型返回 nil
+func exposedObject(llm *aiv1.LLMService, exposeType string) (client.Object, string) {
+	objMeta := metav1.ObjectMeta{Name: llm.Name, Namespace: llm.Namespace}
+	switch exposeType {
+	case aiv1.ExposeTypeIngress:
+		return &networkingv1.Ingress{ObjectMeta: objMeta}, "Ingress"
+	case aiv1.ExposeTypeGatewayAPI:
+		return emptyHTTPRoute(llm), "HTTPRoute"
+	case aiv1.ExposeTypeLoadBalancer:
+		objMeta.Name = publicServiceName(llm)
+		return &corev1.Service{ObjectMeta: objMeta}, "Service"
+	}
+	return nil, ""
+}
+
+// deleteExposed 删除以前按 exposeType 生成的对象
+func (r *LLMServiceReconciler) deleteExposed(ctx context.Context, llm *aiv1.LLMService, exposeType string) error {
+	obj, _ := exposedObject(llm, exposeType)
+	if obj == nil {
+		return nil
+	}
+	if err := r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+		return fmt.Errorf("failed to delete %s for spec.expose: %w", exposeType, err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestExposeBackend(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	llm.Spec.Expose = &aiv1.ExposeSpec{
+		Type:         aiv1.ExposeTypeIngress,
+		Host:         "qwen.example.com",
+		TLSSecretRef: &corev1.LocalObjectReference{Name: "qwen-tls"},
+	}
+
+	backend := func(ing *networkingv1.Ingress) string {
+		return ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name
+	}
+	ing := r.desiredIngress(llm)
+	if got := backend(ing); got != vllmServiceName(llm) {
+		t.Errorf("backend without gateway = %s, want %s", got, vllmServiceName(llm))
+	}
+	if path := ing.Spec.Rules[0].HTTP.Paths[0].Path; path != "/v1" {
+		t.Errorf("ingress path = %s, want /v1 only (gateway /metrics must stay private)", path)
+	}
+	if len(ing.Spec.TLS) != 1 || ing.Spec.TLS[0].SecretName != "qwen-tls" || ing.Spec.TLS[0].Hosts[0] != "qwen.example.com" {
+		t.Errorf("tls = %+v, want qwen-tls for qwen.example.com", ing.Spec.TLS)
+	}
+
+	llm.Spec.Gateway = &aiv1.GatewaySpec{Enabled: true}
+	if got := backend(r.desiredIngress(llm)); got != gatewayName(llm) {
+		t.Errorf("backend with gateway = %s, want %s", got, gatewayName(llm))
+	}
+
+	llm.Spec.Expose = &aiv1.ExposeSpec{Type: aiv1.ExposeTypeGatewayAPI, Host: "qwen.example.com",
+		GatewayRef: &aiv1.GatewayReference{Name: "public", Namespace: "infra"}}
+	route := r.desiredHTTPRoute(llm)
+	spec := route.Object["spec"].(map[string]any)
+	parent := spec["parentRefs"].([]any)[0].(map[string]any)
+	if parent["name"] != "public" || parent["namespace"] != "infra" {
+		t.Errorf("parentRefs = %v, want infra/public", spec["parentRefs"])
+	}
+	rule := spec["rules"].([]any)[0].(map[string]any)
+	ref := rule["backendRefs"].([]any)[0].(map[string]any)
+	if ref["name"] != gatewayName(llm) {
+		t.Errorf("backendRefs = %v, want %s", ref, gatewayName(llm))
+	}
+	path := rule["matches"].([]any)[0].(map[string]any)["path"].(map[string]any)
+	if path["type"] != "PathPrefix" || path["value"] != "/v1" {
+		t.Errorf("matches = %v, want PathPrefix /v1", rule["matches"])
+	}
+}
+
+func TestReconcileExposeSwitchType(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = aiv1.AddToScheme(scheme)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &LLMServiceReconciler{Client: c, Scheme: scheme}
+	llm := testLLMService()
+	llm.Spec.Expose = &aiv1.ExposeSpec{Type: aiv1.ExposeTypeLoadBalancer, Host: "qwen.example.com"}
+	ctx := context.Background()
+
+	changes, err := r.planExpose(ctx, llm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "create Service " + publicServiceName(llm); !slices.Equal(changes, []string{want}) {
+		t.Errorf("plan = %v, want [%s]", changes, want)
+	}
+
+	if err := r.reconcileExpose(ctx, llm); err != nil {
+		t.Fatal(err)
+	}
+	if e := llm.Status.Endpoint; e == nil || e.URL != "http://qwen.example.com/v1" {
+		t.Fatalf("endpoint = %+v, want http://qwen.example.com/v1", e)
+	}
+	svc := &corev1.Service{}
+	key := types.NamespacedName{Namespace: llm.Namespace, Name: publicServiceName(llm)}
+	if err := c.Get(ctx, key, svc); err != nil {
+		t.Fatal(err)
+	}
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer || svc.Annotations[externalDNSHostnameAnnotation] != "qwen.example.com" {
+		t.Errorf("service = %+v, want a LoadBalancer with the external-dns hostname", svc)
+	}
+
+	// 去掉 spec.expose：删除 LoadBalancer Service
+	llm.Spec.Expose = nil
+	changes, err = r.planExpose(ctx, llm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "delete Service " + publicServiceName(llm); !slices.Equal(changes, []string{want}) {
+		t.Errorf("plan = %v, want [%s]", changes, want)
+	}
+	if err := r.reconcileExpose(ctx, llm); err != nil {
+		t.Fatal(err)
+	}
+	if llm.Status.Endpoint != nil {
+		t.Errorf("endpoint = %+v, want nil", llm.Status.Endpoint)
+	}
+	if err := c.Get(ctx, key, svc); err == nil {
+		t.Error("LoadBalancer Service was not deleted")
+	}
+}
@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/audit"
+	"github.com/Moore-Z/kubeinfer/internal/notify"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+// ============================================================================
+// 节点故障检测：coordinator 所在节点 NotReady 时强制让出 Lease
+// ============================================================================
+//
+// 为什么不等 Lease 自然过期？
+// - kubelet 挂了但容器还在跑时，agent 仍然能续约，Lease 永远不会过期
+// - 但这个节点上的 Pod 网络可能已经不通，follower 下载到一半就卡住
+//
+// 做法：controller 发现 coordinator 所在节点 NotReady（或 coordinator Pod 已经不存在），
+// 直接清空 Lease 的 HolderIdentity/RenewTime，follower 在下一个 retryPeriod 就能接管。
+//
+// 只清空持有者挡不住旧的 coordinator：kubelet 挂了但容器还活着时，它看到没有持有者的 Lease 会马上抢回来。
+// 所以同时把它的名字写进 cacheinfo.EvictedCoordinatorAnnotation，agent 不接管写着自己名字的 Lease；
+// 这个 Pod 消失或者节点恢复 Ready 之后再删掉注解。
+// Pod 不存在是从 informer 缓存里看到的，可能只是缓存落后，强制接管之前再直接问一次 API server。
+// ============================================================================
+
+// leaseName 和 agent 保持一致：CONFIGMAP_NAME + "-lease"
+func leaseName(llm *aiv1.LLMService) string {
+	return cacheinfo.ConfigMapName(llm.Name) + "-lease"
+}
+
+// nodeReady 判断节点的 Ready condition 是否为 True
+func nodeReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// checkCoordinatorNode 更新 Status.CacheCoordinator/CoordinatorNode，
+// 如果 coordinator 所在节点不健康，强制让出 Lease
+func (r *LLMServiceReconciler) checkCoordinatorNode(ctx context.Context, llm *aiv1.LLMService) error {
+	l := log.FromContext(ctx)
+
+	lease := &coordinationv1.Lease{}
+	err := r.Get(ctx, types.NamespacedName{Name: leaseName(llm), Namespace: llm.Namespace}, lease)
+	if errors.IsNotFound(err) {
+		// 还没有 agent 参与选举
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get coordinator lease: %w", err)
+	}
+
+	if err := r.clearEvictedCoordinator(ctx, lease); err != nil {
+		return err
+	}
+
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity == "" {
+		llm.Status.CacheCoordinator = ""
+		llm.Status.CoordinatorNode = ""
+		return nil
+	}
+	holder := *lease.Spec.HolderIdentity
+
+	if holder != llm.Status.CacheCoordinator {
+		metrics.RecordCoordinatorElection(llm.Namespace, llm.Name)
+	}
+	llm.Status.CacheCoordinator = holder
+
+	// reason 给人看，trigger 是审计记录里的原因分类
+	reason, trigger := "", ""
+	pod := &corev1.Pod{}
+	podKey := types.NamespacedName{Name: holder, Namespace: llm.Namespace}
+	err = r.Get(ctx, podKey, pod)
+	if errors.IsNotFound(err) {
+		err = r.apiReader().Get(ctx, podKey, pod)
+	}
+	switch {
+	case errors.IsNotFound(err):
+		reason = fmt.Sprintf("coordinator pod %s no longer exists", holder)
+		trigger = "pod-deleted"
+	case err != nil:
+		return fmt.Errorf("failed to get coordinator pod: %w", err)
+	default:
+		llm.Status.CoordinatorNode = pod.Spec.NodeName
+		if pod.Spec.NodeName != "" {
+			node := &corev1.Node{}
+			nodeKey := types.NamespacedName{Name: pod.Spec.NodeName}
+			err := r.Get(ctx, nodeKey, node)
+			if errors.IsNotFound(err) {
+				err = r.apiReader().Get(ctx, nodeKey, node)
+			}
+			if err != nil {
+				if !errors.IsNotFound(err) {
+					return fmt.Errorf("failed to get coordinator node: %w", err)
+				}
+				reason = fmt.Sprintf("node %s hosting coordinator %s was removed", pod.Spec.NodeName, holder)
+				trigger = "node-removed"
+			} else if !nodeReady(node) {
+				reason = fmt.Sprintf("node %s hosting coordinator %s is NotReady", pod.Spec.NodeName, holder)
+				trigger = "node-not-ready"
+			}
+		}
+	}
+
+	if reason == "" {
+		return nil
+	}
+
+	// 清空持有者，isLeaseExpired 会把缺少 RenewTime 的 Lease 当成已过期；旧的持有者不能再抢回来
+	l.Info("Forcing coordinator lease takeover", "reason", reason)
+	lease.Spec.HolderIdentity = nil
+	lease.Spec.RenewTime = nil
+	if lease.Annotations == nil {
+		lease.Annotations = map[string]string{}
+	}
+	lease.Annotations[cacheinfo.EvictedCoordinatorAnnotation] = holder
+	if err := r.Update(ctx, lease); err != nil {
+		return fmt.Errorf("failed to release coordinator lease: %w", err)
+	}
+	if r.Recorder != nil {
+		r.Recorder.Event(llm, corev1.EventTypeWarning, "CoordinatorFailover", reason)
+	}
+	r.recordAudit(ctx, llm, audit.ActionFailover, trigger, "released coordinator lease: "+reason)
+	r.sendNotification(ctx, llm, notify.EventCoordinatorFailover, reason)
+	llm.Status.CacheCoordinator = ""
+	llm.Status.CoordinatorNode = ""
+	return nil
+}
+
+// clearEvictedCoordinator 在被强制让出的 coordinator 消失或者节点恢复 Ready 之后删掉 EvictedCoordinatorAnnotation，
+// 它又可以参与选举了（只有一个副本时只能等它）
+func (r *LLMServiceReconciler) clearEvictedCoordinator(ctx context.Context, lease *coordinationv1.Lease) error {
+	evicted := lease.Annotations[cacheinfo.EvictedCoordinatorAnnotation]
+	if evicted == "" {
+		return nil
+	}
+	pod := &corev1.Pod{}
+	err := r.apiReader().Get(ctx, types.NamespacedName{Name: evicted, Namespace: lease.Namespace}, pod)
+	switch {
+	case errors.IsNotFound(err):
+	case err != nil:
+		return fmt.Errorf("failed to get evicted coordinator pod: %w", err)
+	case pod.Spec.NodeName == "":
+		return nil
+	default:
+		node := &corev1.Node{}
+		if err := r.apiReader().Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+			if errors.IsNotFound(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to get evicted coordinator node: %w", err)
+		}
+		if !nodeReady(node) {
+			return nil
+		}
+	}
+	delete(lease.Annotations, cacheinfo.EvictedCoordinatorAnnotation)
+	if err := r.Update(ctx, lease); err != nil {
+		return fmt.Errorf("failed to clear evicted coordinator: %w", err)
+	}
+	log.FromContext(ctx).Info("Evicted coordinator may take part in the election again", "pod", evicted)
+	return nil
+}
+
+// apiReader 返回不经过 informer 缓存的 Reader，没有设置（测试）时用 Client
+func (r *LLMServiceReconciler) apiReader() client.Reader {
+	if r.APIReader != nil {
+		return r.APIReader
+	}
+	return r.Client
+}
+
+// nodeToLLMServices 把节点事件映射到 coordinator 在这个节点上的 LLMService
+func (r *LLMServiceReconciler) nodeToLLMServices(ctx context.Context, obj client.Object) []reconcile.Request {
+	list := &aiv1.LLMServiceList{}
+	if err := r.List(ctx, list); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list LLMServices for node event")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, llm := range list.Items {
+		if llm.Status.CoordinatorNode == obj.GetName() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: llm.Name, Namespace: llm.Namespace},
+			})
+		}
+	}
+	return requests
+}
+
+// nodeReadinessChanged 只关心 Ready 状态变化，忽略节点的心跳更新
+var nodeReadinessChanged = predicate.Funcs{
+	CreateFunc: func(event.CreateEvent) bool { return false },
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldNode, ok1 := e.ObjectOld.(*corev1.Node)
+		newNode, ok2 := e.ObjectNew.(*corev1.Node)
+		return ok1 && ok2 && nodeReady(oldNode) != nodeReady(newNode)
+	},
+	DeleteFunc:  func(event.DeleteEvent) bool { return true },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+)
+
+func TestCheckCoordinatorNode(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = aiv1.AddToScheme(scheme)
+	ctx := context.Background()
+
+	holder := "qwen-a"
+	renew := metav1.NowMicro()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: holder, Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: "gpu-1"},
+	}
+	node := func(ready corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "gpu-1"},
+			Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}},
+		}
+	}
+
+	tests := []struct {
+		name string
+		// cached 是 informer 缓存里的对象，api 是 API server 上的（为 nil 时和 cached 一样）
+		cached, api  []client.Object
+		wantTakeover bool
+	}{
+		{name: "healthy", cached: []client.Object{pod, node(corev1.ConditionTrue)}},
+		{name: "node not ready", cached: []client.Object{pod, node(corev1.ConditionFalse)}, wantTakeover: true},
+		{name: "node removed", cached: []client.Object{pod}, wantTakeover: true},
+		{name: "pod deleted", wantTakeover: true},
+		// 缓存还没同步到 Pod，API server 上它还在、节点正常：不能强制接管
+		{name: "pod missing from the cache only", api: []client.Object{pod, node(corev1.ConditionTrue)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := testLLMService()
+			lease := &coordinationv1.Lease{
+				ObjectMeta: metav1.ObjectMeta{Name: leaseName(llm), Namespace: llm.Namespace},
+				Spec:       coordinationv1.LeaseSpec{HolderIdentity: &holder, RenewTime: &renew},
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(append([]client.Object{lease}, tt.cached...)...).Build()
+			r := &LLMServiceReconciler{Client: c}
+			if tt.api != nil {
+				r.APIReader = fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.api...).Build()
+			}
+
+			if err := r.checkCoordinatorNode(ctx, llm); err != nil {
+				t.Fatal(err)
+			}
+			got := &coordinationv1.Lease{}
+			if err := c.Get(ctx, types.NamespacedName{Name: leaseName(llm), Namespace: llm.Namespace}, got); err != nil {
+				t.Fatal(err)
+			}
+			released := got.Spec.HolderIdentity == nil
+			if released != tt.wantTakeover {
+				t.Fatalf("lease released = %v, want %v", released, tt.wantTakeover)
+			}
+			if evicted := got.Annotations[cacheinfo.EvictedCoordinatorAnnotation]; tt.wantTakeover && evicted != holder {
+				t.Errorf("%s = %q, want the old holder %s fenced", cacheinfo.EvictedCoordinatorAnnotation, evicted, holder)
+			}
+			if tt.wantTakeover && llm.Status.CacheCoordinator != "" {
+				t.Errorf("status.cacheCoordinator = %q after takeover", llm.Status.CacheCoordinator)
+			}
+		})
+	}
+}
+
+func TestClearEvictedCoordinator(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	ctx := context.Background()
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "qwen-a", Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: "gpu-1"},
+	}
+	node := func(ready corev1.ConditionStatus) *corev1.Node {
+		return &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "gpu-1"},
+			Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}}},
+		}
+	}
+	tests := []struct {
+		name      string
+		objects   []client.Object
+		wantFence bool
+	}{
+		{name: "node still not ready", objects: []client.Object{pod, node(corev1.ConditionFalse)}, wantFence: true},
+		{name: "node ready again", objects: []client.Object{pod, node(corev1.ConditionTrue)}},
+		{name: "pod gone", objects: []client.Object{node(corev1.ConditionFalse)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{
+				Name:        "qwen-cache-lease",
+				Namespace:   "default",
+				Annotations: map[string]string{cacheinfo.EvictedCoordinatorAnnotation: "qwen-a"},
+			}}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(append([]client.Object{lease}, tt.objects...)...).Build()
+			r := &LLMServiceReconciler{Client: c}
+			if err := r.clearEvictedCoordinator(ctx, lease); err != nil {
+				t.Fatal(err)
+			}
+			got := &coordinationv1.Lease{}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(lease), got); err != nil {
+				t.Fatal(err)
+			}
+			if _, fenced := got.Annotations[cacheinfo.EvictedCoordinatorAnnotation]; fenced != tt.wantFence {
+				t.Errorf("still fenced = %v, want %v", fenced, tt.wantFence)
+			}
+		})
+	}
+}
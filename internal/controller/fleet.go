@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/fleet"
+)
+
+// 成员集群通过 manager 命名空间下的 kubeconfig Secret 注册
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+
+// reconcileFleetFinalizer 维护 fleet finalizer
+//
+// 返回 true 表示 LLMService 正在删除且清理已经完成，Reconcile 不需要再往下走
+//
+// - 正在删除：先删成员集群里的副本，再去掉 finalizer
+// - 关闭了 fleet：删副本、去掉 finalizer，继续正常 reconcile
+// - 开启了 fleet：确保有 finalizer
+func (r *LLMServiceReconciler) reconcileFleetFinalizer(ctx context.Context, llm *aiv1.LLMService) (bool, error) {
+	deleting := !llm.DeletionTimestamp.IsZero()
+
+	if deleting || llm.Spec.Fleet == nil {
+		if !controllerutil.ContainsFinalizer(llm, fleet.Finalizer) {
+			return deleting, nil
+		}
+		log.FromContext(ctx).Info("Removing LLMService from member clusters")
+		if err := r.Fleet.Cleanup(ctx, llm); err != nil {
+			return false, err
+		}
+		controllerutil.RemoveFinalizer(llm, fleet.Finalizer)
+		return deleting, r.Update(ctx, llm)
+	}
+
+	if controllerutil.AddFinalizer(llm, fleet.Finalizer) {
+		return false, r.Update(ctx, llm)
+	}
+	return false, nil
+}
@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/gateway"
+)
+
+// vllmServiceName 是指向 vLLM Pods 的 Service 名称
+func vllmServiceName(llm *aiv1.LLMService) string {
+	return llm.Name + "-vllm"
+}
+
+// gatewayName 是 gateway Deployment 和 Service 的名称
+func gatewayName(llm *aiv1.LLMService) string {
+	return llm.Name + "-gateway"
+}
+
+// inferencePodApp 是 vLLM Pod 的 app label，manager 的 Pod 缓存也按它过滤（CacheOptions）
+const inferencePodApp = "llm-inference"
+
+// podLabels 是 vLLM Pod 的 label，Deployment selector 和 Service selector 共用
+func podLabels(llm *aiv1.LLMService) map[string]string {
+	return map[string]string{
+		"app":    inferencePodApp,
+		"llm_cr": llm.Name,
+	}
+}
+
+// gatewayLabels 是 gateway Pod 的 label
+func gatewayLabels(llm *aiv1.LLMService) map[string]string {
+	return map[string]string{
+		"app":    "llm-gateway",
+		"llm_cr": llm.Name,
+	}
+}
+
+// deleteIfExists 删除对象，不存在时忽略
+func (r *LLMServiceReconciler) deleteIfExists(ctx context.Context, obj client.Object) error {
+	return client.IgnoreNotFound(r.Delete(ctx, obj))
+}
+
+// reconcileGateway 根据 spec 创建或删除 gateway 的 Deployment 和 Service
+func (r *LLMServiceReconciler) reconcileGateway(ctx context.Context, llm *aiv1.LLMService) error {
+	deploy := r.desiredGatewayDeployment(llm)
+	svc := desiredGatewayService(llm)
+
+	if !r.gatewayEnabled(llm) {
+		if err := r.deleteIfExists(ctx, deploy); err != nil {
+			return fmt.Errorf("failed to delete gateway deployment: %w", err)
+		}
+		if err := r.deleteIfExists(ctx, svc); err != nil {
+			return fmt.Errorf("failed to delete gateway service: %w", err)
+		}
+		return nil
+	}
+
+	if err := r.applyDeployment(ctx, llm, deploy); err != nil {
+		return fmt.Errorf("failed to apply gateway deployment: %w", err)
+	}
+	if err := r.applyService(ctx, llm, svc); err != nil {
+		return fmt.Errorf("failed to apply gateway service: %w", err)
+	}
+	return nil
+}
+
+// desiredVLLMService 生成指向 vLLM Pods 的 ClusterIP Service
+func desiredVLLMService(llm *aiv1.LLMService) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      vllmServiceName(llm),
+			Namespace: llm.Namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: podLabels(llm),
+			Ports: []corev1.ServicePort{{
+				Name:       "http",
+				Port:       8000,
+				TargetPort: intstr.FromString("vllm"),
+			}},
+		},
+	}
+}
+
+// desiredGatewayService 生成 gateway 的 Service（客户端应该访问这个）
+func desiredGatewayService(llm *aiv1.LLMService) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gatewayName(llm),
+			Namespace: llm.Namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: gatewayLabels(llm),
+			Ports: []corev1.ServicePort{{
+				Name:       "http",
+				Port:       8000,
+				TargetPort: intstr.FromString("http"),
+			}},
+		},
+	}
+}
+
+// desiredGatewayDeployment 生成 gateway 的 Deployment
+func (r *LLMServiceReconciler) desiredGatewayDeployment(llm *aiv1.LLMService) *appsv1.Deployment {
+	replicas := int32(1)
+	if llm.Spec.Gateway != nil && llm.Spec.Gateway.Replicas > 0 {
+		replicas = llm.Spec.Gateway.Replicas
+	}
+	image := pinnedImage(llm, r.gatewayImage(llm))
+	labels := gatewayLabels(llm)
+
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gatewayName(llm),
+			Namespace: llm.Namespace,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:            "gateway",
+						Image:           image,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Command:         []string{"/gateway"},
+						Env: []corev1.EnvVar{
+							{Name: "LLMSERVICE_NAME", Value: llm.Name},
+							{
+								Name: "POD_NAMESPACE",
+								ValueFrom: &corev1.EnvVarSource{
+									FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+								},
+							},
+							{
+								Name:  "UPSTREAM_URL",
+								Value: fmt.Sprintf("http://%s.%s.svc:8000", vllmServiceName(llm), llm.Namespace),
+							},
+						},
+						Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8000}},
+						ReadinessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{
+								HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromString("http")},
+							},
+						},
+					}},
+					// gateway 需要 patch LLMService 的 annotation（config/rbac/gateway_role.yaml）
+					ServiceAccountName: "kubeinfer-gateway",
+				},
+			},
+		},
+	}
+
+	addFailoverSecret(llm, &deploy.Spec.Template.Spec)
+	addGuardrails(llm, &deploy.Spec.Template.Spec)
+	// restricted 命名空间里 gateway Pod 也要满足同样的要求
+	applyPodSecurity(llm, &deploy.Spec.Template)
+	return deploy
+}
+
+// addGuardrails 把 spec.gateway.guardrails 序列化成 gateway 的环境变量，修改后 gateway 滚动重启
+func addGuardrails(llm *aiv1.LLMService, pod *corev1.PodSpec) {
+	if llm.Spec.Gateway == nil || llm.Spec.Gateway.Guardrails == nil {
+		return
+	}
+	data, err := json.Marshal(llm.Spec.Gateway.Guardrails)
+	if err != nil {
+		return
+	}
+	c := &pod.Containers[0]
+	c.Env = append(c.Env, corev1.EnvVar{Name: gateway.GuardrailsEnv, Value: string(data)})
+}
+
+// failoverMountPath 是 failover Secret 在 gateway 容器里的挂载目录
+const failoverMountPath = "/etc/kubeinfer/failover"
+
+// addFailoverSecret 挂载 spec.gateway.failoverSecret，gateway 从里面读取其他集群的地址
+func addFailoverSecret(llm *aiv1.LLMService, pod *corev1.PodSpec) {
+	if llm.Spec.Gateway == nil || llm.Spec.Gateway.FailoverSecret == "" {
+		return
+	}
+
+	pod.Volumes = append(pod.Volumes, corev1.Volume{
+		Name: "failover",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: llm.Spec.Gateway.FailoverSecret},
+		},
+	})
+	c := &pod.Containers[0]
+	c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+		Name:      "failover",
+		MountPath: failoverMountPath,
+		ReadOnly:  true,
+	})
+	c.Env = append(c.Env, corev1.EnvVar{Name: "FAILOVER_CONFIG", Value: failoverMountPath + "/upstreams.json"})
+}
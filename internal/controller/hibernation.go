@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// 空闲检测与自动休眠
+// ============================================================================
+//
+// 流程：
+//  1. gateway 收到请求时把时间写到 annotation kubeinfer.io/last-request-time
+//  2. controller 发现超过 Spec.IdleTimeout 没有请求 → Hibernated=True，缩容到 IdleReplicas
+//  3. 休眠期间 gateway 收到请求 → 立即刷新 annotation，触发 reconcile
+//  4. controller 发现请求时间晚于休眠时间 → Hibernated=False，恢复 Spec.Replicas
+//
+// 休眠开始时间就是 Hibernated condition 的 LastUpdateTime。
+// ============================================================================
+
+// defaultIdleReplicas 休眠时默认保留 1 个副本，模型缓存不会丢
+const defaultIdleReplicas int32 = 1
+
+// gatewayEnabled 判断是否需要部署 gateway
+//
+// 空闲检测、SLO 和外部扩缩容都依赖 gateway 统计请求，所以设置了 IdleTimeout、SLO 或 External 模式就隐式开启。
+// 没有设置 spec.gateway 时看 operator 配置的 gateway.enabledByDefault。
+func (r *LLMServiceReconciler) gatewayEnabled(llm *aiv1.LLMService) bool {
+	if llm.Spec.IdleTimeout != nil || llm.Spec.SLO != nil || externalAutoscaling(llm) {
+		return true
+	}
+	if llm.Spec.Gateway == nil {
+		return r.config().Gateway.EnabledByDefault
+	}
+	return llm.Spec.Gateway.Enabled
+}
+
+// lastRequestTime 读取 gateway 写入的最近请求时间，没有的话返回 false
+func lastRequestTime(llm *aiv1.LLMService) (time.Time, bool) {
+	raw, ok := llm.Annotations[aiv1.LastRequestTimeAnnotation]
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// evaluateHibernation 根据最近请求时间更新 Hibernated condition
+//
+// 返回值是距离下一次需要检查的时间（0 表示不需要定时检查，
+// 例如已经休眠——这时只有新请求写 annotation 才会触发唤醒）。
+func evaluateHibernation(llm *aiv1.LLMService, now time.Time) time.Duration {
+	hibernated := isConditionTrue(llm, aiv1.ConditionHibernated)
+
+	if llm.Spec.IdleTimeout == nil {
+		if hibernated {
+			setCondition(llm, aiv1.ConditionHibernated, metav1.ConditionFalse,
+				"IdleTimeoutRemoved", "idleTimeout was removed from spec")
+		}
+		return 0
+	}
+	timeout := llm.Spec.IdleTimeout.Duration
+
+	// 最近一次活动：请求时间、创建时间、上次状态切换时间，取最晚的
+	lastActivity := llm.CreationTimestamp.Time
+	if t, ok := lastRequestTime(llm); ok && t.After(lastActivity) {
+		lastActivity = t
+	}
+	cond := findCondition(llm, aiv1.ConditionHibernated)
+
+	if hibernated {
+		if lastActivity.After(cond.LastUpdateTime.Time) {
+			setCondition(llm, aiv1.ConditionHibernated, metav1.ConditionFalse,
+				"RequestReceived", "woke up on incoming request")
+			return timeout
+		}
+		return 0
+	}
+
+	// 刚唤醒时从唤醒时间开始重新计时
+	if cond != nil && cond.LastUpdateTime.After(lastActivity) {
+		lastActivity = cond.LastUpdateTime.Time
+	}
+
+	idleFor := now.Sub(lastActivity)
+	if idleFor >= timeout {
+		setCondition(llm, aiv1.ConditionHibernated, metav1.ConditionTrue,
+			"Idle", fmt.Sprintf("no requests for %s, scaled down to %d replicas",
+				idleFor.Truncate(time.Second), idleReplicas(llm)))
+		return 0
+	}
+	return timeout - idleFor
+}
+
+// idleReplicas 休眠时保留的副本数（不超过 Spec.Replicas）
+func idleReplicas(llm *aiv1.LLMService) int32 {
+	replicas := defaultIdleReplicas
+	if llm.Spec.IdleReplicas != nil {
+		replicas = *llm.Spec.IdleReplicas
+	}
+	return min(replicas, llm.Spec.Replicas)
+}
+
+// effectiveReplicas 返回当前应该运行的副本数
+//
+// 休眠优先；没有休眠时取 Spec.Replicas 和 SLO 扩容后的副本数中较大的
+func effectiveReplicas(llm *aiv1.LLMService) int32 {
+	if isConditionTrue(llm, aiv1.ConditionHibernated) {
+		return idleReplicas(llm)
+	}
+	return max(llm.Spec.Replicas, sloReplicas(llm))
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// TestEvaluateHibernation 测试空闲休眠和请求唤醒
+func TestEvaluateHibernation(t *testing.T) {
+	created := time.Now().Add(-time.Hour)
+	llm := &aiv1.LLMService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "llama",
+			CreationTimestamp: metav1.NewTime(created),
+			Annotations: map[string]string{
+				aiv1.LastRequestTimeAnnotation: created.Add(10 * time.Minute).Format(time.RFC3339),
+			},
+		},
+		Spec: aiv1.LLMServiceSpec{
+			Replicas:    4,
+			IdleTimeout: &metav1.Duration{Duration: 30 * time.Minute},
+		},
+	}
+
+	// 最近请求在 50 分钟前，超过 30 分钟 → 休眠
+	if next := evaluateHibernation(llm, time.Now()); next != 0 {
+		t.Errorf("got recheck=%v, want 0 after hibernating", next)
+	}
+	if !isConditionTrue(llm, aiv1.ConditionHibernated) {
+		t.Fatal("expected Hibernated=True")
+	}
+	if got := effectiveReplicas(llm); got != defaultIdleReplicas {
+		t.Errorf("got replicas=%d, want %d", got, defaultIdleReplicas)
+	}
+
+	// 休眠之后来了新请求 → 唤醒
+	cond := findCondition(llm, aiv1.ConditionHibernated)
+	cond.LastUpdateTime = metav1.NewTime(time.Now().Add(-time.Minute))
+	llm.Annotations[aiv1.LastRequestTimeAnnotation] = time.Now().Format(time.RFC3339)
+
+	if next := evaluateHibernation(llm, time.Now()); next != 30*time.Minute {
+		t.Errorf("got recheck=%v, want 30m after waking", next)
+	}
+	if isConditionTrue(llm, aiv1.ConditionHibernated) {
+		t.Fatal("expected Hibernated=False")
+	}
+	if got := effectiveReplicas(llm); got != 4 {
+		t.Errorf("got replicas=%d, want 4", got)
+	}
+}
+
+// TestIdleReplicas 测试休眠副本数不超过 Spec.Replicas
+func TestIdleReplicas(t *testing.T) {
+	zero := int32(0)
+	tests := []struct {
+		name     string
+		replicas int32
+		idle     *int32
+		expected int32
+	}{
+		{"默认保留 1 个", 3, nil, 1},
+		{"缩容到 0", 3, &zero, 0},
+		{"不超过 Spec.Replicas", 0, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Replicas: tt.replicas, IdleReplicas: tt.idle}}
+			if got := idleReplicas(llm); got != tt.expected {
+				t.Errorf("got %d, want %d", got, tt.expected)
+			}
+		})
+	}
+}
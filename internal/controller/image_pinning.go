@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/registry"
+)
+
+// ============================================================================
+// 镜像 digest 固定（spec.imagePinning）
+// ============================================================================
+//
+// vllm/vllm-openai:latest 被重新推送之后，扩容、重建出来的 Pod 会拉到新版本，
+// 同一个 Deployment 里跑着两个版本的 vLLM，行为变化也没有任何记录。
+//
+// 开启之后：
+//  1. spec 里的镜像（主容器、initContainer 模式的 agent 容器、gateway）第一次出现时查询仓库，
+//     tag 当前指向的 digest 记到 status.images，Pod 模板改用 image:tag@digest
+//  2. 每隔 checkInterval 再查一次，tag 指向了新的 digest：
+//     - Notify：ImageTagMoved=True + Warning Event，继续用旧的 digest
+//     - Redeploy：status.images 换成新的 digest，Pod 模板跟着变，按正常的滚动更新重新部署
+//  3. spec 里换了镜像（改了 tag）就是一个新的镜像，重新解析
+//
+// 查询失败（私有仓库、网络不通）不影响 reconcile：还没固定的镜像继续用 tag，已经固定的保持不变。
+// ============================================================================
+
+const (
+	// defaultImageCheckInterval 是 spec.imagePinning.checkInterval 的默认值
+	defaultImageCheckInterval = time.Hour
+	// imageResolveRetryInterval 是解析失败之后的重试间隔
+	imageResolveRetryInterval = time.Minute
+)
+
+// ImageResolver 把镜像 tag 解析成 digest
+type ImageResolver interface {
+	Resolve(ctx context.Context, image string) (string, error)
+}
+
+// defaultImageResolver 是 ImageResolver 为 nil 时使用的镜像仓库客户端
+var defaultImageResolver ImageResolver = registry.NewResolver()
+
+func (r *LLMServiceReconciler) imageResolver() ImageResolver {
+	if r.ImageResolver != nil {
+		return r.ImageResolver
+	}
+	return defaultImageResolver
+}
+
+// imageCheckInterval 返回检查 tag 是否移动的间隔
+func imageCheckInterval(llm *aiv1.LLMService) time.Duration {
+	if p := llm.Spec.ImagePinning; p != nil && p.CheckInterval != nil && p.CheckInterval.Duration > 0 {
+		return p.CheckInterval.Duration
+	}
+	return defaultImageCheckInterval
+}
+
+// pinnedImage 返回 Pod 模板里使用的镜像：status.images 里有 digest 时是 image@digest，否则原样返回
+func pinnedImage(llm *aiv1.LLMService, image string) string {
+	if llm.Spec.ImagePinning == nil {
+		return image
+	}
+	for _, p := range llm.Status.Images {
+		if p.Image == image {
+			return registry.Pin(image, p.Digest)
+		}
+	}
+	return image
+}
+
+// deployedImages 返回这个 LLMService 部署的镜像，已经写了 digest 的不需要固定
+func (r *LLMServiceReconciler) deployedImages(llm *aiv1.LLMService) []string {
+	images := []string{r.agentImage(llm)}
+	if initContainerMode(llm) {
+		images = append(images, r.agentOnlyImage(llm))
+	}
+	if r.gatewayEnabled(llm) {
+		images = append(images, r.gatewayImage(llm))
+	}
+	slices.Sort(images)
+	images = slices.Compact(images)
+	return slices.DeleteFunc(images, registry.HasDigest)
+}
+
+// reconcileImagePinning 解析还没固定的镜像、定期检查 tag 是否移动，更新 status.images 和 ImageTagMoved
+//
+// 要在生成 Deployment 之前调用。返回值是下一次需要检查的时间（0 表示不需要定时检查）
+func (r *LLMServiceReconciler) reconcileImagePinning(ctx context.Context, llm *aiv1.LLMService, now time.Time) time.Duration {
+	if llm.Spec.ImagePinning == nil {
+		llm.Status.Images = nil
+		if findCondition(llm, aiv1.ConditionImageTagMoved) != nil {
+			setCondition(llm, aiv1.ConditionImageTagMoved, metav1.ConditionFalse,
+				"PinningDisabled", "imagePinning was removed from spec")
+		}
+		return 0
+	}
+
+	interval := imageCheckInterval(llm)
+	redeploy := llm.Spec.ImagePinning.OnTagMove == aiv1.TagMoveRedeploy
+	var pinned []aiv1.PinnedImage
+	var recheck time.Duration
+	for _, image := range r.deployedImages(llm) {
+		i := slices.IndexFunc(llm.Status.Images, func(p aiv1.PinnedImage) bool { return p.Image == image })
+		if i >= 0 && now.Sub(llm.Status.Images[i].LastCheckTime.Time) < interval {
+			pinned = append(pinned, llm.Status.Images[i])
+			recheck = minRecheck(recheck, interval-now.Sub(llm.Status.Images[i].LastCheckTime.Time))
+			continue
+		}
+
+		digest, err := r.imageResolver().Resolve(ctx, image)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "Failed to resolve image digest", "image", image)
+			if r.Recorder != nil {
+				r.Recorder.Eventf(llm, corev1.EventTypeWarning, "ImageResolveFailed",
+					"failed to resolve %s to a digest: %v", image, err)
+			}
+			if i >= 0 {
+				pinned = append(pinned, llm.Status.Images[i])
+			}
+			recheck = minRecheck(recheck, imageResolveRetryInterval)
+			continue
+		}
+		recheck = minRecheck(recheck, interval)
+
+		if i < 0 {
+			// 第一次出现的镜像：固定到当前的 digest
+			pinned = append(pinned, aiv1.PinnedImage{Image: image, Digest: digest, LatestDigest: digest, LastCheckTime: metav1.NewTime(now)})
+			continue
+		}
+		p := llm.Status.Images[i]
+		p.LatestDigest, p.LastCheckTime = digest, metav1.NewTime(now)
+		if p.Digest != digest && r.Recorder != nil {
+			if redeploy {
+				r.Recorder.Eventf(llm, corev1.EventTypeNormal, "ImageTagMoved",
+					"%s moved from %s to %s, redeploying", image, p.Digest, digest)
+			} else if p.LatestDigest != llm.Status.Images[i].LatestDigest {
+				// 同一次移动只提醒一次
+				r.Recorder.Eventf(llm, corev1.EventTypeWarning, "ImageTagMoved",
+					"%s moved from %s to %s, still deploying the pinned digest", image, p.Digest, digest)
+			}
+		}
+		if redeploy {
+			p.Digest = digest
+		}
+		pinned = append(pinned, p)
+	}
+	llm.Status.Images = pinned
+
+	var moved []string
+	for _, p := range pinned {
+		if p.LatestDigest != "" && p.LatestDigest != p.Digest {
+			moved = append(moved, fmt.Sprintf("%s now points to %s", p.Image, p.LatestDigest))
+		}
+	}
+	if len(moved) > 0 {
+		setCondition(llm, aiv1.ConditionImageTagMoved, metav1.ConditionTrue,
+			"TagMoved", strings.Join(moved, "; "))
+	} else {
+		setCondition(llm, aiv1.ConditionImageTagMoved, metav1.ConditionFalse,
+			"DigestsPinned", "all image tags still point to the pinned digests")
+	}
+	return recheck
+}
+
+// minRecheck 返回两个间隔里较小的那个，0 表示没有
+func minRecheck(a, b time.Duration) time.Duration {
+	if a == 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/record"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// fakeImageResolver 按 tags 返回 digest，没有的镜像解析失败
+type fakeImageResolver struct {
+	tags map[string]string
+}
+
+func (f *fakeImageResolver) Resolve(_ context.Context, image string) (string, error) {
+	if d, ok := f.tags[image]; ok {
+		return d, nil
+	}
+	return "", fmt.Errorf("image %s not found", image)
+}
+
+func TestReconcileImagePinning(t *testing.T) {
+	resolver := &fakeImageResolver{tags: map[string]string{aiv1.DefaultImage: "sha256:old"}}
+	recorder := record.NewFakeRecorder(10)
+	r := &LLMServiceReconciler{ImageResolver: resolver, Recorder: recorder}
+	llm := testLLMService()
+	llm.Spec.ImagePinning = &aiv1.ImagePinningSpec{OnTagMove: aiv1.TagMoveNotify}
+	now := time.Now()
+
+	// 第一次：固定到当前的 digest
+	if recheck := r.reconcileImagePinning(context.Background(), llm, now); recheck != defaultImageCheckInterval {
+		t.Errorf("recheck = %v, want the check interval", recheck)
+	}
+	main := r.desiredDeployment(llm).Spec.Template.Spec.Containers[0]
+	if want := aiv1.DefaultImage + "@sha256:old"; main.Image != want {
+		t.Fatalf("main image = %q, want %q", main.Image, want)
+	}
+
+	// tag 移动了，还没到检查时间：不查询
+	resolver.tags[aiv1.DefaultImage] = "sha256:new"
+	r.reconcileImagePinning(context.Background(), llm, now.Add(time.Minute))
+	if isConditionTrue(llm, aiv1.ConditionImageTagMoved) {
+		t.Error("checked the registry before the interval elapsed")
+	}
+
+	// Notify：继续用旧的 digest，condition 和 Event 提醒
+	later := now.Add(defaultImageCheckInterval)
+	r.reconcileImagePinning(context.Background(), llm, later)
+	if !isConditionTrue(llm, aiv1.ConditionImageTagMoved) {
+		t.Error("ImageTagMoved should be True after the tag moved")
+	}
+	if got := pinnedImage(llm, aiv1.DefaultImage); got != aiv1.DefaultImage+"@sha256:old" {
+		t.Errorf("Notify redeployed the new digest: %q", got)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("got %d events, want one ImageTagMoved warning", len(recorder.Events))
+	}
+
+	// Redeploy：换成新的 digest
+	llm.Spec.ImagePinning.OnTagMove = aiv1.TagMoveRedeploy
+	r.reconcileImagePinning(context.Background(), llm, later.Add(defaultImageCheckInterval))
+	if got := pinnedImage(llm, aiv1.DefaultImage); got != aiv1.DefaultImage+"@sha256:new" {
+		t.Errorf("Redeploy kept the old digest: %q", got)
+	}
+	if isConditionTrue(llm, aiv1.ConditionImageTagMoved) {
+		t.Error("ImageTagMoved should be False once the new digest is deployed")
+	}
+
+	// 换了解析不了的镜像：继续用 tag，一分钟后重试
+	llm.Spec.Image = "registry.internal/vllm:custom"
+	if recheck := r.reconcileImagePinning(context.Background(), llm, later); recheck != imageResolveRetryInterval {
+		t.Errorf("recheck after a failure = %v, want %v", recheck, imageResolveRetryInterval)
+	}
+	if got := r.desiredDeployment(llm).Spec.Template.Spec.Containers[0].Image; got != "registry.internal/vllm:custom" {
+		t.Errorf("unresolved image = %q, want the tag", got)
+	}
+
+	// 关掉：清空 status.images
+	llm.Spec.ImagePinning = nil
+	r.reconcileImagePinning(context.Background(), llm, later)
+	if llm.Status.Images != nil || isConditionTrue(llm, aiv1.ConditionImageTagMoved) {
+		t.Errorf("pinning disabled but status.images = %v", llm.Status.Images)
+	}
+}
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/agent/runtime"
+)
+
+// ============================================================================
+// initContainer 模式（spec.distribution.mode=initContainer）
+// ============================================================================
+//
+// 默认模式下主容器运行 agent，agent 拉取模型后再把推理服务作为子进程启动，
+// 所以镜像里必须同时有 agent 和 vLLM。有些用户只想用官方的 vLLM 镜像。
+//
+// initContainer 模式的 Pod：
+//
+//	initContainers:
+//	  model-server（原生 sidecar，restartPolicy: Always）: agent serve-model
+//	      参与 coordinator 选举，把 /models 通过 8080 提供给其他副本，Pod 运行期间一直在
+//	  model-fetch: agent fetch-model
+//	      自己是 coordinator → 从 HuggingFace/上游下载；否则从 coordinator 的 model server 同步，完成后退出
+//	containers:
+//	  agent: 直接运行推理服务（command/args 由 runtime.BuildArgs 生成），探针打推理服务自己的 /health
+//
+// agent 镜像来自 spec.images.agent 或 operator 配置的 distribution.agentImage（默认是 manager 镜像），
+// 不需要 CUDA，可以比主容器的 vLLM 镜像小很多。
+// 主容器没有 agent，所以没有 watchdog，preStop 也不会排空 in-flight 请求。
+// ============================================================================
+
+const (
+	// modelServerContainerName 是提供模型文件的 sidecar
+	modelServerContainerName = "model-server"
+	// modelFetchContainerName 是拉取模型的 initContainer
+	modelFetchContainerName = "model-fetch"
+
+	// modelMountPath 是模型存储卷的挂载路径（和 MODEL_PATH 一致）
+	modelMountPath = "/models"
+)
+
+// initContainerMode 判断是否由 initContainer 拉取模型
+func initContainerMode(llm *aiv1.LLMService) bool {
+	return llm.Spec.Distribution != nil && llm.Spec.Distribution.Mode == aiv1.DistributionModeInitContainer
+}
+
+// applyInitContainerMode 把 agent 模式的 Pod 模板改成 initContainer 模式
+//
+// 主容器的环境变量（POD_NAME、CONFIGMAP_NAME、MODEL_REPO、校验/下载/带宽参数）原样交给两个 agent 容器
+func (r *LLMServiceReconciler) applyInitContainerMode(llm *aiv1.LLMService, podSpec *corev1.PodSpec) {
+	main := &podSpec.Containers[0]
+	agentEnv := main.Env
+	image := pinnedImage(llm, r.agentOnlyImage(llm))
+
+	always := corev1.ContainerRestartPolicyAlways
+	modelServer := corev1.Container{
+		Name:            modelServerContainerName,
+		Image:           image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/agent", "serve-model"},
+		Env:             slices.Clone(agentEnv),
+		RestartPolicy:   &always,
+		Ports: []corev1.ContainerPort{{
+			Name:          "model-server",
+			ContainerPort: 8080,
+		}},
+		VolumeMounts: slices.Clone(main.VolumeMounts),
+		Resources:    initContainerResources("256Mi"),
+	}
+	modelFetch := corev1.Container{
+		Name:            modelFetchContainerName,
+		Image:           image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Command:         []string{"/agent", "fetch-model"},
+		Env:             slices.Clone(agentEnv),
+		VolumeMounts:    slices.Clone(main.VolumeMounts),
+		Resources:       initContainerResources("512Mi"),
+	}
+	// sidecar 必须排在前面：kubelet 等它启动后才运行 model-fetch
+	podSpec.InitContainers = []corev1.Container{modelServer, modelFetch}
+	if !modelServerEnabled(llm) {
+		// 没有其他副本来拿，model-fetch 自己下载（MODEL_SERVER_ENABLED=false）
+		podSpec.InitContainers = []corev1.Container{modelFetch}
+	}
+
+	// llamacpp、stub 被 webhook 拒绝，New 只会因为未知的 runtime 失败，这时保留镜像自己的入口
+	cfg := runtime.DefaultConfig(modelMountPath)
+	cfg.TensorParallelSize = int(tensorParallelSize(llm))
+	applyVLLMConfig(llm, cfg)
+	if rt, err := runtime.New(inferenceRuntime(llm), cfg); err == nil {
+		main.Command = []string{rt.Binary()}
+		main.Args = rt.BuildArgs()
+	}
+	main.Env = nil
+	main.Lifecycle = nil
+	// 推理服务没有 agent 写终止消息，失败时用最后几行日志代替（controller 从里面认出 CUDA OOM）
+	main.TerminationMessagePolicy = corev1.TerminationMessageFallbackToLogsOnError
+	main.Ports = slices.DeleteFunc(main.Ports, func(p corev1.ContainerPort) bool { return p.Name != "vllm" })
+	main.StartupProbe, main.ReadinessProbe, main.LivenessProbe = runtimeProbes(llm)
+}
+
+// runtimeProbes 和 agentProbes 一样，只是直接打推理服务的 /health（vLLM、TGI 都有）
+//
+// 用户在 spec.probes 里自定义的探针如果也指向 agent 的健康检查端口，同样改成推理服务的 /health
+func runtimeProbes(llm *aiv1.LLMService) (startup, readiness, liveness *corev1.Probe) {
+	startup, readiness, liveness = agentProbes(llm)
+	for _, p := range []*corev1.Probe{startup, readiness, liveness} {
+		if p.HTTPGet != nil && p.HTTPGet.Port == intstr.FromString(healthPortName) {
+			p.HTTPGet.Path = "/health"
+			p.HTTPGet.Port = intstr.FromString("vllm")
+		}
+	}
+	return startup, readiness, liveness
+}
+
+// initContainerResources 是 agent 容器的资源：很少的 CPU，内存主要是下载缓冲
+func initContainerResources(memoryLimit string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("50m"),
+			corev1.ResourceMemory: resource.MustParse("64Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse(memoryLimit),
+		},
+	}
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/operatorconfig"
+)
+
+func TestInitContainerMode(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer, Topology: aiv1.TopologyZone}
+
+	spec := r.desiredDeployment(llm).Spec.Template.Spec
+	if len(spec.InitContainers) != 2 {
+		t.Fatalf("got %d init containers, want model-server and model-fetch", len(spec.InitContainers))
+	}
+	server, fetch := spec.InitContainers[0], spec.InitContainers[1]
+	if server.Name != modelServerContainerName || server.RestartPolicy == nil ||
+		*server.RestartPolicy != corev1.ContainerRestartPolicyAlways {
+		t.Errorf("model-server must be a native sidecar declared first, got %s", server.Name)
+	}
+	if fetch.Name != modelFetchContainerName || fetch.RestartPolicy != nil {
+		t.Errorf("model-fetch must be a regular init container, got %s", fetch.Name)
+	}
+	for _, c := range spec.InitContainers {
+		if c.Image != operatorconfig.DefaultAgentImage {
+			t.Errorf("%s image = %q, want the agent image", c.Name, c.Image)
+		}
+		if !slices.ContainsFunc(c.Env, func(e corev1.EnvVar) bool { return e.Name == "MODEL_REPO" && e.Value == llm.Spec.Model }) {
+			t.Errorf("%s is missing MODEL_REPO", c.Name)
+		}
+		// initContainer 模式只支持 flat
+		if slices.ContainsFunc(c.Env, func(e corev1.EnvVar) bool { return e.Name == "DISTRIBUTION_TOPOLOGY" && e.Value == aiv1.TopologyZone }) {
+			t.Errorf("%s must not use zone topology", c.Name)
+		}
+	}
+
+	// 主容器直接运行 vLLM
+	main := spec.Containers[0]
+	if main.Image != aiv1.DefaultImage {
+		t.Errorf("main image = %q, want the inference image", main.Image)
+	}
+	if !slices.Equal(main.Command, []string{"python"}) || !slices.Contains(main.Args, "vllm.entrypoints.openai.api_server") {
+		t.Errorf("main container must run vLLM directly, got %v %v", main.Command, main.Args)
+	}
+	// 只有 scratch 卷的 HF_HOME、TMPDIR
+	agentEnv := slices.ContainsFunc(main.Env, func(e corev1.EnvVar) bool { return e.Name != "HF_HOME" && e.Name != "TMPDIR" })
+	if main.Lifecycle != nil || agentEnv {
+		t.Error("main container must not depend on the agent (preStop /drain, agent env)")
+	}
+	for _, p := range []*corev1.Probe{main.StartupProbe, main.ReadinessProbe, main.LivenessProbe} {
+		if p.HTTPGet.Path != "/health" || p.HTTPGet.Port != intstr.FromString("vllm") {
+			t.Errorf("probe must hit the inference server, got %s on %s", p.HTTPGet.Path, p.HTTPGet.Port.String())
+		}
+	}
+}
+
+func TestImagesPerRole(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer}
+	llm.Spec.Images = &aiv1.ImagesSpec{
+		Runtime: "vllm/vllm-openai:v0.6.3",
+		Agent:   "kubeinfer-agent:slim",
+		Gateway: "kubeinfer:v0.3.0",
+	}
+
+	spec := r.desiredDeployment(llm).Spec.Template.Spec
+	for _, c := range spec.InitContainers {
+		if c.Image != "kubeinfer-agent:slim" {
+			t.Errorf("%s image = %q, want spec.images.agent", c.Name, c.Image)
+		}
+	}
+	if got := spec.Containers[0].Image; got != "vllm/vllm-openai:v0.6.3" {
+		t.Errorf("main image = %q, want spec.images.runtime", got)
+	}
+	if got := r.desiredGatewayDeployment(llm).Spec.Template.Spec.Containers[0].Image; got != "kubeinfer:v0.3.0" {
+		t.Errorf("gateway image = %q, want spec.images.gateway", got)
+	}
+}
@@ -18,11 +18,16 @@ package controller
 
 import (
 	"context" //Go 标准库： 用于传递上下文关系（超时，取消）
-	"time"    //Go 标准库： 处理时间相关的操作（计时，延迟）
+	"slices"
+	"strconv"
+	"time" //Go 标准库： 处理时间相关的操作（计时，延迟）
 
 	// Kubernetes 核心API
-	appsv1 "k8s.io/api/apps/v1" //Deployment， StatefulSet 等工作负载类型
-	corev1 "k8s.io/api/core/v1" // Pod，Service， ConfigMap 等核心资源类型
+	appsv1 "k8s.io/api/apps/v1"             //Deployment， StatefulSet 等工作负载类型
+	batchv1 "k8s.io/api/batch/v1"           // CronJob（下载断点的 janitor）
+	corev1 "k8s.io/api/core/v1"             // Pod，Service， ConfigMap 等核心资源类型
+	networkingv1 "k8s.io/api/networking/v1" // Ingress
+	policyv1 "k8s.io/api/policy/v1"         // PodDisruptionBudget
 
 	// "k8s.io/apiserver/pkg/endpoints/request"
 
@@ -33,12 +38,21 @@ import (
 	"k8s.io/apimachinery/pkg/types"               // Namespace type
 
 	// Controller-runtime 库 （KubeBuilder 的底层框架）
-	ctrl "sigs.k8s.io/controller-runtime"       // Controller 管理器， Reconciler 接口
-	"sigs.k8s.io/controller-runtime/pkg/client" //K8S client 接口（CRUD）
-	"sigs.k8s.io/controller-runtime/pkg/log"    // 结构化日志工具
+	"k8s.io/client-go/tools/record"                 // Event 记录
+	ctrl "sigs.k8s.io/controller-runtime"           // Controller 管理器， Reconciler 接口
+	"sigs.k8s.io/controller-runtime/pkg/builder"    // Watch 选项（predicate）
+	"sigs.k8s.io/controller-runtime/pkg/client"     //K8S client 接口（CRUD）
+	"sigs.k8s.io/controller-runtime/pkg/controller" // controller 选项（自定义 workqueue）
+	"sigs.k8s.io/controller-runtime/pkg/handler"    // Watch 事件映射
+	"sigs.k8s.io/controller-runtime/pkg/log"        // 结构化日志工具
 
 	// 本地代码项目
 	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/agent/snapshot"
+	"github.com/Moore-Z/kubeinfer/internal/fleet"
+	"github.com/Moore-Z/kubeinfer/internal/notify"
+	"github.com/Moore-Z/kubeinfer/internal/operatorconfig"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
 	"github.com/Moore-Z/kubeinfer/pkg/metrics" // ← 新增这一行
 )
 
@@ -51,6 +65,31 @@ import (
 type LLMServiceReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// APIReader 直接读 API server（不经过缓存）：强制 coordinator 接管之前确认 Pod 确实不在了，
+	// 读 ConfigMap、Secret 的内容（它们只缓存 metadata，见 cache_options.go）；为 nil 时用 Client
+	APIReader client.Reader
+
+	// Config 是 operator 配置（默认镜像、gateway、选举参数、费用统计），为 nil 时用内置默认值
+	Config *operatorconfig.Store
+	// costReporters 缓存按当前配置创建的 CostReporter
+	costReporters costReporterCache
+	// sloSources 缓存按当前配置创建的 SLO 指标来源
+	sloSources sloSourceCache
+
+	// Recorder 用于发 Kubernetes Event（kubectl describe 可以看到）
+	Recorder record.EventRecorder
+
+	// Fleet 为 nil 时不做多集群复制（spec.fleet 被忽略）
+	Fleet *fleet.Manager
+
+	// notifier 推送关键事件，记录最近发过的通知用于去重
+	notifier notify.Notifier
+
+	// downloads 分配集群下载名额（operator 配置了 distribution.maxConcurrentDownloads 时）
+	downloads downloadQueue
+
+	// ImageResolver 给 spec.imagePinning 查询镜像 digest，为 nil 时直接访问镜像仓库
+	ImageResolver ImageResolver
 }
 
 // 下面这几行注释非常重要！它们是 RBAC 权限声明。
@@ -62,14 +101,20 @@ type LLMServiceReconciler struct {
 //+kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=keda.sh,resources=scaledobjects,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;update;patch
 
-func (r *LLMServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *LLMServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	l := log.FromContext(ctx)
 	startTime := time.Now()
 
+	// 命名返回值：defer 里能看到最终返回的 result 和 err，按结果分别计数
 	defer func() {
 		duration := time.Since(startTime).Seconds()
-		metrics.RecordReconcile("LLMService", "completed", duration)
+		outcome, reason := reconcileOutcome(result, err)
+		metrics.RecordReconcile(llmServiceControllerLabel, outcome, reason, duration)
 	}()
 
 	// 1. 从 K8s 集群获取 LLMService 对象
@@ -81,17 +126,68 @@ func (r *LLMServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	llmService := &aiv1.LLMService{}
 
 	// 去k8s 查一下llmservice 这个资源
-	err := r.Get(ctx, req.NamespacedName, llmService)
+	err = r.Get(ctx, req.NamespacedName, llmService)
 
 	if err != nil {
 		// 意思是：用户已经把 CR (LLMService) 给删了。
 		// 既然老板把订单都撕了，那我们就没必要干活了。
 		// 直接收工 (return nil)，也不需要报错。
+		// 它的指标还在导出删除前的值，一起删掉
 		if errors.IsNotFound(err) {
+			metrics.DeleteLLMServiceMetrics(req.Namespace, req.Name)
+			r.downloads.forget(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		return ctrl.Result{}, err
 	}
+
+	// fleet：成员集群里的副本要在 hub 上的 LLMService 删除前清理掉
+	if r.Fleet != nil {
+		done, err := r.reconcileFleetFinalizer(ctx, llmService)
+		if done || err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// 2. 空闲检测：根据 gateway 写入的最近请求时间决定是否休眠/唤醒
+	// 结果会影响下面 Deployment 的副本数
+	idleRecheck := evaluateHibernation(llmService, time.Now())
+
+	// dry-run：只计算变更计划，不修改任何子资源
+	if isDryRun(llmService) {
+		return r.reconcileDryRun(ctx, llmService)
+	}
+	llmService.Status.Plan = nil
+
+	// SLO：读 gateway 指标更新 SLOViolated，设置了 maxReplicas 时会影响下面的副本数
+	sloRecheck := r.checkSLO(ctx, llmService, time.Now())
+
+	// chat template：读 ConfigMap 算 checksum，内容变化时下面的 Pod 模板跟着变
+	if err := r.syncChatTemplate(ctx, llmService); err != nil {
+		l.Error(err, "Failed to sync chat template")
+		return ctrl.Result{}, err
+	}
+
+	// 镜像 digest 固定：status.images 决定下面 Pod 模板里的镜像
+	pinRecheck := r.reconcileImagePinning(ctx, llmService, time.Now())
+
+	// peer serving：Pod 通过 secretKeyRef 引用 token，Secret 要在 Deployment 之前创建
+	if err := r.ensureModelServerToken(ctx, llmService); err != nil {
+		l.Error(err, "Failed to create model server token")
+		return ctrl.Result{}, err
+	}
+	// agent 的 /debug/ 同样用 secretKeyRef 引用 token
+	if err := r.ensureAgentDebugToken(ctx, llmService); err != nil {
+		l.Error(err, "Failed to create agent debug token")
+		return ctrl.Result{}, err
+	}
+
+	// 引用的 ConfigMap、Secret：算内容的 checksum，变化时下面的 Pod 模板跟着变
+	if err := r.syncConfigChecksum(ctx, llmService); err != nil {
+		l.Error(err, "Failed to sync config checksum")
+		return ctrl.Result{}, err
+	}
+
 	// 定义我们想要什么deployment的format
 	deployment := r.desiredDeployment(llmService)
 
@@ -115,8 +211,9 @@ func (r *LLMServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			"Deployment.Namespace", deployment.Namespace,
 			"Deployment.Name", deployment.Name)
 
-		// 调用 K8s API 创建 Deployment
-		err = r.Create(ctx, deployment)
+		// server-side apply 创建 Deployment（apply configuration 里带 OwnerReference：
+		// LLMService 删除时 Deployment 被级联删除，Owns(&appsv1.Deployment{}) 才能把变化映射回来）
+		err = r.applyDeployment(ctx, llmService, deployment)
 		if err != nil {
 			l.Error(err, "Failed to create new Deployment")
 			return ctrl.Result{}, err
@@ -145,14 +242,117 @@ func (r *LLMServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		// ReadyReplicas：有多少个 Pod 处于 Ready 状态
 		// 用户可以通过 kubectl get llmservice 看到这个数字
 	*/
+	// 4. 同步 Deployment：副本数立即生效（休眠/唤醒），
+	// Pod 模板变化会重启推理服务，只在 UpdateWindow 内应用
+	updateRecheck, err := r.syncDeployment(ctx, llmService, found, deployment, time.Now())
+	if err != nil {
+		l.Error(err, "Failed to update Deployment")
+		return ctrl.Result{}, err
+	}
+
+	// vLLM Service 和 gateway
+	if err := r.applyService(ctx, llmService, desiredVLLMService(llmService)); err != nil {
+		l.Error(err, "Failed to apply vLLM Service")
+		return ctrl.Result{}, err
+	}
+	if err := r.reconcileGateway(ctx, llmService); err != nil {
+		l.Error(err, "Failed to reconcile gateway")
+		return ctrl.Result{}, err
+	}
+	// spec.storage.checkpoint：清理过期下载断点的 CronJob
+	if err := r.reconcileCheckpointJanitor(ctx, llmService); err != nil {
+		l.Error(err, "Failed to reconcile checkpoint janitor")
+		return ctrl.Result{}, err
+	}
+	// spec.expose：Ingress / HTTPRoute / LoadBalancer 指向 gateway 或 vLLM Service
+	if err := r.reconcileExpose(ctx, llmService); err != nil {
+		l.Error(err, "Failed to reconcile expose")
+		return ctrl.Result{}, err
+	}
+	// KEDA 模式：副本数由生成的 ScaledObject 管理
+	if err := r.reconcileScaledObject(ctx, llmService); err != nil {
+		l.Error(err, "Failed to reconcile ScaledObject")
+		return ctrl.Result{}, err
+	}
+
 	llmService.Status.AvailableReplicas = found.Status.ReadyReplicas
 
-	metrics.LLMServiceReadyReplicas.WithLabelValues(
-		llmService.Name,
-		llmService.Namespace).Set(float64(found.Status.ReadyReplicas))
+	metrics.RecordReadyReplicas(llmService.Namespace, llmService.Name, found.Status.ReadyReplicas)
+
+	// 部分副本挂了但还在服务 → Degraded=True；完全不可用 → Degraded=False, Reason=Unavailable
+	if err := r.checkDegraded(ctx, llmService, found); err != nil {
+		l.Error(err, "Failed to check degraded state")
+		return ctrl.Result{}, err
+	}
+
+	// 费用统计：把这段时间的 GPU 用量累加进 Status.CostReport
+	costReporter := r.costReporter()
+	if costReporter != nil {
+		costReporter.Update(ctx, llmService, found.Status.ReadyReplicas)
+	}
 
 	// 注意：Coordinator 选举现在由 Agent 通过 Lease 自己完成
 	// 不再需要 Controller 调用 ensureCacheCoordinator()
+	// Controller 只负责观察：记录当前 coordinator，节点故障时强制让出 Lease
+	if err := r.checkCoordinatorNode(ctx, llmService); err != nil {
+		l.Error(err, "Failed to check coordinator node")
+		return ctrl.Result{}, err
+	}
+	// spec.coordination.pinnedPod：Pod 可用时写到 lease 注解上，由 agent 接管
+	if err := r.reconcileCoordinatorPin(ctx, llmService); err != nil {
+		l.Error(err, "Failed to reconcile coordinator pin")
+		return ctrl.Result{}, err
+	}
+	// 同一个 coordinator 持续 settlePeriod 之后才按它调整 Pod，lease 来回易手时不跟着改
+	settleRecheck, err := r.checkCoordinatorStable(ctx, llmService, time.Now())
+	if err != nil {
+		l.Error(err, "Failed to check coordinator stability")
+		return ctrl.Result{}, err
+	}
+	// 缩容和滚动更新时先删 follower，coordinator 最后被删
+	if err := r.reconcileDeletionCost(ctx, llmService); err != nil {
+		l.Error(err, "Failed to set pod deletion cost")
+		return ctrl.Result{}, err
+	}
+	// coordinator 给足够多的 follower 供货时阻止驱逐
+	evictionRecheck, err := r.reconcileEvictionProtection(ctx, llmService, time.Now())
+	if err != nil {
+		l.Error(err, "Failed to reconcile eviction protection")
+		return ctrl.Result{}, err
+	}
+	// 集群下载名额：排队时写 status.download，分到名额后写到 -cache ConfigMap 里
+	downloadRecheck, err := r.reconcileDownloadQueue(ctx, llmService, time.Now())
+	if err != nil {
+		l.Error(err, "Failed to reconcile download queue")
+		return ctrl.Result{}, err
+	}
+	// follower 通过 <name>-coordinator Service 访问 coordinator，顺便清理旧 coordinator 残留的 role label
+	if err := r.reconcileCoordinatorService(ctx, llmService); err != nil {
+		l.Error(err, "Failed to reconcile coordinator Service")
+		return ctrl.Result{}, err
+	}
+
+	// agent 因为 token 无效、磁盘满等原因退出时，把原因反映到 Condition 上
+	if err := r.checkAgentErrors(ctx, llmService); err != nil {
+		l.Error(err, "Failed to check agent errors")
+		return ctrl.Result{}, err
+	}
+
+	// 协调数据写到 -cache ConfigMap（agent 读），模型信息写到 Status.Model（用户看）
+	if err := r.applyCacheInfo(ctx, llmService); err != nil {
+		l.Error(err, "Failed to apply cache info ConfigMap")
+		return ctrl.Result{}, err
+	}
+	r.auditModelSwitch(ctx, llmService)
+	llmService.Status.Model = modelStatus(llmService)
+
+	// fleet：把 spec 复制到成员集群，汇总成员集群的副本状态
+	if r.Fleet != nil && llmService.Spec.Fleet != nil {
+		if err := r.Fleet.Sync(ctx, llmService); err != nil {
+			l.Error(err, "Failed to sync LLMService to member clusters")
+			return ctrl.Result{}, err
+		}
+	}
 
 	// 6. 把 Status 的更新保存到 K8s API server
 	//
@@ -165,12 +365,20 @@ func (r *LLMServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		l.Error(err, "Failed to update LLMService status")
 		return ctrl.Result{}, err
 	}
-	// 8. 全部成功，返回空结果
-	//
-	// ctrl.Result{}: 表示这次 reconcile 成功完成
-	// - 如果对象有新的变化，controller-runtime 会自动再调用
-	// - 不需要我们主动 requeue
-	return ctrl.Result{}, nil
+	// 7. 费用统计需要定期累计、空闲检测和 SLO 需要定时检查、漂移需要定期兜底检查，
+	// 即使对象没有变化也要 requeue
+	requeueAfter := driftResyncPeriod
+	if costReporter != nil && costReporter.Interval > 0 && costReporter.Interval < requeueAfter {
+		requeueAfter = costReporter.Interval
+	}
+	for _, recheck := range []time.Duration{idleRecheck, updateRecheck, sloRecheck, settleRecheck, evictionRecheck, downloadRecheck, pinRecheck} {
+		if recheck > 0 && recheck < requeueAfter {
+			requeueAfter = recheck
+		}
+	}
+
+	// 8. 全部成功，按上面算出的间隔再检查一次
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 // desiredDeployment 生成期望的 Deployment
@@ -180,17 +388,18 @@ func (r *LLMServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 // 2. 添加必要的环境变量（POD_NAME, POD_NAMESPACE, CONFIGMAP_NAME, MODEL_PATH, MODEL_REPO）
 // 3. 挂载模型存储卷
 func (r *LLMServiceReconciler) desiredDeployment(llm *aiv1.LLMService) *appsv1.Deployment {
-	replicas := llm.Spec.Replicas
+	// 休眠时副本数是 IdleReplicas，而不是 Spec.Replicas
+	replicas := effectiveReplicas(llm)
 
-	labels := map[string]string{
-		"app":    "llm-inference",
-		"llm_cr": llm.Name,
-	}
+	labels := podLabels(llm)
+
+	// 探针：大模型加载时间长，startupProbe 的上限按模型大小估算
+	startupProbe, readinessProbe, livenessProbe := agentProbes(llm)
 
-	// ConfigMap 名称（和 cache_coordinator.go 保持一致）
-	configMapName := llm.Name + "-cache"
+	// ConfigMap 名称（和 agent 共用 pkg/cacheinfo 的定义）
+	configMapName := cacheinfo.ConfigMapName(llm.Name)
 
-	return &appsv1.Deployment{
+	deployment := &appsv1.Deployment{
 		// Meta data “data about data” 数据用来管理数据
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      llm.Name + "-deployment",
@@ -213,10 +422,13 @@ func (r *LLMServiceReconciler) desiredDeployment(llm *aiv1.LLMService) *appsv1.D
 				},
 				// 单个Pod 部署说明书
 				Spec: corev1.PodSpec{
+					// 排空需要的时间 = 最长生成时间 + 停止 vLLM 的余量
+					TerminationGracePeriodSeconds: terminationGracePeriod(llm),
+
 					// Container 容器列表
 					Containers: []corev1.Container{{
-						Name:            "agent",
-						Image:           llm.Spec.Image,
+						Name:            agentContainerName,
+						Image:           pinnedImage(llm, r.agentImage(llm)),
 						ImagePullPolicy: corev1.PullIfNotPresent,
 
 						// ========================================
@@ -228,7 +440,7 @@ func (r *LLMServiceReconciler) desiredDeployment(llm *aiv1.LLMService) *appsv1.D
 						// 3. 知道去哪里找角色信息（CONFIGMAP_NAME）
 						// 4. 知道模型存哪里（MODEL_PATH）
 						// 5. 知道下载什么模型（MODEL_REPO）
-						Env: []corev1.EnvVar{
+						Env: append([]corev1.EnvVar{
 							{
 								// POD_NAME: 通过 Downward API 获取 Pod 名称
 								Name: "POD_NAME",
@@ -263,7 +475,27 @@ func (r *LLMServiceReconciler) desiredDeployment(llm *aiv1.LLMService) *appsv1.D
 								Name:  "MODEL_REPO",
 								Value: llm.Spec.Model,
 							},
-						},
+							{
+								// LLMSERVICE_NAME: 下载进度指标的 llmservice 标签
+								Name:  "LLMSERVICE_NAME",
+								Value: llm.Name,
+							},
+							{
+								// INFERENCE_RUNTIME: 推理后端（vllm/tgi/llamacpp）
+								Name:  "INFERENCE_RUNTIME",
+								Value: inferenceRuntime(llm),
+							},
+						}, slices.Concat(r.distributionEnv(llm), modelServerEnv(llm), modelTransferEnv(llm), checkpointEnv(llm), modelLicenseEnv(llm), modelRegistryEnv(llm), coordinatorServiceEnv(llm), drainEnv(llm), tensorParallelEnv(llm), vllmConfigEnv(llm), runtimeSocketEnv(llm), agentDebugEnv(llm))...),
+
+						// CPU/内存 requests/limits，GPU 按 GpuPerReplica 单独添加
+						Resources: agentResources(llm),
+
+						StartupProbe:   startupProbe,
+						ReadinessProbe: readinessProbe,
+						LivenessProbe:  livenessProbe,
+
+						// 优雅下线：preStop 排空 in-flight 请求
+						Lifecycle: drainLifecycle(),
 
 						//端口设置
 						Ports: []corev1.ContainerPort{
@@ -277,6 +509,11 @@ func (r *LLMServiceReconciler) desiredDeployment(llm *aiv1.LLMService) *appsv1.D
 								Name:          "model-server",
 								ContainerPort: 8080,
 							},
+							{
+								// agent 健康检查（/healthz、/readyz）和 metrics
+								Name:          healthPortName,
+								ContainerPort: 8081,
+							},
 						},
 
 						// 数据的（Persistence & Decoupling）， 我们的volume 该插在哪里
@@ -310,12 +547,234 @@ func (r *LLMServiceReconciler) desiredDeployment(llm *aiv1.LLMService) *appsv1.D
 			},
 		},
 	}
+
+	podSpec := &deployment.Spec.Template.Spec
+	if initContainerMode(llm) {
+		// 模型由 initContainer 拉取，model-fetch 本身就会等 coordinator
+		r.applyInitContainerMode(llm, podSpec)
+	} else if modelServerEnabled(llm) {
+		// coordinator 先启动，follower 等它就绪后再错开启动
+		podSpec.InitContainers = []corev1.Container{startupGateContainer(&podSpec.Containers[0])}
+	} else {
+		// 没有 model server：没有 follower 要等 coordinator，也不声明 8080
+		main := &podSpec.Containers[0]
+		main.Ports = slices.DeleteFunc(main.Ports, func(p corev1.ContainerPort) bool { return p.Name == "model-server" })
+	}
+	// chat template、tokenizer（挂载只给主容器，所以放在 initContainer 模式拆分之后）
+	applyVLLMOptions(llm, &deployment.Spec.Template)
+	// /dev/shm 和大页（tensor parallel 需要）
+	applySharedMemory(llm, podSpec)
+	// 主机网络、RDMA、NCCL 参数
+	applyNetworking(llm, podSpec)
+	// HF_HOME、TMPDIR 放到单独的 scratch 卷，下载暂存的文件不写满容器文件系统
+	applyScratch(llm, podSpec)
+	// seccomp、AppArmor、用户命名空间、restricted（initContainer 都加好之后）
+	applyPodSecurity(llm, &deployment.Spec.Template)
+	// 用户的 Env/EnvFrom 最后加，和上面生成的变量同名时跳过
+	applyUserEnv(llm, podSpec)
+	// 最终生效的 VLLM_* 变量和 vLLM 参数的 hash，配置变化时滚动重启
+	applyVLLMConfigHash(&deployment.Spec.Template)
+	// kubeinfer.io/restartedAt：值变化时滚动重启
+	applyRestartedAt(llm, &deployment.Spec.Template)
+	// kubeinfer.io/config-checksum：引用的 ConfigMap、Secret 内容变化时滚动重启
+	applyConfigChecksum(llm, &deployment.Spec.Template)
+
+	// 共享模型卷：模型目录换成 spec.storage.models 的 PVC
+	applyModelVolume(llm, podSpec)
+	// 下载断点：follower 同步到一半的文件放在 spec.storage.checkpoint 的 PVC 上
+	applyCheckpoint(llm, podSpec)
+	// nodeCache：hostPath 模型目录、每个节点一个副本
+	applyNodeCache(llm, deployment)
+	// maxSurge/maxUnavailable（GPU 紧张的集群不能 surge）
+	applyRollout(llm, deployment)
+
+	// 副本数交给 HPA/KEDA 时不 apply replicas，否则每次 reconcile 都会把它改回去
+	if externalAutoscaling(llm) {
+		deployment.Spec.Replicas = nil
+	}
+
+	// 记录模板 hash，用来判断 Pod 模板是否需要更新
+	deployment.Annotations = map[string]string{
+		templateHashAnnotation: templateHash(&deployment.Spec.Template),
+	}
+	return deployment
+}
+
+// inferenceRuntime 返回推理后端，未设置时是 vLLM，开发模式固定是 llama.cpp
+func inferenceRuntime(llm *aiv1.LLMService) string {
+	if devMode(llm) {
+		return aiv1.RuntimeLlamaCpp
+	}
+	if llm.Spec.Runtime == "" {
+		return aiv1.RuntimeVLLM
+	}
+	return llm.Spec.Runtime
+}
+
+// distributionEnv 生成模型分发相关的环境变量
+//
+// agent 需要知道自己所在的节点（NODE_NAME）：选举时读取节点的 kubeinfer.io/seed-preferred label，
+// zone 拓扑下还要读取 topology.kubernetes.io/zone label。
+// 拓扑和选举参数没有在 spec 里设置时用 operator 配置的默认值，API 客户端限流只来自 operator 配置。
+func (r *LLMServiceReconciler) distributionEnv(llm *aiv1.LLMService) []corev1.EnvVar {
+	env := []corev1.EnvVar{{
+		// NODE_NAME: 通过 Downward API 获取节点名称
+		Name: "NODE_NAME",
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{
+				FieldPath: "spec.nodeName",
+			},
+		},
+	}}
+	env = append(env, leaseEnv(r.config().Lease)...)
+	env = append(env, agentClientEnv(r.config().AgentClient)...)
+	if r.downloadSchedulingEnabled() {
+		// DOWNLOAD_SCHEDULING: 从上游下载前排队等名额（download_queue.go）
+		env = append(env, corev1.EnvVar{Name: cacheinfo.DownloadSchedulingEnv, Value: "true"})
+	}
+	if d := llm.Spec.Distribution; d != nil {
+		if d.UpstreamURL != "" {
+			// MODEL_UPSTREAM_URL: coordinator 从另一个集群的 model server 复制模型
+			env = append(env, corev1.EnvVar{Name: "MODEL_UPSTREAM_URL", Value: d.UpstreamURL})
+		}
+		env = append(env, verificationEnv(d.Verification)...)
+		if d.LocalCopy != "" {
+			// MODEL_LOCAL_COPY: follower 怎么对待本地已有的文件（internal/agent/distribution/local_copy.go）
+			env = append(env, corev1.EnvVar{Name: "MODEL_LOCAL_COPY", Value: d.LocalCopy})
+		}
+		env = append(env, snapshotEnv(d.Snapshot)...)
+		env = append(env, downloadEnv(d.Download)...)
+		env = append(env, bandwidthEnv(d.Bandwidth)...)
+		if d.Retention != nil && d.Retention.KeepRevisions > 0 {
+			// MODEL_KEEP_REVISIONS: 模型目录保留的 revision 数（internal/agent/distribution/gc.go）
+			env = append(env, corev1.EnvVar{Name: "MODEL_KEEP_REVISIONS", Value: strconv.Itoa(int(d.Retention.KeepRevisions))})
+		}
+		if d.Layout == aiv1.LayoutVersioned {
+			// MODEL_LAYOUT: 每个 revision 一个目录，推理服务读 current 软链接（internal/agent/distribution/layout.go）
+			env = append(env, corev1.EnvVar{Name: "MODEL_LAYOUT", Value: d.Layout})
+		}
+		env = append(env, r.peerServingEnv(llm)...)
+	}
+	if r.topology(llm) != aiv1.TopologyZone {
+		return env
+	}
+	return append(env, corev1.EnvVar{
+		Name:  "DISTRIBUTION_TOPOLOGY",
+		Value: aiv1.TopologyZone,
+	})
+}
+
+// verificationEnv 把校验策略传给 agent（internal/agent/distribution）
+//
+// 公钥通过 secretKeyRef 注入，不经过 controller
+func verificationEnv(v *aiv1.VerificationSpec) []corev1.EnvVar {
+	if v == nil || v.Policy == "" {
+		return nil
+	}
+	// MODEL_VERIFY_POLICY: size/sha256/signature
+	env := []corev1.EnvVar{{Name: "MODEL_VERIFY_POLICY", Value: v.Policy}}
+	if v.PublicKeySecret != "" {
+		env = append(env, corev1.EnvVar{
+			Name: "MODEL_VERIFY_PUBLIC_KEY",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: v.PublicKeySecret},
+					Key:                  aiv1.VerificationPublicKeyKey,
+				},
+			},
+		})
+	}
+	return env
+}
+
+// snapshotEnv 把模型快照的位置和凭证传给 agent（internal/agent/snapshot）
+//
+// 凭证通过 secretKeyRef 注入，不经过 controller
+func snapshotEnv(sn *aiv1.SnapshotSpec) []corev1.EnvVar {
+	if sn == nil {
+		return nil
+	}
+	secretKey := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: sn.CredentialsSecret},
+			Key:                  key,
+		}}
+	}
+	env := []corev1.EnvVar{
+		{Name: snapshot.URLEnv, Value: sn.URL},
+		{Name: snapshot.AccessKeyEnv, ValueFrom: secretKey(aiv1.SnapshotAccessKeyIDKey)},
+		{Name: snapshot.SecretKeyEnv, ValueFrom: secretKey(aiv1.SnapshotSecretAccessKeyKey)},
+	}
+	if sn.Endpoint != "" {
+		env = append(env, corev1.EnvVar{Name: snapshot.EndpointEnv, Value: sn.Endpoint})
+	}
+	if sn.Region != "" {
+		env = append(env, corev1.EnvVar{Name: snapshot.RegionEnv, Value: sn.Region})
+	}
+	if sn.ReadOnly {
+		env = append(env, corev1.EnvVar{Name: snapshot.ReadOnlyEnv, Value: "true"})
+	}
+	return env
+}
+
+// downloadEnv 把下载并发传给 agent（internal/agent/hfhub），未设置的项用 agent 的默认值
+func downloadEnv(d *aiv1.DownloadSpec) []corev1.EnvVar {
+	if d == nil {
+		return nil
+	}
+	var env []corev1.EnvVar
+	if d.Concurrency > 0 {
+		env = append(env, corev1.EnvVar{Name: "MODEL_DOWNLOAD_CONCURRENCY", Value: strconv.Itoa(int(d.Concurrency))})
+	}
+	if d.ChunkSize != nil {
+		// agent 读字节数，不解析 Quantity
+		env = append(env, corev1.EnvVar{Name: "MODEL_DOWNLOAD_CHUNK_SIZE", Value: strconv.FormatInt(d.ChunkSize.Value(), 10)})
+	}
+	return env
+}
+
+// bandwidthEnv 把带宽预算传给 agent（internal/agent/bandwidth）
+func bandwidthEnv(b *aiv1.BandwidthSpec) []corev1.EnvVar {
+	if b == nil || b.Limit.Value() <= 0 {
+		return nil
+	}
+	env := []corev1.EnvVar{{Name: "AGENT_BANDWIDTH_LIMIT", Value: strconv.FormatInt(b.Limit.Value(), 10)}}
+	if b.ServePercent > 0 {
+		env = append(env, corev1.EnvVar{Name: "AGENT_BANDWIDTH_SERVE_PERCENT", Value: strconv.Itoa(int(b.ServePercent))})
+	}
+	return env
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *LLMServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// 定期更新 LLMService 总数、清理已删除 LLMService 的指标（删除事件可能错过）
+	if err := mgr.Add(&metricsSync{client: mgr.GetClient(), interval: defaultMetricsSyncInterval}); err != nil {
+		return err
+	}
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&aiv1.LLMService{}).
 		Owns(&appsv1.Deployment{}). // 监听 Deployment，如果 Deployment 被误删，Controller 会自动感知
+		Owns(&corev1.Service{}).
+		Owns(&networkingv1.Ingress{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
+		Owns(&batchv1.CronJob{}).
+		// 监听节点 Ready 变化：coordinator 所在节点挂了要尽快让出 Lease
+		Watches(&corev1.Node{},
+			handler.EnqueueRequestsFromMapFunc(r.nodeToLLMServices),
+			builder.WithPredicates(nodeReadinessChanged)).
+		// coordinator 的传输、下载注解变化时更新驱逐保护和下载名额
+		Watches(&corev1.Pod{},
+			handler.EnqueueRequestsFromMapFunc(r.podToLLMService),
+			builder.WithPredicates(agentAnnotationsChanged)).
+		// 引用的 ConfigMap、Secret（chat template、HF_TOKEN...）变化时重新计算 checksum；
+		// 只 watch metadata，不把集群里所有 ConfigMap、Secret 的内容放进内存
+		Watches(&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.configMapToLLMServices),
+			builder.OnlyMetadata, builder.WithPredicates(userConfigObject)).
+		Watches(&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.secretToLLMServices),
+			builder.OnlyMetadata, builder.WithPredicates(userConfigObject)).
+		// 队列长度写到 kubeinfer_reconcile_queue_depth
+		WithOptions(controller.Options{NewQueue: newDepthQueue(llmServiceControllerLabel)}).
 		Complete(r)
 }
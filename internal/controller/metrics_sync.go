@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+// ============================================================================
+// 按集群里的 LLMService 定期同步指标
+// ============================================================================
+//
+// 有些指标不属于某一次 reconcile，要看集群里全部的 LLMService，每个间隔从 informer 缓存 List 一次：
+//  1. kubeinfer_llmservice_total{namespace}：每个 namespace 的 LLMService 数量（fleet 规模）
+//  2. 清理已删除 LLMService 的序列：LLMService 删除后 Reconcile 会收到 NotFound，在那里删除它的时间序列，
+//     但是删除事件可能错过（manager 重启期间删除的、删除时当前副本不是 leader），
+//     所以这里再对比 registry 里的序列和缓存里的 LLMService，删掉多出来的
+// ============================================================================
+
+// defaultMetricsSyncInterval 是同步间隔
+const defaultMetricsSyncInterval = time.Minute
+
+// metricsSync 定期按集群里的 LLMService 更新总数、清理过期的序列
+type metricsSync struct {
+	client   client.Reader
+	interval time.Duration
+
+	// namespaces 是上一次导出了 total 的 namespace，LLMService 删光后要删掉对应的序列
+	namespaces map[string]bool
+}
+
+// Start 实现 manager.Runnable，启动时先同步一次，ctx 取消时退出
+func (s *metricsSync) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.sync(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to sync LLMService metrics")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection 返回 false：总数每个副本都能导出；非 leader 副本之前做过 leader 的话也可能留着旧序列
+func (s *metricsSync) NeedLeaderElection() bool {
+	return false
+}
+
+// sync List 一次 LLMService，更新总数并清理过期的序列
+func (s *metricsSync) sync(ctx context.Context) error {
+	list := &aiv1.LLMServiceList{}
+	if err := s.client.List(ctx, list); err != nil {
+		return err
+	}
+	s.updateTotals(list.Items)
+	return s.prune(ctx, list.Items)
+}
+
+// updateTotals 按 namespace 设置 kubeinfer_llmservice_total
+func (s *metricsSync) updateTotals(items []aiv1.LLMService) {
+	counts := map[string]int{}
+	for _, llm := range items {
+		counts[llm.Namespace]++
+	}
+	for ns, n := range counts {
+		metrics.LLMServiceTotal.WithLabelValues(ns).Set(float64(n))
+	}
+	for ns := range s.namespaces {
+		if counts[ns] == 0 {
+			metrics.LLMServiceTotal.DeleteLabelValues(ns)
+		}
+	}
+	s.namespaces = map[string]bool{}
+	for ns := range counts {
+		s.namespaces[ns] = true
+	}
+}
+
+// prune 删除 registry 里有、集群里已经没有的 LLMService 的序列
+func (s *metricsSync) prune(ctx context.Context, items []aiv1.LLMService) error {
+	series, err := metrics.LLMServiceSeries()
+	if err != nil {
+		return err
+	}
+	existing := make(map[types.NamespacedName]bool, len(items))
+	for _, llm := range items {
+		existing[types.NamespacedName{Namespace: llm.Namespace, Name: llm.Name}] = true
+	}
+	for _, key := range series {
+		if !existing[key] {
+			n := metrics.DeleteLLMServiceMetrics(key.Namespace, key.Name)
+			log.FromContext(ctx).Info("Pruned metrics of deleted LLMService", "llmservice", key, "series", n)
+		}
+	}
+	return nil
+}
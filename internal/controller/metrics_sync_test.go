@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+// TestMetricsSync_Prune 测试只删除已经不存在的 LLMService 的序列
+func TestMetricsSync_Prune(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = aiv1.AddToScheme(scheme)
+	live := &aiv1.LLMService{ObjectMeta: metav1.ObjectMeta{Name: "janitor-live", Namespace: "default"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(live).Build()
+
+	metrics.RecordReadyReplicas("default", "janitor-live", 2)
+	metrics.RecordReadyReplicas("default", "janitor-gone", 1)
+	metrics.RecordCostReport("default", "janitor-gone", 3, 0.5, 7.5)
+	metrics.RecordCoordinatorElection("default", "janitor-gone")
+
+	s := &metricsSync{client: c}
+	if err := s.sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	series, err := metrics.LLMServiceSeries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(series, types.NamespacedName{Namespace: "default", Name: "janitor-live"}) {
+		t.Errorf("series = %v, want janitor-live kept", series)
+	}
+	if slices.Contains(series, types.NamespacedName{Namespace: "default", Name: "janitor-gone"}) {
+		t.Errorf("series = %v, want janitor-gone pruned", series)
+	}
+
+	if n := metrics.DeleteLLMServiceMetrics("default", "janitor-live"); n != 1 {
+		t.Errorf("deleted %d series of janitor-live, want 1", n)
+	}
+}
+
+// TestMetricsSync_Totals 测试按 namespace 统计总数，namespace 里的 LLMService 删光后删掉序列
+func TestMetricsSync_Totals(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = aiv1.AddToScheme(scheme)
+	llm := func(ns, name string) *aiv1.LLMService {
+		return &aiv1.LLMService{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}}
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(llm("team-a", "qwen"), llm("team-a", "llama"), llm("team-b", "qwen")).Build()
+
+	s := &metricsSync{client: c}
+	if err := s.sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := gaugeValue(t, "kubeinfer_llmservice_total", "namespace", "team-a"); got != 2 {
+		t.Errorf("team-a total = %v, want 2", got)
+	}
+	if got := gaugeValue(t, "kubeinfer_llmservice_total", "namespace", "team-b"); got != 1 {
+		t.Errorf("team-b total = %v, want 1", got)
+	}
+
+	if err := c.Delete(context.Background(), llm("team-b", "qwen")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if metrics.LLMServiceTotal.DeleteLabelValues("team-b") {
+		t.Error("team-b series should have been deleted after its last LLMService was removed")
+	}
+}
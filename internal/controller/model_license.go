@@ -0,0 +1,18 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/agent/hfhub"
+)
+
+// modelLicenseEnv 在 spec.modelSource.acceptLicense 打开时告诉 agent 可以下载 gated 模型
+//
+// initContainer 模式下 model-fetch 复制主容器的环境变量，也会拿到
+func modelLicenseEnv(llm *aiv1.LLMService) []corev1.EnvVar {
+	if s := llm.Spec.ModelSource; s == nil || !s.AcceptLicense {
+		return nil
+	}
+	return []corev1.EnvVar{{Name: hfhub.LicenseAcceptedEnv, Value: "true"}}
+}
@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/agent/modelregistry"
+)
+
+// modelRegistryEnv 把 spec.modelSource.registry 传给 agent（internal/agent/modelregistry）
+//
+// 凭证通过 secretKeyRef 注入，不经过 controller；key 都是可选的（token 和 username/password 只用其中一种），
+// Secret 内容变了由 kubeinfer.io/config-checksum 触发滚动重启。
+// initContainer 模式下 model-fetch 复制主容器的环境变量，也会拿到
+func modelRegistryEnv(llm *aiv1.LLMService) []corev1.EnvVar {
+	s := llm.Spec.ModelSource
+	if s == nil || s.Registry == nil {
+		return nil
+	}
+	reg := s.Registry
+	env := []corev1.EnvVar{{Name: modelregistry.URIEnv, Value: reg.URI}}
+	if reg.Endpoint != "" {
+		env = append(env, corev1.EnvVar{Name: modelregistry.EndpointEnv, Value: reg.Endpoint})
+	}
+	if reg.Path != "" {
+		env = append(env, corev1.EnvVar{Name: modelregistry.PathEnv, Value: reg.Path})
+	}
+	if reg.CredentialsSecret == "" {
+		return env
+	}
+	optional := true
+	secretKey := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: reg.CredentialsSecret},
+			Key:                  key,
+			Optional:             &optional,
+		}}
+	}
+	if strings.HasPrefix(reg.URI, modelregistry.WandbScheme) {
+		return append(env, corev1.EnvVar{Name: modelregistry.WandbAPIKeyEnv, ValueFrom: secretKey(aiv1.ModelRegistryTokenKey)})
+	}
+	return append(env,
+		corev1.EnvVar{Name: modelregistry.MLflowTokenEnv, ValueFrom: secretKey(aiv1.ModelRegistryTokenKey)},
+		corev1.EnvVar{Name: modelregistry.MLflowUsernameEnv, ValueFrom: secretKey(aiv1.ModelRegistryUsernameKey)},
+		corev1.EnvVar{Name: modelregistry.MLflowPasswordEnv, ValueFrom: secretKey(aiv1.ModelRegistryPasswordKey)},
+	)
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/agent/modelregistry"
+)
+
+func TestModelRegistryEnv(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	if env := modelRegistryEnv(llm); env != nil {
+		t.Errorf("no registry configured, got env %v", env)
+	}
+
+	llm.Spec.ModelSource = &aiv1.ModelSourceSpec{Registry: &aiv1.ModelRegistrySpec{
+		URI:               "models:/support-bot@champion",
+		Endpoint:          "http://mlflow.mlops:5000",
+		Path:              "model",
+		CredentialsSecret: "mlflow-credentials",
+	}}
+	env := r.desiredDeployment(llm).Spec.Template.Spec.Containers[0].Env
+	for name, want := range map[string]string{
+		modelregistry.URIEnv:      "models:/support-bot@champion",
+		modelregistry.EndpointEnv: "http://mlflow.mlops:5000",
+		modelregistry.PathEnv:     "model",
+	} {
+		if v, _ := envValue(env, name); v != want {
+			t.Errorf("%s = %q, want %q", name, v, want)
+		}
+	}
+	// 凭证只通过 secretKeyRef 引用，key 可选
+	refs := map[string]string{}
+	for _, e := range env {
+		if e.ValueFrom != nil && e.ValueFrom.SecretKeyRef != nil && e.ValueFrom.SecretKeyRef.Name == "mlflow-credentials" {
+			if ref := e.ValueFrom.SecretKeyRef; ref.Optional == nil || !*ref.Optional {
+				t.Errorf("%s must reference an optional key", e.Name)
+			}
+			refs[e.Name] = e.ValueFrom.SecretKeyRef.Key
+		}
+	}
+	want := map[string]string{
+		modelregistry.MLflowTokenEnv:    aiv1.ModelRegistryTokenKey,
+		modelregistry.MLflowUsernameEnv: aiv1.ModelRegistryUsernameKey,
+		modelregistry.MLflowPasswordEnv: aiv1.ModelRegistryPasswordKey,
+	}
+	if len(refs) != len(want) {
+		t.Errorf("secret refs = %v, want %v", refs, want)
+	}
+	for name, key := range want {
+		if refs[name] != key {
+			t.Errorf("%s references key %q, want %q", name, refs[name], key)
+		}
+	}
+
+	// W&B 只有 API key
+	llm.Spec.ModelSource.Registry = &aiv1.ModelRegistrySpec{URI: "wandb-artifact://acme/llm/support-bot:v3", CredentialsSecret: "wandb"}
+	env = modelRegistryEnv(llm)
+	if len(env) != 2 || env[1].Name != modelregistry.WandbAPIKeyEnv || env[1].ValueFrom.SecretKeyRef.Key != aiv1.ModelRegistryTokenKey {
+		t.Errorf("wandb env = %v, want %s and %s from the token key", env, modelregistry.URIEnv, modelregistry.WandbAPIKeyEnv)
+	}
+
+	// initContainer 模式由 model-fetch 下载
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer}
+	var fetch *corev1.Container
+	spec := r.desiredDeployment(llm).Spec.Template.Spec
+	for i := range spec.InitContainers {
+		if spec.InitContainers[i].Name == "model-fetch" {
+			fetch = &spec.InitContainers[i]
+		}
+	}
+	if fetch == nil {
+		t.Fatalf("no model-fetch init container in %v", spec.InitContainers)
+	}
+	if v, _ := envValue(fetch.Env, modelregistry.URIEnv); v != "wandb-artifact://acme/llm/support-bot:v3" {
+		t.Errorf("model-fetch %s = %q", modelregistry.URIEnv, v)
+	}
+}
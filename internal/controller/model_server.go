@@ -0,0 +1,61 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// model server 开关（spec.distribution.modelServer）
+// ============================================================================
+//
+// 8080 上的 model server 只是给其他副本供货的。只有一个副本时没有人来拿，
+// 却一直开着一个端口（hostNetwork 时直接占用节点端口）。
+//
+// 关闭之后：
+//   - 主容器不声明 8080，不加 wait-coordinator 闸门，不创建 coordinator Service
+//   - initContainer 模式不加 model-server sidecar，model-fetch 直接下载
+//   - agent（MODEL_SERVER_ENABLED=false）不按角色切换：自己下载（或者用本地已有的副本）后启动推理服务。
+//     agent 模式的 lease 仍然参与选举，status.cacheCoordinator、下载队列、驱逐保护照常按它；
+//     滚动更新时新 Pod 抢不到 lease，也没有 model server 可以同步，直接从上游下载
+//
+// 不设置时看分发策略（distribution_strategy.go）：direct 关闭，其他策略开启；
+// 策略也没设置时按副本数自动决定，副本数可能超过 1（replicas>1、External/KEDA、slo.maxReplicas、fleet hub）就开启。
+// ============================================================================
+
+// modelServerEnabled 判断是否运行 model server
+func modelServerEnabled(llm *aiv1.LLMService) bool {
+	if d := llm.Spec.Distribution; d != nil && d.ModelServer != nil {
+		return *d.ModelServer
+	}
+	switch distributionStrategy(llm) {
+	case "":
+		return mayHaveMultipleReplicas(llm)
+	case aiv1.StrategyDirect:
+		return false
+	default:
+		return true
+	}
+}
+
+// mayHaveMultipleReplicas 判断副本数是否可能超过 1
+//
+// fleet hub 上的 coordinator 还要给成员集群供货（modelEndpoint），副本数再少也要开着
+func mayHaveMultipleReplicas(llm *aiv1.LLMService) bool {
+	if llm.Spec.Replicas > 1 || externalAutoscaling(llm) || llm.Spec.Fleet != nil {
+		return true
+	}
+	if s := llm.Spec.SLO; s != nil && s.MaxReplicas != nil && *s.MaxReplicas > 1 {
+		return true
+	}
+	return false
+}
+
+// modelServerEnv 在关闭 model server 时告诉 agent（internal/agent/distribution.ModelServerEnabledFromEnv）
+func modelServerEnv(llm *aiv1.LLMService) []corev1.EnvVar {
+	if modelServerEnabled(llm) {
+		return nil
+	}
+	return []corev1.EnvVar{{Name: "MODEL_SERVER_ENABLED", Value: "false"}}
+}
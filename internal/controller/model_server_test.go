@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestModelServerEnabled(t *testing.T) {
+	one, three := int32(1), int32(3)
+	disabled, enabled := false, true
+	tests := []struct {
+		name   string
+		mutate func(*aiv1.LLMService)
+		want   bool
+	}{
+		{name: "multiple replicas", want: true},
+		{name: "single replica", mutate: func(l *aiv1.LLMService) { l.Spec.Replicas = 1 }},
+		{name: "single replica with external autoscaling", want: true, mutate: func(l *aiv1.LLMService) {
+			l.Spec.Replicas = 1
+			l.Spec.Autoscaling = &aiv1.AutoscalingSpec{Mode: aiv1.AutoscalingModeExternal}
+		}},
+		{name: "single replica with SLO scale-out", want: true, mutate: func(l *aiv1.LLMService) {
+			l.Spec.Replicas = 1
+			l.Spec.SLO = &aiv1.SLOSpec{MaxReplicas: &three}
+		}},
+		{name: "single replica with SLO capped at one", mutate: func(l *aiv1.LLMService) {
+			l.Spec.Replicas = 1
+			l.Spec.SLO = &aiv1.SLOSpec{MaxReplicas: &one}
+		}},
+		{name: "fleet hub", want: true, mutate: func(l *aiv1.LLMService) {
+			l.Spec.Replicas = 1
+			l.Spec.Fleet = &aiv1.FleetSpec{}
+		}},
+		{name: "forced on", want: true, mutate: func(l *aiv1.LLMService) {
+			l.Spec.Replicas = 1
+			l.Spec.Distribution = &aiv1.DistributionSpec{ModelServer: &enabled}
+		}},
+		{name: "forced off", mutate: func(l *aiv1.LLMService) {
+			l.Spec.Distribution = &aiv1.DistributionSpec{ModelServer: &disabled}
+		}},
+	}
+	for _, tt := range tests {
+		llm := testLLMService()
+		if tt.mutate != nil {
+			tt.mutate(llm)
+		}
+		if got := modelServerEnabled(llm); got != tt.want {
+			t.Errorf("%s: modelServerEnabled = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestModelServerDisabled(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	llm.Spec.Replicas = 1
+
+	spec := r.desiredDeployment(llm).Spec.Template.Spec
+	main := spec.Containers[0]
+	if slices.ContainsFunc(main.Ports, func(p corev1.ContainerPort) bool { return p.ContainerPort == 8080 }) {
+		t.Error("a single replica must not expose the model server port")
+	}
+	if len(spec.InitContainers) != 0 {
+		t.Errorf("init containers = %d, want no startup gate without followers", len(spec.InitContainers))
+	}
+	if !slices.ContainsFunc(main.Env, func(e corev1.EnvVar) bool { return e.Name == "MODEL_SERVER_ENABLED" && e.Value == "false" }) {
+		t.Error("agent must be told that the model server is disabled")
+	}
+	if coordinatorServiceEnabled(llm) {
+		t.Error("no coordinator Service without a model server")
+	}
+
+	// initContainer 模式：没有 model-server sidecar，只有 model-fetch
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer}
+	spec = r.desiredDeployment(llm).Spec.Template.Spec
+	if len(spec.InitContainers) != 1 || spec.InitContainers[0].Name != modelFetchContainerName {
+		t.Errorf("init containers = %+v, want only %s", spec.InitContainers, modelFetchContainerName)
+	}
+}
+
+func TestDistributionStrategy(t *testing.T) {
+	r := &LLMServiceReconciler{}
+
+	// 旧字段 shared：单副本也运行 model server
+	llm := testLLMService()
+	llm.Spec.Replicas = 1
+	llm.Spec.CacheStrategy = aiv1.CacheStrategyShared
+	if !modelServerEnabled(llm) {
+		t.Error("cacheStrategy shared must keep the model server")
+	}
+	llm.Spec.CacheStrategy = aiv1.CacheStrategyNone
+	if modelServerEnabled(llm) {
+		t.Error("cacheStrategy none must fall back to the replica count")
+	}
+
+	// direct：多副本也不运行
+	llm = testLLMService()
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Strategy: aiv1.StrategyDirect}
+	if modelServerEnabled(llm) {
+		t.Error("direct must not run the model server")
+	}
+
+	// p2p：等同于 peerServing
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Strategy: aiv1.StrategyP2P}
+	if !r.peerServing(llm) {
+		t.Error("p2p must enable peer serving")
+	}
+
+	// nodeCache：hostPath、每个节点一个副本、先删后建
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Strategy: aiv1.StrategyNodeCache}
+	deployment := r.desiredDeployment(llm)
+	spec := deployment.Spec.Template.Spec
+	i := slices.IndexFunc(spec.Volumes, func(v corev1.Volume) bool { return v.Name == "model-storage" })
+	if i < 0 || spec.Volumes[i].HostPath == nil || spec.Volumes[i].HostPath.Path != "/var/lib/kubeinfer/models/default/qwen" {
+		t.Fatalf("model volume = %+v, want the node cache hostPath", spec.Volumes)
+	}
+	if spec.Affinity == nil || spec.Affinity.PodAntiAffinity == nil ||
+		spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].TopologyKey != corev1.LabelHostname {
+		t.Error("nodeCache must spread replicas one per node")
+	}
+	if rolling := deployment.Spec.Strategy.RollingUpdate; rolling == nil || rolling.MaxSurge.IntValue() != 0 {
+		t.Errorf("rolling update = %+v, want maxSurge 0 so the new pod can reuse the node", rolling)
+	}
+}
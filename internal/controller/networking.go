@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"maps"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// rdmaResource 返回 RDMA 设备的资源名和数量，没有申请时 ok=false
+func rdmaResource(llm *aiv1.LLMService) (name corev1.ResourceName, count resource.Quantity, ok bool) {
+	n := llm.Spec.Networking
+	if n == nil || n.RDMA == nil {
+		return "", resource.Quantity{}, false
+	}
+	name = aiv1.DefaultRDMAResourceName
+	if n.RDMA.ResourceName != "" {
+		name = corev1.ResourceName(n.RDMA.ResourceName)
+	}
+	c := int64(1)
+	if n.RDMA.Count > 0 {
+		c = int64(n.RDMA.Count)
+	}
+	return name, *resource.NewQuantity(c, resource.DecimalSI), true
+}
+
+// applyNetworking 把 spec.networking 加到 Pod 模板上
+//
+// RDMA 设备的 limits 在 agentResources 里加；这里负责主机网络、IPC_LOCK 和 NCCL 环境变量。
+// NCCL 变量只给主容器（vLLM 在主容器里运行），按 key 排序，保证 Pod 模板 hash 稳定
+func applyNetworking(llm *aiv1.LLMService, podSpec *corev1.PodSpec) {
+	n := llm.Spec.Networking
+	if n == nil {
+		return
+	}
+	main := &podSpec.Containers[0]
+
+	if n.HostNetwork {
+		podSpec.HostNetwork = true
+		// 主机网络下默认的 ClusterFirst 会退化成节点的 DNS，解析不了集群内的 Service
+		podSpec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+	}
+	if n.RDMA != nil {
+		// RDMA 需要锁定内存（注册 memory region）
+		if main.SecurityContext == nil {
+			main.SecurityContext = &corev1.SecurityContext{}
+		}
+		if main.SecurityContext.Capabilities == nil {
+			main.SecurityContext.Capabilities = &corev1.Capabilities{}
+		}
+		main.SecurityContext.Capabilities.Add = append(main.SecurityContext.Capabilities.Add, "IPC_LOCK")
+	}
+	for _, k := range slices.Sorted(maps.Keys(n.NCCLEnv)) {
+		main.Env = append(main.Env, corev1.EnvVar{Name: k, Value: n.NCCLEnv[k]})
+	}
+}
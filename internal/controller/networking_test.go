@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestNetworking(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	llm.Spec.Networking = &aiv1.NetworkingSpec{
+		HostNetwork: true,
+		RDMA:        &aiv1.RDMASpec{Count: 2},
+		NCCLEnv:     map[string]string{"NCCL_SOCKET_IFNAME": "eth0", "NCCL_IB_HCA": "mlx5"},
+	}
+
+	spec := r.desiredDeployment(llm).Spec.Template.Spec
+	main := spec.Containers[0]
+	if !spec.HostNetwork || spec.DNSPolicy != corev1.DNSClusterFirstWithHostNet {
+		t.Errorf("hostNetwork = %v, dnsPolicy = %s, want host network with ClusterFirstWithHostNet", spec.HostNetwork, spec.DNSPolicy)
+	}
+	rdma := main.Resources.Limits[aiv1.DefaultRDMAResourceName]
+	if rdma.Value() != 2 {
+		t.Errorf("rdma limit = %s, want 2", rdma.String())
+	}
+	if main.SecurityContext == nil || !slices.Contains(main.SecurityContext.Capabilities.Add, "IPC_LOCK") {
+		t.Errorf("security context = %+v, want IPC_LOCK", main.SecurityContext)
+	}
+
+	// NCCL 变量按 key 排序，Pod 模板 hash 才稳定
+	ib := slices.IndexFunc(main.Env, func(e corev1.EnvVar) bool { return e.Name == "NCCL_IB_HCA" })
+	socket := slices.IndexFunc(main.Env, func(e corev1.EnvVar) bool { return e.Name == "NCCL_SOCKET_IFNAME" && e.Value == "eth0" })
+	if ib < 0 || socket < 0 || ib > socket {
+		t.Errorf("env = %v, want NCCL variables sorted by name", main.Env)
+	}
+	for range 5 {
+		if templateHash(&r.desiredDeployment(llm).Spec.Template) != templateHash(&r.desiredDeployment(llm).Spec.Template) {
+			t.Fatal("pod template hash must be stable")
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/notify"
+)
+
+// crashLoopBackOff 是 kubelet 给反复崩溃的容器设置的 waiting reason
+const crashLoopBackOff = "CrashLoopBackOff"
+
+// sendNotification 按 operator 配置的 notifications.webhooks 推送关键事件，没有配置时什么都不做
+func (r *LLMServiceReconciler) sendNotification(ctx context.Context, llm *aiv1.LLMService, event notify.Event, msg string) {
+	cfg := r.config().Notifications
+	r.notifier.Notify(ctx, cfg.Webhooks, cfg.RepeatInterval.Duration, notify.Notification{
+		Event:     event,
+		Namespace: llm.Namespace,
+		Name:      llm.Name,
+		Message:   msg,
+	})
+}
+
+// crashLoopingPods 返回有容器处于 CrashLoopBackOff 的 Pod 名称（排好序，通知去重靠消息内容）
+func crashLoopingPods(pods []corev1.Pod) []string {
+	var names []string
+	for i := range pods {
+		statuses := append(slices.Clone(pods[i].Status.InitContainerStatuses), pods[i].Status.ContainerStatuses...)
+		for _, cs := range statuses {
+			if cs.State.Waiting != nil && cs.State.Waiting.Reason == crashLoopBackOff {
+				names = append(names, pods[i].Name)
+				break
+			}
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// notifyCrashLoop 有 Pod 反复崩溃时发通知
+func (r *LLMServiceReconciler) notifyCrashLoop(ctx context.Context, llm *aiv1.LLMService, pods []corev1.Pod) {
+	if names := crashLoopingPods(pods); len(names) > 0 {
+		r.sendNotification(ctx, llm, notify.EventCrashLoop,
+			fmt.Sprintf("pods in %s: %s", crashLoopBackOff, strings.Join(names, ", ")))
+	}
+}
@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCrashLoopingPods(t *testing.T) {
+	waiting := func(reason string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{Name: agentContainerName, State: corev1.ContainerState{
+			Waiting: &corev1.ContainerStateWaiting{Reason: reason},
+		}}
+	}
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Name: "qwen-b"}, Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{waiting(crashLoopBackOff)}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "qwen-c"}, Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{waiting("ContainerCreating")}}},
+		// initContainer 模式下 model-fetch 反复失败
+		{ObjectMeta: metav1.ObjectMeta{Name: "qwen-a"}, Status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{waiting(crashLoopBackOff)}}},
+	}
+	if got := crashLoopingPods(pods); !slices.Equal(got, []string{"qwen-a", "qwen-b"}) {
+		t.Errorf("crashLoopingPods = %v", got)
+	}
+}
@@ -0,0 +1,117 @@
+package controller
+
+import (
+	"strconv"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/kubeclient"
+	"github.com/Moore-Z/kubeinfer/internal/operatorconfig"
+	"github.com/Moore-Z/kubeinfer/internal/reporting"
+)
+
+// config 返回当前生效的 operator 配置（热加载，每次 reconcile 重新读取）
+func (r *LLMServiceReconciler) config() operatorconfig.Config {
+	return r.Config.Get()
+}
+
+// costReporterCache 按费用统计配置缓存 CostReporter，配置变化时重建
+type costReporterCache struct {
+	mu       sync.Mutex
+	cfg      operatorconfig.CostReportConfig
+	reporter *reporting.CostReporter
+}
+
+// costReporter 返回费用统计器，没有开启时返回 nil
+func (r *LLMServiceReconciler) costReporter() *reporting.CostReporter {
+	cfg := r.config().CostReport
+	if !cfg.Enabled {
+		return nil
+	}
+	c := &r.costReporters
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.reporter == nil || c.cfg != cfg {
+		var utilization reporting.UtilizationSource
+		if cfg.PrometheusURL != "" {
+			utilization = &reporting.PrometheusUtilization{Client: reporting.NewPrometheusClient(cfg.PrometheusURL)}
+		}
+		c.cfg = cfg
+		c.reporter = reporting.NewCostReporter(cfg.GPUHourlyCost, cfg.Currency, utilization)
+	}
+	return c.reporter
+}
+
+// agentImage 返回 agent 容器（主容器）的镜像：spec.images.runtime、spec.image，
+// 都为空时用 operator 配置的默认镜像（开发模式是 devImage）
+func (r *LLMServiceReconciler) agentImage(llm *aiv1.LLMService) string {
+	if llm.Spec.Images != nil && llm.Spec.Images.Runtime != "" {
+		return llm.Spec.Images.Runtime
+	}
+	if llm.Spec.Image != "" {
+		return llm.Spec.Image
+	}
+	if devMode(llm) {
+		return r.config().DevImage
+	}
+	return r.config().DefaultImage
+}
+
+// agentOnlyImage 返回只运行 agent 的容器（initContainer 模式的 model-server、model-fetch）的镜像，
+// spec.images.agent 为空时用 operator 配置的 distribution.agentImage
+func (r *LLMServiceReconciler) agentOnlyImage(llm *aiv1.LLMService) string {
+	if llm.Spec.Images != nil && llm.Spec.Images.Agent != "" {
+		return llm.Spec.Images.Agent
+	}
+	return r.config().Distribution.AgentImage
+}
+
+// gatewayImage 返回 gateway 的镜像，spec.images.gateway 为空时用 operator 配置的 gateway.image
+func (r *LLMServiceReconciler) gatewayImage(llm *aiv1.LLMService) string {
+	if llm.Spec.Images != nil && llm.Spec.Images.Gateway != "" {
+		return llm.Spec.Images.Gateway
+	}
+	return r.config().Gateway.Image
+}
+
+// topology 返回模型分发拓扑，spec.distribution.topology 为空时用 operator 配置的默认拓扑
+//
+// initContainer 模式只支持 flat
+func (r *LLMServiceReconciler) topology(llm *aiv1.LLMService) string {
+	if initContainerMode(llm) {
+		return aiv1.TopologyFlat
+	}
+	if llm.Spec.Distribution != nil && llm.Spec.Distribution.Topology != "" {
+		return llm.Spec.Distribution.Topology
+	}
+	if t := r.config().Distribution.DefaultTopology; t != "" {
+		return t
+	}
+	return aiv1.TopologyFlat
+}
+
+// leaseEnv 把选举参数传给 agent（internal/agent/coordinator），没有配置时用 agent 的默认值
+func leaseEnv(cfg operatorconfig.LeaseConfig) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	if d := cfg.Duration.Duration; d > 0 {
+		env = append(env, corev1.EnvVar{Name: "AGENT_LEASE_DURATION", Value: d.String()})
+	}
+	if d := cfg.RetryPeriod.Duration; d > 0 {
+		env = append(env, corev1.EnvVar{Name: "AGENT_LEASE_RETRY_PERIOD", Value: d.String()})
+	}
+	return env
+}
+
+// agentClientEnv 把 API 客户端限流传给 agent（internal/kubeclient），没有配置时用 client-go 的默认值
+func agentClientEnv(cfg operatorconfig.KubeClientConfig) []corev1.EnvVar {
+	var env []corev1.EnvVar
+	if cfg.QPS > 0 {
+		env = append(env, corev1.EnvVar{Name: kubeclient.QPSEnv, Value: strconv.FormatFloat(float64(cfg.QPS), 'f', -1, 32)})
+	}
+	if cfg.Burst > 0 {
+		env = append(env, corev1.EnvVar{Name: kubeclient.BurstEnv, Value: strconv.Itoa(cfg.Burst)})
+	}
+	return env
+}
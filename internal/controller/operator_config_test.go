@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/operatorconfig"
+)
+
+func TestOperatorConfigDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	config := "defaultImage: custom:v1\ngateway:\n  enabledByDefault: true\ndistribution:\n  defaultTopology: zone\nlease:\n  duration: 30s\nagentClient:\n  qps: 12.5\n  burst: 40\n"
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store, err := operatorconfig.NewStore(operatorconfig.Default(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &LLMServiceReconciler{Config: store}
+
+	llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Model: "m"}}
+	if got := r.agentImage(llm); got != "custom:v1" {
+		t.Errorf("agentImage = %q, want the configured default", got)
+	}
+	if !r.gatewayEnabled(llm) {
+		t.Error("gateway should be enabled by default")
+	}
+	if got := r.topology(llm); got != aiv1.TopologyZone {
+		t.Errorf("topology = %q, want zone", got)
+	}
+	env := map[string]string{}
+	for _, e := range r.distributionEnv(llm) {
+		env[e.Name] = e.Value
+	}
+	if env["DISTRIBUTION_TOPOLOGY"] != aiv1.TopologyZone || env["AGENT_LEASE_DURATION"] != "30s" ||
+		env["KUBE_API_QPS"] != "12.5" || env["KUBE_API_BURST"] != "40" {
+		t.Errorf("distribution env = %v", env)
+	}
+
+	// spec 里写了的值优先
+	llm.Spec.Image = "mine:v2"
+	llm.Spec.Gateway = &aiv1.GatewaySpec{Enabled: false}
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Topology: aiv1.TopologyFlat}
+	if got := r.agentImage(llm); got != "mine:v2" {
+		t.Errorf("agentImage = %q, want spec.image", got)
+	}
+	if r.gatewayEnabled(llm) {
+		t.Error("spec.gateway.enabled=false must win over the default")
+	}
+	if got := r.topology(llm); got != aiv1.TopologyFlat {
+		t.Errorf("topology = %q, want flat", got)
+	}
+
+	// 没有配置：和以前的行为一致
+	r = &LLMServiceReconciler{}
+	llm = &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Model: "m"}}
+	if r.agentImage(llm) != aiv1.DefaultImage || r.gatewayEnabled(llm) || r.costReporter() != nil {
+		t.Error("without operator config the built-in defaults apply")
+	}
+	// 只有选举读节点 label 用的 NODE_NAME
+	if env := r.distributionEnv(llm); len(env) != 1 || env[0].Name != "NODE_NAME" {
+		t.Errorf("only NODE_NAME expected, got %v", env)
+	}
+}
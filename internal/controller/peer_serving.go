@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// peer serving（spec.distribution.peerServing）
+// ============================================================================
+//
+// follower 同步完成后在 8080 上提供只读副本，并向 coordinator 注册（见 internal/agent/coordinator/peers.go）。
+// peer 的 model server 和注册接口要求 token，controller 为每个 LLMService 生成一个 Secret，
+// 通过 secretKeyRef 注入，controller 自己不需要再读它。
+// ============================================================================
+
+//+kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create
+
+// modelServerTokenKey 是 Secret 里 token 的 key
+const modelServerTokenKey = "token"
+
+// modelServerSecretName 是 peer token 的 Secret 名称
+func modelServerSecretName(llm *aiv1.LLMService) string {
+	return llm.Name + "-model-server"
+}
+
+// peerServing 判断是否开启 peer serving（peerServing=true 或者 strategy=p2p）：
+// initContainer 模式、zone 拓扑和不运行 model server 时不支持
+func (r *LLMServiceReconciler) peerServing(llm *aiv1.LLMService) bool {
+	d := llm.Spec.Distribution
+	return d != nil && (d.PeerServing || distributionStrategy(llm) == aiv1.StrategyP2P) &&
+		!initContainerMode(llm) && r.topology(llm) == aiv1.TopologyFlat && modelServerEnabled(llm)
+}
+
+// ensureModelServerToken 在开启 peer serving 时创建 token Secret
+//
+// Pod 通过 secretKeyRef 引用它，所以要在 Deployment 之前创建
+func (r *LLMServiceReconciler) ensureModelServerToken(ctx context.Context, llm *aiv1.LLMService) error {
+	if !r.peerServing(llm) {
+		return nil
+	}
+	return r.ensureTokenSecret(ctx, llm, modelServerSecretName(llm))
+}
+
+// ensureTokenSecret 创建一个带随机 token 的 Secret（key 是 modelServerTokenKey）
+//
+// 已经存在就不动：换 token 要滚动所有 Pod，新旧 Pod 之间会互相拒绝。
+// owner 是 LLMService，删除时一起回收
+func (r *LLMServiceReconciler) ensureTokenSecret(ctx context.Context, llm *aiv1.LLMService, name string) error {
+	key := types.NamespacedName{Namespace: llm.Namespace, Name: name}
+	// Secret 不进 informer 缓存（cache_options.go），直接读 API server
+	err := r.apiReader().Get(ctx, key, &corev1.Secret{})
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return err
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, Labels: podLabels(llm)},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{modelServerTokenKey: []byte(hex.EncodeToString(buf))},
+	}
+	if err := controllerutil.SetControllerReference(llm, secret, r.Scheme); err != nil {
+		return err
+	}
+	err = r.Create(ctx, secret)
+	if errors.IsAlreadyExists(err) {
+		return nil
+	}
+	return err
+}
+
+// peerServingEnv 把 peer serving 的开关、token 和 Pod IP 传给 agent
+func (r *LLMServiceReconciler) peerServingEnv(llm *aiv1.LLMService) []corev1.EnvVar {
+	if !r.peerServing(llm) {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{Name: "MODEL_PEER_SERVING", Value: "true"},
+		{
+			// MODEL_SERVER_TOKEN: peer 之间认证
+			Name: "MODEL_SERVER_TOKEN",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: modelServerSecretName(llm)},
+					Key:                  modelServerTokenKey,
+				},
+			},
+		},
+		{
+			// POD_IP: peer 向 coordinator 注册自己的地址
+			Name:      "POD_IP",
+			ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.podIP"}},
+		},
+	}
+}
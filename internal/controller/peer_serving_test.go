@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestPeerServingEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		topology string
+		mode     string
+		want     bool
+	}{
+		{name: "flat agent mode", want: true},
+		{name: "zone topology", topology: aiv1.TopologyZone},
+		{name: "init container mode", mode: aiv1.DistributionModeInitContainer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &LLMServiceReconciler{}
+			llm := testLLMService()
+			llm.Spec.Distribution = &aiv1.DistributionSpec{Topology: tt.topology, Mode: tt.mode, PeerServing: true}
+
+			env := r.peerServingEnv(llm)
+			if got := len(env) > 0; got != tt.want {
+				t.Fatalf("peerServingEnv() = %+v, want enabled=%v", env, tt.want)
+			}
+			if !tt.want {
+				return
+			}
+			i := slices.IndexFunc(env, func(e corev1.EnvVar) bool { return e.Name == "MODEL_SERVER_TOKEN" })
+			if i < 0 || env[i].ValueFrom == nil || env[i].ValueFrom.SecretKeyRef == nil ||
+				env[i].ValueFrom.SecretKeyRef.Name != modelServerSecretName(llm) {
+				t.Errorf("MODEL_SERVER_TOKEN = %+v, want a reference to %s", env, modelServerSecretName(llm))
+			}
+		})
+	}
+}
@@ -0,0 +1,286 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// Dry-run：只计算变更计划，不执行
+// ============================================================================
+//
+// 改 spec 之前先加上 kubeinfer.io/dry-run: "true"，controller 会把
+// "如果现在执行会改哪些子资源" 写到 Status.Plan 并发一个 Event，
+// 运维可以先看影响范围（会不会重启 Pod、会不会删 gateway），确认后再去掉注解。
+// ============================================================================
+
+// isDryRun 判断 LLMService 是否处于 dry-run 模式
+func isDryRun(llm *aiv1.LLMService) bool {
+	return llm.Annotations[aiv1.DryRunAnnotation] == "true"
+}
+
+// reconcileDryRun 计算变更计划，写到 Status.Plan，不修改任何子资源
+func (r *LLMServiceReconciler) reconcileDryRun(ctx context.Context, llm *aiv1.LLMService) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+
+	changes, err := r.planChanges(ctx, llm, time.Now())
+	if err != nil {
+		l.Error(err, "Failed to compute dry-run plan")
+		return ctrl.Result{}, err
+	}
+
+	// 计划没变就不重复发 Event
+	old := llm.Status.Plan
+	if old == nil || old.ObservedGeneration != llm.Generation || !slices.Equal(old.Changes, changes) {
+		msg := "no changes"
+		if len(changes) > 0 {
+			msg = strings.Join(changes, "; ")
+		}
+		l.Info("Dry-run plan", "changes", changes)
+		if r.Recorder != nil {
+			r.Recorder.Event(llm, corev1.EventTypeNormal, "DryRunPlan", msg)
+		}
+		llm.Status.Plan = &aiv1.ReconcilePlan{
+			Changes:            changes,
+			ObservedGeneration: llm.Generation,
+			GeneratedAt:        metav1.Now(),
+		}
+	}
+
+	if err := r.Status().Update(ctx, llm); err != nil {
+		l.Error(err, "Failed to update LLMService status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// planChanges 对比集群里的子资源和期望状态，返回要执行的变更
+//
+// 和 Reconcile 的逻辑一一对应：Deployment、vLLM Service、-cache ConfigMap、gateway、coordinator Service、
+// 驱逐保护 PDB、对外发布（spec.expose）、KEDA ScaledObject
+func (r *LLMServiceReconciler) planChanges(ctx context.Context, llm *aiv1.LLMService, now time.Time) ([]string, error) {
+	var changes []string
+
+	desired := r.desiredDeployment(llm)
+	found := &appsv1.Deployment{}
+	err := r.Get(ctx, client.ObjectKeyFromObject(desired), found)
+	switch {
+	case errors.IsNotFound(err):
+		changes = append(changes, fmt.Sprintf("create Deployment %s with %s replicas", desired.Name, replicasString(desired.Spec.Replicas)))
+	case err != nil:
+		return nil, err
+	default:
+		changes = append(changes, diffDeployment(llm, found, desired, now)...)
+	}
+
+	svcChange, err := r.planEnsure(ctx, desiredVLLMService(llm), "Service")
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, svcChange...)
+
+	cm, err := desiredCacheConfigMap(llm)
+	if err != nil {
+		return nil, err
+	}
+	// ConfigMap 不进 informer 缓存（cache_options.go），直接读 API server
+	if err := r.apiReader().Get(ctx, client.ObjectKeyFromObject(cm), cm); errors.IsNotFound(err) {
+		changes = append(changes, fmt.Sprintf("create ConfigMap %s", cm.Name))
+	} else if err != nil {
+		return nil, err
+	}
+
+	gwDeploy := r.desiredGatewayDeployment(llm)
+	gwSvc := desiredGatewayService(llm)
+	for _, item := range []struct {
+		obj  client.Object
+		kind string
+	}{{gwDeploy, "Deployment"}, {gwSvc, "Service"}} {
+		var c []string
+		if r.gatewayEnabled(llm) {
+			c, err = r.planEnsure(ctx, item.obj, item.kind)
+		} else {
+			c, err = r.planDelete(ctx, item.obj, item.kind)
+		}
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c...)
+	}
+
+	coordSvc := desiredCoordinatorService(llm)
+	var coordChange []string
+	if coordinatorServiceEnabled(llm) {
+		coordChange, err = r.planEnsure(ctx, coordSvc, "Service")
+	} else {
+		coordChange, err = r.planDelete(ctx, coordSvc, "Service")
+	}
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, coordChange...)
+
+	pdbChanges, err := r.planEvictionProtection(ctx, llm, now)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, pdbChanges...)
+
+	exposeChanges, err := r.planExpose(ctx, llm)
+	if err != nil {
+		return nil, err
+	}
+	changes = append(changes, exposeChanges...)
+
+	// KEDA ScaledObject：没有装 KEDA 时 Get 会返回 NoMatch，plan 里照样列出来，apply 时再报错
+	switch {
+	case kedaAutoscaling(llm):
+		soChange, err := r.planEnsure(ctx, emptyScaledObject(llm), "ScaledObject")
+		if meta.IsNoMatchError(err) {
+			soChange, err = []string{fmt.Sprintf("create ScaledObject %s", llm.Name)}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, soChange...)
+	case llm.Status.ScaledObject != "":
+		changes = append(changes, fmt.Sprintf("delete ScaledObject %s", llm.Status.ScaledObject))
+	}
+
+	return changes, nil
+}
+
+// planExpose 对应 reconcileExpose：类型变了或者去掉 spec.expose 时删除旧对象，再创建新类型的对象
+//
+// HTTPRoute 的 CRD 没有安装时和 ScaledObject 一样照样列出创建，apply 时再报错
+func (r *LLMServiceReconciler) planExpose(ctx context.Context, llm *aiv1.LLMService) ([]string, error) {
+	var changes []string
+	e := llm.Spec.Expose
+	if old := llm.Status.Endpoint; old != nil && (e == nil || e.Type != old.Type) {
+		if obj, kind := exposedObject(llm, old.Type); obj != nil {
+			c, err := r.planDelete(ctx, obj, kind)
+			if err != nil && !meta.IsNoMatchError(err) {
+				return nil, err
+			}
+			changes = append(changes, c...)
+		}
+	}
+	if e == nil {
+		return changes, nil
+	}
+	obj, kind := exposedObject(llm, e.Type)
+	if obj == nil {
+		return nil, fmt.Errorf("unknown spec.expose.type %q", e.Type)
+	}
+	c, err := r.planEnsure(ctx, obj, kind)
+	if meta.IsNoMatchError(err) {
+		c, err = []string{fmt.Sprintf("create %s %s", kind, obj.GetName())}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(changes, c...), nil
+}
+
+// planEnsure 对应 applyService/applyDeployment：不存在时会创建
+func (r *LLMServiceReconciler) planEnsure(ctx context.Context, obj client.Object, kind string) ([]string, error) {
+	err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+	if errors.IsNotFound(err) {
+		return []string{fmt.Sprintf("create %s %s", kind, obj.GetName())}, nil
+	}
+	return nil, err
+}
+
+// planDelete 对应 deleteIfExists：存在时会删除
+func (r *LLMServiceReconciler) planDelete(ctx context.Context, obj client.Object, kind string) ([]string, error) {
+	err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+	if errors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []string{fmt.Sprintf("delete %s %s", kind, obj.GetName())}, nil
+}
+
+// diffDeployment 对应 syncDeployment：副本数和 Pod 模板的变化
+//
+// Pod 模板变化会触发滚动重启，这里尽量列出具体改了什么（镜像、资源、环境变量）
+func diffDeployment(llm *aiv1.LLMService, found, desired *appsv1.Deployment, now time.Time) []string {
+	var changes []string
+
+	if desired.Spec.Replicas != nil && (found.Spec.Replicas == nil || *found.Spec.Replicas != *desired.Spec.Replicas) {
+		from := int32(0)
+		if found.Spec.Replicas != nil {
+			from = *found.Spec.Replicas
+		}
+		changes = append(changes, fmt.Sprintf("scale Deployment %s from %d to %d", found.Name, from, *desired.Spec.Replicas))
+	}
+
+	if found.Annotations[templateHashAnnotation] == desired.Annotations[templateHashAnnotation] {
+		return changes
+	}
+
+	var details []string
+	if len(found.Spec.Template.Spec.Containers) > 0 && len(desired.Spec.Template.Spec.Containers) > 0 {
+		oldC, newC := found.Spec.Template.Spec.Containers[0], desired.Spec.Template.Spec.Containers[0]
+		if oldC.Image != newC.Image {
+			details = append(details, fmt.Sprintf("image %s -> %s", oldC.Image, newC.Image))
+		}
+		if !equality.Semantic.DeepEqual(oldC.Resources, newC.Resources) {
+			details = append(details, "resources")
+		}
+		details = append(details, diffEnv(oldC.Env, newC.Env)...)
+	}
+
+	msg := fmt.Sprintf("update pod template of Deployment %s (rolling restart)", found.Name)
+	if len(details) > 0 {
+		msg += ": " + strings.Join(details, ", ")
+	}
+	if allowed, next, err := updateAllowed(llm, now); err == nil && !allowed && !next.IsZero() {
+		msg += fmt.Sprintf(" [deferred until update window at %s]", next.Format(time.RFC3339))
+	}
+	return append(changes, msg)
+}
+
+// diffEnv 比较环境变量的值（ValueFrom 的变量只比较是否存在）
+func diffEnv(oldEnv, newEnv []corev1.EnvVar) []string {
+	oldVals := map[string]string{}
+	for _, e := range oldEnv {
+		oldVals[e.Name] = e.Value
+	}
+
+	var details []string
+	seen := map[string]bool{}
+	for _, e := range newEnv {
+		seen[e.Name] = true
+		v, ok := oldVals[e.Name]
+		switch {
+		case !ok:
+			details = append(details, fmt.Sprintf("env %s added", e.Name))
+		case v != e.Value:
+			details = append(details, fmt.Sprintf("env %s %q -> %q", e.Name, v, e.Value))
+		}
+	}
+	for _, e := range oldEnv {
+		if !seen[e.Name] {
+			details = append(details, fmt.Sprintf("env %s removed", e.Name))
+		}
+	}
+	return details
+}
@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestDiffDeployment(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := &aiv1.LLMService{
+		ObjectMeta: metav1.ObjectMeta{Name: "qwen", Namespace: "default"},
+		Spec:       aiv1.LLMServiceSpec{Model: "Qwen/Qwen2.5-0.5B", Replicas: 1, Image: "vllm/vllm-openai:v0.6.0"},
+	}
+	now := time.Date(2026, 1, 7, 12, 0, 0, 0, time.UTC)
+
+	found := r.desiredDeployment(llm)
+
+	// 没有变化
+	if changes := diffDeployment(llm, found, r.desiredDeployment(llm), now); len(changes) != 0 {
+		t.Fatalf("expected no changes, got %v", changes)
+	}
+
+	// 改副本数和镜像
+	llm.Spec.Replicas = 3
+	llm.Spec.Image = "vllm/vllm-openai:v0.7.0"
+	changes := diffDeployment(llm, found, r.desiredDeployment(llm), now)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 changes, got %v", changes)
+	}
+	if !strings.Contains(changes[0], "from 1 to 3") {
+		t.Errorf("unexpected scale change: %s", changes[0])
+	}
+	if !strings.Contains(changes[1], "image vllm/vllm-openai:v0.6.0 -> vllm/vllm-openai:v0.7.0") {
+		t.Errorf("unexpected template change: %s", changes[1])
+	}
+
+	// 有维护窗口时标明延后
+	llm.Spec.UpdateWindow = &aiv1.UpdateWindow{Schedule: "0 2 * * *"}
+	changes = diffDeployment(llm, &appsv1.Deployment{
+		ObjectMeta: found.ObjectMeta,
+		Spec:       found.Spec,
+	}, r.desiredDeployment(llm), now)
+	if !strings.Contains(changes[len(changes)-1], "deferred until update window") {
+		t.Errorf("expected deferred note, got %v", changes)
+	}
+}
@@ -0,0 +1,96 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// appArmorAnnotationPrefix 是 Kubernetes 1.30 以前设置 AppArmor 的注解，后面跟容器名
+//
+// 1.30 起用 securityContext.appArmorProfile，两者同时设置时 API server 要求一致
+const appArmorAnnotationPrefix = "container.apparmor.security.beta.kubernetes.io/"
+
+// applyPodSecurity 把 spec.podSecurity 加到 Pod 模板上，所有容器（包括 initContainer）都要满足
+//
+// 要在 applyNetworking 之后调用：restricted 模式下去掉所有 capability，webhook 已经拒绝了和 RDMA 一起使用
+func applyPodSecurity(llm *aiv1.LLMService, template *corev1.PodTemplateSpec) {
+	ps := llm.Spec.PodSecurity
+	if ps == nil {
+		return
+	}
+	podSpec := &template.Spec
+	if podSpec.SecurityContext == nil {
+		podSpec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	sc := podSpec.SecurityContext
+
+	if ps.SeccompProfile != nil {
+		sc.SeccompProfile = ps.SeccompProfile.DeepCopy()
+	}
+	if ps.AppArmorProfile != nil {
+		sc.AppArmorProfile = ps.AppArmorProfile.DeepCopy()
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		value := appArmorAnnotationValue(ps.AppArmorProfile)
+		for _, c := range podSpec.InitContainers {
+			template.Annotations[appArmorAnnotationPrefix+c.Name] = value
+		}
+		for _, c := range podSpec.Containers {
+			template.Annotations[appArmorAnnotationPrefix+c.Name] = value
+		}
+	}
+	if ps.UserNamespace {
+		hostUsers := false
+		podSpec.HostUsers = &hostUsers
+	}
+
+	if !ps.Restricted {
+		return
+	}
+	user := aiv1.DefaultRunAsUser
+	if ps.RunAsUser != nil {
+		user = *ps.RunAsUser
+	}
+	nonRoot := true
+	group := user
+	sc.RunAsNonRoot = &nonRoot
+	sc.RunAsUser = &user
+	// emptyDir、PVC 上的模型文件属于这个组，非 root 的 agent 也能写
+	sc.FSGroup = &group
+	if sc.SeccompProfile == nil {
+		sc.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	}
+	for i := range podSpec.InitContainers {
+		restrictContainer(&podSpec.InitContainers[i])
+	}
+	for i := range podSpec.Containers {
+		restrictContainer(&podSpec.Containers[i])
+	}
+}
+
+// restrictContainer 设置 restricted 级别要求容器自己声明的字段
+func restrictContainer(c *corev1.Container) {
+	if c.SecurityContext == nil {
+		c.SecurityContext = &corev1.SecurityContext{}
+	}
+	escalation := false
+	c.SecurityContext.AllowPrivilegeEscalation = &escalation
+	c.SecurityContext.Capabilities = &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}}
+}
+
+// appArmorAnnotationValue 把 AppArmorProfile 换成注解的写法：runtime/default、localhost/<name>、unconfined
+func appArmorAnnotationValue(p *corev1.AppArmorProfile) string {
+	switch p.Type {
+	case corev1.AppArmorProfileTypeLocalhost:
+		if p.LocalhostProfile == nil {
+			return "localhost/"
+		}
+		return "localhost/" + *p.LocalhostProfile
+	case corev1.AppArmorProfileTypeUnconfined:
+		return "unconfined"
+	default:
+		return "runtime/default"
+	}
+}
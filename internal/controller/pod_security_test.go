@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestPodSecurity(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	profile := "kubeinfer-vllm"
+	llm.Spec.PodSecurity = &aiv1.PodSecuritySpec{
+		Restricted:      true,
+		AppArmorProfile: &corev1.AppArmorProfile{Type: corev1.AppArmorProfileTypeLocalhost, LocalhostProfile: &profile},
+		UserNamespace:   true,
+	}
+
+	tpl := r.desiredDeployment(llm).Spec.Template
+	sc := tpl.Spec.SecurityContext
+	if sc == nil || sc.RunAsNonRoot == nil || !*sc.RunAsNonRoot || *sc.RunAsUser != aiv1.DefaultRunAsUser || *sc.FSGroup != aiv1.DefaultRunAsUser {
+		t.Fatalf("pod security context = %+v, want non-root user %d", sc, aiv1.DefaultRunAsUser)
+	}
+	if sc.SeccompProfile == nil || sc.SeccompProfile.Type != corev1.SeccompProfileTypeRuntimeDefault {
+		t.Errorf("seccomp = %+v, want RuntimeDefault for restricted", sc.SeccompProfile)
+	}
+	if tpl.Spec.HostUsers == nil || *tpl.Spec.HostUsers {
+		t.Error("userNamespace must set hostUsers: false")
+	}
+	// startup gate 也要满足 restricted，AppArmor 注解每个容器一个
+	containers := append(tpl.Spec.InitContainers, tpl.Spec.Containers...)
+	if len(containers) < 2 {
+		t.Fatalf("got %d containers, want the startup gate and the agent", len(containers))
+	}
+	for _, c := range containers {
+		csc := c.SecurityContext
+		if csc == nil || csc.AllowPrivilegeEscalation == nil || *csc.AllowPrivilegeEscalation ||
+			csc.Capabilities == nil || len(csc.Capabilities.Drop) != 1 || csc.Capabilities.Drop[0] != "ALL" {
+			t.Errorf("%s security context = %+v, want no privilege escalation and all capabilities dropped", c.Name, csc)
+		}
+		if got := tpl.Annotations[appArmorAnnotationPrefix+c.Name]; got != "localhost/kubeinfer-vllm" {
+			t.Errorf("%s AppArmor annotation = %q", c.Name, got)
+		}
+	}
+
+	// gateway Pod 同样加固
+	gw := r.desiredGatewayDeployment(llm).Spec.Template.Spec
+	if gw.SecurityContext == nil || gw.SecurityContext.RunAsNonRoot == nil || gw.HostUsers == nil {
+		t.Errorf("gateway pod is not hardened: %+v", gw.SecurityContext)
+	}
+
+	// 只设置 seccomp：不动用户和 capability
+	llm.Spec.PodSecurity = &aiv1.PodSecuritySpec{SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}}
+	tpl = r.desiredDeployment(llm).Spec.Template
+	if tpl.Spec.SecurityContext.RunAsUser != nil || tpl.Spec.HostUsers != nil || tpl.Spec.Containers[0].SecurityContext != nil {
+		t.Errorf("seccomp only must not change users or capabilities: %+v", tpl.Spec)
+	}
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"math"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/modelcatalog"
+)
+
+const (
+	// healthPortName 是 agent 健康检查端口（见 cmd/agent serveHealth）
+	healthPortName = "health"
+
+	// startupPeriodSeconds 启动探针的检查间隔
+	startupPeriodSeconds = 10
+
+	// defaultModelBillions 无法从模型名/GPUMemory 推断大小时，按 7B 估算
+	defaultModelBillions = 7.0
+)
+
+// modelSizeBillions 估算模型参数量（单位：十亿）
+//
+// 优先用 modelcatalog（内置表 + HuggingFace 的命名习惯），
+// 判断不出来就用 GPUMemory 估算（fp16 每个参数 2 字节），都没有就按默认值。
+func modelSizeBillions(llm *aiv1.LLMService) float64 {
+	if size, ok := modelcatalog.ParameterBillions(llm.Spec.Model); ok {
+		return size
+	}
+
+	if llm.Spec.GPUMemory != "" {
+		if q, err := resource.ParseQuantity(llm.Spec.GPUMemory); err == nil {
+			gib := float64(q.Value()) / (1 << 30)
+			if gib > 0 {
+				return gib / 2
+			}
+		}
+	}
+
+	return defaultModelBillions
+}
+
+// startupFailureThreshold 根据模型大小计算启动探针的失败次数上限
+//
+// 允许的启动时间 = 10 分钟 + 每十亿参数 1 分钟，例如：
+// - 0.5B → 11 分钟
+// - 7B   → 17 分钟
+// - 70B  → 80 分钟
+// 启动时间包含模型下载/同步和加载到显存，宁可宽松，避免大模型被反复杀掉
+func startupFailureThreshold(llm *aiv1.LLMService) int32 {
+	minutes := 10 + math.Ceil(modelSizeBillions(llm))
+	return int32(minutes * 60 / startupPeriodSeconds)
+}
+
+// httpProbe 生成访问 agent 健康检查端口的 HTTP 探针
+func httpProbe(path string) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path: path,
+				Port: intstr.FromString(healthPortName),
+			},
+		},
+	}
+}
+
+// agentProbes 生成 agent 容器的 startup/readiness/liveness 探针
+//
+// 为什么需要 startupProbe？
+// - startupProbe 成功之前，kubelet 不会执行 liveness/readiness
+// - 大模型加载期间 liveness 不会误杀 Pod
+func agentProbes(llm *aiv1.LLMService) (startup, readiness, liveness *corev1.Probe) {
+	startup = httpProbe("/readyz")
+	startup.PeriodSeconds = startupPeriodSeconds
+	startup.FailureThreshold = startupFailureThreshold(llm)
+
+	readiness = httpProbe("/readyz")
+	readiness.PeriodSeconds = 10
+	readiness.FailureThreshold = 3
+
+	if devMode(llm) {
+		// 总时间不变，只是检查得更勤
+		startup.FailureThreshold *= startupPeriodSeconds / devProbePeriodSeconds
+		startup.PeriodSeconds = devProbePeriodSeconds
+		readiness.PeriodSeconds = devProbePeriodSeconds
+	}
+
+	liveness = httpProbe("/healthz")
+	liveness.PeriodSeconds = 20
+	liveness.FailureThreshold = 3
+
+	if p := llm.Spec.Probes; p != nil {
+		if p.Startup != nil {
+			startup = p.Startup.DeepCopy()
+		}
+		if p.Readiness != nil {
+			readiness = p.Readiness.DeepCopy()
+		}
+		if p.Liveness != nil {
+			liveness = p.Liveness.DeepCopy()
+		}
+	}
+	return startup, readiness, liveness
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestModelSizeBillions(t *testing.T) {
+	tests := []struct {
+		model     string
+		gpuMemory string
+		want      float64
+	}{
+		{model: "meta-llama/Llama-2-7b-hf", want: 7},
+		{model: "meta-llama/Llama-3.1-70B-Instruct", want: 70},
+		{model: "Qwen/Qwen2.5-0.5B", want: 0.5},
+		{model: "facebook/opt-125m", want: 0.125},
+		{model: "mistralai/Mixtral-8x7B-v0.1", want: 46.7},
+		{model: "someorg/Mixtral-8x7B-finetune", want: 56},
+		{model: "deepseek-ai/deepseek-r1", want: 671},
+		{model: "acme/internal-model", gpuMemory: "24Gi", want: 12},
+		{model: "acme/internal-model", want: defaultModelBillions},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Model: tt.model, GPUMemory: tt.gpuMemory}}
+		if got := modelSizeBillions(llm); got != tt.want {
+			t.Errorf("modelSizeBillions(%q, %q) = %v, want %v", tt.model, tt.gpuMemory, got, tt.want)
+		}
+	}
+}
+
+func TestAgentProbes(t *testing.T) {
+	llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Model: "meta-llama/Llama-3.1-70B-Instruct"}}
+
+	startup, readiness, liveness := agentProbes(llm)
+	// 70B → 80 分钟 → 480 次
+	if startup.FailureThreshold != 480 {
+		t.Errorf("startup failureThreshold = %d, want 480", startup.FailureThreshold)
+	}
+	if readiness.HTTPGet.Path != "/readyz" || liveness.HTTPGet.Path != "/healthz" {
+		t.Errorf("unexpected probe paths: readiness=%s liveness=%s", readiness.HTTPGet.Path, liveness.HTTPGet.Path)
+	}
+
+	// 用户覆盖 startup 探针
+	llm.Spec.Probes = &aiv1.ProbesSpec{Startup: &corev1.Probe{FailureThreshold: 5}}
+	startup, _, _ = agentProbes(llm)
+	if startup.FailureThreshold != 5 {
+		t.Errorf("startup override ignored: failureThreshold = %d", startup.FailureThreshold)
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+// ============================================================================
+// reconcile 指标
+// ============================================================================
+//
+//	kubeinfer_reconcile_total{result}          success / requeue / error
+//	kubeinfer_reconcile_errors_total{reason}   出错时按原因归类
+//	kubeinfer_reconcile_queue_depth            workqueue 里等待的对象数
+//
+// result 和 reason 由 Reconcile 的返回值决定，所以在 defer 里读命名返回值，
+// 而不是在每个 return 前各记一次（很容易漏掉某个错误分支）。
+// ============================================================================
+
+// llmServiceControllerLabel 是 LLMService controller 在指标里的 controller 标签
+const llmServiceControllerLabel = "LLMService"
+
+// reconcileOutcome 把 Reconcile 的返回值归类成 result 和 reason 标签
+func reconcileOutcome(result ctrl.Result, err error) (outcome, reason string) {
+	switch {
+	case err != nil:
+		return "error", errorReason(err)
+	case result.Requeue || result.RequeueAfter > 0:
+		return "requeue", ""
+	default:
+		return "success", ""
+	}
+}
+
+// errorReason 把错误归类成有限的几种，作为 reason 标签
+func errorReason(err error) string {
+	switch {
+	case errors.Is(err, reconcile.TerminalError(nil)):
+		return "terminal"
+	case apierrors.IsConflict(err), apierrors.IsAlreadyExists(err):
+		return "conflict"
+	case apierrors.IsNotFound(err):
+		return "not_found"
+	case apierrors.IsForbidden(err), apierrors.IsUnauthorized(err):
+		return "forbidden"
+	case apierrors.IsTooManyRequests(err):
+		return "throttled"
+	case apierrors.IsTimeout(err), apierrors.IsServerTimeout(err),
+		errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return "timeout"
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err):
+		return "invalid"
+	case meta.IsNoMatchError(err):
+		return "no_kind_match"
+	default:
+		return "unknown"
+	}
+}
+
+// depthQueue 包装 controller 的 workqueue，入队、出队、处理完成时把队列长度写到 kubeinfer_reconcile_queue_depth
+//
+// AddAfter/AddRateLimited 的对象到时间后由内部队列自己入队，不经过这里，
+// 长度会在下一次 Get/Done 时更新；队列积压时 worker 一直在 Get/Done，所以数值是准的
+type depthQueue struct {
+	workqueue.TypedRateLimitingInterface[reconcile.Request]
+	controller string
+}
+
+// newDepthQueue 是 controller.Options.NewQueue，和 controller-runtime 的默认队列一样（带 workqueue_* 指标）
+func newDepthQueue(controller string) func(string, workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+	return func(name string, rateLimiter workqueue.TypedRateLimiter[reconcile.Request]) workqueue.TypedRateLimitingInterface[reconcile.Request] {
+		return &depthQueue{
+			TypedRateLimitingInterface: workqueue.NewTypedRateLimitingQueueWithConfig(rateLimiter,
+				workqueue.TypedRateLimitingQueueConfig[reconcile.Request]{Name: name}),
+			controller: controller,
+		}
+	}
+}
+
+func (q *depthQueue) update() {
+	metrics.ReconcileQueueDepth.WithLabelValues(q.controller).Set(float64(q.Len()))
+}
+
+func (q *depthQueue) Add(item reconcile.Request) {
+	q.TypedRateLimitingInterface.Add(item)
+	q.update()
+}
+
+func (q *depthQueue) Get() (reconcile.Request, bool) {
+	item, shutdown := q.TypedRateLimitingInterface.Get()
+	q.update()
+	return item, shutdown
+}
+
+func (q *depthQueue) Done(item reconcile.Request) {
+	q.TypedRateLimitingInterface.Done(item)
+	q.update()
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// gaugeValue 从 controller-runtime 的 Registry 里读一个 gauge（标签 label 的值等于 value）
+func gaugeValue(t *testing.T, name, label, value string) float64 {
+	t.Helper()
+	families, err := ctrlmetrics.Registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mf := range families {
+		if mf.GetName() != name {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == label && l.GetValue() == value {
+					return m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	t.Fatalf("metric %s{%s=%q} not found", name, label, value)
+	return 0
+}
+
+func TestReconcileOutcome(t *testing.T) {
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	tests := []struct {
+		name        string
+		result      ctrl.Result
+		err         error
+		wantOutcome string
+		wantReason  string
+	}{
+		{name: "done", wantOutcome: "success"},
+		{name: "requeue after", result: ctrl.Result{RequeueAfter: time.Minute}, wantOutcome: "requeue"},
+		{name: "conflict", err: apierrors.NewConflict(gr, "qwen", fmt.Errorf("modified")), wantOutcome: "error", wantReason: "conflict"},
+		{name: "wrapped forbidden", err: fmt.Errorf("failed to apply: %w", apierrors.NewForbidden(gr, "qwen", fmt.Errorf("rbac"))), wantOutcome: "error", wantReason: "forbidden"},
+		{name: "throttled", err: apierrors.NewTooManyRequests("slow down", 1), wantOutcome: "error", wantReason: "throttled"},
+		{name: "deadline", err: fmt.Errorf("get: %w", context.DeadlineExceeded), wantOutcome: "error", wantReason: "timeout"},
+		{name: "no kind", err: &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: "keda.sh", Kind: "ScaledObject"}}, wantOutcome: "error", wantReason: "no_kind_match"},
+		{name: "terminal", err: reconcile.TerminalError(fmt.Errorf("bad spec")), wantOutcome: "error", wantReason: "terminal"},
+		// 出错时 result 被忽略
+		{name: "error with requeue", result: ctrl.Result{RequeueAfter: time.Minute}, err: fmt.Errorf("boom"), wantOutcome: "error", wantReason: "unknown"},
+	}
+
+	for _, tt := range tests {
+		outcome, reason := reconcileOutcome(tt.result, tt.err)
+		if outcome != tt.wantOutcome || reason != tt.wantReason {
+			t.Errorf("%s: outcome = %s/%s, want %s/%s", tt.name, outcome, reason, tt.wantOutcome, tt.wantReason)
+		}
+	}
+}
+
+func TestDepthQueue(t *testing.T) {
+	q := newDepthQueue("test")("test", workqueue.DefaultTypedControllerRateLimiter[reconcile.Request]())
+	defer q.ShutDown()
+	depth := func() float64 { return gaugeValue(t, "kubeinfer_reconcile_queue_depth", "controller", "test") }
+
+	a := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "a"}}
+	b := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "b"}}
+	q.Add(a)
+	q.Add(b)
+	q.Add(a) // 去重
+	if got := depth(); got != 2 {
+		t.Fatalf("depth after add = %v, want 2", got)
+	}
+	item, _ := q.Get()
+	if got := depth(); got != 1 {
+		t.Errorf("depth after get = %v, want 1", got)
+	}
+	q.Done(item)
+	if got := depth(); got != 1 {
+		t.Errorf("depth after done = %v, want 1", got)
+	}
+}
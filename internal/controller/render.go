@@ -0,0 +1,42 @@
+package controller
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/operatorconfig"
+)
+
+// Desired 是 controller 为一个 LLMService 生成的子资源
+type Desired struct {
+	Deployment *appsv1.Deployment
+	Service    *corev1.Service
+	ConfigMap  *corev1.ConfigMap
+	// gateway 没有开启时为 nil（controller 会删除已有的 gateway）
+	GatewayDeployment *appsv1.Deployment
+	GatewayService    *corev1.Service
+}
+
+// RenderDesired 不连集群，按 cfg 生成 llm 的子资源，pkg/render 对外暴露的就是它
+//
+// 和 Reconcile 用的是同一套 desired* 函数，所以渲染结果就是 controller 会 apply 的内容
+// （owner reference 和 server-side apply 的 field manager 除外）
+func RenderDesired(llm *aiv1.LLMService, cfg operatorconfig.Config) (*Desired, error) {
+	r := &LLMServiceReconciler{Config: operatorconfig.NewStaticStore(cfg)}
+
+	cm, err := desiredCacheConfigMap(llm)
+	if err != nil {
+		return nil, err
+	}
+	d := &Desired{
+		Deployment: r.desiredDeployment(llm),
+		Service:    desiredVLLMService(llm),
+		ConfigMap:  cm,
+	}
+	if r.gatewayEnabled(llm) {
+		d.GatewayDeployment = r.desiredGatewayDeployment(llm)
+		d.GatewayService = desiredGatewayService(llm)
+	}
+	return d, nil
+}
@@ -0,0 +1,71 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// defaultAgentResources 是没有设置 Spec.Resources 时 agent 容器的默认资源
+//
+// 为什么需要默认值？
+// - 没有 requests 的 Pod 是 BestEffort，调度器认为它不占资源
+// - GPU 节点上很容易被挤到 CPU/内存不够的地方，或者第一个被驱逐
+// - vLLM 加载模型时要先把权重读进内存，内存需求不小
+func defaultAgentResources() corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("2"),
+			corev1.ResourceMemory: resource.MustParse("8Gi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("32Gi"),
+		},
+	}
+}
+
+// agentResources 生成 agent 容器最终的资源配置
+//
+// CPU/内存来自 Spec.Resources（或默认值，开发模式的默认值更小），GPU 只来自 GpuPerReplica（开发模式不申请）：
+// 用户在 Resources 里写的 nvidia.com/gpu 会被忽略（webhook 会直接拒绝）；大页来自 Spec.HugePages，RDMA 设备来自 Spec.Networking.RDMA
+func agentResources(llm *aiv1.LLMService) corev1.ResourceRequirements {
+	res := defaultAgentResources()
+	if devMode(llm) {
+		res = defaultDevResources()
+	}
+	if llm.Spec.Resources != nil {
+		res = *llm.Spec.Resources.DeepCopy()
+	}
+
+	delete(res.Requests, aiv1.GPUResourceName)
+	delete(res.Limits, aiv1.GPUResourceName)
+
+	if llm.Spec.GpuPerReplica > 0 && !devMode(llm) {
+		gpu := *resource.NewQuantity(int64(llm.Spec.GpuPerReplica), resource.DecimalSI)
+		if res.Limits == nil {
+			res.Limits = corev1.ResourceList{}
+		}
+		// 扩展资源只需要写 limits，requests 会自动等于 limits
+		res.Limits[aiv1.GPUResourceName] = gpu
+	}
+	if name, count, ok := rdmaResource(llm); ok {
+		if res.Limits == nil {
+			res.Limits = corev1.ResourceList{}
+		}
+		res.Limits[name] = count
+	}
+	if h := llm.Spec.HugePages; h != nil {
+		// 大页的 requests 必须等于 limits
+		name := hugePagesResourceName(h)
+		if res.Requests == nil {
+			res.Requests = corev1.ResourceList{}
+		}
+		if res.Limits == nil {
+			res.Limits = corev1.ResourceList{}
+		}
+		res.Requests[name] = h.Size.DeepCopy()
+		res.Limits[name] = h.Size.DeepCopy()
+	}
+	return res
+}
@@ -0,0 +1,39 @@
+package controller
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// 滚动重启：kubectl annotate llmservice qwen kubeinfer.io/restartedAt=...
+// ============================================================================
+//
+// 和 kubectl rollout restart 一样，把注解的值写到 Pod 模板的 annotation 上，模板 hash 变化触发滚动更新。
+// 直接对 Deployment 做 rollout restart 会被下一次 apply 改回去（模板 hash 对不上），所以要从 LLMService 传下去。
+//
+// 和普通的 spec 变更不同：
+//   - 不等 UpdateWindow，用户明确要求现在重启（例如节点升级完 GPU 驱动之后）
+//   - coordinator 最后重启：旧 ReplicaSet 缩容时按 pod-deletion-cost 从低到高删（见 deletion_cost.go），
+//     coordinator 的 cost 最高，新的 follower 先从它同步完模型，它才被删掉、新 Pod 接管 Lease
+// ============================================================================
+
+// applyRestartedAt 把 LLMService 上的 restartedAt 注解传到 Pod 模板
+func applyRestartedAt(llm *aiv1.LLMService, tpl *corev1.PodTemplateSpec) {
+	restartedAt, ok := llm.Annotations[aiv1.RestartedAtAnnotation]
+	if !ok || restartedAt == "" {
+		return
+	}
+	if tpl.Annotations == nil {
+		tpl.Annotations = map[string]string{}
+	}
+	tpl.Annotations[aiv1.RestartedAtAnnotation] = restartedAt
+}
+
+// restartRequested 判断这次模板变化是否包含一次新的重启请求
+func restartRequested(found, desired *appsv1.Deployment) bool {
+	want := desired.Spec.Template.Annotations[aiv1.RestartedAtAnnotation]
+	return want != "" && found.Spec.Template.Annotations[aiv1.RestartedAtAnnotation] != want
+}
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"testing"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestRestartedAt(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	before := r.desiredDeployment(llm)
+
+	llm.Annotations = map[string]string{aiv1.RestartedAtAnnotation: "2026-10-16T08:00:00Z"}
+	after := r.desiredDeployment(llm)
+
+	if got := after.Spec.Template.Annotations[aiv1.RestartedAtAnnotation]; got != "2026-10-16T08:00:00Z" {
+		t.Errorf("template restartedAt = %q, want the LLMService annotation", got)
+	}
+	if before.Annotations[templateHashAnnotation] == after.Annotations[templateHashAnnotation] {
+		t.Error("template hash must change when restartedAt changes")
+	}
+	if !restartRequested(before, after) {
+		t.Error("restartRequested = false, want true for a new restartedAt")
+	}
+	if restartRequested(after, after) {
+		t.Error("restartRequested = true, want false once the restart was applied")
+	}
+}
@@ -0,0 +1,31 @@
+package controller
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// applyRollout 把 spec.rollout 的 maxSurge/maxUnavailable 写到 Deployment 的滚动更新策略上
+//
+// 都没设置时不写 strategy，用 Deployment 的默认值（25%/25%），已有的 LLMService 行为不变。
+// terminationGracePeriodSeconds 在 Pod 模板里，见 terminationGracePeriod
+func applyRollout(llm *aiv1.LLMService, deployment *appsv1.Deployment) {
+	r := llm.Spec.Rollout
+	if r == nil || (r.MaxSurge == nil && r.MaxUnavailable == nil) {
+		return
+	}
+	rolling := &appsv1.RollingUpdateDeployment{}
+	if r.MaxSurge != nil {
+		surge := *r.MaxSurge
+		rolling.MaxSurge = &surge
+	}
+	if r.MaxUnavailable != nil {
+		unavailable := *r.MaxUnavailable
+		rolling.MaxUnavailable = &unavailable
+	}
+	deployment.Spec.Strategy = appsv1.DeploymentStrategy{
+		Type:          appsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: rolling,
+	}
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestRollout(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	if s := r.desiredDeployment(llm).Spec.Strategy; s.RollingUpdate != nil {
+		t.Errorf("strategy = %+v, want the Deployment default without spec.rollout", s)
+	}
+
+	surge, unavailable, grace := intstr.FromInt32(0), intstr.FromInt32(1), int64(90)
+	llm.Spec.Rollout = &aiv1.RolloutSpec{MaxSurge: &surge, MaxUnavailable: &unavailable, TerminationGracePeriodSeconds: &grace}
+	d := r.desiredDeployment(llm)
+
+	s := d.Spec.Strategy
+	if s.Type != appsv1.RollingUpdateDeploymentStrategyType || s.RollingUpdate == nil ||
+		s.RollingUpdate.MaxSurge.IntValue() != 0 || s.RollingUpdate.MaxUnavailable.IntValue() != 1 {
+		t.Errorf("strategy = %+v, want maxSurge=0 maxUnavailable=1", s)
+	}
+	if got := d.Spec.Template.Spec.TerminationGracePeriodSeconds; got == nil || *got != 90 {
+		t.Errorf("terminationGracePeriodSeconds = %v, want 90", got)
+	}
+}
+
+func TestDrainTimeout(t *testing.T) {
+	grace := func(seconds int64) *aiv1.RolloutSpec {
+		return &aiv1.RolloutSpec{TerminationGracePeriodSeconds: &seconds}
+	}
+	tests := []struct {
+		name    string
+		rollout *aiv1.RolloutSpec
+		maxGen  time.Duration
+		want    time.Duration
+	}{
+		{name: "default", want: defaultMaxGenerationTime},
+		{name: "long grace period", rollout: grace(3600), maxGen: 10 * time.Minute, want: 10 * time.Minute},
+		{name: "short grace period", rollout: grace(90), want: time.Minute},
+		{name: "grace period below buffer", rollout: grace(20), want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		llm := testLLMService()
+		llm.Spec.Rollout = tt.rollout
+		if tt.maxGen > 0 {
+			llm.Spec.MaxGenerationTime = &metav1.Duration{Duration: tt.maxGen}
+		}
+		if got := drainTimeout(llm); got != tt.want {
+			t.Errorf("%s: drainTimeout = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/agent/runtime"
+)
+
+// runtimeSocketEnv 在 spec.runtimeSocket 时让推理服务只监听 unix socket（见 agent/runtime/socket.go）
+//
+// agent 和推理服务在同一个容器里，socket 放在容器自己的 /tmp 下，不需要共享卷；
+// 探针、Service 仍然访问 8000 端口，由 agent 转发
+func runtimeSocketEnv(llm *aiv1.LLMService) []corev1.EnvVar {
+	if !llm.Spec.RuntimeSocket {
+		return nil
+	}
+	return []corev1.EnvVar{{Name: runtime.SocketEnv, Value: runtime.DefaultSocket}}
+}
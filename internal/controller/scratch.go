@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"fmt"
+	"math"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/modelcatalog"
+)
+
+const (
+	scratchVolumeName = "scratch"
+	scratchMountPath  = "/scratch"
+
+	// defaultScratchSize 是看不出模型大小时 scratch 卷的 sizeLimit
+	defaultScratchSize = "20Gi"
+	// scratchHeadroomGiB 是权重之外的余量：tokenizer、配置文件、vLLM 和 Python 的临时文件
+	scratchHeadroomGiB = 2
+	// scratchOverhead 是 safetensors 分片元数据、下载重试留下的临时文件
+	scratchOverhead = 1.1
+)
+
+// scratchSize 返回 scratch 卷的 sizeLimit：spec.storage.scratch.size，或者按模型权重大小估算
+//
+// 最坏情况是整个模型先暂存在 HF_HOME 下再复制到模型目录
+func scratchSize(llm *aiv1.LLMService) resource.Quantity {
+	if s := llm.Spec.Storage; s != nil && s.Scratch != nil && s.Scratch.Size != nil {
+		return s.Scratch.Size.DeepCopy()
+	}
+	billions, ok := modelcatalog.ParameterBillions(llm.Spec.Model)
+	if !ok {
+		return resource.MustParse(defaultScratchSize)
+	}
+	gib := math.Ceil(modelcatalog.WeightsGiB(billions, modelcatalog.DetectPrecision(llm.Spec.Model))*scratchOverhead) + scratchHeadroomGiB
+	return resource.MustParse(fmt.Sprintf("%dGi", int64(gib)))
+}
+
+// applyScratch 挂载 scratch 卷，HF_HOME 和 TMPDIR 指向它
+//
+// 挂给会下载或者运行推理服务的容器：主容器，initContainer 模式下还有 model-fetch、model-server。
+// 要在 applyUserEnv 之前调用；用户在 spec.env 里设置了的变量不生成，由 applyUserEnv 加上用户的值
+func applyScratch(llm *aiv1.LLMService, podSpec *corev1.PodSpec) {
+	limit := scratchSize(llm)
+	medium := corev1.StorageMediumDefault
+	if s := llm.Spec.Storage; s != nil && s.Scratch != nil && s.Scratch.Medium == string(corev1.StorageMediumMemory) {
+		medium = corev1.StorageMediumMemory
+	}
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: scratchVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{Medium: medium, SizeLimit: &limit},
+		},
+	})
+
+	userEnv := map[string]bool{}
+	for _, e := range llm.Spec.Env {
+		userEnv[e.Name] = true
+	}
+	var env []corev1.EnvVar
+	for _, e := range []corev1.EnvVar{
+		// huggingface-cli、hf_xet 的缓存和临时文件，vLLM 下载 tokenizer 也用它
+		{Name: "HF_HOME", Value: scratchMountPath + "/huggingface"},
+		// Python tempfile、Go os.TempDir
+		{Name: "TMPDIR", Value: scratchMountPath},
+	} {
+		if !userEnv[e.Name] {
+			env = append(env, e)
+		}
+	}
+
+	containers := []*corev1.Container{&podSpec.Containers[0]}
+	if initContainerMode(llm) {
+		for i := range podSpec.InitContainers {
+			containers = append(containers, &podSpec.InitContainers[i])
+		}
+	}
+	for _, c := range containers {
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{Name: scratchVolumeName, MountPath: scratchMountPath})
+		c.Env = append(c.Env, env...)
+	}
+}
@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestScratchSize(t *testing.T) {
+	size := resource.MustParse("100Gi")
+	tests := []struct {
+		name    string
+		model   string
+		storage *aiv1.StorageSpec
+		want    string
+	}{
+		// 0.5B × 2 字节 ≈ 0.93GiB，×1.1 ≈ 1.02 向上取整 2Gi，再加 2Gi 余量
+		{name: "small model", model: "Qwen/Qwen2.5-0.5B", want: "4Gi"},
+		// 7B ≈ 13.04GiB → 15Gi + 2Gi
+		{name: "7B", model: "Qwen/Qwen2.5-7B-Instruct", want: "17Gi"},
+		{name: "unknown size", model: "my-org/custom-model", want: defaultScratchSize},
+		{name: "override", model: "Qwen/Qwen2.5-7B-Instruct", storage: &aiv1.StorageSpec{Scratch: &aiv1.ScratchSpec{Size: &size}}, want: "100Gi"},
+	}
+	for _, tt := range tests {
+		llm := testLLMService()
+		llm.Spec.Model = tt.model
+		llm.Spec.Storage = tt.storage
+		if got := scratchSize(llm); got.Cmp(resource.MustParse(tt.want)) != 0 {
+			t.Errorf("%s: scratchSize = %s, want %s", tt.name, got.String(), tt.want)
+		}
+	}
+}
+
+func TestScratchVolume(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer}
+	llm.Spec.Env = []corev1.EnvVar{{Name: "TMPDIR", Value: "/data/tmp"}}
+
+	spec := r.desiredDeployment(llm).Spec.Template.Spec
+	i := slices.IndexFunc(spec.Volumes, func(v corev1.Volume) bool { return v.Name == scratchVolumeName })
+	if i < 0 || spec.Volumes[i].EmptyDir == nil || spec.Volumes[i].EmptyDir.SizeLimit == nil {
+		t.Fatalf("volumes = %+v, want a sized scratch emptyDir", spec.Volumes)
+	}
+
+	containers := append(slices.Clone(spec.InitContainers), spec.Containers[0])
+	for _, c := range containers {
+		if !slices.ContainsFunc(c.VolumeMounts, func(m corev1.VolumeMount) bool { return m.Name == scratchVolumeName && m.MountPath == scratchMountPath }) {
+			t.Errorf("%s does not mount the scratch volume", c.Name)
+		}
+		env := map[string][]string{}
+		for _, e := range c.Env {
+			env[e.Name] = append(env[e.Name], e.Value)
+		}
+		if got := env["HF_HOME"]; !slices.Equal(got, []string{"/scratch/huggingface"}) {
+			t.Errorf("%s: HF_HOME = %v, want the scratch volume", c.Name, got)
+		}
+		// 用户设置的 TMPDIR 以用户的为准，不能出现两次
+		if got := env["TMPDIR"]; !slices.Equal(got, []string{"/data/tmp"}) {
+			t.Errorf("%s: TMPDIR = %v, want only the user's value", c.Name, got)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+const (
+	sharedMemoryVolumeName = "dshm"
+	sharedMemoryMountPath  = "/dev/shm"
+
+	hugePagesVolumeName = "hugepages"
+	hugePagesMountPath  = "/dev/hugepages"
+)
+
+// hugePageSize 返回大页的页大小，未设置时是 2Mi
+func hugePageSize(h *aiv1.HugePagesSpec) string {
+	if h.PageSize == "" {
+		return aiv1.DefaultHugePageSize
+	}
+	return h.PageSize
+}
+
+// hugePagesResourceName 是大页对应的资源名，例如 hugepages-2Mi
+func hugePagesResourceName(h *aiv1.HugePagesSpec) corev1.ResourceName {
+	return corev1.ResourceName(corev1.ResourceHugePagesPrefix + hugePageSize(h))
+}
+
+// applySharedMemory 给推理容器挂载 /dev/shm 和大页
+//
+// 大页的 requests/limits 在 agentResources 里加，这里只负责 volume
+func applySharedMemory(llm *aiv1.LLMService, podSpec *corev1.PodSpec) {
+	main := &podSpec.Containers[0]
+	if size := llm.Spec.SharedMemorySize; size != nil {
+		limit := size.DeepCopy()
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: sharedMemoryVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory, SizeLimit: &limit},
+			},
+		})
+		main.VolumeMounts = append(main.VolumeMounts, corev1.VolumeMount{
+			Name:      sharedMemoryVolumeName,
+			MountPath: sharedMemoryMountPath,
+		})
+	}
+	if h := llm.Spec.HugePages; h != nil {
+		podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+			Name: hugePagesVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				// 指定页大小的 medium（HugePages-2Mi），节点同时配置了多种大页时也不会有歧义
+				EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMedium("HugePages-" + hugePageSize(h))},
+			},
+		})
+		main.VolumeMounts = append(main.VolumeMounts, corev1.VolumeMount{
+			Name:      hugePagesVolumeName,
+			MountPath: hugePagesMountPath,
+		})
+	}
+}
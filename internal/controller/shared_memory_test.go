@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestSharedMemory(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	shm := resource.MustParse("16Gi")
+	llm.Spec.SharedMemorySize = &shm
+	llm.Spec.HugePages = &aiv1.HugePagesSpec{Size: resource.MustParse("2Gi")}
+
+	spec := r.desiredDeployment(llm).Spec.Template.Spec
+	main := spec.Containers[0]
+
+	i := slices.IndexFunc(spec.Volumes, func(v corev1.Volume) bool { return v.Name == sharedMemoryVolumeName })
+	if i < 0 {
+		t.Fatalf("volumes = %+v, want %s", spec.Volumes, sharedMemoryVolumeName)
+	}
+	if dir := spec.Volumes[i].EmptyDir; dir == nil || dir.Medium != corev1.StorageMediumMemory || dir.SizeLimit.Cmp(shm) != 0 {
+		t.Errorf("shm volume = %+v, want a 16Gi memory emptyDir", spec.Volumes[i])
+	}
+	for name, path := range map[string]string{sharedMemoryVolumeName: "/dev/shm", hugePagesVolumeName: "/dev/hugepages"} {
+		if !slices.ContainsFunc(main.VolumeMounts, func(m corev1.VolumeMount) bool { return m.Name == name && m.MountPath == path }) {
+			t.Errorf("agent container does not mount %s at %s", name, path)
+		}
+	}
+
+	hugePages := corev1.ResourceName("hugepages-2Mi")
+	req, limit := main.Resources.Requests[hugePages], main.Resources.Limits[hugePages]
+	if req.Cmp(resource.MustParse("2Gi")) != 0 || limit.Cmp(req) != 0 {
+		t.Errorf("hugepages requests = %s, limits = %s, want 2Gi for both", req.String(), limit.String())
+	}
+	// 默认的 CPU/内存 requests 保留（大页要求 Pod 有 CPU 或内存 requests）
+	if _, ok := main.Resources.Requests[corev1.ResourceMemory]; !ok {
+		t.Error("memory request must be kept")
+	}
+}
+
+func TestSharedMemoryUnset(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	spec := r.desiredDeployment(testLLMService()).Spec.Template.Spec
+	if slices.ContainsFunc(spec.Volumes, func(v corev1.Volume) bool {
+		return v.Name == sharedMemoryVolumeName || v.Name == hugePagesVolumeName
+	}) {
+		t.Errorf("volumes = %+v, want no shm or hugepages volume by default", spec.Volumes)
+	}
+}
@@ -0,0 +1,50 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// ============================================================================
+// 共享模型卷（spec.storage.models）
+// ============================================================================
+//
+// 模型目录默认是每个 Pod 一个 emptyDir，follower 从 coordinator 的 model server 通过 HTTP 拿文件。
+// 模型目录放在所有副本共用的 RWX PVC 上时，这次传输就是多余的：
+//   - model-storage 卷换成这个 PVC（applyModelVolume）
+//   - agent 收到 MODEL_TRANSFER=sharedVolume：只有 coordinator 下载，
+//     follower 不传输，等 coordinator 在卷上写完完整标记、校验通过就启动推理服务
+//
+// 不能和 nodeCache（hostPath）、p2p/peerServing（follower 之间互相供货）、zone topology（每个 zone 一份副本）、
+// initContainer 模式（每个 Pod 自己下载）、关闭 model server（每个副本各自下载，同时写一个目录）一起用，webhook 拒绝。
+// 旧 revision 的清理只看得到本节点上的进程（distribution/gc.go），多节点共用时建议用 versioned 布局。
+// ============================================================================
+
+// sharedModelVolume 判断模型目录是不是所有副本共用的 PVC
+func sharedModelVolume(llm *aiv1.LLMService) bool {
+	s := llm.Spec.Storage
+	return s != nil && s.Models != nil && s.Models.ClaimName != ""
+}
+
+// applyModelVolume 把模型卷换成 spec.storage.models 指定的 PVC
+func applyModelVolume(llm *aiv1.LLMService, podSpec *corev1.PodSpec) {
+	if !sharedModelVolume(llm) {
+		return
+	}
+	for i := range podSpec.Volumes {
+		if podSpec.Volumes[i].Name == "model-storage" {
+			podSpec.Volumes[i].VolumeSource = corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: llm.Spec.Storage.Models.ClaimName},
+			}
+		}
+	}
+}
+
+// modelTransferEnv 在共享模型卷时告诉 agent 不用传输（internal/agent/distribution.SharedVolumeFromEnv）
+func modelTransferEnv(llm *aiv1.LLMService) []corev1.EnvVar {
+	if !sharedModelVolume(llm) {
+		return nil
+	}
+	return []corev1.EnvVar{{Name: "MODEL_TRANSFER", Value: "sharedVolume"}}
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestSharedModelVolume(t *testing.T) {
+	r := &LLMServiceReconciler{}
+
+	// 默认：emptyDir，不告诉 agent 跳过传输
+	llm := testLLMService()
+	spec := r.desiredDeployment(llm).Spec.Template.Spec
+	i := slices.IndexFunc(spec.Volumes, func(v corev1.Volume) bool { return v.Name == "model-storage" })
+	if i < 0 || spec.Volumes[i].EmptyDir == nil {
+		t.Fatalf("model volume = %+v, want an emptyDir by default", spec.Volumes)
+	}
+	if _, n := envValue(spec.Containers[0].Env, "MODEL_TRANSFER"); n != 0 {
+		t.Error("MODEL_TRANSFER must not be set without a shared model volume")
+	}
+
+	// 共享 PVC：模型卷换成 PVC，agent 不通过 HTTP 传输
+	llm.Spec.Storage = &aiv1.StorageSpec{Models: &aiv1.ModelStorageSpec{ClaimName: "models-rwx"}}
+	spec = r.desiredDeployment(llm).Spec.Template.Spec
+	i = slices.IndexFunc(spec.Volumes, func(v corev1.Volume) bool { return v.Name == "model-storage" })
+	if i < 0 || spec.Volumes[i].PersistentVolumeClaim == nil || spec.Volumes[i].PersistentVolumeClaim.ClaimName != "models-rwx" {
+		t.Fatalf("model volume = %+v, want the shared PVC", spec.Volumes)
+	}
+	if v, _ := envValue(spec.Containers[0].Env, "MODEL_TRANSFER"); v != "sharedVolume" {
+		t.Errorf("MODEL_TRANSFER = %q, want sharedVolume", v)
+	}
+}
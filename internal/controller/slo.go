@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/notify"
+	"github.com/Moore-Z/kubeinfer/internal/reporting"
+)
+
+// ============================================================================
+// SLO 监控
+// ============================================================================
+//
+// 每次 reconcile（设置了 spec.slo 时至少每分钟一次）：
+//  1. 从 Prometheus 读 gateway 在 Window 内的延迟 P99 和非 5xx 占比
+//  2. 任何一个目标没达到 → SLOViolated=True + Warning Event；恢复后 SLOViolated=False
+//  3. 设置了 MaxReplicas 时顺带扩缩容：违反期间每个窗口加一个副本，
+//     恢复满一个窗口后每个窗口减一个，扩容后的副本数记在 Status.SLO.Replicas
+//
+// 为什么每个窗口只调整一次？新副本要加载模型，指标要一个窗口才能反映扩容效果，
+// 调得太快会一路加到 MaxReplicas。
+// 窗口内没有请求（包括休眠）时没有数据，不算违反。
+// ============================================================================
+
+const (
+	// defaultSLOWindow 是 spec.slo.window 的默认值
+	defaultSLOWindow = 5 * time.Minute
+	// sloEvaluationInterval 是设置了 SLO 时的 requeue 间隔
+	sloEvaluationInterval = time.Minute
+)
+
+// sloObservation 是一个窗口内的观测值
+type sloObservation struct {
+	p99             time.Duration
+	hasLatency      bool
+	availability    float64
+	hasAvailability bool
+}
+
+// sloSourceCache 按 Prometheus 地址缓存 SLOSource，配置变化时重建
+type sloSourceCache struct {
+	mu     sync.Mutex
+	url    string
+	source reporting.SLOSource
+}
+
+// sloSource 返回 SLO 指标来源，没有配置 Prometheus 时返回 nil
+func (r *LLMServiceReconciler) sloSource() reporting.SLOSource {
+	url := r.config().SLO.PrometheusURL
+	if url == "" {
+		return nil
+	}
+	c := &r.sloSources
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.source == nil || c.url != url {
+		c.url = url
+		c.source = &reporting.PrometheusSLO{Client: reporting.NewPrometheusClient(url)}
+	}
+	return c.source
+}
+
+// sloWindow 返回评估窗口
+func sloWindow(llm *aiv1.LLMService) time.Duration {
+	if llm.Spec.SLO.Window != nil && llm.Spec.SLO.Window.Duration > 0 {
+		return llm.Spec.SLO.Window.Duration
+	}
+	return defaultSLOWindow
+}
+
+// checkSLO 评估 SLO，更新 Status.SLO 和 SLOViolated condition
+//
+// 返回值是下一次需要评估的时间（0 表示不需要定时评估）。
+// 查询失败不影响 reconcile：condition 改成 Unknown，扩容的副本数保持不变
+func (r *LLMServiceReconciler) checkSLO(ctx context.Context, llm *aiv1.LLMService, now time.Time) time.Duration {
+	if llm.Spec.SLO == nil {
+		llm.Status.SLO = nil
+		if findCondition(llm, aiv1.ConditionSLOViolated) != nil {
+			setCondition(llm, aiv1.ConditionSLOViolated, metav1.ConditionFalse,
+				"SLORemoved", "slo was removed from spec")
+		}
+		return 0
+	}
+
+	source := r.sloSource()
+	if source == nil {
+		setCondition(llm, aiv1.ConditionSLOViolated, metav1.ConditionUnknown,
+			"NoMetricsSource", "operator config has no slo.prometheusURL")
+		return 0
+	}
+	obs, err := querySLO(ctx, source, llm)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to query SLO metrics")
+		setCondition(llm, aiv1.ConditionSLOViolated, metav1.ConditionUnknown,
+			"MetricsUnavailable", err.Error())
+		return sloEvaluationInterval
+	}
+
+	wasViolated := isConditionTrue(llm, aiv1.ConditionSLOViolated)
+	before := effectiveReplicas(llm)
+	applySLO(llm, obs, now)
+
+	if isConditionTrue(llm, aiv1.ConditionSLOViolated) && !wasViolated {
+		r.sendNotification(ctx, llm, notify.EventSLOViolated, findCondition(llm, aiv1.ConditionSLOViolated).Message)
+	}
+
+	if r.Recorder != nil {
+		cond := findCondition(llm, aiv1.ConditionSLOViolated)
+		switch violated := isConditionTrue(llm, aiv1.ConditionSLOViolated); {
+		case violated && !wasViolated:
+			r.Recorder.Event(llm, corev1.EventTypeWarning, "SLOViolated", cond.Message)
+		case !violated && wasViolated:
+			r.Recorder.Event(llm, corev1.EventTypeNormal, "SLORecovered", cond.Message)
+		}
+		if after := effectiveReplicas(llm); after != before {
+			r.Recorder.Eventf(llm, corev1.EventTypeNormal, "SLOScaled",
+				"scaled from %d to %d replicas to meet the SLO", before, after)
+		}
+	}
+	return sloEvaluationInterval
+}
+
+// querySLO 读取目标对应的指标，没有设置的目标不查询
+func querySLO(ctx context.Context, source reporting.SLOSource, llm *aiv1.LLMService) (sloObservation, error) {
+	var obs sloObservation
+	var err error
+	window := sloWindow(llm)
+	if llm.Spec.SLO.P99LatencyMs != nil {
+		obs.p99, obs.hasLatency, err = source.P99Latency(ctx, llm.Namespace, llm.Name, window)
+		if err != nil {
+			return obs, err
+		}
+	}
+	if llm.Spec.SLO.Availability != "" {
+		obs.availability, obs.hasAvailability, err = source.Availability(ctx, llm.Namespace, llm.Name, window)
+	}
+	return obs, err
+}
+
+// sloViolations 返回没有达到的目标，为空表示达标
+func sloViolations(slo *aiv1.SLOSpec, obs sloObservation) []string {
+	var violations []string
+	if slo.P99LatencyMs != nil && obs.hasLatency && obs.p99.Milliseconds() > int64(*slo.P99LatencyMs) {
+		violations = append(violations, fmt.Sprintf("p99 latency %dms exceeds %dms", obs.p99.Milliseconds(), *slo.P99LatencyMs))
+	}
+	if target, err := strconv.ParseFloat(slo.Availability, 64); err == nil && obs.hasAvailability && obs.availability < target {
+		violations = append(violations, fmt.Sprintf("availability %.2f%% is below %s%%", obs.availability, slo.Availability))
+	}
+	return violations
+}
+
+// applySLO 根据观测值更新 Status.SLO、SLOViolated condition 和扩容的副本数
+func applySLO(llm *aiv1.LLMService, obs sloObservation, now time.Time) {
+	slo := llm.Spec.SLO
+	window := sloWindow(llm)
+	if llm.Status.SLO == nil {
+		llm.Status.SLO = &aiv1.SLOStatus{}
+	}
+	st := llm.Status.SLO
+	st.LastEvaluationTime = metav1.NewTime(now)
+	st.P99LatencyMs, st.Availability = 0, ""
+	if obs.hasLatency {
+		st.P99LatencyMs = obs.p99.Milliseconds()
+	}
+	if obs.hasAvailability {
+		st.Availability = strconv.FormatFloat(obs.availability, 'f', 2, 64)
+	}
+
+	violations := sloViolations(slo, obs)
+	violated := len(violations) > 0
+	if violated {
+		setCondition(llm, aiv1.ConditionSLOViolated, metav1.ConditionTrue,
+			"SLOViolated", fmt.Sprintf("over the last %s: %s", window, strings.Join(violations, "; ")))
+	} else {
+		setCondition(llm, aiv1.ConditionSLOViolated, metav1.ConditionFalse,
+			"SLOMet", fmt.Sprintf("all objectives met over the last %s", window))
+	}
+
+	if slo.MaxReplicas == nil {
+		st.Replicas, st.LastScaleTime = 0, nil
+		return
+	}
+	// 每个窗口最多调整一次
+	if st.LastScaleTime != nil && now.Sub(st.LastScaleTime.Time) < window {
+		return
+	}
+	current := max(st.Replicas, llm.Spec.Replicas)
+	switch {
+	case violated && current < *slo.MaxReplicas:
+		st.Replicas = current + 1
+	case !violated && st.Replicas > llm.Spec.Replicas &&
+		now.Sub(findCondition(llm, aiv1.ConditionSLOViolated).LastUpdateTime.Time) >= window:
+		// 恢复满一个窗口才缩容，避免刚好在阈值附近来回抖动
+		st.Replicas--
+	default:
+		return
+	}
+	st.LastScaleTime = &metav1.Time{Time: now}
+	if st.Replicas <= llm.Spec.Replicas {
+		st.Replicas = 0
+	}
+}
+
+// sloReplicas 返回因为 SLO 扩容后的副本数，没有扩容时返回 0
+func sloReplicas(llm *aiv1.LLMService) int32 {
+	if llm.Spec.SLO == nil || llm.Spec.SLO.MaxReplicas == nil || llm.Status.SLO == nil {
+		return 0
+	}
+	return min(llm.Status.SLO.Replicas, *llm.Spec.SLO.MaxReplicas)
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func sloLLMService(maxReplicas *int32) *aiv1.LLMService {
+	llm := testLLMService()
+	p99 := int32(2000)
+	llm.Spec.Replicas = 2
+	llm.Spec.SLO = &aiv1.SLOSpec{P99LatencyMs: &p99, Availability: "99.5", MaxReplicas: maxReplicas}
+	return llm
+}
+
+func TestSLOViolations(t *testing.T) {
+	slo := sloLLMService(nil).Spec.SLO
+	tests := []struct {
+		name string
+		obs  sloObservation
+		want int
+	}{
+		{"no traffic", sloObservation{}, 0},
+		{"met", sloObservation{p99: 1500 * time.Millisecond, hasLatency: true, availability: 99.9, hasAvailability: true}, 0},
+		{"slow", sloObservation{p99: 3 * time.Second, hasLatency: true, availability: 99.9, hasAvailability: true}, 1},
+		{"slow and failing", sloObservation{p99: 3 * time.Second, hasLatency: true, availability: 97, hasAvailability: true}, 2},
+	}
+	for _, tt := range tests {
+		if got := sloViolations(slo, tt.obs); len(got) != tt.want {
+			t.Errorf("%s: violations = %v, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApplySLOScaling(t *testing.T) {
+	maxReplicas := int32(4)
+	llm := sloLLMService(&maxReplicas)
+	slow := sloObservation{p99: 5 * time.Second, hasLatency: true}
+	fast := sloObservation{p99: time.Second, hasLatency: true}
+	// setCondition 用真实时间记录状态切换，测试时间从一小时前开始，窗口推进不会越过它
+	now := time.Now().Add(-time.Hour)
+
+	applySLO(llm, slow, now)
+	if !isConditionTrue(llm, aiv1.ConditionSLOViolated) {
+		t.Fatal("slow responses must violate the SLO")
+	}
+	if got := effectiveReplicas(llm); got != 3 {
+		t.Fatalf("replicas after first violation = %d, want 3", got)
+	}
+	if llm.Status.SLO.P99LatencyMs != 5000 {
+		t.Errorf("status p99 = %d, want 5000", llm.Status.SLO.P99LatencyMs)
+	}
+
+	// 同一个窗口内不再调整
+	applySLO(llm, slow, now.Add(time.Minute))
+	if got := effectiveReplicas(llm); got != 3 {
+		t.Errorf("replicas within the same window = %d, want 3", got)
+	}
+
+	// 下一个窗口继续加，但不超过 MaxReplicas
+	now = now.Add(defaultSLOWindow)
+	applySLO(llm, slow, now)
+	now = now.Add(defaultSLOWindow)
+	applySLO(llm, slow, now)
+	if got := effectiveReplicas(llm); got != 4 {
+		t.Errorf("replicas = %d, want capped at 4", got)
+	}
+
+	// 恢复后要满一个窗口才缩容
+	now = now.Add(defaultSLOWindow)
+	applySLO(llm, fast, now)
+	if isConditionTrue(llm, aiv1.ConditionSLOViolated) {
+		t.Fatal("fast responses must meet the SLO")
+	}
+	if got := effectiveReplicas(llm); got != 4 {
+		t.Errorf("replicas right after recovery = %d, want 4", got)
+	}
+	findCondition(llm, aiv1.ConditionSLOViolated).LastUpdateTime = metav1.NewTime(now.Add(-defaultSLOWindow))
+	for range 3 {
+		now = now.Add(defaultSLOWindow)
+		applySLO(llm, fast, now)
+	}
+	if got := effectiveReplicas(llm); got != 2 {
+		t.Errorf("replicas after recovery = %d, want spec.replicas 2", got)
+	}
+	if llm.Status.SLO.Replicas != 0 {
+		t.Errorf("status replicas = %d, want 0 once back at spec.replicas", llm.Status.SLO.Replicas)
+	}
+}
+
+func TestApplySLOAlertOnly(t *testing.T) {
+	llm := sloLLMService(nil)
+	applySLO(llm, sloObservation{availability: 90, hasAvailability: true}, time.Now())
+	if !isConditionTrue(llm, aiv1.ConditionSLOViolated) {
+		t.Fatal("low availability must violate the SLO")
+	}
+	if got := effectiveReplicas(llm); got != 2 {
+		t.Errorf("alert-only SLO must not scale, got %d replicas", got)
+	}
+	if llm.Status.SLO.Availability != "90.00" {
+		t.Errorf("status availability = %q, want 90.00", llm.Status.SLO.Availability)
+	}
+}
+
+// fakeSLOSource 返回固定的观测值
+type fakeSLOSource struct {
+	p99 time.Duration
+	err error
+}
+
+func (f fakeSLOSource) P99Latency(context.Context, string, string, time.Duration) (time.Duration, bool, error) {
+	return f.p99, f.err == nil, f.err
+}
+
+func (f fakeSLOSource) Availability(context.Context, string, string, time.Duration) (float64, bool, error) {
+	return 100, f.err == nil, f.err
+}
+
+func TestCheckSLO(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := sloLLMService(nil)
+
+	// 没有配置 Prometheus
+	if recheck := r.checkSLO(context.Background(), llm, time.Now()); recheck != 0 {
+		t.Errorf("recheck without a metrics source = %v, want 0", recheck)
+	}
+	if cond := findCondition(llm, aiv1.ConditionSLOViolated); cond == nil || cond.Reason != "NoMetricsSource" {
+		t.Errorf("condition = %+v, want NoMetricsSource", cond)
+	}
+
+	obs, err := querySLO(context.Background(), fakeSLOSource{err: errors.New("prometheus down")}, llm)
+	if err == nil {
+		t.Fatalf("querySLO() = %+v, want error", obs)
+	}
+
+	obs, err = querySLO(context.Background(), fakeSLOSource{p99: 3 * time.Second}, llm)
+	if err != nil || !obs.hasLatency || !obs.hasAvailability {
+		t.Fatalf("querySLO() = %+v, %v", obs, err)
+	}
+
+	// 删除 spec.slo 后清理状态
+	applySLO(llm, obs, time.Now())
+	llm.Spec.SLO = nil
+	r.checkSLO(context.Background(), llm, time.Now())
+	if llm.Status.SLO != nil || isConditionTrue(llm, aiv1.ConditionSLOViolated) {
+		t.Errorf("status after removing slo = %+v, conditions %+v", llm.Status.SLO, llm.Status.Conditions)
+	}
+}
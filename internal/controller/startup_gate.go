@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/startup"
+)
+
+// startupGateContainerName 是等待 coordinator 就绪的 initContainer
+const startupGateContainerName = "wait-coordinator"
+
+// startupGateContainer 返回 agent 的启动闸门 initContainer
+//
+// 副本同时启动时 follower 会连还没就绪的 coordinator，报错、退避、刷屏。
+// initContainer 用同一个镜像运行 `agent wait-coordinator`：
+// 抢到 lease 的 Pod 直接启动，其他 Pod 等 coordinator 的 model server 就绪后错开启动（见 internal/agent/startup）。
+//
+// 不管 replicas 是多少都加上：按副本数决定的话，扩容 1 → 2 会改 Pod 模板，已有的 Pod 被滚动重建。
+// 只有一个副本时闸门第一次就抢到 lease，几乎没有开销。
+// 不运行 model server 时（model_server.go）没有 follower，不加。
+//
+// 环境变量和 agent 容器相同（POD_NAME、CONFIGMAP_NAME、lease 参数都要用到）；
+// 只请求很少的资源，不占 GPU（initContainer 的 request 和主容器取最大值，不会叠加）。
+func startupGateContainer(agent *corev1.Container) corev1.Container {
+	return corev1.Container{
+		Name:            startupGateContainerName,
+		Image:           agent.Image,
+		ImagePullPolicy: agent.ImagePullPolicy,
+		Args:            []string{startup.WaitCommand},
+		Env:             slices.Clone(agent.Env),
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("10m"),
+				corev1.ResourceMemory: resource.MustParse("32Mi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("128Mi"),
+			},
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/agent/startup"
+)
+
+func TestStartupGateContainer(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	llm.Spec.GpuPerReplica = 1
+
+	deployment := r.desiredDeployment(llm)
+	spec := deployment.Spec.Template.Spec
+	if len(spec.InitContainers) != 1 {
+		t.Fatalf("got %d init containers, want the startup gate", len(spec.InitContainers))
+	}
+	gate, agent := spec.InitContainers[0], spec.Containers[0]
+	if gate.Image != agent.Image {
+		t.Errorf("gate image = %q, want the agent image %q", gate.Image, agent.Image)
+	}
+	if !slices.Equal(gate.Args, []string{startup.WaitCommand}) {
+		t.Errorf("gate args = %v", gate.Args)
+	}
+	for _, name := range []string{"POD_NAME", "POD_NAMESPACE", "CONFIGMAP_NAME"} {
+		if !slices.ContainsFunc(gate.Env, func(e corev1.EnvVar) bool { return e.Name == name }) {
+			t.Errorf("gate is missing env %s", name)
+		}
+	}
+	if _, ok := gate.Resources.Limits[aiv1.GPUResourceName]; ok {
+		t.Error("the gate must not request GPUs")
+	}
+
+	// 扩缩容不能改 Pod 模板，否则已有的 Pod 会被重建（1 个副本不运行 model server，见 model_server_test.go）
+	llm.Spec.Replicas = 3
+	if got := r.desiredDeployment(llm).Annotations[templateHashAnnotation]; got != deployment.Annotations[templateHashAnnotation] {
+		t.Error("changing replicas must not change the pod template")
+	}
+}
@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/modelcatalog"
+)
+
+// tensorParallelSize 返回推理服务的 tensor parallel 大小
+//
+// 以前不管 GpuPerReplica 是多少都按 1 启动，多出来的卡一直空着。现在：
+//   - 设置了 spec.vllm.tensorParallelSize 就用它（webhook 保证合法）
+//   - 否则按 GpuPerReplica 推算（modelcatalog.TensorParallelSize，要能整除模型的注意力头数）
+func tensorParallelSize(llm *aiv1.LLMService) int32 {
+	if devMode(llm) {
+		return 1
+	}
+	if v := llm.Spec.VLLM; v != nil && v.TensorParallelSize != nil {
+		return *v.TensorParallelSize
+	}
+	return int32(modelcatalog.TensorParallelSize(llm.Spec.Model, int(llm.Spec.GpuPerReplica)))
+}
+
+// tensorParallelEnv 把 tensor parallel 大小传给 agent（vLLM 的 --tensor-parallel-size、TGI 的 --num-shard）
+//
+// 1 是 agent 的默认值，不写环境变量，单卡的 LLMService 升级后 Pod 模板不变、不会重启
+func tensorParallelEnv(llm *aiv1.LLMService) []corev1.EnvVar {
+	tp := tensorParallelSize(llm)
+	if tp == 1 {
+		return nil
+	}
+	return []corev1.EnvVar{{Name: "VLLM_TENSOR_PARALLEL_SIZE", Value: strconv.Itoa(int(tp))}}
+}
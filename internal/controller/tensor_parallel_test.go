@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestTensorParallelSize(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	llm.Spec.Model = "Qwen/Qwen2.5-7B-Instruct"
+
+	// 单卡不写环境变量，升级后 Pod 模板不变
+	main := r.desiredDeployment(llm).Spec.Template.Spec.Containers[0]
+	if _, n := envValue(main.Env, "VLLM_TENSOR_PARALLEL_SIZE"); n != 0 {
+		t.Errorf("single GPU must not set VLLM_TENSOR_PARALLEL_SIZE, env = %v", main.Env)
+	}
+
+	// 8 卡，28 个头 → 4
+	llm.Spec.GpuPerReplica = 8
+	main = r.desiredDeployment(llm).Spec.Template.Spec.Containers[0]
+	if v, _ := envValue(main.Env, "VLLM_TENSOR_PARALLEL_SIZE"); v != "4" {
+		t.Errorf("VLLM_TENSOR_PARALLEL_SIZE = %q, want 4", v)
+	}
+	if got := modelStatus(llm).TensorParallelSize; got != 4 {
+		t.Errorf("status tensorParallelSize = %d, want 4", got)
+	}
+
+	// 显式设置优先；initContainer 模式直接写到 vLLM 参数里
+	tp := int32(2)
+	llm.Spec.VLLM = &aiv1.VLLMSpec{TensorParallelSize: &tp}
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer}
+	args := r.desiredDeployment(llm).Spec.Template.Spec.Containers[0].Args
+	if i := slices.Index(args, "--tensor-parallel-size"); i < 0 || args[i+1] != "2" {
+		t.Errorf("vLLM args = %v, want --tensor-parallel-size 2", args)
+	}
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestDistributionEnvTopology(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	tests := []struct {
+		name         string
+		distribution *aiv1.DistributionSpec
+		wantTopology string
+	}{
+		{name: "default is flat"},
+		{name: "flat", distribution: &aiv1.DistributionSpec{Topology: aiv1.TopologyFlat}},
+		{name: "zone", distribution: &aiv1.DistributionSpec{Topology: aiv1.TopologyZone}, wantTopology: aiv1.TopologyZone},
+		// initContainer 模式只支持 flat
+		{name: "zone in initContainer mode",
+			distribution: &aiv1.DistributionSpec{Topology: aiv1.TopologyZone, Mode: aiv1.DistributionModeInitContainer}},
+	}
+	for _, tt := range tests {
+		llm := testLLMService()
+		llm.Spec.Distribution = tt.distribution
+		env := r.distributionEnv(llm)
+
+		value, count := envValue(env, "DISTRIBUTION_TOPOLOGY")
+		if tt.wantTopology == "" && count != 0 {
+			t.Errorf("%s: DISTRIBUTION_TOPOLOGY = %q, want it unset", tt.name, value)
+		}
+		if tt.wantTopology != "" && (count != 1 || value != tt.wantTopology) {
+			t.Errorf("%s: DISTRIBUTION_TOPOLOGY = %q (%d times), want %q", tt.name, value, count, tt.wantTopology)
+		}
+
+		// NODE_NAME 在所有拓扑下都要有：zone 拓扑读节点的 zone label，选举读 seed-preferred label
+		i := slices.IndexFunc(env, func(e corev1.EnvVar) bool { return e.Name == "NODE_NAME" })
+		if i < 0 || env[i].ValueFrom == nil || env[i].ValueFrom.FieldRef == nil || env[i].ValueFrom.FieldRef.FieldPath != "spec.nodeName" {
+			t.Errorf("%s: NODE_NAME must come from spec.nodeName, got %v", tt.name, env)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/audit"
+	"github.com/Moore-Z/kubeinfer/internal/schedule"
+)
+
+// templateHashAnnotation 记录 Deployment 当前 Pod 模板的 hash
+//
+// 为什么不直接比较 Pod 模板？
+// - API server 会给模板补很多默认值（terminationMessagePath、dnsPolicy...）
+// - 直接比较永远不相等；比较我们自己生成的模板的 hash 才准确
+const templateHashAnnotation = "kubeinfer.io/template-hash"
+
+// defaultUpdateWindowDuration 没有设置 UpdateWindow.Duration 时的窗口长度
+const defaultUpdateWindowDuration = time.Hour
+
+// templateHash 计算 Pod 模板的 hash
+func templateHash(tpl *corev1.PodTemplateSpec) string {
+	data, _ := json.Marshal(tpl)
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+// parseUpdateWindow 解析 Spec.UpdateWindow，没有设置时返回 nil
+func parseUpdateWindow(w *aiv1.UpdateWindow) (*schedule.Window, *time.Location, error) {
+	if w == nil {
+		return nil, nil, nil
+	}
+
+	loc := time.UTC
+	if w.TimeZone != "" {
+		var err error
+		if loc, err = time.LoadLocation(w.TimeZone); err != nil {
+			return nil, nil, fmt.Errorf("invalid timeZone %q: %w", w.TimeZone, err)
+		}
+	}
+
+	duration := defaultUpdateWindowDuration
+	if w.Duration != nil && w.Duration.Duration > 0 {
+		duration = w.Duration.Duration
+	}
+
+	window, err := schedule.NewWindow(w.Schedule, duration)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid schedule %q: %w", w.Schedule, err)
+	}
+	return window, loc, nil
+}
+
+// updateAllowed 判断现在能否应用需要重启的变更
+//
+// 不能应用时返回下一个窗口的开始时间（零值表示一年内都没有窗口）
+func updateAllowed(llm *aiv1.LLMService, now time.Time) (bool, time.Time, error) {
+	window, loc, err := parseUpdateWindow(llm.Spec.UpdateWindow)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	if window == nil {
+		return true, time.Time{}, nil
+	}
+
+	local := now.In(loc)
+	if window.Contains(local) {
+		return true, time.Time{}, nil
+	}
+	return false, window.NextStart(local), nil
+}
+
+// syncDeployment 把期望的 Deployment 同步到集群
+//
+// - 副本数：立即同步（扩缩容不会重启已有的 Pod）
+// - Pod 模板：会触发滚动更新、重启推理服务，受 UpdateWindow 限制
+//
+// 每次都用 server-side apply 写入完整的期望状态；窗口外沿用上次 apply 的模板。
+// 返回值是下一次需要检查的时间（等窗口开始），0 表示不需要
+func (r *LLMServiceReconciler) syncDeployment(ctx context.Context, llm *aiv1.LLMService, found, desired *appsv1.Deployment, now time.Time) (time.Duration, error) {
+	l := log.FromContext(ctx)
+	var recheck time.Duration
+
+	ac, err := deploymentApplyConfiguration(llm, desired)
+	if err != nil {
+		return 0, err
+	}
+
+	// 副本数同步（休眠/唤醒会改变期望副本数）
+	scaled := false
+	if desired.Spec.Replicas != nil && (found.Spec.Replicas == nil || *found.Spec.Replicas != *desired.Spec.Replicas) {
+		l.Info("Scaling Deployment", "from", found.Spec.Replicas, "to", *desired.Spec.Replicas)
+		scaled = found.Spec.Replicas != nil
+	}
+
+	desiredHash := desired.Annotations[templateHashAnnotation]
+	keepTemplate, templateUpdated := false, false
+	trigger := triggerSpec
+	if found.Annotations[templateHashAnnotation] != desiredHash {
+		allowed, next, err := updateAllowed(llm, now)
+		// 用户要求的重启不等窗口（同时还没应用的 spec 变更会一起生效）
+		if restartRequested(found, desired) {
+			allowed, err, trigger = true, nil, triggerRestart
+		}
+		if err != nil {
+			// 窗口配置错误（webhook 没拦住）：不应用，等用户修正
+			setCondition(llm, aiv1.ConditionPendingUpdate, metav1.ConditionTrue, "InvalidUpdateWindow", err.Error())
+			keepTemplate = true
+		} else if allowed {
+			l.Info("Updating Deployment pod template", "hash", desiredHash)
+			templateUpdated = true
+			if findCondition(llm, aiv1.ConditionPendingUpdate) != nil {
+				setCondition(llm, aiv1.ConditionPendingUpdate, metav1.ConditionFalse, "Applied", "pod template updated")
+			}
+		} else {
+			msg := "pod template change is waiting for an update window"
+			if !next.IsZero() {
+				msg = fmt.Sprintf("pod template change will be applied in the next update window at %s", next.Format(time.RFC3339))
+				recheck = next.Sub(now)
+			}
+			setCondition(llm, aiv1.ConditionPendingUpdate, metav1.ConditionTrue, "OutsideUpdateWindow", msg)
+			keepTemplate = true
+		}
+	} else {
+		if isConditionTrue(llm, aiv1.ConditionPendingUpdate) {
+			// 变更被撤回了，不再需要等窗口
+			setCondition(llm, aiv1.ConditionPendingUpdate, metav1.ConditionFalse, "UpToDate", "pod template is up to date")
+		}
+
+		// hash 一致但字段不一致：有人手动改了 Deployment，下面的 apply 会把它恢复
+		// （恢复的是上次已经应用过的模板，不受 UpdateWindow 限制）
+		if drifted := detectDrift(found, desired); len(drifted) > 0 {
+			r.reportDrift(ctx, llm, desired, drifted)
+		}
+	}
+
+	if keepTemplate {
+		if err := keepAppliedTemplate(ac, found); err != nil {
+			return 0, err
+		}
+	}
+	if err := r.apply(ctx, ac); err != nil {
+		return 0, err
+	}
+
+	// 写入成功后才记审计：失败的 apply 下一次 reconcile 会重来
+	if scaled {
+		from, to := *found.Spec.Replicas, *desired.Spec.Replicas
+		r.recordAudit(ctx, llm, audit.ActionScaled, scaleTrigger(llm, from),
+			fmt.Sprintf("scaled Deployment %s from %d to %d replicas", desired.Name, from, to))
+	}
+	if templateUpdated {
+		r.recordAudit(ctx, llm, audit.ActionSpecApplied, trigger,
+			fmt.Sprintf("rolled out pod template %s to Deployment %s", desiredHash, desired.Name))
+	}
+	return recheck, nil
+}
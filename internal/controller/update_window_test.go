@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestUpdateAllowed(t *testing.T) {
+	// 每天 UTC+8 02:00-04:00
+	window := &aiv1.UpdateWindow{
+		Schedule: "0 2 * * *",
+		Duration: &metav1.Duration{Duration: 2 * time.Hour},
+		TimeZone: "Asia/Shanghai",
+	}
+
+	tests := []struct {
+		name        string
+		window      *aiv1.UpdateWindow
+		now         time.Time
+		wantAllowed bool
+		wantNext    time.Time
+	}{
+		{
+			name:        "no window",
+			now:         time.Date(2026, 1, 7, 12, 0, 0, 0, time.UTC),
+			wantAllowed: true,
+		},
+		{
+			name:        "inside window",
+			window:      window,
+			now:         time.Date(2026, 1, 6, 19, 0, 0, 0, time.UTC), // 03:00 +08
+			wantAllowed: true,
+		},
+		{
+			name:        "outside window",
+			window:      window,
+			now:         time.Date(2026, 1, 7, 12, 0, 0, 0, time.UTC), // 20:00 +08
+			wantAllowed: false,
+			wantNext:    time.Date(2026, 1, 7, 18, 0, 0, 0, time.UTC), // 次日 02:00 +08
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{UpdateWindow: tt.window}}
+			allowed, next, err := updateAllowed(llm, tt.now)
+			if err != nil {
+				t.Fatalf("updateAllowed: %v", err)
+			}
+			if allowed != tt.wantAllowed {
+				t.Errorf("allowed = %v, want %v", allowed, tt.wantAllowed)
+			}
+			if !next.Equal(tt.wantNext) {
+				t.Errorf("next = %v, want %v", next, tt.wantNext)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package controller
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// addUserEnv 把 Spec.Env/EnvFrom 加到容器上
+//
+// 和已有变量同名的跳过：controller 生成的变量必须生效，而且 server-side apply
+// 把 env 当成以 name 为 key 的 map，重复的 name 会直接 apply 失败
+func addUserEnv(llm *aiv1.LLMService, c *corev1.Container) {
+	seen := make(map[string]bool, len(c.Env))
+	for _, e := range c.Env {
+		seen[e.Name] = true
+	}
+	for _, e := range llm.Spec.Env {
+		if seen[e.Name] {
+			continue
+		}
+		seen[e.Name] = true
+		c.Env = append(c.Env, *e.DeepCopy())
+	}
+	for _, from := range llm.Spec.EnvFrom {
+		c.EnvFrom = append(c.EnvFrom, *from.DeepCopy())
+	}
+}
+
+// applyUserEnv 给推理容器注入用户的环境变量；initContainer 模式下拉取模型的两个 agent 容器也需要（代理、HF_TOKEN）
+func applyUserEnv(llm *aiv1.LLMService, podSpec *corev1.PodSpec) {
+	addUserEnv(llm, &podSpec.Containers[0])
+	if !initContainerMode(llm) {
+		// agent 模式的 initContainer 只是 startup gate，不需要
+		return
+	}
+	for i := range podSpec.InitContainers {
+		addUserEnv(llm, &podSpec.InitContainers[i])
+	}
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func envValue(env []corev1.EnvVar, name string) (string, int) {
+	value, count := "", 0
+	for _, e := range env {
+		if e.Name == name {
+			value = e.Value
+			count++
+		}
+	}
+	return value, count
+}
+
+func TestUserEnv(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+	llm.Spec.Env = []corev1.EnvVar{
+		{Name: "HTTPS_PROXY", Value: "http://proxy:3128"},
+		// 和 controller 生成的同名，controller 的生效
+		{Name: "INFERENCE_RUNTIME", Value: "tgi"},
+	}
+	llm.Spec.EnvFrom = []corev1.EnvFromSource{{
+		SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "hf-token"}},
+	}}
+
+	spec := r.desiredDeployment(llm).Spec.Template.Spec
+	main := spec.Containers[0]
+	if v, n := envValue(main.Env, "HTTPS_PROXY"); v != "http://proxy:3128" || n != 1 {
+		t.Errorf("HTTPS_PROXY = %q (%d times), want the user value once", v, n)
+	}
+	if v, n := envValue(main.Env, "INFERENCE_RUNTIME"); v != aiv1.RuntimeVLLM || n != 1 {
+		t.Errorf("INFERENCE_RUNTIME = %q (%d times), want the controller value once", v, n)
+	}
+	if len(main.EnvFrom) != 1 || main.EnvFrom[0].SecretRef.Name != "hf-token" {
+		t.Errorf("envFrom = %+v, want the hf-token Secret", main.EnvFrom)
+	}
+	// startup gate 不需要用户的变量
+	if _, n := envValue(spec.InitContainers[0].Env, "HTTPS_PROXY"); n != 0 {
+		t.Error("startup gate must not get user env")
+	}
+
+	// initContainer 模式：vLLM 容器和拉取模型的 agent 容器都有
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer}
+	spec = r.desiredDeployment(llm).Spec.Template.Spec
+	for _, c := range slices.Concat(spec.Containers, spec.InitContainers) {
+		if _, n := envValue(c.Env, "HTTPS_PROXY"); n != 1 || len(c.EnvFrom) != 1 {
+			t.Errorf("%s env = %v, envFrom = %v, want user env", c.Name, c.Env, c.EnvFrom)
+		}
+	}
+}
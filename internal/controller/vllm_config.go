@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/agent/runtime"
+)
+
+// ============================================================================
+// vLLM 启动参数（spec.vllm.gpuMemoryUtilization、maxModelLen、dtype）
+// ============================================================================
+//
+// agent 用 runtime.LoadConfigFromEnv 从 VLLM_* 环境变量拼 vLLM 的参数。以前 controller 只设置 MODEL_*，
+// 显存比例、上下文长度、数据类型只能做进镜像（Dockerfile 的 ENV）或者手写在 spec.env 里，
+// 同一个镜像跑不同的模型就得打不同的镜像。现在这些参数都是 spec.vllm 上的字段：
+//   - agent 模式渲染成 VLLM_* 环境变量（容器的 env 覆盖镜像的 ENV），没设置的字段不写，用 agent 的默认值
+//   - initContainer 模式主容器直接运行 vLLM，写进 runtime.Config 再生成参数
+//
+// Pod 模板上的 kubeinfer.io/vllm-config-hash 是最终生效的 VLLM_* 变量和 vLLM 参数的 hash：
+// 配置一变模板就变，按 UpdateWindow 滚动重启；kubectl 看 annotation 就能知道两个副本的配置是不是同一版。
+// spec.envFrom 引用的 ConfigMap 里的 VLLM_* 不在这个 hash 里，内容变化由 kubeinfer.io/config-checksum
+// 触发滚动重启（config_checksum.go）
+// ============================================================================
+
+// vllmConfigHashAnnotation 是 Pod 模板上 vLLM 配置的 hash
+const vllmConfigHashAnnotation = "kubeinfer.io/vllm-config-hash"
+
+// vllmConfigEnv 把 spec.vllm 的启动参数传给 agent（agent 模式）
+//
+// 和 spec.env 里同名的变量一起出现时 webhook 拒绝，这里生成的优先（addUserEnv 跳过同名的）
+func vllmConfigEnv(llm *aiv1.LLMService) []corev1.EnvVar {
+	v := llm.Spec.VLLM
+	if v == nil {
+		return nil
+	}
+	var env []corev1.EnvVar
+	if v.GPUMemoryUtilization != "" {
+		env = append(env, corev1.EnvVar{Name: "VLLM_GPU_MEMORY_UTILIZATION", Value: v.GPUMemoryUtilization})
+	}
+	if v.MaxModelLen != nil {
+		env = append(env, corev1.EnvVar{Name: "VLLM_MAX_MODEL_LEN", Value: strconv.Itoa(int(*v.MaxModelLen))})
+	}
+	if v.Dtype != "" {
+		env = append(env, corev1.EnvVar{Name: "VLLM_DTYPE", Value: v.Dtype})
+	}
+	return env
+}
+
+// applyVLLMConfig 把 spec.vllm 的启动参数写进 initContainer 模式的 runtime.Config
+func applyVLLMConfig(llm *aiv1.LLMService, cfg *runtime.Config) {
+	v := llm.Spec.VLLM
+	if v == nil {
+		return
+	}
+	if u, err := strconv.ParseFloat(v.GPUMemoryUtilization, 64); err == nil {
+		cfg.GPUMemoryUtilization = u
+	}
+	if v.MaxModelLen != nil {
+		cfg.MaxModelLen = int(*v.MaxModelLen)
+	}
+	if v.Dtype != "" {
+		cfg.Dtype = v.Dtype
+	}
+}
+
+// applyVLLMConfigHash 计算主容器最终生效的 vLLM 配置的 hash，写到 Pod 模板的 annotation 上
+//
+// 要在 applyUserEnv 之后调用：spec.env 里手写的 VLLM_* 也算。agent 模式看 VLLM_* 变量
+// （valueFrom 按引用算），initContainer 模式的变量被清空了，看 vLLM 的参数。
+// 全部用默认值时不写，没有配置 vLLM 参数的 LLMService 升级后 Pod 模板不变、不会重启
+func applyVLLMConfigHash(tpl *corev1.PodTemplateSpec) {
+	main := tpl.Spec.Containers[0]
+	env := slices.DeleteFunc(slices.Clone(main.Env), func(e corev1.EnvVar) bool { return !strings.HasPrefix(e.Name, "VLLM_") })
+	if len(env) == 0 && len(main.Args) == 0 {
+		return
+	}
+	slices.SortFunc(env, func(a, b corev1.EnvVar) int { return strings.Compare(a.Name, b.Name) })
+	data, _ := json.Marshal(struct {
+		Env  []corev1.EnvVar `json:"env,omitempty"`
+		Args []string        `json:"args,omitempty"`
+	}{env, main.Args})
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+	if tpl.Annotations == nil {
+		tpl.Annotations = map[string]string{}
+	}
+	tpl.Annotations[vllmConfigHashAnnotation] = fmt.Sprintf("%x", h.Sum64())
+}
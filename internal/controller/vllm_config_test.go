@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestVLLMConfig(t *testing.T) {
+	r := &LLMServiceReconciler{}
+	llm := testLLMService()
+
+	// 没有配置 vLLM 参数：不写 annotation，升级后 Pod 模板不变
+	tpl := r.desiredDeployment(llm).Spec.Template
+	if h, ok := tpl.Annotations[vllmConfigHashAnnotation]; ok {
+		t.Errorf("default config must not set %s, got %s", vllmConfigHashAnnotation, h)
+	}
+
+	maxLen := int32(8192)
+	llm.Spec.VLLM = &aiv1.VLLMSpec{GPUMemoryUtilization: "0.85", MaxModelLen: &maxLen, Dtype: "bfloat16"}
+	tpl = r.desiredDeployment(llm).Spec.Template
+	for name, want := range map[string]string{
+		"VLLM_GPU_MEMORY_UTILIZATION": "0.85",
+		"VLLM_MAX_MODEL_LEN":          "8192",
+		"VLLM_DTYPE":                  "bfloat16",
+	} {
+		if v, _ := envValue(tpl.Spec.Containers[0].Env, name); v != want {
+			t.Errorf("%s = %q, want %q", name, v, want)
+		}
+	}
+	hash := tpl.Annotations[vllmConfigHashAnnotation]
+	if hash == "" {
+		t.Fatalf("pod template annotations = %v, want %s", tpl.Annotations, vllmConfigHashAnnotation)
+	}
+
+	// spec.env 里手写的 VLLM_* 也算进 hash，改了就滚动重启
+	llm.Spec.Env = []corev1.EnvVar{{Name: "VLLM_EXTRA_ARGS", Value: "--enforce-eager"}}
+	if again := r.desiredDeployment(llm).Spec.Template.Annotations[vllmConfigHashAnnotation]; again == hash {
+		t.Errorf("hash unchanged after adding VLLM_EXTRA_ARGS to spec.env")
+	}
+
+	// initContainer 模式直接写到 vLLM 参数里
+	llm.Spec.Distribution = &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer}
+	tpl = r.desiredDeployment(llm).Spec.Template
+	args := tpl.Spec.Containers[0].Args
+	for flag, want := range map[string]string{"--gpu-memory-utilization": "0.85", "--max-model-len": "8192", "--dtype": "bfloat16"} {
+		if i := slices.Index(args, flag); i < 0 || args[i+1] != want {
+			t.Errorf("vLLM args = %v, want %s %s", args, flag, want)
+		}
+	}
+	if tpl.Annotations[vllmConfigHashAnnotation] == "" {
+		t.Errorf("initContainer mode pod template has no %s", vllmConfigHashAnnotation)
+	}
+}
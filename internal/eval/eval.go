@@ -0,0 +1,329 @@
+// Package eval 是 EvalRun 的评测程序
+//
+// controller 为每个 EvalRun 创建一个 Job，运行 manager 镜像里的 /eval：
+//
+//  1. 读评测集（ConfigMap 挂载的 JSONL 文件，每行 {"prompt": "...", "expected": "..."}）
+//  2. 从 /v1/models 读出模型名，Concurrency 个 worker 用 /v1/chat/completions 逐题回答（temperature 0）
+//  3. 有参考答案的题：回答里包含参考答案（忽略大小写和首尾空白）算答对；
+//     设置了评审时每道题再让评审判断输赢，没有参考答案的题由评审单独判断回答好不好
+//  4. 分数 JSON 写到 /dev/termination-log，controller 读出来放进 status，再和 spec.thresholds 比较
+//
+// 和 benchmark 一样所有后端都提供 OpenAI 兼容接口，不区分 runtime。
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/benchmark"
+)
+
+// 环境变量，由 controller 在 Job 里设置
+const (
+	TargetEnv      = "EVAL_TARGET"
+	PromptsFileEnv = "EVAL_PROMPTS_FILE"
+	ConcurrencyEnv = "EVAL_CONCURRENCY"
+	MaxTokensEnv   = "EVAL_MAX_TOKENS"
+	JudgeURLEnv    = "EVAL_JUDGE_URL"
+	JudgeModelEnv  = "EVAL_JUDGE_MODEL"
+	// JudgeAPIKeyEnv 从 spec.judge.apiKeySecretRef 通过 secretKeyRef 注入，不经过 controller
+	JudgeAPIKeyEnv = "EVAL_JUDGE_API_KEY"
+)
+
+// Item 是评测集里的一道题
+type Item struct {
+	Prompt string `json:"prompt"`
+	// Expected 是参考答案，为空时只用来让评审打分（没有评审时不计分）
+	Expected string `json:"expected,omitempty"`
+}
+
+// Judge 是外部评审模型
+type Judge struct {
+	URL    string
+	Model  string
+	APIKey string
+}
+
+// Config 是一次评测
+type Config struct {
+	// Target 是推理服务（或 gateway）的地址，例如 http://qwen-gateway.default.svc:8000
+	Target      string
+	Items       []Item
+	Concurrency int
+	MaxTokens   int
+	// Judge 为 nil 时只计算 accuracy
+	Judge *Judge
+
+	// HTTPClient 为 nil 时使用不带超时的默认 client（由 ctx 控制）
+	HTTPClient *http.Client
+}
+
+// ConfigFromEnv 从环境变量读取 Config，评测集从 PromptsFileEnv 指向的文件读取
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Target: strings.TrimRight(os.Getenv(TargetEnv), "/")}
+	if cfg.Target == "" {
+		return cfg, fmt.Errorf("%s is required", TargetEnv)
+	}
+	path := os.Getenv(PromptsFileEnv)
+	if path == "" {
+		return cfg, fmt.Errorf("%s is required", PromptsFileEnv)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to open the prompt set: %w", err)
+	}
+	defer f.Close()
+	if cfg.Items, err = ParseItems(f); err != nil {
+		return cfg, err
+	}
+	if cfg.Concurrency, err = intEnv(ConcurrencyEnv, 4); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxTokens, err = intEnv(MaxTokensEnv, 256); err != nil {
+		return cfg, err
+	}
+	if url := os.Getenv(JudgeURLEnv); url != "" {
+		cfg.Judge = &Judge{
+			URL:    strings.TrimRight(url, "/"),
+			Model:  os.Getenv(JudgeModelEnv),
+			APIKey: os.Getenv(JudgeAPIKeyEnv),
+		}
+	}
+	return cfg, nil
+}
+
+func intEnv(name string, def int) (int, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s=%q", name, v)
+	}
+	return n, nil
+}
+
+// ParseItems 解析 JSONL 评测集，跳过空行；格式不对时报出行号，评测直接失败而不是少算几道题
+func ParseItems(r io.Reader) ([]Item, error) {
+	var items []Item
+	scanner := bufio.NewScanner(r)
+	// 一道题可能带很长的上下文
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var item Item
+		if err := json.Unmarshal([]byte(text), &item); err != nil {
+			return nil, fmt.Errorf("prompt set line %d: %w", line, err)
+		}
+		if item.Prompt == "" {
+			return nil, fmt.Errorf("prompt set line %d: prompt is empty", line)
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the prompt set: %w", err)
+	}
+	if len(items) == 0 {
+		return nil, fmt.Errorf("prompt set is empty")
+	}
+	return items, nil
+}
+
+// outcome 是一道题的结果
+//
+// err 是候选服务出错，整道题不打分；judgeErr 是评审出错，accuracy 的结果照样保留
+type outcome struct {
+	err      error
+	graded   bool
+	correct  bool
+	judged   bool
+	win      bool
+	judgeErr error
+}
+
+// Run 按 cfg 评测，返回分数
+//
+// 找不到模型（服务没起来）直接返回错误；单道题失败只计入 Errors
+func Run(ctx context.Context, cfg Config) (aiv1.EvalScores, error) {
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
+	model, err := benchmark.ServedModel(ctx, client, cfg.Target)
+	if err != nil {
+		return aiv1.EvalScores{}, err
+	}
+
+	items := make(chan Item)
+	outcomes := make(chan outcome, len(cfg.Items))
+	var wg sync.WaitGroup
+	for range max(cfg.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range items {
+				outcomes <- evaluate(ctx, client, cfg, model, item)
+			}
+		}()
+	}
+	for _, item := range cfg.Items {
+		items <- item
+	}
+	close(items)
+	wg.Wait()
+	close(outcomes)
+	if err := ctx.Err(); err != nil {
+		return aiv1.EvalScores{}, err
+	}
+
+	var all []outcome
+	for o := range outcomes {
+		all = append(all, o)
+	}
+	return summarize(all, cfg.Judge != nil), nil
+}
+
+// evaluate 回答一道题并打分
+func evaluate(ctx context.Context, client *http.Client, cfg Config, model string, item Item) outcome {
+	answer, err := chat(ctx, client, cfg.Target, "", model, item.Prompt, cfg.MaxTokens)
+	if err != nil {
+		return outcome{err: err}
+	}
+	var o outcome
+	if item.Expected != "" {
+		o.graded, o.correct = true, matches(answer, item.Expected)
+	}
+	if cfg.Judge == nil {
+		return o
+	}
+	verdict, err := chat(ctx, client, cfg.Judge.URL, cfg.Judge.APIKey, cfg.Judge.Model, judgePrompt(item, answer), 8)
+	if err != nil {
+		o.judgeErr = fmt.Errorf("judge: %w", err)
+		return o
+	}
+	switch v := strings.ToUpper(strings.TrimSpace(verdict)); {
+	case strings.HasPrefix(v, "WIN"):
+		o.judged, o.win = true, true
+	case strings.HasPrefix(v, "LOSS"):
+		o.judged = true
+	default:
+		o.judgeErr = fmt.Errorf("judge returned %q, want WIN or LOSS", verdict)
+	}
+	return o
+}
+
+// matches 判断回答里是否包含参考答案，忽略大小写和首尾空白
+func matches(answer, expected string) bool {
+	return strings.Contains(strings.ToLower(answer), strings.ToLower(strings.TrimSpace(expected)))
+}
+
+// judgePrompt 让评审比较候选回答和参考答案，只回答 WIN 或 LOSS；没有参考答案时只看候选回答本身
+func judgePrompt(item Item, answer string) string {
+	if item.Expected == "" {
+		return "You are grading an answer to a question.\n" +
+			"Reply with exactly one word: WIN if the candidate answer is correct and helpful, LOSS otherwise.\n\n" +
+			"Question:\n" + item.Prompt + "\n\n" +
+			"Candidate answer:\n" + answer
+	}
+	return "You are grading an answer against a reference answer.\n" +
+		"Reply with exactly one word: WIN if the candidate answer is at least as correct and helpful as the reference, LOSS otherwise.\n\n" +
+		"Question:\n" + item.Prompt + "\n\n" +
+		"Reference answer:\n" + item.Expected + "\n\n" +
+		"Candidate answer:\n" + answer
+}
+
+// chat 发一个 /v1/chat/completions 请求，返回第一个回答；apiKey 不为空时带 Bearer token
+func chat(ctx context.Context, client *http.Client, target, apiKey, model, prompt string, maxTokens int) (string, error) {
+	body, err := json.Marshal(map[string]any{
+		"model":       model,
+		"messages":    []map[string]string{{"role": "user", "content": prompt}},
+		"max_tokens":  maxTokens,
+		"temperature": 0,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target+"/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("chat completion returned status %d", resp.StatusCode)
+	}
+	var out struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("failed to decode chat completion: %w", err)
+	}
+	if len(out.Choices) == 0 {
+		return "", fmt.Errorf("chat completion has no choices")
+	}
+	return out.Choices[0].Message.Content, nil
+}
+
+// summarize 统计分数，judge 为 false 时不输出 WinRate
+func summarize(outcomes []outcome, judge bool) aiv1.EvalScores {
+	scores := aiv1.EvalScores{Prompts: int64(len(outcomes))}
+	var correct, wins int64
+	for _, o := range outcomes {
+		switch {
+		case o.err != nil:
+			scores.Errors++
+			continue
+		case o.graded:
+			scores.Graded++
+			if o.correct {
+				correct++
+			}
+		}
+		switch {
+		case o.judgeErr != nil:
+			scores.JudgeErrors++
+		case o.judged:
+			scores.Judged++
+			if o.win {
+				wins++
+			}
+		}
+	}
+	if scores.Graded > 0 {
+		scores.Accuracy = ratio(correct, scores.Graded)
+	}
+	if judge && scores.Judged > 0 {
+		scores.WinRate = ratio(wins, scores.Judged)
+	}
+	return scores
+}
+
+func ratio(n, d int64) string {
+	return strconv.FormatFloat(float64(n)/float64(d), 'f', 4, 64)
+}
@@ -0,0 +1,131 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// chatRequest 是 /v1/chat/completions 请求里测试关心的部分
+type chatRequest struct {
+	Model    string `json:"model"`
+	Messages []struct {
+		Content string `json:"content"`
+	} `json:"messages"`
+}
+
+func reply(w http.ResponseWriter, content string) {
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"choices": []map[string]any{{"message": map[string]string{"role": "assistant", "content": content}}},
+	})
+}
+
+func TestRun(t *testing.T) {
+	answers := map[string]string{
+		"capital of France?": "The capital of France is Paris.",
+		"12 * 12?":           "It is 124.",
+		"say hi":             "hi",
+	}
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			_, _ = w.Write([]byte(`{"data":[{"id":"qwen"}]}`))
+		case "/v1/chat/completions":
+			var req chatRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "qwen" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			answer, ok := answers[req.Messages[0].Content]
+			if !ok {
+				http.Error(w, "overloaded", http.StatusServiceUnavailable)
+				return
+			}
+			reply(w, answer)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer target.Close()
+	// 评审：回答里有 Paris 的算赢，124 那道题不给结论
+	var noReference atomic.Int32
+	judge := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "judge" ||
+			r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		content := req.Messages[0].Content
+		if !strings.Contains(content, "Reference answer:") {
+			noReference.Add(1)
+		}
+		switch {
+		case strings.Contains(content, "Candidate answer:\nThe capital of France is Paris."):
+			reply(w, "WIN")
+		case strings.Contains(content, "Candidate answer:\nIt is 124."):
+			reply(w, "maybe")
+		default:
+			reply(w, "loss")
+		}
+	}))
+	defer judge.Close()
+
+	items := []Item{
+		{Prompt: "capital of France?", Expected: " paris "},
+		{Prompt: "12 * 12?", Expected: "144"},
+		{Prompt: "say hi"},
+		{Prompt: "unanswerable", Expected: "x"},
+	}
+	scores, err := Run(context.Background(), Config{Target: target.URL, Items: items, Concurrency: 2, MaxTokens: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 没有参考答案的题不打分，失败的题只计入 Errors
+	if scores.Prompts != 4 || scores.Errors != 1 || scores.Graded != 2 || scores.Accuracy != "0.5000" || scores.WinRate != "" {
+		t.Errorf("scores without judge = %+v", scores)
+	}
+
+	scores, err = Run(context.Background(), Config{
+		Target: target.URL, Items: items, Concurrency: 2, MaxTokens: 32,
+		Judge: &Judge{URL: judge.URL, Model: "judge", APIKey: "secret"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 没有参考答案的题交给评审单独判断；评审出错只计入 JudgeErrors，accuracy 不变
+	if scores.Errors != 1 || scores.JudgeErrors != 1 || scores.Graded != 2 || scores.Accuracy != "0.5000" ||
+		scores.Judged != 2 || scores.WinRate != "0.5000" {
+		t.Errorf("scores with judge = %+v", scores)
+	}
+	if n := noReference.Load(); n != 1 {
+		t.Errorf("judge got %d prompts without a reference answer, want 1", n)
+	}
+}
+
+func TestParseItems(t *testing.T) {
+	items, err := ParseItems(strings.NewReader(`{"prompt": "2+2?", "expected": "4"}
+
+{"prompt": "write a haiku"}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Expected != "4" || items[1].Prompt != "write a haiku" {
+		t.Errorf("ParseItems() = %+v", items)
+	}
+
+	for name, input := range map[string]string{
+		"empty":        "\n\n",
+		"not json":     `{"prompt": "a"}` + "\nprompt: b\n",
+		"empty prompt": `{"expected": "4"}`,
+	} {
+		if _, err := ParseItems(strings.NewReader(input)); err == nil {
+			t.Errorf("%s: ParseItems() must fail", name)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Package fleet 把 hub 集群上的 LLMService 复制到成员集群
+//
+// 地理分布式推理：同一个模型部署在多个地区的集群里，用户就近访问。
+// hub 集群的 LLMService 设置 spec.fleet 后，controller 在每次 reconcile 时：
+// 1. 找到已注册的成员集群（kubeconfig Secret）
+// 2. 在成员集群里创建/更新同名的 LLMService（去掉 fleet，避免成员集群再往外复制）
+// 3. 设置了 modelEndpoint 时，让成员集群的 coordinator 从 hub 复制模型
+// 4. 把每个成员集群的副本状态汇总到 hub 的 status.fleet
+//
+// 成员集群需要安装同版本的 CRD 和 controller，由它们自己的 controller 去创建 Deployment。
+package fleet
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+const (
+	// MemberClusterLabel 标记成员集群的 kubeconfig Secret
+	MemberClusterLabel = "kubeinfer.io/member-cluster"
+
+	// KubeconfigKey 是 Secret 里 kubeconfig 的 key
+	KubeconfigKey = "kubeconfig"
+
+	// Finalizer 保证 hub 上的 LLMService 删除时先删掉成员集群里的副本
+	Finalizer = "kubeinfer.io/fleet"
+)
+
+// ClientFactory 根据 kubeconfig 创建成员集群的 client
+type ClientFactory func(kubeconfig []byte) (client.Client, error)
+
+// Manager 管理成员集群的 client 和 LLMService 副本
+type Manager struct {
+	// Reader 读取 hub 集群里成员集群的 Secret
+	// 用 API reader 而不是缓存 client：不需要为了几个 Secret 缓存整个集群的 Secret
+	Reader client.Reader
+
+	// Namespace 是成员集群 Secret 所在的命名空间（通常是 manager 的命名空间）
+	Namespace string
+
+	// NewClient 创建成员集群 client，测试时可以替换
+	NewClient ClientFactory
+
+	mu      sync.Mutex
+	clients map[string]cachedClient
+}
+
+// cachedClient 按 Secret 的 resourceVersion 缓存 client，kubeconfig 轮换后自动重建
+type cachedClient struct {
+	resourceVersion string
+	client          client.Client
+}
+
+// Member 是一个已注册的成员集群
+type Member struct {
+	Name   string
+	Client client.Client
+}
+
+// NewManager 创建 Manager，成员集群 client 使用和 hub 相同的 scheme
+func NewManager(reader client.Reader, scheme *runtime.Scheme, namespace string) *Manager {
+	return &Manager{
+		Reader:    reader,
+		Namespace: namespace,
+		NewClient: func(kubeconfig []byte) (client.Client, error) {
+			cfg, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+			if err != nil {
+				return nil, err
+			}
+			return client.New(cfg, client.Options{Scheme: scheme})
+		},
+	}
+}
+
+// Members 返回 LLMService 需要复制到的成员集群（按名称排序）
+func (m *Manager) Members(ctx context.Context, llm *aiv1.LLMService) ([]Member, error) {
+	secrets := &corev1.SecretList{}
+	if err := m.Reader.List(ctx, secrets,
+		client.InNamespace(m.Namespace),
+		client.HasLabels{MemberClusterLabel}); err != nil {
+		return nil, fmt.Errorf("failed to list member cluster secrets: %w", err)
+	}
+
+	var wanted []string
+	if llm.Spec.Fleet != nil {
+		wanted = llm.Spec.Fleet.Clusters
+	}
+
+	var members []Member
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if len(wanted) > 0 && !slices.Contains(wanted, secret.Name) {
+			continue
+		}
+		c, err := m.clientFor(secret)
+		if err != nil {
+			return nil, fmt.Errorf("member cluster %s: %w", secret.Name, err)
+		}
+		members = append(members, Member{Name: secret.Name, Client: c})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+	return members, nil
+}
+
+// clientFor 返回 Secret 对应的成员集群 client（有缓存）
+func (m *Manager) clientFor(secret *corev1.Secret) (client.Client, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cached, ok := m.clients[secret.Name]; ok && cached.resourceVersion == secret.ResourceVersion {
+		return cached.client, nil
+	}
+
+	kubeconfig, ok := secret.Data[KubeconfigKey]
+	if !ok {
+		return nil, fmt.Errorf("secret has no %q key", KubeconfigKey)
+	}
+	c, err := m.NewClient(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	if m.clients == nil {
+		m.clients = map[string]cachedClient{}
+	}
+	m.clients[secret.Name] = cachedClient{resourceVersion: secret.ResourceVersion, client: c}
+	return c, nil
+}
+
+// DesiredMirror 生成成员集群里的 LLMService
+//
+// - 去掉 fleet：成员集群不再往外复制
+// - 设置了 modelEndpoint 时，coordinator 从 hub 的 model server 复制模型
+func DesiredMirror(llm *aiv1.LLMService) *aiv1.LLMService {
+	spec := llm.Spec.DeepCopy()
+	spec.Fleet = nil
+	if llm.Spec.Fleet != nil && llm.Spec.Fleet.ModelEndpoint != "" {
+		if spec.Distribution == nil {
+			spec.Distribution = &aiv1.DistributionSpec{}
+		}
+		spec.Distribution.UpstreamURL = llm.Spec.Fleet.ModelEndpoint
+	}
+
+	return &aiv1.LLMService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      llm.Name,
+			Namespace: llm.Namespace,
+			Labels:    map[string]string{aiv1.FleetSourceLabel: llm.Name},
+		},
+		Spec: *spec,
+	}
+}
+
+// Sync 把 LLMService 复制到所有成员集群，结果写到 llm.Status.Fleet
+//
+// 单个成员集群失败不影响其他集群，错误记录在对应的 FleetMemberStatus 里；
+// 只有列不出成员集群时才返回错误
+func (m *Manager) Sync(ctx context.Context, llm *aiv1.LLMService) error {
+	members, err := m.Members(ctx, llm)
+	if err != nil {
+		return err
+	}
+
+	desired := DesiredMirror(llm)
+	statuses := make([]aiv1.FleetMemberStatus, 0, len(members))
+	for _, member := range members {
+		statuses = append(statuses, syncMember(ctx, member, desired))
+	}
+
+	// 从 spec.fleet.clusters 里去掉的集群：删除副本
+	for _, old := range llm.Status.Fleet {
+		if slices.ContainsFunc(members, func(mb Member) bool { return mb.Name == old.Cluster }) {
+			continue
+		}
+		if err := m.deleteFrom(ctx, old.Cluster, llm); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to remove LLMService from member cluster", "cluster", old.Cluster)
+			statuses = append(statuses, aiv1.FleetMemberStatus{Cluster: old.Cluster, Message: "removal pending: " + err.Error()})
+		}
+	}
+
+	llm.Status.Fleet = statuses
+	return nil
+}
+
+// syncMember 在一个成员集群里创建或更新副本，并读取它的状态
+func syncMember(ctx context.Context, member Member, desired *aiv1.LLMService) aiv1.FleetMemberStatus {
+	status := aiv1.FleetMemberStatus{Cluster: member.Name}
+
+	found := &aiv1.LLMService{}
+	err := member.Client.Get(ctx, client.ObjectKeyFromObject(desired), found)
+	switch {
+	case errors.IsNotFound(err):
+		if err := member.Client.Create(ctx, desired.DeepCopy()); err != nil {
+			status.Message = fmt.Sprintf("failed to create: %v", err)
+			return status
+		}
+		status.Synced = true
+		return status
+	case err != nil:
+		status.Message = fmt.Sprintf("failed to get: %v", err)
+		return status
+	}
+
+	// 同名但不是 fleet 创建的对象：不覆盖用户自己的配置
+	if found.Labels[aiv1.FleetSourceLabel] != desired.Name {
+		status.Message = "an LLMService with the same name exists and is not managed by fleet"
+		return status
+	}
+
+	if !equalSpec(found, desired) {
+		found.Spec = desired.Spec
+		if err := member.Client.Update(ctx, found); err != nil {
+			status.Message = fmt.Sprintf("failed to update: %v", err)
+			return status
+		}
+	}
+
+	status.Synced = true
+	status.AvailableReplicas = found.Status.AvailableReplicas
+	return status
+}
+
+// equalSpec 比较 spec（用 DeepDerivative 忽略成员集群 API server 补上的默认值）
+func equalSpec(found, desired *aiv1.LLMService) bool {
+	return equality.Semantic.DeepDerivative(desired.Spec, found.Spec)
+}
+
+// Cleanup 删除所有成员集群里的副本（hub 上的 LLMService 删除或关闭 fleet 时调用）
+func (m *Manager) Cleanup(ctx context.Context, llm *aiv1.LLMService) error {
+	clusters := map[string]bool{}
+	for _, s := range llm.Status.Fleet {
+		clusters[s.Cluster] = true
+	}
+	members, err := m.Members(ctx, &aiv1.LLMService{})
+	if err != nil {
+		return err
+	}
+	for _, mb := range members {
+		clusters[mb.Name] = true
+	}
+
+	for name := range clusters {
+		if err := m.deleteFrom(ctx, name, llm); err != nil {
+			return fmt.Errorf("member cluster %s: %w", name, err)
+		}
+	}
+	llm.Status.Fleet = nil
+	return nil
+}
+
+// deleteFrom 删除成员集群里由 fleet 创建的副本；集群已经注销时忽略
+func (m *Manager) deleteFrom(ctx context.Context, cluster string, llm *aiv1.LLMService) error {
+	secret := &corev1.Secret{}
+	err := m.Reader.Get(ctx, types.NamespacedName{Name: cluster, Namespace: m.Namespace}, secret)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c, err := m.clientFor(secret)
+	if err != nil {
+		return err
+	}
+
+	found := &aiv1.LLMService{}
+	err = c.Get(ctx, client.ObjectKeyFromObject(llm), found)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if found.Labels[aiv1.FleetSourceLabel] != llm.Name {
+		return nil
+	}
+	return client.IgnoreNotFound(c.Delete(ctx, found))
+}
@@ -0,0 +1,132 @@
+package fleet
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func newScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := aiv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+func memberSecret(name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "kubeinfer-system",
+			Labels:    map[string]string{MemberClusterLabel: "true"},
+		},
+		Data: map[string][]byte{KubeconfigKey: []byte(name)},
+	}
+}
+
+func TestDesiredMirror(t *testing.T) {
+	llm := &aiv1.LLMService{
+		ObjectMeta: metav1.ObjectMeta{Name: "qwen", Namespace: "default"},
+		Spec: aiv1.LLMServiceSpec{
+			Model: "Qwen/Qwen2.5-7B",
+			Fleet: &aiv1.FleetSpec{ModelEndpoint: "http://models.hub:8080"},
+		},
+	}
+
+	mirror := DesiredMirror(llm)
+	if mirror.Spec.Fleet != nil {
+		t.Error("mirror must not carry spec.fleet")
+	}
+	if mirror.Spec.Distribution == nil || mirror.Spec.Distribution.UpstreamURL != "http://models.hub:8080" {
+		t.Errorf("unexpected distribution %+v", mirror.Spec.Distribution)
+	}
+	if mirror.Labels[aiv1.FleetSourceLabel] != "qwen" {
+		t.Errorf("missing fleet source label: %v", mirror.Labels)
+	}
+	if llm.Spec.Distribution != nil {
+		t.Error("hub spec must not be modified")
+	}
+}
+
+func TestSyncAndCleanup(t *testing.T) {
+	ctx := context.Background()
+	scheme := newScheme(t)
+
+	hub := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(memberSecret("us-east"), memberSecret("eu-west")).Build()
+	members := map[string]client.Client{
+		"us-east": fake.NewClientBuilder().WithScheme(scheme).Build(),
+		// eu-west 已经有一个用户自己创建的同名 LLMService
+		"eu-west": fake.NewClientBuilder().WithScheme(scheme).WithObjects(&aiv1.LLMService{
+			ObjectMeta: metav1.ObjectMeta{Name: "qwen", Namespace: "default"},
+		}).Build(),
+	}
+
+	m := NewManager(hub, scheme, "kubeinfer-system")
+	m.NewClient = func(kubeconfig []byte) (client.Client, error) {
+		return members[string(kubeconfig)], nil
+	}
+
+	llm := &aiv1.LLMService{
+		ObjectMeta: metav1.ObjectMeta{Name: "qwen", Namespace: "default"},
+		Spec:       aiv1.LLMServiceSpec{Model: "Qwen/Qwen2.5-7B", Replicas: 2, Fleet: &aiv1.FleetSpec{}},
+	}
+	if err := m.Sync(ctx, llm); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(llm.Status.Fleet) != 2 {
+		t.Fatalf("expected 2 member statuses, got %+v", llm.Status.Fleet)
+	}
+	// 按名称排序：eu-west 在前
+	if eu := llm.Status.Fleet[0]; eu.Cluster != "eu-west" || eu.Synced {
+		t.Errorf("eu-west should not be synced: %+v", eu)
+	}
+	if us := llm.Status.Fleet[1]; us.Cluster != "us-east" || !us.Synced {
+		t.Errorf("us-east should be synced: %+v", us)
+	}
+
+	mirror := &aiv1.LLMService{}
+	if err := members["us-east"].Get(ctx, client.ObjectKeyFromObject(llm), mirror); err != nil {
+		t.Fatal(err)
+	}
+	if mirror.Spec.Replicas != 2 {
+		t.Errorf("mirror replicas = %d, want 2", mirror.Spec.Replicas)
+	}
+
+	// spec 变化会同步到成员集群
+	llm.Spec.Replicas = 3
+	if err := m.Sync(ctx, llm); err != nil {
+		t.Fatal(err)
+	}
+	if err := members["us-east"].Get(ctx, client.ObjectKeyFromObject(llm), mirror); err != nil {
+		t.Fatal(err)
+	}
+	if mirror.Spec.Replicas != 3 {
+		t.Errorf("mirror replicas = %d, want 3", mirror.Spec.Replicas)
+	}
+
+	// 清理只删除 fleet 创建的副本
+	if err := m.Cleanup(ctx, llm); err != nil {
+		t.Fatal(err)
+	}
+	if err := members["us-east"].Get(ctx, client.ObjectKeyFromObject(llm), mirror); err == nil {
+		t.Error("us-east mirror should be deleted")
+	}
+	if err := members["eu-west"].Get(ctx, client.ObjectKeyFromObject(llm), mirror); err != nil {
+		t.Errorf("user-owned LLMService in eu-west should be kept: %v", err)
+	}
+}
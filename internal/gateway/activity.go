@@ -0,0 +1,93 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// wakeInterval 是唤醒期间写 annotation 的最小间隔
+const wakeInterval = 10 * time.Second
+
+// ActivityReporter 把"最近一次请求时间"写到 LLMService 的 annotation 上
+//
+// 为什么不是 controller 来拉取 gateway 的统计？
+// - gateway 可能有多个副本，controller 要挨个问
+// - 写 annotation 会自动触发 reconcile，唤醒延迟最低
+//
+// 为了不给 API server 造成压力，正常情况下每 interval 最多写一次；
+// 需要唤醒后端时缩短到 wakeInterval，尽快让 controller 感知。
+type ActivityReporter struct {
+	client    client.Client
+	namespace string
+	name      string
+	interval  time.Duration
+
+	mu           sync.Mutex
+	lastReported time.Time
+	reporting    bool // 是否有一个 patch 正在进行
+}
+
+// NewActivityReporter 创建 ActivityReporter
+func NewActivityReporter(c client.Client, namespace, name string, interval time.Duration) *ActivityReporter {
+	return &ActivityReporter{
+		client:    c,
+		namespace: namespace,
+		name:      name,
+		interval:  interval,
+	}
+}
+
+// Touch 记录一次请求
+//
+// wake=true 时使用更短的间隔（用于唤醒休眠中的后端）。
+// patch 在后台 goroutine 里进行，不阻塞请求。
+func (a *ActivityReporter) Touch(wake bool) {
+	minInterval := a.interval
+	if wake {
+		minInterval = wakeInterval
+	}
+
+	a.mu.Lock()
+	now := time.Now()
+	if a.reporting || now.Sub(a.lastReported) < minInterval {
+		a.mu.Unlock()
+		return
+	}
+	a.reporting = true
+	a.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		err := a.report(ctx, now)
+
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.reporting = false
+		if err != nil {
+			log.Printf("⚠️  Failed to report activity: %v", err)
+			return
+		}
+		a.lastReported = now
+	}()
+}
+
+// report 用 merge patch 只更新一个 annotation，不会和 controller 的更新冲突
+func (a *ActivityReporter) report(ctx context.Context, now time.Time) error {
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`,
+		aiv1.LastRequestTimeAnnotation, now.UTC().Format(time.RFC3339))
+
+	llm := &aiv1.LLMService{}
+	llm.Name = a.name
+	llm.Namespace = a.namespace
+	return a.client.Patch(ctx, llm, client.RawPatch(types.MergePatchType, []byte(patch)))
+}
@@ -0,0 +1,252 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+// ============================================================================
+// 跨集群故障转移
+// ============================================================================
+//
+// 本集群的副本全部不可用时（节点故障、整个集群维护、休眠唤醒中），
+// 把请求溢出到其他集群的 gateway，而不是让用户等待或者直接 503。
+//
+//	client ──▶ gateway ──▶ 本地 vLLM（0 个 ready）
+//	              │
+//	              └──溢出──▶ 其他集群的 gateway（按延迟惩罚加权选择）
+//
+// 其他集群的地址来自 Secret（spec.gateway.failoverSecret），格式：
+//
+//	[{"name":"eu-west","url":"https://eu.example.com","latencyPenalty":"80ms"}]
+//
+// latencyPenalty 是跨集群多出来的延迟，惩罚越大被选中的概率越低。
+// ============================================================================
+
+// FailoverConfigKey 是 failover Secret 里 upstream 列表的 key
+const FailoverConfigKey = "upstreams.json"
+
+// DefaultFailoverConfigPath 是 controller 挂载 failover Secret 的位置
+const DefaultFailoverConfigPath = "/etc/kubeinfer/failover/" + FailoverConfigKey
+
+// readinessTTL 本地 ready 副本数的缓存时间，避免每个请求都读 API server
+const readinessTTL = 5 * time.Second
+
+// Secondary 是另一个集群的 gateway
+type Secondary struct {
+	// Name 用于指标和日志，例如 "eu-west"
+	Name string `json:"name"`
+	// URL 是对方 gateway 的地址，例如 "https://eu.example.com"
+	URL string `json:"url"`
+	// LatencyPenalty 是相对本地多出来的延迟
+	LatencyPenalty Duration `json:"latencyPenalty,omitempty"`
+}
+
+// Duration 支持 "80ms" 这种 JSON 写法
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON 解析 "80ms" 或者纳秒数
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int64
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("invalid duration %s", data)
+		}
+		d.Duration = time.Duration(n)
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// LoadSecondaries 从挂载的 Secret 文件读取其他集群的 gateway
+//
+// 文件不存在时返回空列表（没有配置 failover）
+func LoadSecondaries(path string) ([]Secondary, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var secondaries []Secondary
+	if err := json.Unmarshal(data, &secondaries); err != nil {
+		return nil, fmt.Errorf("invalid failover config %s: %w", path, err)
+	}
+	for _, s := range secondaries {
+		if s.Name == "" {
+			return nil, fmt.Errorf("failover upstream %q has no name", s.URL)
+		}
+		if _, err := url.Parse(s.URL); err != nil || s.URL == "" {
+			return nil, fmt.Errorf("failover upstream %s has invalid url %q", s.Name, s.URL)
+		}
+	}
+	return secondaries, nil
+}
+
+// weight 是被选中的相对概率：惩罚 0 的权重是 20，惩罚 1s 的约为 1
+func (s Secondary) weight() float64 {
+	return 1 / (s.LatencyPenalty.Seconds() + 0.05)
+}
+
+// orderSecondaries 按权重做不放回的随机抽样，返回尝试顺序
+//
+// 惩罚小的集群大概率排在前面，但不会永远只打一个集群（避免把它压垮）
+func orderSecondaries(secondaries []Secondary, random func() float64) []Secondary {
+	remaining := append([]Secondary(nil), secondaries...)
+	ordered := make([]Secondary, 0, len(remaining))
+
+	for len(remaining) > 0 {
+		total := 0.0
+		for _, s := range remaining {
+			total += s.weight()
+		}
+		pick := random() * total
+		idx := len(remaining) - 1
+		for i, s := range remaining {
+			pick -= s.weight()
+			if pick < 0 {
+				idx = i
+				break
+			}
+		}
+		ordered = append(ordered, remaining[idx])
+		remaining = append(remaining[:idx], remaining[idx+1:]...)
+	}
+	return ordered
+}
+
+// ReadinessSource 提供本地 ready 副本数
+type ReadinessSource interface {
+	ReadyReplicas(ctx context.Context) (int32, error)
+}
+
+// StatusReadiness 从 LLMService 的 status.availableReplicas 读取本地 ready 副本数（带缓存）
+type StatusReadiness struct {
+	client    client.Client
+	namespace string
+	name      string
+
+	mu      sync.Mutex
+	value   int32
+	fetched time.Time
+}
+
+// NewStatusReadiness 创建 StatusReadiness
+func NewStatusReadiness(c client.Client, namespace, name string) *StatusReadiness {
+	return &StatusReadiness{client: c, namespace: namespace, name: name}
+}
+
+// ReadyReplicas 实现 ReadinessSource
+func (s *StatusReadiness) ReadyReplicas(ctx context.Context) (int32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.fetched) < readinessTTL {
+		return s.value, nil
+	}
+
+	llm := &aiv1.LLMService{}
+	if err := s.client.Get(ctx, types.NamespacedName{Namespace: s.namespace, Name: s.name}, llm); err != nil {
+		return 0, err
+	}
+	s.value = llm.Status.AvailableReplicas
+	s.fetched = time.Now()
+	return s.value, nil
+}
+
+// localDown 判断本地是否已经没有 ready 副本
+//
+// 没有 ReadinessSource 或者读取失败时，只要这次请求失败了就认为本地不可用（按休眠处理）
+func (g *Gateway) localDown(ctx context.Context) bool {
+	if g.config.Readiness == nil {
+		return true
+	}
+	ready, err := g.config.Readiness.ReadyReplicas(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to read local ready replicas: %v", err)
+		return true
+	}
+	return ready == 0
+}
+
+// spill 把请求依次发给其他集群，返回第一个可用的响应
+//
+// ok=false 表示所有集群都不可用，调用方继续等待本地唤醒
+func (g *Gateway) spill(req *http.Request, base http.RoundTripper) (resp *http.Response, ok bool) {
+	random := g.random
+	if random == nil {
+		random = rand.Float64
+	}
+
+	for _, s := range orderSecondaries(g.config.Secondaries, random) {
+		outReq, err := rewriteTo(req, s.URL)
+		if err != nil {
+			log.Printf("⚠️  Invalid failover upstream %s: %v", s.Name, err)
+			continue
+		}
+
+		resp, err := base.RoundTrip(outReq)
+		if err != nil {
+			metrics.RecordGatewaySpill(g.config.Namespace, g.config.Name, s.Name, "error")
+			log.Printf("⚠️  Failover upstream %s failed: %v", s.Name, err)
+			continue
+		}
+		metrics.RecordGatewaySpill(g.config.Namespace, g.config.Name, s.Name, strconv.Itoa(resp.StatusCode))
+		if resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusServiceUnavailable {
+			_ = resp.Body.Close()
+			continue
+		}
+
+		log.Printf("🔀 Spilled request to %s", s.Name)
+		return resp, true
+	}
+	return nil, false
+}
+
+// rewriteTo 把请求改写到另一个集群的 gateway（保留路径和查询参数）
+func rewriteTo(req *http.Request, base string) (*http.Request, error) {
+	target, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+
+	out := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		out.Body = body
+	}
+	out.URL.Scheme = target.Scheme
+	out.URL.Host = target.Host
+	out.URL.Path = strings.TrimSuffix(target.Path, "/") + req.URL.Path
+	out.Host = target.Host
+	return out, nil
+}
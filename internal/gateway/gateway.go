@@ -0,0 +1,299 @@
+// Package gateway 实现每个 LLMService 前面的 OpenAI 兼容网关
+//
+// 网关本身是一个反向代理：
+//
+//	client ──▶ gateway ──▶ <llmservice>-vllm Service ──▶ vLLM Pods
+//
+// 它额外负责：
+// 1. 统计请求（Prometheus 指标），供休眠、SLO、扩缩容使用
+// 2. 把最近请求时间写回 LLMService，controller 据此判断是否空闲；按 API key 计量 token 用量（usage.go）
+// 3. 后端休眠（没有 ready Pod）时，触发唤醒并等待后端恢复，而不是直接返回 503
+// 4. 配置了其他集群的 gateway 时，本地没有 ready 副本就把请求溢出过去（failover.go）
+// 5. 转发前按 spec.gateway.guardrails 检查生成请求（guardrails.go）
+// 6. 通过 /scaler/metrics 给 KEDA 提供实时负载（scaler.go）
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+const (
+	// DefaultPort 是 gateway 监听的端口（和 vLLM 一样，客户端无感切换）
+	DefaultPort = 8000
+
+	// maxBufferedBody 请求体最大缓存大小；后端唤醒期间需要重放请求体
+	maxBufferedBody = 10 << 20
+)
+
+// Config 是 gateway 的配置
+type Config struct {
+	// Namespace/Name 是对应的 LLMService
+	Namespace string
+	Name      string
+
+	// UpstreamURL 是后端 vLLM Service，例如 "http://my-llm-vllm.default.svc:8000"
+	UpstreamURL string
+
+	// WakeTimeout 是后端不可用时最多等待多久（等待唤醒 + 模型加载）
+	WakeTimeout time.Duration
+	// RetryInterval 是等待唤醒期间的重试间隔
+	RetryInterval time.Duration
+
+	// Secondaries 是其他集群的 gateway，本地没有 ready 副本时把请求溢出过去
+	Secondaries []Secondary
+	// Readiness 提供本地 ready 副本数，为 nil 时请求失败即认为本地不可用
+	Readiness ReadinessSource
+
+	// Usage 计量每个请求的 token 用量（usage.go），为 nil 时不计量
+	Usage *UsageMeter
+	// Middlewares 是生成请求转发前依次经过的检查（guardrails.go）
+	Middlewares []Middleware
+}
+
+// Gateway 是反向代理 + 请求统计
+type Gateway struct {
+	config   Config
+	proxy    *httputil.ReverseProxy
+	activity *ActivityReporter
+	// load 是 /scaler/metrics 返回的实时负载（scaler.go）
+	load *loadTracker
+
+	// random 用于按权重选择溢出目标，测试时可以替换
+	random func() float64
+}
+
+// New 创建 Gateway
+//
+// activity 可以为 nil（本地测试时不需要连接 API server）
+func New(cfg Config, activity *ActivityReporter) (*Gateway, error) {
+	upstream, err := url.Parse(cfg.UpstreamURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upstream url %q: %w", cfg.UpstreamURL, err)
+	}
+	if cfg.RetryInterval == 0 {
+		cfg.RetryInterval = 2 * time.Second
+	}
+
+	g := &Gateway{config: cfg, activity: activity, load: newLoadTracker()}
+
+	proxy := httputil.NewSingleHostReverseProxy(upstream)
+	// FlushInterval = -1：每写一次就 flush，支持 stream=true 的 SSE 响应
+	proxy.FlushInterval = -1
+	proxy.Transport = &wakeTransport{gateway: g, base: http.DefaultTransport}
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Printf("❌ Upstream unavailable: %v", err)
+		http.Error(w, "upstream unavailable", http.StatusServiceUnavailable)
+	}
+	g.proxy = proxy
+
+	return g, nil
+}
+
+// Handler 返回 gateway 的 HTTP handler
+func (g *Gateway) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprintf(w, "OK\n")
+	})
+	mux.Handle("/metrics", promhttp.HandlerFor(ctrlmetrics.Registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc("/scaler/metrics", g.handleScalerMetrics)
+	mux.HandleFunc("/", g.handleProxy)
+	return mux
+}
+
+// Run 启动 HTTP 服务器，直到 ctx 被取消
+func (g *Gateway) Run(ctx context.Context, addr string) error {
+	server := &http.Server{Addr: addr, Handler: g.Handler()}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("🌐 Gateway listening on %s → %s", addr, g.config.UpstreamURL)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// handleProxy 统计并代理一个请求
+func (g *Gateway) handleProxy(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	inflight := metrics.GatewayInflightRequests.WithLabelValues(g.config.Namespace, g.config.Name)
+	inflight.Inc()
+	defer inflight.Dec()
+	g.load.inflight.Add(1)
+	defer g.load.inflight.Add(-1)
+
+	if g.activity != nil {
+		g.activity.Touch(false)
+	}
+
+	// 缓存请求体，后端唤醒期间重试时要重放
+	var metered *meteredRequest
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxBufferedBody+1))
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		if len(body) > maxBufferedBody {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		body, ok := g.applyGuardrails(w, r, body)
+		if !ok {
+			metrics.RecordGatewayRequest(g.config.Namespace, g.config.Name,
+				strconv.Itoa(http.StatusBadRequest), time.Since(start).Seconds())
+			return
+		}
+		if g.config.Usage != nil {
+			metered, body = inspectRequest(r, body)
+		}
+		r.ContentLength = int64(len(body))
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	if metered != nil {
+		rec.tail = &tailBuffer{max: maxMeteredBody}
+		if metered.stream {
+			rec.tail.max = maxStreamTail
+		}
+	}
+	g.proxy.ServeHTTP(rec, r)
+
+	var tokens int64
+	if metered != nil && rec.status == http.StatusOK && (metered.stream || !rec.tail.truncated) {
+		if u, ok := parseUsage(rec.tail.buf, metered.stream); ok {
+			g.config.Usage.Record(metered.key, metered.model, u)
+			tokens = u.PromptTokens + u.CompletionTokens
+		}
+	}
+	g.load.add(1, tokens)
+
+	metrics.RecordGatewayRequest(g.config.Namespace, g.config.Name,
+		strconv.Itoa(rec.status), time.Since(start).Seconds())
+}
+
+// wakeTransport 在后端休眠时触发唤醒并重试
+//
+// 请求失败（连接失败、502、503）并且本地没有 ready 副本（休眠、缩到 0）才等待唤醒、重放请求体。
+// 还有 ready 副本时失败原样返回：过载的 vLLM 返回的 503、处理中崩溃的副本，
+// 重放会让客户端一直挂到 WakeTimeout、重复提交生成请求，还会让服务永远不算空闲。
+type wakeTransport struct {
+	gateway *Gateway
+	base    http.RoundTripper
+}
+
+func (t *wakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	cfg := t.gateway.config
+	deadline := time.Now().Add(cfg.WakeTimeout)
+
+	for attempt := 0; ; attempt++ {
+		outReq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			outReq = req.Clone(req.Context())
+			outReq.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(outReq)
+		if err == nil && resp.StatusCode != http.StatusBadGateway && resp.StatusCode != http.StatusServiceUnavailable {
+			return resp, nil
+		}
+
+		if attempt == 0 {
+			// 后端没有休眠：把这次的结果原样返回，不重试
+			if !t.gateway.localDown(req.Context()) {
+				return resp, err
+			}
+			// 本地没有 ready 副本：先溢出到其他集群，同时照常唤醒本地后端
+			if len(cfg.Secondaries) > 0 {
+				if t.gateway.activity != nil {
+					t.gateway.activity.Touch(true)
+				}
+				if spilled, ok := t.gateway.spill(req, t.base); ok {
+					if resp != nil {
+						_ = resp.Body.Close()
+					}
+					return spilled, nil
+				}
+			}
+		}
+
+		// 超时或者客户端已经断开：把最后一次结果返回
+		if time.Now().Add(cfg.RetryInterval).After(deadline) || req.Context().Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			_ = resp.Body.Close()
+		}
+
+		if attempt == 0 {
+			log.Printf("😴 Upstream unavailable, waking up %s/%s", cfg.Namespace, cfg.Name)
+		}
+		if t.gateway.activity != nil {
+			t.gateway.activity.Touch(true)
+		}
+
+		select {
+		case <-time.After(cfg.RetryInterval):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// statusRecorder 记录响应状态码，用于指标
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+
+	// tail 不为 nil 时保留响应体（的末尾），用来解析 token 用量
+	tail *tailBuffer
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	if s.tail != nil {
+		s.tail.Write(p)
+	}
+	return s.ResponseWriter.Write(p)
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Flush 透传给底层 ResponseWriter，保证流式响应能及时发出
+func (s *statusRecorder) Flush() {
+	if f, ok := s.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
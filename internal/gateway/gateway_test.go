@@ -0,0 +1,174 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestGateway_RetriesUntilUpstreamReady 测试后端休眠（503）时 gateway 会等待并重放请求体
+func TestGateway_RetriesUntilUpstreamReady(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer upstream.Close()
+
+	gw, err := New(Config{
+		Namespace:     "default",
+		Name:          "llama",
+		UpstreamURL:   upstream.URL,
+		WakeTimeout:   time.Second,
+		RetryInterval: 10 * time.Millisecond,
+	}, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(`{"prompt":"hi"}`))
+	rec := httptest.NewRecorder()
+	gw.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200", rec.Code)
+	}
+	if rec.Body.String() != `{"prompt":"hi"}` {
+		t.Errorf("got body %q, want replayed request body", rec.Body.String())
+	}
+	if calls.Load() != 3 {
+		t.Errorf("got %d upstream calls, want 3", calls.Load())
+	}
+}
+
+// TestGateway_WakeTimeout 测试超过 WakeTimeout 后返回后端的 503
+func TestGateway_WakeTimeout(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+
+	gw, err := New(Config{
+		Namespace:     "default",
+		Name:          "llama",
+		UpstreamURL:   upstream.URL,
+		WakeTimeout:   50 * time.Millisecond,
+		RetryInterval: 10 * time.Millisecond,
+	}, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	gw.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/models", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want 503", rec.Code)
+	}
+}
+
+type fixedReadiness int32
+
+func (f fixedReadiness) ReadyReplicas(context.Context) (int32, error) { return int32(f), nil }
+
+// TestGateway_NoRetryWhenReplicasReady 测试后端有 ready 副本时（过载返回 503）不等待、不重放
+func TestGateway_NoRetryWhenReplicasReady(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer upstream.Close()
+
+	gw, err := New(Config{
+		Namespace:     "default",
+		Name:          "llama",
+		UpstreamURL:   upstream.URL,
+		WakeTimeout:   time.Minute,
+		RetryInterval: 10 * time.Millisecond,
+		Readiness:     fixedReadiness(2),
+	}, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	start := time.Now()
+	req := httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(`{"prompt":"hi"}`))
+	rec := httptest.NewRecorder()
+	gw.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("got status %d, want the upstream 503", rec.Code)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("got %d upstream calls, want 1 (no replay)", calls.Load())
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request took %v, want an immediate response", elapsed)
+	}
+}
+
+// TestGateway_SpillsToSecondary 测试本地没有 ready 副本时请求溢出到其他集群
+func TestGateway_SpillsToSecondary(t *testing.T) {
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer local.Close()
+
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(r.URL.Path + " " + string(body)))
+	}))
+	defer remote.Close()
+
+	gw, err := New(Config{
+		Namespace:     "default",
+		Name:          "llama",
+		UpstreamURL:   local.URL,
+		WakeTimeout:   time.Second,
+		RetryInterval: 10 * time.Millisecond,
+		Secondaries:   []Secondary{{Name: "eu-west", URL: remote.URL}},
+		Readiness:     fixedReadiness(0),
+	}, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(`{"prompt":"hi"}`))
+	rec := httptest.NewRecorder()
+	gw.Handler().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200", rec.Code)
+	}
+	if rec.Body.String() != `/v1/completions {"prompt":"hi"}` {
+		t.Errorf("got body %q, want spilled request", rec.Body.String())
+	}
+}
+
+// TestOrderSecondaries 测试延迟惩罚小的集群更容易排在前面
+func TestOrderSecondaries(t *testing.T) {
+	secondaries := []Secondary{
+		{Name: "far", LatencyPenalty: Duration{time.Second}},
+		{Name: "near"},
+	}
+
+	// far 的权重约 0.95，near 是 20：random=0.5 落在 near 的区间
+	ordered := orderSecondaries(secondaries, func() float64 { return 0.5 })
+	if ordered[0].Name != "near" || ordered[1].Name != "far" {
+		t.Errorf("got order %s,%s, want near,far", ordered[0].Name, ordered[1].Name)
+	}
+	// 原切片不能被修改
+	if secondaries[0].Name != "far" {
+		t.Error("orderSecondaries modified its input")
+	}
+}
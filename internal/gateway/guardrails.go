@@ -0,0 +1,217 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+// ============================================================================
+// Guardrails：请求转发前的中间件链
+// ============================================================================
+//
+// spec.gateway.guardrails 由 controller 序列化成 GUARDRAILS_CONFIG 环境变量传给 gateway，
+// gateway 启动时用 NewGuardrails 生成中间件链，按顺序处理每个生成请求：
+//
+//	max-prompt-tokens  prompt 太长 → 400
+//	banned-content     prompt 匹配禁用正则 → 400
+//	output-schema      没有指定输出约束的请求加上 guided_json（vLLM guided decoding）
+//
+// 新的检查实现 Middleware 接口，加到 NewGuardrails 里即可。
+// ============================================================================
+
+// GuardrailsEnv 是 gateway 读取 guardrails 配置（GuardrailsSpec 的 JSON）的环境变量
+const GuardrailsEnv = "GUARDRAILS_CONFIG"
+
+// GenerationRequest 是一个生成请求（/v1/completions 或 /v1/chat/completions）
+//
+// Fields 是请求体的顶层字段，中间件可以直接修改，未修改的字段原样转发
+type GenerationRequest struct {
+	Path   string
+	Fields map[string]json.RawMessage
+}
+
+// Prompt 返回请求里所有输入文本：completions 的 prompt，chat 的每条 message 的 content
+func (r *GenerationRequest) Prompt() string {
+	var parts []string
+	var prompt any
+	if err := json.Unmarshal(r.Fields["prompt"], &prompt); err == nil {
+		parts = appendText(parts, prompt)
+	}
+	var messages []struct {
+		Content any `json:"content"`
+	}
+	if err := json.Unmarshal(r.Fields["messages"], &messages); err == nil {
+		for _, m := range messages {
+			parts = appendText(parts, m.Content)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// appendText 收集字符串、字符串数组和 chat content parts（[{"type":"text","text":"..."}]）里的文本
+func appendText(parts []string, v any) []string {
+	switch v := v.(type) {
+	case string:
+		return append(parts, v)
+	case []any:
+		for _, item := range v {
+			if part, ok := item.(map[string]any); ok {
+				item = part["text"]
+			}
+			parts = appendText(parts, item)
+		}
+	}
+	return parts
+}
+
+// Middleware 在请求转发给后端之前检查或修改请求
+type Middleware interface {
+	// Name 出现在拒绝的错误码（guardrail_<Name>）和指标里
+	Name() string
+	// Process 返回非 nil 的错误表示拒绝请求，错误信息会返回给客户端
+	Process(req *GenerationRequest) error
+}
+
+// NewGuardrails 按配置生成中间件链，spec 为 nil 时返回 nil
+func NewGuardrails(spec *aiv1.GuardrailsSpec) ([]Middleware, error) {
+	if spec == nil {
+		return nil, nil
+	}
+	var chain []Middleware
+	if spec.MaxPromptTokens != nil {
+		chain = append(chain, maxPromptTokens(*spec.MaxPromptTokens))
+	}
+	if len(spec.BannedPatterns) > 0 {
+		banned := &bannedContent{}
+		for _, p := range spec.BannedPatterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid banned pattern %q: %w", p, err)
+			}
+			banned.patterns = append(banned.patterns, re)
+		}
+		chain = append(chain, banned)
+	}
+	if spec.OutputJSONSchema != "" {
+		if !json.Valid([]byte(spec.OutputJSONSchema)) {
+			return nil, fmt.Errorf("outputJSONSchema is not valid JSON")
+		}
+		chain = append(chain, outputSchema(spec.OutputJSONSchema))
+	}
+	return chain, nil
+}
+
+// LoadGuardrails 从 GUARDRAILS_CONFIG 环境变量生成中间件链，没有设置时返回 nil
+func LoadGuardrails() ([]Middleware, error) {
+	raw := os.Getenv(GuardrailsEnv)
+	if raw == "" {
+		return nil, nil
+	}
+	spec := &aiv1.GuardrailsSpec{}
+	if err := json.Unmarshal([]byte(raw), spec); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", GuardrailsEnv, err)
+	}
+	return NewGuardrails(spec)
+}
+
+// estimateTokens 按 4 个字符一个 token 估算（英文的经验值，中文会偏少，足够挡住超长输入）
+func estimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
+
+// maxPromptTokens 拒绝超长的 prompt
+type maxPromptTokens int32
+
+func (maxPromptTokens) Name() string { return "max_prompt_tokens" }
+
+func (m maxPromptTokens) Process(req *GenerationRequest) error {
+	if n := estimateTokens(req.Prompt()); n > int(m) {
+		return fmt.Errorf("prompt is about %d tokens, the limit is %d", n, int(m))
+	}
+	return nil
+}
+
+// bannedContent 拒绝匹配禁用正则的 prompt
+type bannedContent struct {
+	patterns []*regexp.Regexp
+}
+
+func (*bannedContent) Name() string { return "banned_content" }
+
+func (b *bannedContent) Process(req *GenerationRequest) error {
+	prompt := req.Prompt()
+	for _, re := range b.patterns {
+		if re.MatchString(prompt) {
+			// 不回显匹配到的内容和规则，避免泄露规则细节
+			return fmt.Errorf("prompt contains banned content")
+		}
+	}
+	return nil
+}
+
+// outputSchema 给没有输出约束的请求加上 guided_json
+type outputSchema string
+
+func (outputSchema) Name() string { return "output_schema" }
+
+func (s outputSchema) Process(req *GenerationRequest) error {
+	// 客户端自己指定了约束就尊重客户端的
+	for _, f := range []string{"guided_json", "guided_regex", "guided_choice", "guided_grammar", "response_format"} {
+		if req.Fields[f] != nil {
+			return nil
+		}
+	}
+	req.Fields["guided_json"] = json.RawMessage(s)
+	return nil
+}
+
+// isGenerationPath 判断是否是需要经过中间件链的生成请求
+func isGenerationPath(path string) bool {
+	return path == "/v1/completions" || path == "/v1/chat/completions"
+}
+
+// applyGuardrails 让请求经过中间件链，返回转发给后端的请求体
+//
+// 被拒绝时已经写好了 400 响应，返回 ok=false；请求体不是 JSON 对象时原样放行（由后端报错）
+func (g *Gateway) applyGuardrails(w http.ResponseWriter, r *http.Request, body []byte) (out []byte, ok bool) {
+	if len(g.config.Middlewares) == 0 || r.Method != http.MethodPost || !isGenerationPath(r.URL.Path) {
+		return body, true
+	}
+	req := &GenerationRequest{Path: r.URL.Path}
+	if err := json.Unmarshal(body, &req.Fields); err != nil || req.Fields == nil {
+		return body, true
+	}
+	for _, m := range g.config.Middlewares {
+		if err := m.Process(req); err != nil {
+			metrics.RecordGuardrailRejection(g.config.Namespace, g.config.Name, m.Name())
+			writeGuardrailError(w, m.Name(), err)
+			return nil, false
+		}
+	}
+	out, err := json.Marshal(req.Fields)
+	if err != nil {
+		return body, true
+	}
+	return out, true
+}
+
+// writeGuardrailError 按 OpenAI 的错误格式返回 400
+func writeGuardrailError(w http.ResponseWriter, name string, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]string{
+			"message": err.Error(),
+			"type":    "invalid_request_error",
+			"code":    "guardrail_" + name,
+		},
+	})
+}
@@ -0,0 +1,94 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ============================================================================
+// /scaler/metrics：给 KEDA metrics-api scaler 用的负载指标
+// ============================================================================
+//
+// spec.autoscaling.mode=External 时副本数交给 HPA/KEDA。有 Prometheus 的集群用 prometheus-adapter
+// 把 kubeinfer_gateway_* 指标暴露给 HPA 就够了（config/autoscaling）；
+// 没有 Prometheus 时，KEDA 的 metrics-api scaler 可以直接读这个接口：
+//
+//	{"inflightRequests": 3, "requestsPerSecond": 1.5, "tokensPerSecond": 420}
+//
+// 速率按最近 scalerWindow 计算。这是单个 gateway 副本的数值，
+// gateway 有多个副本时 KEDA 每次只读到其中一个，这种情况建议走 Prometheus。
+// ============================================================================
+
+// scalerWindow 是计算速率的窗口，按秒分桶
+const scalerWindow = 60
+
+// ScalerMetrics 是 /scaler/metrics 返回的负载指标
+type ScalerMetrics struct {
+	InflightRequests  int64   `json:"inflightRequests"`
+	RequestsPerSecond float64 `json:"requestsPerSecond"`
+	TokensPerSecond   float64 `json:"tokensPerSecond"`
+}
+
+// loadBucket 是一秒内的请求数和 token 数
+type loadBucket struct {
+	second   int64
+	requests int64
+	tokens   int64
+}
+
+// loadTracker 统计 gateway 的实时负载
+type loadTracker struct {
+	inflight atomic.Int64
+
+	mu      sync.Mutex
+	buckets [scalerWindow]loadBucket
+
+	// now 方便测试时注入时间
+	now func() time.Time
+}
+
+func newLoadTracker() *loadTracker {
+	return &loadTracker{now: time.Now}
+}
+
+// add 把一个完成的请求（和它的 token 数）记到当前这一秒
+func (t *loadTracker) add(requests, tokens int64) {
+	sec := t.now().Unix()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := &t.buckets[sec%scalerWindow]
+	if b.second != sec {
+		*b = loadBucket{second: sec}
+	}
+	b.requests += requests
+	b.tokens += tokens
+}
+
+// snapshot 返回当前的 in-flight 请求数和最近一个窗口的平均速率
+func (t *loadTracker) snapshot() ScalerMetrics {
+	sec := t.now().Unix()
+	var requests, tokens int64
+	t.mu.Lock()
+	for _, b := range t.buckets {
+		// 环形缓冲里超过窗口的旧桶还没被覆盖，要跳过
+		if b.second > sec-scalerWindow && b.second <= sec {
+			requests += b.requests
+			tokens += b.tokens
+		}
+	}
+	t.mu.Unlock()
+	return ScalerMetrics{
+		InflightRequests:  t.inflight.Load(),
+		RequestsPerSecond: float64(requests) / scalerWindow,
+		TokensPerSecond:   float64(tokens) / scalerWindow,
+	}
+}
+
+// handleScalerMetrics 返回 ScalerMetrics 的 JSON
+func (g *Gateway) handleScalerMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(g.load.snapshot())
+}
@@ -0,0 +1,70 @@
+package gateway
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadTracker(t *testing.T) {
+	now := time.Unix(1000, 0)
+	tracker := newLoadTracker()
+	tracker.now = func() time.Time { return now }
+
+	tracker.add(1, 100)
+	now = now.Add(30 * time.Second)
+	tracker.add(2, 200)
+	if got := tracker.snapshot(); got.RequestsPerSecond != 3.0/scalerWindow || got.TokensPerSecond != 300.0/scalerWindow {
+		t.Errorf("snapshot = %+v, want 3 requests and 300 tokens in the window", got)
+	}
+
+	// 第一个桶滑出窗口
+	now = now.Add(45 * time.Second)
+	if got := tracker.snapshot(); got.RequestsPerSecond != 2.0/scalerWindow || got.TokensPerSecond != 200.0/scalerWindow {
+		t.Errorf("snapshot = %+v, want only the second bucket", got)
+	}
+	// 同一个槽位被新的一秒复用时要先清零
+	now = time.Unix(1000+2*scalerWindow, 0)
+	tracker.add(1, 0)
+	if got := tracker.snapshot(); got.RequestsPerSecond != 1.0/scalerWindow || got.TokensPerSecond != 0 {
+		t.Errorf("snapshot = %+v, want the reused bucket reset", got)
+	}
+}
+
+// TestGateway_ScalerMetrics 测试 /scaler/metrics 统计经过 gateway 的请求和 token
+func TestGateway_ScalerMetrics(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"choices":[{"text":"hi"}],"usage":{"prompt_tokens":10,"completion_tokens":50}}`)
+	}))
+	defer upstream.Close()
+
+	gw, err := New(Config{
+		Namespace:   "default",
+		Name:        "scaler",
+		UpstreamURL: upstream.URL,
+		WakeTimeout: time.Second,
+		Usage:       NewUsageMeter(nil, "default", "scaler"),
+	}, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	for range 3 {
+		req := httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(`{"model":"qwen","prompt":"hi"}`))
+		gw.Handler().ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	rec := httptest.NewRecorder()
+	gw.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/scaler/metrics", nil))
+	var got ScalerMetrics
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := ScalerMetrics{RequestsPerSecond: 3.0 / scalerWindow, TokensPerSecond: 180.0 / scalerWindow}
+	if got != want {
+		t.Errorf("scaler metrics = %+v, want %+v", got, want)
+	}
+}
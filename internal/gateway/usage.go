@@ -0,0 +1,347 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+// ============================================================================
+// token 用量计量
+// ============================================================================
+//
+// 共享 GPU 集群要按团队/应用计费，按请求数不准（一个长 prompt 顶几百个短请求），要按 token。
+//
+//  1. 请求：记下 model 和 API key 指纹（Authorization: Bearer <key> 的 SHA-256 前缀，不保存原文）
+//     stream=true 且客户端没有设置 stream_options 时，加上 include_usage，让后端在最后一个 chunk 里带 usage
+//  2. 响应：200 时从响应体（流式时是最后一个带 usage 的 data: chunk）里读出 prompt/completion tokens
+//  3. 计量：立即记到 kubeinfer_gateway_tokens_total 指标；同时在内存里累加，
+//     定期累加进 UsageReport（每个 LLMService 每天一个），多个 gateway 副本各自累加自己的增量
+// ============================================================================
+
+const (
+	// anonymousKey 是没有带 API key 的请求
+	anonymousKey = "anonymous"
+
+	// maxMeteredBody 是非流式响应最多缓存多少字节用来解析 usage，超过的不计量
+	maxMeteredBody = 1 << 20
+	// maxStreamTail 是流式响应保留的末尾字节数，usage 在最后一个 chunk 里
+	maxStreamTail = 16 << 10
+)
+
+// usage 对应 OpenAI 响应里的 usage 字段
+type usage struct {
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+}
+
+// KeyFingerprint 返回 API key 的指纹，UsageReport 和指标里用它代替 key 原文
+func KeyFingerprint(apiKey string) string {
+	if apiKey == "" {
+		return anonymousKey
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// requestKey 从 Authorization 头里取 API key 的指纹
+func requestKey(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	key, ok := strings.CutPrefix(auth, "Bearer ")
+	if !ok {
+		return KeyFingerprint("")
+	}
+	return KeyFingerprint(strings.TrimSpace(key))
+}
+
+// meteredRequest 是一个需要计量的请求
+type meteredRequest struct {
+	key    string
+	model  string
+	stream bool
+}
+
+// inspectRequest 解析生成请求的 model/stream，需要时加上 stream_options.include_usage
+//
+// 返回的 body 是发给后端的请求体；不是 JSON 或没有 model 时返回 nil，不计量
+func inspectRequest(r *http.Request, body []byte) (*meteredRequest, []byte) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.URL.Path, "/v1/") {
+		return nil, body
+	}
+	// RawMessage 保留其余字段的原样（数字精度、字段内容），只改 stream_options
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, body
+	}
+	var model string
+	if err := json.Unmarshal(fields["model"], &model); err != nil || model == "" {
+		return nil, body
+	}
+	m := &meteredRequest{key: requestKey(r), model: model}
+	_ = json.Unmarshal(fields["stream"], &m.stream)
+	if m.stream && fields["stream_options"] == nil {
+		fields["stream_options"] = json.RawMessage(`{"include_usage":true}`)
+		if rewritten, err := json.Marshal(fields); err == nil {
+			body = rewritten
+		}
+	}
+	return m, body
+}
+
+// tailBuffer 只保留最后 max 个字节
+type tailBuffer struct {
+	buf       []byte
+	max       int
+	truncated bool
+}
+
+func (t *tailBuffer) Write(p []byte) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = t.buf[len(t.buf)-t.max:]
+		t.truncated = true
+	}
+}
+
+// parseUsage 从响应体里读出 usage
+func parseUsage(body []byte, stream bool) (usage, bool) {
+	var resp struct {
+		Usage *usage `json:"usage"`
+	}
+	if !stream {
+		if err := json.Unmarshal(body, &resp); err != nil || resp.Usage == nil {
+			return usage{}, false
+		}
+		return *resp.Usage, true
+	}
+	// SSE：从后往前找带 usage 的 data: 行（最后一个是 data: [DONE]）
+	lines := bytes.Split(body, []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		data, ok := bytes.CutPrefix(bytes.TrimSpace(lines[i]), []byte("data:"))
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(bytes.TrimSpace(data), &resp); err == nil && resp.Usage != nil {
+			return *resp.Usage, true
+		}
+	}
+	return usage{}, false
+}
+
+// usageKey 是 UsageMeter 内存里累加的维度
+type usageKey struct {
+	day   string // YYYYMMDD（UTC）
+	key   string
+	model string
+}
+
+// UsageMeter 累加 token 用量并定期写进 UsageReport
+type UsageMeter struct {
+	// client 为 nil 时只记录指标，不写 UsageReport（本地测试）
+	client    client.Client
+	namespace string
+	name      string
+
+	mu      sync.Mutex
+	pending map[usageKey]*aiv1.UsageEntry
+
+	// now 方便测试时注入时间
+	now func() time.Time
+}
+
+// NewUsageMeter 创建 UsageMeter
+func NewUsageMeter(c client.Client, namespace, name string) *UsageMeter {
+	return &UsageMeter{
+		client:    c,
+		namespace: namespace,
+		name:      name,
+		pending:   map[usageKey]*aiv1.UsageEntry{},
+		now:       time.Now,
+	}
+}
+
+// Record 记录一次请求的用量
+func (m *UsageMeter) Record(key, model string, u usage) {
+	metrics.RecordGatewayTokens(m.namespace, m.name, key, model, u.PromptTokens, u.CompletionTokens)
+
+	k := usageKey{day: m.now().UTC().Format("20060102"), key: key, model: model}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.pending[k]
+	if e == nil {
+		e = &aiv1.UsageEntry{Key: key, Model: model}
+		m.pending[k] = e
+	}
+	e.Requests++
+	e.PromptTokens += u.PromptTokens
+	e.CompletionTokens += u.CompletionTokens
+}
+
+// Run 每 interval 把累加的用量写进 UsageReport，ctx 取消时最后写一次
+func (m *UsageMeter) Run(ctx context.Context, interval time.Duration) {
+	if m.client == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := m.Flush(flushCtx); err != nil {
+				log.Printf("⚠️  Failed to flush usage on shutdown: %v", err)
+			}
+			return
+		case <-ticker.C:
+			if err := m.Flush(ctx); err != nil {
+				log.Printf("⚠️  Failed to flush usage: %v", err)
+			}
+		}
+	}
+}
+
+// Flush 把内存里的增量累加进 UsageReport，失败的增量留到下一次
+func (m *UsageMeter) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = map[usageKey]*aiv1.UsageEntry{}
+	m.mu.Unlock()
+
+	byDay := map[string][]aiv1.UsageEntry{}
+	for k, e := range pending {
+		byDay[k.day] = append(byDay[k.day], *e)
+	}
+	var firstErr error
+	for day, entries := range byDay {
+		if err := m.addToReport(ctx, day, entries); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			m.restore(pending, day)
+		}
+	}
+	return firstErr
+}
+
+// restore 把写失败的增量放回 pending
+func (m *UsageMeter) restore(failed map[usageKey]*aiv1.UsageEntry, day string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, e := range failed {
+		if k.day != day {
+			continue
+		}
+		if cur := m.pending[k]; cur != nil {
+			cur.Requests += e.Requests
+			cur.PromptTokens += e.PromptTokens
+			cur.CompletionTokens += e.CompletionTokens
+		} else {
+			m.pending[k] = e
+		}
+	}
+}
+
+// UsageReportName 是 LLMService 在某一天（YYYYMMDD）的 UsageReport 名称
+func UsageReportName(llmService, day string) string {
+	return llmService + "-usage-" + day
+}
+
+// addToReport 把 entries 累加进当天的 UsageReport，不存在时先创建
+//
+// 多个 gateway 副本同时写同一个报告，用 resourceVersion 冲突重试保证增量不丢
+func (m *UsageMeter) addToReport(ctx context.Context, day string, entries []aiv1.UsageEntry) error {
+	report, err := m.ensureReport(ctx, day)
+	if err != nil {
+		return err
+	}
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := m.client.Get(ctx, client.ObjectKeyFromObject(report), report); err != nil {
+			return err
+		}
+		mergeUsage(&report.Status, entries)
+		now := metav1.NewTime(m.now())
+		report.Status.LastUpdateTime = &now
+		return m.client.Status().Update(ctx, report)
+	})
+}
+
+// ensureReport 返回当天的 UsageReport，不存在时创建（owner 是 LLMService，删除时一起回收）
+func (m *UsageMeter) ensureReport(ctx context.Context, day string) (*aiv1.UsageReport, error) {
+	report := &aiv1.UsageReport{}
+	key := client.ObjectKey{Namespace: m.namespace, Name: UsageReportName(m.name, day)}
+	err := m.client.Get(ctx, key, report)
+	if err == nil || !errors.IsNotFound(err) {
+		return report, err
+	}
+
+	start, err := time.Parse("20060102", day)
+	if err != nil {
+		return nil, fmt.Errorf("invalid usage day %q: %w", day, err)
+	}
+	llm := &aiv1.LLMService{}
+	if err := m.client.Get(ctx, client.ObjectKey{Namespace: m.namespace, Name: m.name}, llm); err != nil {
+		return nil, err
+	}
+	report = &aiv1.UsageReport{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+		Spec: aiv1.UsageReportSpec{
+			LLMService:  m.name,
+			PeriodStart: metav1.NewTime(start),
+			PeriodEnd:   metav1.NewTime(start.AddDate(0, 0, 1)),
+		},
+	}
+	if err := controllerutil.SetOwnerReference(llm, report, m.client.Scheme()); err != nil {
+		return nil, err
+	}
+	if err := m.client.Create(ctx, report); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return nil, err
+		}
+		// 另一个副本刚创建了
+		return report, m.client.Get(ctx, key, report)
+	}
+	return report, nil
+}
+
+// mergeUsage 把 entries 累加进 status，重新计算合计
+func mergeUsage(status *aiv1.UsageReportStatus, entries []aiv1.UsageEntry) {
+	for _, e := range entries {
+		found := false
+		for i := range status.Entries {
+			cur := &status.Entries[i]
+			if cur.Key == e.Key && cur.Model == e.Model {
+				cur.Requests += e.Requests
+				cur.PromptTokens += e.PromptTokens
+				cur.CompletionTokens += e.CompletionTokens
+				found = true
+				break
+			}
+		}
+		if !found {
+			status.Entries = append(status.Entries, e)
+		}
+	}
+	status.PromptTokens, status.CompletionTokens = 0, 0
+	for _, e := range status.Entries {
+		status.PromptTokens += e.PromptTokens
+		status.CompletionTokens += e.CompletionTokens
+	}
+}
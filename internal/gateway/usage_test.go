@@ -0,0 +1,137 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// TestGateway_MetersUsage 测试非流式和流式响应的 token 计量
+func TestGateway_MetersUsage(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req["stream"] != true {
+			_, _ = io.WriteString(w, `{"choices":[{"text":"hi"}],"usage":{"prompt_tokens":12,"completion_tokens":30}}`)
+			return
+		}
+		// 流式请求必须被加上 include_usage，否则后端不会返回 usage
+		opts, _ := req["stream_options"].(map[string]any)
+		if opts["include_usage"] != true {
+			http.Error(w, "missing include_usage", http.StatusBadRequest)
+			return
+		}
+		_, _ = io.WriteString(w, "data: {\"choices\":[{\"text\":\"h\"}],\"usage\":null}\n\n"+
+			"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":7}}\n\n"+
+			"data: [DONE]\n\n")
+	}))
+	defer upstream.Close()
+
+	meter := NewUsageMeter(nil, "default", "llama")
+	gw, err := New(Config{
+		Namespace:   "default",
+		Name:        "llama",
+		UpstreamURL: upstream.URL,
+		WakeTimeout: time.Second,
+		Usage:       meter,
+	}, nil)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	send := func(body, apiKey string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/completions", strings.NewReader(body))
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		rec := httptest.NewRecorder()
+		gw.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+	for _, body := range []string{`{"model":"qwen","prompt":"hi"}`, `{"model":"qwen","prompt":"hi","stream":true}`} {
+		if code := send(body, "sk-team-a"); code != http.StatusOK {
+			t.Fatalf("request %s returned %d", body, code)
+		}
+	}
+	// 没有 model 的请求（不是生成请求）不计量
+	send(`{"prompt":"hi"}`, "")
+
+	key := KeyFingerprint("sk-team-a")
+	day := time.Now().UTC().Format("20060102")
+	e := meter.pending[usageKey{day: day, key: key, model: "qwen"}]
+	if e == nil {
+		t.Fatalf("no usage recorded for %s, pending = %v", key, meter.pending)
+	}
+	if e.Requests != 2 || e.PromptTokens != 17 || e.CompletionTokens != 37 {
+		t.Errorf("usage = %+v, want 2 requests, 17 prompt, 37 completion tokens", *e)
+	}
+	if len(meter.pending) != 1 {
+		t.Errorf("pending = %v, want only the metered key", meter.pending)
+	}
+}
+
+func TestKeyFingerprint(t *testing.T) {
+	if got := KeyFingerprint(""); got != anonymousKey {
+		t.Errorf("empty key = %q, want %q", got, anonymousKey)
+	}
+	a := KeyFingerprint("sk-secret")
+	if !strings.HasPrefix(a, "sha256:") || len(a) != len("sha256:")+12 || strings.Contains(a, "secret") {
+		t.Errorf("fingerprint %q", a)
+	}
+	if a == KeyFingerprint("sk-other") {
+		t.Error("different keys must have different fingerprints")
+	}
+}
+
+// TestUsageMeter_Flush 测试多次 flush 累加到同一个 UsageReport
+func TestUsageMeter_Flush(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = aiv1.AddToScheme(scheme)
+	llm := &aiv1.LLMService{ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "default", UID: "uid-1"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(llm).
+		WithStatusSubresource(&aiv1.UsageReport{}).Build()
+
+	meter := NewUsageMeter(c, "default", "llama")
+	meter.now = func() time.Time { return time.Date(2026, 3, 9, 15, 0, 0, 0, time.UTC) }
+
+	meter.Record("sha256:aaa", "qwen", usage{PromptTokens: 10, CompletionTokens: 20})
+	meter.Record(anonymousKey, "qwen", usage{PromptTokens: 1, CompletionTokens: 2})
+	if err := meter.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	meter.Record("sha256:aaa", "qwen", usage{PromptTokens: 5, CompletionTokens: 5})
+	if err := meter.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	report := &aiv1.UsageReport{}
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "llama-usage-20260309"}, report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Spec.PeriodEnd.Sub(report.Spec.PeriodStart.Time) != 24*time.Hour {
+		t.Errorf("period = %v - %v", report.Spec.PeriodStart, report.Spec.PeriodEnd)
+	}
+	if len(report.OwnerReferences) != 1 || report.OwnerReferences[0].UID != llm.UID {
+		t.Errorf("owner references = %v", report.OwnerReferences)
+	}
+	if report.Status.PromptTokens != 16 || report.Status.CompletionTokens != 27 || len(report.Status.Entries) != 2 {
+		t.Errorf("status = %+v", report.Status)
+	}
+	for _, e := range report.Status.Entries {
+		if e.Key == "sha256:aaa" && (e.Requests != 2 || e.PromptTokens != 15) {
+			t.Errorf("entry = %+v, want 2 requests and 15 prompt tokens", e)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Package kubeclient 是 manager 和 agent 访问 API server 的客户端设置：QPS/Burst 和限流指标
+//
+// agent 的 client-go 默认只有 5 QPS，几千个 agent 同时启动时选举、查 coordinator 都在客户端排队；
+// manager 默认关掉客户端限流（controller-runtime 依赖 API server 的优先级和公平性），
+// API server 返回 429 时 reconcile 只是变慢，日志里什么都看不到。
+//
+//	manager  --kube-api-qps / --kube-api-burst
+//	agent    --kube-api-qps / --kube-api-burst，默认读 KUBE_API_QPS / KUBE_API_BURST
+//	         （controller 根据 operator 配置的 agentClient 设置）
+//
+// Instrument 把 client-go 的限流等待和 429 响应记到 kubeinfer_kube_client_* 指标上。
+package kubeclient
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+	clientmetrics "k8s.io/client-go/tools/metrics"
+
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+// agent 读取的环境变量
+const (
+	QPSEnv   = "KUBE_API_QPS"
+	BurstEnv = "KUBE_API_BURST"
+)
+
+// 被限流的来源（kubeinfer_kube_client_throttled_requests_total 的 source）
+const (
+	SourceClient = "client"
+	SourceServer = "server"
+)
+
+// longWait 是算作被客户端限流的等待时间，和 client-go 打 "client-side throttling" 日志的阈值一样
+const longWait = time.Second
+
+// RateLimit 是客户端的 QPS 和 Burst，0 表示保留 rest.Config 原来的值
+type RateLimit struct {
+	QPS   float32
+	Burst int
+}
+
+// RateLimitFromEnv 读取 KUBE_API_QPS / KUBE_API_BURST，没有设置或者格式不对时是 0
+func RateLimitFromEnv() RateLimit {
+	var l RateLimit
+	if v, err := strconv.ParseFloat(os.Getenv(QPSEnv), 32); err == nil {
+		l.QPS = float32(v)
+	}
+	if v, err := strconv.Atoi(os.Getenv(BurstEnv)); err == nil {
+		l.Burst = v
+	}
+	return l
+}
+
+// Validate 检查 QPS 和 Burst 不是负数
+func (l RateLimit) Validate() error {
+	if l.QPS < 0 || l.Burst < 0 {
+		return fmt.Errorf("kube API qps and burst must not be negative")
+	}
+	return nil
+}
+
+// Apply 把不为 0 的字段写到 cfg 上
+//
+// Burst 小于 QPS 时客户端每秒实际发不出 QPS 个请求，这时 Burst 至少取 QPS
+func (l RateLimit) Apply(cfg *rest.Config) {
+	if l.QPS > 0 {
+		cfg.QPS = l.QPS
+	}
+	if l.Burst > 0 {
+		cfg.Burst = l.Burst
+	}
+	if cfg.QPS > 0 && float32(cfg.Burst) < cfg.QPS {
+		cfg.Burst = int(cfg.QPS)
+	}
+}
+
+var instrumentOnce sync.Once
+
+// Instrument 把这个进程里所有 client-go 客户端的限流等待和 429 记到指标上，component 是指标的标签
+//
+// 只有第一次调用生效。controller-runtime 已经注册了 client-go 的 RequestResult 指标，
+// 这里包一层，原来的 rest_client_requests_total 照常记录
+func Instrument(component string) {
+	instrumentOnce.Do(func() {
+		clientmetrics.RateLimiterLatency = &rateLimiterLatency{component: component, next: clientmetrics.RateLimiterLatency}
+		clientmetrics.RequestResult = &requestResult{component: component, next: clientmetrics.RequestResult}
+	})
+}
+
+// rateLimiterLatency 记录客户端限流器里的等待时间
+type rateLimiterLatency struct {
+	component string
+	next      clientmetrics.LatencyMetric
+}
+
+func (m *rateLimiterLatency) Observe(ctx context.Context, verb string, u url.URL, latency time.Duration) {
+	metrics.KubeClientRateLimiterWait.WithLabelValues(m.component).Observe(latency.Seconds())
+	if latency >= longWait {
+		metrics.KubeClientThrottledRequests.WithLabelValues(m.component, SourceClient).Inc()
+	}
+	m.next.Observe(ctx, verb, u, latency)
+}
+
+// requestResult 统计 API server 返回的 429
+type requestResult struct {
+	component string
+	next      clientmetrics.ResultMetric
+}
+
+func (m *requestResult) Increment(ctx context.Context, code, method, host string) {
+	if code == "429" {
+		metrics.KubeClientThrottledRequests.WithLabelValues(m.component, SourceServer).Inc()
+	}
+	m.next.Increment(ctx, code, method, host)
+}
@@ -0,0 +1,78 @@
+package kubeclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+func TestRateLimitApply(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     RateLimit
+		base      rest.Config
+		wantQPS   float32
+		wantBurst int
+	}{
+		{name: "unset keeps defaults", base: rest.Config{QPS: 20, Burst: 30}, wantQPS: 20, wantBurst: 30},
+		{name: "both", limit: RateLimit{QPS: 100, Burst: 200}, base: rest.Config{QPS: 20, Burst: 30}, wantQPS: 100, wantBurst: 200},
+		// 只调大 QPS，Burst 跟着至少到 QPS
+		{name: "qps above burst", limit: RateLimit{QPS: 50}, base: rest.Config{QPS: 20, Burst: 30}, wantQPS: 50, wantBurst: 50},
+	}
+	for _, tt := range tests {
+		cfg := tt.base
+		tt.limit.Apply(&cfg)
+		if cfg.QPS != tt.wantQPS || cfg.Burst != tt.wantBurst {
+			t.Errorf("%s: qps/burst = %v/%d, want %v/%d", tt.name, cfg.QPS, cfg.Burst, tt.wantQPS, tt.wantBurst)
+		}
+	}
+
+	if err := (RateLimit{QPS: -1}).Validate(); err == nil {
+		t.Error("negative qps passed validation")
+	}
+}
+
+func TestRateLimitFromEnv(t *testing.T) {
+	t.Setenv(QPSEnv, "12.5")
+	t.Setenv(BurstEnv, "not-a-number")
+	if got := RateLimitFromEnv(); got != (RateLimit{QPS: 12.5}) {
+		t.Errorf("RateLimitFromEnv = %+v, want qps 12.5 and default burst", got)
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	Instrument("test")
+
+	// API server 一直返回 429：client-go 按 Retry-After 重试，每次都算一次
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "0")
+		http.Error(w, "too many requests", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	// Burst 1、QPS 1：第二个请求要在客户端限流器里等将近 1 秒
+	cfg := &rest.Config{Host: server.URL, QPS: 1, Burst: 1}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, _ = clientset.CoreV1().Pods("default").Get(ctx, "qwen-a", metav1.GetOptions{})
+
+	if n := testutil.ToFloat64(metrics.KubeClientThrottledRequests.WithLabelValues("test", SourceServer)); n == 0 {
+		t.Error("429 responses were not counted")
+	}
+	if n := testutil.CollectAndCount(metrics.KubeClientRateLimiterWait); n == 0 {
+		t.Error("rate limiter waits were not observed")
+	}
+}
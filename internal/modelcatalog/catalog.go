@@ -0,0 +1,174 @@
+// Package modelcatalog 估算模型的参数量、显存需求和结构参数
+//
+// controller 用它计算启动探针的时间上限和 tensor parallel 大小，webhook 用它在创建 LLMService 时
+// 检查模型能不能放进集群里的 GPU（例如 70B fp16 放不进 1 张 24Gi 的卡）。
+//
+// 数据来源：
+// 1. 内置表：常见模型的真实参数量（名字里没写大小，或者写的是近似值）和注意力头数
+// 2. 模型名：HuggingFace 的命名习惯，例如 "Llama-2-7b"、"Mixtral-8x7B"
+//
+// 没有联网查询 HuggingFace API：webhook 的超时只有几秒，
+// 而且很多集群（尤其是 GPU 集群）不能访问公网。
+package modelcatalog
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// knownModels 是名字里看不出真实大小的常见模型（key 小写）
+var knownModels = map[string]float64{
+	"deepseek-ai/deepseek-r1":               671,
+	"deepseek-ai/deepseek-v3":               671,
+	"deepseek-ai/deepseek-v2":               236,
+	"deepseek-ai/deepseek-v2-lite":          15.7,
+	"mistralai/mixtral-8x7b-v0.1":           46.7,
+	"mistralai/mixtral-8x7b-instruct-v0.1":  46.7,
+	"mistralai/mixtral-8x22b-v0.1":          141,
+	"mistralai/mixtral-8x22b-instruct-v0.1": 141,
+	"microsoft/phi-2":                       2.7,
+	"gpt2":                                  0.124,
+	"openai-community/gpt2":                 0.124,
+}
+
+// sizePattern 匹配模型名里的参数量，例如 "7b"、"0.5B"、"125m"、"8x7B"
+// 前后必须是分隔符，避免把 "qwen2.5" 里的数字当成参数量
+var sizePattern = regexp.MustCompile(`(?i)(?:^|[-_/.])(?:(\d+)x)?(\d+(?:\.\d+)?)([bm])(?:$|[-_/.])`)
+
+// ParameterBillions 返回模型参数量（单位：十亿），ok=false 表示无法判断
+func ParameterBillions(model string) (float64, bool) {
+	if size, ok := knownModels[strings.ToLower(model)]; ok {
+		return size, true
+	}
+
+	m := sizePattern.FindStringSubmatch(model)
+	if m == nil {
+		return 0, false
+	}
+	size, err := strconv.ParseFloat(m[2], 64)
+	if err != nil || size <= 0 {
+		return 0, false
+	}
+	if strings.EqualFold(m[3], "m") {
+		size /= 1000
+	}
+	// MoE 模型，例如 Mixtral-8x7B（不在内置表里时按专家数 × 单个专家估算，偏大）
+	if m[1] != "" {
+		if experts, err := strconv.Atoi(m[1]); err == nil {
+			size *= float64(experts)
+		}
+	}
+	return size, true
+}
+
+// Precision 是权重的存储精度
+type Precision struct {
+	Name          string
+	BytesPerParam float64
+}
+
+var (
+	FP16 = Precision{Name: "fp16", BytesPerParam: 2}
+	FP8  = Precision{Name: "fp8", BytesPerParam: 1}
+	INT8 = Precision{Name: "int8", BytesPerParam: 1}
+	INT4 = Precision{Name: "int4", BytesPerParam: 0.5}
+)
+
+// quantizationMarkers 模型名里表示量化方式的关键字（按优先级）
+var quantizationMarkers = []struct {
+	marker    string
+	precision Precision
+}{
+	{"awq", INT4},
+	{"gptq", INT4},
+	{"int4", INT4},
+	{"4bit", INT4},
+	{"q4_", INT4},
+	{"gguf", INT4},
+	{"fp8", FP8},
+	{"int8", INT8},
+	{"8bit", INT8},
+	{"q8_", INT8},
+}
+
+// DetectPrecision 从模型名判断权重精度，看不出来时按 fp16
+func DetectPrecision(model string) Precision {
+	lower := strings.ToLower(model)
+	for _, q := range quantizationMarkers {
+		if strings.Contains(lower, q.marker) {
+			return q.precision
+		}
+	}
+	return FP16
+}
+
+// WeightsGiB 是权重本身占用的显存
+func WeightsGiB(billions float64, p Precision) float64 {
+	return billions * 1e9 * p.BytesPerParam / (1 << 30)
+}
+
+// ServingOverhead 是推理时在权重之外需要的显存比例（KV cache、激活、CUDA context）
+const ServingOverhead = 1.2
+
+// knownAttentionHeads 是常见模型的注意力头数（config.json 的 num_attention_heads），按顺序匹配小写模型名的子串
+//
+// vLLM 要求头数能被 tensor parallel 大小整除，例如 Qwen2.5-7B 有 28 个头，8 卡 TP 会启动失败
+var knownAttentionHeads = []struct {
+	marker string
+	heads  int
+}{
+	{"llama-3.1-405b", 128},
+	{"llama-3.1-70b", 64},
+	{"llama-3.3-70b", 64},
+	{"llama-3-70b", 64},
+	{"llama-2-70b", 64},
+	{"llama-3.1-8b", 32},
+	{"llama-3-8b", 32},
+	{"llama-3.2-3b", 24},
+	{"llama-3.2-1b", 32},
+	{"llama-2-13b", 40},
+	{"llama-2-7b", 32},
+	{"qwen2.5-72b", 64},
+	{"qwen2.5-32b", 40},
+	{"qwen2.5-14b", 40},
+	{"qwen2.5-7b", 28},
+	{"qwen2.5-3b", 16},
+	{"qwen2.5-1.5b", 12},
+	{"qwen2.5-0.5b", 14},
+	{"mixtral-8x22b", 48},
+	{"mixtral-8x7b", 32},
+	{"mistral-7b", 32},
+	{"phi-2", 32},
+	{"opt-125m", 12},
+	{"gpt2", 12},
+}
+
+// AttentionHeads 返回模型的注意力头数，ok=false 表示内置表里没有
+func AttentionHeads(model string) (int, bool) {
+	lower := strings.ToLower(model)
+	for _, m := range knownAttentionHeads {
+		if strings.Contains(lower, m.marker) {
+			return m.heads, true
+		}
+	}
+	return 0, false
+}
+
+// TensorParallelSize 返回 gpus 张卡时能用的 tensor parallel 大小
+//
+// 知道模型的注意力头数时，取不超过 gpus、能整除头数的最大的 2 的幂（例如 Qwen2.5-7B 有 28 个头，8 卡时是 4）。
+// 只看头数的话 7 也能整除 28，但 KV 头数（Qwen2.5-7B 是 4）也要和 TP 对得上，2 的幂最稳妥。
+// 不知道头数时就是 gpus
+func TensorParallelSize(model string, gpus int) int {
+	gpus = max(gpus, 1)
+	heads, ok := AttentionHeads(model)
+	if !ok {
+		return gpus
+	}
+	tp := 1
+	for next := 2; next <= gpus && heads%next == 0; next *= 2 {
+		tp = next
+	}
+	return tp
+}
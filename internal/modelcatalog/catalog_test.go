@@ -0,0 +1,93 @@
+package modelcatalog
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParameterBillions(t *testing.T) {
+	tests := []struct {
+		model  string
+		want   float64
+		wantOK bool
+	}{
+		{"meta-llama/Llama-3.1-70B-Instruct", 70, true},
+		{"Qwen/Qwen2.5-0.5B", 0.5, true},
+		{"facebook/opt-125m", 0.125, true},
+		{"deepseek-ai/DeepSeek-R1", 671, true},
+		{"mistralai/Mixtral-8x7B-v0.1", 46.7, true},
+		{"Qwen/Qwen2.5-Instruct", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParameterBillions(tt.model)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("ParameterBillions(%q) = %v, %v, want %v, %v", tt.model, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestDetectPrecision(t *testing.T) {
+	tests := []struct {
+		model string
+		want  Precision
+	}{
+		{"meta-llama/Llama-3.1-70B-Instruct", FP16},
+		{"TheBloke/Llama-2-70B-AWQ", INT4},
+		{"neuralmagic/Meta-Llama-3.1-70B-Instruct-FP8", FP8},
+		{"TheBloke/Llama-2-7B-GGUF", INT4},
+	}
+
+	for _, tt := range tests {
+		if got := DetectPrecision(tt.model); got != tt.want {
+			t.Errorf("DetectPrecision(%q) = %s, want %s", tt.model, got.Name, tt.want.Name)
+		}
+	}
+}
+
+func TestWeightsGiB(t *testing.T) {
+	// 70B fp16 ≈ 130Gi
+	if got := WeightsGiB(70, FP16); math.Abs(got-130.4) > 0.1 {
+		t.Errorf("WeightsGiB(70, fp16) = %.1f, want ~130.4", got)
+	}
+}
+
+func TestAttentionHeads(t *testing.T) {
+	tests := []struct {
+		model  string
+		want   int
+		wantOK bool
+	}{
+		{"Qwen/Qwen2.5-7B-Instruct", 28, true},
+		{"meta-llama/Llama-3.1-70B-Instruct", 64, true},
+		{"TheBloke/Llama-2-7B-AWQ", 32, true},
+		{"deepseek-ai/DeepSeek-R1", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := AttentionHeads(tt.model)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("AttentionHeads(%q) = %d, %v, want %d, %v", tt.model, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestTensorParallelSize(t *testing.T) {
+	tests := []struct {
+		model string
+		gpus  int
+		want  int
+	}{
+		{"meta-llama/Llama-3.1-70B-Instruct", 8, 8},
+		{"Qwen/Qwen2.5-7B-Instruct", 8, 4},
+		{"Qwen/Qwen2.5-0.5B", 4, 2},
+		{"deepseek-ai/DeepSeek-R1", 8, 8},
+		{"Qwen/Qwen2.5-7B-Instruct", 0, 1},
+	}
+
+	for _, tt := range tests {
+		if got := TensorParallelSize(tt.model, tt.gpus); got != tt.want {
+			t.Errorf("TensorParallelSize(%q, %d) = %d, want %d", tt.model, tt.gpus, got, tt.want)
+		}
+	}
+}
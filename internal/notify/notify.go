@@ -0,0 +1,203 @@
+// Package notify 把关键事件推送到聊天工具或运维系统（Slack、企业 IM 机器人、自建 webhook）
+//
+// 小团队往往没有完整的 Prometheus + Alertmanager 告警链路，但 coordinator 切换、模型下载失败
+// 这类事情需要有人马上知道。operator 配置里列出 webhook，controller 遇到下面的事件时 POST 过去：
+//
+//	CoordinatorFailover  coordinator 所在节点故障，controller 强制切换
+//	DownloadFailed       agent 因为不可重试的错误（token 无效、磁盘满……）退出
+//	SLOViolated          SLO 从达标变成违反
+//	CrashLoop            有 Pod 处于 CrashLoopBackOff
+//
+// 同一个 LLMService 的同一条消息在 repeatInterval 内只发一次（CrashLoop 每次 reconcile 都会检测到）。
+// 发送是异步的，失败只打日志，不影响 reconcile。
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Event 是通知的事件类型
+type Event string
+
+const (
+	EventCoordinatorFailover Event = "CoordinatorFailover"
+	EventDownloadFailed      Event = "DownloadFailed"
+	EventSLOViolated         Event = "SLOViolated"
+	EventCrashLoop           Event = "CrashLoop"
+)
+
+// Events 是所有事件类型，配置校验用
+var Events = []Event{EventCoordinatorFailover, EventDownloadFailed, EventSLOViolated, EventCrashLoop}
+
+// 请求体格式
+const (
+	// FormatGeneric 直接 POST Notification 的 JSON
+	FormatGeneric = "generic"
+	// FormatSlack 是 Slack incoming webhook 的 {"text": "..."}，大部分 IM 机器人也兼容
+	FormatSlack = "slack"
+)
+
+// DefaultRepeatInterval 是同一条通知重复发送的最小间隔
+const DefaultRepeatInterval = time.Hour
+
+// sendTimeout 是一次 POST 的超时
+const sendTimeout = 10 * time.Second
+
+// Target 是一个 webhook
+type Target struct {
+	// Name 出现在日志里，方便区分是哪个 webhook 失败了
+	Name string `json:"name"`
+	// URL 是 webhook 地址（Slack 的 incoming webhook URL 本身就是凭据，注意 ConfigMap 的访问权限）
+	URL string `json:"url"`
+	// Format 是 generic（默认）或 slack
+	Format string `json:"format,omitempty"`
+	// Events 只发送这些事件，为空时发送全部
+	Events []Event `json:"events,omitempty"`
+}
+
+// Validate 检查 webhook 配置
+func (t Target) Validate() error {
+	u, err := url.Parse(t.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook %q: url must be an http(s) URL", t.Name)
+	}
+	switch t.Format {
+	case "", FormatGeneric, FormatSlack:
+	default:
+		return fmt.Errorf("webhook %q: format must be %s or %s, got %q", t.Name, FormatGeneric, FormatSlack, t.Format)
+	}
+	for _, e := range t.Events {
+		if !slices.Contains(Events, e) {
+			return fmt.Errorf("webhook %q: unknown event %q", t.Name, e)
+		}
+	}
+	return nil
+}
+
+// wants 判断 webhook 是否订阅了事件
+func (t Target) wants(e Event) bool {
+	return len(t.Events) == 0 || slices.Contains(t.Events, e)
+}
+
+// Notification 是一条通知，也是 generic 格式的请求体
+type Notification struct {
+	Event     Event     `json:"event"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Message   string    `json:"message"`
+	Time      time.Time `json:"time"`
+}
+
+// text 是给人看的一行文字
+func (n Notification) text() string {
+	return fmt.Sprintf("[kubeinfer] %s %s/%s: %s", n.Event, n.Namespace, n.Name, n.Message)
+}
+
+// Notifier 发送通知并去重，零值可以直接使用
+type Notifier struct {
+	// HTTP 为 nil 时使用带超时的默认 client
+	HTTP *http.Client
+
+	mu   sync.Mutex
+	sent map[string]time.Time
+
+	// now 方便测试时注入时间
+	now func() time.Time
+}
+
+// Notify 把 n 异步发送给订阅了这个事件的 targets，repeat 内重复的通知直接丢弃
+func (m *Notifier) Notify(ctx context.Context, targets []Target, repeat time.Duration, n Notification) {
+	if len(targets) == 0 || !m.firstInInterval(n, repeat) {
+		return
+	}
+	l := log.FromContext(ctx)
+	for _, t := range targets {
+		if !t.wants(n.Event) {
+			continue
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			defer cancel()
+			if err := m.Send(ctx, t, n); err != nil {
+				l.Error(err, "Failed to send notification", "webhook", t.Name, "event", n.Event)
+			}
+		}()
+	}
+}
+
+// firstInInterval 记录发送时间，repeat 内已经发过同样的通知时返回 false
+func (m *Notifier) firstInInterval(n Notification, repeat time.Duration) bool {
+	if repeat <= 0 {
+		repeat = DefaultRepeatInterval
+	}
+	now := m.clock()
+	key := string(n.Event) + "/" + n.Namespace + "/" + n.Name + "/" + n.Message
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.sent == nil {
+		m.sent = map[string]time.Time{}
+	}
+	if last, ok := m.sent[key]; ok && now.Sub(last) < repeat {
+		return false
+	}
+	m.sent[key] = now
+	// 顺便清理过期的记录，map 不会一直变大
+	for k, at := range m.sent {
+		if now.Sub(at) >= repeat {
+			delete(m.sent, k)
+		}
+	}
+	return true
+}
+
+func (m *Notifier) clock() time.Time {
+	if m.now != nil {
+		return m.now()
+	}
+	return time.Now()
+}
+
+// Send 同步地把 n 发给 t，非 2xx 响应返回错误
+func (m *Notifier) Send(ctx context.Context, t Target, n Notification) error {
+	if n.Time.IsZero() {
+		n.Time = m.clock().UTC()
+	}
+	var payload any = n
+	if t.Format == FormatSlack {
+		payload = map[string]string{"text": n.text()}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := m.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: sendTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned status %d", t.Name, resp.StatusCode)
+	}
+	return nil
+}
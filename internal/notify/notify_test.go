@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestSend 测试 generic 和 slack 两种请求体
+func TestSend(t *testing.T) {
+	var got map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = nil
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	m := &Notifier{}
+	n := Notification{Event: EventCrashLoop, Namespace: "default", Name: "qwen", Message: "pods in CrashLoopBackOff: qwen-0"}
+	ctx := context.Background()
+
+	if err := m.Send(ctx, Target{Name: "generic", URL: srv.URL}, n); err != nil {
+		t.Fatal(err)
+	}
+	if got["event"] != "CrashLoop" || got["name"] != "qwen" || got["time"] == "" {
+		t.Errorf("generic payload = %v", got)
+	}
+
+	if err := m.Send(ctx, Target{Name: "slack", URL: srv.URL, Format: FormatSlack}, n); err != nil {
+		t.Fatal(err)
+	}
+	if got["text"] != "[kubeinfer] CrashLoop default/qwen: pods in CrashLoopBackOff: qwen-0" {
+		t.Errorf("slack payload = %v", got)
+	}
+
+	if err := m.Send(ctx, Target{Name: "broken", URL: srv.URL + "/fail"}, n); err == nil {
+		t.Error("expected an error for a 500 response")
+	}
+}
+
+// TestFirstInInterval 测试同一条通知在 repeatInterval 内只发一次
+func TestFirstInInterval(t *testing.T) {
+	now := time.Date(2026, 5, 1, 10, 0, 0, 0, time.UTC)
+	m := &Notifier{now: func() time.Time { return now }}
+	n := Notification{Event: EventCoordinatorFailover, Namespace: "default", Name: "qwen", Message: "node gpu-1 is NotReady"}
+
+	if !m.firstInInterval(n, time.Hour) {
+		t.Fatal("first notification must be sent")
+	}
+	if m.firstInInterval(n, time.Hour) {
+		t.Error("duplicate within the interval must be dropped")
+	}
+	other := n
+	other.Message = "node gpu-2 is NotReady"
+	if !m.firstInInterval(other, time.Hour) {
+		t.Error("a different message must be sent")
+	}
+	now = now.Add(time.Hour)
+	if !m.firstInInterval(n, time.Hour) {
+		t.Error("the notification must be sent again after the interval")
+	}
+}
+
+func TestTargetWants(t *testing.T) {
+	all := Target{}
+	only := Target{Events: []Event{EventSLOViolated}}
+	if !all.wants(EventCrashLoop) || !only.wants(EventSLOViolated) || only.wants(EventCrashLoop) {
+		t.Error("event filter mismatch")
+	}
+}
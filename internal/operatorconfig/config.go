@@ -0,0 +1,253 @@
+// Package operatorconfig 是 manager 的组件配置（component config）
+//
+// 以前默认镜像、gateway 镜像、费用统计等默认值散落在常量和命令行参数里，改一个值要重新部署 manager。
+// 现在统一放在 ConfigMap kubeinfer-operator-config 的 config.yaml 里，挂载到 manager 容器：
+//
+//	defaultImage: vllm/vllm-openai:v0.6.3
+//	devImage: registry.example.com/kubeinfer-agent:llamacpp-cpu
+//	gateway:
+//	  image: registry.example.com/kubeinfer:v0.3.0
+//	  enabledByDefault: true
+//	distribution:
+//	  defaultTopology: zone
+//	  agentImage: registry.example.com/kubeinfer:v0.3.0
+//	  maxConcurrentDownloads: 4
+//	lease:
+//	  duration: 30s
+//	  retryPeriod: 5s
+//	agentClient:
+//	  qps: 20
+//	  burst: 40
+//	costReport:
+//	  enabled: true
+//	  gpuHourlyCost: 2.5
+//	  currency: USD
+//	  prometheusURL: http://prometheus.monitoring:9090
+//	slo:
+//	  prometheusURL: http://prometheus.monitoring:9090
+//	autoscaling:
+//	  prometheusURL: http://prometheus.monitoring:9090
+//	audit:
+//	  sink: configMap
+//	  maxEntries: 100
+//	notifications:
+//	  repeatInterval: 1h
+//	  webhooks:
+//	  - name: ops-slack
+//	    url: https://hooks.slack.com/services/...
+//	    format: slack
+//	    events: [CoordinatorFailover, DownloadFailed]
+//
+// 命令行参数（--gateway-image、--enable-cost-report 等）是基础配置，文件里写了的字段覆盖参数。
+// manager 运行期间定期重新读取文件（kubelet 更新挂载的 ConfigMap 大约需要一分钟），
+// 下一次 reconcile 就会用新配置，不需要重启。
+package operatorconfig
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/audit"
+	"github.com/Moore-Z/kubeinfer/internal/notify"
+)
+
+// DefaultGatewayImage 是 gateway 默认镜像（和 manager 同一个镜像，入口是 /gateway）
+const DefaultGatewayImage = "controller:latest"
+
+// DefaultAgentImage 是 initContainer 模式下拉取模型、运行 model server 的镜像
+// （manager 镜像里也带了 /agent）
+const DefaultAgentImage = "controller:latest"
+
+// agent 的默认选举参数（internal/agent/coordinator/election.go）
+const (
+	agentLeaseDuration = 15 * time.Second
+	agentRetryPeriod   = 2 * time.Second
+)
+
+// Config 是 manager 的配置
+type Config struct {
+	// DefaultImage 是 spec.image 为空时使用的镜像
+	DefaultImage string `json:"defaultImage,omitempty"`
+	// DevImage 是 spec.devMode 为 true、spec.image 为空时使用的镜像（agent + CPU 版 llama-server）
+	DevImage string `json:"devImage,omitempty"`
+
+	Gateway       GatewayConfig       `json:"gateway,omitempty"`
+	Distribution  DistributionConfig  `json:"distribution,omitempty"`
+	Lease         LeaseConfig         `json:"lease,omitempty"`
+	AgentClient   KubeClientConfig    `json:"agentClient,omitempty"`
+	CostReport    CostReportConfig    `json:"costReport,omitempty"`
+	SLO           SLOConfig           `json:"slo,omitempty"`
+	Autoscaling   AutoscalingConfig   `json:"autoscaling,omitempty"`
+	Audit         AuditConfig         `json:"audit,omitempty"`
+	Notifications NotificationsConfig `json:"notifications,omitempty"`
+}
+
+// GatewayConfig 是 gateway 的默认值
+type GatewayConfig struct {
+	// Image 是 gateway Deployment 使用的镜像
+	Image string `json:"image,omitempty"`
+	// EnabledByDefault 为 true 时没有设置 spec.gateway 的 LLMService 也部署 gateway
+	EnabledByDefault bool `json:"enabledByDefault,omitempty"`
+}
+
+// DistributionConfig 是模型分发的默认值
+type DistributionConfig struct {
+	// DefaultTopology 是没有设置 spec.distribution.topology 时的拓扑（flat/zone）
+	DefaultTopology string `json:"defaultTopology,omitempty"`
+	// AgentImage 是 spec.distribution.mode=initContainer 时 model-fetch/model-server 容器的镜像
+	AgentImage string `json:"agentImage,omitempty"`
+	// MaxConcurrentDownloads 是整个集群同时从上游（HuggingFace）下载的 coordinator 数，
+	// 很多 LLMService 同时创建时避免触发 HuggingFace 的限流；0 表示不限制
+	MaxConcurrentDownloads int `json:"maxConcurrentDownloads,omitempty"`
+}
+
+// LeaseConfig 是 agent 选举 coordinator 的时间参数，不设置时用 agent 的默认值（15s/2s）
+type LeaseConfig struct {
+	// Duration 是 lease 有效期，coordinator 失联后最多这么久会选出新的 coordinator
+	Duration metav1.Duration `json:"duration,omitempty"`
+	// RetryPeriod 是续约/抢占的间隔，必须小于 Duration
+	RetryPeriod metav1.Duration `json:"retryPeriod,omitempty"`
+	// SettlePeriod 是同一个 coordinator 连续持有 lease 多久之后 CoordinatorStable 才变成 True（默认 30s），
+	// 在这之前 controller 不按新的 coordinator 调整 Pod 的 deletion cost
+	SettlePeriod metav1.Duration `json:"settlePeriod,omitempty"`
+}
+
+// KubeClientConfig 是 agent 访问 API server 的客户端限流，不设置时用 client-go 的默认值（5 QPS/10 burst）
+//
+// agent 多的集群里 API server 返回 429 时调小，agent 自己在客户端排队时调大
+// （看 kubeinfer_kube_client_throttled_requests_total 的 source）
+type KubeClientConfig struct {
+	QPS   float32 `json:"qps,omitempty"`
+	Burst int     `json:"burst,omitempty"`
+}
+
+// CostReportConfig 是费用统计（status.costReport 和 kubeinfer_llmservice_* 指标）的配置
+type CostReportConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// GPUHourlyCost 是一张 GPU 一小时的估算费用
+	GPUHourlyCost float64 `json:"gpuHourlyCost,omitempty"`
+	// Currency 是写进 costReport 的币种
+	Currency string `json:"currency,omitempty"`
+	// PrometheusURL 用于读取 DCGM 的 GPU 利用率，为空时不统计利用率
+	PrometheusURL string `json:"prometheusURL,omitempty"`
+}
+
+// SLOConfig 是 spec.slo 评估的配置
+type SLOConfig struct {
+	// PrometheusURL 用于读取 gateway 的请求指标，为空时无法评估 SLO（SLOViolated 为 Unknown）
+	PrometheusURL string `json:"prometheusURL,omitempty"`
+}
+
+// AutoscalingConfig 是 spec.autoscaling.mode=KEDA 的配置
+type AutoscalingConfig struct {
+	// PrometheusURL 写进生成的 ScaledObject，KEDA 从这里查询 gateway 指标
+	// 是 KEDA 访问的地址（不是 manager），为空时使用 slo.prometheusURL
+	PrometheusURL string `json:"prometheusURL,omitempty"`
+}
+
+// AuditConfig 是审计记录（internal/audit）的配置，审计日志总是会输出
+type AuditConfig struct {
+	// Sink 是日志以外的落地位置：configMap（每个 LLMService 一个 <name>-audit）或 event，为空时只输出日志
+	Sink string `json:"sink,omitempty"`
+	// MaxEntries 是 configMap sink 每个 LLMService 保留的条数，默认 100
+	MaxEntries int `json:"maxEntries,omitempty"`
+}
+
+// NotificationsConfig 是关键事件通知（internal/notify）的配置，没有 webhook 时不发送
+type NotificationsConfig struct {
+	// RepeatInterval 是同一条通知重复发送的最小间隔，默认 1h
+	RepeatInterval metav1.Duration `json:"repeatInterval,omitempty"`
+	// Webhooks 是接收通知的地址
+	Webhooks []notify.Target `json:"webhooks,omitempty"`
+}
+
+// Default 返回内置默认值，和引入配置文件之前的行为一致
+func Default() Config {
+	return Config{
+		DefaultImage: aiv1.DefaultImage,
+		DevImage:     aiv1.DefaultDevImage,
+		Gateway:      GatewayConfig{Image: DefaultGatewayImage},
+		Distribution: DistributionConfig{DefaultTopology: aiv1.TopologyFlat, AgentImage: DefaultAgentImage},
+		CostReport:   CostReportConfig{Currency: "USD"},
+	}
+}
+
+// Parse 把 data（config.yaml 的内容）覆盖到 base 上，没写的字段保留 base 的值
+//
+// 不认识的字段直接报错，拼错的字段名不会被静默忽略
+func Parse(base Config, data []byte) (Config, error) {
+	cfg := base
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid operator config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Validate 检查配置
+func (c Config) Validate() error {
+	if c.DefaultImage == "" {
+		return fmt.Errorf("defaultImage must not be empty")
+	}
+	if c.DevImage == "" {
+		return fmt.Errorf("devImage must not be empty")
+	}
+	if c.Gateway.Image == "" {
+		return fmt.Errorf("gateway.image must not be empty")
+	}
+	if c.Distribution.AgentImage == "" {
+		return fmt.Errorf("distribution.agentImage must not be empty")
+	}
+	switch c.Distribution.DefaultTopology {
+	case "", aiv1.TopologyFlat, aiv1.TopologyZone:
+	default:
+		return fmt.Errorf("distribution.defaultTopology must be %s or %s, got %q",
+			aiv1.TopologyFlat, aiv1.TopologyZone, c.Distribution.DefaultTopology)
+	}
+	if c.Distribution.MaxConcurrentDownloads < 0 {
+		return fmt.Errorf("distribution.maxConcurrentDownloads must not be negative")
+	}
+	d, retry := c.Lease.Duration.Duration, c.Lease.RetryPeriod.Duration
+	if d < 0 || retry < 0 || c.Lease.SettlePeriod.Duration < 0 {
+		return fmt.Errorf("lease timings must not be negative")
+	}
+	// 只设置了其中一个时和 agent 的默认值比较
+	if d == 0 {
+		d = agentLeaseDuration
+	}
+	if retry == 0 {
+		retry = agentRetryPeriod
+	}
+	if retry >= d {
+		return fmt.Errorf("lease.retryPeriod (%s) must be shorter than lease.duration (%s)", retry, d)
+	}
+	if c.AgentClient.QPS < 0 || c.AgentClient.Burst < 0 {
+		return fmt.Errorf("agentClient.qps and agentClient.burst must not be negative")
+	}
+	if c.CostReport.GPUHourlyCost < 0 {
+		return fmt.Errorf("costReport.gpuHourlyCost must not be negative")
+	}
+	switch c.Audit.Sink {
+	case audit.SinkNone, audit.SinkConfigMap, audit.SinkEvent:
+	default:
+		return fmt.Errorf("audit.sink must be %s or %s, got %q", audit.SinkConfigMap, audit.SinkEvent, c.Audit.Sink)
+	}
+	if c.Audit.MaxEntries < 0 {
+		return fmt.Errorf("audit.maxEntries must not be negative")
+	}
+	if c.Notifications.RepeatInterval.Duration < 0 {
+		return fmt.Errorf("notifications.repeatInterval must not be negative")
+	}
+	for _, w := range c.Notifications.Webhooks {
+		if err := w.Validate(); err != nil {
+			return fmt.Errorf("notifications: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package operatorconfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestParse(t *testing.T) {
+	base := Default()
+	base.Gateway.Image = "registry.example.com/kubeinfer:v1"
+	base.CostReport.Enabled = true
+
+	tests := []struct {
+		name    string
+		data    string
+		check   func(t *testing.T, c Config)
+		wantErr string
+	}{
+		{
+			name: "empty file keeps the base",
+			data: "",
+			check: func(t *testing.T, c Config) {
+				if !reflect.DeepEqual(c, base) {
+					t.Errorf("got %+v, want base %+v", c, base)
+				}
+			},
+		},
+		{
+			name: "fields override the base, others are kept",
+			data: "defaultImage: vllm/vllm-openai:v0.6.3\ngateway:\n  enabledByDefault: true\nlease:\n  duration: 30s\n",
+			check: func(t *testing.T, c Config) {
+				if c.DefaultImage != "vllm/vllm-openai:v0.6.3" || !c.Gateway.EnabledByDefault {
+					t.Errorf("overrides not applied: %+v", c)
+				}
+				if c.Gateway.Image != base.Gateway.Image || !c.CostReport.Enabled {
+					t.Errorf("fields missing from the file must keep the base value: %+v", c)
+				}
+				if c.Lease.Duration.Duration != 30*time.Second {
+					t.Errorf("lease.duration = %v", c.Lease.Duration)
+				}
+			},
+		},
+		{name: "unknown field", data: "defaultImge: typo\n", wantErr: "unknown field"},
+		{name: "bad topology", data: "distribution:\n  defaultTopology: mesh\n", wantErr: "defaultTopology"},
+		{name: "negative download limit", data: "distribution:\n  maxConcurrentDownloads: -1\n", wantErr: "maxConcurrentDownloads"},
+		{name: "retry not shorter than duration", data: "lease:\n  duration: 5s\n  retryPeriod: 5s\n", wantErr: "retryPeriod"},
+		{name: "retry longer than the agent default duration", data: "lease:\n  retryPeriod: 20s\n", wantErr: "retryPeriod"},
+		{name: "negative settle period", data: "lease:\n  settlePeriod: -1s\n", wantErr: "lease timings"},
+		{name: "negative agent client qps", data: "agentClient:\n  qps: -1\n", wantErr: "agentClient"},
+		{name: "empty image", data: "defaultImage: \"\"\n", wantErr: "defaultImage"},
+		{name: "unknown audit sink", data: "audit:\n  sink: syslog\n", wantErr: "audit.sink"},
+		{
+			name: "notification webhooks",
+			data: "notifications:\n  webhooks:\n  - name: ops\n    url: https://hooks.example.com/x\n    format: slack\n    events: [CrashLoop]\n",
+			check: func(t *testing.T, c Config) {
+				if len(c.Notifications.Webhooks) != 1 || c.Notifications.Webhooks[0].Format != "slack" {
+					t.Errorf("webhooks = %+v", c.Notifications.Webhooks)
+				}
+			},
+		},
+		{name: "unknown notification event", data: "notifications:\n  webhooks:\n  - name: ops\n    url: https://hooks.example.com/x\n    events: [Reboot]\n", wantErr: "unknown event"},
+		{name: "notification url without scheme", data: "notifications:\n  webhooks:\n  - name: ops\n    url: hooks.example.com\n", wantErr: "http(s) URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := Parse(base, []byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("got err %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t, c)
+		})
+	}
+}
+
+func TestStoreReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	// ConfigMap 还没创建：用 base
+	s, err := NewStore(Default(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Get().DefaultImage; got != aiv1.DefaultImage {
+		t.Errorf("without a file DefaultImage = %q", got)
+	}
+	if changed, _ := s.reload(); changed {
+		t.Error("a missing file must not be reported as a change every time")
+	}
+
+	if err := os.WriteFile(path, []byte("defaultImage: custom:v1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := s.reload(); !changed || err != nil {
+		t.Fatalf("reload = %v, %v", changed, err)
+	}
+	if got := s.Get().DefaultImage; got != "custom:v1" {
+		t.Errorf("after reload DefaultImage = %q", got)
+	}
+
+	// 改坏了：保留之前的配置
+	if err := os.WriteFile(path, []byte("defaultImage: [\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.reload(); err == nil {
+		t.Error("invalid file must be reported")
+	}
+	if got := s.Get().DefaultImage; got != "custom:v1" {
+		t.Errorf("invalid file must keep the previous config, got %q", got)
+	}
+
+	var nilStore *Store
+	if !reflect.DeepEqual(nilStore.Get(), Default()) {
+		t.Error("nil store must return the built-in defaults")
+	}
+}
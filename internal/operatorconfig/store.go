@@ -0,0 +1,127 @@
+package operatorconfig
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// DefaultReloadInterval 是重新读取配置文件的间隔
+const DefaultReloadInterval = 10 * time.Second
+
+// Store 保存当前生效的配置，并在文件变化时热加载
+//
+// 实现了 manager.Runnable，mgr.Add(store) 之后随 manager 启动。
+// 为什么轮询而不是 inotify？
+// kubelet 更新 ConfigMap 卷是替换 ..data 符号链接，inotify 监听文件本身收不到事件；
+// 配置文件很小，每 10 秒读一次没有成本。
+type Store struct {
+	path     string
+	base     Config
+	interval time.Duration
+
+	mu      sync.RWMutex
+	current Config
+	raw     []byte
+}
+
+// NewStore 读取 path 创建 Store，path 为空或者文件不存在时使用 base
+//
+// 启动时配置无效直接返回错误（manager 起不来比带着错误配置跑更容易发现）
+func NewStore(base Config, path string) (*Store, error) {
+	s := &Store{path: path, base: base, interval: DefaultReloadInterval, current: base}
+	if err := base.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewStaticStore 返回固定配置的 Store，不读文件（pkg/render 离线渲染时用）
+func NewStaticStore(cfg Config) *Store {
+	return &Store{base: cfg, current: cfg, interval: DefaultReloadInterval}
+}
+
+// Get 返回当前配置；Store 为 nil 时返回 Default()（测试里直接 new 的 reconciler）
+func (s *Store) Get() Config {
+	if s == nil {
+		return Default()
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// reload 重新读取文件，内容有变化时返回 true
+func (s *Store) reload() (bool, error) {
+	if s.path == "" {
+		return false, nil
+	}
+	raw, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		// ConfigMap 是 optional 的，没有创建时用命令行参数
+		raw = nil
+	} else if err != nil {
+		return false, err
+	}
+
+	s.mu.RLock()
+	unchanged := s.raw != nil && bytes.Equal(raw, s.raw)
+	s.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cfg := s.base
+	if len(raw) > 0 {
+		if cfg, err = Parse(s.base, raw); err != nil {
+			return false, err
+		}
+	}
+	s.mu.Lock()
+	s.current = cfg
+	// 记录空切片而不是 nil，文件一直不存在时不会每次都当成变化
+	s.raw = append([]byte{}, raw...)
+	s.mu.Unlock()
+	return true, nil
+}
+
+// Start 定期重新读取配置文件，直到 ctx 被取消
+//
+// 读取或者解析失败时保留之前的配置，只打日志
+func (s *Store) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("operator-config")
+	if s.path == "" {
+		<-ctx.Done()
+		return nil
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			changed, err := s.reload()
+			if err != nil {
+				logger.Error(err, "Ignoring invalid operator config, keeping the previous one", "path", s.path)
+				continue
+			}
+			if changed {
+				logger.Info("Reloaded operator config", "path", s.path)
+			}
+		}
+	}
+}
+
+// NeedLeaderElection 返回 false：每个 manager 副本都要热加载，切换 leader 后配置已经是最新的
+func (s *Store) NeedLeaderElection() bool {
+	return false
+}
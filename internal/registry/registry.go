@@ -0,0 +1,252 @@
+// Package registry 把镜像 tag 解析成 digest（spec.imagePinning 用）
+//
+// 只实现了 Docker Registry HTTP API V2 里的一个请求：
+//
+//	HEAD /v2/<repository>/manifests/<tag>  →  Docker-Content-Digest: sha256:...
+//
+// Docker Hub、GHCR、Quay 等公共仓库需要先拿一个匿名 token（401 响应的 WWW-Authenticate 里给了地址）。
+// HEAD 不计入 Docker Hub 的拉取次数限制。私有仓库的认证没有实现，解析失败时 controller 继续用 tag。
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// dockerHubRegistry 是 docker.io 镜像实际的仓库地址
+	dockerHubRegistry = "registry-1.docker.io"
+	defaultTag        = "latest"
+)
+
+// manifestTypes 是 HEAD manifest 时接受的类型：多架构镜像返回 index 本身的 digest，
+// 和 kubelet 按 tag 拉取时解析到的 digest 一致
+var manifestTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Reference 是解析后的镜像引用，例如 vllm/vllm-openai:v0.6.3
+// → {Registry: registry-1.docker.io, Repository: vllm/vllm-openai, Tag: v0.6.3}
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference 解析镜像引用，没有写仓库时是 Docker Hub，没有写 tag 时是 latest
+func ParseReference(image string) (Reference, error) {
+	var ref Reference
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.Digest = name[:i], name[i+1:]
+	}
+	// tag 在最后一个 / 之后（registry 的端口号里也有冒号）
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Tag = name[:i], name[i+1:]
+	}
+	if name == "" {
+		return Reference{}, fmt.Errorf("invalid image reference %q", image)
+	}
+
+	// 第一段带 . 或 :（或者是 localhost）才是仓库地址，否则是 Docker Hub 上的用户名
+	first, rest, found := strings.Cut(name, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		ref.Registry, ref.Repository = first, rest
+	} else {
+		ref.Registry, ref.Repository = dockerHubRegistry, name
+	}
+	if ref.Registry == "docker.io" || ref.Registry == "index.docker.io" {
+		ref.Registry = dockerHubRegistry
+	}
+	if ref.Registry == dockerHubRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+	return ref, nil
+}
+
+// HasDigest 判断镜像引用是否已经带了 digest
+func HasDigest(image string) bool {
+	return strings.Contains(image, "@")
+}
+
+// Pin 返回固定到 digest 的镜像引用：vllm/vllm-openai:v0.6.3@sha256:...
+//
+// 保留 tag 方便人看，kubelet 只按 digest 拉取
+func Pin(image, digest string) string {
+	if digest == "" || HasDigest(image) {
+		return image
+	}
+	return image + "@" + digest
+}
+
+// Resolver 查询镜像仓库
+type Resolver struct {
+	httpClient *http.Client
+}
+
+// NewResolver 创建 Resolver
+func NewResolver() *Resolver {
+	return &Resolver{httpClient: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// NewResolverWithClient 用指定的 http.Client 创建 Resolver（测试用自签名证书的仓库）
+func NewResolverWithClient(c *http.Client) *Resolver {
+	return &Resolver{httpClient: c}
+}
+
+// Resolve 返回镜像 tag 当前指向的 digest，已经带 digest 的引用直接返回
+func (r *Resolver) Resolve(ctx context.Context, image string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" {
+		return ref.Digest, nil
+	}
+
+	u := fmt.Sprintf("https://%s/v2/%s/manifests/%s", ref.Registry, ref.Repository, ref.Tag)
+	resp, err := r.manifest(ctx, http.MethodHead, u, "")
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	var token string
+	if resp.StatusCode == http.StatusUnauthorized {
+		if token, err = r.token(ctx, resp.Header.Get("WWW-Authenticate")); err != nil {
+			return "", fmt.Errorf("failed to authenticate to %s: %w", ref.Registry, err)
+		}
+		if resp, err = r.manifest(ctx, http.MethodHead, u, token); err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if d := resp.Header.Get("Docker-Content-Digest"); d != "" {
+			return d, nil
+		}
+		// 有的仓库 HEAD 不返回 Docker-Content-Digest，GET 下来自己算
+		return r.digestFromBody(ctx, u, token)
+	case http.StatusNotFound:
+		return "", fmt.Errorf("image %s not found", image)
+	default:
+		return "", fmt.Errorf("unexpected registry status code %d for %s", resp.StatusCode, image)
+	}
+}
+
+// manifest 请求 manifest，token 为空时不带认证
+func (r *Resolver) manifest(ctx context.Context, method, u, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build registry request: %w", err)
+	}
+	req.Header.Set("Accept", strings.Join(manifestTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query registry: %w", err)
+	}
+	return resp, nil
+}
+
+// digestFromBody GET manifest，digest 是内容的 sha256
+func (r *Resolver) digestFromBody(ctx context.Context, u, token string) (string, error) {
+	resp, err := r.manifest(ctx, http.MethodGet, u, token)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected registry status code %d", resp.StatusCode)
+	}
+	if d := resp.Header.Get("Docker-Content-Digest"); d != "" {
+		return d, nil
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, resp.Body); err != nil {
+		return "", fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// token 按 WWW-Authenticate 拿匿名 token：
+//
+//	Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:vllm/vllm-openai:pull"
+func (r *Resolver) token(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported auth challenge %q", challenge)
+	}
+	attrs := parseChallenge(params)
+	realm, err := url.Parse(attrs["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid auth realm %q", attrs["realm"])
+	}
+	q := realm.Query()
+	for _, k := range []string{"service", "scope"} {
+		if v := attrs[k]; v != "" {
+			q.Set(k, v)
+		}
+	}
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d (private registries are not supported)", resp.StatusCode)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode token: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// parseChallenge 解析 key="value",key="value"
+func parseChallenge(s string) map[string]string {
+	attrs := map[string]string{}
+	for s != "" {
+		var key, value string
+		key, s, _ = strings.Cut(strings.TrimLeft(s, " ,"), "=")
+		if strings.HasPrefix(s, `"`) {
+			value, s, _ = strings.Cut(s[1:], `"`)
+		} else {
+			value, s, _ = strings.Cut(s, ",")
+		}
+		if key != "" {
+			attrs[strings.ToLower(strings.TrimSpace(key))] = value
+		}
+	}
+	return attrs
+}
@@ -0,0 +1,78 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		image string
+		want  Reference
+	}{
+		{image: "vllm/vllm-openai:v0.6.3", want: Reference{Registry: dockerHubRegistry, Repository: "vllm/vllm-openai", Tag: "v0.6.3"}},
+		{image: "ubuntu", want: Reference{Registry: dockerHubRegistry, Repository: "library/ubuntu", Tag: "latest"}},
+		{image: "docker.io/library/ubuntu:24.04", want: Reference{Registry: dockerHubRegistry, Repository: "library/ubuntu", Tag: "24.04"}},
+		{image: "ghcr.io/huggingface/text-generation-inference:2.4", want: Reference{Registry: "ghcr.io", Repository: "huggingface/text-generation-inference", Tag: "2.4"}},
+		// 端口号里的冒号不是 tag
+		{image: "registry.local:5000/kubeinfer", want: Reference{Registry: "registry.local:5000", Repository: "kubeinfer", Tag: "latest"}},
+		{image: "localhost/kubeinfer:dev@sha256:abc", want: Reference{Registry: "localhost", Repository: "kubeinfer", Tag: "dev", Digest: "sha256:abc"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseReference(tt.image)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseReference(%q) = %+v, %v, want %+v", tt.image, got, err, tt.want)
+		}
+	}
+
+	if got := Pin("vllm/vllm-openai:v0.6.3", "sha256:abc"); got != "vllm/vllm-openai:v0.6.3@sha256:abc" {
+		t.Errorf("Pin = %q", got)
+	}
+	if got := Pin("vllm/vllm-openai@sha256:abc", "sha256:def"); got != "vllm/vllm-openai@sha256:abc" {
+		t.Errorf("Pin of a digest reference = %q, want it unchanged", got)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	const digest = "sha256:4f2a"
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			if r.URL.Query().Get("scope") != "repository:vllm/vllm-openai:pull" {
+				http.Error(w, "bad scope", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"token":"anonymous"}`)
+		case r.Header.Get("Authorization") != "Bearer anonymous":
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(
+				`Bearer realm="%s/token",service="registry.test",scope="repository:vllm/vllm-openai:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Method != http.MethodHead || !strings.Contains(r.Header.Get("Accept"), "image.index"):
+			http.Error(w, "bad request", http.StatusBadRequest)
+		case r.URL.Path == "/v2/vllm/vllm-openai/manifests/latest":
+			w.Header().Set("Docker-Content-Digest", digest)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	r := NewResolverWithClient(server.Client())
+	got, err := r.Resolve(context.Background(), host+"/vllm/vllm-openai")
+	if err != nil || got != digest {
+		t.Fatalf("Resolve = %q, %v, want %s", got, err, digest)
+	}
+	if _, err := r.Resolve(context.Background(), host+"/vllm/vllm-openai:missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Resolve of a missing tag = %v, want not found", err)
+	}
+	// 已经带 digest 的不查询
+	if got, err := r.Resolve(context.Background(), "unreachable.invalid/x@sha256:1"); err != nil || got != "sha256:1" {
+		t.Errorf("Resolve of a digest reference = %q, %v", got, err)
+	}
+}
@@ -0,0 +1,159 @@
+// Package reporting 计算每个 LLMService 的 GPU 用量、利用率和估算费用
+//
+// 平台团队需要 chargeback 数据：哪个团队的模型用了多少 GPU 小时、花了多少钱。
+// 这个包负责：
+// 1. 每次 reconcile 时把"上次统计到现在"这段时间的 GPU 秒数累加进 Status.CostReport
+// 2. 从 DCGM 指标读取 GPU 利用率，按 GPU 时间加权
+// 3. 按单价估算费用（每段时间按当时的单价累加），同时暴露成 Prometheus 指标
+package reporting
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/pkg/metrics"
+)
+
+// DefaultInterval 是开启费用统计时的默认 requeue 间隔
+const DefaultInterval = time.Minute
+
+// UtilizationSource 提供 LLMService 的 GPU 利用率
+type UtilizationSource interface {
+	// GPUUtilization 返回该 LLMService 所有 Pod 的平均 GPU 利用率（0-100）
+	// found=false 表示暂时没有数据
+	GPUUtilization(ctx context.Context, namespace, name string) (value float64, found bool, err error)
+}
+
+// PrometheusUtilization 从 Prometheus 查询 GPU 利用率
+//
+// 使用 DCGM exporter 的 DCGM_FI_DEV_GPU_UTIL。集群没装 DCGM 时返回 found=false，平均利用率保持不变；
+// 不用 vLLM 的 vllm:gpu_cache_usage_perc 代替，那是 KV cache 占用率，不是 GPU 利用率。
+type PrometheusUtilization struct {
+	Client *PrometheusClient
+}
+
+// GPUUtilization 实现 UtilizationSource
+func (p *PrometheusUtilization) GPUUtilization(ctx context.Context, namespace, name string) (float64, bool, error) {
+	// Pod 名称格式：<llmservice>-deployment-<hash>-<id>
+	selector := fmt.Sprintf(`namespace=%q,pod=~%q`, namespace, name+"-deployment-.*")
+
+	return p.Client.QueryScalar(ctx, fmt.Sprintf("avg(DCGM_FI_DEV_GPU_UTIL{%s})", selector))
+}
+
+// CostReporter 负责累计 Status.CostReport
+type CostReporter struct {
+	// HourlyCostPerGPU 每张 GPU 每小时的单价
+	HourlyCostPerGPU float64
+	// Currency 费用单位，例如 "USD"
+	Currency string
+	// Utilization 可以为 nil，此时平均利用率保持不变
+	Utilization UtilizationSource
+	// Interval 是 reconcile 的 requeue 间隔，决定了统计精度
+	Interval time.Duration
+
+	// now 方便测试时注入时间
+	now func() time.Time
+}
+
+// NewCostReporter 创建 CostReporter
+func NewCostReporter(hourlyCostPerGPU float64, currency string, utilization UtilizationSource) *CostReporter {
+	return &CostReporter{
+		HourlyCostPerGPU: hourlyCostPerGPU,
+		Currency:         currency,
+		Utilization:      utilization,
+		Interval:         DefaultInterval,
+		now:              time.Now,
+	}
+}
+
+// Update 把上次统计到现在这段时间的用量累加进 llm.Status.CostReport，并更新指标
+//
+// 调用方负责之后调用 Status().Update() 持久化。
+func (r *CostReporter) Update(ctx context.Context, llm *aiv1.LLMService, readyReplicas int32) {
+	l := log.FromContext(ctx)
+	now := metav1.NewTime(r.now())
+
+	report := llm.Status.CostReport
+	if report == nil {
+		// 第一次统计：只记录起点，不累计。截到整秒，和 Status 序列化后的精度一致
+		now = metav1.NewTime(now.Truncate(time.Second))
+		report = &aiv1.CostReport{
+			GPUHours:      "0.00",
+			EstimatedCost: "0.00",
+			Currency:      r.Currency,
+			WindowStart:   now,
+		}
+		report.LastUpdateTime = now
+		llm.Status.CostReport = report
+		r.recordMetrics(llm, report)
+		return
+	}
+
+	// 只统计整秒，LastUpdateTime 也只前进这么多，不足一秒的部分留到下一次；
+	// 否则两次 reconcile 间隔不到一秒时每次都算 0，费用会一直少算
+	seconds := int64(now.Sub(report.LastUpdateTime.Time) / time.Second)
+	if seconds <= 0 {
+		return
+	}
+
+	gpus := int64(readyReplicas) * int64(llm.Spec.GpuPerReplica)
+	gpuSeconds := gpus * seconds
+
+	// 利用率：查不到时沿用历史平均值，避免把"没数据"当成"0% 利用率"
+	utilization := float64(report.AverageUtilizationPercent)
+	if r.Utilization != nil && gpuSeconds > 0 {
+		v, found, err := r.Utilization.GPUUtilization(ctx, llm.Namespace, llm.Name)
+		if err != nil {
+			l.Error(err, "Failed to query GPU utilization, using previous average")
+		} else if found {
+			utilization = v
+		}
+	}
+
+	accumulate(report, gpuSeconds, utilization, r.HourlyCostPerGPU)
+	report.Currency = r.Currency
+	report.LastUpdateTime = metav1.NewTime(report.LastUpdateTime.Add(time.Duration(seconds) * time.Second))
+	r.recordMetrics(llm, report)
+}
+
+// accumulate 把一段时间的用量累加进 report
+//
+// 费用按这段时间的单价累加进 EstimatedCostMicros，不用"总 GPU 小时 × 当前单价"：
+// 单价热更新后那样会把历史用量也按新价格重算
+func accumulate(report *aiv1.CostReport, gpuSeconds int64, utilizationPercent, hourlyCost float64) {
+	if report.EstimatedCostMicros == 0 && report.GPUSeconds > 0 {
+		// 升级前的 report 只有 EstimatedCost 字符串，从它接着累加
+		if v, err := strconv.ParseFloat(report.EstimatedCost, 64); err == nil {
+			report.EstimatedCostMicros = int64(math.Round(v * 1e6))
+		}
+	}
+
+	report.GPUSeconds += gpuSeconds
+	report.UtilizedGPUSeconds += int64(float64(gpuSeconds) * utilizationPercent / 100)
+	report.EstimatedCostMicros += int64(math.Round(float64(gpuSeconds) / 3600 * hourlyCost * 1e6))
+
+	hours := float64(report.GPUSeconds) / 3600
+	report.GPUHours = fmt.Sprintf("%.2f", hours)
+	report.EstimatedCost = fmt.Sprintf("%.2f", float64(report.EstimatedCostMicros)/1e6)
+	if report.GPUSeconds > 0 {
+		report.AverageUtilizationPercent = int32(report.UtilizedGPUSeconds * 100 / report.GPUSeconds)
+	}
+}
+
+func (r *CostReporter) recordMetrics(llm *aiv1.LLMService, report *aiv1.CostReport) {
+	hours := float64(report.GPUSeconds) / 3600
+	metrics.RecordCostReport(
+		llm.Namespace,
+		llm.Name,
+		hours,
+		float64(report.AverageUtilizationPercent)/100,
+		float64(report.EstimatedCostMicros)/1e6,
+	)
+}
@@ -0,0 +1,150 @@
+package reporting
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// fakeUtilization 返回固定的利用率
+type fakeUtilization struct {
+	value float64
+	found bool
+}
+
+func (f *fakeUtilization) GPUUtilization(_ context.Context, _, _ string) (float64, bool, error) {
+	return f.value, f.found, nil
+}
+
+// TestCostReporter_Update 测试 GPU 秒数、利用率和费用的累计
+func TestCostReporter_Update(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	current := start
+
+	r := NewCostReporter(2.5, "USD", &fakeUtilization{value: 50, found: true})
+	r.now = func() time.Time { return current }
+
+	llm := &aiv1.LLMService{
+		ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "default"},
+		Spec:       aiv1.LLMServiceSpec{GpuPerReplica: 2},
+	}
+
+	// 第一次：只记录起点
+	r.Update(context.Background(), llm, 3)
+	report := llm.Status.CostReport
+	if report == nil {
+		t.Fatal("expected CostReport to be initialized")
+	}
+	if report.GPUSeconds != 0 {
+		t.Errorf("got GPUSeconds=%d, want 0", report.GPUSeconds)
+	}
+
+	// 一小时后：3 副本 × 2 GPU × 3600s = 6 GPU 小时
+	current = start.Add(time.Hour)
+	r.Update(context.Background(), llm, 3)
+
+	if report.GPUSeconds != 6*3600 {
+		t.Errorf("got GPUSeconds=%d, want %d", report.GPUSeconds, 6*3600)
+	}
+	if report.GPUHours != "6.00" {
+		t.Errorf("got GPUHours=%s, want 6.00", report.GPUHours)
+	}
+	if report.EstimatedCost != "15.00" {
+		t.Errorf("got EstimatedCost=%s, want 15.00", report.EstimatedCost)
+	}
+	if report.AverageUtilizationPercent != 50 {
+		t.Errorf("got AverageUtilizationPercent=%d, want 50", report.AverageUtilizationPercent)
+	}
+}
+
+// TestAccumulate_WeightedUtilization 测试利用率按 GPU 时间加权
+func TestAccumulate_WeightedUtilization(t *testing.T) {
+	report := &aiv1.CostReport{}
+
+	// 1 GPU 小时 100% + 3 GPU 小时 0% → 平均 25%
+	accumulate(report, 3600, 100, 1)
+	accumulate(report, 3*3600, 0, 1)
+
+	if report.AverageUtilizationPercent != 25 {
+		t.Errorf("got AverageUtilizationPercent=%d, want 25", report.AverageUtilizationPercent)
+	}
+	if report.EstimatedCost != "4.00" {
+		t.Errorf("got EstimatedCost=%s, want 4.00", report.EstimatedCost)
+	}
+}
+
+// TestCostReporter_PriceChange 测试单价热更新后历史费用不会按新价格重算
+func TestCostReporter_PriceChange(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	current := start
+
+	r := NewCostReporter(2, "USD", nil)
+	r.now = func() time.Time { return current }
+
+	llm := &aiv1.LLMService{
+		ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "default"},
+		Spec:       aiv1.LLMServiceSpec{GpuPerReplica: 1},
+	}
+	r.Update(context.Background(), llm, 1)
+
+	// 第一个小时 $2/h
+	current = start.Add(time.Hour)
+	r.Update(context.Background(), llm, 1)
+
+	// 单价改成 $5/h，第二个小时按新价格算，第一个小时不变：2 + 5 = 7
+	r.HourlyCostPerGPU = 5
+	current = start.Add(2 * time.Hour)
+	r.Update(context.Background(), llm, 1)
+
+	report := llm.Status.CostReport
+	if report.EstimatedCostMicros != 7_000_000 {
+		t.Errorf("got EstimatedCostMicros=%d, want 7000000", report.EstimatedCostMicros)
+	}
+	if report.EstimatedCost != "7.00" {
+		t.Errorf("got EstimatedCost=%s, want 7.00", report.EstimatedCost)
+	}
+}
+
+// TestAccumulate_LegacyReport 测试没有 EstimatedCostMicros 的旧 report 从 EstimatedCost 接着累加
+func TestAccumulate_LegacyReport(t *testing.T) {
+	report := &aiv1.CostReport{GPUSeconds: 3600, GPUHours: "1.00", EstimatedCost: "3.00"}
+
+	accumulate(report, 3600, 0, 1)
+
+	if report.EstimatedCost != "4.00" {
+		t.Errorf("got EstimatedCost=%s, want 4.00", report.EstimatedCost)
+	}
+}
+
+// TestCostReporter_SubSecondUpdates 测试间隔不到一秒的 reconcile 不会丢掉用量
+func TestCostReporter_SubSecondUpdates(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	current := start
+
+	r := NewCostReporter(1, "USD", nil)
+	r.now = func() time.Time { return current }
+
+	llm := &aiv1.LLMService{
+		ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "default"},
+		Spec:       aiv1.LLMServiceSpec{GpuPerReplica: 1},
+	}
+	r.Update(context.Background(), llm, 1)
+
+	// 每 400ms reconcile 一次，持续 10 秒
+	for i := 0; i < 25; i++ {
+		current = current.Add(400 * time.Millisecond)
+		r.Update(context.Background(), llm, 1)
+	}
+
+	report := llm.Status.CostReport
+	if report.GPUSeconds != 10 {
+		t.Errorf("got GPUSeconds=%d, want 10", report.GPUSeconds)
+	}
+	if !report.LastUpdateTime.Equal(&metav1.Time{Time: start.Add(10 * time.Second)}) {
+		t.Errorf("got LastUpdateTime=%v, want %v", report.LastUpdateTime, start.Add(10*time.Second))
+	}
+}
@@ -0,0 +1,90 @@
+package reporting
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// PrometheusClient 是一个极简的 Prometheus HTTP API 客户端
+//
+// 只实现了 instant query（/api/v1/query），并且只取结果向量里的第一个值。
+// 我们只需要 avg(...) 这种单值查询，没必要引入完整的 client_golang/api 依赖。
+type PrometheusClient struct {
+	// BaseURL 例如 "http://prometheus-operated.monitoring:9090"
+	BaseURL string
+
+	httpClient *http.Client
+}
+
+// NewPrometheusClient 创建 Prometheus 客户端
+func NewPrometheusClient(baseURL string) *PrometheusClient {
+	return &PrometheusClient{
+		BaseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// queryResponse 对应 Prometheus /api/v1/query 的返回格式
+//
+//	{"status":"success","data":{"resultType":"vector","result":[{"metric":{...},"value":[1700000000.0,"42.5"]}]}}
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Value []any `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// QueryScalar 执行一个 PromQL 查询并返回第一个样本的值
+//
+// 返回值：
+//   - value: 样本值
+//   - found: 查询结果为空时返回 false（例如 DCGM exporter 还没采集到这个 Pod）
+func (p *PrometheusClient) QueryScalar(ctx context.Context, query string) (float64, bool, error) {
+	u := fmt.Sprintf("%s/api/v1/query?query=%s", p.BaseURL, url.QueryEscape(query))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to build prometheus request: %w", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to query prometheus: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, false, fmt.Errorf("unexpected prometheus status code: %d", resp.StatusCode)
+	}
+
+	var qr queryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&qr); err != nil {
+		return 0, false, fmt.Errorf("failed to decode prometheus response: %w", err)
+	}
+	if qr.Status != "success" {
+		return 0, false, fmt.Errorf("prometheus query failed: %s", qr.Error)
+	}
+	if len(qr.Data.Result) == 0 || len(qr.Data.Result[0].Value) != 2 {
+		return 0, false, nil
+	}
+
+	// value[1] 是字符串形式的数字，例如 "42.5"
+	raw, ok := qr.Data.Result[0].Value[1].(string)
+	if !ok {
+		return 0, false, fmt.Errorf("unexpected prometheus sample format: %v", qr.Data.Result[0].Value)
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to parse prometheus sample %q: %w", raw, err)
+	}
+	return v, true, nil
+}
@@ -0,0 +1,58 @@
+package reporting
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// SLOSource 提供 gateway 在一个窗口内观测到的服务质量
+type SLOSource interface {
+	// P99Latency 返回窗口内端到端延迟的 P99，found=false 表示窗口内没有请求
+	P99Latency(ctx context.Context, namespace, name string, window time.Duration) (latency time.Duration, found bool, err error)
+	// Availability 返回窗口内非 5xx 响应的百分比（0-100），found=false 表示窗口内没有请求
+	Availability(ctx context.Context, namespace, name string, window time.Duration) (percent float64, found bool, err error)
+}
+
+// PrometheusSLO 从 Prometheus 查询 gateway 指标（pkg/metrics 里的 kubeinfer_gateway_*）
+type PrometheusSLO struct {
+	Client *PrometheusClient
+}
+
+// P99Latency 实现 SLOSource
+func (p *PrometheusSLO) P99Latency(ctx context.Context, namespace, name string, window time.Duration) (time.Duration, bool, error) {
+	query := fmt.Sprintf(
+		`histogram_quantile(0.99, sum by (le) (rate(kubeinfer_gateway_request_duration_seconds_bucket{%s}[%s])))`,
+		gatewaySelector(namespace, name), promDuration(window))
+	v, found, err := p.Client.QueryScalar(ctx, query)
+	// 窗口内没有请求时 rate 全是 0，histogram_quantile 返回 NaN
+	if err != nil || !found || math.IsNaN(v) {
+		return 0, false, err
+	}
+	return time.Duration(v * float64(time.Second)), true, nil
+}
+
+// Availability 实现 SLOSource
+func (p *PrometheusSLO) Availability(ctx context.Context, namespace, name string, window time.Duration) (float64, bool, error) {
+	selector, w := gatewaySelector(namespace, name), promDuration(window)
+	query := fmt.Sprintf(
+		`100 * sum(rate(kubeinfer_gateway_requests_total{%s,code!~"5.."}[%s])) / sum(rate(kubeinfer_gateway_requests_total{%s}[%s]))`,
+		selector, w, selector, w)
+	v, found, err := p.Client.QueryScalar(ctx, query)
+	// 分母为 0（没有请求）时结果是 NaN
+	if err != nil || !found || math.IsNaN(v) {
+		return 0, false, err
+	}
+	return v, true, nil
+}
+
+// gatewaySelector 选出某个 LLMService 的 gateway 指标
+func gatewaySelector(namespace, name string) string {
+	return fmt.Sprintf(`namespace=%q,name=%q`, namespace, name)
+}
+
+// promDuration 把 Go 的 Duration 转成 PromQL 的区间（"5m0s" 不是合法的 PromQL）
+func promDuration(d time.Duration) string {
+	return fmt.Sprintf("%ds", int64(d.Seconds()))
+}
@@ -0,0 +1,146 @@
+// Package schedule 提供一个最小的 cron 表达式解析器和时间窗口
+//
+// 只支持标准的 5 段 cron（分 时 日 月 周），每段支持：
+//   - *        任意值
+//   - 5        单个值
+//   - 1-5      范围
+//   - 1,3,5    列表
+//   - */15、1-30/5  步长
+//
+// 不支持 @daily、L、W、# 这类扩展语法，对维护窗口来说够用了。
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 是解析后的 cron 表达式，每段是一个允许值的集合
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// domStar/dowStar 记录日/周是否是 *：
+	// cron 的规则是日和周都不是 * 时，满足其中一个就算匹配
+	domStar, dowStar bool
+}
+
+type fieldRange struct {
+	min, max int
+}
+
+var (
+	minuteRange = fieldRange{0, 59}
+	hourRange   = fieldRange{0, 23}
+	domRange    = fieldRange{1, 31}
+	monthRange  = fieldRange{1, 12}
+	dowRange    = fieldRange{0, 7} // 0 和 7 都表示周日
+)
+
+// Parse 解析 5 段 cron 表达式，例如 "0 2 * * 6"（每周六凌晨 2 点）
+func Parse(expr string) (*Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields (minute hour day-of-month month day-of-week)", expr)
+	}
+
+	s := &Schedule{}
+	var err error
+	if s.minute, err = parseField(fields[0], minuteRange); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if s.hour, err = parseField(fields[1], hourRange); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if s.dom, err = parseField(fields[2], domRange); err != nil {
+		return nil, fmt.Errorf("day-of-month: %w", err)
+	}
+	if s.month, err = parseField(fields[3], monthRange); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if s.dow, err = parseField(fields[4], dowRange); err != nil {
+		return nil, fmt.Errorf("day-of-week: %w", err)
+	}
+	// 7 和 0 都是周日
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+	return s, nil
+}
+
+// parseField 把一段解析成位图，第 i 位为 1 表示允许值 i
+func parseField(field string, r fieldRange) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := r.min, r.max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			lo, err1 = strconv.Atoi(bounds[0])
+			hi, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			lo, hi = n, n
+		}
+
+		if lo < r.min || hi > r.max || lo > hi {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, r.min, r.max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Matches 判断 t 所在的这一分钟是否匹配表达式
+func (s *Schedule) Matches(t time.Time) bool {
+	if s.minute&(1<<uint(t.Minute())) == 0 ||
+		s.hour&(1<<uint(t.Hour())) == 0 ||
+		s.month&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// maxSearch 向前/向后查找匹配时间的上限（一年多一点，覆盖 2 月 29 日这类表达式之外的所有情况）
+const maxSearch = 366 * 24 * time.Hour
+
+// Next 返回 after 之后（不含）第一个匹配的时间，找不到返回零值
+func (s *Schedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	end := after.Add(maxSearch)
+	for ; !t.After(end); t = t.Add(time.Minute) {
+		if s.Matches(t) {
+			return t
+		}
+	}
+	return time.Time{}
+}
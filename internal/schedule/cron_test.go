@@ -0,0 +1,66 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) expected error", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// 2026-01-07 是周三
+	base := time.Date(2026, 1, 7, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{expr: "* * * * *", want: time.Date(2026, 1, 7, 10, 31, 0, 0, time.UTC)},
+		{expr: "0 2 * * *", want: time.Date(2026, 1, 8, 2, 0, 0, 0, time.UTC)},
+		{expr: "0 2 * * 6", want: time.Date(2026, 1, 10, 2, 0, 0, 0, time.UTC)},
+		{expr: "0 2 * * 7", want: time.Date(2026, 1, 11, 2, 0, 0, 0, time.UTC)},
+		{expr: "*/15 10-11 * * *", want: time.Date(2026, 1, 7, 10, 45, 0, 0, time.UTC)},
+		{expr: "0 0 1 * *", want: time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 3 1,15 * 1", want: time.Date(2026, 1, 12, 3, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		s, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.expr, err)
+		}
+		if got := s.Next(base); !got.Equal(tt.want) {
+			t.Errorf("Next(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestWindowContains(t *testing.T) {
+	// 每周六 02:00 开始，持续 4 小时
+	w, err := NewWindow("0 2 * * 6", 4*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		t    time.Time
+		want bool
+	}{
+		{t: time.Date(2026, 1, 10, 1, 59, 0, 0, time.UTC), want: false},
+		{t: time.Date(2026, 1, 10, 2, 0, 0, 0, time.UTC), want: true},
+		{t: time.Date(2026, 1, 10, 5, 59, 0, 0, time.UTC), want: true},
+		{t: time.Date(2026, 1, 10, 6, 0, 0, 0, time.UTC), want: false},
+		{t: time.Date(2026, 1, 7, 3, 0, 0, 0, time.UTC), want: false},
+	}
+	for _, tt := range tests {
+		if got := w.Contains(tt.t); got != tt.want {
+			t.Errorf("Contains(%v) = %v, want %v", tt.t, got, tt.want)
+		}
+	}
+}
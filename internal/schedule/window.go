@@ -0,0 +1,41 @@
+package schedule
+
+import (
+	"time"
+)
+
+// Window 是从每个 cron 匹配时间开始、持续 Duration 的时间窗口
+//
+// 例如 Schedule="0 2 * * 6"、Duration=4h 表示每周六 02:00-06:00
+type Window struct {
+	Schedule *Schedule
+	Duration time.Duration
+}
+
+// NewWindow 解析 cron 表达式并创建窗口
+func NewWindow(expr string, duration time.Duration) (*Window, error) {
+	s, err := Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+	return &Window{Schedule: s, Duration: duration}, nil
+}
+
+// Contains 判断 t 是否在某个窗口内
+//
+// 往回找 Duration 时间内有没有窗口开始时间
+func (w *Window) Contains(t time.Time) bool {
+	start := t.Truncate(time.Minute)
+	earliest := t.Add(-w.Duration)
+	for ; start.After(earliest); start = start.Add(-time.Minute) {
+		if w.Schedule.Matches(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextStart 返回 t 之后下一个窗口的开始时间，找不到返回零值
+func (w *Window) NextStart(t time.Time) time.Time {
+	return w.Schedule.Next(t)
+}
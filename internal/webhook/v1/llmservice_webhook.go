@@ -0,0 +1,1250 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/version"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/agent/modelregistry"
+	agentruntime "github.com/Moore-Z/kubeinfer/internal/agent/runtime"
+	"github.com/Moore-Z/kubeinfer/internal/modelcatalog"
+	"github.com/Moore-Z/kubeinfer/internal/registry"
+	"github.com/Moore-Z/kubeinfer/internal/schedule"
+)
+
+// nolint:unused
+// log is for logging in this package.
+var llmservicelog = logf.Log.WithName("llmservice-resource")
+
+// SetupLLMServiceWebhookWithManager registers the webhook for LLMService in the manager.
+// - conversion：v1 是 Hub，v1beta1 实现 Convertible，controller-runtime 自动提供 /convert
+// - validation：检查 Spec.Resources 是否合理、能否被某个节点放下，模型能否放进集群里的 GPU
+func SetupLLMServiceWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&aiv1.LLMService{}).
+		WithValidator(&LLMServiceCustomValidator{Reader: mgr.GetAPIReader(), Registry: registry.NewResolver()}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-ai-ruijie-io-v1-llmservice,mutating=false,failurePolicy=fail,sideEffects=None,groups=ai.ruijie.io,resources=llmservices,verbs=create;update,versions=v1,name=vllmservice-v1.kb.io,admissionReviewVersions=v1
+
+// LLMServiceCustomValidator struct is responsible for validating the LLMService resource
+// when it is created, updated, or deleted.
+type LLMServiceCustomValidator struct {
+	// Reader 用来列出节点；用 APIReader 而不是缓存，避免 webhook 为了节点建 informer
+	Reader client.Reader
+	// Registry 在提交时检查 spec.imagePinning 的镜像能不能解析成 digest，为 nil 时不检查
+	Registry ImageResolver
+}
+
+// ImageResolver 把镜像 tag 解析成 digest
+type ImageResolver interface {
+	Resolve(ctx context.Context, image string) (string, error)
+}
+
+// imageResolveTimeout 是提交时解析一个镜像的时间上限，不能占满 webhook 的超时（10s）
+const imageResolveTimeout = 3 * time.Second
+
+var _ webhook.CustomValidator = &LLMServiceCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type LLMService.
+func (v *LLMServiceCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	llm, ok := obj.(*aiv1.LLMService)
+	if !ok {
+		return nil, fmt.Errorf("expected a LLMService object but got %T", obj)
+	}
+	llmservicelog.Info("Validation for LLMService upon creation", "name", llm.GetName())
+
+	return v.validate(ctx, llm)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type LLMService.
+func (v *LLMServiceCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	llm, ok := newObj.(*aiv1.LLMService)
+	if !ok {
+		return nil, fmt.Errorf("expected a LLMService object for the newObj but got %T", newObj)
+	}
+	old, ok := oldObj.(*aiv1.LLMService)
+	if !ok {
+		return nil, fmt.Errorf("expected a LLMService object for the oldObj but got %T", oldObj)
+	}
+	llmservicelog.Info("Validation for LLMService upon update", "name", llm.GetName())
+
+	// spec 没变就不重新检查：gateway 会频繁 patch 注解，
+	// 不能因为节点变化导致这些 patch 被拒绝
+	if equality.Semantic.DeepEqual(old.Spec, llm.Spec) {
+		return nil, nil
+	}
+
+	return v.validate(ctx, llm)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type LLMService.
+func (v *LLMServiceCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate 汇总所有校验
+//
+// 节点列表拿不到时只给 warning，不阻止创建：
+// webhook 的 failurePolicy 是 Fail，不能因为 API 抖动就让用户完全无法提交
+func (v *LLMServiceCustomValidator) validate(ctx context.Context, llm *aiv1.LLMService) (admission.Warnings, error) {
+	var warnings admission.Warnings
+
+	var nodes []corev1.Node
+	if v.Reader != nil {
+		nodeList := &corev1.NodeList{}
+		if err := v.Reader.List(ctx, nodeList); err != nil {
+			warnings = append(warnings, fmt.Sprintf("could not list nodes, skipping allocatable check: %v", err))
+		} else {
+			nodes = nodeList.Items
+		}
+	}
+
+	// 换了后端但还在用默认的 vLLM 镜像，多半是忘了改 Image
+	// （Image 为空时用 operator 配置的 defaultImage，默认也是 vLLM 镜像）
+	// （开发模式忽略 Runtime，Image 为空时用的是 devImage）
+	if llm.Spec.Runtime != "" && llm.Spec.Runtime != aiv1.RuntimeVLLM && !llm.Spec.DevMode {
+		image := llm.Spec.Image
+		if llm.Spec.Images != nil && llm.Spec.Images.Runtime != "" {
+			image = llm.Spec.Images.Runtime
+		}
+		switch image {
+		case aiv1.DefaultImage:
+			warnings = append(warnings, fmt.Sprintf("spec.runtime is %q but spec.image is the default vLLM image", llm.Spec.Runtime))
+		case "":
+			warnings = append(warnings, fmt.Sprintf("spec.runtime is %q but spec.image is not set, the operator default image will be used", llm.Spec.Runtime))
+		}
+	}
+
+	// 自由格式的 VLLM_EXTRA_ARGS 不经过校验，可能和生成的参数冲突
+	if slices.ContainsFunc(llm.Spec.Env, func(e corev1.EnvVar) bool { return e.Name == agentruntime.ExtraArgsEnv }) {
+		warnings = append(warnings, fmt.Sprintf("spec.env %s is passed to vLLM unchecked, use spec.vllm.extraArgs instead", agentruntime.ExtraArgsEnv))
+	}
+
+	fitWarnings, fitErrs := validateModelFit(llm, nodes)
+	warnings = append(warnings, fitWarnings...)
+	tpWarnings, tpErrs := validateTensorParallel(llm)
+	warnings = append(warnings, tpWarnings...)
+	rolloutWarnings, rolloutErrs := validateRollout(llm)
+	warnings = append(warnings, rolloutWarnings...)
+	devWarnings, devErrs := validateDevMode(llm)
+	warnings = append(warnings, devWarnings...)
+	imagesWarnings, imagesErrs := validateImages(llm)
+	warnings = append(warnings, imagesWarnings...)
+	securityWarnings, securityErrs := validatePodSecurity(llm, nodes)
+	warnings = append(warnings, securityWarnings...)
+	strategyWarnings, strategyErrs := validateDistributionStrategy(llm)
+	warnings = append(warnings, strategyWarnings...)
+	warnings = append(warnings, v.resolveImages(ctx, llm)...)
+
+	allErrs := validateResources(llm, nodes)
+	allErrs = append(allErrs, fitErrs...)
+	allErrs = append(allErrs, tpErrs...)
+	allErrs = append(allErrs, validateUpdateWindow(llm)...)
+	allErrs = append(allErrs, validateVerification(llm)...)
+	allErrs = append(allErrs, validateDownload(llm)...)
+	allErrs = append(allErrs, validateSnapshot(llm)...)
+	allErrs = append(allErrs, validateModelRegistry(llm)...)
+	allErrs = append(allErrs, validateDistributionMode(llm)...)
+	allErrs = append(allErrs, strategyErrs...)
+	allErrs = append(allErrs, validateCoordination(llm)...)
+	allErrs = append(allErrs, validateSLO(llm)...)
+	allErrs = append(allErrs, validateGuardrails(llm)...)
+	allErrs = append(allErrs, validateVLLM(llm)...)
+	allErrs = append(allErrs, validateEnv(llm)...)
+	allErrs = append(allErrs, validateSharedMemory(llm)...)
+	allErrs = append(allErrs, validateStorage(llm)...)
+	allErrs = append(allErrs, validateModelStorage(llm)...)
+	allErrs = append(allErrs, validateCheckpoint(llm)...)
+	allErrs = append(allErrs, validateNetworking(llm)...)
+	allErrs = append(allErrs, validateRuntimeSocket(llm)...)
+	allErrs = append(allErrs, validateAutoscaling(llm)...)
+	allErrs = append(allErrs, rolloutErrs...)
+	allErrs = append(allErrs, devErrs...)
+	allErrs = append(allErrs, imagesErrs...)
+	allErrs = append(allErrs, validateImagePinning(llm)...)
+	allErrs = append(allErrs, securityErrs...)
+	allErrs = append(allErrs, validateExpose(llm)...)
+	if len(allErrs) > 0 {
+		return warnings, allErrs.ToAggregate()
+	}
+	return warnings, nil
+}
+
+// validateResources 检查 Spec.Resources：
+// 1. 不允许在 Resources 里写 GPU，GPU 统一由 gpuPerReplica 控制
+// 2. requests 不能大于 limits
+// 3. 至少有一个节点的 allocatable 能放下一个副本（CPU、内存、GPU）
+//
+// nodes 为空时跳过第 3 步（例如集群还没有节点，或者列节点失败）
+func validateResources(llm *aiv1.LLMService, nodes []corev1.Node) field.ErrorList {
+	var allErrs field.ErrorList
+	resPath := field.NewPath("spec", "resources")
+
+	res := llm.Spec.Resources
+	if res != nil {
+		if _, ok := res.Requests[aiv1.GPUResourceName]; ok {
+			allErrs = append(allErrs, field.Forbidden(resPath.Child("requests").Key(string(aiv1.GPUResourceName)),
+				"use spec.gpuPerReplica to request GPUs"))
+		}
+		if _, ok := res.Limits[aiv1.GPUResourceName]; ok {
+			allErrs = append(allErrs, field.Forbidden(resPath.Child("limits").Key(string(aiv1.GPUResourceName)),
+				"use spec.gpuPerReplica to request GPUs"))
+		}
+		for name, req := range res.Requests {
+			if limit, ok := res.Limits[name]; ok && req.Cmp(limit) > 0 {
+				allErrs = append(allErrs, field.Invalid(resPath.Child("requests").Key(string(name)),
+					req.String(), fmt.Sprintf("must be less than or equal to limit %s", limit.String())))
+			}
+		}
+	}
+	if len(allErrs) > 0 || len(nodes) == 0 {
+		return allErrs
+	}
+
+	// 一个副本需要的资源：requests（没写 requests 时按 limits 算）+ GPU
+	need := corev1.ResourceList{}
+	if res != nil {
+		for name, q := range res.Limits {
+			need[name] = q
+		}
+		for name, q := range res.Requests {
+			need[name] = q
+		}
+	}
+	if llm.Spec.GpuPerReplica > 0 {
+		need[aiv1.GPUResourceName] = *resource.NewQuantity(int64(llm.Spec.GpuPerReplica), resource.DecimalSI)
+	}
+	if h := llm.Spec.HugePages; h != nil {
+		need[hugePagesResourceName(h)] = h.Size
+	}
+	if n := llm.Spec.Networking; n != nil && n.RDMA != nil {
+		need[rdmaResourceName(n.RDMA)] = *resource.NewQuantity(int64(max(n.RDMA.Count, 1)), resource.DecimalSI)
+	}
+	if len(need) == 0 {
+		return allErrs
+	}
+
+	for i := range nodes {
+		if fits(need, nodes[i].Status.Allocatable) {
+			return allErrs
+		}
+	}
+	return append(allErrs, field.Invalid(resPath, need,
+		fmt.Sprintf("no node has enough allocatable resources for one replica (checked %d nodes)", len(nodes))))
+}
+
+// GPU feature discovery 给节点打的 label
+const (
+	// gpuMemoryLabel 是单张 GPU 的显存（MiB）
+	gpuMemoryLabel = "nvidia.com/gpu.memory"
+	// gpuProductLabel 是 GPU 型号，例如 "NVIDIA-A100-SXM4-80GB"
+	gpuProductLabel = "nvidia.com/gpu.product"
+)
+
+// validateModelFit 检查模型能不能放进集群里的 GPU
+//
+// - 权重本身都放不下（例如 70B fp16 ≈ 130Gi，单卡 24Gi）：拒绝，提示量化或加卡
+// - 权重放得下但没有给 KV cache 留空间：只给 warning
+//
+// 以下情况跳过：llama.cpp 和开发模式（可以只用 CPU）、stub（不加载模型）、看不出模型大小、
+// 节点没有 GPU feature discovery 的 label（不知道显存多大）
+func validateModelFit(llm *aiv1.LLMService, nodes []corev1.Node) (admission.Warnings, field.ErrorList) {
+	if llm.Spec.Runtime == aiv1.RuntimeLlamaCpp || llm.Spec.Runtime == aiv1.RuntimeStub || llm.Spec.DevMode {
+		return nil, nil
+	}
+	billions, ok := modelcatalog.ParameterBillions(llm.Spec.Model)
+	if !ok {
+		return nil, nil
+	}
+	precision := modelcatalog.DetectPrecision(llm.Spec.Model)
+	weights := modelcatalog.WeightsGiB(billions, precision)
+	serving := weights * modelcatalog.ServingOverhead
+	modelPath := field.NewPath("spec", "model")
+
+	if llm.Spec.GpuPerReplica == 0 {
+		return admission.Warnings{fmt.Sprintf(
+			"spec.gpuPerReplica is 0 but %s (~%gB parameters, %s) needs about %.0fGi of GPU memory",
+			llm.Spec.Model, billions, precision.Name, serving)}, nil
+	}
+
+	// 找出一个副本能拿到的最大显存（单卡显存 × gpuPerReplica，节点上的 GPU 数量要够）
+	gpus := llm.Spec.GpuPerReplica
+	best, largestGPU := 0.0, 0.0
+	bestProduct := ""
+	for i := range nodes {
+		mib, err := strconv.ParseFloat(nodes[i].Labels[gpuMemoryLabel], 64)
+		if err != nil || mib <= 0 {
+			continue
+		}
+		perGPU := mib / 1024
+		largestGPU = max(largestGPU, perGPU)
+
+		count := nodes[i].Status.Allocatable[aiv1.GPUResourceName]
+		if count.Value() < int64(gpus) {
+			continue
+		}
+		if capacity := perGPU * float64(gpus); capacity > best {
+			best = capacity
+			bestProduct = nodes[i].Labels[gpuProductLabel]
+		}
+	}
+	if largestGPU == 0 || best == 0 {
+		// 不知道显存大小，或者没有节点有足够的 GPU（validateResources 会报）
+		return nil, nil
+	}
+	if bestProduct == "" {
+		bestProduct = "GPU"
+	}
+
+	if weights > best {
+		minGPUs := int(math.Ceil(serving / largestGPU))
+		return nil, field.ErrorList{field.Invalid(modelPath, llm.Spec.Model, fmt.Sprintf(
+			"~%gB parameters at %s need ~%.0fGi of GPU memory for the weights alone, "+
+				"but the largest configuration in the cluster is %d x %s (%.0fGi); "+
+				"use a quantized model (AWQ/GPTQ/FP8) or set spec.gpuPerReplica to at least %d",
+			billions, precision.Name, weights, gpus, bestProduct, best, minGPUs))}
+	}
+	if serving > best {
+		return admission.Warnings{fmt.Sprintf(
+			"%s needs ~%.0fGi for weights and ~%.0fGi with KV cache, but one replica gets at most %.0fGi (%d x %s); "+
+				"expect a small context window or out-of-memory errors",
+			llm.Spec.Model, weights, serving, best, gpus, bestProduct)}, nil
+	}
+	return nil, nil
+}
+
+// validateUpdateWindow 检查维护窗口的 cron 表达式和时区
+func validateUpdateWindow(llm *aiv1.LLMService) field.ErrorList {
+	var allErrs field.ErrorList
+	w := llm.Spec.UpdateWindow
+	if w == nil {
+		return nil
+	}
+	path := field.NewPath("spec", "updateWindow")
+
+	if _, err := schedule.Parse(w.Schedule); err != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("schedule"), w.Schedule, err.Error()))
+	}
+	if w.TimeZone != "" {
+		if _, err := time.LoadLocation(w.TimeZone); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("timeZone"), w.TimeZone, err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validateVerification 检查模型校验策略：signature 必须配置公钥
+func validateVerification(llm *aiv1.LLMService) field.ErrorList {
+	if llm.Spec.Distribution == nil || llm.Spec.Distribution.Verification == nil {
+		return nil
+	}
+	v := llm.Spec.Distribution.Verification
+	path := field.NewPath("spec", "distribution", "verification")
+	if v.Policy == aiv1.VerifySignature && v.PublicKeySecret == "" {
+		return field.ErrorList{field.Required(path.Child("publicKeySecret"), "required when policy is signature")}
+	}
+	return nil
+}
+
+// validateSnapshot 检查快照的 endpoint（url 的格式由 CRD 的 pattern 检查）
+func validateSnapshot(llm *aiv1.LLMService) field.ErrorList {
+	if llm.Spec.Distribution == nil || llm.Spec.Distribution.Snapshot == nil {
+		return nil
+	}
+	sn := llm.Spec.Distribution.Snapshot
+	path := field.NewPath("spec", "distribution", "snapshot")
+	var allErrs field.ErrorList
+	if sn.CredentialsSecret == "" {
+		allErrs = append(allErrs, field.Required(path.Child("credentialsSecret"), "object storage credentials are required"))
+	}
+	if sn.Endpoint != "" {
+		if u, err := url.Parse(sn.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			allErrs = append(allErrs, field.Invalid(path.Child("endpoint"), sn.Endpoint, "must be an http(s) URL"))
+		}
+	}
+	return allErrs
+}
+
+// validateModelRegistry 检查模型仓库的 URI 和 endpoint（前缀由 CRD 的 pattern 检查），不访问仓库
+func validateModelRegistry(llm *aiv1.LLMService) field.ErrorList {
+	if llm.Spec.ModelSource == nil || llm.Spec.ModelSource.Registry == nil {
+		return nil
+	}
+	reg := llm.Spec.ModelSource.Registry
+	path := field.NewPath("spec", "modelSource", "registry")
+	var allErrs field.ErrorList
+	if err := modelregistry.Validate(reg.URI, reg.Endpoint); err != nil {
+		allErrs = append(allErrs, field.Invalid(path.Child("uri"), reg.URI, err.Error()))
+	}
+	if reg.Path != "" && !filepath.IsLocal(reg.Path) {
+		allErrs = append(allErrs, field.Invalid(path.Child("path"), reg.Path, "must be a relative path inside the model version"))
+	}
+	return allErrs
+}
+
+// minDownloadChunkSize 是 spec.distribution.download.chunkSize 的下限，
+// 分段太小时请求数太多，反而比单连接慢
+var minDownloadChunkSize = resource.MustParse("1Mi")
+
+// validateDownload 检查下载分段大小
+func validateDownload(llm *aiv1.LLMService) field.ErrorList {
+	if llm.Spec.Distribution == nil || llm.Spec.Distribution.Download == nil {
+		return nil
+	}
+	chunk := llm.Spec.Distribution.Download.ChunkSize
+	if chunk != nil && chunk.Cmp(minDownloadChunkSize) < 0 {
+		path := field.NewPath("spec", "distribution", "download", "chunkSize")
+		return field.ErrorList{field.Invalid(path, chunk.String(), "must be at least 1Mi")}
+	}
+	return nil
+}
+
+// validateRuntimeSocket 检查 spec.runtimeSocket：TGI 不能监听 unix socket，
+// initContainer 模式下推理服务是主容器自己启动的，没有 agent 在 8000 端口转发
+func validateRuntimeSocket(llm *aiv1.LLMService) field.ErrorList {
+	if !llm.Spec.RuntimeSocket {
+		return nil
+	}
+	path := field.NewPath("spec", "runtimeSocket")
+	var allErrs field.ErrorList
+	if !llm.Spec.DevMode && !agentruntime.SupportsSocket(llm.Spec.Runtime) {
+		allErrs = append(allErrs, field.Forbidden(path,
+			fmt.Sprintf("runtime %s cannot listen on a unix socket", llm.Spec.Runtime)))
+	}
+	if d := llm.Spec.Distribution; d != nil && d.Mode == aiv1.DistributionModeInitContainer {
+		allErrs = append(allErrs, field.Forbidden(path, "not supported with mode initContainer"))
+	}
+	return allErrs
+}
+
+// validateImages 检查 spec.images：runtime 和 spec.image 是同一个镜像，只能设置一个；
+// agent 模式下没有只运行 agent 的容器，images.agent 不起作用
+func validateImages(llm *aiv1.LLMService) (admission.Warnings, field.ErrorList) {
+	images := llm.Spec.Images
+	if images == nil {
+		return nil, nil
+	}
+	var allErrs field.ErrorList
+	if images.Runtime != "" && llm.Spec.Image != "" {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "images", "runtime"),
+			"must not be set together with spec.image"))
+	}
+	var warnings admission.Warnings
+	if d := llm.Spec.Distribution; images.Agent != "" && (d == nil || d.Mode != aiv1.DistributionModeInitContainer) {
+		warnings = append(warnings, "spec.images.agent only applies to distribution.mode initContainer, "+
+			"in agent mode the agent runs inside the runtime image")
+	}
+	return warnings, allErrs
+}
+
+// validateImagePinning 检查 spec.imagePinning.checkInterval：太频繁会被公共仓库限流
+func validateImagePinning(llm *aiv1.LLMService) field.ErrorList {
+	p := llm.Spec.ImagePinning
+	if p == nil || p.CheckInterval == nil || p.CheckInterval.Duration >= time.Minute {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(field.NewPath("spec", "imagePinning", "checkInterval"),
+		p.CheckInterval.Duration.String(), "must be at least 1m")}
+}
+
+// resolveImages 在提交时把 spec 里写的镜像解析一遍，tag 不存在、仓库访问不了时给 warning
+//
+// 不阻止提交：controller 解析成功之前继续用 tag 部署。operator 配置的默认镜像不在这里检查
+func (v *LLMServiceCustomValidator) resolveImages(ctx context.Context, llm *aiv1.LLMService) admission.Warnings {
+	if v.Registry == nil || llm.Spec.ImagePinning == nil {
+		return nil
+	}
+	images := []string{llm.Spec.Image}
+	if i := llm.Spec.Images; i != nil {
+		images = append(images, i.Runtime, i.Agent, i.Gateway)
+	}
+	var warnings admission.Warnings
+	for _, image := range images {
+		if image == "" || registry.HasDigest(image) {
+			continue
+		}
+		resolveCtx, cancel := context.WithTimeout(ctx, imageResolveTimeout)
+		_, err := v.Registry.Resolve(resolveCtx, image)
+		cancel()
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("spec.imagePinning: cannot resolve %s to a digest (%v), "+
+				"it is deployed by tag until it resolves", image, err))
+		}
+	}
+	return warnings
+}
+
+// validateDistributionMode 检查 initContainer 模式不支持的组合
+//
+// - zone 拓扑：seeder 选举在 agent 主进程里，model-fetch 只会从 coordinator 拿
+// - llamacpp：启动参数里的 .gguf 文件名要等模型下载完才知道，controller 生成不了主容器的 args
+// - peerServing：follower 的 model server 在 agent 主进程里；zone 拓扑下 seeder 已经占着 8080
+// - versioned 布局：主容器的推理服务直接读模型目录，current 软链接由 agent 主进程维护
+//
+// 关掉 model server（modelServer=false）时 peerServing、zone 拓扑都没有 model server 可用
+func validateDistributionMode(llm *aiv1.LLMService) field.ErrorList {
+	d := llm.Spec.Distribution
+	if d == nil {
+		return nil
+	}
+	var allErrs field.ErrorList
+	if d.PeerServing && (d.Mode == aiv1.DistributionModeInitContainer || d.Topology == aiv1.TopologyZone) {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "distribution", "peerServing"),
+			"peer serving is only supported with mode agent and flat topology"))
+	}
+	if d.ModelServer != nil && !*d.ModelServer && (d.PeerServing || d.Topology == aiv1.TopologyZone) {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "distribution", "modelServer"),
+			"the model server cannot be disabled with peerServing or zone topology"))
+	}
+	if p := d.EvictionProtection; p != nil {
+		path := field.NewPath("spec", "distribution", "evictionProtection")
+		if d.Mode == aiv1.DistributionModeInitContainer {
+			allErrs = append(allErrs, field.Forbidden(path, "eviction protection is not supported with mode initContainer"))
+		}
+		if p.MaxDuration != nil && p.MaxDuration.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child("maxDuration"), p.MaxDuration.Duration.String(), "must be positive"))
+		}
+	}
+	if d.Mode != aiv1.DistributionModeInitContainer {
+		return allErrs
+	}
+	if d.Topology == aiv1.TopologyZone {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "distribution", "topology"),
+			"zone topology is not supported with mode initContainer"))
+	}
+	if d.Layout == aiv1.LayoutVersioned {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "distribution", "layout"),
+			"versioned layout is not supported with mode initContainer"))
+	}
+	// llama.cpp 的参数依赖下载好的 .gguf 文件名，stub 在 agent 进程里运行，都没法直接作为主容器启动
+	if llm.Spec.Runtime == aiv1.RuntimeLlamaCpp || llm.Spec.Runtime == aiv1.RuntimeStub {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "distribution", "mode"),
+			fmt.Sprintf("mode initContainer is not supported with runtime %s", llm.Spec.Runtime)))
+	}
+	return allErrs
+}
+
+// validateDistributionStrategy 检查 spec.distribution.strategy 和对应的开关不矛盾
+//
+//   - direct 不运行 model server：不能再打开 modelServer、peerServing，也没有 zone seeder
+//   - 其他策略都要 model server；p2p 就是 peerServing，限制也一样
+//   - nodeCache 用 hostPath，Pod Security Standards 的 restricted 级别不允许
+//
+// 旧字段 cacheStrategy=shared 给一个弃用提示（none 是旧的默认值，存量对象上都有，不提示）
+func validateDistributionStrategy(llm *aiv1.LLMService) (admission.Warnings, field.ErrorList) {
+	var warnings admission.Warnings
+	if llm.Spec.CacheStrategy == aiv1.CacheStrategyShared { // nolint:staticcheck
+		warnings = append(warnings, "spec.cacheStrategy is deprecated, use spec.distribution.strategy: coordinator")
+	}
+	d := llm.Spec.Distribution
+	if d == nil || d.Strategy == "" {
+		return warnings, nil
+	}
+
+	var allErrs field.ErrorList
+	path := field.NewPath("spec", "distribution", "strategy")
+	if d.Strategy == aiv1.StrategyDirect {
+		if (d.ModelServer != nil && *d.ModelServer) || d.PeerServing || d.Topology == aiv1.TopologyZone {
+			allErrs = append(allErrs, field.Invalid(path, d.Strategy,
+				"direct runs no model server, so modelServer, peerServing and zone topology cannot be set"))
+		}
+		return warnings, allErrs
+	}
+	if d.ModelServer != nil && !*d.ModelServer {
+		allErrs = append(allErrs, field.Invalid(path, d.Strategy,
+			fmt.Sprintf("%s needs the model server, remove modelServer: false", d.Strategy)))
+	}
+	if d.Strategy == aiv1.StrategyP2P && (d.Mode == aiv1.DistributionModeInitContainer || d.Topology == aiv1.TopologyZone) {
+		allErrs = append(allErrs, field.Invalid(path, d.Strategy, "p2p is only supported with mode agent and flat topology"))
+	}
+	if d.Strategy == aiv1.StrategyNodeCache && llm.Spec.PodSecurity != nil && llm.Spec.PodSecurity.Restricted {
+		allErrs = append(allErrs, field.Invalid(path, d.Strategy,
+			"nodeCache mounts a hostPath volume, which the restricted Pod Security Standard forbids"))
+	}
+	return warnings, allErrs
+}
+
+// validateCoordination 检查 spec.coordination.pinnedPod 是合法的 Pod 名
+//
+// Pod 存不存在、是不是这个 LLMService 的 Pod 由 controller 检查（CoordinatorPinned Condition），
+// Pod 会被重建，准入时判断没有意义
+func validateCoordination(llm *aiv1.LLMService) field.ErrorList {
+	c := llm.Spec.Coordination
+	if c == nil || c.PinnedPod == "" {
+		return nil
+	}
+	var allErrs field.ErrorList
+	for _, msg := range validation.IsDNS1123Subdomain(c.PinnedPod) {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "coordination", "pinnedPod"), c.PinnedPod, msg))
+	}
+	return allErrs
+}
+
+// validateSLO 检查 SLO：至少要有一个目标，自动扩容的上限不能低于 spec.replicas
+func validateSLO(llm *aiv1.LLMService) field.ErrorList {
+	slo := llm.Spec.SLO
+	if slo == nil {
+		return nil
+	}
+	path := field.NewPath("spec", "slo")
+	var allErrs field.ErrorList
+	if slo.P99LatencyMs == nil && slo.Availability == "" {
+		allErrs = append(allErrs, field.Required(path, "at least one of p99LatencyMs and availability must be set"))
+	}
+	if slo.Window != nil && slo.Window.Duration < time.Minute {
+		allErrs = append(allErrs, field.Invalid(path.Child("window"), slo.Window.Duration.String(),
+			"must be at least 1m (Prometheus needs several scrapes to compute a rate)"))
+	}
+	if slo.MaxReplicas != nil && *slo.MaxReplicas < llm.Spec.Replicas {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxReplicas"), *slo.MaxReplicas,
+			fmt.Sprintf("must not be less than spec.replicas (%d)", llm.Spec.Replicas)))
+	}
+	return allErrs
+}
+
+// validateGuardrails 检查禁用正则能编译、输出 schema 是 JSON 对象
+//
+// guided_json 是 vLLM 的扩展参数，其他 runtime 不认识，所以 outputJSONSchema 只能配合 vllm 使用
+func validateGuardrails(llm *aiv1.LLMService) field.ErrorList {
+	if llm.Spec.Gateway == nil || llm.Spec.Gateway.Guardrails == nil {
+		return nil
+	}
+	g := llm.Spec.Gateway.Guardrails
+	path := field.NewPath("spec", "gateway", "guardrails")
+	var allErrs field.ErrorList
+	for i, p := range g.BannedPatterns {
+		if _, err := regexp.Compile(p); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("bannedPatterns").Index(i), p, err.Error()))
+		}
+	}
+	if g.OutputJSONSchema != "" {
+		var schema map[string]any
+		if err := json.Unmarshal([]byte(g.OutputJSONSchema), &schema); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("outputJSONSchema"), g.OutputJSONSchema,
+				"must be a JSON object: "+err.Error()))
+		}
+		if llm.Spec.Runtime != "" && llm.Spec.Runtime != aiv1.RuntimeVLLM {
+			allErrs = append(allErrs, field.Forbidden(path.Child("outputJSONSchema"),
+				"guided decoding is only supported with runtime vllm"))
+		}
+	}
+	return allErrs
+}
+
+// fits 判断 need 里的每一项是否都不超过 allocatable
+func fits(need, allocatable corev1.ResourceList) bool {
+	for name, q := range need {
+		avail, ok := allocatable[name]
+		if !ok || q.Cmp(avail) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// validateVLLM 检查 spec.vllm 只和 vllm runtime 一起使用（TGI、llama.cpp 不认识 --chat-template），
+// extraArgs 不能覆盖 controller 生成的参数
+func validateVLLM(llm *aiv1.LLMService) field.ErrorList {
+	if llm.Spec.VLLM == nil {
+		return nil
+	}
+	if llm.Spec.Runtime != "" && llm.Spec.Runtime != aiv1.RuntimeVLLM {
+		return field.ErrorList{field.Forbidden(field.NewPath("spec", "vllm"),
+			fmt.Sprintf("only supported with runtime vllm, got %s", llm.Spec.Runtime))}
+	}
+	var allErrs field.ErrorList
+	path := field.NewPath("spec", "vllm", "extraArgs")
+	for i, arg := range llm.Spec.VLLM.ExtraArgs {
+		switch {
+		case !vllmArgName.MatchString(arg.Name):
+			allErrs = append(allErrs, field.Invalid(path.Index(i).Child("name"), arg.Name,
+				"must be a long flag like --enable-prefix-caching, put the value in value"))
+		case agentruntime.IsManagedVLLMFlag(arg.Name):
+			allErrs = append(allErrs, field.Forbidden(path.Index(i).Child("name"),
+				fmt.Sprintf("%s is set by the controller", arg.Name)))
+		}
+	}
+	return append(allErrs, validateVLLMConfig(llm)...)
+}
+
+// validateVLLMConfig 检查 spec.vllm 的启动参数：显存比例不能是 0，
+// 已经用字段设置的参数不能再在 spec.env 里写一遍（controller 生成的变量优先，spec.env 里的会被悄悄忽略）
+func validateVLLMConfig(llm *aiv1.LLMService) field.ErrorList {
+	v := llm.Spec.VLLM
+	var allErrs field.ErrorList
+	if v.GPUMemoryUtilization != "" {
+		if u, err := strconv.ParseFloat(v.GPUMemoryUtilization, 64); err != nil || u <= 0 || u > 1 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "vllm", "gpuMemoryUtilization"),
+				v.GPUMemoryUtilization, "must be greater than 0 and at most 1"))
+		}
+	}
+	fields := map[string]string{}
+	if v.GPUMemoryUtilization != "" {
+		fields["VLLM_GPU_MEMORY_UTILIZATION"] = "gpuMemoryUtilization"
+	}
+	if v.MaxModelLen != nil {
+		fields["VLLM_MAX_MODEL_LEN"] = "maxModelLen"
+	}
+	if v.Dtype != "" {
+		fields["VLLM_DTYPE"] = "dtype"
+	}
+	for i, e := range llm.Spec.Env {
+		if name, ok := fields[e.Name]; ok {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "env").Index(i).Child("name"),
+				fmt.Sprintf("%s is set from spec.vllm.%s", e.Name, name)))
+		}
+	}
+	return allErrs
+}
+
+// vllmArgName 和 CRD 上 VLLMArg.Name 的 pattern 一致
+var vllmArgName = regexp.MustCompile(`^--[a-z0-9][a-z0-9-]*$`)
+
+// reservedEnv 是 controller 给 agent 设置的身份和模型变量，Spec.Env 里同名的会被忽略，直接拒绝更清楚
+var reservedEnv = map[string]bool{
+	"POD_NAME":          true,
+	"POD_NAMESPACE":     true,
+	"NODE_NAME":         true,
+	"CONFIGMAP_NAME":    true,
+	"MODEL_PATH":        true,
+	"MODEL_REPO":        true,
+	"LLMSERVICE_NAME":   true,
+	"INFERENCE_RUNTIME": true,
+	// spec.runtimeSocket
+	agentruntime.SocketEnv: true,
+	// spec.vllm.extraArgs
+	"VLLM_EXTRA_ARGS_JSON": true,
+}
+
+// validateEnv 检查 Spec.Env 没有重复，也没有覆盖 controller 生成的变量
+func validateEnv(llm *aiv1.LLMService) field.ErrorList {
+	path := field.NewPath("spec", "env")
+	var allErrs field.ErrorList
+	seen := map[string]bool{}
+	for i, e := range llm.Spec.Env {
+		switch {
+		case reservedEnv[e.Name]:
+			allErrs = append(allErrs, field.Forbidden(path.Index(i).Child("name"),
+				fmt.Sprintf("%s is set by the controller", e.Name)))
+		case seen[e.Name]:
+			allErrs = append(allErrs, field.Duplicate(path.Index(i).Child("name"), e.Name))
+		}
+		seen[e.Name] = true
+	}
+	return allErrs
+}
+
+// hugePageSize 返回大页的页大小，未设置时是 2Mi（和 controller 一致）
+func hugePageSize(h *aiv1.HugePagesSpec) string {
+	if h.PageSize == "" {
+		return aiv1.DefaultHugePageSize
+	}
+	return h.PageSize
+}
+
+// hugePagesResourceName 是大页对应的资源名，例如 hugepages-2Mi
+func hugePagesResourceName(h *aiv1.HugePagesSpec) corev1.ResourceName {
+	return corev1.ResourceName(corev1.ResourceHugePagesPrefix + hugePageSize(h))
+}
+
+// validateSharedMemory 检查 /dev/shm 和大页的大小
+//
+//   - medium=Memory 的 emptyDir 计入内存 limit，/dev/shm 不能把 limit 占满
+//   - 大页必须是页大小的整数倍，而且 Pod 必须有 CPU 或内存 requests（没设置 Resources 时用默认值，一定有）
+func validateSharedMemory(llm *aiv1.LLMService) field.ErrorList {
+	var allErrs field.ErrorList
+	res := llm.Spec.Resources
+
+	if size := llm.Spec.SharedMemorySize; size != nil {
+		path := field.NewPath("spec", "sharedMemorySize")
+		if size.Sign() <= 0 {
+			allErrs = append(allErrs, field.Invalid(path, size.String(), "must be greater than 0"))
+		} else if res != nil {
+			if limit, ok := res.Limits[corev1.ResourceMemory]; ok && size.Cmp(limit) >= 0 {
+				allErrs = append(allErrs, field.Invalid(path, size.String(),
+					fmt.Sprintf("must be less than the memory limit %s (shared memory counts against it)", limit.String())))
+			}
+		}
+	}
+
+	if h := llm.Spec.HugePages; h != nil {
+		path := field.NewPath("spec", "hugePages")
+		// PageSize 由 CRD 的 enum 限制为 2Mi/1Gi
+		pageSize := resource.MustParse(hugePageSize(h))
+		if h.Size.Sign() <= 0 || h.Size.Value()%pageSize.Value() != 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child("size"), h.Size.String(),
+				fmt.Sprintf("must be a positive multiple of the page size %s", pageSize.String())))
+		}
+		if res != nil && !hasCPUOrMemory(res.Requests) && !hasCPUOrMemory(res.Limits) {
+			allErrs = append(allErrs, field.Required(field.NewPath("spec", "resources", "requests"),
+				"hugepages require a cpu or memory request"))
+		}
+	}
+	return allErrs
+}
+
+// validateStorage 检查 spec.storage.scratch
+//
+// medium=Memory 的 scratch 卷计入内存 limit，而按模型估算的大小可能比 limit 还大，所以必须写明 size
+func validateStorage(llm *aiv1.LLMService) field.ErrorList {
+	var allErrs field.ErrorList
+	if llm.Spec.Storage == nil || llm.Spec.Storage.Scratch == nil {
+		return nil
+	}
+	scratch := llm.Spec.Storage.Scratch
+	path := field.NewPath("spec", "storage", "scratch")
+	if scratch.Size != nil && scratch.Size.Sign() <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("size"), scratch.Size.String(), "must be greater than 0"))
+	}
+	if scratch.Medium != string(corev1.StorageMediumMemory) {
+		return allErrs
+	}
+	if scratch.Size == nil {
+		allErrs = append(allErrs, field.Required(path.Child("size"), "size is required when medium is Memory"))
+	} else if res := llm.Spec.Resources; res != nil {
+		if limit, ok := res.Limits[corev1.ResourceMemory]; ok && scratch.Size.Cmp(limit) >= 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child("size"), scratch.Size.String(),
+				fmt.Sprintf("must be less than the memory limit %s (a Memory scratch volume counts against it)", limit.String())))
+		}
+	}
+	return allErrs
+}
+
+// validateModelStorage 检查 spec.storage.models 和分发方式不矛盾
+//
+// 共享模型卷上只有 coordinator 写，follower 只读：
+//   - nodeCache 自己把模型卷换成 hostPath
+//   - p2p/peerServing、zone topology 要 follower 或 zone seeder 往自己的目录里同步
+//   - initContainer 模式、关闭 model server（direct）时每个副本各自下载，会同时写同一个目录
+func validateModelStorage(llm *aiv1.LLMService) field.ErrorList {
+	if llm.Spec.Storage == nil || llm.Spec.Storage.Models == nil {
+		return nil
+	}
+	d := llm.Spec.Distribution
+	if d == nil {
+		return nil
+	}
+	path := field.NewPath("spec", "storage", "models")
+	var allErrs field.ErrorList
+	switch {
+	case d.Strategy == aiv1.StrategyNodeCache:
+		allErrs = append(allErrs, field.Forbidden(path, "a shared model volume cannot be combined with strategy nodeCache"))
+	case d.Strategy == aiv1.StrategyP2P || d.PeerServing:
+		allErrs = append(allErrs, field.Forbidden(path, "a shared model volume cannot be combined with peer serving"))
+	case d.Strategy == aiv1.StrategyDirect || (d.ModelServer != nil && !*d.ModelServer):
+		allErrs = append(allErrs, field.Forbidden(path,
+			"a shared model volume needs the model server (only the coordinator downloads), remove modelServer: false / strategy direct"))
+	}
+	if d.Topology == aiv1.TopologyZone {
+		allErrs = append(allErrs, field.Forbidden(path, "a shared model volume cannot be combined with zone topology"))
+	}
+	if d.Mode == aiv1.DistributionModeInitContainer {
+		allErrs = append(allErrs, field.Forbidden(path, "a shared model volume is only supported with mode agent"))
+	}
+	return allErrs
+}
+
+// validateCheckpoint 检查 spec.storage.checkpoint 和模型目录、分发方式不矛盾
+//
+// 下载断点只用于 follower 通过 HTTP 同步到 flat 布局的 emptyDir：
+//   - 共享模型卷、nodeCache 下 follower 不传输，或者模型本来就留在节点上
+//   - versioned 布局的 revision 目录本来就是增量同步的
+//   - zone seeder 边同步边提供自己的目录，initContainer 模式的主容器不是 agent
+func validateCheckpoint(llm *aiv1.LLMService) field.ErrorList {
+	s := llm.Spec.Storage
+	if s == nil || s.Checkpoint == nil {
+		return nil
+	}
+	path := field.NewPath("spec", "storage", "checkpoint")
+	var allErrs field.ErrorList
+	if s.Checkpoint.TTL != nil && s.Checkpoint.TTL.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("ttl"), s.Checkpoint.TTL.Duration.String(), "must be positive"))
+	}
+	if s.Models != nil {
+		allErrs = append(allErrs, field.Forbidden(path, "download checkpoints are not needed with a shared model volume"))
+	}
+	d := llm.Spec.Distribution
+	if d == nil {
+		return allErrs
+	}
+	if d.Strategy == aiv1.StrategyNodeCache {
+		allErrs = append(allErrs, field.Forbidden(path, "download checkpoints cannot be combined with strategy nodeCache"))
+	}
+	if d.Layout == aiv1.LayoutVersioned {
+		allErrs = append(allErrs, field.Forbidden(path, "download checkpoints are only supported with the flat layout"))
+	}
+	if d.Topology == aiv1.TopologyZone {
+		allErrs = append(allErrs, field.Forbidden(path, "download checkpoints cannot be combined with zone topology"))
+	}
+	if d.Mode == aiv1.DistributionModeInitContainer {
+		allErrs = append(allErrs, field.Forbidden(path, "download checkpoints are only supported with mode agent"))
+	}
+	return allErrs
+}
+
+// hasCPUOrMemory 判断资源列表里有没有 CPU 或内存
+func hasCPUOrMemory(list corev1.ResourceList) bool {
+	_, cpu := list[corev1.ResourceCPU]
+	_, memory := list[corev1.ResourceMemory]
+	return cpu || memory
+}
+
+// ncclEnvPattern 是 NCCLEnv 允许的 key
+var ncclEnvPattern = regexp.MustCompile(`^NCCL_[A-Z0-9_]+$`)
+
+// rdmaResourceName 返回 RDMA 设备的资源名，未设置时是 rdma/rdma_shared_device_a（和 controller 一致）
+func rdmaResourceName(r *aiv1.RDMASpec) corev1.ResourceName {
+	if r.ResourceName == "" {
+		return aiv1.DefaultRDMAResourceName
+	}
+	return corev1.ResourceName(r.ResourceName)
+}
+
+// validateNetworking 检查 NCCL 变量名、RDMA 资源名
+//
+// RDMA 资源必须是带域名前缀的扩展资源（例如 rdma/hca_shared_devices_a）；
+// NCCLEnv 的 key 不能再出现在 spec.env 里，否则哪个生效不直观
+func validateNetworking(llm *aiv1.LLMService) field.ErrorList {
+	n := llm.Spec.Networking
+	if n == nil {
+		return nil
+	}
+	path := field.NewPath("spec", "networking")
+	var allErrs field.ErrorList
+
+	if n.RDMA != nil {
+		name := string(rdmaResourceName(n.RDMA))
+		if errs := validation.IsQualifiedName(name); len(errs) > 0 || !strings.Contains(name, "/") {
+			allErrs = append(allErrs, field.Invalid(path.Child("rdma", "resourceName"), name,
+				"must be an extended resource name with a domain prefix, e.g. rdma/rdma_shared_device_a"))
+		} else if corev1.ResourceName(name) == aiv1.GPUResourceName {
+			allErrs = append(allErrs, field.Forbidden(path.Child("rdma", "resourceName"),
+				"use spec.gpuPerReplica to request GPUs"))
+		}
+	}
+
+	userEnv := map[string]bool{}
+	for _, e := range llm.Spec.Env {
+		userEnv[e.Name] = true
+	}
+	// 排序让错误信息的顺序稳定
+	for _, k := range slices.Sorted(maps.Keys(n.NCCLEnv)) {
+		keyPath := path.Child("ncclEnv").Key(k)
+		switch {
+		case !ncclEnvPattern.MatchString(k):
+			allErrs = append(allErrs, field.Invalid(keyPath, k, "must start with NCCL_ and contain only A-Z, 0-9 and _"))
+		case userEnv[k]:
+			allErrs = append(allErrs, field.Duplicate(keyPath, "also set in spec.env"))
+		}
+	}
+	return allErrs
+}
+
+// 用户命名空间（hostUsers: false）对节点的要求：
+// kubelet 1.30 起是 beta（UserNamespacesSupport 1.33 起默认打开），idmap 挂载需要 Linux 6.3
+var (
+	userNamespaceMinKubelet = version.MustParseGeneric("1.30.0")
+	userNamespaceMinKernel  = version.MustParseGeneric("6.3.0")
+)
+
+// validatePodSecurity 检查 spec.podSecurity
+//
+// - restricted 级别不允许主机网络，也不允许添加 capability（RDMA 需要 IPC_LOCK）
+// - 用户命名空间不能和主机网络一起使用（API server 会拒绝 Pod）
+// - Localhost 类型的 profile 必须写 localhostProfile
+// - 对照节点的 kubelet 和内核版本检查用户命名空间，不满足的节点只给 warning（可能不会调度到这些节点上）
+func validatePodSecurity(llm *aiv1.LLMService, nodes []corev1.Node) (admission.Warnings, field.ErrorList) {
+	ps := llm.Spec.PodSecurity
+	if ps == nil {
+		return nil, nil
+	}
+	path := field.NewPath("spec", "podSecurity")
+	hostNetwork := llm.Spec.Networking != nil && llm.Spec.Networking.HostNetwork
+	var allErrs field.ErrorList
+	if ps.Restricted && hostNetwork {
+		allErrs = append(allErrs, field.Forbidden(path.Child("restricted"), "not allowed with spec.networking.hostNetwork"))
+	}
+	if ps.Restricted && llm.Spec.Networking != nil && llm.Spec.Networking.RDMA != nil {
+		allErrs = append(allErrs, field.Forbidden(path.Child("restricted"), "spec.networking.rdma needs the IPC_LOCK capability"))
+	}
+	if ps.UserNamespace && hostNetwork {
+		allErrs = append(allErrs, field.Forbidden(path.Child("userNamespace"), "not allowed with spec.networking.hostNetwork"))
+	}
+	if p := ps.SeccompProfile; p != nil && p.Type == corev1.SeccompProfileTypeLocalhost && (p.LocalhostProfile == nil || *p.LocalhostProfile == "") {
+		allErrs = append(allErrs, field.Required(path.Child("seccompProfile", "localhostProfile"), "required for type Localhost"))
+	}
+	if p := ps.AppArmorProfile; p != nil && p.Type == corev1.AppArmorProfileTypeLocalhost && (p.LocalhostProfile == nil || *p.LocalhostProfile == "") {
+		allErrs = append(allErrs, field.Required(path.Child("appArmorProfile", "localhostProfile"), "required for type Localhost"))
+	}
+	if ps.Restricted && ps.SeccompProfile != nil && ps.SeccompProfile.Type == corev1.SeccompProfileTypeUnconfined {
+		allErrs = append(allErrs, field.Forbidden(path.Child("seccompProfile"), "Unconfined is not allowed with restricted"))
+	}
+
+	if !ps.UserNamespace || len(nodes) == 0 {
+		return nil, allErrs
+	}
+	var unsupported []string
+	for _, n := range nodes {
+		info := n.Status.NodeInfo
+		kubelet, kerr := version.ParseGeneric(info.KubeletVersion)
+		kernel, nerr := version.ParseGeneric(info.KernelVersion)
+		if kerr != nil || nerr != nil || kubelet.LessThan(userNamespaceMinKubelet) || kernel.LessThan(userNamespaceMinKernel) {
+			unsupported = append(unsupported, n.Name)
+		}
+	}
+	if len(unsupported) == 0 {
+		return nil, allErrs
+	}
+	count := len(unsupported)
+	slices.Sort(unsupported)
+	// 大集群只列前几个
+	if len(unsupported) > 5 {
+		unsupported = append(unsupported[:5], "...")
+	}
+	return admission.Warnings{fmt.Sprintf("spec.podSecurity.userNamespace needs kubelet >= 1.30 and Linux >= 6.3, "+
+		"%d of %d nodes do not qualify (%s); pods scheduled there will fail to start",
+		count, len(nodes), strings.Join(unsupported, ", "))}, allErrs
+}
+
+// devModeMaxBillions 是开发模式允许的最大模型（十亿参数），CPU 上更大的模型加载和推理都太慢
+const devModeMaxBillions = 3
+
+// validateDevMode 检查开发模式没有和 GPU 相关的配置一起使用，模型足够小
+//
+// spec.runtime 默认就是 vllm，分不清是不是用户写的，只拒绝明确写了 tgi 的
+func validateDevMode(llm *aiv1.LLMService) (admission.Warnings, field.ErrorList) {
+	if !llm.Spec.DevMode {
+		return nil, nil
+	}
+	var allErrs field.ErrorList
+	if llm.Spec.GpuPerReplica > 0 {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "gpuPerReplica"),
+			"must be 0 when spec.devMode is true"))
+	}
+	if llm.Spec.Runtime == aiv1.RuntimeTGI {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "runtime"),
+			"spec.devMode always runs llamacpp"))
+	}
+	if llm.Spec.VLLM != nil {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "vllm"),
+			"not supported when spec.devMode is true"))
+	}
+	if d := llm.Spec.Distribution; d != nil && d.Mode == aiv1.DistributionModeInitContainer {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "distribution", "mode"),
+			"mode initContainer is not supported when spec.devMode is true"))
+	}
+
+	billions, ok := modelcatalog.ParameterBillions(llm.Spec.Model)
+	if !ok {
+		return admission.Warnings{fmt.Sprintf(
+			"cannot tell the size of %s, spec.devMode runs it on CPU and is meant for models up to %dB parameters",
+			llm.Spec.Model, devModeMaxBillions)}, allErrs
+	}
+	if billions > devModeMaxBillions {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec", "model"), llm.Spec.Model,
+			fmt.Sprintf("~%gB parameters is too large for spec.devMode (at most %dB)", billions, devModeMaxBillions)))
+	}
+	return nil, allErrs
+}
+
+// validateTensorParallel 检查 tensor parallel 大小
+//
+//   - spec.vllm.tensorParallelSize 不能超过 GpuPerReplica，已知头数时必须能整除
+//   - 没有设置时按 GpuPerReplica 推算，推算结果用不满所有卡时给 warning
+func validateTensorParallel(llm *aiv1.LLMService) (admission.Warnings, field.ErrorList) {
+	gpus := int(llm.Spec.GpuPerReplica)
+	heads, headsKnown := modelcatalog.AttentionHeads(llm.Spec.Model)
+
+	if v := llm.Spec.VLLM; v != nil && v.TensorParallelSize != nil {
+		tp := int(*v.TensorParallelSize)
+		path := field.NewPath("spec", "vllm", "tensorParallelSize")
+		var allErrs field.ErrorList
+		if tp > max(gpus, 1) {
+			allErrs = append(allErrs, field.Invalid(path, tp,
+				fmt.Sprintf("must not be greater than spec.gpuPerReplica (%d)", gpus)))
+		}
+		if headsKnown && heads%tp != 0 {
+			allErrs = append(allErrs, field.Invalid(path, tp,
+				fmt.Sprintf("%s has %d attention heads, which is not divisible by %d (try %d)",
+					llm.Spec.Model, heads, tp, modelcatalog.TensorParallelSize(llm.Spec.Model, tp))))
+		}
+		return nil, allErrs
+	}
+
+	if tp := modelcatalog.TensorParallelSize(llm.Spec.Model, gpus); gpus > 1 && tp < gpus {
+		return admission.Warnings{fmt.Sprintf(
+			"%s has %d attention heads, tensor parallel size %d will be used and %d of %d GPUs per replica stay idle",
+			llm.Spec.Model, heads, tp, gpus-tp, gpus)}, nil
+	}
+	return nil, nil
+}
+
+// validateAutoscaling 检查 External/KEDA 模式没有和内置的副本数管理一起使用，KEDA 模式的参数完整
+//
+// 休眠和 SLO 扩容都要写 Deployment 的 replicas，和 HPA/KEDA 同时改会来回打架
+func validateAutoscaling(llm *aiv1.LLMService) field.ErrorList {
+	a := llm.Spec.Autoscaling
+	if a == nil {
+		return nil
+	}
+	path := field.NewPath("spec", "autoscaling")
+	var allErrs field.ErrorList
+
+	if a.Mode == aiv1.AutoscalingModeExternal || a.Mode == aiv1.AutoscalingModeKEDA {
+		if llm.Spec.IdleTimeout != nil {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "idleTimeout"),
+				fmt.Sprintf("hibernation is not supported when spec.autoscaling.mode is %s, scale to zero with KEDA instead", a.Mode)))
+		}
+		if llm.Spec.SLO != nil && llm.Spec.SLO.MaxReplicas != nil {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "slo", "maxReplicas"),
+				fmt.Sprintf("SLO scaling is not supported when spec.autoscaling.mode is %s", a.Mode)))
+		}
+	}
+
+	if a.Mode != aiv1.AutoscalingModeKEDA {
+		if a.MinReplicas != nil || a.MaxReplicas != 0 || a.TargetQueueDepth != nil ||
+			a.TargetTokensPerSecond != nil || a.CooldownPeriod != nil {
+			allErrs = append(allErrs, field.Forbidden(path,
+				"minReplicas, maxReplicas, targets and cooldownPeriod are only used when mode is KEDA"))
+		}
+		return allErrs
+	}
+
+	if a.MaxReplicas == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("maxReplicas"), "must be set when mode is KEDA"))
+	}
+	minReplicas := llm.Spec.Replicas
+	if a.MinReplicas != nil {
+		minReplicas = *a.MinReplicas
+	}
+	if a.MaxReplicas != 0 && a.MaxReplicas < minReplicas {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxReplicas"), a.MaxReplicas,
+			fmt.Sprintf("must not be less than the minimum replicas (%d)", minReplicas)))
+	}
+	if a.TargetQueueDepth == nil && a.TargetTokensPerSecond == nil {
+		allErrs = append(allErrs, field.Required(path,
+			"at least one of targetQueueDepth and targetTokensPerSecond must be set when mode is KEDA"))
+	}
+	return allErrs
+}
+
+// validateRollout 检查 maxSurge/maxUnavailable 的格式、不能同时为 0（滚动更新永远不会前进），
+// grace period 比 maxGenerationTime 短时给出警告
+func validateRollout(llm *aiv1.LLMService) (admission.Warnings, field.ErrorList) {
+	r := llm.Spec.Rollout
+	if r == nil {
+		return nil, nil
+	}
+	path := field.NewPath("spec", "rollout")
+	var allErrs field.ErrorList
+
+	// 按 100 个副本换算：只关心格式和是否为 0
+	zero := map[string]bool{}
+	for _, p := range []struct {
+		name string
+		v    *intstr.IntOrString
+	}{{"maxSurge", r.MaxSurge}, {"maxUnavailable", r.MaxUnavailable}} {
+		name, v := p.name, p.v
+		if v == nil {
+			continue
+		}
+		n, err := intstr.GetScaledValueFromIntOrPercent(v, 100, true)
+		switch {
+		case err != nil:
+			allErrs = append(allErrs, field.Invalid(path.Child(name), v.String(), "must be an integer or a percentage like 25%"))
+		case n < 0:
+			allErrs = append(allErrs, field.Invalid(path.Child(name), v.String(), "must not be negative"))
+		case n == 0:
+			zero[name] = true
+		}
+	}
+	if zero["maxSurge"] && zero["maxUnavailable"] {
+		allErrs = append(allErrs, field.Invalid(path.Child("maxUnavailable"), r.MaxUnavailable.String(),
+			"must not be 0 when maxSurge is 0"))
+	}
+
+	var warnings admission.Warnings
+	if r.TerminationGracePeriodSeconds != nil && llm.Spec.MaxGenerationTime != nil {
+		grace := time.Duration(*r.TerminationGracePeriodSeconds) * time.Second
+		if grace < llm.Spec.MaxGenerationTime.Duration {
+			warnings = append(warnings, fmt.Sprintf(
+				"spec.rollout.terminationGracePeriodSeconds (%s) is shorter than spec.maxGenerationTime (%s), requests still running at shutdown will be cut off",
+				grace, llm.Spec.MaxGenerationTime.Duration))
+		}
+	}
+	return warnings, allErrs
+}
+
+// validateExpose 检查每种发布方式需要的字段，以及用不上的字段没有设置
+func validateExpose(llm *aiv1.LLMService) field.ErrorList {
+	e := llm.Spec.Expose
+	if e == nil {
+		return nil
+	}
+	path := field.NewPath("spec", "expose")
+	var allErrs field.ErrorList
+
+	if e.Host != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(strings.TrimPrefix(e.Host, "*.")) {
+			allErrs = append(allErrs, field.Invalid(path.Child("host"), e.Host, msg))
+		}
+	} else if e.Type == aiv1.ExposeTypeIngress || e.Type == aiv1.ExposeTypeGatewayAPI {
+		allErrs = append(allErrs, field.Required(path.Child("host"), fmt.Sprintf("must be set when type is %s", e.Type)))
+	}
+	if e.Type == aiv1.ExposeTypeGatewayAPI && (e.GatewayRef == nil || e.GatewayRef.Name == "") {
+		allErrs = append(allErrs, field.Required(path.Child("gatewayRef"), "must be set when type is GatewayAPI"))
+	}
+	if e.Type != aiv1.ExposeTypeGatewayAPI && e.GatewayRef != nil {
+		allErrs = append(allErrs, field.Forbidden(path.Child("gatewayRef"), "only used when type is GatewayAPI"))
+	}
+	if e.Type != aiv1.ExposeTypeIngress {
+		if e.TLSSecretRef != nil {
+			allErrs = append(allErrs, field.Forbidden(path.Child("tlsSecretRef"),
+				"only used when type is Ingress, configure TLS on the Gateway listener or the load balancer instead"))
+		}
+		if e.IngressClassName != nil {
+			allErrs = append(allErrs, field.Forbidden(path.Child("ingressClassName"), "only used when type is Ingress"))
+		}
+	}
+	return allErrs
+}
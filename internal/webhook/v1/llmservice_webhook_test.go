@@ -0,0 +1,905 @@
+/*
+Copyright 2026.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func TestValidateResources(t *testing.T) {
+	gpuNode := corev1.Node{Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("16"),
+		corev1.ResourceMemory: resource.MustParse("64Gi"),
+		aiv1.GPUResourceName:  resource.MustParse("2"),
+	}}}
+
+	tests := []struct {
+		name      string
+		resources *corev1.ResourceRequirements
+		gpus      int32
+		nodes     []corev1.Node
+		wantErr   bool
+	}{
+		{
+			name:    "defaults without nodes",
+			wantErr: false,
+		},
+		{
+			name: "fits on gpu node",
+			resources: &corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("16Gi"),
+			}},
+			gpus:    1,
+			nodes:   []corev1.Node{gpuNode},
+			wantErr: false,
+		},
+		{
+			name: "gpu in resources is forbidden",
+			resources: &corev1.ResourceRequirements{Limits: corev1.ResourceList{
+				aiv1.GPUResourceName: resource.MustParse("1"),
+			}},
+			wantErr: true,
+		},
+		{
+			name: "request above limit",
+			resources: &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("64Gi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("32Gi")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "memory larger than any node",
+			resources: &corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("128Gi"),
+			}},
+			nodes:   []corev1.Node{gpuNode},
+			wantErr: true,
+		},
+		{
+			name:    "too many gpus per replica",
+			gpus:    4,
+			nodes:   []corev1.Node{gpuNode},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{
+				Model:         "m",
+				Resources:     tt.resources,
+				GpuPerReplica: tt.gpus,
+			}}
+			errs := validateResources(llm, tt.nodes)
+			if (len(errs) > 0) != tt.wantErr {
+				t.Errorf("validateResources() = %v, wantErr %v", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateModelFit(t *testing.T) {
+	gpuNode := func(memMiB string, gpus string) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+				gpuMemoryLabel:  memMiB,
+				gpuProductLabel: "NVIDIA-L4",
+			}},
+			Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+				aiv1.GPUResourceName: resource.MustParse(gpus),
+			}},
+		}
+	}
+	l4 := gpuNode("24576", "4")
+
+	tests := []struct {
+		name        string
+		model       string
+		runtime     string
+		gpus        int32
+		nodes       []corev1.Node
+		wantErr     bool
+		wantWarning bool
+	}{
+		{name: "7B fp16 fits on one 24Gi GPU", model: "meta-llama/Llama-2-7b-hf", gpus: 1, nodes: []corev1.Node{l4}},
+		{name: "70B fp16 on one 24Gi GPU", model: "meta-llama/Llama-3.1-70B-Instruct", gpus: 1, nodes: []corev1.Node{l4}, wantErr: true},
+		{name: "70B AWQ on two 24Gi GPUs", model: "TheBloke/Llama-2-70B-AWQ", gpus: 2, nodes: []corev1.Node{l4}},
+		{name: "11B fp16 leaves no room for KV cache", model: "meta-llama/Llama-3.2-11B-Vision", gpus: 1, nodes: []corev1.Node{l4}, wantWarning: true},
+		{name: "no gpu requested", model: "meta-llama/Llama-2-7b-hf", nodes: []corev1.Node{l4}, wantWarning: true},
+		{name: "llama.cpp can run on cpu", model: "meta-llama/Llama-3.1-70B-Instruct", runtime: aiv1.RuntimeLlamaCpp, gpus: 1, nodes: []corev1.Node{l4}},
+		{name: "unknown size", model: "acme/internal-model", gpus: 1, nodes: []corev1.Node{l4}},
+		{name: "no gpu labels", model: "meta-llama/Llama-3.1-70B-Instruct", gpus: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Model: tt.model, Runtime: tt.runtime, GpuPerReplica: tt.gpus}}
+			warnings, errs := validateModelFit(llm, tt.nodes)
+			if (len(errs) > 0) != tt.wantErr {
+				t.Errorf("errors = %v, wantErr %v", errs, tt.wantErr)
+			}
+			if (len(warnings) > 0) != tt.wantWarning {
+				t.Errorf("warnings = %v, wantWarning %v", warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestValidateVerification(t *testing.T) {
+	tests := []struct {
+		name         string
+		distribution *aiv1.DistributionSpec
+		wantErr      bool
+	}{
+		{name: "no distribution"},
+		{name: "sha256", distribution: &aiv1.DistributionSpec{Verification: &aiv1.VerificationSpec{Policy: aiv1.VerifySHA256}}},
+		{name: "signature without key", distribution: &aiv1.DistributionSpec{Verification: &aiv1.VerificationSpec{Policy: aiv1.VerifySignature}}, wantErr: true},
+		{name: "signature with key", distribution: &aiv1.DistributionSpec{Verification: &aiv1.VerificationSpec{
+			Policy: aiv1.VerifySignature, PublicKeySecret: "model-signing-key"}}},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Distribution: tt.distribution}}
+		if errs := validateVerification(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateDownload(t *testing.T) {
+	chunk := func(s string) *resource.Quantity {
+		q := resource.MustParse(s)
+		return &q
+	}
+	tests := []struct {
+		name     string
+		download *aiv1.DownloadSpec
+		wantErr  bool
+	}{
+		{name: "no download"},
+		{name: "concurrency only", download: &aiv1.DownloadSpec{Concurrency: 16}},
+		{name: "256Mi chunks", download: &aiv1.DownloadSpec{ChunkSize: chunk("256Mi")}},
+		{name: "tiny chunks", download: &aiv1.DownloadSpec{ChunkSize: chunk("64Ki")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Distribution: &aiv1.DistributionSpec{Download: tt.download}}}
+		if errs := validateDownload(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateSnapshot(t *testing.T) {
+	tests := []struct {
+		name     string
+		snapshot *aiv1.SnapshotSpec
+		wantErr  bool
+	}{
+		{name: "no snapshot"},
+		{name: "s3", snapshot: &aiv1.SnapshotSpec{URL: "s3://models/cache", CredentialsSecret: "s3-creds"}},
+		{name: "minio", snapshot: &aiv1.SnapshotSpec{URL: "s3://models", Endpoint: "http://minio:9000", CredentialsSecret: "s3-creds"}},
+		{name: "no credentials", snapshot: &aiv1.SnapshotSpec{URL: "gs://models"}, wantErr: true},
+		{name: "endpoint without scheme", snapshot: &aiv1.SnapshotSpec{URL: "s3://models", Endpoint: "minio:9000", CredentialsSecret: "s3-creds"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Distribution: &aiv1.DistributionSpec{Snapshot: tt.snapshot}}}
+		if errs := validateSnapshot(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateModelRegistry(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry *aiv1.ModelRegistrySpec
+		wantErr  bool
+	}{
+		{name: "no registry"},
+		{name: "mlflow version", registry: &aiv1.ModelRegistrySpec{URI: "models:/support-bot/3", Endpoint: "http://mlflow:5000", Path: "model"}},
+		{name: "mlflow alias", registry: &aiv1.ModelRegistrySpec{URI: "models:/support-bot@champion", Endpoint: "https://mlflow.example.com"}},
+		{name: "mlflow without endpoint", registry: &aiv1.ModelRegistrySpec{URI: "models:/support-bot/3"}, wantErr: true},
+		{name: "mlflow stage", registry: &aiv1.ModelRegistrySpec{URI: "models:/support-bot/Production", Endpoint: "http://mlflow:5000"}, wantErr: true},
+		{name: "wandb", registry: &aiv1.ModelRegistrySpec{URI: "wandb-artifact://acme/llm/support-bot:v7"}},
+		{name: "wandb without project", registry: &aiv1.ModelRegistrySpec{URI: "wandb-artifact://acme/support-bot"}, wantErr: true},
+		{name: "path escapes", registry: &aiv1.ModelRegistrySpec{URI: "wandb-artifact://acme/llm/support-bot", Path: "../other"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{ModelSource: &aiv1.ModelSourceSpec{Registry: tt.registry}}}
+		if errs := validateModelRegistry(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateDistributionMode(t *testing.T) {
+	disabled, enabled := false, true
+	tests := []struct {
+		name     string
+		runtime  string
+		topology string
+		mode     string
+		layout   string
+		peer     bool
+		eviction *aiv1.EvictionProtectionSpec
+		server   *bool
+		wantErr  bool
+	}{
+		{name: "agent mode with zone", topology: aiv1.TopologyZone, mode: aiv1.DistributionModeAgent},
+		{name: "init mode", mode: aiv1.DistributionModeInitContainer},
+		{name: "init mode with tgi", runtime: aiv1.RuntimeTGI, mode: aiv1.DistributionModeInitContainer},
+		{name: "init mode with zone", topology: aiv1.TopologyZone, mode: aiv1.DistributionModeInitContainer, wantErr: true},
+		{name: "init mode with llamacpp", runtime: aiv1.RuntimeLlamaCpp, mode: aiv1.DistributionModeInitContainer, wantErr: true},
+		{name: "init mode with stub", runtime: aiv1.RuntimeStub, mode: aiv1.DistributionModeInitContainer, wantErr: true},
+		{name: "versioned layout", layout: aiv1.LayoutVersioned},
+		{name: "versioned layout in init mode", layout: aiv1.LayoutVersioned, mode: aiv1.DistributionModeInitContainer, wantErr: true},
+		{name: "peer serving", peer: true},
+		{name: "peer serving with zone", topology: aiv1.TopologyZone, peer: true, wantErr: true},
+		{name: "peer serving in init mode", mode: aiv1.DistributionModeInitContainer, peer: true, wantErr: true},
+		{name: "eviction protection", eviction: &aiv1.EvictionProtectionSpec{MinActiveTransfers: 2}},
+		{name: "eviction protection in init mode", mode: aiv1.DistributionModeInitContainer,
+			eviction: &aiv1.EvictionProtectionSpec{}, wantErr: true},
+		{name: "eviction protection without limit", wantErr: true,
+			eviction: &aiv1.EvictionProtectionSpec{MaxDuration: &metav1.Duration{}}},
+		{name: "model server disabled", server: &disabled},
+		{name: "model server disabled in init mode", mode: aiv1.DistributionModeInitContainer, server: &disabled},
+		{name: "model server disabled with peer serving", peer: true, server: &disabled, wantErr: true},
+		{name: "model server disabled with zone", topology: aiv1.TopologyZone, server: &disabled, wantErr: true},
+		{name: "model server enabled with peer serving", peer: true, server: &enabled},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{
+			Runtime: tt.runtime,
+			Distribution: &aiv1.DistributionSpec{
+				Mode: tt.mode, Topology: tt.topology, Layout: tt.layout, PeerServing: tt.peer, EvictionProtection: tt.eviction,
+				ModelServer: tt.server,
+			},
+		}}
+		if errs := validateDistributionMode(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateSLO(t *testing.T) {
+	p99 := int32(2000)
+	low, high := int32(1), int32(4)
+	tests := []struct {
+		name    string
+		slo     *aiv1.SLOSpec
+		wantErr bool
+	}{
+		{name: "no slo"},
+		{name: "latency only", slo: &aiv1.SLOSpec{P99LatencyMs: &p99}},
+		{name: "availability with scale up", slo: &aiv1.SLOSpec{Availability: "99.9", MaxReplicas: &high}},
+		{name: "no targets", slo: &aiv1.SLOSpec{MaxReplicas: &high}, wantErr: true},
+		{name: "short window", slo: &aiv1.SLOSpec{P99LatencyMs: &p99, Window: &metav1.Duration{Duration: 10 * time.Second}}, wantErr: true},
+		{name: "max below replicas", slo: &aiv1.SLOSpec{P99LatencyMs: &p99, MaxReplicas: &low}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Replicas: 2, SLO: tt.slo}}
+		if errs := validateSLO(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateGuardrails(t *testing.T) {
+	tests := []struct {
+		name       string
+		runtime    string
+		guardrails *aiv1.GuardrailsSpec
+		wantErr    bool
+	}{
+		{name: "none"},
+		{name: "valid", guardrails: &aiv1.GuardrailsSpec{
+			BannedPatterns:   []string{`(?i)ignore previous instructions`},
+			OutputJSONSchema: `{"type":"object"}`,
+		}},
+		{name: "bad regex", guardrails: &aiv1.GuardrailsSpec{BannedPatterns: []string{`(unclosed`}}, wantErr: true},
+		{name: "schema not an object", guardrails: &aiv1.GuardrailsSpec{OutputJSONSchema: `"string"`}, wantErr: true},
+		{name: "schema with tgi", runtime: aiv1.RuntimeTGI, guardrails: &aiv1.GuardrailsSpec{OutputJSONSchema: `{}`}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{
+			Runtime: tt.runtime,
+			Gateway: &aiv1.GatewaySpec{Enabled: true, Guardrails: tt.guardrails},
+		}}
+		if errs := validateGuardrails(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateVLLM(t *testing.T) {
+	vllm := &aiv1.VLLMSpec{ChatTemplateConfigMapRef: &aiv1.ConfigMapKeyRef{Name: "qwen-template"}}
+	tests := []struct {
+		name    string
+		runtime string
+		vllm    *aiv1.VLLMSpec
+		env     []corev1.EnvVar
+		wantErr bool
+	}{
+		{name: "none", runtime: aiv1.RuntimeTGI},
+		{name: "default runtime", vllm: vllm},
+		{name: "vllm", runtime: aiv1.RuntimeVLLM, vllm: vllm},
+		{name: "llamacpp", runtime: aiv1.RuntimeLlamaCpp, vllm: vllm, wantErr: true},
+		{name: "extra args", vllm: &aiv1.VLLMSpec{ExtraArgs: []aiv1.VLLMArg{
+			{Name: "--enable-prefix-caching"}, {Name: "--max-num-seqs", Value: "64"}}}},
+		{name: "managed flag", vllm: &aiv1.VLLMSpec{ExtraArgs: []aiv1.VLLMArg{{Name: "--tensor-parallel-size", Value: "4"}}}, wantErr: true},
+		{name: "value in name", vllm: &aiv1.VLLMSpec{ExtraArgs: []aiv1.VLLMArg{{Name: "--max-num-seqs=64"}}}, wantErr: true},
+		{name: "short flag", vllm: &aiv1.VLLMSpec{ExtraArgs: []aiv1.VLLMArg{{Name: "-q", Value: "awq"}}}, wantErr: true},
+		{name: "config", vllm: &aiv1.VLLMSpec{GPUMemoryUtilization: "0.85", Dtype: "bfloat16"}},
+		{name: "zero memory utilization", vllm: &aiv1.VLLMSpec{GPUMemoryUtilization: "0.0"}, wantErr: true},
+		{name: "config also in env", vllm: &aiv1.VLLMSpec{Dtype: "half"},
+			env: []corev1.EnvVar{{Name: "VLLM_DTYPE", Value: "float16"}}, wantErr: true},
+		{name: "unmanaged env", vllm: &aiv1.VLLMSpec{Dtype: "half"},
+			env: []corev1.EnvVar{{Name: "VLLM_MAX_MODEL_LEN", Value: "8192"}}},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Runtime: tt.runtime, VLLM: tt.vllm, Env: tt.env}}
+		if errs := validateVLLM(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateDevMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        aiv1.LLMServiceSpec
+		wantErr     bool
+		wantWarning bool
+	}{
+		{name: "off", spec: aiv1.LLMServiceSpec{Model: "meta-llama/Llama-2-70b-hf", GpuPerReplica: 4}},
+		{name: "tiny model", spec: aiv1.LLMServiceSpec{Model: "Qwen/Qwen2.5-0.5B-Instruct-GGUF", Runtime: aiv1.RuntimeVLLM, DevMode: true}},
+		{name: "unknown size", spec: aiv1.LLMServiceSpec{Model: "mock-model", DevMode: true}, wantWarning: true},
+		{name: "too large", spec: aiv1.LLMServiceSpec{Model: "Qwen/Qwen2.5-7B-Instruct-GGUF", DevMode: true}, wantErr: true},
+		{name: "gpu", spec: aiv1.LLMServiceSpec{Model: "Qwen/Qwen2.5-0.5B", GpuPerReplica: 1, DevMode: true}, wantErr: true},
+		{name: "tgi", spec: aiv1.LLMServiceSpec{Model: "Qwen/Qwen2.5-0.5B", Runtime: aiv1.RuntimeTGI, DevMode: true}, wantErr: true},
+		{name: "initContainer", spec: aiv1.LLMServiceSpec{Model: "Qwen/Qwen2.5-0.5B", DevMode: true,
+			Distribution: &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		warnings, errs := validateDevMode(&aiv1.LLMService{Spec: tt.spec})
+		if (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+		if (len(warnings) > 0) != tt.wantWarning {
+			t.Errorf("%s: warnings = %v, wantWarning %v", tt.name, warnings, tt.wantWarning)
+		}
+	}
+}
+
+func TestValidateEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     []corev1.EnvVar
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "proxy and nccl", env: []corev1.EnvVar{{Name: "HTTPS_PROXY", Value: "http://proxy:3128"}, {Name: "NCCL_DEBUG", Value: "INFO"}}},
+		{name: "reserved", env: []corev1.EnvVar{{Name: "MODEL_REPO", Value: "other/model"}}, wantErr: true},
+		{name: "duplicate", env: []corev1.EnvVar{{Name: "HF_HOME", Value: "/a"}, {Name: "HF_HOME", Value: "/b"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Env: tt.env}}
+		if errs := validateEnv(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateSharedMemory(t *testing.T) {
+	q := func(s string) *resource.Quantity {
+		v := resource.MustParse(s)
+		return &v
+	}
+	memoryLimit := &corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("32Gi")}}
+	gpuOnly := &corev1.ResourceRequirements{}
+	tests := []struct {
+		name      string
+		shm       *resource.Quantity
+		hugePages *aiv1.HugePagesSpec
+		resources *corev1.ResourceRequirements
+		wantErr   bool
+	}{
+		{name: "none"},
+		{name: "shm", shm: q("16Gi"), resources: memoryLimit},
+		{name: "shm without resources", shm: q("16Gi")},
+		{name: "shm fills memory limit", shm: q("32Gi"), resources: memoryLimit, wantErr: true},
+		{name: "zero shm", shm: q("0"), wantErr: true},
+		{name: "hugepages", hugePages: &aiv1.HugePagesSpec{Size: resource.MustParse("1Gi")}},
+		{name: "1Gi pages", hugePages: &aiv1.HugePagesSpec{PageSize: "1Gi", Size: resource.MustParse("4Gi")}, resources: memoryLimit},
+		{name: "not a multiple", hugePages: &aiv1.HugePagesSpec{PageSize: "1Gi", Size: resource.MustParse("1536Mi")}, wantErr: true},
+		{name: "hugepages without cpu or memory", hugePages: &aiv1.HugePagesSpec{Size: resource.MustParse("1Gi")}, resources: gpuOnly, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{
+			SharedMemorySize: tt.shm,
+			HugePages:        tt.hugePages,
+			Resources:        tt.resources,
+		}}
+		if errs := validateSharedMemory(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateDistributionStrategy(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name         string
+		cache        string
+		distribution *aiv1.DistributionSpec
+		restricted   bool
+		wantErr      bool
+		wantWarning  bool
+	}{
+		{name: "none"},
+		{name: "legacy none", cache: aiv1.CacheStrategyNone},
+		{name: "legacy shared", cache: aiv1.CacheStrategyShared, wantWarning: true},
+		{name: "direct", distribution: &aiv1.DistributionSpec{Strategy: aiv1.StrategyDirect}},
+		{name: "direct with model server", distribution: &aiv1.DistributionSpec{Strategy: aiv1.StrategyDirect, ModelServer: &enabled}, wantErr: true},
+		{name: "direct with peer serving", distribution: &aiv1.DistributionSpec{Strategy: aiv1.StrategyDirect, PeerServing: true}, wantErr: true},
+		{name: "direct with zone", distribution: &aiv1.DistributionSpec{Strategy: aiv1.StrategyDirect, Topology: aiv1.TopologyZone}, wantErr: true},
+		{name: "coordinator with zone", distribution: &aiv1.DistributionSpec{Strategy: aiv1.StrategyCoordinator, Topology: aiv1.TopologyZone}},
+		{name: "coordinator without model server", distribution: &aiv1.DistributionSpec{Strategy: aiv1.StrategyCoordinator, ModelServer: &disabled}, wantErr: true},
+		{name: "p2p", distribution: &aiv1.DistributionSpec{Strategy: aiv1.StrategyP2P}},
+		{name: "p2p in init mode", distribution: &aiv1.DistributionSpec{Strategy: aiv1.StrategyP2P, Mode: aiv1.DistributionModeInitContainer}, wantErr: true},
+		{name: "node cache", distribution: &aiv1.DistributionSpec{Strategy: aiv1.StrategyNodeCache}},
+		{name: "node cache restricted", distribution: &aiv1.DistributionSpec{Strategy: aiv1.StrategyNodeCache}, restricted: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{CacheStrategy: tt.cache, Distribution: tt.distribution}}
+		if tt.restricted {
+			llm.Spec.PodSecurity = &aiv1.PodSecuritySpec{Restricted: true}
+		}
+		warnings, errs := validateDistributionStrategy(llm)
+		if (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+		if (len(warnings) > 0) != tt.wantWarning {
+			t.Errorf("%s: warnings = %v, wantWarning %v", tt.name, warnings, tt.wantWarning)
+		}
+	}
+}
+
+func TestValidateStorage(t *testing.T) {
+	q := func(s string) *resource.Quantity {
+		v := resource.MustParse(s)
+		return &v
+	}
+	memoryLimit := &corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("32Gi")}}
+	tests := []struct {
+		name      string
+		scratch   *aiv1.ScratchSpec
+		resources *corev1.ResourceRequirements
+		wantErr   bool
+	}{
+		{name: "none"},
+		{name: "estimated size", scratch: &aiv1.ScratchSpec{}},
+		{name: "explicit size", scratch: &aiv1.ScratchSpec{Size: q("100Gi")}},
+		{name: "zero size", scratch: &aiv1.ScratchSpec{Size: q("0")}, wantErr: true},
+		{name: "memory", scratch: &aiv1.ScratchSpec{Size: q("8Gi"), Medium: "Memory"}, resources: memoryLimit},
+		{name: "memory without size", scratch: &aiv1.ScratchSpec{Medium: "Memory"}, wantErr: true},
+		{name: "memory fills memory limit", scratch: &aiv1.ScratchSpec{Size: q("32Gi"), Medium: "Memory"}, resources: memoryLimit, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Resources: tt.resources}}
+		if tt.scratch != nil {
+			llm.Spec.Storage = &aiv1.StorageSpec{Scratch: tt.scratch}
+		}
+		if errs := validateStorage(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateCoordination(t *testing.T) {
+	for _, tt := range []struct {
+		pinned  string
+		wantErr bool
+	}{
+		{pinned: ""},
+		{pinned: "qwen-7d9f8c6b5-x2k4p"},
+		{pinned: "Qwen_Pod", wantErr: true},
+	} {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Coordination: &aiv1.CoordinationSpec{PinnedPod: tt.pinned}}}
+		if errs := validateCoordination(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("pinnedPod %q: errors = %v, wantErr %v", tt.pinned, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateModelStorage(t *testing.T) {
+	disabled := false
+	tests := []struct {
+		name         string
+		distribution *aiv1.DistributionSpec
+		wantErr      bool
+	}{
+		{name: "default distribution"},
+		{name: "coordinator", distribution: &aiv1.DistributionSpec{Strategy: aiv1.StrategyCoordinator}},
+		{name: "versioned layout", distribution: &aiv1.DistributionSpec{Layout: aiv1.LayoutVersioned}},
+		{name: "node cache", distribution: &aiv1.DistributionSpec{Strategy: aiv1.StrategyNodeCache}, wantErr: true},
+		{name: "p2p", distribution: &aiv1.DistributionSpec{Strategy: aiv1.StrategyP2P}, wantErr: true},
+		{name: "peer serving", distribution: &aiv1.DistributionSpec{PeerServing: true}, wantErr: true},
+		{name: "direct", distribution: &aiv1.DistributionSpec{Strategy: aiv1.StrategyDirect}, wantErr: true},
+		{name: "model server disabled", distribution: &aiv1.DistributionSpec{ModelServer: &disabled}, wantErr: true},
+		{name: "zone topology", distribution: &aiv1.DistributionSpec{Topology: aiv1.TopologyZone}, wantErr: true},
+		{name: "init container mode", distribution: &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{
+			Distribution: tt.distribution,
+			Storage:      &aiv1.StorageSpec{Models: &aiv1.ModelStorageSpec{ClaimName: "models-rwx"}},
+		}}
+		if errs := validateModelStorage(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateCheckpoint(t *testing.T) {
+	tests := []struct {
+		name         string
+		distribution *aiv1.DistributionSpec
+		storage      aiv1.StorageSpec
+		wantErr      bool
+	}{
+		{name: "default distribution"},
+		{name: "ttl", storage: aiv1.StorageSpec{Checkpoint: &aiv1.CheckpointSpec{TTL: &metav1.Duration{Duration: time.Hour}}}},
+		{name: "negative ttl", storage: aiv1.StorageSpec{Checkpoint: &aiv1.CheckpointSpec{TTL: &metav1.Duration{Duration: -time.Hour}}}, wantErr: true},
+		{name: "shared model volume", storage: aiv1.StorageSpec{Models: &aiv1.ModelStorageSpec{ClaimName: "models-rwx"}}, wantErr: true},
+		{name: "peer serving", distribution: &aiv1.DistributionSpec{PeerServing: true}},
+		{name: "node cache", distribution: &aiv1.DistributionSpec{Strategy: aiv1.StrategyNodeCache}, wantErr: true},
+		{name: "versioned layout", distribution: &aiv1.DistributionSpec{Layout: aiv1.LayoutVersioned}, wantErr: true},
+		{name: "zone topology", distribution: &aiv1.DistributionSpec{Topology: aiv1.TopologyZone}, wantErr: true},
+		{name: "init container mode", distribution: &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		storage := tt.storage
+		if storage.Checkpoint == nil {
+			storage.Checkpoint = &aiv1.CheckpointSpec{}
+		}
+		storage.Checkpoint.ClaimName = "checkpoints"
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Distribution: tt.distribution, Storage: &storage}}
+		if errs := validateCheckpoint(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateNetworking(t *testing.T) {
+	tests := []struct {
+		name       string
+		networking *aiv1.NetworkingSpec
+		env        []corev1.EnvVar
+		wantErr    bool
+	}{
+		{name: "none"},
+		{name: "host network with rdma", networking: &aiv1.NetworkingSpec{
+			HostNetwork: true,
+			RDMA:        &aiv1.RDMASpec{Count: 2},
+			NCCLEnv:     map[string]string{"NCCL_IB_HCA": "mlx5", "NCCL_SOCKET_IFNAME": "eth0"},
+		}},
+		{name: "custom rdma resource", networking: &aiv1.NetworkingSpec{RDMA: &aiv1.RDMASpec{ResourceName: "rdma/hca_shared_devices_a"}}},
+		{name: "rdma resource without domain", networking: &aiv1.NetworkingSpec{RDMA: &aiv1.RDMASpec{ResourceName: "rdma"}}, wantErr: true},
+		{name: "rdma resource is gpu", networking: &aiv1.NetworkingSpec{RDMA: &aiv1.RDMASpec{ResourceName: "nvidia.com/gpu"}}, wantErr: true},
+		{name: "not nccl", networking: &aiv1.NetworkingSpec{NCCLEnv: map[string]string{"CUDA_VISIBLE_DEVICES": "0"}}, wantErr: true},
+		{
+			name:       "also in env",
+			networking: &aiv1.NetworkingSpec{NCCLEnv: map[string]string{"NCCL_DEBUG": "INFO"}},
+			env:        []corev1.EnvVar{{Name: "NCCL_DEBUG", Value: "WARN"}},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Networking: tt.networking, Env: tt.env}}
+		if errs := validateNetworking(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateRuntimeSocket(t *testing.T) {
+	initContainer := &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer}
+	tests := []struct {
+		name    string
+		spec    aiv1.LLMServiceSpec
+		wantErr bool
+	}{
+		{name: "off with tgi", spec: aiv1.LLMServiceSpec{Runtime: aiv1.RuntimeTGI}},
+		{name: "vllm", spec: aiv1.LLMServiceSpec{RuntimeSocket: true, Runtime: aiv1.RuntimeVLLM}},
+		{name: "llamacpp", spec: aiv1.LLMServiceSpec{RuntimeSocket: true, Runtime: aiv1.RuntimeLlamaCpp}},
+		{name: "tgi", spec: aiv1.LLMServiceSpec{RuntimeSocket: true, Runtime: aiv1.RuntimeTGI}, wantErr: true},
+		{name: "init container", spec: aiv1.LLMServiceSpec{RuntimeSocket: true, Distribution: initContainer}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if errs := validateRuntimeSocket(&aiv1.LLMService{Spec: tt.spec}); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateImages(t *testing.T) {
+	initContainer := &aiv1.DistributionSpec{Mode: aiv1.DistributionModeInitContainer}
+	tests := []struct {
+		name        string
+		spec        aiv1.LLMServiceSpec
+		wantErr     bool
+		wantWarning bool
+	}{
+		{name: "unset", spec: aiv1.LLMServiceSpec{Image: "vllm/vllm-openai:v0.6.3"}},
+		{name: "all roles", spec: aiv1.LLMServiceSpec{Distribution: initContainer, Images: &aiv1.ImagesSpec{
+			Runtime: "vllm/vllm-openai:v0.6.3", Agent: "kubeinfer-agent:slim", Gateway: "kubeinfer:v0.3.0"}}},
+		{name: "runtime and image", spec: aiv1.LLMServiceSpec{Image: "a:v1", Images: &aiv1.ImagesSpec{Runtime: "b:v1"}}, wantErr: true},
+		// agent 模式没有只运行 agent 的容器
+		{name: "agent in agent mode", spec: aiv1.LLMServiceSpec{Images: &aiv1.ImagesSpec{Agent: "kubeinfer-agent:slim"}}, wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		warnings, errs := validateImages(&aiv1.LLMService{Spec: tt.spec})
+		if (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+		if (len(warnings) > 0) != tt.wantWarning {
+			t.Errorf("%s: warnings = %v, wantWarning %v", tt.name, warnings, tt.wantWarning)
+		}
+	}
+}
+
+// fakeRegistry 只认识 tags 里的镜像
+type fakeRegistry struct {
+	tags map[string]string
+}
+
+func (f fakeRegistry) Resolve(_ context.Context, image string) (string, error) {
+	if d, ok := f.tags[image]; ok {
+		return d, nil
+	}
+	return "", fmt.Errorf("image %s not found", image)
+}
+
+func TestValidateImagePinning(t *testing.T) {
+	interval := func(d time.Duration) *aiv1.ImagePinningSpec {
+		return &aiv1.ImagePinningSpec{CheckInterval: &metav1.Duration{Duration: d}}
+	}
+	if errs := validateImagePinning(&aiv1.LLMService{Spec: aiv1.LLMServiceSpec{ImagePinning: interval(10 * time.Second)}}); len(errs) == 0 {
+		t.Error("a 10s check interval passed validation")
+	}
+	if errs := validateImagePinning(&aiv1.LLMService{Spec: aiv1.LLMServiceSpec{ImagePinning: interval(time.Hour)}}); len(errs) != 0 {
+		t.Errorf("errors = %v", errs)
+	}
+
+	v := &LLMServiceCustomValidator{Registry: fakeRegistry{tags: map[string]string{"vllm/vllm-openai:v0.6.3": "sha256:1"}}}
+	llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{
+		Image:        "vllm/vllm-openai:v0.6.3",
+		Images:       &aiv1.ImagesSpec{Gateway: "kubeinfer:missing", Agent: "kubeinfer@sha256:2"},
+		ImagePinning: &aiv1.ImagePinningSpec{},
+	}}
+	// 解析不了的 tag 只给 warning，已经带 digest 的不查询
+	if warnings := v.resolveImages(context.Background(), llm); len(warnings) != 1 || !strings.Contains(warnings[0], "kubeinfer:missing") {
+		t.Errorf("warnings = %v, want one for the missing gateway tag", warnings)
+	}
+	llm.Spec.ImagePinning = nil
+	if warnings := v.resolveImages(context.Background(), llm); len(warnings) != 0 {
+		t.Errorf("resolved images without spec.imagePinning: %v", warnings)
+	}
+}
+
+func TestValidatePodSecurity(t *testing.T) {
+	node := func(name, kubelet, kernel string) corev1.Node {
+		n := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		n.Status.NodeInfo.KubeletVersion, n.Status.NodeInfo.KernelVersion = kubelet, kernel
+		return n
+	}
+	nodes := []corev1.Node{node("gpu-new", "v1.31.2", "6.8.0-1015-aws"), node("gpu-old", "v1.29.4", "5.15.0-105-generic")}
+	tests := []struct {
+		name        string
+		spec        aiv1.LLMServiceSpec
+		wantErr     bool
+		wantWarning bool
+	}{
+		{name: "restricted", spec: aiv1.LLMServiceSpec{PodSecurity: &aiv1.PodSecuritySpec{Restricted: true}}},
+		{name: "restricted with host network", spec: aiv1.LLMServiceSpec{
+			PodSecurity: &aiv1.PodSecuritySpec{Restricted: true}, Networking: &aiv1.NetworkingSpec{HostNetwork: true}}, wantErr: true},
+		{name: "restricted with rdma", spec: aiv1.LLMServiceSpec{
+			PodSecurity: &aiv1.PodSecuritySpec{Restricted: true}, Networking: &aiv1.NetworkingSpec{RDMA: &aiv1.RDMASpec{}}}, wantErr: true},
+		{name: "restricted unconfined", spec: aiv1.LLMServiceSpec{PodSecurity: &aiv1.PodSecuritySpec{
+			Restricted: true, SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined}}}, wantErr: true},
+		{name: "localhost apparmor without profile", spec: aiv1.LLMServiceSpec{PodSecurity: &aiv1.PodSecuritySpec{
+			AppArmorProfile: &corev1.AppArmorProfile{Type: corev1.AppArmorProfileTypeLocalhost}}}, wantErr: true},
+		// 有一个节点的 kubelet 和内核太老
+		{name: "user namespace", spec: aiv1.LLMServiceSpec{PodSecurity: &aiv1.PodSecuritySpec{UserNamespace: true}}, wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		warnings, errs := validatePodSecurity(&aiv1.LLMService{Spec: tt.spec}, nodes)
+		if (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+		if (len(warnings) > 0) != tt.wantWarning {
+			t.Errorf("%s: warnings = %v, wantWarning %v", tt.name, warnings, tt.wantWarning)
+		}
+	}
+	if warnings, _ := validatePodSecurity(&aiv1.LLMService{Spec: aiv1.LLMServiceSpec{
+		PodSecurity: &aiv1.PodSecuritySpec{UserNamespace: true}}}, nodes[:1]); len(warnings) != 0 {
+		t.Errorf("warnings = %v, want none when every node qualifies", warnings)
+	}
+}
+
+func TestValidateTensorParallel(t *testing.T) {
+	tp := func(v int32) *aiv1.VLLMSpec { return &aiv1.VLLMSpec{TensorParallelSize: &v} }
+	tests := []struct {
+		name        string
+		model       string
+		gpus        int32
+		vllm        *aiv1.VLLMSpec
+		wantWarning bool
+		wantErr     bool
+	}{
+		{name: "derived", model: "meta-llama/Llama-3.1-70B-Instruct", gpus: 8},
+		{name: "derived leaves gpus idle", model: "Qwen/Qwen2.5-7B-Instruct", gpus: 8, wantWarning: true},
+		{name: "explicit", model: "Qwen/Qwen2.5-7B-Instruct", gpus: 8, vllm: tp(4)},
+		{name: "explicit not divisible", model: "Qwen/Qwen2.5-7B-Instruct", gpus: 8, vllm: tp(8), wantErr: true},
+		{name: "explicit more than gpus", model: "meta-llama/Llama-3.1-70B-Instruct", gpus: 2, vllm: tp(4), wantErr: true},
+		{name: "unknown model", model: "my-org/custom", gpus: 3, vllm: tp(3)},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Model: tt.model, GpuPerReplica: tt.gpus, VLLM: tt.vllm}}
+		warnings, errs := validateTensorParallel(llm)
+		if (len(warnings) > 0) != tt.wantWarning || (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: warnings = %v, errors = %v, want warning %v, error %v", tt.name, warnings, errs, tt.wantWarning, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateAutoscaling(t *testing.T) {
+	external := &aiv1.AutoscalingSpec{Mode: aiv1.AutoscalingModeExternal}
+	maxReplicas, target, zero := int32(4), int32(8), int32(0)
+	tests := []struct {
+		name        string
+		replicas    int32
+		autoscaling *aiv1.AutoscalingSpec
+		idleTimeout *metav1.Duration
+		slo         *aiv1.SLOSpec
+		wantErr     bool
+	}{
+		{name: "none", idleTimeout: &metav1.Duration{Duration: time.Hour}},
+		{name: "external", autoscaling: external, slo: &aiv1.SLOSpec{Availability: "99.9"}},
+		{name: "builtin with hibernation", autoscaling: &aiv1.AutoscalingSpec{Mode: aiv1.AutoscalingModeBuiltin}, idleTimeout: &metav1.Duration{Duration: time.Hour}},
+		{name: "external with hibernation", autoscaling: external, idleTimeout: &metav1.Duration{Duration: time.Hour}, wantErr: true},
+		{name: "external with slo scaling", autoscaling: external, slo: &aiv1.SLOSpec{Availability: "99.9", MaxReplicas: &maxReplicas}, wantErr: true},
+		{name: "keda", autoscaling: &aiv1.AutoscalingSpec{Mode: aiv1.AutoscalingModeKEDA, MaxReplicas: 4, TargetQueueDepth: &target}},
+		{name: "keda scale to zero", autoscaling: &aiv1.AutoscalingSpec{Mode: aiv1.AutoscalingModeKEDA, MinReplicas: &zero, MaxReplicas: 1, TargetTokensPerSecond: &target}},
+		{name: "keda without max", autoscaling: &aiv1.AutoscalingSpec{Mode: aiv1.AutoscalingModeKEDA, TargetQueueDepth: &target}, wantErr: true},
+		{name: "keda max below spec.replicas", autoscaling: &aiv1.AutoscalingSpec{Mode: aiv1.AutoscalingModeKEDA, MaxReplicas: 1, TargetQueueDepth: &target}, replicas: 2, wantErr: true},
+		{name: "keda without target", autoscaling: &aiv1.AutoscalingSpec{Mode: aiv1.AutoscalingModeKEDA, MaxReplicas: 4}, wantErr: true},
+		{name: "keda with hibernation", autoscaling: &aiv1.AutoscalingSpec{Mode: aiv1.AutoscalingModeKEDA, MaxReplicas: 4, TargetQueueDepth: &target}, idleTimeout: &metav1.Duration{Duration: time.Hour}, wantErr: true},
+		{name: "keda fields in external mode", autoscaling: &aiv1.AutoscalingSpec{Mode: aiv1.AutoscalingModeExternal, MaxReplicas: 4}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{
+			Replicas:    tt.replicas,
+			Autoscaling: tt.autoscaling,
+			IdleTimeout: tt.idleTimeout,
+			SLO:         tt.slo,
+		}}
+		if errs := validateAutoscaling(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateRollout(t *testing.T) {
+	intOrPercent := func(s string) *intstr.IntOrString {
+		v := intstr.Parse(s)
+		return &v
+	}
+	grace := func(seconds int64) *int64 { return &seconds }
+	tests := []struct {
+		name        string
+		rollout     *aiv1.RolloutSpec
+		maxGen      *metav1.Duration
+		wantErr     bool
+		wantWarning bool
+	}{
+		{name: "none"},
+		{name: "no surge", rollout: &aiv1.RolloutSpec{MaxSurge: intOrPercent("0"), MaxUnavailable: intOrPercent("1")}},
+		{name: "percentages", rollout: &aiv1.RolloutSpec{MaxSurge: intOrPercent("50%"), MaxUnavailable: intOrPercent("0%")}},
+		{name: "both zero", rollout: &aiv1.RolloutSpec{MaxSurge: intOrPercent("0"), MaxUnavailable: intOrPercent("0%")}, wantErr: true},
+		{name: "invalid percentage", rollout: &aiv1.RolloutSpec{MaxSurge: intOrPercent("half")}, wantErr: true},
+		{name: "negative", rollout: &aiv1.RolloutSpec{MaxUnavailable: intOrPercent("-1")}, wantErr: true},
+		{name: "grace period covers generation", rollout: &aiv1.RolloutSpec{TerminationGracePeriodSeconds: grace(600)},
+			maxGen: &metav1.Duration{Duration: 5 * time.Minute}},
+		{name: "grace period shorter than generation", rollout: &aiv1.RolloutSpec{TerminationGracePeriodSeconds: grace(60)},
+			maxGen: &metav1.Duration{Duration: 5 * time.Minute}, wantWarning: true},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Rollout: tt.rollout, MaxGenerationTime: tt.maxGen}}
+		warnings, errs := validateRollout(llm)
+		if (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+		if (len(warnings) > 0) != tt.wantWarning {
+			t.Errorf("%s: warnings = %v, wantWarning %v", tt.name, warnings, tt.wantWarning)
+		}
+	}
+}
+
+func TestValidateExpose(t *testing.T) {
+	className := "nginx"
+	tests := []struct {
+		name    string
+		expose  *aiv1.ExposeSpec
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "ingress", expose: &aiv1.ExposeSpec{Type: aiv1.ExposeTypeIngress, Host: "qwen.example.com",
+			TLSSecretRef: &corev1.LocalObjectReference{Name: "qwen-tls"}, IngressClassName: &className}},
+		{name: "ingress without host", expose: &aiv1.ExposeSpec{Type: aiv1.ExposeTypeIngress}, wantErr: true},
+		{name: "invalid host", expose: &aiv1.ExposeSpec{Type: aiv1.ExposeTypeIngress, Host: "Qwen_Example"}, wantErr: true},
+		{name: "gateway api", expose: &aiv1.ExposeSpec{Type: aiv1.ExposeTypeGatewayAPI, Host: "qwen.example.com",
+			GatewayRef: &aiv1.GatewayReference{Name: "public", Namespace: "infra"}}},
+		{name: "gateway api without gateway", expose: &aiv1.ExposeSpec{Type: aiv1.ExposeTypeGatewayAPI, Host: "qwen.example.com"}, wantErr: true},
+		{name: "gateway api with tls secret", expose: &aiv1.ExposeSpec{Type: aiv1.ExposeTypeGatewayAPI, Host: "qwen.example.com",
+			GatewayRef: &aiv1.GatewayReference{Name: "public"}, TLSSecretRef: &corev1.LocalObjectReference{Name: "qwen-tls"}}, wantErr: true},
+		{name: "load balancer", expose: &aiv1.ExposeSpec{Type: aiv1.ExposeTypeLoadBalancer}},
+		{name: "load balancer with gateway", expose: &aiv1.ExposeSpec{Type: aiv1.ExposeTypeLoadBalancer,
+			GatewayRef: &aiv1.GatewayReference{Name: "public"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		llm := &aiv1.LLMService{Spec: aiv1.LLMServiceSpec{Expose: tt.expose}}
+		if errs := validateExpose(llm); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("%s: errors = %v, wantErr %v", tt.name, errs, tt.wantErr)
+		}
+	}
+}
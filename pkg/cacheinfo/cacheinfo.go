@@ -0,0 +1,158 @@
+// Package cacheinfo 定义 <llm>-cache ConfigMap 的内容格式
+//
+// controller 写、agent 读，两边都用这个包，避免 key 名不一致
+// （以前 agent 读 "coordinator-pod"，文档里写的是 "coordinator"，谁也对不上）。
+//
+// ConfigMap 只放协调用的数据（谁是 coordinator）；
+// 给用户看的模型信息放在 LLMService 的 Status.Model 里。
+// coordinator Service 的名称和角色 label 也在这里定义。
+//
+// 格式：
+//
+//	data:
+//	  schemaVersion: "1"
+//	  cacheInfo: '{"coordinator":"qwen-deployment-abc","coordinatorNode":"gpu-node-1"}'
+package cacheinfo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+const (
+	// SchemaVersion 是当前的格式版本，格式不兼容地变化时加一
+	SchemaVersion = 1
+
+	// SchemaVersionKey 存格式版本号的 key
+	SchemaVersionKey = "schemaVersion"
+
+	// DataKey 存 CacheInfo JSON 的 key
+	DataKey = "cacheInfo"
+
+	// legacyCoordinatorKey 是没有版本号的旧格式里 coordinator 的 key
+	legacyCoordinatorKey = "coordinator-pod"
+)
+
+// CacheInfo 是 <llm>-cache ConfigMap 里的协调数据
+type CacheInfo struct {
+	// Coordinator 是当前持有 Lease 的 Pod 名称，空表示还没有选出来
+	Coordinator string `json:"coordinator,omitempty"`
+
+	// CoordinatorNode 是 coordinator 所在的节点
+	CoordinatorNode string `json:"coordinatorNode,omitempty"`
+
+	// DownloadGrantedTo 是分到集群下载名额的 Pod，启用了下载排队（DownloadSchedulingEnv）时
+	// coordinator 要等这里是自己才开始从上游下载
+	DownloadGrantedTo string `json:"downloadGrantedTo,omitempty"`
+}
+
+// coordinator Service：follower 通过固定的 DNS 名字访问 coordinator，而不是每次查 Pod IP
+//
+// agent 当选时把自己 Pod 的 RoleLabel 改成 RoleCoordinator，失去身份时改回 RoleFollower；
+// controller 创建 <llm>-coordinator Service 选择带 RoleCoordinator 的 Pod，
+// 并通过 CoordinatorServiceEnv 把 Service 的地址告诉 agent
+const (
+	// RoleLabel 是 Pod 当前角色的 label
+	RoleLabel = "kubeinfer.io/role"
+
+	RoleCoordinator = "coordinator"
+	RoleFollower    = "follower"
+
+	// CoordinatorServiceEnv 是 coordinator Service 的地址（<llm>-coordinator.<namespace>.svc），
+	// 没有设置时 agent 按 Lease 查 coordinator 的 Pod IP
+	CoordinatorServiceEnv = "COORDINATOR_SERVICE"
+)
+
+// coordinator 的供货状态：agent 写在自己 Pod 的注解上，controller 据此决定是否阻止驱逐
+const (
+	// ActiveTransfersAnnotation 是正在从 coordinator 下载的 follower 数
+	ActiveTransfersAnnotation = "kubeinfer.io/active-transfers"
+	// TransfersSinceAnnotation 是这一轮供货开始的时间（RFC3339），follower 数降到 0 时清除；
+	// 只用于观察，驱逐保护的 maxDuration 由 controller 从创建 PDB 开始计时
+	TransfersSinceAnnotation = "kubeinfer.io/transfers-since"
+)
+
+// PinnedCoordinatorAnnotation 是 coordinator lease 上被人工固定的 coordinator Pod 名称（spec.coordination.pinnedPod）
+//
+// controller 确认 Pod 可用之后才写，Pod 不可用时删掉；agent 的 LeaseManager 按它决定谁接管
+const PinnedCoordinatorAnnotation = "kubeinfer.io/pinned-coordinator"
+
+// EvictedCoordinatorAnnotation 是 coordinator lease 上被 controller 强制让出的 coordinator Pod 名称
+//
+// 节点 NotReady 时容器可能还活着（只是 kubelet 挂了），它看到没有持有者的 lease 会马上再抢回来。
+// agent 的 LeaseManager 不接管写着自己名字的 lease；Pod 消失或者节点恢复 Ready 之后 controller 删掉
+const EvictedCoordinatorAnnotation = "kubeinfer.io/evicted-coordinator"
+
+// 集群下载排队：agent 在 Pod 注解上声明自己要下载，controller 按名额在 ConfigMap 里放行
+const (
+	// DownloadSchedulingEnv 为 "true" 时 coordinator 从上游下载前要排队
+	DownloadSchedulingEnv = "DOWNLOAD_SCHEDULING"
+
+	// DownloadStateAnnotation 是 coordinator 的下载状态：DownloadWaiting 或 DownloadActive，下载结束后删除
+	DownloadStateAnnotation = "kubeinfer.io/download-state"
+
+	DownloadWaiting = "waiting"
+	DownloadActive  = "downloading"
+)
+
+// CoordinatorServiceName 返回 coordinator Service 的名称
+func CoordinatorServiceName(llmName string) string {
+	return llmName + "-coordinator"
+}
+
+// AgentDebugSecretName 返回 agent admin API（/debug/）token 的 Secret 名称，token 在 AgentDebugTokenKey 下
+//
+// controller 创建它，kubectl kubeinfer status 读它
+func AgentDebugSecretName(llmName string) string {
+	return llmName + "-agent-debug"
+}
+
+// AgentDebugTokenKey 是 AgentDebugSecretName 里 token 的 key
+const AgentDebugTokenKey = "token"
+
+// ConfigMapName 返回 LLMService 对应的 ConfigMap 名称
+//
+// agent 通过 CONFIGMAP_NAME 环境变量拿到这个名字，Lease 名称也是在它后面加 "-lease"
+func ConfigMapName(llmName string) string {
+	return llmName + "-cache"
+}
+
+// Encode 把 CacheInfo 序列化成 ConfigMap 的 data
+func Encode(info CacheInfo) (map[string]string, error) {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		SchemaVersionKey: strconv.Itoa(SchemaVersion),
+		DataKey:          string(data),
+	}, nil
+}
+
+// Parse 从 ConfigMap 的 data 解析 CacheInfo
+//
+// - 没有 schemaVersion：旧格式，只认 coordinator-pod
+// - 版本比当前新：agent 比 controller 旧，拒绝解析而不是猜
+func Parse(data map[string]string) (*CacheInfo, error) {
+	raw, ok := data[SchemaVersionKey]
+	if !ok {
+		return &CacheInfo{Coordinator: data[legacyCoordinatorKey]}, nil
+	}
+
+	version, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", SchemaVersionKey, raw, err)
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("unsupported cache info schema version %d (max %d)", version, SchemaVersion)
+	}
+
+	info := &CacheInfo{}
+	if payload := data[DataKey]; payload != "" {
+		if err := json.Unmarshal([]byte(payload), info); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", DataKey, err)
+		}
+	}
+	return info, nil
+}
@@ -0,0 +1,50 @@
+package cacheinfo
+
+import "testing"
+
+func TestEncodeParseRoundTrip(t *testing.T) {
+	in := CacheInfo{Coordinator: "qwen-deployment-abc", CoordinatorNode: "gpu-node-1"}
+	data, err := Encode(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[SchemaVersionKey] != "1" {
+		t.Errorf("schemaVersion = %q, want 1", data[SchemaVersionKey])
+	}
+
+	out, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *out != in {
+		t.Errorf("Parse() = %+v, want %+v", *out, in)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]string
+		want    string
+		wantErr bool
+	}{
+		{"legacy format", map[string]string{"coordinator-pod": "pod-0", "coordinator-ip": "10.0.0.5"}, "pod-0", false},
+		{"empty", map[string]string{}, "", false},
+		{"no payload", map[string]string{SchemaVersionKey: "1"}, "", false},
+		{"newer schema", map[string]string{SchemaVersionKey: "2", DataKey: `{}`}, "", true},
+		{"bad version", map[string]string{SchemaVersionKey: "v1"}, "", true},
+		{"bad payload", map[string]string{SchemaVersionKey: "1", DataKey: "{"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := Parse(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && info.Coordinator != tt.want {
+				t.Errorf("Coordinator = %q, want %q", info.Coordinator, tt.want)
+			}
+		})
+	}
+}
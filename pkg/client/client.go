@@ -0,0 +1,126 @@
+// Package client 是 ai.ruijie.io API 组的 Go 客户端
+//
+// 外部工具（CI 流水线、自定义 autoscaler、看板）读写 LLMService 时不需要复制 API 类型，
+// 也不需要自己拼 scheme：
+//
+//	c, err := client.NewForConfig(ctrl.GetConfigOrDie())
+//	llm, err := c.LLMServices("default").Get(ctx, "qwen")
+//
+//	informer := client.NewLLMServiceInformer(c, "", 10*time.Minute)
+//	informer.AddEventHandler(...)
+//	go informer.Run(ctx.Done())
+//	lister := client.NewLLMServiceLister(informer.GetIndexer())
+//
+// 底层是 controller-runtime 的 client（不是 client-gen 生成的 clientset），
+// 类型化的方法只是薄薄一层包装，需要更多功能时可以用 Raw() 拿到原始 client。
+package client
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/rest"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// NewScheme 返回注册了 ai.ruijie.io/v1 的 scheme
+func NewScheme() (*runtime.Scheme, error) {
+	scheme := runtime.NewScheme()
+	if err := aiv1.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	return scheme, nil
+}
+
+// Client 是 LLMService 的客户端
+type Client struct {
+	c ctrlclient.WithWatch
+}
+
+// NewForConfig 用 rest.Config 创建 Client
+func NewForConfig(cfg *rest.Config) (*Client, error) {
+	scheme, err := NewScheme()
+	if err != nil {
+		return nil, err
+	}
+	c, err := ctrlclient.NewWithWatch(cfg, ctrlclient.Options{Scheme: scheme})
+	if err != nil {
+		return nil, err
+	}
+	return &Client{c: c}, nil
+}
+
+// NewFromClient 包装已有的 controller-runtime client（scheme 里需要有 ai.ruijie.io/v1），测试时传 fake client
+func NewFromClient(c ctrlclient.WithWatch) *Client {
+	return &Client{c: c}
+}
+
+// Raw 返回底层的 controller-runtime client
+func (c *Client) Raw() ctrlclient.WithWatch {
+	return c.c
+}
+
+// LLMServices 返回 namespace 里 LLMService 的客户端，namespace 为空表示所有 namespace（只能 List/Watch）
+func (c *Client) LLMServices(namespace string) *LLMServiceClient {
+	return &LLMServiceClient{c: c.c, namespace: namespace}
+}
+
+// LLMServiceClient 读写一个 namespace 里的 LLMService
+type LLMServiceClient struct {
+	c         ctrlclient.WithWatch
+	namespace string
+}
+
+// Get 读取名为 name 的 LLMService
+func (l *LLMServiceClient) Get(ctx context.Context, name string) (*aiv1.LLMService, error) {
+	llm := &aiv1.LLMService{}
+	if err := l.c.Get(ctx, ctrlclient.ObjectKey{Namespace: l.namespace, Name: name}, llm); err != nil {
+		return nil, err
+	}
+	return llm, nil
+}
+
+// List 列出 LLMService，可以加 ctrlclient.MatchingLabels 等过滤条件
+func (l *LLMServiceClient) List(ctx context.Context, opts ...ctrlclient.ListOption) (*aiv1.LLMServiceList, error) {
+	list := &aiv1.LLMServiceList{}
+	if err := l.c.List(ctx, list, l.listOptions(opts)...); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Watch 监听 LLMService 的变化
+func (l *LLMServiceClient) Watch(ctx context.Context, opts ...ctrlclient.ListOption) (watch.Interface, error) {
+	return l.c.Watch(ctx, &aiv1.LLMServiceList{}, l.listOptions(opts)...)
+}
+
+// Create 创建 LLMService，namespace 为空时使用客户端的 namespace
+func (l *LLMServiceClient) Create(ctx context.Context, llm *aiv1.LLMService) error {
+	if llm.Namespace == "" {
+		llm.Namespace = l.namespace
+	}
+	return l.c.Create(ctx, llm)
+}
+
+// Update 更新 spec/metadata
+func (l *LLMServiceClient) Update(ctx context.Context, llm *aiv1.LLMService) error {
+	return l.c.Update(ctx, llm)
+}
+
+// Delete 删除名为 name 的 LLMService
+func (l *LLMServiceClient) Delete(ctx context.Context, name string) error {
+	return l.c.Delete(ctx, &aiv1.LLMService{
+		ObjectMeta: metav1.ObjectMeta{Namespace: l.namespace, Name: name},
+	})
+}
+
+func (l *LLMServiceClient) listOptions(opts []ctrlclient.ListOption) []ctrlclient.ListOption {
+	if l.namespace == "" {
+		return opts
+	}
+	return append([]ctrlclient.ListOption{ctrlclient.InNamespace(l.namespace)}, opts...)
+}
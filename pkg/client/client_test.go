@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func newLLM(namespace, name, team string) *aiv1.LLMService {
+	return &aiv1.LLMService{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: map[string]string{"team": team}},
+		Spec:       aiv1.LLMServiceSpec{Model: "Qwen/Qwen2.5-0.5B", Replicas: 1},
+	}
+}
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	scheme, err := NewScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewFromClient(fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(newLLM("team-a", "qwen", "a"), newLLM("team-b", "llama", "b")).Build())
+}
+
+func TestLLMServiceClient(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t)
+
+	llm, err := c.LLMServices("team-a").Get(ctx, "qwen")
+	if err != nil || llm.Spec.Model != "Qwen/Qwen2.5-0.5B" {
+		t.Fatalf("Get() = %v, %v", llm, err)
+	}
+
+	all, err := c.LLMServices("").List(ctx)
+	if err != nil || len(all.Items) != 2 {
+		t.Fatalf("List() across namespaces = %v, %v, want 2 items", all, err)
+	}
+	teamB, err := c.LLMServices("").List(ctx, ctrlclient.MatchingLabels{"team": "b"})
+	if err != nil || len(teamB.Items) != 1 || teamB.Items[0].Name != "llama" {
+		t.Fatalf("List() by label = %v, %v", teamB, err)
+	}
+
+	if err := c.LLMServices("team-a").Create(ctx, newLLM("", "mistral", "a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.LLMServices("team-a").Get(ctx, "mistral"); err != nil {
+		t.Errorf("created object not found in the client namespace: %v", err)
+	}
+	if err := c.LLMServices("team-a").Delete(ctx, "mistral"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.LLMServices("team-a").Get(ctx, "mistral"); !errors.IsNotFound(err) {
+		t.Errorf("Get() after delete = %v, want NotFound", err)
+	}
+}
+
+func TestLLMServiceInformer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := newTestClient(t)
+
+	informer := NewLLMServiceInformer(c, "", 0)
+	go informer.Run(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		t.Fatal("informer did not sync")
+	}
+	lister := NewLLMServiceLister(informer.GetIndexer())
+
+	all, err := lister.List(labels.Everything())
+	if err != nil || len(all) != 2 {
+		t.Fatalf("List() = %d items, %v, want 2", len(all), err)
+	}
+	teamA, err := lister.LLMServices("team-a").List(labels.Everything())
+	if err != nil || len(teamA) != 1 {
+		t.Fatalf("namespace List() = %d items, %v, want 1", len(teamA), err)
+	}
+	if _, err := lister.LLMServices("team-a").Get("llama"); !errors.IsNotFound(err) {
+		t.Errorf("Get() of an object in another namespace = %v, want NotFound", err)
+	}
+
+	// watch 到新建的对象
+	if err := c.LLMServices("team-b").Create(ctx, newLLM("", "mistral", "b")); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := lister.LLMServices("team-b").Get("mistral"); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("informer did not observe the created LLMService")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
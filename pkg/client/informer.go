@@ -0,0 +1,98 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+// NewLLMServiceInformer 创建 LLMService 的 SharedIndexInformer
+//
+// namespace 为空时监听所有 namespace；resync 为 0 时不定期重放。
+// 带 namespace 索引（cache.NamespaceIndex），NewLLMServiceLister 按 namespace 查询时用到。
+func NewLLMServiceInformer(c *Client, namespace string, resync time.Duration) cache.SharedIndexInformer {
+	llms := c.LLMServices(namespace)
+	lw := listWatch{&cache.ListWatch{
+		ListWithContextFunc: func(ctx context.Context, options metav1.ListOptions) (runtime.Object, error) {
+			return llms.List(ctx, &ctrlclient.ListOptions{Raw: &options})
+		},
+		WatchFuncWithContext: func(ctx context.Context, options metav1.ListOptions) (watch.Interface, error) {
+			return llms.Watch(ctx, &ctrlclient.ListOptions{Raw: &options})
+		},
+	}}
+	return cache.NewSharedIndexInformer(lw, &aiv1.LLMService{}, resync,
+		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+}
+
+// listWatch 让 reflector 用传统的 List + Watch，而不是 WatchList（streaming list）
+//
+// WatchList 依赖 API server 发 initial events 和 bookmark，controller-runtime 的 fake client
+// 和老版本的 API server 都不发，informer 会一直等不到同步完成
+type listWatch struct {
+	*cache.ListWatch
+}
+
+// IsWatchListSemanticsUnSupported 见 k8s.io/client-go/util/watchlist
+func (listWatch) IsWatchListSemanticsUnSupported() bool { return true }
+
+// LLMServiceLister 从 informer 的本地缓存读取 LLMService，不访问 API server
+//
+// 返回的对象和缓存共享，修改之前先 DeepCopy
+type LLMServiceLister struct {
+	indexer cache.Indexer
+}
+
+// NewLLMServiceLister 用 informer.GetIndexer() 创建 Lister
+func NewLLMServiceLister(indexer cache.Indexer) *LLMServiceLister {
+	return &LLMServiceLister{indexer: indexer}
+}
+
+// List 返回所有 namespace 里匹配 selector 的 LLMService
+func (l *LLMServiceLister) List(selector labels.Selector) ([]*aiv1.LLMService, error) {
+	var out []*aiv1.LLMService
+	err := cache.ListAll(l.indexer, selector, func(obj any) {
+		out = append(out, obj.(*aiv1.LLMService))
+	})
+	return out, err
+}
+
+// LLMServices 返回一个 namespace 的 Lister
+func (l *LLMServiceLister) LLMServices(namespace string) *LLMServiceNamespaceLister {
+	return &LLMServiceNamespaceLister{indexer: l.indexer, namespace: namespace}
+}
+
+// LLMServiceNamespaceLister 读取一个 namespace 里的 LLMService
+type LLMServiceNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List 返回 namespace 里匹配 selector 的 LLMService
+func (l *LLMServiceNamespaceLister) List(selector labels.Selector) ([]*aiv1.LLMService, error) {
+	var out []*aiv1.LLMService
+	err := cache.ListAllByNamespace(l.indexer, l.namespace, selector, func(obj any) {
+		out = append(out, obj.(*aiv1.LLMService))
+	})
+	return out, err
+}
+
+// Get 返回名为 name 的 LLMService，不存在时返回 NotFound 错误
+func (l *LLMServiceNamespaceLister) Get(name string) (*aiv1.LLMService, error) {
+	obj, exists, err := l.indexer.GetByKey(l.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(aiv1.GroupVersion.WithResource("llmservices").GroupResource(), name)
+	}
+	return obj.(*aiv1.LLMService), nil
+}
@@ -12,6 +12,7 @@
 package metrics
 
 import (
+	"k8s.io/apimachinery/pkg/types"
 	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics" // ← 改这里，加一个别名
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -25,11 +26,17 @@ import (
 // - 不同的 goroutine 都可以安全地记录数据（Prometheus 保证线程安全）
 
 var (
-	LLMServiceTotal = prometheus.NewGauge(
+	/*
+		// 用途：每个 namespace 有多少个 LLMService（fleet 规模）
+		// controller 定期从 informer 缓存里数一遍（internal/controller/metrics_sync.go），
+		// 所有 namespace 加起来用 sum(kubeinfer_llmservice_total)
+	*/
+	LLMServiceTotal = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "kubeinfer_llmservice_total",  // Metric 名称（必须唯一）
 			Help: "Total number of LLMServices", // 描述（会显示在 Prometheus UI）
 		},
+		[]string{"namespace"},
 	)
 	/*
 		// 用途：记录每个 LLMService 有多少个 Ready 的 Pod
@@ -51,6 +58,19 @@ var (
 		},
 		[]string{"namespace", "name"}, // 定义标签的 key
 	)
+	/*
+		// 用途：LLMService 当前的服务状态，和 Degraded condition 一致
+		// 每个 LLMService 每个 state 一条序列，当前状态为 1，其余为 0：
+		//     kubeinfer_llmservice_serving_state{state="degraded"} == 1     → warning
+		//     kubeinfer_llmservice_serving_state{state="unavailable"} == 1  → page
+	*/
+	LLMServiceServingState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubeinfer_llmservice_serving_state",
+			Help: "Current serving state per LLMService (available, degraded, unavailable, scaled_to_zero)",
+		},
+		[]string{"namespace", "name", "state"},
+	)
 	/*
 		// 用途：记录每个 LLMService 的 Coordinator 选举了多少次
 		// 类型选择：Counter，因为：
@@ -113,9 +133,10 @@ var (
 		// - 异常检测：reconcile 次数突然激增 → 可能有问题
 		//
 		// 标签 result 的作用：
-		// - "success": reconcile 成功
-		// - "error": reconcile 出错
-		// - 计算错误率：error / (success + error)
+		// - "success": reconcile 成功，不需要再检查
+		// - "requeue": reconcile 成功，但要求过一段时间再检查（等 Pod Ready、定时评估 SLO 等）
+		// - "error": reconcile 出错，会按退避重试；具体原因见 kubeinfer_reconcile_errors_total
+		// - 计算错误率：error / 全部
 	*/
 	ReconcileTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
@@ -124,6 +145,31 @@ var (
 		},
 		[]string{"controller", "result"},
 	)
+	/*
+		// ReconcileErrors 按原因统计失败的 reconcile
+		//
+		// reason 是归类后的错误（conflict、not_found、forbidden、timeout、throttled、invalid、
+		// no_kind_match、terminal、unknown），不是错误信息原文，避免标签基数爆炸
+	*/
+	ReconcileErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeinfer_reconcile_errors_total",
+			Help: "Total number of failed reconciliations by error reason",
+		},
+		[]string{"controller", "reason"},
+	)
+	/*
+		// ReconcileQueueDepth 是 controller workqueue 里等待 reconcile 的对象数
+		//
+		// 持续增长说明 reconcile 跟不上事件速度（API server 慢、并发数太低）
+	*/
+	ReconcileQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubeinfer_reconcile_queue_depth",
+			Help: "Number of objects waiting in the controller work queue",
+		},
+		[]string{"controller"},
+	)
 	/*
 		// ReconcileDuration 是一个 HistogramVec
 		//
@@ -144,6 +190,267 @@ var (
 		},
 		[]string{"controller"},
 	)
+	/*
+		// 费用统计相关的 GaugeVec（chargeback 用）
+		//
+		// 为什么是 Gauge 而不是 Counter？
+		// - 值来自 Status.CostReport 的累计值，controller 重启后会从 status 恢复
+		// - Counter 在进程重启后从 0 开始，和 status 对不上
+	*/
+	LLMServiceGPUHours = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubeinfer_llmservice_gpu_hours",
+			Help: "Accumulated GPU hours consumed per LLMService",
+		},
+		[]string{"namespace", "name"},
+	)
+	LLMServiceGPUUtilization = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubeinfer_llmservice_gpu_utilization_ratio",
+			Help: "GPU-time weighted average GPU utilization (0-1) per LLMService",
+		},
+		[]string{"namespace", "name"},
+	)
+	LLMServiceEstimatedCost = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubeinfer_llmservice_estimated_cost",
+			Help: "Estimated accumulated GPU cost per LLMService",
+		},
+		[]string{"namespace", "name"},
+	)
+	/*
+		// Gateway 相关指标
+		//
+		// 每个 LLMService 的 gateway Pod 都会暴露这些指标，
+		// 休眠、SLO、扩缩容都依赖这里的请求统计。
+	*/
+	GatewayRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeinfer_gateway_requests_total",
+			Help: "Total number of requests proxied by the gateway",
+		},
+		[]string{"namespace", "name", "code"},
+	)
+	GatewayRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "kubeinfer_gateway_request_duration_seconds",
+			Help:    "End-to-end request latency observed by the gateway",
+			Buckets: prometheus.ExponentialBuckets(0.05, 2, 12),
+		},
+		[]string{"namespace", "name"},
+	)
+	GatewayInflightRequests = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubeinfer_gateway_inflight_requests",
+			Help: "Number of requests currently being proxied by the gateway",
+		},
+		[]string{"namespace", "name"},
+	)
+	GatewaySpilledRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeinfer_gateway_spilled_requests_total",
+			Help: "Requests spilled to another cluster's gateway because no local replica was ready",
+		},
+		[]string{"namespace", "name", "target", "code"},
+	)
+	/*
+		// token 用量（gateway 暴露），按 API key 指纹和模型区分，用于内部计费
+		// type 是 prompt 或 completion
+	*/
+	GatewayTokens = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeinfer_gateway_tokens_total",
+			Help: "Prompt and completion tokens metered by the gateway, per API key fingerprint and model",
+		},
+		[]string{"namespace", "name", "key", "model", "type"},
+	)
+	GatewayGuardrailRejections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeinfer_gateway_guardrail_rejections_total",
+			Help: "Requests rejected by a gateway guardrail before reaching the inference server",
+		},
+		[]string{"namespace", "name", "guardrail"},
+	)
+	/*
+		// vLLM watchdog 指标（agent 暴露）
+		//
+		// 卡死检测：进程活着但生成卡住时 watchdog 会重启 vLLM，
+		// trips 持续增长说明模型或 GPU 有问题，需要告警。
+	*/
+	VLLMWatchdogTrips = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeinfer_vllm_watchdog_trips_total",
+			Help: "Number of times the watchdog restarted a stalled vLLM process",
+		},
+		[]string{"namespace", "pod"},
+	)
+	VLLMWatchdogProbeDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "kubeinfer_vllm_watchdog_probe_duration_seconds",
+			Help:    "Latency of the watchdog generation probe",
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 12),
+		},
+		[]string{"namespace", "pod", "result"},
+	)
+	/*
+		// vLLM 日志解析出的指标（agent 暴露）
+		// - log events: OOM、引擎崩溃、加载完成等按 reason 计数
+		// - throughput: vLLM 每隔几秒打印一次的吞吐量
+	*/
+	VLLMLogEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeinfer_vllm_log_events_total",
+			Help: "Number of known vLLM log events (OOM, engine crash, model loaded) by reason",
+		},
+		[]string{"namespace", "pod", "reason"},
+	)
+	VLLMPromptThroughput = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubeinfer_vllm_prompt_throughput_tokens_per_second",
+			Help: "Average prompt throughput reported in vLLM logs",
+		},
+		[]string{"namespace", "pod"},
+	)
+	VLLMGenerationThroughput = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubeinfer_vllm_generation_throughput_tokens_per_second",
+			Help: "Average generation throughput reported in vLLM logs",
+		},
+		[]string{"namespace", "pod"},
+	)
+	/*
+		// coordinator 从 HuggingFace 下载的带宽（agent 暴露）
+		// - bytes: 累计写入磁盘的字节数，rate() 就是平均带宽
+		// - throughput: 所有连接加起来的瞬时带宽，下载结束后归零
+		//
+		// 为什么单独看带宽？
+		// - 冷启动时间基本就是下载时间，带宽上不去时调大 spec.distribution.download.concurrency
+	*/
+	ModelDownloadBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeinfer_model_download_bytes_total",
+			Help: "Bytes downloaded from HuggingFace by the coordinator",
+		},
+		[]string{"namespace", "pod"},
+	)
+	ModelDownloadThroughput = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubeinfer_model_download_throughput_bytes_per_second",
+			Help: "Aggregate download throughput across all connections",
+		},
+		[]string{"namespace", "pod"},
+	)
+	/*
+		// 模型同步进度（coordinator 和 follower 的 agent 都暴露），按 LLMService 和模型区分
+		// - source: 从哪里拿的模型，hub 是 HuggingFace（或镜像站），peer 是另一个 agent 的 model server
+		// - received_bytes: 从上游收到的字节数
+		// - served_bytes: model server 发给 follower 的字节数
+		// - file_duration: 每个文件从开始下载到落盘的耗时
+		// - throughput / eta: 当前所有连接加起来的带宽和按这个带宽估算的剩余时间，同步结束后归零
+		//
+		// 用法：
+		//   sum by (llmservice) (kubeinfer_model_sync_throughput_bytes_per_second)
+		//   max by (llmservice) (kubeinfer_model_sync_eta_seconds)   // 最慢的副本什么时候能起来
+		//
+		// follower 的 eta 只算 coordinator 已经下载完的文件，coordinator 还在下载时偏乐观
+	*/
+	ModelReceivedBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeinfer_model_received_bytes_total",
+			Help: "Bytes of model files received from upstream",
+		},
+		[]string{"namespace", "llmservice", "model", "source"},
+	)
+	ModelServedBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeinfer_model_served_bytes_total",
+			Help: "Bytes of model files served to followers by the model server",
+		},
+		[]string{"namespace", "llmservice", "model"},
+	)
+	ModelFileDownloadDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "kubeinfer_model_file_download_duration_seconds",
+			Help: "Time to download a single model file",
+			// 1s 到 ~1h：配置文件秒级，10GB 的分片在慢链路上几十分钟
+			Buckets: prometheus.ExponentialBuckets(1, 2, 13),
+		},
+		[]string{"namespace", "llmservice", "model", "source"},
+	)
+	ModelSyncThroughput = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubeinfer_model_sync_throughput_bytes_per_second",
+			Help: "Current aggregate model sync throughput",
+		},
+		[]string{"namespace", "llmservice", "model", "source"},
+	)
+	ModelSyncETA = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubeinfer_model_sync_eta_seconds",
+			Help: "Estimated seconds until the model sync completes at the current throughput",
+		},
+		[]string{"namespace", "llmservice", "model", "source"},
+	)
+	/*
+		// coordinator 选举的稳定性（agent 暴露），pod 是参与选举的 agent
+		// - renew_duration: coordinator 续约 lease 的往返时间，接近 lease.retryPeriod 时很容易续约失败丢掉身份
+		// - consecutive_failures: 连续失败的选举操作（续约、抢占、读取 lease），成功一次归零；
+		//     乘以 retryPeriod 接近 lease.duration 时 coordinator 马上会被别人接管
+		// - leadership_age: 当前持有者拿到 lease（acquireTime）到现在的时间，每个 agent 都能看到
+		//
+		// 用法：
+		//   min by (namespace) (kubeinfer_coordinator_leadership_age_seconds) < 60   // 最近一分钟换过 coordinator
+	*/
+	CoordinatorLeaseRenewDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "kubeinfer_coordinator_lease_renew_duration_seconds",
+			Help: "Round-trip time of coordinator lease renewals",
+			// 5ms 到 ~10s
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 12),
+		},
+		[]string{"namespace", "pod", "result"},
+	)
+	CoordinatorLeaseConsecutiveFailures = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubeinfer_coordinator_lease_consecutive_failures",
+			Help: "Consecutive failed lease acquire/renew attempts",
+		},
+		[]string{"namespace", "pod"},
+	)
+	CoordinatorLeadershipAge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "kubeinfer_coordinator_leadership_age_seconds",
+			Help: "Seconds since the coordinator lease last changed holder",
+		},
+		[]string{"namespace", "pod"},
+	)
+	/*
+		// API server 客户端限流（manager 和 agent 都暴露），component 是 manager 或 agent
+		// - rate_limiter_wait: 请求在客户端限流器（QPS/Burst）里排队的时间
+		// - throttled_requests: 被限流的请求数
+		//     source=client：在客户端限流器里等了超过 1 秒，调大 --kube-api-qps/--kube-api-burst
+		//                    （agent 是 operator 配置的 agentClient）
+		//     source=server：API server 返回 429（APF 限流），要调整 API server 的 FlowSchema
+		//
+		// 为什么要单独看？
+		// - 限流时 reconcile 和选举只是变慢，没有任何报错，大规模部署时 operator 会"安静地"失灵
+	*/
+	KubeClientRateLimiterWait = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "kubeinfer_kube_client_rate_limiter_wait_seconds",
+			Help: "Time requests to the Kubernetes API server spent waiting in the client-side rate limiter",
+			// 1ms 到 ~30s
+			Buckets: prometheus.ExponentialBuckets(0.001, 3, 10),
+		},
+		[]string{"component"},
+	)
+	KubeClientThrottledRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "kubeinfer_kube_client_throttled_requests_total",
+			Help: "Requests to the Kubernetes API server that were throttled, by the client-side rate limiter or with HTTP 429",
+		},
+		[]string{"component", "source"},
+	)
 )
 
 /*
@@ -161,10 +468,39 @@ func init() {
 	ctrlmetrics.Registry.MustRegister(
 		LLMServiceTotal,
 		LLMServiceReadyReplicas,
+		LLMServiceServingState,
 		CoordinatorElections,
 		ModelDownloadDuration,
 		ReconcileTotal,
+		ReconcileErrors,
+		ReconcileQueueDepth,
 		ReconcileDuration,
+		LLMServiceGPUHours,
+		LLMServiceGPUUtilization,
+		LLMServiceEstimatedCost,
+		GatewayRequests,
+		GatewayRequestDuration,
+		GatewayInflightRequests,
+		GatewaySpilledRequests,
+		GatewayTokens,
+		GatewayGuardrailRejections,
+		VLLMWatchdogTrips,
+		VLLMWatchdogProbeDuration,
+		VLLMLogEvents,
+		VLLMPromptThroughput,
+		VLLMGenerationThroughput,
+		ModelDownloadBytes,
+		ModelDownloadThroughput,
+		ModelReceivedBytes,
+		ModelServedBytes,
+		ModelFileDownloadDuration,
+		ModelSyncThroughput,
+		ModelSyncETA,
+		CoordinatorLeaseRenewDuration,
+		CoordinatorLeaseConsecutiveFailures,
+		CoordinatorLeadershipAge,
+		KubeClientRateLimiterWait,
+		KubeClientThrottledRequests,
 	)
 }
 
@@ -181,22 +517,26 @@ func init() {
 //
 // 参数：
 //   - controller: 哪个 controller（例如 "LLMService"）
-//   - result: 结果（"success" 或 "error"）
+//   - result: 结果（"success"、"requeue" 或 "error"）
+//   - reason: 出错的原因（例如 "conflict"、"timeout"），result 不是 "error" 时忽略
 //   - duration: 耗时（秒）
 //
 // 这个函数做了什么？
-// 1. 增加 reconcile 的计数（Counter）
+// 1. 增加 reconcile 的计数（Counter），出错时还按原因计数
 // 2. 记录耗时到直方图（Histogram）
 //
 // 使用例子：
 //   startTime := time.Now()
 //   // ... 执行 reconcile 逻辑 ...
 //   duration := time.Since(startTime).Seconds()
-//   metrics.RecordReconcile("LLMService", "success", duration)
+//   metrics.RecordReconcile("LLMService", "error", "conflict", duration)
 */
 
-func RecordReconcile(controller, result string, duration float64) {
+func RecordReconcile(controller, result, reason string, duration float64) {
 	ReconcileTotal.WithLabelValues(controller, result).Inc()
+	if result == "error" {
+		ReconcileErrors.WithLabelValues(controller, reason).Inc()
+	}
 	ReconcileDuration.WithLabelValues(controller).Observe(duration)
 }
 
@@ -234,3 +574,259 @@ func RecordModelDownload(controller, status string, duration float64) {
 func RecordCoordinatorElection(controller, name string) {
 	CoordinatorElections.WithLabelValues(controller, name).Inc()
 }
+
+/*
+// RecordCostReport 记录一个 LLMService 的费用统计
+//
+// 参数：
+//   - gpuHours: 累计 GPU 小时
+//   - utilization: 平均利用率（0-1）
+//   - cost: 估算费用
+*/
+func RecordCostReport(namespace, name string, gpuHours, utilization, cost float64) {
+	LLMServiceGPUHours.WithLabelValues(namespace, name).Set(gpuHours)
+	LLMServiceGPUUtilization.WithLabelValues(namespace, name).Set(utilization)
+	LLMServiceEstimatedCost.WithLabelValues(namespace, name).Set(cost)
+}
+
+/*
+// RecordGatewayRequest 记录 gateway 代理的一次请求
+//
+// 参数：
+//   - code: HTTP 状态码，例如 "200"、"503"
+//   - duration: 端到端耗时（秒），包括等待后端唤醒的时间
+*/
+func RecordGatewayRequest(namespace, name, code string, duration float64) {
+	GatewayRequests.WithLabelValues(namespace, name, code).Inc()
+	GatewayRequestDuration.WithLabelValues(namespace, name).Observe(duration)
+}
+
+/*
+// RecordGatewayTokens 记录一次请求的 token 用量
+//
+// 参数：
+//   - key: API key 指纹（不是 key 原文），没有 key 时是 "anonymous"
+//   - model: 请求里的 model 字段
+*/
+func RecordGatewayTokens(namespace, name, key, model string, prompt, completion int64) {
+	GatewayTokens.WithLabelValues(namespace, name, key, model, "prompt").Add(float64(prompt))
+	GatewayTokens.WithLabelValues(namespace, name, key, model, "completion").Add(float64(completion))
+}
+
+/*
+// RecordGuardrailRejection 记录一次被 guardrail 拒绝的请求
+//
+// 参数：
+//   - guardrail: 拒绝请求的中间件名称，例如 "max_prompt_tokens"
+*/
+func RecordGuardrailRejection(namespace, name, guardrail string) {
+	GatewayGuardrailRejections.WithLabelValues(namespace, name, guardrail).Inc()
+}
+
+/*
+// RecordGatewaySpill 记录一次跨集群溢出
+//
+// 参数：
+//   - target: 目标集群名称
+//   - code: 对方返回的 HTTP 状态码，连接失败时是 "error"
+*/
+func RecordGatewaySpill(namespace, name, target, code string) {
+	GatewaySpilledRequests.WithLabelValues(namespace, name, target, code).Inc()
+}
+
+/*
+// RecordWatchdogProbe 记录一次 watchdog 生成检查
+//
+// 参数：
+//   - ok: 检查是否成功（决定 result 标签是 "success" 还是 "failed"）
+//   - duration: 耗时（秒），失败时通常接近超时时间
+*/
+func RecordWatchdogProbe(namespace, pod string, ok bool, duration float64) {
+	result := "success"
+	if !ok {
+		result = "failed"
+	}
+	VLLMWatchdogProbeDuration.WithLabelValues(namespace, pod, result).Observe(duration)
+}
+
+/*
+// RecordWatchdogTrip 记录一次 watchdog 触发（vLLM 被重启）
+*/
+func RecordWatchdogTrip(namespace, pod string) {
+	VLLMWatchdogTrips.WithLabelValues(namespace, pod).Inc()
+}
+
+/*
+// RecordVLLMLogEvent 记录一次从 vLLM 日志解析出的事件
+*/
+func RecordVLLMLogEvent(namespace, pod, reason string) {
+	VLLMLogEvents.WithLabelValues(namespace, pod, reason).Inc()
+}
+
+/*
+// RecordVLLMThroughput 记录 vLLM 日志里的吞吐量（tokens/s）
+*/
+func RecordVLLMThroughput(namespace, pod string, prompt, generation float64) {
+	VLLMPromptThroughput.WithLabelValues(namespace, pod).Set(prompt)
+	VLLMGenerationThroughput.WithLabelValues(namespace, pod).Set(generation)
+}
+
+/*
+// RecordLeaseRenew 记录一次 lease 续约的往返时间
+*/
+func RecordLeaseRenew(namespace, pod string, ok bool, duration float64) {
+	result := "success"
+	if !ok {
+		result = "failed"
+	}
+	CoordinatorLeaseRenewDuration.WithLabelValues(namespace, pod, result).Observe(duration)
+}
+
+/*
+// RecordLeaseFailures 记录连续失败的选举操作次数
+*/
+func RecordLeaseFailures(namespace, pod string, n int) {
+	CoordinatorLeaseConsecutiveFailures.WithLabelValues(namespace, pod).Set(float64(n))
+}
+
+/*
+// RecordLeadershipAge 记录当前 coordinator 持有 lease 的时间（秒）
+*/
+func RecordLeadershipAge(namespace, pod string, seconds float64) {
+	CoordinatorLeadershipAge.WithLabelValues(namespace, pod).Set(seconds)
+}
+
+/*
+// RecordModelDownloadBytes 记录下载写入的字节数
+*/
+func RecordModelDownloadBytes(namespace, pod string, n int) {
+	ModelDownloadBytes.WithLabelValues(namespace, pod).Add(float64(n))
+}
+
+/*
+// RecordModelDownloadThroughput 记录所有连接加起来的下载带宽（bytes/s）
+*/
+func RecordModelDownloadThroughput(namespace, pod string, bytesPerSecond float64) {
+	ModelDownloadThroughput.WithLabelValues(namespace, pod).Set(bytesPerSecond)
+}
+
+/*
+// RecordModelReceivedBytes 记录从上游收到的模型字节数（source 是 "hub" 或 "peer"）
+*/
+func RecordModelReceivedBytes(namespace, llmService, model, source string, n int) {
+	ModelReceivedBytes.WithLabelValues(namespace, llmService, model, source).Add(float64(n))
+}
+
+/*
+// RecordModelServedBytes 记录 model server 发给 follower 的字节数
+*/
+func RecordModelServedBytes(namespace, llmService, model string, n int64) {
+	ModelServedBytes.WithLabelValues(namespace, llmService, model).Add(float64(n))
+}
+
+/*
+// RecordModelFileDownload 记录一个文件的下载耗时（秒）
+*/
+func RecordModelFileDownload(namespace, llmService, model, source string, duration float64) {
+	ModelFileDownloadDuration.WithLabelValues(namespace, llmService, model, source).Observe(duration)
+}
+
+/*
+// RecordModelSyncProgress 记录当前同步带宽（bytes/s）和预计剩余时间（秒）
+*/
+func RecordModelSyncProgress(namespace, llmService, model, source string, bytesPerSecond, etaSeconds float64) {
+	ModelSyncThroughput.WithLabelValues(namespace, llmService, model, source).Set(bytesPerSecond)
+	ModelSyncETA.WithLabelValues(namespace, llmService, model, source).Set(etaSeconds)
+}
+
+/*
+// RecordReadyReplicas 记录一个 LLMService 的 Ready 副本数
+*/
+func RecordReadyReplicas(namespace, name string, ready int32) {
+	LLMServiceReadyReplicas.WithLabelValues(namespace, name).Set(float64(ready))
+}
+
+/*
+// ServingStates 是 kubeinfer_llmservice_serving_state 的所有 state 取值
+*/
+var ServingStates = []string{"available", "degraded", "unavailable", "scaled_to_zero"}
+
+/*
+// RecordServingState 记录一个 LLMService 当前的服务状态（其他 state 置 0，方便直接按 == 1 告警）
+*/
+func RecordServingState(namespace, name, state string) {
+	for _, s := range ServingStates {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		LLMServiceServingState.WithLabelValues(namespace, name, s).Set(value)
+	}
+}
+
+/*
+// ============================================================
+// 第四部分：清理已删除对象的时间序列
+// ============================================================
+// Vec 里的每个标签组合一旦创建就会一直导出最后一次的值，
+// LLMService 删除后 ready_replicas、费用这些 gauge 会一直停在删除前的值，dashboard 上看起来它还活着。
+//
+// controller 导出的按 LLMService 区分（namespace + name 标签）的指标都要列在这里。
+// gateway 和 agent 的指标不用管：它们的 Pod 随 LLMService 一起删除，序列自然消失。
+*/
+var llmServiceVecs = map[string]interface {
+	DeletePartialMatch(prometheus.Labels) int
+}{
+	"kubeinfer_llmservice_ready_replicas":        LLMServiceReadyReplicas,
+	"kubeinfer_llmservice_serving_state":         LLMServiceServingState,
+	"kubeinfer_coordinator_elections_total":      CoordinatorElections,
+	"kubeinfer_llmservice_gpu_hours":             LLMServiceGPUHours,
+	"kubeinfer_llmservice_gpu_utilization_ratio": LLMServiceGPUUtilization,
+	"kubeinfer_llmservice_estimated_cost":        LLMServiceEstimatedCost,
+}
+
+/*
+// DeleteLLMServiceMetrics 删除一个 LLMService 的所有时间序列，返回删除的序列数
+*/
+func DeleteLLMServiceMetrics(namespace, name string) int {
+	deleted := 0
+	for _, vec := range llmServiceVecs {
+		deleted += vec.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "name": name})
+	}
+	return deleted
+}
+
+/*
+// LLMServiceSeries 返回当前导出了指标的所有 LLMService（namespace, name）
+//
+// 从 registry 里读，而不是自己记一份：不管序列是从哪里设置的都能找到
+*/
+func LLMServiceSeries() ([]types.NamespacedName, error) {
+	families, err := ctrlmetrics.Registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+	seen := map[types.NamespacedName]bool{}
+	var keys []types.NamespacedName
+	for _, mf := range families {
+		if _, ok := llmServiceVecs[mf.GetName()]; !ok {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			var key types.NamespacedName
+			for _, l := range m.GetLabel() {
+				switch l.GetName() {
+				case "namespace":
+					key.Namespace = l.GetValue()
+				case "name":
+					key.Name = l.GetValue()
+				}
+			}
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys, nil
+}
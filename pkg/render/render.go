@@ -0,0 +1,142 @@
+// Package render 生成 LLMService 对应的 Kubernetes 资源，不需要连接集群
+//
+// controller 调和时 apply 的 Deployment、Service、-cache ConfigMap（以及开启 gateway 时的 gateway
+// Deployment/Service）都由同一套代码生成，这个包把它公开出来，方便：
+//
+//   - 平台团队把 kubeinfer 嵌进自己的 operator，直接拿生成好的对象
+//   - CI 里预览某个 LLMService 会生成什么（kubectl diff 之前先看一眼）
+//
+// 用法：
+//
+//	llm := &aiv1.LLMService{ObjectMeta: metav1.ObjectMeta{Name: "qwen", Namespace: "default"}, Spec: spec}
+//	objs, err := render.Render(llm, render.Options{})
+//	data, err := objs.YAML()
+//
+// Options 对应 operator 配置（config.yaml）里影响生成结果的字段，不设置时用内置默认值。
+// 生成的对象不带 owner reference；状态相关的内容（休眠时的副本数、-cache ConfigMap 里的 coordinator）
+// 按传入对象的 Status 计算。
+package render
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+	"github.com/Moore-Z/kubeinfer/internal/controller"
+	"github.com/Moore-Z/kubeinfer/internal/operatorconfig"
+)
+
+// Options 是渲染时使用的 operator 配置，空字段使用 manager 的内置默认值
+type Options struct {
+	// DefaultImage 是 spec.image 为空时使用的推理镜像
+	DefaultImage string
+	// GatewayImage 是 gateway Deployment 的镜像
+	GatewayImage string
+	// GatewayEnabledByDefault 为 true 时没有设置 spec.gateway 的 LLMService 也生成 gateway
+	GatewayEnabledByDefault bool
+	// AgentImage 是 spec.distribution.mode=initContainer 时 agent 容器的镜像
+	AgentImage string
+	// DefaultTopology 是 spec.distribution.topology 为空时的分发拓扑（flat/zone）
+	DefaultTopology string
+}
+
+// config 把 Options 覆盖到默认配置上
+func (o Options) config() (operatorconfig.Config, error) {
+	cfg := operatorconfig.Default()
+	if o.DefaultImage != "" {
+		cfg.DefaultImage = o.DefaultImage
+	}
+	if o.GatewayImage != "" {
+		cfg.Gateway.Image = o.GatewayImage
+	}
+	cfg.Gateway.EnabledByDefault = o.GatewayEnabledByDefault
+	if o.AgentImage != "" {
+		cfg.Distribution.AgentImage = o.AgentImage
+	}
+	if o.DefaultTopology != "" {
+		cfg.Distribution.DefaultTopology = o.DefaultTopology
+	}
+	return cfg, cfg.Validate()
+}
+
+// Objects 是一个 LLMService 生成的全部资源
+type Objects struct {
+	// Deployment 运行 agent + 推理服务的 Pod
+	Deployment *appsv1.Deployment
+	// Service 指向推理 Pod 的 ClusterIP Service
+	Service *corev1.Service
+	// ConfigMap 是 agent 之间协调用的 <name>-cache ConfigMap
+	ConfigMap *corev1.ConfigMap
+	// GatewayDeployment 和 GatewayService 在 gateway 没有开启时为 nil
+	GatewayDeployment *appsv1.Deployment
+	GatewayService    *corev1.Service
+}
+
+// Render 生成 llm 对应的资源，llm 需要设置 Name 和 Namespace
+func Render(llm *aiv1.LLMService, opts Options) (*Objects, error) {
+	if llm.Name == "" || llm.Namespace == "" {
+		return nil, fmt.Errorf("render: LLMService name and namespace must be set")
+	}
+	cfg, err := opts.config()
+	if err != nil {
+		return nil, fmt.Errorf("render: %w", err)
+	}
+	d, err := controller.RenderDesired(llm, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("render: %w", err)
+	}
+
+	objs := &Objects{
+		Deployment:        d.Deployment,
+		Service:           d.Service,
+		ConfigMap:         d.ConfigMap,
+		GatewayDeployment: d.GatewayDeployment,
+		GatewayService:    d.GatewayService,
+	}
+	// 补上 apiVersion/kind，序列化成 YAML 后可以直接 kubectl apply
+	for _, obj := range objs.List() {
+		setTypeMeta(obj)
+	}
+	return objs, nil
+}
+
+// List 按 apply 的顺序返回所有非 nil 的对象
+func (o *Objects) List() []client.Object {
+	list := []client.Object{o.ConfigMap, o.Deployment, o.Service}
+	if o.GatewayDeployment != nil {
+		list = append(list, o.GatewayDeployment)
+	}
+	if o.GatewayService != nil {
+		list = append(list, o.GatewayService)
+	}
+	return list
+}
+
+// YAML 把所有对象序列化成多文档 YAML
+func (o *Objects) YAML() ([]byte, error) {
+	var out []byte
+	for _, obj := range o.List() {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, "---\n"...)
+		out = append(out, data...)
+	}
+	return out, nil
+}
+
+func setTypeMeta(obj client.Object) {
+	switch v := obj.(type) {
+	case *appsv1.Deployment:
+		v.APIVersion, v.Kind = "apps/v1", "Deployment"
+	case *corev1.Service:
+		v.APIVersion, v.Kind = "v1", "Service"
+	case *corev1.ConfigMap:
+		v.APIVersion, v.Kind = "v1", "ConfigMap"
+	}
+}
@@ -0,0 +1,73 @@
+package render
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/Moore-Z/kubeinfer/api/v1"
+)
+
+func testLLM() *aiv1.LLMService {
+	return &aiv1.LLMService{
+		ObjectMeta: metav1.ObjectMeta{Name: "qwen", Namespace: "team-a"},
+		Spec:       aiv1.LLMServiceSpec{Model: "Qwen/Qwen2.5-0.5B", Replicas: 2},
+	}
+}
+
+func TestRender(t *testing.T) {
+	objs, err := Render(testLLM(), Options{DefaultImage: "registry.example.com/vllm:v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objs.GatewayDeployment != nil || objs.GatewayService != nil {
+		t.Error("gateway must not be rendered unless enabled")
+	}
+	if len(objs.List()) != 3 {
+		t.Errorf("got %d objects, want Deployment, Service and ConfigMap", len(objs.List()))
+	}
+
+	d := objs.Deployment
+	if d.Namespace != "team-a" || *d.Spec.Replicas != 2 {
+		t.Errorf("deployment %s/%s replicas %d", d.Namespace, d.Name, *d.Spec.Replicas)
+	}
+	if img := d.Spec.Template.Spec.Containers[0].Image; img != "registry.example.com/vllm:v1" {
+		t.Errorf("image = %q, want the DefaultImage option", img)
+	}
+	if d.Kind != "Deployment" || objs.Service.APIVersion != "v1" {
+		t.Error("rendered objects must carry apiVersion/kind")
+	}
+
+	data, err := objs.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "---\n"); n != 3 {
+		t.Errorf("YAML has %d documents, want 3", n)
+	}
+}
+
+func TestRenderGateway(t *testing.T) {
+	objs, err := Render(testLLM(), Options{GatewayEnabledByDefault: true, GatewayImage: "registry.example.com/kubeinfer:v1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objs.GatewayDeployment == nil || objs.GatewayService == nil {
+		t.Fatal("gateway enabled by default must be rendered")
+	}
+	if img := objs.GatewayDeployment.Spec.Template.Spec.Containers[0].Image; img != "registry.example.com/kubeinfer:v1" {
+		t.Errorf("gateway image = %q", img)
+	}
+}
+
+func TestRenderErrors(t *testing.T) {
+	llm := testLLM()
+	llm.Namespace = ""
+	if _, err := Render(llm, Options{}); err == nil {
+		t.Error("missing namespace must be an error")
+	}
+	if _, err := Render(testLLM(), Options{DefaultTopology: "ring"}); err == nil {
+		t.Error("invalid topology must be an error")
+	}
+}
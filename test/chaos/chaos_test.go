@@ -0,0 +1,200 @@
+//go:build chaos
+// +build chaos
+
+// Package chaos 用故障注入（internal/agent/faults）自动验证 agent 的容错路径：
+//   - coordinator 续约失败 / 主动退出后，其他 pod 接管 lease
+//   - model server 返回 500、下载中途断开后，follower 重试并且不重复下载已完成的文件
+//
+// 所有组件在同一个进程里运行（fake clientset + httptest），不需要集群：
+//
+//	make test-chaos
+package chaos
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/agenterr"
+	"github.com/Moore-Z/kubeinfer/internal/agent/coordinator"
+	"github.com/Moore-Z/kubeinfer/internal/agent/faults"
+	"github.com/Moore-Z/kubeinfer/internal/agent/follower"
+)
+
+const (
+	namespace = "default"
+	leaseName = "qwen-cache-lease"
+)
+
+// waitFor 轮询 cond 直到为 true，超时则失败
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("timed out after %s waiting for %s", timeout, what)
+}
+
+func leaseHolder(t *testing.T, client *fake.Clientset) string {
+	t.Helper()
+	lease, err := client.CoordinationV1().Leases(namespace).Get(context.Background(), leaseName, metav1.GetOptions{})
+	if err != nil || lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+	return *lease.Spec.HolderIdentity
+}
+
+// TestFailoverOnDroppedRenewals：coordinator 和 API server 之间网络分区，lease 过期后 follower 接管
+func TestFailoverOnDroppedRenewals(t *testing.T) {
+	defer faults.Reset()
+	client := fake.NewSimpleClientset()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a := coordinator.NewLeaseManagerForIdentity(client, namespace, leaseName, "qwen-a")
+	b := coordinator.NewLeaseManagerForIdentity(client, namespace, leaseName, "qwen-b")
+
+	go a.Run(ctx, nil, nil)
+	waitFor(t, 10*time.Second, "qwen-a to become coordinator", a.IsCoordinator)
+
+	go b.Run(ctx, nil, nil)
+	// 此时只有 qwen-a 持有 lease，续约全部丢弃只影响它
+	if err := faults.Configure(faults.DropLeaseRenewal); err != nil {
+		t.Fatal(err)
+	}
+
+	// lease 有效期 15s，加上选举间隔
+	waitFor(t, 30*time.Second, "qwen-b to take over", b.IsCoordinator)
+	faults.Reset()
+
+	if a.IsCoordinator() {
+		t.Error("qwen-a should no longer consider itself coordinator")
+	}
+	if holder := leaseHolder(t, client); holder != "qwen-b" {
+		t.Errorf("lease holder = %q, want qwen-b", holder)
+	}
+}
+
+// TestFailoverOnGracefulShutdown：coordinator 退出时主动让出 lease，follower 不用等过期
+func TestFailoverOnGracefulShutdown(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	ctxA, cancelA := context.WithCancel(context.Background())
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+
+	a := coordinator.NewLeaseManagerForIdentity(client, namespace, leaseName, "qwen-a")
+	b := coordinator.NewLeaseManagerForIdentity(client, namespace, leaseName, "qwen-b")
+
+	go a.Run(ctxA, nil, nil)
+	waitFor(t, 10*time.Second, "qwen-a to become coordinator", a.IsCoordinator)
+	go b.Run(ctxB, nil, nil)
+
+	cancelA()
+	if err := a.Release(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 远小于 lease 有效期（15s）
+	waitFor(t, 6*time.Second, "qwen-b to take over after release", b.IsCoordinator)
+}
+
+// countingHandler 记录每个路径被请求的次数
+type countingHandler struct {
+	next http.Handler
+
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+func (c *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	c.counts[r.URL.Path]++
+	c.mu.Unlock()
+	c.next.ServeHTTP(w, r)
+}
+
+// TestDownloadResume：model server 出错、下载中途断开后 follower 重试，已完成的文件不重复下载
+func TestDownloadResume(t *testing.T) {
+	defer faults.Reset()
+
+	src := t.TempDir()
+	files := map[string][]byte{
+		"config.json":                      []byte(`{"model_type": "qwen2"}`),
+		"model-00001-of-00002.safetensors": bytes.Repeat([]byte{1}, 64*1024),
+		"model-00002-of-00002.safetensors": bytes.Repeat([]byte{2}, 64*1024),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(src, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := coordinator.MarkComplete(src); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := &countingHandler{next: coordinator.NewModelServer(src).Handler(), counts: map[string]int{}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	// 第一次列文件返回 500；第一个下载的文件（config.json）传到一半断开
+	if err := faults.Configure("model-server-500=1x,truncate-download=1x"); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := t.TempDir()
+	attempts := 0
+	for {
+		attempts++
+		err := follower.NewFollowerFromURL(server.URL, dst).Sync(context.Background())
+		if err == nil {
+			break
+		}
+		if agenterr.Kind(err) != agenterr.ErrTransientNetwork {
+			t.Fatalf("attempt %d: want a transient network error, got %v (%s)", attempts, err, agenterr.Reason(err))
+		}
+		if attempts == 5 {
+			t.Fatalf("sync did not succeed after %d attempts: %v", attempts, err)
+		}
+	}
+	if attempts != 3 {
+		t.Errorf("sync succeeded after %d attempts, want 3 (500, truncated, ok)", attempts)
+	}
+
+	for name, want := range files {
+		got, err := os.ReadFile(filepath.Join(dst, name))
+		if err != nil || !bytes.Equal(got, want) {
+			t.Errorf("%s: content mismatch (err %v)", name, err)
+		}
+		if _, err := os.Stat(filepath.Join(dst, name+coordinator.PartialSuffix)); err == nil {
+			t.Errorf("%s: partial file left behind", name)
+		}
+	}
+	if !coordinator.ModelComplete(dst) {
+		t.Error("complete marker not written")
+	}
+
+	// 只有被截断的文件下载了两次
+	wantCounts := map[string]int{
+		"/models/config.json":                      2,
+		"/models/model-00001-of-00002.safetensors": 1,
+		"/models/model-00002-of-00002.safetensors": 1,
+	}
+	for path, want := range wantCounts {
+		if got := handler.counts[path]; got != want {
+			t.Errorf("%s requested %d times, want %d", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/Moore-Z/kubeinfer/internal/agent/admin"
+	"github.com/Moore-Z/kubeinfer/pkg/cacheinfo"
+	"github.com/Moore-Z/kubeinfer/test/utils"
+)
+
+// LLMService 端到端测试：真实的 operator + 真实的 agent + 一个很小的 HuggingFace 模型，
+// 推理服务用 test/testdata/vllm-mock（不需要 GPU）。
+//
+// 覆盖：
+//  1. coordinator 选举（Lease 有持有者）
+//  2. 模型分发（每个 Pod 的 /models 都有完整副本）
+//  3. mock vLLM 就绪（Status.AvailableReplicas）
+//  4. follower 能通过 coordinator 的 Pod IP 访问 model server（IPv6-only 集群上是 IPv6 地址）
+//  5. kubectl kubeinfer status 经 Pod proxy 读到每个 agent 的 /debug/status
+//  6. 强杀 coordinator Pod 后在 SLO 内选出新 coordinator、副本恢复
+//
+// E2E_RUNTIME=stub 时用 agent 内置的 stub 推理服务、生成占位模型，不访问 HuggingFace
+// （make test-e2e-ipv6：IPv6-only 的 Kind 集群一般出不了公网）
+var _ = Describe("LLMService", Ordered, func() {
+	const (
+		llmNamespace = "default"
+		llmName      = "e2e-tiny"
+		leaseName    = llmName + "-cache-lease"
+		agentImage   = "example.com/kubeinfer-agent:e2e"
+		// tiny-random-gpt2 只有几 MB，CI 里几秒就能下完
+		tinyModel = "hf-internal-testing/tiny-random-gpt2"
+
+		// 恢复 SLO：lease 有效期 15s + 选举间隔 2s，留一些余量
+		electionSLO = 30 * time.Second
+		// 新 Pod 调度、从新 coordinator 拿模型、mock vLLM 就绪
+		recoverySLO = 3 * time.Minute
+	)
+
+	llmServiceYAML := fmt.Sprintf(`apiVersion: ai.ruijie.io/v1
+kind: LLMService
+metadata:
+  name: %s
+  namespace: %s
+spec:
+  model: %s
+  image: %s
+  replicas: 2
+  resources:
+    requests:
+      cpu: 100m
+      memory: 256Mi
+`, llmName, llmNamespace, tinyModel, agentImage)
+	if runtime := os.Getenv("E2E_RUNTIME"); runtime != "" {
+		llmServiceYAML += fmt.Sprintf("  runtime: %s\n", runtime)
+	}
+
+	kubectl := func(args ...string) (string, error) {
+		return utils.Run(exec.Command("kubectl", args...))
+	}
+
+	leaseHolder := func() string {
+		out, err := kubectl("get", "lease", leaseName, "-n", llmNamespace, "-o", "jsonpath={.spec.holderIdentity}")
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(out)
+	}
+
+	availableReplicas := func() string {
+		out, _ := kubectl("get", "llmservice", llmName, "-n", llmNamespace, "-o", "jsonpath={.status.availableReplicas}")
+		return strings.TrimSpace(out)
+	}
+
+	runningPods := func() []string {
+		out, err := kubectl("get", "pods", "-n", llmNamespace, "-l", "llm_cr="+llmName,
+			"--field-selector=status.phase=Running", "-o", "jsonpath={.items[*].metadata.name}")
+		if err != nil {
+			return nil
+		}
+		return strings.Fields(out)
+	}
+
+	BeforeAll(func() {
+		By("building the agent image with the mock vLLM runtime")
+		_, err := utils.Run(exec.Command("env", "CGO_ENABLED=0", "GOOS=linux",
+			"go", "build", "-o", "test/testdata/agent-e2e/agent", "./cmd/agent"))
+		Expect(err).NotTo(HaveOccurred(), "Failed to build the agent binary")
+		_, err = utils.Run(exec.Command("docker", "build", "-t", agentImage,
+			"-f", "test/testdata/agent-e2e/Dockerfile", "test/testdata"))
+		Expect(err).NotTo(HaveOccurred(), "Failed to build the agent image")
+		Expect(utils.LoadImageToKindClusterWithName(agentImage)).To(Succeed(), "Failed to load the agent image into Kind")
+
+		By("deploying the controller-manager")
+		// Manager 测试的 AfterAll 会删掉 namespace，这里重新创建，已存在时忽略错误
+		_, _ = kubectl("create", "ns", namespace)
+		_, err = utils.Run(exec.Command("make", "install"))
+		Expect(err).NotTo(HaveOccurred(), "Failed to install CRDs")
+		_, err = utils.Run(exec.Command("make", "deploy", fmt.Sprintf("IMG=%s", projectImage)))
+		Expect(err).NotTo(HaveOccurred(), "Failed to deploy the controller-manager")
+		_, err = kubectl("rollout", "status", "deployment/kubeinfer-controller-manager", "-n", namespace, "--timeout=3m")
+		Expect(err).NotTo(HaveOccurred(), "controller-manager did not become available")
+
+		By("granting the agent its RBAC")
+		_, err = kubectl("apply", "-f", "config/rbac/agent_role.yaml")
+		Expect(err).NotTo(HaveOccurred(), "Failed to apply agent RBAC")
+	})
+
+	AfterAll(func() {
+		By("deleting the LLMService")
+		_, _ = kubectl("delete", "llmservice", llmName, "-n", llmNamespace, "--ignore-not-found", "--timeout=2m")
+
+		By("removing the agent RBAC")
+		_, _ = kubectl("delete", "-f", "config/rbac/agent_role.yaml", "--ignore-not-found")
+
+		By("undeploying the controller-manager")
+		_, _ = utils.Run(exec.Command("make", "undeploy"))
+		_, _ = utils.Run(exec.Command("make", "uninstall"))
+		// Manager 测试的 BeforeAll 要求 namespace 不存在
+		_, _ = kubectl("delete", "ns", namespace, "--ignore-not-found")
+	})
+
+	AfterEach(func() {
+		if !CurrentSpecReport().Failed() {
+			return
+		}
+		By("Fetching agent pod logs and events")
+		if out, err := kubectl("logs", "-n", llmNamespace, "-l", "llm_cr="+llmName, "-c", "agent", "--tail=200", "--prefix"); err == nil {
+			_, _ = fmt.Fprintf(GinkgoWriter, "Agent logs:\n%s", out)
+		}
+		if out, err := kubectl("get", "events", "-n", llmNamespace, "--sort-by=.lastTimestamp"); err == nil {
+			_, _ = fmt.Fprintf(GinkgoWriter, "Kubernetes events:\n%s", out)
+		}
+		if out, err := kubectl("get", "llmservice", llmName, "-n", llmNamespace, "-o", "yaml"); err == nil {
+			_, _ = fmt.Fprintf(GinkgoWriter, "LLMService:\n%s", out)
+		}
+	})
+
+	It("should elect a coordinator, distribute the model and become ready", func() {
+		By("creating the LLMService")
+		cmd := exec.Command("kubectl", "apply", "-f", "-")
+		cmd.Stdin = strings.NewReader(llmServiceYAML)
+		_, err := utils.Run(cmd)
+		Expect(err).NotTo(HaveOccurred(), "Failed to create the LLMService")
+
+		By("waiting for a coordinator to be elected")
+		// 第一次要拉镜像，给足时间
+		Eventually(leaseHolder, 5*time.Minute, time.Second).ShouldNot(BeEmpty())
+
+		By("waiting for both replicas to become available")
+		Eventually(availableReplicas, 5*time.Minute, 2*time.Second).Should(Equal("2"))
+
+		By("checking every pod has a complete copy of the model")
+		pods := runningPods()
+		Expect(pods).To(HaveLen(2))
+		for _, pod := range pods {
+			out, err := kubectl("exec", pod, "-n", llmNamespace, "-c", "agent", "--", "ls", "-a", "/models")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out).To(ContainSubstring("config.json"), "pod %s is missing model files", pod)
+			Expect(out).To(ContainSubstring(".kubeinfer-complete"), "pod %s has an incomplete model", pod)
+		}
+	})
+
+	It("should serve the model on the coordinator's pod IP", func() {
+		coordinator := leaseHolder()
+		Expect(coordinator).NotTo(BeEmpty())
+		podIP, err := kubectl("get", "pod", coordinator, "-n", llmNamespace, "-o", "jsonpath={.status.podIP}")
+		Expect(err).NotTo(HaveOccurred())
+		podIP = strings.TrimSpace(podIP)
+		_, _ = fmt.Fprintf(GinkgoWriter, "coordinator %s has pod IP %s\n", coordinator, podIP)
+
+		var follower string
+		for _, pod := range runningPods() {
+			if pod != coordinator {
+				follower = pod
+			}
+		}
+		Expect(follower).NotTo(BeEmpty())
+
+		By("fetching the file list from a follower over the pod IP")
+		// 和 agent 一样用 JoinHostPort 拼地址，IPv6 要加方括号
+		url := "http://" + net.JoinHostPort(podIP, "8080") + "/models"
+		out, err := kubectl("exec", follower, "-n", llmNamespace, "-c", "agent", "--",
+			"python", "-c", fmt.Sprintf("import urllib.request; print(urllib.request.urlopen(%q).read().decode())", url))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out).To(ContainSubstring("config.json"))
+	})
+
+	It("should report every agent's state through kubectl kubeinfer status", func() {
+		coordinator := leaseHolder()
+		Expect(coordinator).NotTo(BeEmpty())
+
+		By("running the kubectl plugin against the debug API")
+		out, err := utils.Run(exec.Command("go", "run", "./cmd/kubectl-kubeinfer",
+			"status", llmName, "-n", llmNamespace, "-o", "json"))
+		Expect(err).NotTo(HaveOccurred())
+		var statuses []struct {
+			Pod    string        `json:"pod"`
+			Status *admin.Status `json:"status"`
+			Error  string        `json:"error"`
+		}
+		Expect(json.Unmarshal([]byte(out), &statuses)).To(Succeed(), "unexpected plugin output:\n%s", out)
+		Expect(statuses).To(HaveLen(2))
+
+		coordinators := 0
+		for _, ps := range statuses {
+			Expect(ps.Status).NotTo(BeNil(), "pod %s: %s", ps.Pod, ps.Error)
+			Expect(ps.Status.Model.Complete).To(BeTrue(), "pod %s has an incomplete model", ps.Pod)
+			Expect(ps.Status.Runtime.Ready).To(BeTrue(), "pod %s is not ready", ps.Pod)
+			Expect(ps.Status.Lease).NotTo(BeNil(), "pod %s: %s", ps.Pod, ps.Status.LeaseError)
+			Expect(ps.Status.Lease.Holder).To(Equal(coordinator))
+			if ps.Status.Role == cacheinfo.RoleCoordinator {
+				coordinators++
+				Expect(ps.Pod).To(Equal(coordinator))
+			}
+		}
+		Expect(coordinators).To(Equal(1))
+	})
+
+	It("should recover within the SLO after the coordinator pod is killed", func() {
+		oldCoordinator := leaseHolder()
+		Expect(oldCoordinator).NotTo(BeEmpty())
+
+		By(fmt.Sprintf("force-deleting the coordinator pod %s", oldCoordinator))
+		// 强制删除不会走 preStop，也来不及让出 lease，和节点宕机一样只能等 lease 过期
+		start := time.Now()
+		_, err := kubectl("delete", "pod", oldCoordinator, "-n", llmNamespace, "--grace-period=0", "--force", "--wait=false")
+		Expect(err).NotTo(HaveOccurred())
+
+		By("waiting for a new coordinator")
+		Eventually(func(g Gomega) {
+			holder := leaseHolder()
+			g.Expect(holder).NotTo(BeEmpty())
+			g.Expect(holder).NotTo(Equal(oldCoordinator))
+		}, electionSLO, time.Second).Should(Succeed())
+		_, _ = fmt.Fprintf(GinkgoWriter, "new coordinator elected after %s\n", time.Since(start))
+
+		By("waiting for the replacement pod to sync the model and become ready")
+		Eventually(func(g Gomega) {
+			g.Expect(availableReplicas()).To(Equal("2"))
+			g.Expect(runningPods()).NotTo(ContainElement(oldCoordinator))
+		}, recoverySLO, 2*time.Second).Should(Succeed())
+		_, _ = fmt.Fprintf(GinkgoWriter, "fully recovered after %s\n", time.Since(start))
+	})
+})
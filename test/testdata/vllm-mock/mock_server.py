@@ -1,6 +1,8 @@
 from fastapi import FastAPI
+import argparse
 import uvicorn
 import os
+import socket
 import time
 
 app = FastAPI()
@@ -28,10 +30,35 @@ def models():
         ]
     }
 
+@app.post("/v1/completions")
+def completions():
+    # agent 的 watchdog 会定期发一个很短的生成请求
+    return {
+        "id": "cmpl-mock",
+        "object": "text_completion",
+        "created": int(time.time()),
+        "model": "mock-model",
+        "choices": [{"index": 0, "text": " ok", "finish_reason": "length"}],
+    }
+
 @app.get("/")
 def root():
     return {"message": "Mock vLLM server running", "pod": os.getenv("POD_NAME", "unknown")}
 
 if __name__ == "__main__":
+    # 接受和 vLLM 一样的参数（agent 会传 --model/--host/--port 等），不认识的参数忽略
+    parser = argparse.ArgumentParser()
+    parser.add_argument("--host", default="0.0.0.0")
+    parser.add_argument("--port", type=int, default=8000)
+    args, _ = parser.parse_known_args()
+
     print(f"Starting mock vLLM server on pod: {os.getenv('POD_NAME', 'unknown')}")
-    uvicorn.run(app, host="0.0.0.0", port=8000)
\ No newline at end of file
+    # 和 vLLM 一样自己创建 socket：agent 传 --host :: 时要同时接受 IPv4（agent 用 127.0.0.1 检查健康），
+    # uvicorn.run(host="::") 走 asyncio，会设置 IPV6_V6ONLY 变成只有 IPv6
+    family = socket.AF_INET6 if ":" in args.host else socket.AF_INET
+    sock = socket.socket(family, socket.SOCK_STREAM)
+    sock.setsockopt(socket.SOL_SOCKET, socket.SO_REUSEADDR, 1)
+    if family == socket.AF_INET6:
+        sock.setsockopt(socket.IPPROTO_IPV6, socket.IPV6_V6ONLY, 0)
+    sock.bind((args.host, args.port))
+    uvicorn.Server(uvicorn.Config(app)).run(sockets=[sock])
\ No newline at end of file